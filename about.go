@@ -0,0 +1,116 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/spf13/cobra"
+)
+
+// integrations maps the integrations of core to the module paths they are
+// built upon, including the database drivers of gorm.
+var integrations = map[string][]string{
+	"core":  {"github.com/DoNewsCode/core"},
+	"gorm":  {"gorm.io/", "github.com/go-sql-driver/mysql", "github.com/mattn/go-sqlite3", "github.com/jackc/pgx", "github.com/denisenkom/go-mssqldb", "github.com/ClickHouse/clickhouse-go"},
+	"kafka": {"github.com/segmentio/kafka-go"},
+	"redis": {"github.com/go-redis/redis"},
+	"etcd":  {"go.etcd.io/etcd"},
+	"mongo": {"go.mongodb.org/mongo-driver"},
+	"es":    {"github.com/olivere/elastic"},
+	"s3":    {"github.com/aws/aws-sdk-go"},
+}
+
+// Dependency is a Go module linked into the binary.
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// About is the report of the "about" command.
+type About struct {
+	Build     contract.BuildInfo `json:"build"`
+	GoVersion string             `json:"goVersion"`
+	Main      Dependency         `json:"main"`
+	// Integrations are the dependencies of the integrations linked into the
+	// binary, keyed by the name of the integration, eg. "gorm".
+	Integrations map[string][]Dependency `json:"integrations"`
+	// Modules are the types of the modules registered in the container.
+	Modules      []string     `json:"modules"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// AboutModule exposes the "about" command, which reports the versions of the
+// integrations, the enabled modules and the build dependencies of the binary.
+// It is useful for fleet-wide audits of the services built on core.
+type AboutModule struct {
+	about     About
+	container contract.Container
+}
+
+// NewAboutModule creates an AboutModule. The build dependencies are gathered
+// from the binary when it is created.
+func NewAboutModule(info contract.BuildInfo, container contract.Container) AboutModule {
+	buildInfo, _ := debug.ReadBuildInfo()
+	return AboutModule{about: newAbout(info, buildInfo), container: container}
+}
+
+// ProvideCommand implements container.CommandProvider
+func (a AboutModule) ProvideCommand(command *cobra.Command) {
+	command.AddCommand(&cobra.Command{
+		Use:   "about",
+		Short: "Print the versions of the integrations, modules and dependencies",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			about := a.about
+			about.Modules = []string{}
+			for _, module := range a.container.Modules() {
+				about.Modules = append(about.Modules, fmt.Sprintf("%T", module))
+			}
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(about)
+		},
+	})
+}
+
+func newAbout(info contract.BuildInfo, buildInfo *debug.BuildInfo) About {
+	about := About{
+		Build:        info,
+		GoVersion:    runtime.Version(),
+		Integrations: make(map[string][]Dependency),
+		Dependencies: []Dependency{},
+	}
+	if buildInfo == nil {
+		return about
+	}
+	about.Main = toDependency(&buildInfo.Main)
+	for _, module := range buildInfo.Deps {
+		dependency := toDependency(module)
+		about.Dependencies = append(about.Dependencies, dependency)
+		for name, prefixes := range integrations {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(module.Path, prefix) {
+					about.Integrations[name] = append(about.Integrations[name], dependency)
+					break
+				}
+			}
+		}
+	}
+	return about
+}
+
+func toDependency(module *debug.Module) Dependency {
+	dependency := Dependency{Path: module.Path, Version: module.Version, Sum: module.Sum}
+	if module.Replace != nil {
+		dependency.Replace = module.Replace.Path
+		if module.Replace.Version != "" {
+			dependency.Replace += "@" + module.Replace.Version
+		}
+	}
+	return dependency
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime/debug"
+	"testing"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAboutModule(t *testing.T) {
+	c := Default(WithBuildInfo("v1.2.3", "abcdef", "2021-01-01T00:00:00Z"))
+	c.AddModuleFunc(NewAboutModule)
+
+	var buf bytes.Buffer
+	rootCmd := &cobra.Command{}
+	rootCmd.SetOut(&buf)
+	c.ApplyRootCommand(rootCmd)
+	rootCmd.SetArgs([]string{"about"})
+	assert.NoError(t, rootCmd.Execute())
+
+	var about About
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &about))
+	assert.Equal(t, "v1.2.3", about.Build.Version)
+	assert.NotEmpty(t, about.GoVersion)
+	assert.Contains(t, about.Modules, "core.AboutModule")
+}
+
+func Test_newAbout(t *testing.T) {
+	t.Parallel()
+	about := newAbout(contract.BuildInfo{}, &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/DoNewsCode/core", Version: "v0.10.0", Sum: "h1:abc"},
+			{Path: "gorm.io/gorm", Version: "v1.21.10"},
+			{Path: "gorm.io/driver/mysql", Version: "v1.0.4"},
+			{Path: "github.com/go-sql-driver/mysql", Version: "v1.5.0"},
+			{Path: "github.com/segmentio/kafka-go", Version: "v0.4.16", Replace: &debug.Module{Path: "example.com/kafka-go", Version: "v0.4.17"}},
+			{Path: "github.com/pkg/errors", Version: "v0.9.1"},
+		},
+	})
+	assert.Equal(t, "example.com/app", about.Main.Path)
+	assert.Len(t, about.Dependencies, 6)
+	assert.Len(t, about.Integrations["gorm"], 3)
+	assert.Equal(t, "h1:abc", about.Integrations["core"][0].Sum)
+	assert.Equal(t, "example.com/kafka-go@v0.4.17", about.Integrations["kafka"][0].Replace)
+	assert.NotContains(t, about.Integrations, "redis")
+
+	about = newAbout(contract.BuildInfo{}, nil)
+	assert.Empty(t, about.Dependencies)
+}
@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func setup(t *testing.T, s *Server, token string) (*grpc.ClientConn, func()) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer(grpc.UnaryInterceptor(MakeAuthInterceptor(token)))
+	Register(server, s)
+	go server.Serve(ln)
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure())
+	assert.NoError(t, err)
+	return conn, func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+type persisterFunc func(ctx context.Context, level string, modules map[string]string) error
+
+func (p persisterFunc) PersistLevels(ctx context.Context, level string, modules map[string]string) error {
+	return p(ctx, level, modules)
+}
+
+func TestServer(t *testing.T) {
+	dispatcher := &events.SyncDispatcher{}
+	var flushed string
+	dispatcher.Subscribe(events.Listen(OnCacheFlush, func(ctx context.Context, event interface{}) error {
+		flushed = event.(OnCacheFlushPayload).Name
+		return nil
+	}))
+	crontab := cron.New()
+	ran := make(chan struct{})
+	id, _ := crontab.AddFunc("@yearly", func() { close(ran) })
+	s := &Server{
+		Dispatcher:  dispatcher,
+		LogLevels:   logging.NewLevels("debug"),
+		Maintenance: &Maintenance{},
+		Cron:        crontab,
+	}
+	conn, cleanup := setup(t, s, "secret")
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := NewClient(conn, "wrong").Call(ctx, "SetLogLevel", map[string]interface{}{"level": "error"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	client := NewClient(conn, "secret")
+	resp, err := client.Call(ctx, "SetLogLevel", map[string]interface{}{"level": "error"})
+	assert.NoError(t, err)
+	assert.Equal(t, "error", resp["level"])
+	assert.Equal(t, "error", s.LogLevels.Get())
+
+	_, err = client.Call(ctx, "SetLogLevel", map[string]interface{}{"level": "foo"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err = client.Call(ctx, "SetLogLevel", map[string]interface{}{"level": "debug", "module": "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": "debug"}, resp["modules"])
+
+	_, err = client.Call(ctx, "SetLogLevel", map[string]interface{}{"level": "info", "persist": true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	var persisted map[string]string
+	s.LevelPersister = persisterFunc(func(ctx context.Context, level string, modules map[string]string) error {
+		persisted = modules
+		return nil
+	})
+	_, err = client.Call(ctx, "SetLogLevel", map[string]interface{}{"level": "", "module": "foo", "persist": true})
+	assert.NoError(t, err)
+	assert.Empty(t, persisted)
+
+	_, err = client.Call(ctx, "SetMaintenance", map[string]interface{}{"enable": true})
+	assert.NoError(t, err)
+	assert.True(t, s.Maintenance.Enabled())
+
+	resp, err = client.Call(ctx, "ListCron", nil)
+	assert.NoError(t, err)
+	assert.Len(t, resp["entries"], 1)
+
+	_, err = client.Call(ctx, "TriggerCron", map[string]interface{}{"id": int(id)})
+	assert.NoError(t, err)
+	<-ran
+
+	_, err = client.Call(ctx, "TriggerCron", map[string]interface{}{"id": 100})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Call(ctx, "FlushCache", map[string]interface{}{"name": "default"})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", flushed)
+
+	_, err = client.Call(ctx, "ReloadConfig", nil)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestMaintenance(t *testing.T) {
+	maintenance := &Maintenance{}
+	handler := MakeHTTPMiddleware(maintenance)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	maintenance.Set(true)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	_, err := MakeUnaryInterceptor(maintenance)(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
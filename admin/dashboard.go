@@ -0,0 +1,376 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DashboardOption is the configuration of the web dashboard.
+type DashboardOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Path is the path prefix of the dashboard on the HTTP server. Defaults to
+	// /admin.
+	Path string    `json:"path" yaml:"path"`
+	SSO  SSOOption `json:"sso" yaml:"sso"`
+}
+
+// HealthChecker is implemented by modules that report their health on the
+// dashboard.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthStatus is the health of a module.
+type HealthStatus struct {
+	Module  string `json:"module"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ConnectionStatus is the state of a connection created by the factories, as
+// of the last events.OnConnectionUp or events.OnConnectionDown.
+type ConnectionStatus struct {
+	Kind  string    `json:"kind"`
+	Name  string    `json:"name"`
+	Up    bool      `json:"up"`
+	Since time.Time `json:"since"`
+}
+
+// CronRun is a finished run of a cron job.
+type CronRun struct {
+	Job      string    `json:"job"`
+	Finished time.Time `json:"finished"`
+	Duration string    `json:"duration"`
+	Panic    string    `json:"panic,omitempty"`
+}
+
+// QueueFailure is a failed queue job.
+type QueueFailure struct {
+	Queue string    `json:"queue"`
+	Job   string    `json:"job"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// Overview is everything shown on the dashboard.
+type Overview struct {
+	User          string                 `json:"user,omitempty"`
+	Maintenance   bool                   `json:"maintenance"`
+	Health        []HealthStatus         `json:"health"`
+	Connections   []ConnectionStatus     `json:"connections"`
+	CronEntries   []interface{}          `json:"cronEntries"`
+	CronHistory   []CronRun              `json:"cronHistory"`
+	QueueFailures []QueueFailure         `json:"queueFailures"`
+	Flags         map[string]interface{} `json:"flags"`
+	LogLevels     map[string]interface{} `json:"logLevels"`
+	Config        map[string]interface{} `json:"config"`
+}
+
+// historySize is the number of cron runs and queue failures kept.
+const historySize = 50
+
+// Dashboard is a web page aggregating the runtime state and controls of the
+// application: the configuration, the health of the modules and connections,
+// the cron jobs and their history, the failed queue jobs, the feature flags,
+// the log levels and the maintenance mode. The actions are the ones of Server.
+//
+// The feature flags are the "flags" configuration, read only. The values of
+// sensitive configuration keys, such as passwords and tokens, are masked.
+type Dashboard struct {
+	server    *Server
+	container contract.Container
+
+	mu            sync.Mutex
+	connections   map[string]ConnectionStatus
+	cronHistory   []CronRun
+	queueFailures []QueueFailure
+}
+
+// NewDashboard creates a *Dashboard. The history of connections, cron jobs
+// and queue jobs is collected from the events of the dispatcher in Server.
+func NewDashboard(server *Server, container contract.Container) *Dashboard {
+	d := &Dashboard{
+		server:      server,
+		container:   container,
+		connections: make(map[string]ConnectionStatus),
+	}
+	if server.Dispatcher != nil {
+		d.subscribe(server.Dispatcher)
+	}
+	return d
+}
+
+func (d *Dashboard) subscribe(dispatcher contract.Dispatcher) {
+	dispatcher.Subscribe(events.Listen(events.OnConnectionUp, func(ctx context.Context, event interface{}) error {
+		payload := event.(events.OnConnectionUpPayload)
+		d.setConnection(payload.Name, payload.Conn, true)
+		return nil
+	}))
+	dispatcher.Subscribe(events.Listen(events.OnConnectionDown, func(ctx context.Context, event interface{}) error {
+		payload := event.(events.OnConnectionDownPayload)
+		d.setConnection(payload.Name, payload.Conn, false)
+		return nil
+	}))
+	dispatcher.Subscribe(events.Listen(events.OnCronJobFinished, func(ctx context.Context, event interface{}) error {
+		payload := event.(events.OnCronJobFinishedPayload)
+		run := CronRun{Job: payload.Job, Finished: time.Now(), Duration: payload.Duration.String()}
+		if payload.Panic != nil {
+			run.Panic = fmt.Sprint(payload.Panic)
+		}
+		d.mu.Lock()
+		d.cronHistory = append([]CronRun{run}, d.cronHistory...)
+		if len(d.cronHistory) > historySize {
+			d.cronHistory = d.cronHistory[:historySize]
+		}
+		d.mu.Unlock()
+		return nil
+	}))
+	dispatcher.Subscribe(events.Listen(events.OnQueueJobFailed, func(ctx context.Context, event interface{}) error {
+		payload := event.(events.OnQueueJobFailedPayload)
+		failure := QueueFailure{Queue: payload.Queue, Job: payload.Job, Time: time.Now()}
+		if payload.Err != nil {
+			failure.Error = payload.Err.Error()
+		}
+		d.mu.Lock()
+		d.queueFailures = append([]QueueFailure{failure}, d.queueFailures...)
+		if len(d.queueFailures) > historySize {
+			d.queueFailures = d.queueFailures[:historySize]
+		}
+		d.mu.Unlock()
+		return nil
+	}))
+}
+
+func (d *Dashboard) setConnection(name string, conn interface{}, up bool) {
+	kind := fmt.Sprintf("%T", conn)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.connections[kind+"/"+name] = ConnectionStatus{Kind: kind, Name: name, Up: up, Since: time.Now()}
+}
+
+// Overview collects the current state.
+func (d *Dashboard) Overview(ctx context.Context) Overview {
+	overview := Overview{
+		User:        UserFromContext(ctx),
+		Maintenance: d.server.Maintenance.Enabled(),
+		Health:      []HealthStatus{},
+		Connections: []ConnectionStatus{},
+		CronEntries: []interface{}{},
+		Flags:       map[string]interface{}{},
+		LogLevels:   map[string]interface{}{},
+		Config:      map[string]interface{}{},
+	}
+
+	if d.container != nil {
+		for _, module := range d.container.Modules() {
+			checker, ok := module.(HealthChecker)
+			if !ok {
+				continue
+			}
+			status := HealthStatus{Module: fmt.Sprintf("%T", module), Healthy: true}
+			if err := checker.HealthCheck(ctx); err != nil {
+				status.Healthy = false
+				status.Error = err.Error()
+			}
+			overview.Health = append(overview.Health, status)
+		}
+	}
+
+	d.mu.Lock()
+	for _, status := range d.connections {
+		overview.Connections = append(overview.Connections, status)
+	}
+	overview.CronHistory = append([]CronRun{}, d.cronHistory...)
+	overview.QueueFailures = append([]QueueFailure{}, d.queueFailures...)
+	d.mu.Unlock()
+	sort.Slice(overview.Connections, func(i, j int) bool {
+		return overview.Connections[i].Kind+"/"+overview.Connections[i].Name < overview.Connections[j].Kind+"/"+overview.Connections[j].Name
+	})
+
+	if resp, err := d.server.ListCron(ctx, nil); err == nil {
+		overview.CronEntries = resp.AsMap()["entries"].([]interface{})
+	}
+	if resp, err := d.server.GetLogLevel(ctx, nil); err == nil {
+		overview.LogLevels = resp.AsMap()
+	}
+	_ = d.server.Conf.Unmarshal("", &overview.Config)
+	if flags, ok := overview.Config["flags"].(map[string]interface{}); ok {
+		overview.Flags = flags
+	}
+	overview.Config = mask(overview.Config).(map[string]interface{})
+	return overview
+}
+
+var sensitiveKeys = []string{"password", "secret", "token", "credential", "dsn", "privatekey", "apikey"}
+
+func mask(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			masked[key] = mask(value)
+			lower := strings.ToLower(key)
+			for _, sensitive := range sensitiveKeys {
+				if strings.Contains(lower, sensitive) {
+					if s, ok := value.(string); !ok || s != "" {
+						masked[key] = "******"
+					}
+					break
+				}
+			}
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i := range v {
+			masked[i] = mask(v[i])
+		}
+		return masked
+	}
+	return value
+}
+
+// Handler returns the http.Handler of the dashboard under the path prefix.
+// The page is served at path + "/", the overview as JSON at path + "/api/overview",
+// and the actions accept POST requests with form values:
+//
+//	POST /api/log-level    level, module
+//	POST /api/maintenance  enable
+//	POST /api/cron/trigger id
+//	POST /api/cache/flush  name
+//	POST /api/reload
+//
+// The handler itself is not authenticated, see SSO.
+func (d *Dashboard) Handler(path string) http.Handler {
+	router := mux.NewRouter()
+	router.Methods(http.MethodGet).Path(path + "/").HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(writer, struct {
+			Path string
+			Overview
+		}{path, d.Overview(request.Context())}); err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	router.Methods(http.MethodGet).Path(path + "/api/overview").HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		srvhttp.NewResponseEncoder(writer).EncodeResponse(d.Overview(request.Context()))
+	})
+	action := func(name string, m method, fields func(request *http.Request) (map[string]interface{}, error)) {
+		router.Methods(http.MethodPost).Path(path + "/api/" + name).HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			encoder := srvhttp.NewResponseEncoder(writer)
+			values, err := fields(request)
+			if err != nil {
+				encoder.EncodeError(err)
+				return
+			}
+			req, err := structpb.NewStruct(values)
+			if err != nil {
+				encoder.EncodeError(err)
+				return
+			}
+			resp, err := m(d.server, request.Context(), req)
+			if err != nil {
+				encoder.EncodeError(err)
+				return
+			}
+			if strings.HasPrefix(request.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+				http.Redirect(writer, request, path+"/", http.StatusSeeOther)
+				return
+			}
+			encoder.EncodeResponse(resp.AsMap())
+		})
+	}
+	action("log-level", (*Server).SetLogLevel, func(request *http.Request) (map[string]interface{}, error) {
+		return map[string]interface{}{"level": request.FormValue("level"), "module": request.FormValue("module")}, nil
+	})
+	action("maintenance", (*Server).SetMaintenance, func(request *http.Request) (map[string]interface{}, error) {
+		enable, err := strconv.ParseBool(request.FormValue("enable"))
+		return map[string]interface{}{"enable": enable}, err
+	})
+	action("cron/trigger", (*Server).TriggerCron, func(request *http.Request) (map[string]interface{}, error) {
+		id, err := strconv.Atoi(request.FormValue("id"))
+		return map[string]interface{}{"id": id}, err
+	})
+	action("cache/flush", (*Server).FlushCache, func(request *http.Request) (map[string]interface{}, error) {
+		return map[string]interface{}{"name": request.FormValue("name")}, nil
+	})
+	action("reload", (*Server).ReloadConfig, func(request *http.Request) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	})
+	return router
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Admin</title>
+<style>
+body{font-family:sans-serif;margin:2em}table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:.2em .5em;text-align:left}
+.down{color:#c00}form{display:inline}pre{background:#f6f6f6;padding:1em;overflow:auto}
+</style>
+</head>
+<body>
+<h1>Admin</h1>
+{{if .User}}<p>Signed in as {{.User}}</p>{{end}}
+
+<h2>Maintenance</h2>
+<p>Maintenance mode is {{if .Maintenance}}on{{else}}off{{end}}.
+<form method="post" action="{{.Path}}/api/maintenance"><input type="hidden" name="enable" value="{{not .Maintenance}}"><button>Turn {{if .Maintenance}}off{{else}}on{{end}}</button></form>
+<form method="post" action="{{.Path}}/api/reload"><button>Reload configuration</button></form>
+<form method="post" action="{{.Path}}/api/cache/flush"><input name="name" placeholder="cache, empty for all"><button>Flush cache</button></form>
+</p>
+
+<h2>Health</h2>
+<table><tr><th>Module</th><th>Status</th></tr>
+{{range .Health}}<tr><td>{{.Module}}</td><td{{if not .Healthy}} class="down"{{end}}>{{if .Healthy}}healthy{{else}}{{.Error}}{{end}}</td></tr>{{end}}
+</table>
+<h3>Connections</h3>
+<table><tr><th>Kind</th><th>Name</th><th>Status</th><th>Since</th></tr>
+{{range .Connections}}<tr><td>{{.Kind}}</td><td>{{.Name}}</td><td{{if not .Up}} class="down"{{end}}>{{if .Up}}up{{else}}down{{end}}</td><td>{{.Since}}</td></tr>{{end}}
+</table>
+
+<h2>Cron</h2>
+<table><tr><th>ID</th><th>Previous</th><th>Next</th><th></th></tr>
+{{range .CronEntries}}<tr><td>{{.id}}</td><td>{{.prev}}</td><td>{{.next}}</td><td><form method="post" action="{{$.Path}}/api/cron/trigger"><input type="hidden" name="id" value="{{.id}}"><button>Run now</button></form></td></tr>{{end}}
+</table>
+<h3>History</h3>
+<table><tr><th>Job</th><th>Finished</th><th>Duration</th><th>Panic</th></tr>
+{{range .CronHistory}}<tr><td>{{.Job}}</td><td>{{.Finished}}</td><td>{{.Duration}}</td><td class="down">{{.Panic}}</td></tr>{{end}}
+</table>
+
+<h2>Queues</h2>
+<table><tr><th>Queue</th><th>Job</th><th>Error</th><th>Time</th></tr>
+{{range .QueueFailures}}<tr><td>{{.Queue}}</td><td>{{.Job}}</td><td class="down">{{.Error}}</td><td>{{.Time}}</td></tr>{{end}}
+</table>
+
+<h2>Feature flags</h2>
+<table><tr><th>Flag</th><th>Value</th></tr>
+{{range $name, $value := .Flags}}<tr><td>{{$name}}</td><td>{{$value}}</td></tr>{{end}}
+</table>
+
+<h2>Log levels</h2>
+<p>Global: {{.LogLevels.level}}</p>
+<table><tr><th>Module</th><th>Level</th></tr>
+{{range $module, $level := .LogLevels.modules}}<tr><td>{{$module}}</td><td>{{$level}}</td></tr>{{end}}
+</table>
+<form method="post" action="{{.Path}}/api/log-level"><input name="module" placeholder="module, empty for global"><input name="level" placeholder="debug, info, warn, error, none"><button>Set level</button></form>
+
+<h2>Configuration</h2>
+<p><a href="{{.Path}}/api/overview">JSON</a></p>
+<pre>{{range $key, $value := .Config}}{{$key}}: {{printf "%v" $value}}
+{{end}}</pre>
+</body>
+</html>
+`))
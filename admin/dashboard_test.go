@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/container"
+	"github.com/DoNewsCode/core/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type unhealthyModule struct{}
+
+func (u unhealthyModule) HealthCheck(ctx context.Context) error {
+	return errors.New("db unreachable")
+}
+
+func TestDashboard(t *testing.T) {
+	dispatcher := &events.SyncDispatcher{}
+	var modules container.Container
+	modules.AddModule(unhealthyModule{})
+	maintenance := &Maintenance{exempt: "/admin"}
+	server := &Server{
+		Conf: config.MapAdapter{
+			"flags": map[string]interface{}{"newCheckout": true},
+			"gorm":  map[string]interface{}{"default": map[string]interface{}{"dsn": "root:pass@tcp(db)/app"}},
+		},
+		Dispatcher:  dispatcher,
+		Maintenance: maintenance,
+	}
+	dashboard := NewDashboard(server, &modules)
+	dispatcher.Dispatch(context.Background(), events.OnCronJobFinished, events.OnCronJobFinishedPayload{Job: "cleanup"})
+	dispatcher.Dispatch(context.Background(), events.OnConnectionUp, events.OnConnectionUpPayload{Name: "default", Conn: "conn"})
+	dispatcher.Dispatch(context.Background(), events.OnQueueJobFailed, events.OnQueueJobFailedPayload{Queue: "search", Job: "users/1", Err: errors.New("timeout")})
+
+	handler := MakeHTTPMiddleware(maintenance)(dashboard.Handler("/admin"))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/maintenance", strings.NewReader("enable=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.True(t, maintenance.Enabled())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/api/overview", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var overview Overview
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overview))
+	assert.True(t, overview.Maintenance)
+	assert.Equal(t, []HealthStatus{{Module: "admin.unhealthyModule", Error: "db unreachable"}}, overview.Health)
+	assert.Equal(t, "cleanup", overview.CronHistory[0].Job)
+	assert.Equal(t, "timeout", overview.QueueFailures[0].Error)
+	assert.True(t, overview.Connections[0].Up)
+	assert.Equal(t, map[string]interface{}{"newCheckout": true}, overview.Flags)
+	assert.Equal(t, "******", overview.Config["gorm"].(map[string]interface{})["default"].(map[string]interface{})["dsn"])
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "db unreachable")
+}
+
+func TestSSO(t *testing.T) {
+	provider := http.NewServeMux()
+	provider.HandleFunc("/authorize", func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		http.Redirect(writer, request, query.Get("redirect_uri")+"?code=abc&state="+query.Get("state"), http.StatusFound)
+	})
+	provider.HandleFunc("/token", func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "abc", request.FormValue("code"))
+		writer.Write([]byte(`{"access_token":"at"}`))
+	})
+	provider.HandleFunc("/userinfo", func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "Bearer at", request.Header.Get("Authorization"))
+		writer.Write([]byte(`{"email":"alice@example.com","email_verified":true}`))
+	})
+	idp := httptest.NewServer(provider)
+	defer idp.Close()
+
+	newApp := func(option SSOOption) *httptest.Server {
+		sso, err := NewSSO(option)
+		assert.NoError(t, err)
+		return httptest.NewServer(sso.Middleware("/admin", "token")(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Write([]byte(UserFromContext(request.Context())))
+		})))
+	}
+	option := SSOOption{
+		AuthURL:        idp.URL + "/authorize",
+		TokenURL:       idp.URL + "/token",
+		UserInfoURL:    idp.URL + "/userinfo",
+		ClientID:       "admin",
+		AllowedDomains: []string{"example.com"},
+	}
+
+	t.Run("signed in", func(t *testing.T) {
+		app := newApp(option)
+		defer app.Close()
+		jar, _ := cookiejar.New(nil)
+		client := &http.Client{Jar: jar}
+		resp, err := client.Get(app.URL + "/admin/")
+		assert.NoError(t, err)
+		body := make([]byte, 100)
+		n, _ := resp.Body.Read(body)
+		resp.Body.Close()
+		assert.Equal(t, "alice@example.com", string(body[:n]))
+
+		resp, err = client.PostForm(app.URL+"/admin/api/reload", url.Values{})
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		option := option
+		option.AllowedDomains = nil
+		option.AllowedEmails = []string{"bob@example.com"}
+		app := newApp(option)
+		defer app.Close()
+		jar, _ := cookiejar.New(nil)
+		resp, err := (&http.Client{Jar: jar}).Get(app.URL + "/admin/")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("token", func(t *testing.T) {
+		app := newApp(option)
+		defer app.Close()
+		req, _ := http.NewRequest(http.MethodPost, app.URL+"/admin/api/reload", nil)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
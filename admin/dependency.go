@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/robfig/cron/v3"
+)
+
+// Option is the configuration of the admin service.
+type Option struct {
+	// Disable stops the admin service from listening. The client commands are
+	// still available.
+	Disable bool `json:"disable" yaml:"disable"`
+	// Addr is the listen address. Defaults to 127.0.0.1:9099.
+	Addr string `json:"addr" yaml:"addr"`
+	// Token is the bearer token required by the service, if not empty. It is
+	// also accepted by the dashboard.
+	Token string `json:"token" yaml:"token"`
+	// Dashboard is the web dashboard served on the HTTP server.
+	Dashboard DashboardOption `json:"dashboard" yaml:"dashboard"`
+}
+
+/*
+Providers returns a set of dependency providers for package admin.
+
+	Depends On:
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+		*logging.Levels `optional:"true"`
+		LevelPersister `optional:"true"`
+		*cron.Cron `optional:"true"`
+		contract.Container `optional:"true"`
+	Provide:
+		*Maintenance
+		*Server
+		*Dashboard
+		*SSO
+		Option
+*/
+func Providers() di.Deps {
+	return di.Deps{provideAdmin, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+	LogLevels  *logging.Levels     `optional:"true"`
+	Persister  LevelPersister      `optional:"true"`
+	Cron       *cron.Cron          `optional:"true"`
+	Container  contract.Container  `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Maintenance *Maintenance
+	Server      *Server
+	Dashboard   *Dashboard
+	SSO         *SSO
+	Option      Option
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideAdmin(in in) (out, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("admin", &option); err != nil {
+		return out{}, fmt.Errorf("admin configuration error: %w", err)
+	}
+	if option.Addr == "" {
+		option.Addr = "127.0.0.1:9099"
+	}
+	if option.Dashboard.Path == "" {
+		option.Dashboard.Path = "/admin"
+	}
+	option.Dashboard.Path = strings.TrimSuffix(option.Dashboard.Path, "/")
+	maintenance := &Maintenance{}
+	server := &Server{
+		Conf:           in.Conf,
+		Dispatcher:     in.Dispatcher,
+		LogLevels:      in.LogLevels,
+		LevelPersister: in.Persister,
+		Maintenance:    maintenance,
+		Cron:           in.Cron,
+	}
+	var (
+		dashboard *Dashboard
+		sso       *SSO
+	)
+	if option.Dashboard.Enable {
+		var err error
+		if sso, err = NewSSO(option.Dashboard.SSO); err != nil {
+			return out{}, fmt.Errorf("admin dashboard sso configuration error: %w", err)
+		}
+		maintenance.exempt = option.Dashboard.Path
+		dashboard = NewDashboard(server, in.Container)
+	}
+	return out{
+		Maintenance: maintenance,
+		Option:      option,
+		Server:      server,
+		Dashboard:   dashboard,
+		SSO:         sso,
+	}, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "admin",
+			Data: map[string]interface{}{
+				"admin": Option{
+					Disable: false,
+					Addr:    "127.0.0.1:9099",
+					Token:   "",
+					Dashboard: DashboardOption{
+						Enable: false,
+						Path:   "/admin",
+						SSO: SSOOption{
+							Scopes:         []string{"openid", "email"},
+							AllowedEmails:  []string{},
+							AllowedDomains: []string{},
+							SessionTTL:     config.Duration{Duration: 12 * time.Hour},
+						},
+					},
+				},
+			},
+			Comment: "The admin service configuration",
+		},
+	}}
+}
@@ -0,0 +1,79 @@
+/*
+Package admin provides an internal gRPC service for runtime control, and a
+command line client for it.
+
+The admin service listens on its own address, separated from the business
+listeners, and is bound to the loopback interface by default. It offers RPCs to:
+
+	- reload the configuration,
+	- show and change the log level, globally or per module,
+	- toggle the maintenance mode,
+	- list and trigger cron jobs,
+	- flush caches.
+
+Log level changes last until restart. To keep them, provide a LevelPersister,
+which writes the levels back to the configuration source, and set the persist
+flag. The per module levels apply to logs whose "module" key matches, and can
+also be configured under log.modules.
+
+The maintenance mode is enforced by the middlewares in this package. Caches
+are flushed by dispatching the OnCacheFlush event, so modules owning caches
+should subscribe to it. Cron jobs can only be triggered if the *cron.Cron is
+provided to the core.
+
+The messages are google.protobuf.Struct, so the service can be called without
+generated code, for example with grpcurl.
+
+The same controls are also available on a web dashboard, served on the HTTP
+server under admin.dashboard.path and protected by single sign-on with an
+OpenID Connect provider. Besides the controls, the dashboard shows the
+configuration with sensitive values masked, the health of the modules
+implementing HealthChecker, the state of the connections, the history of cron
+runs and failed queue jobs, and the feature flags under the "flags"
+configuration. The dashboard stays reachable in maintenance mode.
+
+Integration
+
+package admin exports the configuration in the following format:
+
+	admin:
+	  disable: false
+	  addr: 127.0.0.1:9099
+	  token: ""
+	  dashboard:
+	    enable: false
+	    path: /admin
+	    sso:
+	      authURL: https://accounts.example.com/authorize
+	      tokenURL: https://accounts.example.com/token
+	      userInfoURL: https://accounts.example.com/userinfo
+	      clientID: admin
+	      clientSecret: secret
+	      scopes: [openid, email]
+	      redirectURL: ""
+	      allowedEmails: []
+	      allowedDomains: [example.com]
+	      cookieSecret: ""
+	      sessionTTL: 12h
+
+If token is not empty, the callers must send it in the "authorization"
+metadata as a bearer token. Add the admin dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(admin.Providers())
+	c.Invoke(func(maintenance *admin.Maintenance) {
+		router.Use(admin.MakeHTTPMiddleware(maintenance))
+	})
+
+The client commands are:
+
+	app admin reload
+	app admin log-level
+	app admin log-level info
+	app admin log-level debug --module otgorm --persist
+	app admin maintenance on
+	app admin cron list
+	app admin cron trigger 1
+	app admin cache flush [name]
+*/
+package admin
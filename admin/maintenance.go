@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/DoNewsCode/core/unierr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Maintenance is the maintenance mode switch. Maintenance is safe for
+// concurrent use.
+type Maintenance struct {
+	on int32
+	// exempt is the path prefix of the dashboard, which stays reachable in
+	// maintenance mode, so that the mode can be turned off there.
+	exempt string
+}
+
+// Enabled reports whether the maintenance mode is on.
+func (m *Maintenance) Enabled() bool {
+	return atomic.LoadInt32(&m.on) == 1
+}
+
+// Set turns the maintenance mode on or off.
+func (m *Maintenance) Set(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&m.on, v)
+}
+
+func (m *Maintenance) isExempt(path string) bool {
+	return m.exempt != "" && strings.HasPrefix(path, m.exempt+"/")
+}
+
+func (m *Maintenance) err() *unierr.Error {
+	e := unierr.UnavailableErr(nil, "service under maintenance")
+	e.HttpStatusCodeFunc = func(code codes.Code) int {
+		return http.StatusServiceUnavailable
+	}
+	return e
+}
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that rejects requests
+// with 503 Service Unavailable while in maintenance mode. The dashboard is not
+// affected.
+func MakeHTTPMiddleware(maintenance *Maintenance) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if maintenance.Enabled() && !maintenance.isExempt(request.URL.Path) {
+				srvhttp.NewResponseEncoder(writer).EncodeError(maintenance.err())
+				return
+			}
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that rejects
+// calls with UNAVAILABLE while in maintenance mode.
+func MakeUnaryInterceptor(maintenance *Maintenance) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if maintenance.Enabled() {
+			return nil, maintenance.err()
+		}
+		return handler(ctx, req)
+	}
+}
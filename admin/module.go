@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/oklog/run"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// ProvideRunGroup starts the admin gRPC service, unless disabled.
+func (m out) ProvideRunGroup(group *run.Group) {
+	if m.Option.Disable {
+		return
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(MakeAuthInterceptor(m.Option.Token)))
+	Register(server, m.Server)
+	group.Add(func() error {
+		ln, err := net.Listen("tcp", m.Option.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on admin address %s: %w", m.Option.Addr, err)
+		}
+		return server.Serve(ln)
+	}, func(err error) {
+		server.Stop()
+	})
+}
+
+// ProvideHTTP serves the dashboard behind the single sign-on, if enabled.
+func (m out) ProvideHTTP(router *mux.Router) {
+	if m.Dashboard == nil {
+		return
+	}
+	path := m.Option.Dashboard.Path
+	router.PathPrefix(path + "/").Handler(m.SSO.Middleware(path, m.Option.Token)(m.Dashboard.Handler(path)))
+}
+
+// ProvideCommand adds the "admin" commands, which call the admin service.
+func (m out) ProvideCommand(command *cobra.Command) {
+	var (
+		addr  string
+		token string
+	)
+	call := func(cmd *cobra.Command, name string, req map[string]interface{}) error {
+		ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			return fmt.Errorf("failed to dial %s: %w", addr, err)
+		}
+		defer conn.Close()
+		resp, err := NewClient(conn, token).Call(ctx, name, req)
+		if err != nil {
+			return err
+		}
+		b, _ := json.MarshalIndent(resp, "", "  ")
+		cmd.Println(string(b))
+		return nil
+	}
+
+	reloadCmd := &cobra.Command{
+		Use:   "reload",
+		Short: "reload the configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return call(cmd, "ReloadConfig", nil)
+		},
+	}
+	var (
+		module  string
+		persist bool
+	)
+	logLevelCmd := &cobra.Command{
+		Use:   "log-level [level]",
+		Short: "show or change the log level",
+		Long:  "show the log levels, or change the log level to one of debug, info, warn, error or none. If --module is set, only the logs of that module are affected, and an empty level removes the override.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return call(cmd, "GetLogLevel", nil)
+			}
+			return call(cmd, "SetLogLevel", map[string]interface{}{"level": args[0], "module": module, "persist": persist})
+		},
+	}
+	logLevelCmd.Flags().StringVar(&module, "module", "", "the module whose level is changed")
+	logLevelCmd.Flags().BoolVar(&persist, "persist", false, "write the levels back to the configuration source")
+	maintenanceCmd := &cobra.Command{
+		Use:       "maintenance [on|off]",
+		Short:     "toggle the maintenance mode",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{"on", "off"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return call(cmd, "SetMaintenance", map[string]interface{}{"enable": args[0] == "on"})
+		},
+	}
+	cronListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the cron jobs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return call(cmd, "ListCron", nil)
+		},
+	}
+	cronTriggerCmd := &cobra.Command{
+		Use:   "trigger [id]",
+		Short: "run the cron job immediately",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid cron id %s: %w", args[0], err)
+			}
+			return call(cmd, "TriggerCron", map[string]interface{}{"id": id})
+		},
+	}
+	cronCmd := &cobra.Command{
+		Use:   "cron",
+		Short: "manage the cron jobs",
+	}
+	cronCmd.AddCommand(cronListCmd, cronTriggerCmd)
+	cacheFlushCmd := &cobra.Command{
+		Use:   "flush [name]",
+		Short: "flush the caches",
+		Long:  "flush the named cache, or all caches if no name is given.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return call(cmd, "FlushCache", map[string]interface{}{"name": name})
+		},
+	}
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the caches",
+	}
+	cacheCmd.AddCommand(cacheFlushCmd)
+
+	adminCmd := &cobra.Command{
+		Use:   "admin",
+		Short: "control the running application",
+		Long:  "control the running application through the admin service.",
+	}
+	adminCmd.PersistentFlags().StringVar(&addr, "addr", m.Option.Addr, "the admin service address")
+	adminCmd.PersistentFlags().StringVar(&token, "token", m.Option.Token, "the admin token")
+	adminCmd.AddCommand(reloadCmd, logLevelCmd, maintenanceCmd, cronCmd, cacheCmd)
+	command.AddCommand(adminCmd)
+}
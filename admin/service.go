@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/DoNewsCode/core/unierr"
+	"github.com/robfig/cron/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ServiceName is the full name of the admin gRPC service.
+const ServiceName = "core.admin.Admin"
+
+type event string
+
+// OnCacheFlush is an event dispatched by the FlushCache RPC. The event payload
+// is OnCacheFlushPayload.
+const OnCacheFlush event = "onCacheFlush"
+
+// OnCacheFlushPayload is the payload of OnCacheFlush.
+type OnCacheFlushPayload struct {
+	// Name is the cache to flush. Empty means all caches.
+	Name string
+}
+
+// LevelPersister writes the log levels back to the configuration source, eg.
+// a remote config key, so that they survive restarts. The levels are in the
+// format of the "log.level" and "log.modules" configuration.
+type LevelPersister interface {
+	PersistLevels(ctx context.Context, level string, modules map[string]string) error
+}
+
+// Server implements the admin gRPC service.
+type Server struct {
+	Conf           contract.ConfigAccessor
+	Dispatcher     contract.Dispatcher
+	LogLevels      *logging.Levels
+	LevelPersister LevelPersister
+	Maintenance    *Maintenance
+	Cron           *cron.Cron
+}
+
+// ReloadConfig reloads the configuration, if the config supports reloading.
+func (s *Server) ReloadConfig(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	reloader, ok := s.Conf.(interface{ Reload() error })
+	if !ok {
+		return nil, unierr.UnimplementedErr(nil, "config doesn't support reloading")
+	}
+	if err := reloader.Reload(); err != nil {
+		return nil, unierr.InternalErr(err, "failed to reload config")
+	}
+	return &structpb.Struct{}, nil
+}
+
+// GetLogLevel returns the global log level and the per module levels.
+func (s *Server) GetLogLevel(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if s.LogLevels == nil {
+		return nil, unierr.UnimplementedErr(nil, "the logger doesn't support runtime levels")
+	}
+	return s.logLevels()
+}
+
+// SetLogLevel changes the log level. The request has the field "level", and the
+// optional field "module". If module is set, only the level of that module is
+// changed, and an empty level removes the module override. The change lasts
+// until restart, unless the field "persist" is true, in which case the levels
+// are also written back by the LevelPersister.
+func (s *Server) SetLogLevel(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if s.LogLevels == nil {
+		return nil, unierr.UnimplementedErr(nil, "the logger doesn't support runtime levels")
+	}
+	fields := req.GetFields()
+	persist := fields["persist"].GetBoolValue()
+	if persist && s.LevelPersister == nil {
+		return nil, unierr.FailedPreconditionErr(nil, "admin.LevelPersister is not provided")
+	}
+	var err error
+	if module := fields["module"].GetStringValue(); module != "" {
+		err = s.LogLevels.SetModule(module, fields["level"].GetStringValue())
+	} else {
+		err = s.LogLevels.Set(fields["level"].GetStringValue())
+	}
+	if err != nil {
+		return nil, unierr.InvalidArgumentErr(err)
+	}
+	if persist {
+		if err := s.LevelPersister.PersistLevels(ctx, s.LogLevels.Get(), s.LogLevels.Modules()); err != nil {
+			return nil, unierr.InternalErr(err, "failed to persist log levels")
+		}
+	}
+	return s.logLevels()
+}
+
+func (s *Server) logLevels() (*structpb.Struct, error) {
+	modules := make(map[string]interface{})
+	for module, lvl := range s.LogLevels.Modules() {
+		modules[module] = lvl
+	}
+	return structpb.NewStruct(map[string]interface{}{"level": s.LogLevels.Get(), "modules": modules})
+}
+
+// SetMaintenance toggles the maintenance mode. The request has the field "enable".
+func (s *Server) SetMaintenance(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	s.Maintenance.Set(req.GetFields()["enable"].GetBoolValue())
+	return structpb.NewStruct(map[string]interface{}{"enable": s.Maintenance.Enabled()})
+}
+
+// ListCron lists the cron entries.
+func (s *Server) ListCron(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if s.Cron == nil {
+		return nil, unierr.FailedPreconditionErr(nil, "*cron.Cron is not provided")
+	}
+	entries := s.Cron.Entries()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	list := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, map[string]interface{}{
+			"id":   float64(entry.ID),
+			"next": entry.Next.String(),
+			"prev": entry.Prev.String(),
+		})
+	}
+	return structpb.NewStruct(map[string]interface{}{"entries": list})
+}
+
+// TriggerCron runs the cron entry immediately, in background. The request has
+// the field "id".
+func (s *Server) TriggerCron(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if s.Cron == nil {
+		return nil, unierr.FailedPreconditionErr(nil, "*cron.Cron is not provided")
+	}
+	id := cron.EntryID(req.GetFields()["id"].GetNumberValue())
+	entry := s.Cron.Entry(id)
+	if !entry.Valid() {
+		return nil, unierr.NotFoundErr(nil, "cron entry %d not found", id)
+	}
+	go entry.WrappedJob.Run()
+	return &structpb.Struct{}, nil
+}
+
+// FlushCache dispatches the OnCacheFlush event. The request has the optional
+// field "name".
+func (s *Server) FlushCache(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if s.Dispatcher == nil {
+		return nil, unierr.FailedPreconditionErr(nil, "contract.Dispatcher is not provided")
+	}
+	payload := OnCacheFlushPayload{Name: req.GetFields()["name"].GetStringValue()}
+	if err := s.Dispatcher.Dispatch(ctx, OnCacheFlush, payload); err != nil {
+		return nil, unierr.InternalErr(err, "failed to flush cache")
+	}
+	return &structpb.Struct{}, nil
+}
+
+type method func(s *Server, ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+
+var methods = map[string]method{
+	"ReloadConfig":   (*Server).ReloadConfig,
+	"GetLogLevel":    (*Server).GetLogLevel,
+	"SetLogLevel":    (*Server).SetLogLevel,
+	"SetMaintenance": (*Server).SetMaintenance,
+	"ListCron":       (*Server).ListCron,
+	"TriggerCron":    (*Server).TriggerCron,
+	"FlushCache":     (*Server).FlushCache,
+}
+
+// Register registers the admin service to the gRPC server.
+func Register(server *grpc.Server, s *Server) {
+	desc := grpc.ServiceDesc{
+		ServiceName: ServiceName,
+		HandlerType: (*interface{})(nil),
+	}
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: name,
+			Handler:    handler(name, methods[name]),
+		})
+	}
+	server.RegisterService(&desc, s)
+}
+
+func handler(name string, m method) func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(structpb.Struct)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return m(srv.(*Server), ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: fmt.Sprintf("/%s/%s", ServiceName, name),
+		}
+		return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return m(srv.(*Server), ctx, req.(*structpb.Struct))
+		})
+	}
+}
+
+// MakeAuthInterceptor creates a grpc.UnaryServerInterceptor that requires the
+// bearer token in the "authorization" metadata. An empty token disables the
+// check.
+func MakeAuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if token == "" {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(values[0], "Bearer ")), []byte(token)) != 1 {
+			return nil, unierr.UnauthenticatedErr(nil, "invalid admin token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Client calls the admin service.
+type Client struct {
+	conn  *grpc.ClientConn
+	token string
+}
+
+// NewClient creates a *Client over the connection. The token is sent as a
+// bearer token if not empty.
+func NewClient(conn *grpc.ClientConn, token string) *Client {
+	return &Client{conn: conn, token: token}
+}
+
+// Call invokes the admin RPC by name, eg. "SetLogLevel".
+func (c *Client) Call(ctx context.Context, name string, req map[string]interface{}) (map[string]interface{}, error) {
+	in, err := structpb.NewStruct(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+	}
+	out := new(structpb.Struct)
+	if err := c.conn.Invoke(ctx, fmt.Sprintf("/%s/%s", ServiceName, name), in, out); err != nil {
+		return nil, err
+	}
+	return out.AsMap(), nil
+}
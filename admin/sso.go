@@ -0,0 +1,301 @@
+package admin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+)
+
+const (
+	sessionCookie = "admin_session"
+	stateCookie   = "admin_sso_state"
+)
+
+// SSOOption configures the single sign-on of the dashboard, with the OAuth2
+// authorization code flow of an OpenID Connect provider.
+type SSOOption struct {
+	AuthURL      string   `json:"authURL" yaml:"authURL"`
+	TokenURL     string   `json:"tokenURL" yaml:"tokenURL"`
+	UserInfoURL  string   `json:"userInfoURL" yaml:"userInfoURL"`
+	ClientID     string   `json:"clientID" yaml:"clientID"`
+	ClientSecret string   `json:"clientSecret" yaml:"clientSecret"`
+	Scopes       []string `json:"scopes" yaml:"scopes"`
+	// RedirectURL is the callback URL registered at the provider. Defaults to
+	// the callback path on the requested host.
+	RedirectURL string `json:"redirectURL" yaml:"redirectURL"`
+	// AllowedEmails and AllowedDomains are the users allowed to the dashboard.
+	// At least one of them must be set.
+	AllowedEmails  []string `json:"allowedEmails" yaml:"allowedEmails"`
+	AllowedDomains []string `json:"allowedDomains" yaml:"allowedDomains"`
+	// CookieSecret signs the session cookies. If empty, a random secret is
+	// used, so the sessions don't survive restarts or span replicas.
+	CookieSecret string `json:"cookieSecret" yaml:"cookieSecret"`
+	// SessionTTL is how long a session lasts. Defaults to 12h.
+	SessionTTL config.Duration `json:"sessionTTL" yaml:"sessionTTL"`
+}
+
+type userKey struct{}
+
+// UserFromContext returns the email of the user signed in to the dashboard.
+func UserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// SSO authenticates the dashboard users with an OpenID Connect provider. The
+// session is kept in a signed cookie.
+type SSO struct {
+	option SSOOption
+	secret []byte
+	doer   contract.HttpDoer
+	now    func() time.Time
+}
+
+// NewSSO creates a *SSO.
+func NewSSO(option SSOOption) (*SSO, error) {
+	if option.AuthURL == "" || option.TokenURL == "" || option.UserInfoURL == "" || option.ClientID == "" {
+		return nil, errors.New("authURL, tokenURL, userInfoURL and clientID are required")
+	}
+	if len(option.AllowedEmails) == 0 && len(option.AllowedDomains) == 0 {
+		return nil, errors.New("allowedEmails or allowedDomains is required")
+	}
+	if len(option.Scopes) == 0 {
+		option.Scopes = []string{"openid", "email"}
+	}
+	if option.SessionTTL.Duration == 0 {
+		option.SessionTTL = config.Duration{Duration: 12 * time.Hour}
+	}
+	secret := []byte(option.CookieSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return &SSO{option: option, secret: secret, doer: http.DefaultClient, now: time.Now}, nil
+}
+
+// Middleware creates a HTTP middleware that requires a signed in user under
+// the path prefix. The callback of the provider is handled at path + "/sso/callback".
+// Unauthenticated GET requests are redirected to the provider, the others are
+// rejected with 401. A request carrying token as the bearer token is let
+// through as well, if token is not empty.
+func (s *SSO) Middleware(path string, token string) func(handler http.Handler) http.Handler {
+	callback := path + "/sso/callback"
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path == callback {
+				s.handleCallback(writer, request, path)
+				return
+			}
+			if token != "" {
+				auth := request.Header.Get("Authorization")
+				if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) == 1 {
+					handler.ServeHTTP(writer, request)
+					return
+				}
+			}
+			if user, ok := s.session(request); ok {
+				handler.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), userKey{}, user)))
+				return
+			}
+			if request.Method != http.MethodGet {
+				http.Error(writer, "unauthenticated", http.StatusUnauthorized)
+				return
+			}
+			s.login(writer, request, path)
+		})
+	}
+}
+
+func (s *SSO) login(writer http.ResponseWriter, request *http.Request, path string) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(b)
+	http.SetCookie(writer, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state + "|" + request.URL.RequestURI(),
+		Path:     path,
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   isHTTPS(request),
+		SameSite: http.SameSiteLaxMode,
+	})
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {s.option.ClientID},
+		"redirect_uri":  {s.redirectURL(request, path)},
+		"scope":         {strings.Join(s.option.Scopes, " ")},
+		"state":         {state},
+	}
+	sep := "?"
+	if strings.Contains(s.option.AuthURL, "?") {
+		sep = "&"
+	}
+	http.Redirect(writer, request, s.option.AuthURL+sep+query.Encode(), http.StatusFound)
+}
+
+func (s *SSO) handleCallback(writer http.ResponseWriter, request *http.Request, path string) {
+	cookie, err := request.Cookie(stateCookie)
+	if err != nil {
+		http.Error(writer, "missing sso state", http.StatusBadRequest)
+		return
+	}
+	parts := strings.SplitN(cookie.Value, "|", 2)
+	if len(parts) != 2 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(request.URL.Query().Get("state"))) != 1 {
+		http.Error(writer, "invalid sso state", http.StatusBadRequest)
+		return
+	}
+	email, err := s.exchange(request.Context(), request.URL.Query().Get("code"), s.redirectURL(request, path))
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !s.allowed(email) {
+		http.Error(writer, fmt.Sprintf("%s is not allowed", email), http.StatusForbidden)
+		return
+	}
+	http.SetCookie(writer, &http.Cookie{Name: stateCookie, Path: path, MaxAge: -1})
+	http.SetCookie(writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    s.sign(email, s.now().Add(s.option.SessionTTL.Duration)),
+		Path:     path,
+		HttpOnly: true,
+		Secure:   isHTTPS(request),
+		SameSite: http.SameSiteLaxMode,
+	})
+	target := parts[1]
+	if !strings.HasPrefix(target, path) || strings.HasPrefix(target, "//") {
+		target = path + "/"
+	}
+	http.Redirect(writer, request, target, http.StatusFound)
+}
+
+func (s *SSO) exchange(ctx context.Context, code string, redirectURL string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {s.option.ClientID},
+		"client_secret": {s.option.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.option.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := s.do(req, &token); err != nil {
+		return "", fmt.Errorf("failed to exchange the authorization code: %w", err)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, s.option.UserInfoURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	var info struct {
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+	}
+	if err := s.do(req, &info); err != nil {
+		return "", fmt.Errorf("failed to get the user info: %w", err)
+	}
+	if info.Email == "" || (info.EmailVerified != nil && !*info.EmailVerified) {
+		return "", errors.New("the user has no verified email")
+	}
+	return info.Email, nil
+}
+
+func (s *SSO) do(req *http.Request, v interface{}) error {
+	resp, err := s.doer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (s *SSO) allowed(email string) bool {
+	email = strings.ToLower(email)
+	for _, allowed := range s.option.AllowedEmails {
+		if strings.ToLower(allowed) == email {
+			return true
+		}
+	}
+	for _, domain := range s.option.AllowedDomains {
+		if strings.HasSuffix(email, "@"+strings.ToLower(domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SSO) session(request *http.Request) (string, bool) {
+	cookie, err := request.Cookie(sessionCookie)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	user, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", false
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || s.now().Unix() > expiry {
+		return "", false
+	}
+	if !hmac.Equal([]byte(cookie.Value), []byte(s.sign(string(user), time.Unix(expiry, 0)))) {
+		return "", false
+	}
+	return string(user), true
+}
+
+func (s *SSO) sign(user string, expiry time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(user)) + "." + strconv.FormatInt(expiry.Unix(), 10)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *SSO) redirectURL(request *http.Request, path string) string {
+	if s.option.RedirectURL != "" {
+		return s.option.RedirectURL
+	}
+	scheme := "http"
+	if isHTTPS(request) {
+		scheme = "https"
+	}
+	return scheme + "://" + request.Host + path + "/sso/callback"
+}
+
+func isHTTPS(request *http.Request) bool {
+	return request.TLS != nil || request.Header.Get("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,190 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Alert is a notification rendered from a dispatched event.
+type Alert struct {
+	// Event is the name of the event topic.
+	Event string
+	// Summary is the rendered message.
+	Summary string
+	// Suppressed is the number of alerts of the same rule dropped by the rate
+	// limit since the last one.
+	Suppressed int
+}
+
+// Notifier sends alerts to a notification channel, such as Slack.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Rule maps an event to the notification channels.
+type Rule struct {
+	// Event is the name of the event topic, eg. "onCertExpiring".
+	Event string `json:"event" yaml:"event"`
+	// Channels are the names of the notification channels.
+	Channels []string `json:"channels" yaml:"channels"`
+	// Template renders the summary with text/template. The data has the
+	// fields Event, Payload, AppName and Env. Defaults to the event name and
+	// the payload.
+	Template string `json:"template" yaml:"template"`
+	// Interval is the minimum time between two alerts of the rule. The alerts
+	// in between are dropped, and counted in the next one.
+	Interval config.Duration `json:"interval" yaml:"interval"`
+}
+
+type compiledRule struct {
+	Rule
+	template *template.Template
+
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// allow applies the rate limit. It returns the number of suppressed alerts
+// since the last allowed one.
+func (r *compiledRule) allow(now time.Time) (bool, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.last.IsZero() && now.Sub(r.last) < r.Interval.Duration {
+		r.suppressed++
+		return false, 0
+	}
+	suppressed := r.suppressed
+	r.last, r.suppressed = now, 0
+	return true, suppressed
+}
+
+// Alerter turns the dispatched events into alerts, according to the rules.
+type Alerter struct {
+	notifiers map[string]Notifier
+	rules     map[string][]*compiledRule
+	appName   string
+	env       string
+	timeout   time.Duration
+	logger    log.Logger
+	wg        sync.WaitGroup
+}
+
+// AlerterOption changes the behavior of Alerter.
+type AlerterOption func(*Alerter)
+
+// WithLogger sets the logger for the notification errors.
+func WithLogger(logger log.Logger) AlerterOption {
+	return func(alerter *Alerter) {
+		alerter.logger = logger
+	}
+}
+
+// WithApp sets the AppName and Env available to the templates.
+func WithApp(appName contract.AppName, env contract.Env) AlerterOption {
+	return func(alerter *Alerter) {
+		alerter.appName = appName.String()
+		alerter.env = env.String()
+	}
+}
+
+// WithTimeout sets the timeout of each notification. Defaults to 10 seconds.
+func WithTimeout(timeout time.Duration) AlerterOption {
+	return func(alerter *Alerter) {
+		alerter.timeout = timeout
+	}
+}
+
+// NewAlerter creates a new *Alerter. It returns an error if a rule refers to
+// an unknown channel, or has an invalid template.
+func NewAlerter(notifiers map[string]Notifier, rules []Rule, options ...AlerterOption) (*Alerter, error) {
+	alerter := &Alerter{
+		notifiers: notifiers,
+		rules:     make(map[string][]*compiledRule),
+		timeout:   10 * time.Second,
+		logger:    log.NewNopLogger(),
+	}
+	for _, option := range options {
+		option(alerter)
+	}
+	for i, rule := range rules {
+		for _, channel := range rule.Channels {
+			if _, ok := notifiers[channel]; !ok {
+				return nil, fmt.Errorf("rule %d refers to unknown channel %s", i, channel)
+			}
+		}
+		text := rule.Template
+		if text == "" {
+			text = "{{.Event}}: {{.Payload}}"
+		}
+		tpl, err := template.New(rule.Event).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d has an invalid template: %w", i, err)
+		}
+		alerter.rules[rule.Event] = append(alerter.rules[rule.Event], &compiledRule{Rule: rule, template: tpl})
+	}
+	return alerter, nil
+}
+
+// Watch subscribes the alerter to the topics. The topics are matched against
+// the rules by their names, eg. certs.OnExpiring is "onCertExpiring".
+func (a *Alerter) Watch(dispatcher contract.Dispatcher, topics ...interface{}) {
+	for _, topic := range topics {
+		name := fmt.Sprint(topic)
+		dispatcher.Subscribe(events.Listen(topic, func(ctx context.Context, event interface{}) error {
+			a.alert(name, event)
+			return nil
+		}))
+	}
+}
+
+// alert sends the notifications in background, so that the dispatcher is not
+// blocked by slow channels.
+func (a *Alerter) alert(name string, payload interface{}) {
+	data := map[string]interface{}{
+		"Event":   name,
+		"Payload": payload,
+		"AppName": a.appName,
+		"Env":     a.env,
+	}
+	for _, rule := range a.rules[name] {
+		ok, suppressed := rule.allow(time.Now())
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := rule.template.Execute(&buf, data); err != nil {
+			level.Warn(a.logger).Log("msg", "failed to render alert", "event", name, "err", err)
+			continue
+		}
+		alert := Alert{Event: name, Summary: buf.String(), Suppressed: suppressed}
+		for _, channel := range rule.Channels {
+			a.wg.Add(1)
+			go func(channel string) {
+				defer a.wg.Done()
+
+				ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+				defer cancel()
+				if err := a.notifiers[channel].Notify(ctx, alert); err != nil {
+					level.Warn(a.logger).Log("msg", "failed to send alert", "event", name, "channel", channel, "err", err)
+				}
+			}(channel)
+		}
+	}
+}
+
+// Wait waits for the alerts in flight.
+func (a *Alerter) Wait() {
+	a.wg.Wait()
+}
@@ -0,0 +1,108 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type event string
+
+const onJobFailed event = "onJobFailed"
+
+type recorder struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (r *recorder) Notify(ctx context.Context, alert Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+type failing struct{}
+
+func (failing) Notify(ctx context.Context, alert Alert) error {
+	return errors.New("unavailable")
+}
+
+func TestAlerter(t *testing.T) {
+	rec := &recorder{}
+	alerter, err := NewAlerter(map[string]Notifier{"rec": rec, "failing": failing{}}, []Rule{
+		{
+			Event:    "onJobFailed",
+			Channels: []string{"rec", "failing"},
+			Template: "{{.AppName}}: job {{.Payload}} failed",
+			Interval: config.Duration{Duration: time.Hour},
+		},
+		{Event: "onJobFailed", Channels: []string{"rec"}},
+	}, WithApp(config.AppName("app"), config.EnvTesting))
+	assert.NoError(t, err)
+
+	dispatcher := &events.SyncDispatcher{}
+	alerter.Watch(dispatcher, onJobFailed)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, dispatcher.Dispatch(context.Background(), onJobFailed, "foo"))
+		alerter.Wait()
+	}
+
+	// the first rule is limited to one alert an hour, the second isn't.
+	summaries := make(map[string]int)
+	for _, alert := range rec.alerts {
+		summaries[alert.Summary]++
+	}
+	assert.Equal(t, map[string]int{"app: job foo failed": 1, "onJobFailed: foo": 3}, summaries)
+
+	rule := alerter.rules["onJobFailed"][0]
+	rule.last = time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), onJobFailed, "foo"))
+	alerter.Wait()
+	var suppressed int
+	for _, alert := range rec.alerts[4:] {
+		suppressed += alert.Suppressed
+	}
+	assert.Equal(t, 2, suppressed)
+}
+
+func TestNewAlerter_invalid(t *testing.T) {
+	_, err := NewAlerter(nil, []Rule{{Event: "foo", Channels: []string{"bar"}}})
+	assert.Error(t, err)
+	_, err = NewAlerter(nil, []Rule{{Event: "foo", Template: "{{"}})
+	assert.Error(t, err)
+}
+
+func TestNotifiers(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(request.Body).Decode(&body))
+		bodies = append(bodies, body)
+		if request.URL.Path == "/fail" {
+			writer.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	alert := Alert{Event: "onJobFailed", Summary: "job failed", Suppressed: 1}
+	assert.NoError(t, Slack{URL: server.URL}.Notify(context.Background(), alert))
+	assert.Equal(t, "job failed (1 similar alerts suppressed)", bodies[0]["text"])
+
+	assert.NoError(t, PagerDuty{URL: server.URL, RoutingKey: "key", Source: "app"}.Notify(context.Background(), alert))
+	assert.Equal(t, "key", bodies[1]["routing_key"])
+	assert.Equal(t, "trigger", bodies[1]["event_action"])
+	assert.Equal(t, "error", bodies[1]["payload"].(map[string]interface{})["severity"])
+
+	assert.Error(t, Slack{URL: server.URL + "/fail"}.Notify(context.Background(), alert))
+}
@@ -0,0 +1,113 @@
+package alerts
+
+import (
+	"fmt"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/go-kit/kit/log"
+)
+
+// Channel is the configuration of a notification channel.
+type Channel struct {
+	// Type is one of "slack", "pagerduty" or "email".
+	Type string `json:"type" yaml:"type"`
+	// URL is the slack webhook URL, or overrides the PagerDuty endpoint.
+	URL string `json:"url" yaml:"url"`
+	// RoutingKey is the PagerDuty integration key.
+	RoutingKey string `json:"routingKey" yaml:"routingKey"`
+	// Severity is the PagerDuty severity.
+	Severity string `json:"severity" yaml:"severity"`
+	// Addr is the SMTP server address.
+	Addr     string   `json:"addr" yaml:"addr"`
+	Username string   `json:"username" yaml:"username"`
+	Password string   `json:"password" yaml:"password"`
+	From     string   `json:"from" yaml:"from"`
+	To       []string `json:"to" yaml:"to"`
+}
+
+// Option is the configuration of Alerter.
+type Option struct {
+	Channels map[string]Channel `json:"channels" yaml:"channels"`
+	Rules    []Rule             `json:"rules" yaml:"rules"`
+}
+
+func (c Channel) notifier(source string) (Notifier, error) {
+	switch c.Type {
+	case "slack":
+		return Slack{URL: c.URL}, nil
+	case "pagerduty":
+		return PagerDuty{RoutingKey: c.RoutingKey, Severity: c.Severity, Source: source, URL: c.URL}, nil
+	case "email":
+		return Email{Addr: c.Addr, Username: c.Username, Password: c.Password, From: c.From, To: c.To}, nil
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", c.Type)
+	}
+}
+
+/*
+Providers returns a set of dependency providers for *Alerter. The alerter
+doesn't watch any event by itself, see Alerter.Watch.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		contract.AppName
+		contract.Env
+	Provide:
+		*Alerter
+*/
+func Providers() di.Deps {
+	return di.Deps{provideAlerter, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger  log.Logger
+	Conf    contract.ConfigAccessor
+	AppName contract.AppName
+	Env     contract.Env
+}
+
+func provideAlerter(in in) (*Alerter, func(), error) {
+	var option Option
+	if err := in.Conf.Unmarshal("alerts", &option); err != nil {
+		return nil, nil, fmt.Errorf("alerts configuration error: %w", err)
+	}
+	notifiers := make(map[string]Notifier, len(option.Channels))
+	for name, channel := range option.Channels {
+		notifier, err := channel.notifier(in.AppName.String())
+		if err != nil {
+			return nil, nil, fmt.Errorf("alerts channel %s: %w", name, err)
+		}
+		notifiers[name] = notifier
+	}
+	alerter, err := NewAlerter(notifiers, option.Rules, WithLogger(in.Logger), WithApp(in.AppName, in.Env))
+	if err != nil {
+		return nil, nil, fmt.Errorf("alerts configuration error: %w", err)
+	}
+	return alerter, alerter.Wait, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "alerts",
+			Data: map[string]interface{}{
+				"alerts": Option{
+					Channels: map[string]Channel{},
+					Rules:    []Rule{},
+				},
+			},
+			Comment: "The notification channels, one of slack, pagerduty or email, and the rules mapping events to them",
+		},
+	}}
+}
@@ -0,0 +1,48 @@
+/*
+Package alerts maps dispatched events, such as certificates expiring or the
+leadership changing, to notifications on Slack, PagerDuty or email.
+
+Each rule renders the event with a text/template into a summary, and sends it
+to the channels of the rule in background. The Interval of a rule limits how
+often it fires: the alerts in between are dropped, and counted in the next one.
+
+Integration
+
+package alerts exports the configuration in the following format:
+
+	alerts:
+	  channels:
+	    ops:
+	      type: slack
+	      url: https://hooks.slack.com/services/XXX
+	    oncall:
+	      type: pagerduty
+	      routingKey: XXX
+	      severity: critical
+	    mail:
+	      type: email
+	      addr: smtp.example.com:587
+	      username: alerts@example.com
+	      password: XXX
+	      from: alerts@example.com
+	      to:
+	        - ops@example.com
+	  rules:
+	    - event: onCertExpiring
+	      channels: [ops, mail]
+	      template: "{{.AppName}}.{{.Env}}: certificate expires in {{.Payload.Remaining}}"
+	      interval: 1h
+
+Add the alerts dependency to core, and watch the events. The rules refer to
+the events by their names, eg. certs.OnExpiring is "onCertExpiring":
+
+	var c *core.C = core.New()
+	c.Provide(alerts.Providers())
+	c.Invoke(func(alerter *alerts.Alerter, dispatcher contract.Dispatcher) {
+		alerter.Watch(dispatcher, certs.OnExpiring, leader.OnStatusChanged)
+	})
+
+Custom channels can be added by implementing Notifier, and calling NewAlerter
+directly.
+*/
+package alerts
@@ -0,0 +1,130 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/smtp"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+func summary(alert Alert) string {
+	if alert.Suppressed > 0 {
+		return fmt.Sprintf("%s (%d similar alerts suppressed)", alert.Summary, alert.Suppressed)
+	}
+	return alert.Summary
+}
+
+func postJSON(ctx context.Context, doer contract.HttpDoer, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := doer.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Slack sends alerts to a Slack incoming webhook.
+type Slack struct {
+	// URL is the webhook URL.
+	URL string
+	// Doer sends the requests. Defaults to http.DefaultClient.
+	Doer contract.HttpDoer
+}
+
+// Notify implements Notifier
+func (s Slack) Notify(ctx context.Context, alert Alert) error {
+	doer := s.Doer
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return postJSON(ctx, doer, s.URL, map[string]string{"text": summary(alert)})
+}
+
+// PagerDutyEndpoint is the PagerDuty Events API v2 endpoint.
+const PagerDutyEndpoint = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty triggers incidents through the PagerDuty Events API v2.
+type PagerDuty struct {
+	// RoutingKey is the integration key of the service.
+	RoutingKey string
+	// Severity is one of "critical", "error", "warning" or "info". Defaults to
+	// "error".
+	Severity string
+	// Source is the affected system, eg. the AppName.
+	Source string
+	// URL overrides PagerDutyEndpoint.
+	URL string
+	// Doer sends the requests. Defaults to http.DefaultClient.
+	Doer contract.HttpDoer
+}
+
+// Notify implements Notifier
+func (p PagerDuty) Notify(ctx context.Context, alert Alert) error {
+	doer, url, severity := p.Doer, p.URL, p.Severity
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	if url == "" {
+		url = PagerDutyEndpoint
+	}
+	if severity == "" {
+		severity = "error"
+	}
+	return postJSON(ctx, doer, url, map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"payload": map[string]interface{}{
+			"summary":  summary(alert),
+			"source":   p.Source,
+			"severity": severity,
+			"group":    alert.Event,
+		},
+	})
+}
+
+// Email sends alerts through SMTP.
+type Email struct {
+	// Addr is the SMTP server address, eg. "smtp.example.com:587".
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify implements Notifier. The context is not honored by net/smtp.
+func (e Email) Notify(ctx context.Context, alert Alert) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		host := e.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	msg := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: [alert] %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		e.From, strings.Join(e.To, ", "), alert.Event, summary(alert),
+	)
+	return smtp.SendMail(e.Addr, auth, e.From, e.To, []byte(msg))
+}
@@ -0,0 +1,225 @@
+// Package boot makes the serve command wait for the dependencies, such as the
+// database, kafka, redis or any TCP or HTTP endpoint, before it starts
+// serving. It replaces the init containers and sleeps that hold an application
+// back until its dependencies are up.
+//
+// The dependencies are listed in the "boot.waitFor" configuration:
+//
+//	boot:
+//	  timeout: 1m
+//	  waitFor:
+//	    - name: db
+//	      kind: gorm
+//	      entry: default
+//	    - name: search
+//	      http: http://127.0.0.1:9200/_cluster/health
+//	    - name: legacy
+//	      tcp: 127.0.0.1:4000
+//
+// The kinds "gorm", "redis" and "kafka" are contributed by the packages otgorm,
+// otredis and otkafka. Other packages can contribute more kinds by providing
+// Kind to the "bootKinds" group.
+package boot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/go-kit/kit/log"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultMaxInterval = 10 * time.Second
+	defaultTimeout     = time.Minute
+)
+
+// Config is the "boot" configuration.
+type Config struct {
+	// Timeout is how long to wait for all the gates. Defaults to 1m.
+	Timeout config.Duration `json:"timeout" yaml:"timeout"`
+	// Interval is the delay before the first retry. It doubles after every
+	// retry up to MaxInterval. Defaults to 1s and 10s.
+	Interval    config.Duration `json:"interval" yaml:"interval"`
+	MaxInterval config.Duration `json:"maxInterval" yaml:"maxInterval"`
+	// WaitFor lists the gates.
+	WaitFor []Gate `json:"waitFor" yaml:"waitFor"`
+}
+
+// Gate is a dependency to wait for. Exactly one of TCP, HTTP and Kind is set.
+type Gate struct {
+	// Name identifies the gate in the logs.
+	Name string `json:"name" yaml:"name"`
+	// TCP is an address that must accept connections.
+	TCP string `json:"tcp,omitempty" yaml:"tcp,omitempty"`
+	// HTTP is a URL that must respond to GET with a 2xx status.
+	HTTP string `json:"http,omitempty" yaml:"http,omitempty"`
+	// Kind is the kind of the dependency, eg. gorm, redis or kafka, and Entry
+	// is the name of its configuration entry. Entry defaults to "default".
+	Kind  string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Entry string `json:"entry,omitempty" yaml:"entry,omitempty"`
+}
+
+// Kind is a kind of dependency that gates can refer to. Probe returns nil if
+// the dependency of the configuration entry is ready.
+type Kind struct {
+	Name  string
+	Probe func(ctx context.Context, entry string) error
+}
+
+// KindOut provides Kind to the "bootKinds" group.
+type KindOut struct {
+	di.Out
+
+	Kind Kind `group:"bootKinds"`
+}
+
+// Waiter waits for the gates.
+type Waiter struct {
+	kinds  map[string]Kind
+	logger logging.LevelLogger
+}
+
+// NewWaiter creates a *Waiter that knows the kinds.
+func NewWaiter(kinds []Kind, logger log.Logger) *Waiter {
+	w := &Waiter{kinds: make(map[string]Kind), logger: logging.WithLevel(logger)}
+	for _, kind := range kinds {
+		w.kinds[kind.Name] = kind
+	}
+	return w
+}
+
+// Wait probes every gate concurrently, retrying with exponential backoff,
+// until all of them pass. It returns an error naming the gates not passed in
+// time.
+func (w *Waiter) Wait(ctx context.Context, conf Config) error {
+	if len(conf.WaitFor) == 0 {
+		return nil
+	}
+	if conf.Timeout.Duration == 0 {
+		conf.Timeout.Duration = defaultTimeout
+	}
+	if conf.Interval.Duration == 0 {
+		conf.Interval.Duration = defaultInterval
+	}
+	if conf.MaxInterval.Duration == 0 {
+		conf.MaxInterval.Duration = defaultMaxInterval
+	}
+	probes := make([]func(ctx context.Context) error, len(conf.WaitFor))
+	for i, gate := range conf.WaitFor {
+		probe, err := w.probe(gate)
+		if err != nil {
+			return err
+		}
+		probes[i] = probe
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, conf.Timeout.Duration)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		pending []string
+	)
+	for i, gate := range conf.WaitFor {
+		wg.Add(1)
+		go func(gate Gate, probe func(ctx context.Context) error) {
+			defer wg.Done()
+			if err := w.retry(ctx, gate, probe, conf.Interval.Duration, conf.MaxInterval.Duration); err != nil {
+				mu.Lock()
+				pending = append(pending, fmt.Sprintf("%s (%s)", gate.Name, err))
+				mu.Unlock()
+			}
+		}(gate, probes[i])
+	}
+	wg.Wait()
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		return fmt.Errorf("dependencies not ready after %s: %s", conf.Timeout.Duration, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+func (w *Waiter) retry(ctx context.Context, gate Gate, probe func(ctx context.Context) error, interval, maxInterval time.Duration) error {
+	for {
+		err := probe(ctx)
+		if err == nil {
+			w.logger.Infof("dependency %s is ready", gate.Name)
+			return nil
+		}
+		w.logger.Warnf("waiting for dependency %s: %s", gate.Name, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+func (w *Waiter) probe(gate Gate) (func(ctx context.Context) error, error) {
+	switch {
+	case gate.TCP != "":
+		return TCP(gate.TCP), nil
+	case gate.HTTP != "":
+		return HTTP(gate.HTTP), nil
+	case gate.Kind != "":
+		kind, ok := w.kinds[gate.Kind]
+		if !ok {
+			return nil, fmt.Errorf("boot gate %s has unknown kind %s", gate.Name, gate.Kind)
+		}
+		entry := gate.Entry
+		if entry == "" {
+			entry = "default"
+		}
+		return func(ctx context.Context) error {
+			return kind.Probe(ctx, entry)
+		}, nil
+	}
+	return nil, fmt.Errorf("boot gate %s needs one of tcp, http or kind", gate.Name)
+}
+
+// TCP returns a probe that passes if the address accepts connections.
+func TCP(addr string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTP returns a probe that passes if the URL responds to GET with a 2xx
+// status.
+func HTTP(url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return errors.New(resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+package boot
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaiter_Wait(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	var calls int
+	waiter := NewWaiter([]Kind{{
+		Name: "flaky",
+		Probe: func(ctx context.Context, entry string) error {
+			assert.Equal(t, "default", entry)
+			if calls++; calls < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		},
+	}}, log.NewNopLogger())
+
+	err = waiter.Wait(context.Background(), Config{
+		Interval: config.Duration{Duration: time.Millisecond},
+		WaitFor: []Gate{
+			{Name: "tcp", TCP: ln.Addr().String()},
+			{Name: "http", HTTP: server.URL},
+			{Name: "flaky", Kind: "flaky"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestWaiter_Wait_timeout(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	waiter := NewWaiter(nil, log.NewNopLogger())
+	err := waiter.Wait(context.Background(), Config{
+		Timeout:  config.Duration{Duration: 50 * time.Millisecond},
+		Interval: config.Duration{Duration: 10 * time.Millisecond},
+		WaitFor:  []Gate{{Name: "search", HTTP: server.URL}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "search (503 Service Unavailable)")
+}
+
+func TestWaiter_Wait_invalid(t *testing.T) {
+	t.Parallel()
+	waiter := NewWaiter(nil, log.NewNopLogger())
+	assert.NoError(t, waiter.Wait(context.Background(), Config{}))
+	assert.Error(t, waiter.Wait(context.Background(), Config{WaitFor: []Gate{{Name: "db", Kind: "gorm"}}}))
+	assert.Error(t, waiter.Wait(context.Background(), Config{WaitFor: []Gate{{Name: "db"}}}))
+}
@@ -0,0 +1,22 @@
+/*
+Package bufpool provides tiered pools of *bytes.Buffer for the hot paths, where
+allocating a buffer per request dominates the garbage. It is used by the
+response encoder of srvhttp, the compress middleware, logging.AsyncWriter and
+the encoders of the log sinks, and applications may use it too:
+
+	buf := bufpool.Get(4096)
+	defer bufpool.Put(buf)
+	json.NewEncoder(buf).Encode(v)
+
+The buffers are grouped into tiers by capacity, so that the pool neither hands
+out a huge buffer for a small payload nor keeps growing a small one. Buffers
+grown far beyond the largest tier are dropped instead of being pooled.
+
+Each tier counts its hits and misses. A low hit rate suggests the tiers don't
+fit the payloads. srvhttp.MetricsModule reports the hit rate of the Default
+pool as "buffer_pool_hit_rate" on every scrape. Other pools can be reported to a
+gauge:
+
+	pool.Collect(hitRate)
+*/
+package bufpool
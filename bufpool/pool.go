@@ -0,0 +1,160 @@
+package bufpool
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// DefaultSizes are the tier sizes of the Default pool.
+var DefaultSizes = []int{512, 4 << 10, 32 << 10, 256 << 10}
+
+// Default is the pool used by Get and Put.
+var Default = New(DefaultSizes...)
+
+// Get takes a buffer with at least size bytes of capacity from the Default pool.
+func Get(size int) *bytes.Buffer {
+	return Default.Get(size)
+}
+
+// Put returns the buffer to the Default pool.
+func Put(buf *bytes.Buffer) {
+	Default.Put(buf)
+}
+
+// Pool is a tiered pool of *bytes.Buffer. Each tier holds the buffers whose
+// capacity falls between its size and the size of the next tier, so that a
+// request for a small buffer doesn't pin a large one, and vice versa. Pool is
+// safe for concurrent use.
+type Pool struct {
+	// discards must be the first word for 64-bit atomic alignment.
+	discards uint64
+	tiers    []*tier
+}
+
+type tier struct {
+	hits   uint64
+	misses uint64
+	size   int
+	pool   sync.Pool
+}
+
+// New creates a pool with the given tier sizes in bytes. If no size is given,
+// DefaultSizes are used.
+func New(sizes ...int) *Pool {
+	if len(sizes) == 0 {
+		sizes = DefaultSizes
+	}
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+
+	var p Pool
+	for _, size := range sorted {
+		if size <= 0 || len(p.tiers) > 0 && p.tiers[len(p.tiers)-1].size == size {
+			continue
+		}
+		p.tiers = append(p.tiers, &tier{size: size})
+	}
+	return &p
+}
+
+// Get takes an empty buffer with at least size bytes of capacity. The size is
+// only a hint and the buffer grows as usual. If size exceeds the largest tier,
+// a new buffer is allocated and counted as a miss of the largest tier.
+func (p *Pool) Get(size int) *bytes.Buffer {
+	if len(p.tiers) == 0 {
+		return bytes.NewBuffer(make([]byte, 0, size))
+	}
+	i := sort.Search(len(p.tiers), func(i int) bool { return p.tiers[i].size >= size })
+	if i == len(p.tiers) {
+		atomic.AddUint64(&p.tiers[i-1].misses, 1)
+		return bytes.NewBuffer(make([]byte, 0, size))
+	}
+	t := p.tiers[i]
+	if buf, ok := t.pool.Get().(*bytes.Buffer); ok {
+		atomic.AddUint64(&t.hits, 1)
+		return buf
+	}
+	atomic.AddUint64(&t.misses, 1)
+	return bytes.NewBuffer(make([]byte, 0, t.size))
+}
+
+// Put resets the buffer and returns it to the tier matching its capacity. The
+// buffers smaller than the smallest tier, or grown to twice the largest tier,
+// are discarded, so that an occasional huge payload is not retained forever.
+// The buffer must not be used after Put.
+func (p *Pool) Put(buf *bytes.Buffer) {
+	if buf == nil || len(p.tiers) == 0 {
+		return
+	}
+	c := buf.Cap()
+	i := sort.Search(len(p.tiers), func(i int) bool { return p.tiers[i].size > c }) - 1
+	if i < 0 || c >= 2*p.tiers[len(p.tiers)-1].size {
+		atomic.AddUint64(&p.discards, 1)
+		return
+	}
+	buf.Reset()
+	p.tiers[i].pool.Put(buf)
+}
+
+// Stats returns the counters of the pool.
+func (p *Pool) Stats() Stats {
+	stats := Stats{Discards: atomic.LoadUint64(&p.discards)}
+	for _, t := range p.tiers {
+		stats.Tiers = append(stats.Tiers, TierStats{
+			Size:   t.size,
+			Hits:   atomic.LoadUint64(&t.hits),
+			Misses: atomic.LoadUint64(&t.misses),
+		})
+	}
+	return stats
+}
+
+// Collect reports the hit rate of every tier to the gauge, labeled by "size".
+// It is meant to be called periodically, like the connection stats collectors
+// of the other packages.
+func (p *Pool) Collect(hitRate metrics.Gauge) {
+	for _, t := range p.Stats().Tiers {
+		hitRate.With("size", strconv.Itoa(t.Size)).Set(t.HitRate())
+	}
+}
+
+// Stats is a snapshot of the pool counters.
+type Stats struct {
+	Tiers []TierStats
+	// Discards counts the buffers not returned to the pool due to their size.
+	Discards uint64
+}
+
+// TierStats is a snapshot of the counters of a tier.
+type TierStats struct {
+	Size int
+	// Hits counts the Gets served by a pooled buffer.
+	Hits uint64
+	// Misses counts the Gets that allocated a new buffer.
+	Misses uint64
+}
+
+// HitRate returns the ratio of hits to gets of the tier, or 0 if nothing has
+// been taken.
+func (t TierStats) HitRate() float64 {
+	if t.Hits+t.Misses == 0 {
+		return 0
+	}
+	return float64(t.Hits) / float64(t.Hits+t.Misses)
+}
+
+// HitRate returns the ratio of hits to gets across all tiers, or 0 if nothing
+// has been taken.
+func (s Stats) HitRate() float64 {
+	var total TierStats
+	for _, t := range s.Tiers {
+		total.Hits += t.Hits
+		total.Misses += t.Misses
+	}
+	return total.HitRate()
+}
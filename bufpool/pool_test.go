@@ -0,0 +1,62 @@
+package bufpool
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+	p := New(1024, 64, 64)
+
+	buf := p.Get(10)
+	assert.Equal(t, 0, buf.Len())
+	assert.GreaterOrEqual(t, buf.Cap(), 64)
+	buf.WriteString("foo")
+	p.Put(buf)
+
+	// sync.Pool may drop the buffer at any time, so only the contents are
+	// asserted on a hit.
+	buf = p.Get(64)
+	assert.Equal(t, 0, buf.Len())
+	assert.Less(t, buf.Cap(), 1024)
+
+	big := p.Get(4096)
+	assert.GreaterOrEqual(t, big.Cap(), 4096)
+	p.Put(big)
+	p.Put(bytes.NewBuffer(make([]byte, 0, 10)))
+	p.Put(nil)
+
+	stats := p.Stats()
+	assert.Len(t, stats.Tiers, 2)
+	assert.Equal(t, 64, stats.Tiers[0].Size)
+	assert.Equal(t, uint64(2), stats.Tiers[0].Hits+stats.Tiers[0].Misses)
+	assert.Equal(t, uint64(1), stats.Tiers[1].Misses)
+	assert.Equal(t, uint64(2), stats.Discards)
+	assert.True(t, stats.HitRate() >= 0 && stats.HitRate() <= 1)
+
+	hitRate := generic.NewGauge("hit_rate")
+	p.Collect(hitRate)
+}
+
+func TestStats_HitRate(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 0.0, Stats{}.HitRate())
+	stats := Stats{Tiers: []TierStats{{Hits: 3, Misses: 1}, {Hits: 1, Misses: 3}}}
+	assert.Equal(t, 0.5, stats.HitRate())
+	assert.Equal(t, 0.75, stats.Tiers[0].HitRate())
+}
+
+func BenchmarkPool(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := Get(1024)
+			buf.WriteString("hello world")
+			Put(buf)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/gorilla/mux"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+)
+
+// WithBuildInfo is a CoreOption that sets the build metadata of the binary.
+// The values are usually injected by the linker flags:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%FT%TZ)"
+//
+// The build info is then provided as contract.BuildInfo, added to every log
+// line as "version" and "commit", and exported as the "build_info" gauge.
+func WithBuildInfo(version, commit, date string) CoreOption {
+	return func(values *coreValues) {
+		values.buildInfo = contract.BuildInfo{Version: version, Commit: commit, Date: date}
+	}
+}
+
+var buildInfoGauge struct {
+	once sync.Once
+	*stdprometheus.GaugeVec
+}
+
+// setBuildInfoGauge exports the build info in the prometheus convention, a
+// gauge whose value is always 1.
+func setBuildInfoGauge(info contract.BuildInfo) {
+	buildInfoGauge.once.Do(func() {
+		buildInfoGauge.GaugeVec = stdprometheus.NewGaugeVec(stdprometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "The build info of the binary, always 1.",
+		}, []string{"version", "commit", "date"})
+		stdprometheus.MustRegister(buildInfoGauge.GaugeVec)
+	})
+	buildInfoGauge.Reset()
+	buildInfoGauge.WithLabelValues(info.Version, info.Commit, info.Date).Set(1)
+}
+
+// BuildInfoModule exposes the build info through the "version" command and
+// the "/version" HTTP endpoint.
+type BuildInfoModule struct {
+	info contract.BuildInfo
+}
+
+// NewBuildInfoModule creates a BuildInfoModule.
+func NewBuildInfoModule(info contract.BuildInfo) BuildInfoModule {
+	return BuildInfoModule{info: info}
+}
+
+// ProvideCommand implements container.CommandProvider
+func (b BuildInfoModule) ProvideCommand(command *cobra.Command) {
+	command.AddCommand(&cobra.Command{
+		Use:   "version",
+		Short: "Print the build info",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(b.info)
+		},
+	})
+}
+
+// ProvideHTTP implements container.HTTPProvider
+func (b BuildInfoModule) ProvideHTTP(router *mux.Router) {
+	router.Path("/version").Methods(http.MethodGet).HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		srvhttp.NewResponseEncoder(writer).Encode(b.info, nil)
+	})
+}
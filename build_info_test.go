@@ -0,0 +1,48 @@
+package core
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/gorilla/mux"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithBuildInfo(t *testing.T) {
+	c := Default(WithBuildInfo("v1.2.3", "abcdef", "2021-01-01T00:00:00Z"))
+	c.AddModuleFunc(NewBuildInfoModule)
+	c.Invoke(func(info contract.BuildInfo) {
+		assert.Equal(t, "v1.2.3", info.Version)
+	})
+
+	var buf bytes.Buffer
+	rootCmd := &cobra.Command{}
+	rootCmd.SetOut(&buf)
+	c.ApplyRootCommand(rootCmd)
+	rootCmd.SetArgs([]string{"version"})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), `"commit": "abcdef"`)
+
+	router := mux.NewRouter()
+	c.ApplyRouter(router)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/version", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"version":"v1.2.3"`)
+
+	families, err := stdprometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+	var found bool
+	for _, family := range families {
+		if family.GetName() == "build_info" {
+			found = strings.Contains(family.String(), "v1.2.3")
+		}
+	}
+	assert.True(t, found)
+}
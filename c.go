@@ -10,15 +10,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"regexp"
 
+	"github.com/DoNewsCode/core/codec/dotenv"
 	"github.com/DoNewsCode/core/codec/yaml"
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/config/watcher"
 	"github.com/DoNewsCode/core/container"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
 	"github.com/DoNewsCode/core/logging"
 	"github.com/go-kit/kit/log"
 	"github.com/knadh/koanf/providers/confmap"
@@ -29,13 +32,18 @@ import (
 // dependencies. C is mean to be used in the boostrap phase of the application.
 // Do not pass C into services and use it as a service locator.
 type C struct {
-	AppName contract.AppName
-	Env     contract.Env
+	AppName   contract.AppName
+	Env       contract.Env
+	BuildInfo contract.BuildInfo
 	contract.ConfigAccessor
 	logging.LevelLogger
 	contract.Container
 	contract.Dispatcher
-	di DiContainer
+	di          DiContainer
+	logTee      *logging.Tee
+	logLevels   *logging.Levels
+	hotPlug     *HotPlug
+	configFlags *config.Flags
 }
 
 // ConfParser models a parser for configuration. For example, yaml.Parser.
@@ -70,8 +78,12 @@ type LoggerProvider func(conf contract.ConfigAccessor, appName contract.AppName,
 
 type coreValues struct {
 	// Base Values
-	configStack   []config.ProviderSet
-	configWatcher contract.ConfigWatcher
+	configStack    []config.ProviderSet
+	configDefaults []config.ProviderSet
+	configWatcher  contract.ConfigWatcher
+	configFlags    *config.Flags
+	transformers   []config.Transformer
+	buildInfo      contract.BuildInfo
 	// ConfProvider functions
 	configProvider          ConfigProvider
 	eventDispatcherProvider EventDispatcherProvider
@@ -87,10 +99,40 @@ type CoreOption func(*coreValues)
 // WithYamlFile is a two-in-one coreOption. It uses the configuration file as the
 // source of configuration, and watches the change of that file for hot reloading.
 func WithYamlFile(path string) (CoreOption, CoreOption) {
-	return WithConfigStack(file.Provider(path), config.CodecParser{Codec: yaml.Codec{}}),
+	return WithConfigStack(config.File{Path: path}, config.CodecParser{Codec: yaml.Codec{}}),
 		WithConfigWatcher(watcher.File{Path: path})
 }
 
+// WithDotEnv is a CoreOption that uses the .env file as a configuration layer.
+// The keys are normalized into configuration paths, eg. FOO_BAR into foo.bar.
+// A missing file is treated as empty, so the same option works in production
+// where no .env file is deployed.
+func WithDotEnv(path string) CoreOption {
+	return WithConfigStack(optionalFile{file.Provider(path)}, config.CodecParser{Codec: dotenv.Codec{}})
+}
+
+// optionalFile is a file provider that reads a missing file as empty.
+type optionalFile struct {
+	*file.File
+}
+
+func (o optionalFile) ReadBytes() ([]byte, error) {
+	b, err := o.File.ReadBytes()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// WithYamlDir is a two-in-one coreOption. It uses every yaml file matching the
+// pattern, eg. "conf.d/*.yaml", as the source of configuration. The files are
+// deep merged in lexical order, so the later files override the earlier ones.
+// The directory is watched for hot reloading.
+func WithYamlDir(pattern string) (CoreOption, CoreOption) {
+	return WithConfigStack(config.Glob{Pattern: pattern, Codec: yaml.Codec{}}, nil),
+		WithConfigWatcher(watcher.Glob{Pattern: pattern})
+}
+
 // WithInline is a CoreOption that creates a inline config in the configuration stack.
 func WithInline(key string, entry interface{}) CoreOption {
 	return WithConfigStack(confmap.Provider(map[string]interface{}{
@@ -105,10 +147,48 @@ func WithConfigStack(provider ConfProvider, parser ConfParser) CoreOption {
 	}
 }
 
+// WithDefaults is a CoreOption that sets the default values of configuration.
+// The defaults are under every layer added by WithConfigStack, but override the
+// built-in defaults of package core. The keys can be key paths, eg.
+// "http.addr".
+func WithDefaults(defaults map[string]interface{}) CoreOption {
+	return func(values *coreValues) {
+		values.configDefaults = append(values.configDefaults, config.ProviderSet{Provider: confmap.Provider(defaults, ".")})
+	}
+}
+
+// WithConfigFlags is a CoreOption that binds every leaf key of the
+// configuration to a flag of the serve command, eg. "--http.addr", on top of
+// the configuration stack. The flags are registered with the keys known when
+// the core is created, and the configuration is reloaded before serving if any
+// of them is set. See config.Flags.
+func WithConfigFlags() CoreOption {
+	return func(values *coreValues) {
+		values.configFlags = config.NewFlags()
+	}
+}
+
+// WithConfigTransformers is a CoreOption that adds transformers to the
+// configuration, which rewrite the merged configuration before it is
+// validated. See config.Transformer.
+func WithConfigTransformers(transformers ...config.Transformer) CoreOption {
+	return func(values *coreValues) {
+		values.transformers = append(values.transformers, transformers...)
+	}
+}
+
 // WithConfigWatcher is a CoreOption that adds a config watcher to the core (for hot reloading configs).
-func WithConfigWatcher(watcher contract.ConfigWatcher) CoreOption {
+// If more than one watcher is added, the configuration is reloaded whenever any of them notifies.
+func WithConfigWatcher(w contract.ConfigWatcher) CoreOption {
 	return func(values *coreValues) {
-		values.configWatcher = watcher
+		switch existing := values.configWatcher.(type) {
+		case nil:
+			values.configWatcher = w
+		case watcher.Multi:
+			values.configWatcher = append(existing, w)
+		default:
+			values.configWatcher = watcher.Multi{existing, w}
+		}
 	}
 }
 
@@ -169,7 +249,19 @@ func New(opts ...CoreOption) *C {
 	for _, f := range opts {
 		f(&values)
 	}
-	conf := values.configProvider(values.configStack, values.configWatcher)
+	configStack := values.configStack
+	if values.configFlags != nil {
+		configStack = append([]config.ProviderSet{{Provider: values.configFlags}}, configStack...)
+	}
+	conf := values.configProvider(append(configStack, values.configDefaults...), values.configWatcher)
+	if adapter, ok := conf.(*config.KoanfAdapter); ok && len(values.transformers) > 0 {
+		if err := adapter.AddTransformers(values.transformers...); err != nil {
+			panic(err)
+		}
+	}
+	if adapter, ok := conf.(*config.KoanfAdapter); ok && values.configFlags != nil {
+		values.configFlags.Bind(adapter.K.All())
+	}
 	env := values.envProvider(conf)
 	appName := values.appNameProvider(conf)
 	logger := values.loggerProvider(conf, appName, env)
@@ -179,11 +271,29 @@ func New(opts ...CoreOption) *C {
 	var c = C{
 		AppName:        appName,
 		Env:            env,
+		BuildInfo:      values.buildInfo,
 		ConfigAccessor: conf,
 		LevelLogger:    logging.WithLevel(logger),
 		Container:      &container.Container{},
 		Dispatcher:     dispatcher,
 		di:             diContainer,
+		hotPlug:        NewHotPlug(dispatcher),
+		configFlags:    values.configFlags,
+	}
+	c.subscribeConfigListeners()
+	if closer, ok := logger.(container.CloserProvider); ok {
+		c.AddModule(closer)
+	}
+	if tee, ok := logger.(*logging.Tee); ok {
+		c.logTee = tee
+	}
+	c.logLevels = logging.LevelsOf(logger)
+	if !values.buildInfo.IsZero() {
+		c.LevelLogger = logging.WithLevel(log.With(logger, values.buildInfo.KeyVals()...))
+		setBuildInfoGauge(values.buildInfo)
+	}
+	if err := applyRuntime(conf, c.LevelLogger); err != nil {
+		c.LevelLogger.Err(err.Error())
 	}
 	return &c
 }
@@ -212,6 +322,8 @@ func Default(opts ...CoreOption) *C {
 //
 // A Module is a group of functionality. It must provide some runnable stuff:
 // http handlers, grpc handlers, cron jobs, one-time command, etc.
+//
+// An events.OnModuleAdded event is dispatched for each of the modules.
 func (c *C) AddModule(modules ...interface{}) {
 	for i := range modules {
 		switch modules[i].(type) {
@@ -219,6 +331,7 @@ func (c *C) AddModule(modules ...interface{}) {
 			panic(modules[i].(error))
 		default:
 			c.Container.AddModule(modules[i])
+			_ = c.Dispatcher.Dispatch(context.Background(), events.OnModuleAdded, events.OnModuleAddedPayload{Module: modules[i]})
 		}
 	}
 }
@@ -239,13 +352,36 @@ func (c *C) AddModule(modules ...interface{}) {
 // from google/wire (https://github.com/google/wire). All "func()" returned by
 // constructor are treated as clean up functions. It also respect the core's unique
 // "di.Module" annotation.
+//
+// A di.Provider, created by di.Describe, is provided as its constructor. Its
+// metadata is recorded in the DiContainer, if the container has a
+// Describe(di.Provider) method like di.Graph.
+//
+// Several values of the same type, eg. two *gorm.DB, can coexist if they are
+// provided by constructors annotated with di.Named, and injected by the name
+// tag of di.In structs:
+//
+//  c.Provide(di.Deps{di.Named("report", provideReportDB)})
 func (c *C) Provide(deps di.Deps) {
 	for _, dep := range deps {
+		if p, ok := dep.(di.Provider); ok {
+			c.provide(p.Constructor)
+			if describer, ok := c.di.(interface{ Describe(di.Provider) }); ok {
+				describer.Describe(p)
+			}
+			continue
+		}
 		c.provide(dep)
 	}
 }
 
 func (c *C) provide(constructor interface{}) {
+	c.provideWith(constructor, c.di.Provide)
+}
+
+// provideWith intercepts the cleanup functions and the modules returned by the
+// constructor, and registers the constructor with register.
+func (c *C) provideWith(constructor interface{}, register func(constructor interface{}) error) {
 
 	var shouldMakeFunc bool
 
@@ -281,7 +417,7 @@ func (c *C) provide(constructor interface{}) {
 
 	// no cleanup or module, we can use normal dig.
 	if !shouldMakeFunc {
-		err := c.di.Provide(constructor)
+		err := register(constructor)
 		if err != nil {
 			panic(err)
 		}
@@ -306,12 +442,34 @@ func (c *C) provide(constructor interface{}) {
 		}
 		return filteredOuts
 	})
-	err := c.di.Provide(fn.Interface())
+	err := register(fn.Interface())
 	if err != nil {
 		panic(err)
 	}
 }
 
+// ProvideOverride is like Provide, but the constructors replace the ones
+// provided before for the same types, eg. to swap the real *gorm.DB or
+// kafka writer for fakes in integration tests:
+//
+//  c.Provide(otgorm.Providers())
+//  c.ProvideOverride(di.Deps{func() *gorm.DB { return fakeDB }})
+//
+// It must be called before Invoke, and requires a DiContainer with an
+// Override(constructor) method like di.Graph.
+func (c *C) ProvideOverride(deps di.Deps) {
+	overrider, ok := c.di.(interface{ Override(constructor interface{}) error })
+	if !ok {
+		panic(fmt.Sprintf("the di container %T does not support overrides", c.di))
+	}
+	for _, dep := range deps {
+		if p, ok := dep.(di.Provider); ok {
+			dep = p.Constructor
+		}
+		c.provideWith(dep, overrider.Override)
+	}
+}
+
 // ProvideEssentials adds the default core dependencies to the core.
 func (c *C) ProvideEssentials() {
 	type coreDependencies struct {
@@ -319,12 +477,17 @@ func (c *C) ProvideEssentials() {
 
 		Env            contract.Env
 		AppName        contract.AppName
+		BuildInfo      contract.BuildInfo
 		Container      contract.Container
 		ConfigAccessor contract.ConfigAccessor
 		ConfigRouter   contract.ConfigRouter
 		ConfigWatcher  contract.ConfigWatcher
 		Logger         log.Logger
 		Dispatcher     contract.Dispatcher
+		LogTee         *logging.Tee
+		LogLevels      *logging.Levels
+		HotPlug        *HotPlug
+		ConfigFlags    *config.Flags
 		DefaultConfigs []config.ExportedConfig `group:"config,flatten"`
 	}
 
@@ -332,10 +495,15 @@ func (c *C) ProvideEssentials() {
 		coreDependencies := coreDependencies{
 			Env:            c.Env,
 			AppName:        c.AppName,
+			BuildInfo:      c.BuildInfo,
 			Container:      c.Container,
 			ConfigAccessor: c.ConfigAccessor,
 			Logger:         c.LevelLogger,
 			Dispatcher:     c.Dispatcher,
+			LogTee:         c.logTee,
+			LogLevels:      c.logLevels,
+			HotPlug:        c.hotPlug,
+			ConfigFlags:    c.configFlags,
 			DefaultConfigs: provideDefaultConfig(),
 		}
 		if cc, ok := c.ConfigAccessor.(contract.ConfigRouter); ok {
@@ -348,6 +516,12 @@ func (c *C) ProvideEssentials() {
 	})
 }
 
+// HotPlug returns the *HotPlug of the core, to register and deregister modules
+// while serving. It is experimental.
+func (c *C) HotPlug() *HotPlug {
+	return c.hotPlug
+}
+
 // Serve runs the serve command bundled in the core.
 // For larger projects, consider use full-featured ServeModule instead of calling serve directly.
 func (c *C) Serve(ctx context.Context) error {
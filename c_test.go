@@ -2,8 +2,15 @@ package core
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -17,6 +24,7 @@ import (
 	"github.com/DoNewsCode/core/srvgrpc"
 	"github.com/DoNewsCode/core/srvhttp"
 
+	"github.com/gorilla/mux"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
@@ -108,6 +116,37 @@ func TestC_ServeDisable(t *testing.T) {
 	assert.Equal(t, int32(0), atomic.LoadInt32(&called))
 }
 
+func TestC_ServeBoot(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var called int32
+	c := New(
+		WithInline("http.disable", "true"),
+		WithInline("grpc.disable", "true"),
+		WithInline("cron.disable", "true"),
+		WithInline("boot", map[string]interface{}{
+			"timeout":  "50ms",
+			"interval": "10ms",
+			"waitFor":  []interface{}{map[string]interface{}{"name": "closed", "tcp": addr}},
+		}),
+	)
+	c.ProvideEssentials()
+	c.AddModule(srvhttp.HealthCheckModule{})
+	c.Invoke(func(dispatcher contract.Dispatcher) {
+		dispatcher.Subscribe(events.Listen(OnHTTPServerStart, func(ctx context.Context, start interface{}) error {
+			atomic.AddInt32(&called, 1)
+			return nil
+		}))
+	})
+	e := c.Serve(context.Background())
+	assert.Error(t, e)
+	assert.Contains(t, e.Error(), "closed")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&called))
+}
+
 func TestC_Default(t *testing.T) {
 	c := New()
 	c.ProvideEssentials()
@@ -155,6 +194,17 @@ func TestC_Provide(t *testing.T) {
 	})
 }
 
+func TestC_AddModule_event(t *testing.T) {
+	c := New()
+	var added []interface{}
+	c.Subscribe(events.Listen(events.OnModuleAdded, func(ctx context.Context, event interface{}) error {
+		added = append(added, event.(events.OnModuleAddedPayload).Module)
+		return nil
+	}))
+	c.AddModule(m1{})
+	assert.Equal(t, []interface{}{m1{}}, added)
+}
+
 type a struct{}
 type b struct{}
 
@@ -179,3 +229,178 @@ func TestNew_missingDependencyErrorMessage(t *testing.T) {
 		return nil
 	})
 }
+
+func TestC_Runtime(t *testing.T) {
+	defer debug.SetGCPercent(debug.SetGCPercent(100))
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	New(
+		WithInline("runtime.maxProcs", 3),
+		WithInline("runtime.gcPercent", 200),
+		WithInline("runtime.ballast", "1MiB"),
+	)
+	assert.Equal(t, 3, runtime.GOMAXPROCS(0))
+	assert.Equal(t, 200, debug.SetGCPercent(100))
+	assert.Len(t, ballast, 1<<20)
+}
+
+type mountShared interface{ Name() string }
+
+type mountSharedImpl struct{}
+
+func (m mountSharedImpl) Name() string { return "shared" }
+
+type mountTestModule struct {
+	conf   contract.ConfigAccessor
+	shared mountShared
+}
+
+func (m mountTestModule) ProvideHTTP(router *mux.Router) {
+	router.HandleFunc("/ping", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(m.conf.String("greeting") + " " + m.shared.Name()))
+	})
+}
+
+func TestC_Mount(t *testing.T) {
+	c := New(WithInline("apps.orders.greeting", "hello"))
+	c.ProvideEssentials()
+	c.Provide(di.Deps{func() mountShared { return mountSharedImpl{} }})
+
+	orders := c.Mount("orders", (*mountShared)(nil))
+	orders.AddModuleFunc(func(conf contract.ConfigAccessor, shared mountShared) mountTestModule {
+		return mountTestModule{conf: conf, shared: shared}
+	})
+	assert.Equal(t, contract.AppName(config.AppName("orders")), orders.AppName)
+
+	router := mux.NewRouter()
+	c.ApplyRouter(router)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/orders/ping", nil))
+	assert.Equal(t, "hello shared", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/ping", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestC_WithDotEnv(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dotenv")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".env")
+	ioutil.WriteFile(path, []byte("APP_NAME=orders\nHTTP_ADDR=:8080\n"), 0644)
+
+	c := New(WithDotEnv(path), WithInline("http.addr", ":9090"))
+	assert.Equal(t, "orders", c.String("app.name"))
+	assert.Equal(t, ":8080", c.String("http.addr"))
+
+	c = New(WithDotEnv(filepath.Join(dir, "missing.env")), WithInline("http.addr", ":9090"))
+	assert.Equal(t, ":9090", c.String("http.addr"))
+}
+
+func TestC_Provide_describe(t *testing.T) {
+	c := New()
+	c.Provide(di.Deps{di.Describe(func() mountShared { return mountSharedImpl{} }, "core", "the shared dependency")})
+	c.Invoke(func(shared mountShared) {
+		assert.Equal(t, "shared", shared.Name())
+	})
+	assert.Contains(t, c.di.(*di.Graph).String(), "core -> core.mountShared // the shared dependency")
+}
+
+func TestWithDefaults(t *testing.T) {
+	c := New(
+		WithInline("foo.bar", "inline"),
+		WithDefaults(map[string]interface{}{"foo.bar": "default", "foo.baz": "default", "log.level": "error"}),
+	)
+	assert.Equal(t, "inline", c.String("foo.bar"))
+	assert.Equal(t, "default", c.String("foo.baz"))
+	assert.Equal(t, "error", c.String("log.level"))
+}
+
+func TestWithYamlDir(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "conf.d")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "00-base.yaml"), []byte("name: app\nfoo: bar"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "10-override.yaml"), []byte("foo: baz"), 0644)
+
+	c := New(WithYamlDir(filepath.Join(dir, "*.yaml")))
+	assert.Equal(t, "app", c.String("name"))
+	assert.Equal(t, "baz", c.String("foo"))
+}
+
+type configListener struct {
+	conf contract.ConfigAccessor
+	err  error
+}
+
+func (l *configListener) OnConfigReload(conf contract.ConfigAccessor) error {
+	l.conf = conf
+	return l.err
+}
+
+func TestC_ConfigListener(t *testing.T) {
+	c := New(WithInline("foo", "bar"))
+	failing := &configListener{err: errors.New("failed")}
+	listener := &configListener{}
+	c.AddModule(failing, listener)
+
+	err := c.Dispatch(context.Background(), events.OnReload, events.OnReloadPayload{NewConf: c.ConfigAccessor})
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", failing.conf.String("foo"))
+	assert.Equal(t, "bar", listener.conf.String("foo"))
+}
+
+func TestWithConfigFlags(t *testing.T) {
+	c := New(WithConfigFlags(), WithInline("http.addr", ":8080"))
+	c.ProvideEssentials()
+	c.Invoke(func(in serveIn) {
+		cmd := newServeCmd(in)
+		assert.NotNil(t, cmd.Flags().Lookup("http.addr"))
+		assert.NoError(t, cmd.ParseFlags([]string{"--http.addr=:9090"}))
+		assert.NoError(t, cmd.PreRunE(cmd, nil))
+	})
+	assert.Equal(t, ":9090", c.String("http.addr"))
+}
+
+func TestWithConfigTransformers(t *testing.T) {
+	c := New(
+		WithInline("db.dsn", "foo"),
+		WithConfigTransformers(config.RenameKeys(map[string]string{"db.dsn": "gorm.default.dsn"})),
+	)
+	assert.Equal(t, "foo", c.String("gorm.default.dsn"))
+	assert.Equal(t, "", c.String("db.dsn"))
+}
+
+func TestC_Provide_named(t *testing.T) {
+	var cleaned bool
+	c := New()
+	c.ProvideEssentials()
+	c.Provide(di.Deps{
+		func() string { return "default" },
+		di.Named("report", func() (string, func(), error) { return "report", func() { cleaned = true }, nil }),
+	})
+	type in struct {
+		di.In
+
+		Default string
+		Report  string `name:"report"`
+	}
+	c.Invoke(func(in in) {
+		assert.Equal(t, "default", in.Default)
+		assert.Equal(t, "report", in.Report)
+	})
+	c.Shutdown()
+	assert.True(t, cleaned)
+}
+
+func TestC_ProvideOverride(t *testing.T) {
+	c := New()
+	c.ProvideEssentials()
+	c.Provide(di.Deps{func() (string, func()) { return "real", func() {} }, func() int { return 1 }})
+	c.ProvideOverride(di.Deps{func() string { return "fake" }})
+	c.Invoke(func(s string, i int) {
+		assert.Equal(t, "fake", s)
+		assert.Equal(t, 1, i)
+	})
+	c.Invoke(func(conf contract.ConfigAccessor) {
+		assert.NotNil(t, conf)
+	})
+}
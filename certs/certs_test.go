@@ -0,0 +1,179 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/events"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newAuthority(t *testing.T) authority {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, _ := x509.ParseCertificate(der)
+	return authority{cert: cert, key: key}
+}
+
+// issue returns the DER certificate and the PKCS#8 DER key.
+func (a authority) issue(t *testing.T, serial int64) ([]byte, []byte) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	assert.NoError(t, err)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	return der, keyDER
+}
+
+func pemOf(typ string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func TestManager(t *testing.T) {
+	ca := newAuthority(t)
+	dir, _ := ioutil.TempDir("", "certs")
+	defer os.RemoveAll(dir)
+	source := FileSource{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	write := func(serial int64) {
+		cert, key := ca.issue(t, serial)
+		ioutil.WriteFile(source.CertFile, pemOf("CERTIFICATE", cert), 0600)
+		ioutil.WriteFile(source.KeyFile, pemOf("PRIVATE KEY", key), 0600)
+	}
+	ioutil.WriteFile(source.CAFile, pemOf("CERTIFICATE", ca.cert.Raw), 0600)
+	write(2)
+
+	dispatcher := &events.SyncDispatcher{}
+	var rotations int
+	dispatcher.Subscribe(events.Listen(OnRotate, func(ctx context.Context, event interface{}) error {
+		rotations++
+		return nil
+	}))
+	manager := NewManager(source, WithDispatcher(dispatcher))
+	assert.NoError(t, manager.Load(context.Background()))
+	assert.NoError(t, manager.Load(context.Background()))
+	assert.Equal(t, 1, rotations)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.TLS.PeerCertificates[0].SerialNumber.String()))
+	}))
+	server.TLS = manager.ServerTLSConfig(true)
+	server.StartTLS()
+	defer server.Close()
+
+	get := func() string {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: manager.ClientTLSConfig("localhost")}}
+		resp, err := client.Get(server.URL)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return string(b)
+	}
+	assert.Equal(t, "2", get())
+
+	write(3)
+	assert.NoError(t, manager.Load(context.Background()))
+	assert.Equal(t, 2, rotations)
+	assert.Equal(t, "3", get())
+
+	// a client without certificate is rejected.
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	_, err := client.Get(server.URL)
+	assert.Error(t, err)
+}
+
+func TestVaultSource(t *testing.T) {
+	ca := newAuthority(t)
+	cert, key := ca.issue(t, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/v1/pki/issue/web", request.URL.Path)
+		assert.Equal(t, "token", request.Header.Get("X-Vault-Token"))
+		json.NewEncoder(writer).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": string(pemOf("CERTIFICATE", cert)),
+				"private_key": string(pemOf("PRIVATE KEY", key)),
+				"issuing_ca":  string(pemOf("CERTIFICATE", ca.cert.Raw)),
+				"ca_chain":    []string{string(pemOf("CERTIFICATE", ca.cert.Raw))},
+			},
+		})
+	}))
+	defer server.Close()
+
+	bundle, err := VaultSource{Addr: server.URL, Token: "token", Role: "web", CommonName: "localhost"}.Fetch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", bundle.Certificate.Leaf.Subject.CommonName)
+	assert.Len(t, bundle.Certificate.Certificate, 2)
+}
+
+func TestParseX509SVIDResponse(t *testing.T) {
+	ca := newAuthority(t)
+	cert, key := ca.issue(t, 2)
+	var svid []byte
+	svid = protowire.AppendTag(svid, 1, protowire.BytesType)
+	svid = protowire.AppendString(svid, "spiffe://example.org/foo")
+	svid = protowire.AppendTag(svid, 2, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, cert)
+	svid = protowire.AppendTag(svid, 3, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, key)
+	svid = protowire.AppendTag(svid, 4, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, ca.cert.Raw)
+	var resp []byte
+	resp = protowire.AppendTag(resp, 1, protowire.BytesType)
+	resp = protowire.AppendBytes(resp, svid)
+
+	bundle, err := parseX509SVIDResponse(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), bundle.Certificate.Leaf.SerialNumber.Int64())
+	assert.NotNil(t, bundle.Roots)
+
+	_, err = parseX509SVIDResponse(nil)
+	assert.Error(t, err)
+}
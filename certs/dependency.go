@@ -0,0 +1,173 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+)
+
+// Option is the configuration of the certificate manager.
+type Option struct {
+	// Source is one of "file", "spiffe" or "vault".
+	Source string       `json:"source" yaml:"source"`
+	File   FileOption   `json:"file" yaml:"file"`
+	SPIFFE SPIFFEOption `json:"spiffe" yaml:"spiffe"`
+	Vault  VaultOption  `json:"vault" yaml:"vault"`
+	// RefreshInterval is the maximum interval between two refreshes.
+	RefreshInterval config.Duration `json:"refreshInterval" yaml:"refreshInterval"`
+	// ExpiryWarning is how long before expiry OnExpiring is dispatched.
+	ExpiryWarning config.Duration `json:"expiryWarning" yaml:"expiryWarning"`
+}
+
+// FileOption configures FileSource.
+type FileOption struct {
+	Cert string `json:"cert" yaml:"cert"`
+	Key  string `json:"key" yaml:"key"`
+	CA   string `json:"ca" yaml:"ca"`
+}
+
+// SPIFFEOption configures SPIFFESource.
+type SPIFFEOption struct {
+	Addr string `json:"addr" yaml:"addr"`
+}
+
+// VaultOption configures VaultSource.
+type VaultOption struct {
+	Addr       string          `json:"addr" yaml:"addr"`
+	Token      string          `json:"token" yaml:"token"`
+	Mount      string          `json:"mount" yaml:"mount"`
+	Role       string          `json:"role" yaml:"role"`
+	CommonName string          `json:"commonName" yaml:"commonName"`
+	AltNames   []string        `json:"altNames" yaml:"altNames"`
+	TTL        config.Duration `json:"ttl" yaml:"ttl"`
+}
+
+// NewSource creates the Source described by the Option.
+func NewSource(option Option) (Source, error) {
+	switch option.Source {
+	case "file":
+		return FileSource{CertFile: option.File.Cert, KeyFile: option.File.Key, CAFile: option.File.CA}, nil
+	case "spiffe":
+		return SPIFFESource{Addr: option.SPIFFE.Addr}, nil
+	case "vault":
+		return VaultSource{
+			Addr:       option.Vault.Addr,
+			Token:      option.Vault.Token,
+			Mount:      option.Vault.Mount,
+			Role:       option.Vault.Role,
+			CommonName: option.Vault.CommonName,
+			AltNames:   option.Vault.AltNames,
+			TTL:        option.Vault.TTL.Duration,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown certificate source %q", option.Source)
+	}
+}
+
+/*
+Providers returns a set of dependency providers for package certs.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+		*Metrics `optional:"true"`
+	Provide:
+		*Manager
+*/
+func Providers() di.Deps {
+	return di.Deps{provideManager, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger     log.Logger
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+	Metrics    *Metrics            `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Manager *Manager
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideManager(in in) (out, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("certs", &option); err != nil {
+		return out{}, fmt.Errorf("certs configuration error: %w", err)
+	}
+	source, err := NewSource(option)
+	if err != nil {
+		return out{}, fmt.Errorf("certs configuration error: %w", err)
+	}
+	options := []ManagerOption{WithLogger(in.Logger), WithDispatcher(in.Dispatcher)}
+	if option.RefreshInterval.Duration > 0 {
+		options = append(options, WithRefreshInterval(option.RefreshInterval.Duration))
+	}
+	if option.ExpiryWarning.Duration > 0 {
+		options = append(options, WithExpiryWarning(option.ExpiryWarning.Duration))
+	}
+	if in.Metrics != nil {
+		options = append(options, WithMetrics(in.Metrics))
+	}
+	manager := NewManager(source, options...)
+	if err := manager.Load(context.Background()); err != nil {
+		return out{}, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	return out{Manager: manager}, nil
+}
+
+// ProvideRunGroup refreshes the certificate in background.
+func (o out) ProvideRunGroup(group *run.Group) {
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		return o.Manager.Run(ctx)
+	}, func(err error) {
+		cancel()
+	})
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "certs",
+			Data: map[string]interface{}{
+				"certs": Option{
+					Source: "file",
+					File: FileOption{
+						Cert: "tls.crt",
+						Key:  "tls.key",
+						CA:   "ca.crt",
+					},
+					SPIFFE: SPIFFEOption{Addr: "unix:///run/spire/sockets/agent.sock"},
+					Vault: VaultOption{
+						Addr:     "http://127.0.0.1:8200",
+						Mount:    "pki",
+						AltNames: []string{},
+					},
+					RefreshInterval: config.Duration{Duration: time.Hour},
+					ExpiryWarning:   config.Duration{Duration: 24 * time.Hour},
+				},
+			},
+			Comment: "The certificate source, one of file, spiffe or vault",
+		},
+	}}
+}
@@ -0,0 +1,50 @@
+/*
+Package certs manages TLS certificates with automatic rotation.
+
+Certificates are loaded from a Source: PEM files, the SPIFFE workload API, or
+the Vault PKI secrets engine. The Manager refreshes the certificate
+periodically, and at the latest after two thirds of its lifetime, so that a new
+one is in use well before the old one expires. The TLS configs returned by the
+Manager always use the current certificate and roots, so servers and clients
+pick up rotations without restarts.
+
+On rotation, OnRotate is dispatched. While the certificate is about to expire,
+OnExpiring is dispatched on each refresh. The Metrics, provided by package
+observability, record the expiry and the rotations.
+
+Integration
+
+package certs exports the configuration in the following format:
+
+	certs:
+	  source: file
+	  file:
+	    cert: tls.crt
+	    key: tls.key
+	    ca: ca.crt
+	  spiffe:
+	    addr: unix:///run/spire/sockets/agent.sock
+	  vault:
+	    addr: http://127.0.0.1:8200
+	    token: ""
+	    mount: pki
+	    role: ""
+	    commonName: ""
+	    altNames: []
+	    ttl: 0s
+	  refreshInterval: 1h
+	  expiryWarning: 24h
+
+Add the certs dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(certs.Providers())
+
+Then feed the TLS configs to the servers and clients:
+
+	c.Invoke(func(manager *certs.Manager) {
+		server := &http.Server{TLSConfig: manager.ServerTLSConfig(true)}
+		conn, _ := grpc.Dial(target, grpc.WithTransportCredentials(credentials.NewTLS(manager.ClientTLSConfig("foo.svc"))))
+	})
+*/
+package certs
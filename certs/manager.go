@@ -0,0 +1,261 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+)
+
+type event string
+
+const (
+	// OnRotate is an event triggered when a new certificate is in use. The
+	// event payload is OnRotatePayload.
+	OnRotate event = "onCertRotate"
+	// OnExpiring is an event triggered on each refresh while the certificate
+	// in use is about to expire. The event payload is OnExpiringPayload.
+	OnExpiring event = "onCertExpiring"
+)
+
+// OnRotatePayload is the payload of OnRotate.
+type OnRotatePayload struct {
+	Old *Bundle
+	New *Bundle
+}
+
+// OnExpiringPayload is the payload of OnExpiring.
+type OnExpiringPayload struct {
+	Bundle    *Bundle
+	Remaining time.Duration
+}
+
+// Metrics is a collection of metrics for certificate rotation.
+type Metrics struct {
+	// Expiry gauges the expiry of the certificate in use, in unix seconds.
+	Expiry metrics.Gauge
+	// Rotations counts the rotations.
+	Rotations metrics.Counter
+	// Failures counts the failed refreshes.
+	Failures metrics.Counter
+}
+
+// Manager keeps the certificate fresh, and feeds it to the TLS configs. Manager
+// is safe for concurrent use.
+type Manager struct {
+	source          Source
+	refreshInterval time.Duration
+	expiryWarning   time.Duration
+	retryInterval   time.Duration
+	dispatcher      contract.Dispatcher
+	metrics         *Metrics
+	logger          log.Logger
+	now             func() time.Time
+
+	mu      sync.RWMutex
+	current *Bundle
+}
+
+// ManagerOption changes the behavior of Manager.
+type ManagerOption func(*Manager)
+
+// WithRefreshInterval sets the maximum interval between two refreshes. The
+// certificate is also refreshed after two thirds of its lifetime. Defaults to
+// 1h.
+func WithRefreshInterval(interval time.Duration) ManagerOption {
+	return func(manager *Manager) {
+		manager.refreshInterval = interval
+	}
+}
+
+// WithExpiryWarning sets how long before expiry OnExpiring is dispatched.
+// Defaults to 24h.
+func WithExpiryWarning(warning time.Duration) ManagerOption {
+	return func(manager *Manager) {
+		manager.expiryWarning = warning
+	}
+}
+
+// WithDispatcher sets the dispatcher for OnRotate and OnExpiring.
+func WithDispatcher(dispatcher contract.Dispatcher) ManagerOption {
+	return func(manager *Manager) {
+		manager.dispatcher = dispatcher
+	}
+}
+
+// WithMetrics sets the metrics.
+func WithMetrics(metrics *Metrics) ManagerOption {
+	return func(manager *Manager) {
+		manager.metrics = metrics
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger log.Logger) ManagerOption {
+	return func(manager *Manager) {
+		manager.logger = logger
+	}
+}
+
+// NewManager creates a new *Manager. Load must be called before the TLS
+// configs are used.
+func NewManager(source Source, options ...ManagerOption) *Manager {
+	manager := &Manager{
+		source:          source,
+		refreshInterval: time.Hour,
+		expiryWarning:   24 * time.Hour,
+		retryInterval:   30 * time.Second,
+		logger:          log.NewNopLogger(),
+		now:             time.Now,
+	}
+	for _, option := range options {
+		option(manager)
+	}
+	return manager
+}
+
+// Current returns the certificate in use, or nil if not loaded.
+func (m *Manager) Current() *Bundle {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.current
+}
+
+// Load fetches the certificate from the source. If it differs from the one in
+// use, it replaces it and OnRotate is dispatched.
+func (m *Manager) Load(ctx context.Context) error {
+	bundle, err := m.source.Fetch(ctx)
+	if err != nil {
+		if m.metrics != nil {
+			m.metrics.Failures.Add(1)
+		}
+		return err
+	}
+
+	m.mu.Lock()
+	old := m.current
+	rotated := old == nil || !bytes.Equal(old.Certificate.Certificate[0], bundle.Certificate.Certificate[0])
+	if rotated {
+		m.current = bundle
+	}
+	m.mu.Unlock()
+
+	if rotated {
+		if m.metrics != nil {
+			m.metrics.Rotations.Add(1)
+			m.metrics.Expiry.Set(float64(bundle.NotAfter().Unix()))
+		}
+		level.Info(m.logger).Log("msg", "certificate loaded", "subject", bundle.Certificate.Leaf.Subject.String(), "notAfter", bundle.NotAfter())
+		if m.dispatcher != nil {
+			_ = m.dispatcher.Dispatch(ctx, OnRotate, OnRotatePayload{Old: old, New: bundle})
+		}
+	}
+	return nil
+}
+
+// Run refreshes the certificate periodically until the context is canceled.
+func (m *Manager) Run(ctx context.Context) error {
+	for {
+		wait := m.refreshInterval
+		if err := m.Load(ctx); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to refresh certificate", "err", err)
+			wait = m.retryInterval
+		}
+		if current := m.Current(); current != nil {
+			remaining := current.NotAfter().Sub(m.now())
+			if remaining < m.expiryWarning {
+				level.Warn(m.logger).Log("msg", "certificate is about to expire", "remaining", remaining)
+				if m.dispatcher != nil {
+					_ = m.dispatcher.Dispatch(ctx, OnExpiring, OnExpiringPayload{Bundle: current, Remaining: remaining})
+				}
+			}
+			lifetime := current.NotAfter().Sub(current.Certificate.Leaf.NotBefore)
+			if renew := current.Certificate.Leaf.NotBefore.Add(lifetime * 2 / 3).Sub(m.now()); renew > 0 && renew < wait {
+				wait = renew
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+var errNotLoaded = errors.New("certificate is not loaded")
+
+// ServerTLSConfig returns a *tls.Config for servers that always uses the
+// current certificate. If the bundle has roots and requireClientCert is true,
+// the client certificates are required and verified.
+func (m *Manager) ServerTLSConfig(requireClientCert bool) *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			current := m.Current()
+			if current == nil {
+				return nil, errNotLoaded
+			}
+			conf := &tls.Config{Certificates: []tls.Certificate{current.Certificate}}
+			if requireClientCert && current.Roots != nil {
+				conf.ClientAuth = tls.RequireAndVerifyClientCert
+				conf.ClientCAs = current.Roots
+			}
+			return conf, nil
+		},
+	}
+}
+
+// ClientTLSConfig returns a *tls.Config for clients that presents the current
+// certificate, and verifies the server against the current roots. The server
+// name is verified too, unless it is empty.
+func (m *Manager) ClientTLSConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		ServerName: serverName,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			current := m.Current()
+			if current == nil {
+				return nil, errNotLoaded
+			}
+			return &current.Certificate, nil
+		},
+		// the roots may rotate, so the verification is done by
+		// VerifyPeerCertificate instead of the static RootCAs.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			current := m.Current()
+			if current == nil {
+				return errNotLoaded
+			}
+			certs := make([]*x509.Certificate, 0, len(rawCerts))
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return fmt.Errorf("invalid peer certificate: %w", err)
+				}
+				certs = append(certs, cert)
+			}
+			if len(certs) == 0 {
+				return errors.New("no peer certificate")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range certs[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := certs[0].Verify(x509.VerifyOptions{
+				DNSName:       serverName,
+				Roots:         current.Roots,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}
+}
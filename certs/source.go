@@ -0,0 +1,286 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Bundle is a certificate with its trust roots.
+type Bundle struct {
+	// Certificate is the certificate chain and private key.
+	Certificate tls.Certificate
+	// Roots are the trusted CAs to verify peers with. It can be nil, in which
+	// case the system roots are used by clients, and servers don't verify
+	// client certificates.
+	Roots *x509.CertPool
+}
+
+// NotAfter returns the expiry of the leaf certificate.
+func (b *Bundle) NotAfter() time.Time {
+	if b.Certificate.Leaf == nil {
+		return time.Time{}
+	}
+	return b.Certificate.Leaf.NotAfter
+}
+
+// Source fetches bundles. Each call to Fetch may return a new certificate.
+type Source interface {
+	Fetch(ctx context.Context) (*Bundle, error)
+}
+
+// FileSource loads the bundle from PEM files. Rotation is done by replacing the
+// files, eg. by cert-manager or a sidecar.
+type FileSource struct {
+	// CertFile contains the certificate chain.
+	CertFile string
+	// KeyFile contains the private key.
+	KeyFile string
+	// CAFile contains the trusted CAs. Optional.
+	CAFile string
+}
+
+// Fetch reads the files.
+func (f FileSource) Fetch(ctx context.Context) (*Bundle, error) {
+	cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key pair: %w", err)
+	}
+	bundle := &Bundle{Certificate: cert}
+	if f.CAFile != "" {
+		ca, err := ioutil.ReadFile(f.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ca: %w", err)
+		}
+		bundle.Roots = x509.NewCertPool()
+		if !bundle.Roots.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", f.CAFile)
+		}
+	}
+	return withLeaf(bundle)
+}
+
+// VaultSource issues certificates from the Vault PKI secrets engine.
+type VaultSource struct {
+	// Addr is the Vault address, eg. "https://vault:8200".
+	Addr string
+	// Token is the Vault token.
+	Token string
+	// Mount is the mount path of the PKI engine. Defaults to "pki".
+	Mount string
+	// Role is the PKI role to issue the certificates with.
+	Role string
+	// CommonName is the requested common name.
+	CommonName string
+	// AltNames are the requested DNS subject alternative names.
+	AltNames []string
+	// TTL is the requested lifetime. If zero, the role's default applies.
+	TTL time.Duration
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client contract.HttpDoer
+}
+
+// Fetch issues a new certificate.
+func (v VaultSource) Fetch(ctx context.Context) (*Bundle, error) {
+	mount := v.Mount
+	if mount == "" {
+		mount = "pki"
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body := map[string]interface{}{"common_name": v.CommonName}
+	if len(v.AltNames) > 0 {
+		body["alt_names"] = strings.Join(v.AltNames, ",")
+	}
+	if v.TTL > 0 {
+		body["ttl"] = v.TTL.String()
+	}
+	b, _ := json.Marshal(body)
+	url := fmt.Sprintf("%s/v1/%s/issue/%s", strings.TrimSuffix(v.Addr, "/"), mount, v.Role)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate from vault: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Errors []string `json:"errors"`
+		Data   struct {
+			Certificate string   `json:"certificate"`
+			PrivateKey  string   `json:"private_key"`
+			IssuingCA   string   `json:"issuing_ca"`
+			CAChain     []string `json:"ca_chain"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response (%d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to issue certificate from vault (%d): %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+	}
+	chain := result.Data.Certificate + "\n" + strings.Join(result.Data.CAChain, "\n")
+	cert, err := tls.X509KeyPair([]byte(chain), []byte(result.Data.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate from vault: %w", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(result.Data.IssuingCA))
+	return withLeaf(&Bundle{Certificate: cert, Roots: roots})
+}
+
+// SPIFFESource fetches X.509 SVIDs from the SPIFFE workload API, eg. a SPIRE
+// agent.
+type SPIFFESource struct {
+	// Addr is the workload API address, eg. "unix:///run/spire/sockets/agent.sock".
+	Addr string
+	// DialOptions are added to the default dial options.
+	DialOptions []grpc.DialOption
+}
+
+// Fetch returns the first SVID from the workload API.
+func (s SPIFFESource) Fetch(ctx context.Context) (*Bundle, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, s.Addr, append([]grpc.DialOption{grpc.WithInsecure()}, s.DialOptions...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial the workload api: %w", err)
+	}
+	defer conn.Close()
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "workload.spiffe.io", "true")
+	stream, err := conn.NewStream(
+		ctx,
+		&grpc.StreamDesc{ServerStreams: true},
+		"/SpiffeWorkloadAPI/FetchX509SVID",
+		grpc.ForceCodec(rawCodec{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch svid: %w", err)
+	}
+	// X509SVIDRequest is an empty message.
+	if err := stream.SendMsg(&[]byte{}); err != nil {
+		return nil, fmt.Errorf("failed to fetch svid: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, fmt.Errorf("failed to fetch svid: %w", err)
+	}
+	var resp []byte
+	if err := stream.RecvMsg(&resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch svid: %w", err)
+	}
+	return parseX509SVIDResponse(resp)
+}
+
+// parseX509SVIDResponse decodes the first SVID of a X509SVIDResponse:
+//
+//	message X509SVIDResponse { repeated X509SVID svids = 1; }
+//	message X509SVID {
+//		string spiffe_id = 1;
+//		bytes x509_svid = 2;     // ASN.1 DER certificate chain
+//		bytes x509_svid_key = 3; // PKCS#8 DER private key
+//		bytes bundle = 4;        // ASN.1 DER trust bundle
+//	}
+func parseX509SVIDResponse(b []byte) (*Bundle, error) {
+	svid, err := firstField(b, 1)
+	if err != nil || svid == nil {
+		return nil, fmt.Errorf("no svid in the workload api response")
+	}
+	chain, _ := firstField(svid, 2)
+	key, _ := firstField(svid, 3)
+	bundle, _ := firstField(svid, 4)
+
+	certs, err := x509.ParseCertificates(chain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid svid: %w", err)
+	}
+	var certPEM []byte
+	for _, cert := range certs {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid svid: %w", err)
+	}
+	roots := x509.NewCertPool()
+	cas, err := x509.ParseCertificates(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust bundle: %w", err)
+	}
+	for _, ca := range cas {
+		roots.AddCert(ca)
+	}
+	return withLeaf(&Bundle{Certificate: cert, Roots: roots})
+}
+
+// firstField returns the first bytes field with the number.
+func firstField(b []byte, field protowire.Number) ([]byte, error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.BytesType && num == field {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			return v, nil
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil, nil
+}
+
+// rawCodec passes *[]byte messages through as is.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*[]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+func withLeaf(bundle *Bundle) (*Bundle, error) {
+	if len(bundle.Certificate.Certificate) == 0 {
+		return nil, fmt.Errorf("empty certificate chain")
+	}
+	leaf, err := x509.ParseCertificate(bundle.Certificate.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+	bundle.Certificate.Leaf = leaf
+	return bundle, nil
+}
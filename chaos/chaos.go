@@ -0,0 +1,122 @@
+package chaos
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/unierr"
+	"google.golang.org/grpc/codes"
+)
+
+// Option is the configuration of Injector.
+type Option struct {
+	// Enable switches all faults on or off.
+	Enable bool `json:"enable" yaml:"enable"`
+	// HTTP configures faults injected into HTTP handlers.
+	HTTP Faults `json:"http" yaml:"http"`
+	// GRPC configures faults injected into gRPC methods.
+	GRPC Faults `json:"grpc" yaml:"grpc"`
+	// Conn configures faults injected into connections made by Injector.DialContext.
+	Conn Faults `json:"conn" yaml:"conn"`
+}
+
+// Faults is a set of faults and their probabilities.
+type Faults struct {
+	Latency LatencyFault `json:"latency" yaml:"latency"`
+	Error   ErrorFault   `json:"error" yaml:"error"`
+	Reset   ResetFault   `json:"reset" yaml:"reset"`
+}
+
+// LatencyFault delays the request.
+type LatencyFault struct {
+	Probability float64         `json:"probability" yaml:"probability"`
+	Duration    config.Duration `json:"duration" yaml:"duration"`
+}
+
+// ErrorFault fails the request with the given gRPC code.
+type ErrorFault struct {
+	Probability float64 `json:"probability" yaml:"probability"`
+	Code        int     `json:"code" yaml:"code"`
+}
+
+// ResetFault closes the connection abruptly.
+type ResetFault struct {
+	Probability float64 `json:"probability" yaml:"probability"`
+}
+
+// Injector decides whether to inject faults. Injector is safe for concurrent use.
+type Injector struct {
+	rwLock sync.RWMutex
+	option Option
+}
+
+// NewInjector creates a new *Injector from the given Option.
+func NewInjector(option Option) *Injector {
+	return &Injector{option: option}
+}
+
+// Update replaces the Option of Injector. It is called when config reloads.
+func (i *Injector) Update(option Option) {
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	i.option = option
+}
+
+func (i *Injector) faults(pick func(option Option) Faults) (Faults, bool) {
+	i.rwLock.RLock()
+	defer i.rwLock.RUnlock()
+
+	if !i.option.Enable {
+		return Faults{}, false
+	}
+	return pick(i.option), true
+}
+
+func (i *Injector) httpFaults() (Faults, bool) {
+	return i.faults(func(option Option) Faults { return option.HTTP })
+}
+
+func (i *Injector) grpcFaults() (Faults, bool) {
+	return i.faults(func(option Option) Faults { return option.GRPC })
+}
+
+func (i *Injector) connFaults() (Faults, bool) {
+	return i.faults(func(option Option) Faults { return option.Conn })
+}
+
+// delay sleeps for the configured latency if triggered, or until the context is done.
+func (f Faults) delay(ctx context.Context) {
+	if !hit(f.Latency.Probability) {
+		return
+	}
+	timer := time.NewTimer(f.Latency.Duration.Duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// err returns the configured error if triggered.
+func (f Faults) err() error {
+	if !hit(f.Error.Probability) {
+		return nil
+	}
+	code := codes.Code(f.Error.Code)
+	if code == codes.OK {
+		code = codes.Unavailable
+	}
+	return unierr.Newf(code, "fault injected by chaos")
+}
+
+func (f Faults) reset() bool {
+	return hit(f.Reset.Probability)
+}
+
+func hit(probability float64) bool {
+	return probability > 0 && rand.Float64() < probability
+}
@@ -0,0 +1,75 @@
+package chaos
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/unierr"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	injector := NewInjector(Option{
+		Enable: true,
+		HTTP: Faults{
+			Latency: LatencyFault{Probability: 1, Duration: config.Duration{Duration: 10 * time.Millisecond}},
+			Error:   ErrorFault{Probability: 1, Code: int(codes.Unavailable)},
+		},
+	})
+	handler := MakeHTTPMiddleware(injector)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Fatal("error should be injected")
+	}))
+	start := time.Now()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	injector.Update(Option{Enable: false, HTTP: injector.option.HTTP})
+	called := false
+	handler = MakeHTTPMiddleware(injector)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		called = true
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, called)
+}
+
+func TestMakeUnaryInterceptor(t *testing.T) {
+	injector := NewInjector(Option{
+		Enable: true,
+		GRPC:   Faults{Reset: ResetFault{Probability: 1}},
+	})
+	_, err := MakeUnaryInterceptor(injector)(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.True(t, unierr.IsUnavailableErr(err))
+}
+
+func TestInjector_DialContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	injector := NewInjector(Option{Enable: true, Conn: Faults{Reset: ResetFault{Probability: 1}}})
+	conn, err := injector.DialContext(context.Background(), "tcp", ln.Addr().String())
+	assert.NoError(t, err)
+	_, err = conn.Write([]byte("foo"))
+	assert.Equal(t, ErrReset, err)
+}
+
+func TestLoadOption(t *testing.T) {
+	conf := config.MapAdapter{"chaos": map[string]interface{}{"enable": true}}
+	option, err := loadOption(config.EnvProduction, conf)
+	assert.NoError(t, err)
+	assert.False(t, option.Enable)
+	option, err = loadOption(config.EnvTesting, conf)
+	assert.NoError(t, err)
+	assert.True(t, option.Enable)
+}
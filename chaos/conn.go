@@ -0,0 +1,57 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrReset is returned by connections reset by chaos.
+var ErrReset = errors.New("connection reset by chaos")
+
+// DialContext dials the address, injecting the configured connection faults.
+// Its signature is compatible with the dialer options of most clients, eg.
+// redis.UniversalOptions.Dialer.
+func (i *Injector) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if faults, ok := i.connFaults(); ok {
+		faults.delay(ctx)
+		if err := faults.err(); err != nil {
+			return nil, err
+		}
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &chaosConn{Conn: conn, injector: i}, nil
+}
+
+// chaosConn resets the underlying connection upon read or write, if triggered.
+type chaosConn struct {
+	net.Conn
+	injector *Injector
+}
+
+func (c *chaosConn) Read(b []byte) (int, error) {
+	if c.shouldReset() {
+		return 0, ErrReset
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *chaosConn) Write(b []byte) (int, error) {
+	if c.shouldReset() {
+		return 0, ErrReset
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *chaosConn) shouldReset() bool {
+	faults, ok := c.injector.connFaults()
+	if !ok || !faults.reset() {
+		return false
+	}
+	_ = c.Conn.Close()
+	return true
+}
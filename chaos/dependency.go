@@ -0,0 +1,92 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+	"google.golang.org/grpc/codes"
+)
+
+/*
+Providers returns a set of dependency providers for *Injector.
+
+	Depends On:
+		contract.Env
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		*Injector
+*/
+func Providers() di.Deps {
+	return di.Deps{provideInjector, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Env        contract.Env
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+func provideInjector(in in) (*Injector, error) {
+	option, err := loadOption(in.Env, in.Conf)
+	if err != nil {
+		return nil, err
+	}
+	injector := NewInjector(option)
+	if in.Dispatcher != nil {
+		in.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+			option, err := loadOption(in.Env, event.(events.OnReloadPayload).NewConf)
+			if err != nil {
+				return err
+			}
+			injector.Update(option)
+			return nil
+		}))
+	}
+	return injector, nil
+}
+
+func loadOption(env contract.Env, conf contract.ConfigAccessor) (Option, error) {
+	var option Option
+	if err := conf.Unmarshal("chaos", &option); err != nil {
+		return Option{}, fmt.Errorf("chaos configuration error: %w", err)
+	}
+	if env.IsProduction() {
+		option.Enable = false
+	}
+	return option, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	faults := Faults{
+		Latency: LatencyFault{Duration: config.Duration{Duration: 100 * time.Millisecond}},
+		Error:   ErrorFault{Code: int(codes.Unavailable)},
+	}
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "chaos",
+			Data: map[string]interface{}{
+				"chaos": Option{
+					Enable: false,
+					HTTP:   faults,
+					GRPC:   faults,
+					Conn:   faults,
+				},
+			},
+			Comment: "The fault injection configuration. Never enabled in production env.",
+		},
+	}}
+}
@@ -0,0 +1,51 @@
+/*
+Package chaos injects faults into HTTP handlers, gRPC methods and connections
+for game-day resilience testing.
+
+Three kinds of faults are supported, each triggered by a configured probability:
+
+	- latency: the request or connection is delayed.
+	- error: the request fails with the given gRPC code (converted to HTTP status
+	  for HTTP requests), or the dial fails.
+	- reset: the connection is closed abruptly.
+
+Package chaos is opt-in, and is never enabled in production env, regardless of
+the configuration. The configuration is reloaded at runtime, so that faults can
+be switched on and off during a game day.
+
+Integration
+
+package chaos exports the configuration in the following format:
+
+	chaos:
+	    enable: false
+	    http:
+	        latency:
+	            probability: 0
+	            duration: 100ms
+	        error:
+	            probability: 0
+	            code: 14
+	        reset:
+	            probability: 0
+	    grpc: ...
+	    conn: ...
+
+Add the chaos dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(chaos.Providers())
+
+Then apply the middleware, interceptor and dialer:
+
+	c.Invoke(func(injector *chaos.Injector) {
+		router.Use(chaos.MakeHTTPMiddleware(injector))
+	})
+
+	c.Provide(di.Deps{func(injector *chaos.Injector) otredis.RedisConfigurationInterceptor {
+		return func(name string, opts *redis.UniversalOptions) {
+			opts.Dialer = injector.DialContext
+		}
+	}})
+*/
+package chaos
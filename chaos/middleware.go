@@ -0,0 +1,52 @@
+package chaos
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/DoNewsCode/core/unierr"
+	"google.golang.org/grpc"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that injects faults
+// into HTTP handlers. A reset aborts the connection without response.
+func MakeHTTPMiddleware(injector *Injector) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			faults, ok := injector.httpFaults()
+			if !ok {
+				handler.ServeHTTP(writer, request)
+				return
+			}
+			faults.delay(request.Context())
+			if faults.reset() {
+				panic(http.ErrAbortHandler)
+			}
+			if err := faults.err(); err != nil {
+				srvhttp.NewResponseEncoder(writer).EncodeError(err)
+				return
+			}
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that injects
+// faults into gRPC methods. A reset is reported as codes.Unavailable.
+func MakeUnaryInterceptor(injector *Injector) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		faults, ok := injector.grpcFaults()
+		if !ok {
+			return handler(ctx, req)
+		}
+		faults.delay(ctx)
+		if faults.reset() {
+			return nil, unierr.UnavailableErr(nil, "connection reset by chaos")
+		}
+		if err := faults.err(); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
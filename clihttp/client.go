@@ -10,6 +10,7 @@ import (
 	"strconv"
 
 	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/timeout"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -86,6 +87,8 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	c.logRequest(req, clientSpan)
 
 	c.tracer.Inject(clientSpan.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	// Propagate the deadline so that the callee stops when the caller gives up.
+	timeout.InjectHTTPHeader(ctx, req.Header)
 	response, err := c.underlying.Do(req)
 	if err != nil {
 		return response, err
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/DoNewsCode/core/timeout"
 	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 
@@ -80,3 +82,24 @@ func TestClient_context(t *testing.T) {
 	assert.Len(t, tracer.FinishedSpans(), 2)
 	assert.Equal(t, "bar", tracer.FinishedSpans()[1].BaggageItem("foo"))
 }
+
+type headerDoer struct {
+	header http.Header
+}
+
+func (h *headerDoer) Do(req *http.Request) (*http.Response, error) {
+	h.header = req.Header
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func TestClient_deadline(t *testing.T) {
+	doer := &headerDoer{}
+	client := NewClient(mocktracer.New(), WithDoer(doer))
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/", nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, timeout.FormatDeadline(deadline), doer.header.Get(timeout.DeadlineHeader))
+}
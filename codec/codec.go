@@ -0,0 +1,54 @@
+// Package codec looks up the payload codecs by name, so that the format of the
+// payloads, such as the distributed events, is a matter of configuration
+// rather than code.
+//
+// The built-in codecs are "json" (the default), "msgpack", "protobuf" and
+// "gob". More can be added with Register.
+package codec
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/DoNewsCode/core/codec/gob"
+	"github.com/DoNewsCode/core/codec/json"
+	"github.com/DoNewsCode/core/codec/msgpack"
+	"github.com/DoNewsCode/core/codec/protobuf"
+	"github.com/DoNewsCode/core/contract"
+)
+
+var (
+	mu     sync.RWMutex
+	codecs = map[string]contract.Codec{
+		"json":     json.NewCodec(),
+		"msgpack":  msgpack.Codec{},
+		"protobuf": protobuf.Codec{},
+		"gob":      gob.Codec{},
+	}
+)
+
+// Register makes the codec available under the name. It replaces the codec
+// registered under the same name, if any.
+func Register(name string, codec contract.Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	codecs[name] = codec
+}
+
+// Get returns the codec registered under the name. An empty name returns the
+// json codec.
+func Get(name string) (contract.Codec, error) {
+	if name == "" {
+		name = "json"
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("codec %s is not registered", name)
+	}
+	return codec, nil
+}
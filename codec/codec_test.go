@@ -0,0 +1,23 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	for _, name := range []string{"", "json", "msgpack", "protobuf", "gob"} {
+		c, err := Get(name)
+		assert.NoError(t, err, name)
+		assert.NotNil(t, c, name)
+	}
+	_, err := Get("yaml")
+	assert.Error(t, err)
+
+	Register("yaml", yaml.Codec{})
+	c, err := Get("yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, yaml.Codec{}, c)
+}
@@ -0,0 +1,77 @@
+// Package dotenv provides the dotenv codec, for the .env files of the twelve-factor
+// convention. It can be used with config.CodecParser, see also core.WithDotEnv.
+//
+// The keys are normalized into configuration paths: they are lower cased, and
+// underscores become dots, so FOO_BAR=baz is read as {"foo": {"bar": "baz"}}.
+// Mixed case keys, such as http.proxyProtocol, can't be expressed this way.
+package dotenv
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/knadh/koanf/maps"
+)
+
+// Codec is a Codec implementation with dotenv.
+type Codec struct{}
+
+// Marshal serialize the interface{} to []byte. The nested values are flattened
+// into upper cased keys joined by underscores.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	flat, _ := maps.Flatten(m, nil, ".")
+	env := make(map[string]string, len(flat))
+	for key, value := range flat {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		s := string(b)
+		if str, ok := value.(string); ok {
+			s = str
+		}
+		env[strings.ToUpper(strings.ReplaceAll(key, ".", "_"))] = s
+	}
+	out, err := godotenv.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out + "\n"), nil
+}
+
+// Unmarshal deserialize the []byte to interface{}. The values are strings.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	env, err := godotenv.Unmarshal(string(data))
+	if err != nil {
+		return err
+	}
+	flat := make(map[string]interface{}, len(env))
+	for key, value := range env {
+		flat[Normalize(key)] = value
+	}
+	m := maps.Unflatten(flat, ".")
+	if p, ok := v.(*map[string]interface{}); ok {
+		*p = m
+		return nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Normalize converts an environment variable name into a configuration path,
+// eg. FOO_BAR into foo.bar.
+func Normalize(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "."))
+}
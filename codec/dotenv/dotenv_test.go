@@ -0,0 +1,29 @@
+package dotenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec(t *testing.T) {
+	var m map[string]interface{}
+	err := Codec{}.Unmarshal([]byte("# comment\nFOO_BAR=baz\nexport LOG_LEVEL=\"debug\"\n"), &m)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"foo": map[string]interface{}{"bar": "baz"},
+		"log": map[string]interface{}{"level": "debug"},
+	}, m)
+
+	b, err := Codec{}.Marshal(m)
+	assert.NoError(t, err)
+	assert.Equal(t, "FOO_BAR=\"baz\"\nLOG_LEVEL=\"debug\"\n", string(b))
+
+	var s struct {
+		Foo struct {
+			Bar string `json:"bar"`
+		} `json:"foo"`
+	}
+	assert.NoError(t, Codec{}.Unmarshal([]byte("FOO_BAR=baz"), &s))
+	assert.Equal(t, "baz", s.Foo.Bar)
+}
@@ -0,0 +1,26 @@
+// Package gob provides the gob codec.
+package gob
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// Codec is a Codec implementation with encoding/gob. Both ends must agree on
+// the Go types, and the concrete types behind interfaces must be registered
+// with gob.Register.
+type Codec struct{}
+
+// Marshal serialize the interface{} to []byte
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialize the []byte to interface{}
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
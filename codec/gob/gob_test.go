@@ -0,0 +1,24 @@
+package gob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	ID    int
+	Buyer string
+}
+
+func TestCodec(t *testing.T) {
+	var c Codec
+	data, err := c.Marshal(payload{ID: 1, Buyer: "foo"})
+	assert.NoError(t, err)
+
+	var p payload
+	assert.NoError(t, c.Unmarshal(data, &p))
+	assert.Equal(t, payload{ID: 1, Buyer: "foo"}, p)
+
+	assert.Error(t, c.Unmarshal([]byte("foo"), &p))
+}
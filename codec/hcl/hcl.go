@@ -0,0 +1,49 @@
+// Package hcl provides the hcl codec. It can be used with config.CodecParser to
+// load HCL configuration files, eg. those migrated from Terraform or Consul.
+package hcl
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/hcl"
+)
+
+// Codec is a Codec implementation with hcl.
+type Codec struct{}
+
+// Marshal serialize the interface{} to []byte. The output is in the JSON
+// flavor of HCL, which is understood by Unmarshal.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal deserialize the []byte to interface{}. When decoding into a
+// map[string]interface{}, the blocks are decoded into maps rather than
+// single-element lists of maps. See https://github.com/hashicorp/hcl/issues/162.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	if err := hcl.Unmarshal(data, v); err != nil {
+		return err
+	}
+	if m, ok := v.(*map[string]interface{}); ok {
+		flatten(*m)
+	}
+	return nil
+}
+
+func flatten(m map[string]interface{}) {
+	for k, val := range m {
+		if v, ok := val.([]map[string]interface{}); ok && len(v) == 1 {
+			m[k] = v[0]
+		}
+	}
+	for _, val := range m {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			flatten(v)
+		case []map[string]interface{}:
+			for _, item := range v {
+				flatten(item)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package hcl
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testHCL = `
+name = "app"
+http {
+  addr = ":8080"
+}
+redis "default" {
+  addrs = ["127.0.0.1:6379"]
+  db = 1
+}
+`
+
+func TestCodec_Unmarshal(t *testing.T) {
+	var m map[string]interface{}
+	if err := (Codec{}).Unmarshal([]byte(testHCL), &m); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name": "app",
+		"http": map[string]interface{}{"addr": ":8080"},
+		"redis": map[string]interface{}{
+			"default": map[string]interface{}{
+				"addrs": []interface{}{"127.0.0.1:6379"},
+				"db":    1,
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, m) {
+		t.Fatalf("expected %#v, got %#v", expected, m)
+	}
+}
+
+func TestCodec_Marshal(t *testing.T) {
+	input := map[string]interface{}{
+		"http": map[string]interface{}{"addr": ":8080"},
+	}
+	data, err := (Codec{}).Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := (Codec{}).Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(input, m) {
+		t.Fatalf("expected %#v, got %#v", input, m)
+	}
+}
@@ -0,0 +1,30 @@
+// Package msgpack provides the msgpack codec.
+package msgpack
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec is a Codec implementation with msgpack. Struct fields are keyed by the
+// json tags, so the types encoded as json can be encoded as msgpack unchanged.
+type Codec struct{}
+
+// Marshal serialize the interface{} to []byte
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialize the []byte to interface{}
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
@@ -0,0 +1,29 @@
+package msgpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	ID    int      `json:"id"`
+	Buyer string   `json:"buyer"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func TestCodec(t *testing.T) {
+	var c Codec
+	data, err := c.Marshal(payload{ID: 1, Buyer: "foo", Tags: []string{"bar"}})
+	assert.NoError(t, err)
+
+	var p payload
+	assert.NoError(t, c.Unmarshal(data, &p))
+	assert.Equal(t, payload{ID: 1, Buyer: "foo", Tags: []string{"bar"}}, p)
+
+	var m map[string]interface{}
+	assert.NoError(t, c.Unmarshal(data, &m))
+	assert.Equal(t, "foo", m["buyer"], "fields should be keyed by json tags")
+
+	assert.Error(t, c.Unmarshal([]byte{0xc1}, &p))
+}
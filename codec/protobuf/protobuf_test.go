@@ -0,0 +1,22 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodec(t *testing.T) {
+	var c Codec
+	data, err := c.Marshal(wrapperspb.String("foo"))
+	assert.NoError(t, err)
+
+	var s wrapperspb.StringValue
+	assert.NoError(t, c.Unmarshal(data, &s))
+	assert.Equal(t, "foo", s.GetValue())
+
+	_, err = c.Marshal("foo")
+	assert.Error(t, err)
+	assert.Error(t, c.Unmarshal(data, new(string)))
+}
@@ -0,0 +1,34 @@
+// Package toml provides the toml codec. It can be used with config.CodecParser
+// to load TOML configuration files:
+//
+//	core.New(core.WithConfigStack(file.Provider("config.toml"), config.CodecParser{Codec: toml.Codec{}}))
+package toml
+
+import (
+	"encoding/json"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Codec is a Codec implementation with toml.
+type Codec struct{}
+
+// Marshal serialize the interface{} to []byte. The value is converted through
+// JSON first, so the json tags of structs are respected, as in the rest of the
+// configuration.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return toml.Marshal(m)
+}
+
+// Unmarshal deserialize the []byte to interface{}
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	return toml.Unmarshal(data, v)
+}
@@ -0,0 +1,44 @@
+package toml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec_Unmarshal(t *testing.T) {
+	var v map[string]interface{}
+	err := Codec{}.Unmarshal([]byte("name = \"app\"\n\n[http]\naddr = \":8080\"\nports = [1, 2]\n"), &v)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": "app",
+		"http": map[string]interface{}{"addr": ":8080", "ports": []interface{}{int64(1), int64(2)}},
+	}, v)
+
+	var s struct {
+		Name string `toml:"name"`
+	}
+	assert.NoError(t, Codec{}.Unmarshal([]byte(`name = "app"`), &s))
+	assert.Equal(t, "app", s.Name)
+
+	assert.Error(t, Codec{}.Unmarshal([]byte("name = "), &v))
+}
+
+func TestCodec_Marshal(t *testing.T) {
+	type option struct {
+		Addr    string `json:"addr"`
+		Disable bool   `json:"disable"`
+	}
+	b, err := Codec{}.Marshal(map[string]interface{}{
+		"name": "app",
+		"http": option{Addr: ":8080"},
+	})
+	assert.NoError(t, err)
+
+	var v map[string]interface{}
+	assert.NoError(t, Codec{}.Unmarshal(b, &v))
+	assert.Equal(t, map[string]interface{}{
+		"name": "app",
+		"http": map[string]interface{}{"addr": ":8080", "disable": false},
+	}, v)
+}
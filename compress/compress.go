@@ -0,0 +1,181 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported encodings.
+const (
+	Brotli = "br"
+	Zstd   = "zstd"
+	Gzip   = "gzip"
+)
+
+// Option is the configuration of Compressor.
+type Option struct {
+	// Encodings are the enabled encodings in the order of preference. Unknown
+	// encodings are ignored. Defaults to br, zstd and gzip.
+	Encodings []string `json:"encodings" yaml:"encodings"`
+	// MinSize is the minimum response size in bytes to compress. Defaults to
+	// 1024.
+	MinSize int `json:"minSize" yaml:"minSize"`
+	// ContentTypes are the compressible media types. An entry ending with "/"
+	// matches all subtypes, eg. "text/". Defaults to DefaultContentTypes.
+	ContentTypes []string `json:"contentTypes" yaml:"contentTypes"`
+	// Exclude are the HTTP route templates or paths that are never compressed,
+	// eg. the ones serving already compressed files.
+	Exclude []string `json:"exclude" yaml:"exclude"`
+}
+
+// DefaultContentTypes are the compressible media types used when
+// Option.ContentTypes is empty.
+var DefaultContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/x-protobuf",
+	"image/svg+xml",
+}
+
+// encoder is a compressing writer that can be reused after Close.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var pools = map[string]*sync.Pool{
+	Brotli: {New: func() interface{} { return brotli.NewWriter(nil) }},
+	Gzip:   {New: func() interface{} { return gzip.NewWriter(nil) }},
+	Zstd: {New: func() interface{} {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+}
+
+func getEncoder(encoding string, w io.Writer) encoder {
+	e := pools[encoding].Get().(encoder)
+	e.Reset(w)
+	return e
+}
+
+func putEncoder(encoding string, e encoder) {
+	pools[encoding].Put(e)
+}
+
+// Compressor decides whether and how responses are compressed. Compressor is
+// safe for concurrent use.
+type Compressor struct {
+	rwLock sync.RWMutex
+	option Option
+}
+
+// NewCompressor creates a new *Compressor from the given Option.
+func NewCompressor(option Option) *Compressor {
+	return &Compressor{option: withDefaults(option)}
+}
+
+// Update replaces the Option of Compressor. It is called when config reloads.
+func (c *Compressor) Update(option Option) {
+	c.rwLock.Lock()
+	defer c.rwLock.Unlock()
+
+	c.option = withDefaults(option)
+}
+
+func withDefaults(option Option) Option {
+	var encodings []string
+	for _, encoding := range option.Encodings {
+		if _, ok := pools[encoding]; ok {
+			encodings = append(encodings, encoding)
+		}
+	}
+	option.Encodings = encodings
+	if len(option.Encodings) == 0 {
+		option.Encodings = []string{Brotli, Zstd, Gzip}
+	}
+	if option.MinSize <= 0 {
+		option.MinSize = 1024
+	}
+	if len(option.ContentTypes) == 0 {
+		option.ContentTypes = DefaultContentTypes
+	}
+	return option
+}
+
+func (c *Compressor) load() Option {
+	c.rwLock.RLock()
+	defer c.rwLock.RUnlock()
+
+	return c.option
+}
+
+// excluded reports whether the route template or path opts out.
+func (o Option) excluded(name string) bool {
+	for _, exclude := range o.Exclude {
+		if exclude == name {
+			return true
+		}
+	}
+	return false
+}
+
+// compressible reports whether the Content-Type header is compressible.
+func (o Option) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range o.ContentTypes {
+		if t == mediaType || strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate picks the most preferred enabled encoding accepted by the
+// Accept-Encoding header. It returns an empty string if none is acceptable.
+func (o Option) negotiate(acceptEncoding string) string {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[name] = q
+	}
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, encoding := range o.Encodings {
+		q, ok := accepted[encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
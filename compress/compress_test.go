@@ -0,0 +1,132 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	option := withDefaults(Option{Encodings: []string{Brotli, Zstd, Gzip, "deflate"}})
+	cases := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"gzip", Gzip},
+		{"gzip, deflate, br", Brotli},
+		{"br;q=0.5, gzip", Gzip},
+		{"br;q=0, zstd;q=0", ""},
+		{"*", Brotli},
+		{"identity", ""},
+		{"deflate", ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, option.negotiate(c.acceptEncoding), c.acceptEncoding)
+	}
+}
+
+func decode(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case Gzip:
+		gr, err := gzip.NewReader(body)
+		assert.NoError(t, err)
+		r = gr
+	case Brotli:
+		r = brotli.NewReader(body)
+	case Zstd:
+		zr, err := zstd.NewReader(body)
+		assert.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		r = body
+	}
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	large := strings.Repeat("hello world ", 200)
+	router := mux.NewRouter()
+	router.HandleFunc("/text", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain")
+		writer.Write([]byte(large[:100]))
+		writer.Write([]byte(large[100:]))
+	})
+	router.HandleFunc("/small", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("hello"))
+	})
+	router.HandleFunc("/image", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "image/png")
+		writer.Write([]byte(large))
+	})
+	router.HandleFunc("/excluded/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(large))
+	})
+	router.HandleFunc("/created", func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusCreated)
+		writer.Write([]byte(large))
+	})
+	router.Use(MakeHTTPMiddleware(NewCompressor(Option{Exclude: []string{"/excluded/{id}"}})))
+
+	cases := []struct {
+		path           string
+		acceptEncoding string
+		status         int
+		encoding       string
+		body           string
+	}{
+		{"/text", "gzip", 200, Gzip, large},
+		{"/text", "br", 200, Brotli, large},
+		{"/text", "zstd", 200, Zstd, large},
+		{"/text", "", 200, "", large},
+		{"/small", "gzip", 200, "", "hello"},
+		{"/image", "gzip", 200, "", large},
+		{"/excluded/1", "gzip", 200, "", large},
+		{"/created", "gzip", 201, Gzip, large},
+	}
+	for _, c := range cases {
+		for i := 0; i < 2; i++ {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, c.path, nil)
+			request.Header.Set("Accept-Encoding", c.acceptEncoding)
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, c.status, recorder.Code, c.path)
+			assert.Equal(t, c.encoding, recorder.Header().Get("Content-Encoding"), c.path)
+			assert.Equal(t, c.body, decode(t, c.encoding, recorder.Body), c.path)
+			if c.path != "/excluded/1" {
+				assert.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"), c.path)
+			}
+		}
+	}
+}
+
+func TestMakeHTTPMiddleware_flush(t *testing.T) {
+	handler := MakeHTTPMiddleware(NewCompressor(Option{}))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/event-stream")
+		writer.Write([]byte("data: 1\n\n"))
+		writer.(http.Flusher).Flush()
+	}))
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(recorder, request)
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, "", recorder.Header().Get("Content-Encoding"))
+	assert.True(t, bytes.Equal([]byte("data: 1\n\n"), recorder.Body.Bytes()))
+}
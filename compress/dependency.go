@@ -0,0 +1,73 @@
+package compress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+)
+
+/*
+Providers returns a set of dependency providers for *Compressor.
+
+	Depends On:
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		*Compressor
+*/
+func Providers() di.Deps {
+	return di.Deps{provideCompressor, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+func provideCompressor(in in) (*Compressor, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("compress", &option); err != nil {
+		return nil, fmt.Errorf("compress configuration error: %w", err)
+	}
+	compressor := NewCompressor(option)
+	if in.Dispatcher != nil {
+		in.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+			var option Option
+			if err := event.(events.OnReloadPayload).NewConf.Unmarshal("compress", &option); err != nil {
+				return fmt.Errorf("compress configuration error: %w", err)
+			}
+			compressor.Update(option)
+			return nil
+		}))
+	}
+	return compressor, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "compress",
+			Data: map[string]interface{}{
+				"compress": Option{
+					Encodings:    []string{Brotli, Zstd, Gzip},
+					MinSize:      1024,
+					ContentTypes: DefaultContentTypes,
+					Exclude:      []string{},
+				},
+			},
+			Comment: "The response compression",
+		},
+	}}
+}
@@ -0,0 +1,34 @@
+/*
+Package compress provides an HTTP middleware that compresses responses with
+brotli, zstd or gzip.
+
+The encoding is negotiated from the Accept-Encoding header, in the configured
+order of preference. Small responses and media types that are already
+compressed, such as images, are sent as is. Individual routes can opt out, eg.
+the ones serving pre-compressed files. Every response passing the middleware
+carries "Vary: Accept-Encoding", so that caches don't mix up the encodings. The
+encoders are pooled, so compression doesn't allocate a new encoder per request.
+
+Integration
+
+package compress exports the configuration in the following format:
+
+	compress:
+	    encodings: [br, zstd, gzip]
+	    minSize: 1024
+	    contentTypes: [text/, application/json]
+	    exclude:
+	        - /download/{file}
+
+Add the compress dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(compress.Providers())
+
+Then apply the middleware:
+
+	c.Invoke(func(compressor *compress.Compressor) {
+		router.Use(compress.MakeHTTPMiddleware(compressor))
+	})
+*/
+package compress
@@ -0,0 +1,195 @@
+package compress
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/DoNewsCode/core/bufpool"
+	"github.com/gorilla/mux"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that compresses the
+// responses with the encoding negotiated by Accept-Encoding. A response is
+// compressed only if its Content-Type is compressible and its size reaches the
+// minimum, so the first bytes are buffered until the decision is made. Routes
+// are matched by the mux path template, falling back to the request path.
+func MakeHTTPMiddleware(compressor *Compressor) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			option := compressor.load()
+			name := request.URL.Path
+			if route := mux.CurrentRoute(request); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					name = tpl
+				}
+			}
+			if option.excluded(name) || request.Header.Get("Upgrade") != "" {
+				handler.ServeHTTP(writer, request)
+				return
+			}
+			// the response depends on Accept-Encoding whether it ends up
+			// compressed or not, so caches must key on it.
+			writer.Header().Add("Vary", "Accept-Encoding")
+			encoding := option.negotiate(request.Header.Get("Accept-Encoding"))
+			if encoding == "" || request.Method == http.MethodHead {
+				handler.ServeHTTP(writer, request)
+				return
+			}
+
+			w := writerPool.Get().(*responseWriter)
+			w.ResponseWriter = writer
+			w.option = option
+			w.encoding = encoding
+			w.buf = bufpool.Get(option.MinSize)
+			defer func() {
+				w.finish()
+				w.reset()
+				writerPool.Put(w)
+			}()
+			handler.ServeHTTP(w, request)
+		})
+	}
+}
+
+var writerPool = sync.Pool{New: func() interface{} { return &responseWriter{} }}
+
+// responseWriter buffers the response until it knows whether to compress.
+type responseWriter struct {
+	http.ResponseWriter
+	option   Option
+	encoding string
+	status   int
+	buf      *bytes.Buffer
+	decided  bool
+	// encoder is set once the response is being compressed.
+	encoder encoder
+}
+
+func (w *responseWriter) reset() {
+	w.ResponseWriter = nil
+	w.encoding = ""
+	w.status = 0
+	bufpool.Put(w.buf)
+	w.buf = nil
+	w.decided = false
+	w.encoder = nil
+}
+
+// WriteHeader records the status. The header is sent once the compression is
+// decided.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = status
+	if !w.eligible() {
+		w.passthrough()
+	}
+}
+
+// Write buffers the data until the minimum size is reached.
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if !w.eligible() {
+			w.passthrough()
+		}
+	}
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf.Write(p)
+	if w.buf.Len() >= w.option.MinSize {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+		}
+		if w.option.compressible(w.Header().Get("Content-Type")) {
+			if err := w.compress(); err != nil {
+				return 0, err
+			}
+		} else if err := w.passthrough(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends the buffered data. A response flushed before reaching the
+// minimum size is not compressed, since it is likely a stream.
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.passthrough()
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// eligible reports whether the response can still be compressed, judging by
+// the status and the headers set so far.
+func (w *responseWriter) eligible() bool {
+	switch {
+	case w.status < http.StatusOK,
+		w.status == http.StatusNoContent,
+		w.status == http.StatusPartialContent,
+		w.status == http.StatusNotModified:
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if contentType := header.Get("Content-Type"); contentType != "" && !w.option.compressible(contentType) {
+		return false
+	}
+	return true
+}
+
+func (w *responseWriter) compress() error {
+	w.decided = true
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Encoding", w.encoding)
+	w.ResponseWriter.WriteHeader(w.status)
+	w.encoder = getEncoder(w.encoding, w.ResponseWriter)
+	_, err := w.encoder.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *responseWriter) passthrough() error {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	return err
+}
+
+// finish sends what is left once the handler returns.
+func (w *responseWriter) finish() {
+	if !w.decided {
+		if w.status == 0 && w.buf.Len() == 0 {
+			return
+		}
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.passthrough()
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+		putEncoder(w.encoding, w.encoder)
+	}
+}
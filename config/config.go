@@ -4,24 +4,29 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/events"
 	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/mitchellh/mapstructure"
 )
 
 // KoanfAdapter is a implementation of contract.Config based on Koanf (https://github.com/knadh/koanf).
 type KoanfAdapter struct {
-	layers     []ProviderSet
-	validators []Validator
-	watcher    contract.ConfigWatcher
-	dispatcher contract.Dispatcher
-	delimiter  string
-	rwlock     sync.RWMutex
-	K          *koanf.Koanf
+	layers       []ProviderSet
+	defaults     map[string]interface{}
+	validators   []Validator
+	watcher      contract.ConfigWatcher
+	dispatcher   contract.Dispatcher
+	delimiter    string
+	pending      map[string]interface{}
+	transformers []Transformer
+	rwlock       sync.RWMutex
+	K            *koanf.Koanf
 }
 
 // ProviderSet is a configuration layer formed by a parser and a provider.
@@ -41,6 +46,21 @@ func WithProviderLayer(provider koanf.Provider, parser koanf.Parser) Option {
 	}
 }
 
+// WithDefaults is an option for *KoanfAdapter that adds the default values
+// under the configuration stack, so they have the lowest priority. The keys can
+// be key paths, eg. "http.addr". This option can be used multiple times, and
+// the later defaults override the earlier ones.
+func WithDefaults(defaults map[string]interface{}) Option {
+	return func(option *KoanfAdapter) {
+		if option.defaults == nil {
+			option.defaults = make(map[string]interface{})
+		}
+		for key, value := range defaults {
+			option.defaults[key] = value
+		}
+	}
+}
+
 // WithWatcher is an option for *KoanfAdapter that adds a config watcher. The watcher should notify the configurations
 // whenever a reload event is triggered.
 func WithWatcher(watcher contract.ConfigWatcher) Option {
@@ -63,10 +83,12 @@ func WithDispatcher(dispatcher contract.Dispatcher) Option {
 	}
 }
 
-// WithValidators changes the validators of Koanf.
+// WithValidators adds validators to Koanf. The merged configuration is
+// validated on startup and on every reload. See SchemaValidator and
+// StructValidator for the validators built from a schema.
 func WithValidators(validators ...Validator) Option {
 	return func(option *KoanfAdapter) {
-		option.validators = validators
+		option.validators = append(option.validators, validators...)
 	}
 }
 
@@ -78,8 +100,6 @@ func NewConfig(options ...Option) (*KoanfAdapter, error) {
 		f(&adapter)
 	}
 
-	adapter.K = koanf.New(adapter.delimiter)
-
 	if err := adapter.Reload(); err != nil {
 		return nil, err
 	}
@@ -89,32 +109,96 @@ func NewConfig(options ...Option) (*KoanfAdapter, error) {
 
 // Reload reloads the whole configuration stack. It reloads layer by layer, so if
 // an error occurred, Reload will return early and abort the rest of the
-// reloading.
+// reloading. If a dispatcher is set, Reload dispatches events.OnConfigKeyChanged
+// for every changed key, and then events.OnReload.
 func (k *KoanfAdapter) Reload() error {
+	tmp, err := k.load()
+	if err != nil {
+		return err
+	}
+
+	k.rwlock.Lock()
+	old := k.K
+	k.K = tmp
+	k.rwlock.Unlock()
+
+	if k.dispatcher != nil && old != nil {
+		for _, change := range diff(old, tmp) {
+			k.dispatcher.Dispatch(context.Background(), events.OnConfigKeyChanged, change)
+		}
+	}
+
+	if k.dispatcher != nil {
+		k.dispatcher.Dispatch(context.Background(), events.OnReload, events.OnReloadPayload{NewConf: k})
+	}
+
+	return nil
+}
+
+// load loads and validates the whole configuration stack, without applying it.
+func (k *KoanfAdapter) load() (*koanf.Koanf, error) {
 	var tmp = koanf.New(".")
 
+	k.rwlock.RLock()
+	defaults := maps.Copy(k.defaults)
+	k.rwlock.RUnlock()
+	if err := tmp.Load(confmap.Provider(defaults, "."), nil); err != nil {
+		return nil, fmt.Errorf("unable to load defaults %w", err)
+	}
+
 	for i := len(k.layers) - 1; i >= 0; i-- {
-		err := tmp.Load(k.layers[i].Provider, k.layers[i].Parser)
+		m, err := loadLayer(k.layers[i])
 		if err != nil {
-			return fmt.Errorf("unable to load config %w", err)
+			return nil, fmt.Errorf("unable to load config %w", err)
+		}
+		if err := tmp.Load(confmap.Provider(m, ""), nil); err != nil {
+			return nil, fmt.Errorf("unable to load config %w", err)
 		}
 	}
 
+	k.rwlock.RLock()
+	pending := maps.Copy(k.pending)
+	k.rwlock.RUnlock()
+	if err := tmp.Load(confmap.Provider(pending, "."), nil); err != nil {
+		return nil, fmt.Errorf("unable to load runtime changes %w", err)
+	}
+
+	tmp, err := k.transform(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to transform config: %w", err)
+	}
+
 	for _, f := range k.validators {
 		if err := f(tmp.Raw()); err != nil {
-			return fmt.Errorf("validation failed: %w", err)
+			return nil, fmt.Errorf("validation failed: %w", err)
 		}
 	}
+	return tmp, nil
+}
 
+// SetDefault sets the default value of a key path. The default value has the
+// lowest priority, so it only takes effect if no layer in the configuration
+// stack has the key. It is kept across reloads. Modules can set their defaults
+// programmatically in the constructors:
+//
+//	if d, ok := conf.(interface{ SetDefault(string, interface{}) }); ok {
+//		d.SetDefault("foo.timeout", "5s")
+//	}
+func (k *KoanfAdapter) SetDefault(key string, value interface{}) {
 	k.rwlock.Lock()
-	k.K = tmp
-	k.rwlock.Unlock()
+	defer k.rwlock.Unlock()
 
-	if k.dispatcher != nil {
-		k.dispatcher.Dispatch(context.Background(), events.OnReload, events.OnReloadPayload{NewConf: k})
+	if k.defaults == nil {
+		k.defaults = make(map[string]interface{})
 	}
+	k.defaults[key] = value
 
-	return nil
+	tmp := koanf.New(".")
+	_ = tmp.Load(confmap.Provider(map[string]interface{}{key: value}, "."), nil)
+	if k.K != nil {
+		tmp.Merge(k.K)
+	}
+	k.K = tmp
 }
 
 // Watch uses the internal watcher to watch the configuration reload signals.
@@ -135,19 +219,24 @@ func (k *KoanfAdapter) Unmarshal(path string, o interface{}) error {
 	defer k.rwlock.RUnlock()
 
 	return k.K.UnmarshalWithConf(path, o, koanf.UnmarshalConf{
-		Tag: "json",
-		DecoderConfig: &mapstructure.DecoderConfig{
-			Result:           o,
-			ErrorUnused:      true,
-			WeaklyTypedInput: true,
-			DecodeHook: mapstructure.ComposeDecodeHookFunc(
-				mapstructure.StringToTimeDurationHookFunc(),
-				stringToConfigDurationHookFunc(),
-			),
-		},
+		Tag:           "json",
+		DecoderConfig: decoderConfig(o),
 	})
 }
 
+func decoderConfig(o interface{}) *mapstructure.DecoderConfig {
+	return &mapstructure.DecoderConfig{
+		Result:           o,
+		TagName:          "json",
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			stringToConfigDurationHookFunc(),
+		),
+	}
+}
+
 // Route cuts the config map at a given key path into a sub map and returns a new contract.ConfigAccessor instance
 // with the cut config map loaded. For instance, if the loaded config has a path that looks like parent.child.sub.a.b,
 // `Route("parent.child")` returns a new contract.ConfigAccessor instance with the config map `sub.a.b` where
@@ -300,3 +389,27 @@ func stringToConfigDurationHookFunc() mapstructure.DecodeHookFunc {
 		return d, nil
 	}
 }
+
+// diff compares the flattened key paths of two configuration snapshots, and
+// returns the changes sorted by key.
+func diff(old, new *koanf.Koanf) []events.OnConfigKeyChangedPayload {
+	var (
+		before  = old.All()
+		after   = new.All()
+		changes []events.OnConfigKeyChangedPayload
+	)
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			changes = append(changes, events.OnConfigKeyChangedPayload{Key: key, Old: before[key], New: value})
+		}
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, events.OnConfigKeyChangedPayload{Key: key, Old: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
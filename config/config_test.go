@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/DoNewsCode/core/config/watcher"
+	"github.com/DoNewsCode/core/events"
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -248,3 +249,105 @@ func prepareYamlTestSubject(t *gotesting.T) *KoanfAdapter {
 	ka := KoanfAdapter{K: k}
 	return &ka
 }
+
+func TestKoanfAdapter_Reload_keyChanged(t *gotesting.T) {
+	t.Parallel()
+	var changes []events.OnConfigKeyChangedPayload
+	dispatcher := &events.SyncDispatcher{}
+	dispatcher.Subscribe(events.Listen(events.OnConfigKeyChanged, func(ctx context.Context, event interface{}) error {
+		changes = append(changes, event.(events.OnConfigKeyChangedPayload))
+		return nil
+	}))
+
+	provider := &mutableProvider{content: "gorm:\n  default:\n    dsn: foo\n  alt:\n    dsn: bar\nname: app"}
+	conf, err := NewConfig(WithProviderLayer(provider, yaml.Parser()), WithDispatcher(dispatcher))
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	provider.content = "gorm:\n  default:\n    dsn: baz\n  alt:\n    dsn: bar\nenv: local"
+	assert.NoError(t, conf.Reload())
+	assert.Equal(t, []events.OnConfigKeyChangedPayload{
+		{Key: "env", New: "local"},
+		{Key: "gorm.default.dsn", Old: "foo", New: "baz"},
+		{Key: "name", Old: "app"},
+	}, changes)
+}
+
+func TestKoanfAdapter_defaults(t *gotesting.T) {
+	t.Parallel()
+	provider := &mutableProvider{content: "http:\n  addr: :8080"}
+	conf, err := NewConfig(
+		WithProviderLayer(provider, yaml.Parser()),
+		WithDefaults(map[string]interface{}{"http.addr": ":80", "http.disable": true}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, ":8080", conf.String("http.addr"))
+	assert.True(t, conf.Bool("http.disable"))
+
+	conf.SetDefault("grpc.addr", ":9090")
+	conf.SetDefault("http.addr", ":81")
+	assert.Equal(t, ":9090", conf.String("grpc.addr"))
+	assert.Equal(t, ":8080", conf.String("http.addr"))
+
+	provider.content = "grpc:\n  addr: :9091"
+	assert.NoError(t, conf.Reload())
+	assert.Equal(t, ":9091", conf.String("grpc.addr"))
+	assert.Equal(t, ":81", conf.String("http.addr"))
+	assert.True(t, conf.Bool("http.disable"))
+}
+
+func TestKoanfAdapter_SetPersist(t *gotesting.T) {
+	t.Parallel()
+	f, _ := ioutil.TempFile("", "*.yaml")
+	defer os.Remove(f.Name())
+	ioutil.WriteFile(f.Name(), []byte("http:\n  addr: :8080"), 0600)
+
+	var changed []events.OnConfigKeyChangedPayload
+	dispatcher := &events.SyncDispatcher{}
+	dispatcher.Subscribe(events.Listen(events.OnConfigKeyChanged, func(ctx context.Context, payload interface{}) error {
+		changed = append(changed, payload.(events.OnConfigKeyChangedPayload))
+		return nil
+	}))
+	conf, err := NewConfig(
+		WithProviderLayer(File{Path: f.Name()}, yaml.Parser()),
+		WithDispatcher(dispatcher),
+		WithValidators(func(data map[string]interface{}) error {
+			if data["http"].(map[string]interface{})["addr"] == "" {
+				return errors.New("empty addr")
+			}
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, conf.Set("http.addr", ":80"))
+	assert.Equal(t, ":80", conf.String("http.addr"))
+	assert.Len(t, changed, 1)
+	assert.Error(t, conf.Set("http.addr", ""))
+	assert.Equal(t, ":80", conf.String("http.addr"))
+
+	assert.NoError(t, conf.Reload())
+	assert.Equal(t, ":80", conf.String("http.addr"), "changes are kept across reloads")
+	b, _ := ioutil.ReadFile(f.Name())
+	assert.Equal(t, "http:\n  addr: :8080", string(b))
+
+	assert.NoError(t, conf.Persist())
+	b, _ = ioutil.ReadFile(f.Name())
+	assert.Contains(t, string(b), "addr: :80\n")
+	info, _ := os.Stat(f.Name())
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	ioutil.WriteFile(f.Name(), []byte("http:\n  addr: :8081"), 0600)
+	assert.NoError(t, conf.Reload())
+	assert.Equal(t, ":8081", conf.String("http.addr"), "persisted changes no longer override the file")
+}
+
+func TestKoanfAdapter_Persist_noWritableLayer(t *gotesting.T) {
+	t.Parallel()
+	conf, err := NewConfig(WithProviderLayer(&mutableProvider{content: "foo: bar"}, yaml.Parser()))
+	assert.NoError(t, err)
+	assert.NoError(t, conf.Persist())
+	assert.NoError(t, conf.Set("foo", "baz"))
+	assert.Error(t, conf.Persist())
+	assert.Equal(t, "baz", conf.String("foo"))
+}
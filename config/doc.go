@@ -45,6 +45,42 @@
 //
 //  go run main.go config init -o ./config/config.yaml
 //
+// The defaults of selected modules can be exported in another format:
+//
+//  go run main.go config init --owner otgorm --owner otkafka --format json -o ./config/config.json
+//
+// If a remote config store is registered as a Remote, the config file can be
+// compared with it, and promoted to it after confirmation:
+//
+//  go run main.go config diff --against production -t ./config/production.yaml
+//  go run main.go config promote --against production -t ./config/production.yaml
+//
+// A configuration file can include other files with the "_include" directive.
+// The included files are merged in order, and the keys of the including file
+// take precedence. When the file is loaded by config.File, eg. by
+// core.WithYamlFile, the paths are relative to the including file:
+//
+//  _include: [logging.yaml, secrets.yaml]
+//  name: app
+//
+// The configuration can be changed at runtime through contract.ConfigWriter,
+// eg. by an admin endpoint. Set overrides the stack in memory, and Persist
+// writes the changes back to the writable layer of the highest priority, such
+// as the config.File or the etcd provider:
+//
+//  if w, ok := conf.(contract.ConfigWriter); ok {
+//    _ = w.Set("log.level", "debug")
+//    _ = w.Persist()
+//  }
+//
+// Organization specific conventions can be applied by transformers, which
+// rewrite the merged configuration before it is validated. RenameKeys and
+// NormalizeKeys cover the common cases:
+//
+//  core.New(core.WithConfigTransformers(
+//    config.RenameKeys(map[string]string{"db.dsn": "gorm.default.dsn"}),
+//  ))
+//
 // Best Practice
 //
 // In general you should not pass contract.ConfigAccessor or config.KoanfAdapter to your services. You should only
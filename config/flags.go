@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/knadh/koanf/maps"
+	"github.com/spf13/pflag"
+)
+
+// Flags is a koanf.Provider that reads the command line flags bound to the
+// configuration keys, eg. "--http.addr=:8080" overrides "http.addr". Only the
+// flags set in the command line are read, so the flags left alone don't
+// override the other layers.
+type Flags struct {
+	FlagSet *pflag.FlagSet
+}
+
+// NewFlags creates a *Flags with an empty flag set.
+func NewFlags() *Flags {
+	return &Flags{FlagSet: pflag.NewFlagSet("config", pflag.ContinueOnError)}
+}
+
+// Bind registers a flag for every leaf key of the flattened configuration,
+// named after the key path. The current value is used as the default, and
+// decides the type of the flag. The keys already bound are skipped.
+func (f *Flags) Bind(flattened map[string]interface{}) {
+	keys := make([]string, 0, len(flattened))
+	for key := range flattened {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if f.FlagSet.Lookup(key) != nil {
+			continue
+		}
+		usage := fmt.Sprintf("overrides the config %s", key)
+		switch v := flattened[key].(type) {
+		case map[string]interface{}:
+			// empty maps are not leaves.
+		case bool:
+			f.FlagSet.Bool(key, v, usage)
+		case int:
+			f.FlagSet.Int64(key, int64(v), usage)
+		case int64:
+			f.FlagSet.Int64(key, v, usage)
+		case float64:
+			f.FlagSet.Float64(key, v, usage)
+		case []interface{}:
+			values := make([]string, 0, len(v))
+			for _, value := range v {
+				values = append(values, fmt.Sprint(value))
+			}
+			f.FlagSet.StringSlice(key, values, usage)
+		case nil:
+			f.FlagSet.String(key, "", usage)
+		default:
+			f.FlagSet.String(key, fmt.Sprint(v), usage)
+		}
+	}
+}
+
+// Changed reports whether any flag is set in the command line.
+func (f *Flags) Changed() bool {
+	changed := false
+	f.FlagSet.VisitAll(func(flag *pflag.Flag) {
+		changed = changed || flag.Changed
+	})
+	return changed
+}
+
+// Read returns the flags set in the command line as a nested map.
+func (f *Flags) Read() (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	// the flags may be parsed by another flag set they are added to, eg. the
+	// one of a cobra command, so Changed is checked instead of Visit.
+	f.FlagSet.VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed {
+			return
+		}
+		switch flag.Value.Type() {
+		case "bool":
+			m[flag.Name], _ = f.FlagSet.GetBool(flag.Name)
+		case "int64":
+			m[flag.Name], _ = f.FlagSet.GetInt64(flag.Name)
+		case "float64":
+			m[flag.Name], _ = f.FlagSet.GetFloat64(flag.Name)
+		case "stringSlice":
+			values, _ := f.FlagSet.GetStringSlice(flag.Name)
+			list := make([]interface{}, 0, len(values))
+			for _, value := range values {
+				list = append(list, value)
+			}
+			m[flag.Name] = list
+		default:
+			m[flag.Name] = flag.Value.String()
+		}
+	})
+	return maps.Unflatten(m, "."), nil
+}
+
+// ReadBytes is not supported by the flags provider.
+func (f *Flags) ReadBytes() ([]byte, error) {
+	return nil, errors.New("flags provider does not support this method")
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	gotesting "testing"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags(t *gotesting.T) {
+	t.Parallel()
+	flags := NewFlags()
+	conf, err := NewConfig(
+		WithProviderLayer(flags, nil),
+		WithProviderLayer(confmap.Provider(map[string]interface{}{
+			"http.addr":     ":8080",
+			"http.disable":  false,
+			"gorm.default":  map[string]interface{}{"maxIdle": 2},
+			"kafka.brokers": []interface{}{"127.0.0.1:9092"},
+			"ratio":         0.5,
+			"empty":         map[string]interface{}{},
+		}, "."), nil),
+	)
+	assert.NoError(t, err)
+	flags.Bind(conf.K.All())
+	assert.NotNil(t, flags.FlagSet.Lookup("http.addr"))
+	assert.NotNil(t, flags.FlagSet.Lookup("gorm.default.maxIdle"))
+	assert.Nil(t, flags.FlagSet.Lookup("empty"))
+	assert.False(t, flags.Changed())
+
+	err = flags.FlagSet.Parse([]string{"--http.disable", "--gorm.default.maxIdle=5", "--kafka.brokers=a:1,b:2", "--ratio=0.1"})
+	assert.NoError(t, err)
+	assert.True(t, flags.Changed())
+	assert.NoError(t, conf.Reload())
+	assert.Equal(t, ":8080", conf.String("http.addr"))
+	assert.True(t, conf.Bool("http.disable"))
+	assert.Equal(t, 5, conf.Int("gorm.default.maxIdle"))
+	assert.Equal(t, []string{"a:1", "b:2"}, conf.Strings("kafka.brokers"))
+	assert.Equal(t, 0.1, conf.Float64("ratio"))
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/knadh/koanf/maps"
+)
+
+// Glob is a koanf.Provider that reads every file matching a pattern, eg.
+// "conf.d/*.yaml", and deep merges them in lexical order, so the later files
+// override the earlier ones. It allows a large configuration to be split into
+// files per concern.
+type Glob struct {
+	// Pattern is the pattern of filepath.Glob.
+	Pattern string
+	// Codec decodes each file.
+	Codec contract.Codec
+}
+
+// ReadBytes is not supported by Glob, as the files are merged after decoding.
+func (g Glob) ReadBytes() ([]byte, error) {
+	return nil, errors.New("glob provider does not support this method")
+}
+
+// Read decodes and merges the matching files. No matching file results in an
+// empty configuration.
+func (g Glob) Read() (map[string]interface{}, error) {
+	paths, err := filepath.Glob(g.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{})
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		m, err := CodecParser{Codec: g.Codec}.Unmarshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		maps.IntfaceKeysToStrings(m)
+		maps.Merge(m, out)
+	}
+	return out, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	gotesting "testing"
+
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlob(t *gotesting.T) {
+	t.Parallel()
+	dir, _ := ioutil.TempDir("", "conf.d")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "00-base.yaml"), []byte("http:\n  addr: :8080\n  disable: false\nname: app"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "10-http.yaml"), []byte("http:\n  addr: :80"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "ignored.json"), []byte(`{"name": "ignored"}`), 0644)
+
+	conf, err := NewConfig(WithProviderLayer(Glob{Pattern: filepath.Join(dir, "*.yaml"), Codec: yaml.Codec{}}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, ":80", conf.String("http.addr"))
+	assert.False(t, conf.Bool("http.disable"))
+	assert.Equal(t, "app", conf.String("name"))
+
+	ioutil.WriteFile(filepath.Join(dir, "20-broken.yaml"), []byte("{"), 0644)
+	_, err = NewConfig(WithProviderLayer(Glob{Pattern: filepath.Join(dir, "*.yaml"), Codec: yaml.Codec{}}, nil))
+	assert.Error(t, err)
+
+	conf, err = NewConfig(WithProviderLayer(Glob{Pattern: filepath.Join(dir, "*.toml"), Codec: yaml.Codec{}}, nil))
+	assert.NoError(t, err)
+	assert.Empty(t, conf.K.Raw())
+}
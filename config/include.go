@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DoNewsCode/core/codec/json"
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
+)
+
+// includeKey is the directive that includes other files into a configuration,
+// eg. "_include: [logging.yaml, tracing.yaml]".
+const includeKey = "_include"
+
+// File is a koanf.Provider that reads a file. Unlike the file provider of
+// koanf, the "_include" directive in the file is resolved relative to the
+// directory of the file, rather than the working directory.
+type File struct {
+	Path string
+}
+
+// ReadBytes reads the contents of the file.
+func (f File) ReadBytes() ([]byte, error) {
+	return ioutil.ReadFile(filepath.Clean(f.Path))
+}
+
+// WriteBytes replaces the contents of the file, keeping its permission.
+func (f File) WriteBytes(b []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(f.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return ioutil.WriteFile(filepath.Clean(f.Path), b, mode)
+}
+
+// Read is not supported by the file provider.
+func (f File) Read() (map[string]interface{}, error) {
+	return nil, errors.New("file provider does not support this method")
+}
+
+// String describes the provider.
+func (f File) String() string {
+	return "file " + f.Path
+}
+
+// loadLayer reads a layer of the configuration stack, and resolves the
+// "_include" directive in it.
+func loadLayer(layer ProviderSet) (map[string]interface{}, error) {
+	var (
+		m   map[string]interface{}
+		err error
+	)
+	if layer.Parser == nil {
+		m, err = layer.Provider.Read()
+	} else {
+		var b []byte
+		if b, err = layer.Provider.ReadBytes(); err == nil {
+			m, err = layer.Parser.Unmarshal(b)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	maps.IntfaceKeysToStrings(m)
+
+	var (
+		dir      string
+		visiting []string
+	)
+	if f, ok := layer.Provider.(File); ok {
+		dir = filepath.Dir(f.Path)
+		visiting = []string{filepath.Clean(f.Path)}
+	}
+	return include(m, dir, layer.Parser, visiting)
+}
+
+// include merges the files listed in the "_include" directive of the map. The
+// included files are merged in order, and the keys of the map itself take
+// precedence over them. The paths are relative to dir, and the files may
+// include other files in turn.
+func include(m map[string]interface{}, dir string, parser koanf.Parser, visiting []string) (map[string]interface{}, error) {
+	directive, ok := m[includeKey]
+	if !ok {
+		return m, nil
+	}
+	delete(m, includeKey)
+
+	var paths []string
+	switch v := directive.(type) {
+	case string:
+		paths = []string{v}
+	case []interface{}:
+		for _, path := range v {
+			s, ok := path.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s directive: %v", includeKey, directive)
+			}
+			paths = append(paths, s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s directive: %v", includeKey, directive)
+	}
+
+	out := make(map[string]interface{})
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		for _, p := range visiting {
+			if p == path {
+				return nil, fmt.Errorf("circular %s: %s", includeKey, strings.Join(append(visiting, path), " -> "))
+			}
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %s: %w", path, err)
+		}
+		p := parserFor(path, parser)
+		if p == nil {
+			return nil, fmt.Errorf("failed to include %s: unknown file type", path)
+		}
+		included, err := p.Unmarshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %s: %w", path, err)
+		}
+		maps.IntfaceKeysToStrings(included)
+		if included, err = include(included, filepath.Dir(path), parser, append(visiting, path)); err != nil {
+			return nil, err
+		}
+		maps.Merge(included, out)
+	}
+	maps.Merge(m, out)
+	return out, nil
+}
+
+// parserFor chooses the parser by the file extension, and falls back to the
+// parser of the including layer.
+func parserFor(path string, fallback koanf.Parser) koanf.Parser {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return CodecParser{Codec: yaml.Codec{}}
+	case ".json":
+		return CodecParser{Codec: json.NewCodec()}
+	}
+	return fallback
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	gotesting "testing"
+
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInclude(t *gotesting.T) {
+	t.Parallel()
+	dir, _ := ioutil.TempDir("", "include")
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "shared"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("_include: [shared/base.yaml, secrets.json]\nname: app\nhttp:\n  addr: :80"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "shared", "base.yaml"), []byte("_include: log.yaml\nname: base\nhttp:\n  addr: :8080\n  disable: true"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "shared", "log.yaml"), []byte("log:\n  level: debug"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "secrets.json"), []byte(`{"http": {"disable": false}, "password": "secret"}`), 0644)
+
+	conf, err := NewConfig(WithProviderLayer(File{Path: filepath.Join(dir, "app.yaml")}, CodecParser{Codec: yaml.Codec{}}))
+	assert.NoError(t, err)
+	assert.Equal(t, "app", conf.String("name"))
+	assert.Equal(t, ":80", conf.String("http.addr"))
+	assert.False(t, conf.Bool("http.disable"))
+	assert.Equal(t, "secret", conf.String("password"))
+	assert.Equal(t, "debug", conf.String("log.level"))
+	assert.False(t, conf.K.Exists("_include"))
+
+	ioutil.WriteFile(filepath.Join(dir, "shared", "log.yaml"), []byte("_include: ../app.yaml"), 0644)
+	_, err = NewConfig(WithProviderLayer(File{Path: filepath.Join(dir, "app.yaml")}, CodecParser{Codec: yaml.Codec{}}))
+	assert.Error(t, err)
+
+	ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("_include: missing.yaml"), 0644)
+	_, err = NewConfig(WithProviderLayer(File{Path: filepath.Join(dir, "app.yaml")}, CodecParser{Codec: yaml.Codec{}}))
+	assert.Error(t, err)
+}
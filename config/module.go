@@ -6,14 +6,21 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/DoNewsCode/core/codec/json"
+	"github.com/DoNewsCode/core/codec/toml"
 	"github.com/DoNewsCode/core/codec/yaml"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/oklog/run"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 // Module is the configuration module that bundles the reload watcher and exportConfig commands.
@@ -22,6 +29,8 @@ type Module struct {
 	conf            *KoanfAdapter
 	exportedConfigs []ExportedConfig
 	dispatcher      contract.Dispatcher
+	remotes         []Remote
+	logger          log.Logger
 }
 
 // ConfigIn is the injection parameter for config.New.
@@ -31,6 +40,8 @@ type ConfigIn struct {
 	Conf            contract.ConfigAccessor
 	Dispatcher      contract.Dispatcher `optional:"true"`
 	ExportedConfigs []ExportedConfig    `group:"config"`
+	Remotes         []Remote            `group:"configRemote"`
+	Logger          log.Logger          `optional:"true"`
 }
 
 // New creates a new config module. It contains the init command.
@@ -47,10 +58,16 @@ func New(p ConfigIn) (Module, error) {
 		return Module{}, err
 	}
 
+	if p.Logger == nil {
+		p.Logger = log.NewNopLogger()
+	}
+
 	return Module{
 		dispatcher:      p.Dispatcher,
 		conf:            adapter,
 		exportedConfigs: p.ExportedConfigs,
+		remotes:         p.Remotes,
+		logger:          p.Logger,
 	}, nil
 }
 
@@ -69,12 +86,15 @@ func (m Module) ProvideRunGroup(group *run.Group) {
 func (m Module) ProvideCommand(command *cobra.Command) {
 	var (
 		targetFilePath string
-		style          string
+		format         string
+		stack          bool
+		owners         []string
 	)
 	initCmd := &cobra.Command{
 		Use:   "init [module]",
 		Short: "export a copy of default config.",
-		Long:  "export a default config for currently installed modules.",
+		Long: "export a default config for currently installed modules. The modules can be selected by the " +
+			"arguments or by --owner, eg. --owner otgorm --owner otkafka, and the format by --format.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var (
 				handler         handler
@@ -83,10 +103,11 @@ func (m Module) ProvideCommand(command *cobra.Command) {
 				confMap         map[string]interface{}
 				err             error
 			)
-			handler, err = getHandler(style)
+			handler, err = getHandler(format)
 			if err != nil {
 				return err
 			}
+			args = append(args, owners...)
 			if len(args) == 0 {
 				exportedConfigs = m.exportedConfigs
 			}
@@ -128,7 +149,9 @@ func (m Module) ProvideCommand(command *cobra.Command) {
 	verifyCmd := &cobra.Command{
 		Use:   "verify [module]",
 		Short: "verify the config file is correct.",
-		Long:  "verify the config file is correct based on the methods exported by modules.",
+		Long: "verify the config file is correct based on the methods exported by modules, and that the " +
+			"exported config entries unmarshal into the types of their defaults. With --stack, the whole " +
+			"configuration stack is verified instead of the config file, including the validators.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var (
 				handler         handler
@@ -137,7 +160,7 @@ func (m Module) ProvideCommand(command *cobra.Command) {
 				confMap         map[string]interface{}
 				err             error
 			)
-			handler, err = getHandler(style)
+			handler, err = getHandler(format)
 			if err != nil {
 				return err
 			}
@@ -156,33 +179,199 @@ func (m Module) ProvideCommand(command *cobra.Command) {
 				}
 				exportedConfigs = copy
 			}
-			os.MkdirAll(filepath.Dir(targetFilePath), os.ModePerm)
-			targetFile, err = os.OpenFile(targetFilePath,
-				handler.flags(), os.ModePerm)
+			if stack {
+				k, err := m.conf.load()
+				if err != nil {
+					return errors.Wrap(err, "failed to load the configuration stack")
+				}
+				confMap = k.Raw()
+			} else {
+				os.MkdirAll(filepath.Dir(targetFilePath), os.ModePerm)
+				targetFile, err = os.OpenFile(targetFilePath,
+					handler.flags(), os.ModePerm)
+				if err != nil {
+					return errors.Wrap(err, "failed to open config file")
+				}
+				defer targetFile.Close()
+				bytes, err := ioutil.ReadAll(targetFile)
+				if err != nil {
+					return errors.Wrap(err, "failed to read config file")
+				}
+				err = handler.unmarshal(bytes, &confMap)
+				if err != nil {
+					return errors.Wrap(err, "failed to unmarshal config file")
+				}
+			}
+			problems := verify(confMap, exportedConfigs)
+			for _, problem := range problems {
+				fmt.Fprintln(cmd.ErrOrStderr(), problem)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("invalid config: %d problem(s) found, the first is: %s", len(problems), problems[0])
+			}
+			return nil
+		},
+	}
+	verifyCmd.Flags().BoolVar(&stack, "stack", false, "verify the whole configuration stack instead of the config file")
+
+	getCmd := &cobra.Command{
+		Use:   "get key",
+		Short: "get a key from the configuration.",
+		Long:  "get a key from the merged configuration stack, and the configuration layer it comes from.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source, err := m.conf.Source(args[0])
 			if err != nil {
-				return errors.Wrap(err, "failed to open config file")
+				return err
 			}
-			defer targetFile.Close()
-			bytes, err := ioutil.ReadAll(targetFile)
+			bytes, err := yaml.Codec{}.Marshal(m.conf.Get(args[0]))
 			if err != nil {
-				return errors.Wrap(err, "failed to read config file")
+				return err
 			}
-			err = handler.unmarshal(bytes, &confMap)
+			fmt.Fprintf(cmd.OutOrStdout(), "# source: %s\n%s", source, bytes)
+			return nil
+		},
+	}
+
+	var remote bool
+	setCmd := &cobra.Command{
+		Use:   "set key value",
+		Short: "set a key in the config file.",
+		Long: "set a key in the config file. The value is parsed as yaml, eg. 80 is a number. Yaml files are " +
+			"edited in place, keeping the comments and the order of the keys, while json and toml files are " +
+			"rewritten. With --remote, the key is set in the writable configuration layer of the highest " +
+			"priority, such as etcd, instead.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var value interface{}
+			if err := (yaml.Codec{}).Unmarshal([]byte(args[1]), &value); err != nil {
+				return errors.Wrap(err, "failed to parse the value")
+			}
+			if remote {
+				source, err := m.conf.Write(args[0], value)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is set in %s\n", args[0], source)
+				return nil
+			}
+			handler, err := getHandler(format)
 			if err != nil {
-				return errors.Wrap(err, "failed to unmarshal config file")
+				return err
+			}
+			bytes, err := ioutil.ReadFile(targetFilePath)
+			if err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, "failed to read config file")
 			}
-			for _, config := range exportedConfigs {
-				if config.Validate == nil {
-					continue
+			if format == "yaml" {
+				if bytes, err = setYAMLPath(bytes, args[0], value); err != nil {
+					return errors.Wrap(err, "failed to set the key in config file")
 				}
-				if err := config.Validate(confMap); err != nil {
-					return errors.Wrap(err, "invalid config")
+			} else {
+				var confMap map[string]interface{}
+				if err := handler.unmarshal(bytes, &confMap); err != nil {
+					return errors.Wrap(err, "failed to unmarshal config file")
 				}
+				if confMap == nil {
+					confMap = make(map[string]interface{})
+				}
+				setPath(confMap, args[0], value)
+				if bytes, err = handler.marshal(confMap); err != nil {
+					return errors.Wrap(err, "failed to marshal config file")
+				}
+			}
+			os.MkdirAll(filepath.Dir(targetFilePath), os.ModePerm)
+			if err := ioutil.WriteFile(targetFilePath, bytes, 0644); err != nil {
+				return errors.Wrap(err, "failed to write config file")
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is set in %s\n", args[0], targetFilePath)
+			return nil
+		},
+	}
+	setCmd.Flags().BoolVar(&remote, "remote", false, "set the key in the writable configuration layer instead of the config file")
+
+	var (
+		against string
+		yes     bool
+	)
+	readLocal := func() ([]byte, handler, error) {
+		h, err := getHandler(format)
+		if err != nil {
+			return nil, nil, err
+		}
+		local, err := ioutil.ReadFile(targetFilePath)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read config file")
+		}
+		return local, h, nil
+	}
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "compare the config file with a remote.",
+		Long:  "compare the config file with the content of a remote config store, showing the changes a promotion would make.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remote, err := m.findRemote(against)
+			if err != nil {
+				return err
 			}
+			local, h, err := readLocal()
+			if err != nil {
+				return err
+			}
+			changes, err := diffRemote(remote, local, h)
+			if err != nil {
+				return err
+			}
+			printChanges(cmd.OutOrStdout(), changes)
 			return nil
 		},
 	}
 
+	promoteCmd := &cobra.Command{
+		Use:   "promote",
+		Short: "push the config file to a remote.",
+		Long:  "push the config file to a remote config store after confirmation. Every promotion is logged for audit.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remote, err := m.findRemote(against)
+			if err != nil {
+				return err
+			}
+			local, h, err := readLocal()
+			if err != nil {
+				return err
+			}
+			changes, err := diffRemote(remote, local, h)
+			if err != nil {
+				return err
+			}
+			printChanges(cmd.OutOrStdout(), changes)
+			if len(changes) == 0 {
+				return nil
+			}
+			if !yes && !confirm(cmd.InOrStdin(), cmd.OutOrStdout(), fmt.Sprintf("promote %d changes to %s?", len(changes), remote.Name)) {
+				return errors.New("promotion cancelled")
+			}
+
+			var keys []string
+			for _, change := range changes {
+				keys = append(keys, change.Key)
+			}
+			logger := log.With(m.logger, "tag", "audit", "remote", remote.Name, "operator", operator(), "file", targetFilePath, "checksum", checksum(local), "keys", strings.Join(keys, ","))
+			if err := remote.Store.WriteBytes(local); err != nil {
+				level.Error(logger).Log("msg", "config promotion failed", "err", err)
+				return errors.Wrapf(err, "failed to promote to %s", remote.Name)
+			}
+			level.Info(logger).Log("msg", "config promoted")
+			return nil
+		},
+	}
+	promoteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip the confirmation")
+	for _, c := range []*cobra.Command{diffCmd, promoteCmd} {
+		c.Flags().StringVar(&against, "against", "", "the name of the remote")
+		c.MarkFlagRequired("against")
+	}
+
 	configCmd := &cobra.Command{
 		Use:   "config",
 		Short: "manage configuration",
@@ -202,18 +391,65 @@ func (m Module) ProvideCommand(command *cobra.Command) {
 		"./config/config.yaml",
 		"The targeted config file",
 	)
+	configCmd.PersistentFlags().StringVar(
+		&format,
+		"format",
+		"yaml",
+		"The output file format, one of yaml, json and toml",
+	)
 	configCmd.PersistentFlags().StringVarP(
-		&style,
+		&format,
 		"style",
 		"s",
 		"yaml",
-		"The output file style",
+		"The output file format, one of yaml, json and toml",
+	)
+	configCmd.PersistentFlags().MarkDeprecated("style", "use --format instead")
+	initCmd.Flags().StringSliceVar(
+		&owners,
+		"owner",
+		nil,
+		"Only export the config of the owner, eg. otgorm. Repeat to export several owners",
 	)
 	configCmd.AddCommand(initCmd)
 	configCmd.AddCommand(verifyCmd)
+	configCmd.AddCommand(getCmd)
+	configCmd.AddCommand(setCmd)
+	configCmd.AddCommand(diffCmd)
+	configCmd.AddCommand(promoteCmd)
 	command.AddCommand(configCmd)
 }
 
+// verify checks the configuration against the validators of the exported
+// configs, and that the entries unmarshal into the types of their defaults. It
+// returns all the problems found.
+func verify(confMap map[string]interface{}, exportedConfigs []ExportedConfig) []string {
+	var problems []string
+	conf := MapAdapter(confMap)
+	for _, config := range exportedConfigs {
+		if config.Validate != nil {
+			if err := config.Validate(confMap); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", config.Owner, err))
+			}
+		}
+		keys := make([]string, 0, len(config.Data))
+		for key := range config.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if config.Data[key] == nil {
+				continue
+			}
+			target := reflect.New(reflect.TypeOf(config.Data[key]))
+			if err := conf.Unmarshal(key, target.Interface()); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s: %s", config.Owner, key, err))
+			}
+		}
+	}
+	return problems
+}
+
 func loadValidators(k *KoanfAdapter, exportedConfigs []ExportedConfig) error {
 	for _, config := range exportedConfigs {
 		if config.Validate == nil {
@@ -229,12 +465,54 @@ func loadValidators(k *KoanfAdapter, exportedConfigs []ExportedConfig) error {
 	return nil
 }
 
+// setYAMLPath sets the value at the path in the yaml document. The node tree is
+// edited in place, so that the comments and the order of the keys are kept.
+func setYAMLPath(data []byte, path string, value interface{}) ([]byte, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc = yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}}}
+	}
+	node := doc.Content[0]
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if node.Kind != yamlv3.MappingNode {
+			*node = yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map", HeadComment: node.HeadComment, LineComment: node.LineComment, FootComment: node.FootComment}
+		}
+		var next *yamlv3.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				next = node.Content[j+1]
+				break
+			}
+		}
+		if next == nil {
+			next = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, next)
+		}
+		if i == len(keys)-1 {
+			var replacement yamlv3.Node
+			if err := replacement.Encode(value); err != nil {
+				return nil, err
+			}
+			replacement.HeadComment, replacement.LineComment, replacement.FootComment = next.HeadComment, next.LineComment, next.FootComment
+			*next = replacement
+		}
+		node = next
+	}
+	return yamlv3.Marshal(&doc)
+}
+
 func getHandler(style string) (handler, error) {
 	switch style {
 	case "json":
 		return rewriteHandler{codec: json.NewCodec(json.WithIndent("  "))}, nil
 	case "yaml":
 		return appendHandler{codec: yaml.Codec{}}, nil
+	case "toml":
+		return rewriteHandler{codec: toml.Codec{}}, nil
 	default:
 		return nil, fmt.Errorf("unsupported config style %s", style)
 	}
@@ -243,6 +521,7 @@ func getHandler(style string) (handler, error) {
 type handler interface {
 	flags() int
 	unmarshal(bytes []byte, o interface{}) error
+	marshal(o interface{}) ([]byte, error)
 	write(file *os.File, configs []ExportedConfig, confMap map[string]interface{}) error
 }
 
@@ -258,6 +537,10 @@ func (y appendHandler) unmarshal(bytes []byte, o interface{}) error {
 	return y.codec.Unmarshal(bytes, o)
 }
 
+func (y appendHandler) marshal(o interface{}) ([]byte, error) {
+	return y.codec.Marshal(o)
+}
+
 func (y appendHandler) write(file *os.File, configs []ExportedConfig, confMap map[string]interface{}) error {
 out:
 	for i, config := range configs {
@@ -297,11 +580,19 @@ func (r rewriteHandler) flags() int {
 
 func (r rewriteHandler) unmarshal(bytes []byte, o interface{}) error {
 	if len(bytes) == 0 {
-		bytes = []byte("{}")
+		return nil
 	}
 	return r.codec.Unmarshal(bytes, o)
 }
 
+func (r rewriteHandler) marshal(o interface{}) ([]byte, error) {
+	data, err := r.codec.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
 func (r rewriteHandler) write(file *os.File, configs []ExportedConfig, confMap map[string]interface{}) error {
 	if confMap == nil {
 		confMap = make(map[string]interface{})
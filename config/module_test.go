@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/DoNewsCode/core/codec/yaml"
 	"github.com/DoNewsCode/core/events"
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/oklog/run"
@@ -56,6 +57,7 @@ func setup() *cobra.Command {
 func tearDown() {
 	os.Remove("./testdata/module_test.yaml")
 	os.Remove("./testdata/module_test.json")
+	os.Remove("./testdata/module_test.toml")
 	ioutil.WriteFile("./testdata/module_test_partial.json", []byte("{\n  \"foo\": \"bar\"\n}"), os.ModePerm)
 	ioutil.WriteFile("./testdata/module_test_partial.yaml", []byte("# A mock config\nfoo: bar\n"), os.ModePerm)
 }
@@ -93,6 +95,18 @@ func TestModule_ProvideCommand_initCmd(t *testing.T) {
 			[]string{"config", "init", "--outputFile", "./testdata/module_test.json", "--style", "json"},
 			"./testdata/module_test_expected.json",
 		},
+		{
+			"foo json by owner",
+			"./testdata/module_test.json",
+			[]string{"config", "init", "--owner", "foo", "--outputFile", "./testdata/module_test.json", "--format", "json"},
+			"./testdata/module_test_foo_expected.json",
+		},
+		{
+			"old toml",
+			"./testdata/module_test.toml",
+			[]string{"config", "init", "--outputFile", "./testdata/module_test.toml", "--style", "toml"},
+			"./testdata/module_test_expected.toml",
+		},
 		{
 			"partial json",
 			"./testdata/module_test_partial.json",
@@ -169,6 +183,55 @@ func TestModule_ProvideCommand_verifyCmd(t *testing.T) {
 	}
 }
 
+func TestModule_ProvideCommand_verifyCmd_stack(t *testing.T) {
+	cases := []struct {
+		name  string
+		conf  map[string]interface{}
+		isErr bool
+	}{
+		{"good config", map[string]interface{}{"foo": "bar", "server": map[string]interface{}{"port": 80}}, false},
+		{"wrong type", map[string]interface{}{"foo": "bar", "server": map[string]interface{}{"port": "eighty"}}, true},
+		{"unknown key", map[string]interface{}{"foo": "bar", "server": map[string]interface{}{"prot": 80}}, true},
+		{"failed validation", map[string]interface{}{"server": map[string]interface{}{"port": 80}}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conf, err := NewConfig(WithProviderLayer(confmap.Provider(c.conf, "."), nil))
+			assert.NoError(t, err)
+			mod := Module{
+				conf: conf,
+				exportedConfigs: []ExportedConfig{
+					{
+						Owner: "foo",
+						Data: map[string]interface{}{
+							"foo": "bar",
+							"server": struct {
+								Port int `json:"port"`
+							}{Port: 8080},
+						},
+						Validate: func(data map[string]interface{}) error {
+							if _, ok := data["foo"]; !ok {
+								return errors.New("bad config")
+							}
+							return nil
+						},
+					},
+				},
+			}
+			rootCmd := &cobra.Command{Use: "root"}
+			rootCmd.SetErr(ioutil.Discard)
+			mod.ProvideCommand(rootCmd)
+			rootCmd.SetArgs([]string{"config", "verify", "--stack"})
+			err = rootCmd.Execute()
+			if c.isErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestModule_Watch(t *testing.T) {
 	t.Run("test without module", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -217,3 +280,83 @@ type MockWatcher struct{}
 func (m *MockWatcher) Watch(ctx context.Context, reload func() error) error {
 	return reload()
 }
+
+type writableProvider struct {
+	bytes []byte
+}
+
+func (w *writableProvider) ReadBytes() ([]byte, error) {
+	return w.bytes, nil
+}
+
+func (w *writableProvider) Read() (map[string]interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (w *writableProvider) WriteBytes(b []byte) error {
+	w.bytes = b
+	return nil
+}
+
+func (w *writableProvider) String() string {
+	return "writable"
+}
+
+func TestModule_ProvideCommand_getSetCmd(t *testing.T) {
+	writable := &writableProvider{bytes: []byte("http:\n  addr: :8080\n")}
+	conf, err := NewConfig(
+		WithProviderLayer(confmap.Provider(map[string]interface{}{"log.level": "info"}, "."), nil),
+		WithProviderLayer(writable, CodecParser{Codec: yaml.Codec{}}),
+	)
+	assert.NoError(t, err)
+	conf.SetDefault("grpc.addr", ":9090")
+	mod := Module{conf: conf}
+
+	run := func(args ...string) (string, error) {
+		rootCmd := &cobra.Command{Use: "root"}
+		mod.ProvideCommand(rootCmd)
+		var out strings.Builder
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("config", "get", "http.addr")
+	assert.NoError(t, err)
+	assert.Equal(t, "# source: writable\n:8080\n", out)
+
+	out, err = run("config", "get", "log")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "# source: layer 0 (*confmap.Confmap)\nlevel: info\n")
+
+	out, err = run("config", "get", "grpc.addr")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "# source: defaults")
+
+	_, err = run("config", "get", "missing")
+	assert.Error(t, err)
+
+	_, err = run("config", "set", "--remote", "http.port", "80")
+	assert.NoError(t, err)
+	assert.NoError(t, conf.Reload())
+	assert.Equal(t, 80, conf.Int("http.port"))
+	assert.Equal(t, ":8080", conf.String("http.addr"))
+
+	defer os.Remove("./testdata/module_test_set.json")
+	_, err = run("config", "set", "foo.bar", "true", "--targetFile", "./testdata/module_test_set.json", "--style", "json")
+	assert.NoError(t, err)
+	_, err = run("config", "set", "foo.baz", "qux", "--targetFile", "./testdata/module_test_set.json", "--style", "json")
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadFile("./testdata/module_test_set.json")
+	assert.Equal(t, "{\n  \"foo\": {\n    \"bar\": true,\n    \"baz\": \"qux\"\n  }\n}\n", string(b))
+
+	defer os.Remove("./testdata/module_test_set.yaml")
+	ioutil.WriteFile("./testdata/module_test_set.yaml", []byte("# The http configuration\nhttp:\n    addr: :8080 # the address\n    disable: false\nname: app\n"), 0644)
+	_, err = run("config", "set", "http.addr", ":80", "--targetFile", "./testdata/module_test_set.yaml")
+	assert.NoError(t, err)
+	_, err = run("config", "set", "log.level", "info", "--targetFile", "./testdata/module_test_set.yaml")
+	assert.NoError(t, err)
+	b, _ = ioutil.ReadFile("./testdata/module_test_set.yaml")
+	assert.Equal(t, "# The http configuration\nhttp:\n    addr: :80 # the address\n    disable: false\nname: app\nlog:\n    level: info\n", string(b))
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf/maps"
+)
+
+// ErrRemoteNotFound is returned by RemoteStore.ReadBytes when the remote
+// configuration doesn't exist yet.
+var ErrRemoteNotFound = errors.New("no such config")
+
+// RemoteStore is a remote configuration store that can be written, such as a
+// key in etcd.
+type RemoteStore interface {
+	ReadBytes() ([]byte, error)
+	BytesWriter
+}
+
+// Remote is a named RemoteStore, which the "config diff" and "config promote"
+// commands compare the local configuration file against. To register one,
+// provide it to the "configRemote" group:
+//
+//	type remoteOut struct {
+//		di.Out
+//
+//		Remote config.Remote `group:"configRemote"`
+//	}
+//
+//	c.Provide(di.Deps{func() remoteOut {
+//		return remoteOut{Remote: config.Remote{Name: "production", Store: etcd.Provider(cfg, "app.yaml")}}
+//	}})
+type Remote struct {
+	Name  string
+	Store RemoteStore
+}
+
+// Change is a changed key between two configurations.
+type Change struct {
+	Key string
+	// Old is nil if the key is added.
+	Old interface{}
+	// New is nil if the key is removed.
+	New interface{}
+}
+
+// String formats the change as a line of diff.
+func (c Change) String() string {
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("+ %s: %v", c.Key, c.New)
+	case c.New == nil:
+		return fmt.Sprintf("- %s: %v", c.Key, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %v -> %v", c.Key, c.Old, c.New)
+	}
+}
+
+// Diff compares the flattened key paths of two configuration maps, and returns
+// the changes sorted by key.
+func Diff(old, new map[string]interface{}) []Change {
+	before, _ := maps.Flatten(old, nil, ".")
+	after, _ := maps.Flatten(new, nil, ".")
+
+	var changes []Change
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			changes = append(changes, Change{Key: key, Old: before[key], New: value})
+		}
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, Change{Key: key, Old: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+func (m Module) findRemote(name string) (Remote, error) {
+	var names []string
+	for _, remote := range m.remotes {
+		if remote.Name == name {
+			return remote, nil
+		}
+		names = append(names, remote.Name)
+	}
+	return Remote{}, fmt.Errorf("no remote named %q, the remotes are: %s", name, strings.Join(names, ", "))
+}
+
+// diffRemote reads the local file and the remote, and returns the changes to
+// be promoted.
+func diffRemote(remote Remote, local []byte, h handler) ([]Change, error) {
+	var localMap, remoteMap map[string]interface{}
+	if err := h.unmarshal(local, &localMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the local config: %w", err)
+	}
+	data, err := remote.Store.ReadBytes()
+	if err != nil && !errors.Is(err, ErrRemoteNotFound) {
+		return nil, fmt.Errorf("failed to read remote %s: %w", remote.Name, err)
+	}
+	// The remote may not be created yet, in which case everything is new.
+	if err == nil {
+		if err := h.unmarshal(data, &remoteMap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the config of remote %s: %w", remote.Name, err)
+		}
+	}
+	return Diff(remoteMap, localMap), nil
+}
+
+func printChanges(out io.Writer, changes []Change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "no changes")
+		return
+	}
+	for _, change := range changes {
+		fmt.Fprintln(out, change)
+	}
+}
+
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N]: ", prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func operator() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "unknown"
+}
+
+func checksum(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
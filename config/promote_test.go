@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStore struct {
+	data []byte
+}
+
+func (m *memoryStore) ReadBytes() ([]byte, error) {
+	if m.data == nil {
+		return nil, fmt.Errorf("%w key: test", ErrRemoteNotFound)
+	}
+	return m.data, nil
+}
+
+func (m *memoryStore) WriteBytes(data []byte) error {
+	m.data = data
+	return nil
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	changes := Diff(
+		map[string]interface{}{"http": map[string]interface{}{"addr": ":80"}, "name": "app"},
+		map[string]interface{}{"http": map[string]interface{}{"addr": ":8080"}, "env": "local"},
+	)
+	assert.Equal(t, []Change{
+		{Key: "env", New: "local"},
+		{Key: "http.addr", Old: ":80", New: ":8080"},
+		{Key: "name", Old: "app"},
+	}, changes)
+	assert.Equal(t, "+ env: local", changes[0].String())
+	assert.Equal(t, "~ http.addr: :80 -> :8080", changes[1].String())
+	assert.Equal(t, "- name: app", changes[2].String())
+}
+
+func TestModule_ProvideCommand_promote(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "promote")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	ioutil.WriteFile(file, []byte("# comment\nname: app\nhttp:\n  addr: :8080\n"), 0644)
+
+	store := &memoryStore{}
+	var audit bytes.Buffer
+	conf, _ := NewConfig()
+	mod := Module{conf: conf, remotes: []Remote{{Name: "production", Store: store}}, logger: log.NewLogfmtLogger(&audit)}
+
+	run := func(stdin string, args ...string) (string, error) {
+		rootCmd := &cobra.Command{Use: "root"}
+		mod.ProvideCommand(rootCmd)
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetIn(strings.NewReader(stdin))
+		rootCmd.SetArgs(append(args, "--targetFile", file))
+		err := rootCmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("", "config", "diff", "--against", "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "+ http.addr: :8080\n+ name: app\n", out)
+
+	_, err = run("", "config", "diff", "--against", "staging")
+	assert.Error(t, err)
+
+	_, err = run("n\n", "config", "promote", "--against", "production")
+	assert.Error(t, err)
+	assert.Nil(t, store.data)
+
+	_, err = run("y\n", "config", "promote", "--against", "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "# comment\nname: app\nhttp:\n  addr: :8080\n", string(store.data))
+	assert.Contains(t, audit.String(), "msg=\"config promoted\"")
+	assert.Contains(t, audit.String(), "keys=http.addr,name")
+
+	out, err = run("", "config", "diff", "--against", "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "no changes\n", out)
+
+	ioutil.WriteFile(file, []byte("name: app\nhttp:\n  addr: :80\n"), 0644)
+	out, err = run("", "config", "promote", "--against", "production", "--yes")
+	assert.NoError(t, err)
+	assert.Equal(t, "~ http.addr: :8080 -> :80\n", out)
+	assert.Equal(t, "name: app\nhttp:\n  addr: :80\n", string(store.data))
+}
@@ -0,0 +1,232 @@
+// Package aws allows the core package to bootstrap its configuration from AWS
+// SSM Parameter Store or Secrets Manager, so that apps deployed on AWS don't
+// need a sidecar to inject the secrets.
+//
+// The parameters or secrets under a path prefix are merged into the
+// configuration, with the rest of the name as the configuration path, eg.
+// "/app/prod/gorm/default/dsn" under "/app/prod/" becomes "gorm.default.dsn".
+// Secrets in JSON objects are merged as nested configuration.
+//
+//	sess := session.Must(session.NewSession())
+//	c := core.New(aws.WithParameterStore(ssm.New(sess), "/app/prod/", aws.WithInterval(time.Minute)))
+//
+// With WithInterval, the provider polls AWS at the interval, and reloads the
+// configuration when anything changes. Otherwise the configuration is only read
+// once.
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core"
+	sdkaws "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/knadh/koanf/maps"
+)
+
+// Provider is a core.ConfProvider and contract.ConfigWatcher implementation to
+// read and poll the configuration from AWS.
+type Provider struct {
+	fetch    func(ctx context.Context) (map[string]interface{}, error)
+	interval time.Duration
+	logger   log.Logger
+
+	mu   sync.Mutex
+	last map[string]interface{}
+}
+
+// ProviderOption changes the behavior of Provider.
+type ProviderOption func(*Provider)
+
+// WithInterval makes Watch poll AWS at the interval.
+func WithInterval(interval time.Duration) ProviderOption {
+	return func(provider *Provider) {
+		provider.interval = interval
+	}
+}
+
+// WithLogger sets the logger for the polling failures, which are retried at
+// the next interval.
+func WithLogger(logger log.Logger) ProviderOption {
+	return func(provider *Provider) {
+		provider.logger = logger
+	}
+}
+
+func newProvider(fetch func(ctx context.Context) (map[string]interface{}, error), options []ProviderOption) *Provider {
+	p := &Provider{fetch: fetch, logger: log.NewNopLogger()}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// ParameterStore creates a *Provider that reads the parameters under the path
+// from SSM Parameter Store recursively. SecureString parameters are decrypted,
+// and StringList parameters are split into lists.
+func ParameterStore(client ssmiface.SSMAPI, path string, options ...ProviderOption) *Provider {
+	return newProvider(func(ctx context.Context) (map[string]interface{}, error) {
+		out := make(map[string]interface{})
+		err := client.GetParametersByPathPagesWithContext(ctx, &ssm.GetParametersByPathInput{
+			Path:           sdkaws.String(path),
+			Recursive:      sdkaws.Bool(true),
+			WithDecryption: sdkaws.Bool(true),
+		}, func(page *ssm.GetParametersByPathOutput, lastPage bool) bool {
+			for _, param := range page.Parameters {
+				value := sdkaws.StringValue(param.Value)
+				if sdkaws.StringValue(param.Type) == ssm.ParameterTypeStringList {
+					out[key(path, sdkaws.StringValue(param.Name))] = strings.Split(value, ",")
+					continue
+				}
+				out[key(path, sdkaws.StringValue(param.Name))] = value
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parameters under %s: %w", path, err)
+		}
+		return maps.Unflatten(out, "."), nil
+	}, options)
+}
+
+// SecretsManager creates a *Provider that reads the secrets whose names start
+// with the prefix from Secrets Manager.
+func SecretsManager(client secretsmanageriface.SecretsManagerAPI, prefix string, options ...ProviderOption) *Provider {
+	return newProvider(func(ctx context.Context) (map[string]interface{}, error) {
+		var names []string
+		err := client.ListSecretsPagesWithContext(ctx, &secretsmanager.ListSecretsInput{
+			Filters: []*secretsmanager.Filter{{
+				Key:    sdkaws.String(secretsmanager.FilterNameStringTypeName),
+				Values: []*string{sdkaws.String(prefix)},
+			}},
+		}, func(page *secretsmanager.ListSecretsOutput, lastPage bool) bool {
+			for _, entry := range page.SecretList {
+				// The name filter matches words rather than prefixes.
+				if name := sdkaws.StringValue(entry.Name); strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets under %s: %w", prefix, err)
+		}
+
+		out := make(map[string]interface{})
+		for _, name := range names {
+			secret, err := client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: sdkaws.String(name)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+			}
+			value := sdkaws.StringValue(secret.SecretString)
+			if secret.SecretString == nil {
+				value = string(secret.SecretBinary)
+			}
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(value), &fields); err == nil {
+				for k, v := range fields {
+					out[join(key(prefix, name), k)] = v
+				}
+				continue
+			}
+			out[key(prefix, name)] = value
+		}
+		return maps.Unflatten(out, "."), nil
+	}, options)
+}
+
+// key converts the name under the prefix to the configuration path.
+func key(prefix, name string) string {
+	name = strings.Trim(strings.TrimPrefix(name, prefix), "/")
+	return strings.ReplaceAll(name, "/", ".")
+}
+
+func join(path, k string) string {
+	if path == "" {
+		return k
+	}
+	return path + "." + k
+}
+
+// WithParameterStore is a two-in-one coreOption. It uses the parameters under
+// the path as the source of configuration, and polls them for hot reloading.
+func WithParameterStore(client ssmiface.SSMAPI, path string, options ...ProviderOption) (core.CoreOption, core.CoreOption) {
+	p := ParameterStore(client, path, options...)
+	return core.WithConfigStack(p, nil), core.WithConfigWatcher(p)
+}
+
+// WithSecretsManager is a two-in-one coreOption. It uses the secrets under the
+// prefix as the source of configuration, and polls them for hot reloading.
+func WithSecretsManager(client secretsmanageriface.SecretsManagerAPI, prefix string, options ...ProviderOption) (core.CoreOption, core.CoreOption) {
+	p := SecretsManager(client, prefix, options...)
+	return core.WithConfigStack(p, nil), core.WithConfigWatcher(p)
+}
+
+// ReadBytes is not supported by the AWS provider.
+func (p *Provider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("aws provider does not support this method")
+}
+
+// Read returns the configuration map. It is read from AWS on the first call,
+// and then refreshed by Watch.
+func (p *Provider) Read() (map[string]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.last == nil {
+		conf, err := p.fetch(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		p.last = conf
+	}
+	return maps.Copy(p.last), nil
+}
+
+// Watch polls AWS at the interval. If the configuration changes, the reload
+// function will be called. note the reload function should reload the whole
+// config stack, so that the other layers keep their precedence. Without
+// WithInterval, Watch blocks until the context is done.
+func (p *Provider) Watch(ctx context.Context, reload func() error) error {
+	if p.interval <= 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			conf, err := p.fetch(ctx)
+			if err != nil {
+				level.Warn(p.logger).Log("msg", "failed to poll aws", "err", err)
+				continue
+			}
+			p.mu.Lock()
+			changed := !reflect.DeepEqual(conf, p.last)
+			p.last = conf
+			p.mu.Unlock()
+			if !changed {
+				continue
+			}
+			if err := reload(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core"
+	sdkaws "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSSM struct {
+	ssmiface.SSMAPI
+
+	mu     sync.Mutex
+	params map[string]string
+	fail   bool
+}
+
+func (f *fakeSSM) set(name, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.params[name] = value
+}
+
+func (f *fakeSSM) GetParametersByPathPagesWithContext(ctx sdkaws.Context, input *ssm.GetParametersByPathInput, fn func(*ssm.GetParametersByPathOutput, bool) bool, opts ...request.Option) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fail {
+		return errors.New("unavailable")
+	}
+	var page ssm.GetParametersByPathOutput
+	for name, value := range f.params {
+		typ := ssm.ParameterTypeString
+		if name == "/app/prod/kafka/brokers" {
+			typ = ssm.ParameterTypeStringList
+		}
+		page.Parameters = append(page.Parameters, &ssm.Parameter{Name: sdkaws.String(name), Value: sdkaws.String(value), Type: sdkaws.String(typ)})
+	}
+	fn(&page, true)
+	return nil
+}
+
+type fakeSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManager) ListSecretsPagesWithContext(ctx sdkaws.Context, input *secretsmanager.ListSecretsInput, fn func(*secretsmanager.ListSecretsOutput, bool) bool, opts ...request.Option) error {
+	var page secretsmanager.ListSecretsOutput
+	for name := range f.secrets {
+		page.SecretList = append(page.SecretList, &secretsmanager.SecretListEntry{Name: sdkaws.String(name)})
+	}
+	fn(&page, true)
+	return nil
+}
+
+func (f *fakeSecretsManager) GetSecretValueWithContext(ctx sdkaws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	return &secretsmanager.GetSecretValueOutput{SecretString: sdkaws.String(f.secrets[*input.SecretId])}, nil
+}
+
+func TestParameterStore(t *testing.T) {
+	client := &fakeSSM{params: map[string]string{
+		"/app/prod/gorm/default/dsn": "root@tcp(db)/app",
+		"/app/prod/kafka/brokers":    "a:9092,b:9092",
+	}}
+	c := core.New(WithParameterStore(client, "/app/prod/"))
+	assert.Equal(t, "root@tcp(db)/app", c.String("gorm.default.dsn"))
+	assert.Equal(t, []string{"a:9092", "b:9092"}, c.Strings("kafka.brokers"))
+}
+
+func TestSecretsManager(t *testing.T) {
+	client := &fakeSecretsManager{secrets: map[string]string{
+		"app/prod/gorm":  `{"default": {"dsn": "root@tcp(db)/app"}}`,
+		"app/prod/token": "secret",
+		"other/token":    "other",
+	}}
+	c := core.New(WithSecretsManager(client, "app/prod/"))
+	assert.Equal(t, "root@tcp(db)/app", c.String("gorm.default.dsn"))
+	assert.Equal(t, "secret", c.String("token"))
+	assert.Equal(t, "", c.String("other.token"))
+}
+
+func TestProvider_Watch(t *testing.T) {
+	client := &fakeSSM{params: map[string]string{"/app/token": "foo"}}
+	p := ParameterStore(client, "/app/", WithInterval(10*time.Millisecond))
+	conf, err := p.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"token": "foo"}, conf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reloaded := make(chan struct{})
+	go p.Watch(ctx, func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+
+	client.mu.Lock()
+	client.fail = true
+	client.mu.Unlock()
+	time.Sleep(30 * time.Millisecond)
+	client.mu.Lock()
+	client.fail = false
+	client.mu.Unlock()
+
+	client.set("/app/token", "bar")
+	select {
+	case <-reloaded:
+	case <-ctx.Done():
+		t.Fatal("not reloaded")
+	}
+	conf, err = p.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"token": "bar"}, conf)
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+
 	"github.com/DoNewsCode/core"
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/contract"
+	"github.com/knadh/koanf/maps"
 	"go.etcd.io/etcd/client/v3"
 )
 
@@ -16,6 +18,9 @@ import (
 type ETCD struct {
 	key          string
 	clientConfig clientv3.Config
+	// prefix mode merges every key under the key, decoded by codec.
+	prefix bool
+	codec  contract.Codec
 }
 
 // Provider create a *ETCD
@@ -26,6 +31,26 @@ func Provider(clientConfig clientv3.Config, key string) *ETCD {
 	}
 }
 
+// PrefixProvider creates a *ETCD that reads every key under the prefix. The
+// value of each key is decoded by the codec, and the results are deep merged
+// in the order of the keys, so the later keys override the earlier ones.
+func PrefixProvider(clientConfig clientv3.Config, prefix string, codec contract.Codec) *ETCD {
+	return &ETCD{
+		key:          prefix,
+		clientConfig: clientConfig,
+		prefix:       true,
+		codec:        codec,
+	}
+}
+
+// WithPrefix is a two-in-one coreOption. It uses every key under the prefix on
+// etcd as the source of configuration, eg. a key per module, and watches the
+// changes of those keys for hot reloading.
+func WithPrefix(cfg clientv3.Config, prefix string, codec contract.Codec) (core.CoreOption, core.CoreOption) {
+	r := PrefixProvider(cfg, prefix, codec)
+	return core.WithConfigStack(r, nil), core.WithConfigWatcher(r)
+}
+
 // WithKey is a two-in-one coreOption. It uses the remote key on etcd as the
 // source of configuration, and watches the change of that key for hot reloading.
 func WithKey(cfg clientv3.Config, key string, codec contract.Codec) (core.CoreOption, core.CoreOption) {
@@ -33,8 +58,12 @@ func WithKey(cfg clientv3.Config, key string, codec contract.Codec) (core.CoreOp
 	return core.WithConfigStack(r, config.CodecParser{Codec: codec}), core.WithConfigWatcher(r)
 }
 
-// ReadBytes reads the contents of a key from etcd and returns the bytes.
+// ReadBytes reads the contents of a key from etcd and returns the bytes. It is
+// not supported in prefix mode.
 func (r *ETCD) ReadBytes() ([]byte, error) {
+	if r.prefix {
+		return nil, errors.New("etcd prefix provider does not support this method")
+	}
 	client, err := clientv3.New(r.clientConfig)
 	if err != nil {
 		return nil, err
@@ -46,18 +75,67 @@ func (r *ETCD) ReadBytes() ([]byte, error) {
 		return nil, err
 	}
 	if resp.Count == 0 {
-		return nil, fmt.Errorf("no such config key: %s", r.key)
+		return nil, fmt.Errorf("%w key: %s", config.ErrRemoteNotFound, r.key)
 	}
 
 	return resp.Kvs[0].Value, nil
 }
 
-// Read is not supported by the remote provider.
+// WriteBytes writes the contents to the key on etcd. It implements
+// config.BytesWriter, so that the config set and promote commands can write
+// to the key. It is not supported in prefix mode.
+func (r *ETCD) WriteBytes(b []byte) error {
+	if r.prefix {
+		return errors.New("etcd prefix provider does not support this method")
+	}
+	client, err := clientv3.New(r.clientConfig)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.Put(context.Background(), r.key, string(b))
+	return err
+}
+
+// String describes the provider.
+func (r *ETCD) String() string {
+	if r.prefix {
+		return "etcd prefix " + r.key
+	}
+	return "etcd key " + r.key
+}
+
+// Read decodes and merges the keys under the prefix in prefix mode. No key
+// results in an empty configuration. It is not supported otherwise.
 func (r *ETCD) Read() (map[string]interface{}, error) {
-	return nil, errors.New("remote provider does not support this method")
+	if !r.prefix {
+		return nil, errors.New("remote provider does not support this method")
+	}
+	client, err := clientv3.New(r.clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), r.key, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{})
+	for _, kv := range resp.Kvs {
+		m, err := config.CodecParser{Codec: r.codec}.Unmarshal(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", kv.Key, err)
+		}
+		maps.IntfaceKeysToStrings(m)
+		maps.Merge(m, out)
+	}
+	return out, nil
 }
 
-// Watch watches the change to the remote key from etcd. If the key is edited or created, the reload function
+// Watch watches the change to the remote key, or any key under the prefix in
+// prefix mode, from etcd. If the key is edited or created, the reload function
 // will be called. note the reload function should not just load the changes made within this key, but rather
 // it should reload the whole config stack. For example, if the flag or env takes precedence over the config
 // key, they should remain to be so after the key changes.
@@ -68,7 +146,11 @@ func (r *ETCD) Watch(ctx context.Context, reload func() error) error {
 	}
 	defer client.Close()
 
-	rch := client.Watch(ctx, r.key)
+	var opts []clientv3.OpOption
+	if r.prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	rch := client.Watch(ctx, r.key, opts...)
 	for {
 		select {
 		case resp := <-rch:
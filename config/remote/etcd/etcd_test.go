@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DoNewsCode/core/codec/yaml"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/client/v3"
 )
@@ -60,6 +61,53 @@ func TestRemote(t *testing.T) {
 	assert.Equal(t, testVal, newVal)
 }
 
+func TestPrefixProvider(t *testing.T) {
+	r := PrefixProvider(clientv3.Config{}, "app/", yaml.Codec{})
+	assert.Equal(t, "etcd prefix app/", r.String())
+	_, err := r.ReadBytes()
+	assert.Error(t, err)
+	assert.Error(t, r.WriteBytes([]byte("name: app")))
+}
+
+func TestPrefix(t *testing.T) {
+	if os.Getenv("ETCD_ADDR") == "" {
+		t.Skip("set ETCD_ADDR to run TestPrefix")
+		return
+	}
+	addrs := strings.Split(os.Getenv("ETCD_ADDR"), ",")
+	cfg := clientv3.Config{
+		Endpoints:   addrs,
+		DialTimeout: 2 * time.Second,
+	}
+
+	assert.NoError(t, put(Provider(cfg, "prefix-test/a"), "name: app\nhttp:\n  addr: :8080"))
+	assert.NoError(t, put(Provider(cfg, "prefix-test/b"), "http:\n  addr: :8081"))
+	r := PrefixProvider(cfg, "prefix-test/", yaml.Codec{})
+
+	m, err := r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": "app",
+		"http": map[string]interface{}{"addr": ":8081"},
+	}, m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ch = make(chan struct{}, 1)
+	go r.Watch(ctx, func() error {
+		ch <- struct{}{}
+		return nil
+	})
+
+	time.Sleep(1 * time.Second)
+	assert.NoError(t, put(Provider(cfg, "prefix-test/c"), "env: testing"))
+	<-ch
+
+	m, err = r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "testing", m["env"])
+}
+
 func TestError(t *testing.T) {
 	if os.Getenv("ETCD_ADDR") == "" {
 		t.Skip("set ETCD_ADDR to run TestError")
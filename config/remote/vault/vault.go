@@ -0,0 +1,310 @@
+// Package vault allows the core package to merge secrets from HashiCorp Vault
+// into its configuration.
+//
+// Both static secrets of the KV secrets engine and dynamic secrets with leases,
+// such as the database credentials, are supported. The secrets are read once
+// and cached, so that reloading the configuration for other reasons doesn't
+// generate new credentials. In the background, the leases are renewed before
+// they expire. When a lease can no longer be renewed, or a KV secret has
+// changed, the secret is read again and the configuration is reloaded, which
+// dispatches events.OnReload.
+//
+//	c := core.New(vault.WithSecrets(vault.Option{
+//		Addr:  "https://vault:8200",
+//		Token: os.Getenv("VAULT_TOKEN"),
+//		Secrets: []vault.Secret{
+//			{Path: "secret/data/app", Key: "app"},
+//			{Path: "database/creds/readonly", Key: "gorm.default"},
+//		},
+//	}))
+//
+// The secrets are then available in the configuration, eg.
+// "gorm.default.username" and "gorm.default.password".
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/knadh/koanf/maps"
+)
+
+// Option is the configuration of the Vault provider.
+type Option struct {
+	// Addr is the Vault address, eg. "https://vault:8200".
+	Addr string
+	// Token is the Vault token.
+	Token string
+	// Secrets are the secrets merged into the configuration.
+	Secrets []Secret
+	// PollInterval is the interval to check the KV secrets for changes.
+	// Defaults to 1 minute.
+	PollInterval time.Duration
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client contract.HttpDoer
+	// Logger logs the failures to refresh the secrets, which are retried.
+	// Defaults to a no-op logger.
+	Logger log.Logger
+}
+
+// Secret is a secret merged into the configuration.
+type Secret struct {
+	// Path is the API path of the secret, eg. "secret/data/app" for KV version
+	// 2, or "database/creds/readonly" for dynamic database credentials.
+	Path string
+	// Key is the configuration path the fields of the secret are merged at,
+	// eg. "gorm.default". If empty, the fields are merged at the root.
+	Key string
+}
+
+// lease is the state of a secret read from Vault.
+type lease struct {
+	id        string
+	duration  time.Duration
+	renewable bool
+	// renewed is the time of the read or the last renewal.
+	renewed time.Time
+	data    map[string]interface{}
+}
+
+// expiring reports whether the lease is in the last third of its duration.
+func (l lease) expiring(now time.Time) bool {
+	return l.id != "" && now.Sub(l.renewed) >= l.duration*2/3
+}
+
+// Vault is a core.ConfProvider and contract.ConfigWatcher implementation to
+// read secrets from Vault, and renew their leases.
+type Vault struct {
+	option Option
+
+	mu     sync.Mutex
+	leases map[string]lease
+	now    func() time.Time
+}
+
+// Provider creates a *Vault.
+func Provider(option Option) *Vault {
+	if option.PollInterval == 0 {
+		option.PollInterval = time.Minute
+	}
+	if option.Client == nil {
+		option.Client = http.DefaultClient
+	}
+	if option.Logger == nil {
+		option.Logger = log.NewNopLogger()
+	}
+	return &Vault{option: option, leases: make(map[string]lease), now: time.Now}
+}
+
+// WithSecrets is a two-in-one coreOption. It merges the secrets into the
+// configuration, and renews their leases in the background for hot reloading.
+// The Vault watcher is added alongside the existing ones.
+func WithSecrets(option Option) (core.CoreOption, core.CoreOption) {
+	v := Provider(option)
+	return core.WithConfigStack(v, nil), core.WithConfigWatcher(v)
+}
+
+// ReadBytes is not supported by the Vault provider.
+func (v *Vault) ReadBytes() ([]byte, error) {
+	return nil, errors.New("vault provider does not support this method")
+}
+
+// Read returns the secrets as a configuration map. The secrets are read from
+// Vault on the first call, and cached afterwards.
+func (v *Vault) Read() (map[string]interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	out := make(map[string]interface{})
+	for _, secret := range v.option.Secrets {
+		l, ok := v.leases[secret.Path]
+		if !ok {
+			var err error
+			if l, err = v.read(context.Background(), secret.Path); err != nil {
+				return nil, err
+			}
+			v.leases[secret.Path] = l
+		}
+		for k, value := range l.data {
+			if secret.Key != "" {
+				k = secret.Key + "." + k
+			}
+			out[k] = value
+		}
+	}
+	return maps.Unflatten(out, "."), nil
+}
+
+// Watch renews the leases of the secrets before they expire, and polls the
+// secrets without leases for changes. If a secret is rotated, the reload
+// function will be called. note the reload function should reload the whole
+// config stack, so that the other layers keep their precedence.
+func (v *Vault) Watch(ctx context.Context, reload func() error) error {
+	timer := time.NewTimer(v.next())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			rotated, err := v.refresh(ctx)
+			if err != nil {
+				level.Warn(v.option.Logger).Log("msg", "failed to refresh vault secrets", "err", err)
+			}
+			if rotated {
+				if err := reload(); err != nil {
+					return err
+				}
+			}
+			timer.Reset(v.next())
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// next returns the duration until the next lease needs renewing, capped by the
+// poll interval.
+func (v *Vault) next() time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	next := v.option.PollInterval
+	now := v.now()
+	for _, l := range v.leases {
+		if l.id == "" {
+			continue
+		}
+		if d := l.renewed.Add(l.duration * 2 / 3).Sub(now); d < next {
+			next = d
+		}
+	}
+	if next < time.Second {
+		next = time.Second
+	}
+	return next
+}
+
+// refresh renews the expiring leases, and reads the secrets again if needed.
+// It reports whether any secret has changed. The secrets failed to refresh keep
+// their old values, and are retried next time.
+func (v *Vault) refresh(ctx context.Context) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var (
+		rotated bool
+		errs    []string
+	)
+	now := v.now()
+	for _, secret := range v.option.Secrets {
+		l, ok := v.leases[secret.Path]
+		if ok && l.id != "" {
+			if !l.expiring(now) {
+				continue
+			}
+			if l.renewable {
+				renewed, err := v.renew(ctx, l)
+				// The lease is renewed unless it reaches the max TTL.
+				if err == nil && renewed.duration >= l.duration {
+					v.leases[secret.Path] = renewed
+					continue
+				}
+			}
+		}
+		fresh, err := v.read(ctx, secret.Path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if !ok || !reflect.DeepEqual(fresh.data, l.data) {
+			rotated = true
+		}
+		v.leases[secret.Path] = fresh
+	}
+	if len(errs) > 0 {
+		return rotated, errors.New(strings.Join(errs, "; "))
+	}
+	return rotated, nil
+}
+
+type secretResponse struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Errors        []string               `json:"errors"`
+}
+
+func (v *Vault) read(ctx context.Context, path string) (lease, error) {
+	var resp secretResponse
+	if err := v.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return lease{}, fmt.Errorf("failed to read secret %s from vault: %w", path, err)
+	}
+	data := resp.Data
+	// KV version 2 nests the secret in data.data.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	return lease{
+		id:        resp.LeaseID,
+		duration:  time.Duration(resp.LeaseDuration) * time.Second,
+		renewable: resp.Renewable,
+		renewed:   v.now(),
+		data:      data,
+	}, nil
+}
+
+func (v *Vault) renew(ctx context.Context, l lease) (lease, error) {
+	var resp secretResponse
+	err := v.do(ctx, http.MethodPut, "sys/leases/renew", map[string]interface{}{
+		"lease_id":  l.id,
+		"increment": int(l.duration / time.Second),
+	}, &resp)
+	if err != nil {
+		return lease{}, fmt.Errorf("failed to renew lease %s: %w", l.id, err)
+	}
+	l.duration = time.Duration(resp.LeaseDuration) * time.Second
+	l.renewable = resp.Renewable
+	l.renewed = v.now()
+	return l, nil
+}
+
+func (v *Vault) do(ctx context.Context, method, path string, body interface{}, result *secretResponse) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(v.option.Addr, "/"), strings.TrimPrefix(path, "/")), &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.option.Token)
+	resp, err := v.option.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode vault response (%d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault responded %d: %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+	}
+	return nil
+}
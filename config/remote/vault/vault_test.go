@@ -0,0 +1,145 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeVault struct {
+	mu       sync.Mutex
+	password string
+	creds    int
+	maxTTL   bool
+}
+
+func (f *fakeVault) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if request.Header.Get("X-Vault-Token") != "token" {
+		writer.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(writer).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+	var resp map[string]interface{}
+	switch request.URL.Path {
+	case "/v1/secret/data/app":
+		resp = map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     map[string]interface{}{"password": f.password},
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		}
+	case "/v1/database/creds/readonly":
+		f.creds++
+		resp = map[string]interface{}{
+			"lease_id":       "database/creds/readonly/1",
+			"lease_duration": 60,
+			"renewable":      true,
+			"data":           map[string]interface{}{"username": "user" + string(rune('0'+f.creds))},
+		}
+	case "/v1/sys/leases/renew":
+		duration := 60
+		if f.maxTTL {
+			duration = 10
+		}
+		resp = map[string]interface{}{
+			"lease_id":       "database/creds/readonly/1",
+			"lease_duration": duration,
+			"renewable":      true,
+		}
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(writer).Encode(map[string]interface{}{"errors": []string{}})
+		return
+	}
+	json.NewEncoder(writer).Encode(resp)
+}
+
+func TestVault(t *testing.T) {
+	fake := &fakeVault{password: "foo"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	v := Provider(Option{
+		Addr:  server.URL,
+		Token: "token",
+		Secrets: []Secret{
+			{Path: "secret/data/app", Key: "app"},
+			{Path: "database/creds/readonly", Key: "gorm.default"},
+		},
+	})
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	conf, err := v.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"app":  map[string]interface{}{"password": "foo"},
+		"gorm": map[string]interface{}{"default": map[string]interface{}{"username": "user1"}},
+	}, conf)
+	assert.Equal(t, 40*time.Second, v.next())
+
+	// nothing changed
+	rotated, err := v.refresh(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+
+	// the lease is renewed
+	now = now.Add(45 * time.Second)
+	rotated, err = v.refresh(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+	assert.Equal(t, 1, fake.creds)
+
+	// the lease reaches the max TTL, new credentials are read
+	fake.maxTTL = true
+	now = now.Add(45 * time.Second)
+	rotated, err = v.refresh(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+	assert.Equal(t, 2, fake.creds)
+
+	// the KV secret changes
+	fake.password = "bar"
+	rotated, err = v.refresh(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+
+	conf, err = v.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"app":  map[string]interface{}{"password": "bar"},
+		"gorm": map[string]interface{}{"default": map[string]interface{}{"username": "user2"}},
+	}, conf)
+}
+
+func TestWithSecrets(t *testing.T) {
+	server := httptest.NewServer(&fakeVault{password: "foo"})
+	defer server.Close()
+
+	c := core.New(WithSecrets(Option{
+		Addr:    server.URL,
+		Token:   "token",
+		Secrets: []Secret{{Path: "secret/data/app", Key: "app"}},
+	}))
+	assert.Equal(t, "foo", c.String("app.password"))
+}
+
+func TestVault_error(t *testing.T) {
+	server := httptest.NewServer(&fakeVault{})
+	defer server.Close()
+
+	v := Provider(Option{Addr: server.URL, Token: "wrong", Secrets: []Secret{{Path: "secret/data/app"}}})
+	_, err := v.Read()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+}
@@ -0,0 +1,170 @@
+// Package zookeeper allows the core package to bootstrap its configuration from a ZooKeeper node.
+package zookeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core"
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-zookeeper/zk"
+)
+
+// Option is the connection configuration of ZooKeeper.
+type Option struct {
+	// Servers are the addresses of the ensemble, eg. "zk1:2181".
+	Servers []string `json:"servers" yaml:"servers"`
+	// SessionTimeout defaults to 10 seconds.
+	SessionTimeout config.Duration `json:"sessionTimeout" yaml:"sessionTimeout"`
+	// Auth are the credentials added to the session.
+	Auth []Auth `json:"auth" yaml:"auth"`
+}
+
+// Auth is a credential of ZooKeeper.
+type Auth struct {
+	// Scheme is the auth scheme, eg. "digest".
+	Scheme string `json:"scheme" yaml:"scheme"`
+	// Credential is scheme specific, eg. "user:password" for digest.
+	Credential string `json:"credential" yaml:"credential"`
+}
+
+// ReadOption reads the Option at "zookeeper" from the bootstrap configuration,
+// such as the one loaded from a local file or the environment, in the
+// following format:
+//
+//	zookeeper:
+//	  servers:
+//	    - zk1:2181
+//	  sessionTimeout: 10s
+//	  auth:
+//	    - scheme: digest
+//	      credential: user:password
+func ReadOption(bootstrap contract.ConfigAccessor) (Option, error) {
+	var option Option
+	if err := bootstrap.Unmarshal("zookeeper", &option); err != nil {
+		return Option{}, fmt.Errorf("zookeeper configuration error: %w", err)
+	}
+	if len(option.Servers) == 0 {
+		return Option{}, errors.New("zookeeper configuration error: no servers")
+	}
+	return option, nil
+}
+
+// ZooKeeper is a core.ConfProvider and contract.ConfigWatcher implementation to read and watch a remote config node.
+type ZooKeeper struct {
+	path   string
+	option Option
+}
+
+// Provider create a *ZooKeeper
+func Provider(option Option, path string) *ZooKeeper {
+	if option.SessionTimeout.Duration == 0 {
+		option.SessionTimeout = config.Duration{Duration: 10 * time.Second}
+	}
+	return &ZooKeeper{
+		path:   path,
+		option: option,
+	}
+}
+
+// WithPath is a two-in-one coreOption. It uses the remote node on ZooKeeper as
+// the source of configuration, and watches the change of that node for hot
+// reloading.
+func WithPath(option Option, path string, codec contract.Codec) (core.CoreOption, core.CoreOption) {
+	r := Provider(option, path)
+	return core.WithConfigStack(r, config.CodecParser{Codec: codec}), core.WithConfigWatcher(r)
+}
+
+func (r *ZooKeeper) connect() (*zk.Conn, error) {
+	conn, _, err := zk.Connect(r.option.Servers, r.option.SessionTimeout.Duration, zk.WithLogInfo(false))
+	if err != nil {
+		return nil, err
+	}
+	for _, auth := range r.option.Auth {
+		if err := conn.AddAuth(auth.Scheme, []byte(auth.Credential)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to add %s auth: %w", auth.Scheme, err)
+		}
+	}
+	return conn, nil
+}
+
+// ReadBytes reads the contents of a node from ZooKeeper and returns the bytes.
+func (r *ZooKeeper) ReadBytes() ([]byte, error) {
+	conn, err := r.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	data, _, err := conn.Get(r.path)
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil, fmt.Errorf("%w node: %s", config.ErrRemoteNotFound, r.path)
+	}
+	return data, err
+}
+
+// WriteBytes writes the contents to the node on ZooKeeper, creating it if
+// necessary. It makes *ZooKeeper a config.RemoteStore for the "config promote"
+// command.
+func (r *ZooKeeper) WriteBytes(data []byte) error {
+	conn, err := r.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Set(r.path, data, -1)
+	if errors.Is(err, zk.ErrNoNode) {
+		_, err = conn.Create(r.path, data, 0, zk.WorldACL(zk.PermAll))
+	}
+	return err
+}
+
+// Read is not supported by the remote provider.
+func (r *ZooKeeper) Read() (map[string]interface{}, error) {
+	return nil, errors.New("remote provider does not support this method")
+}
+
+// Watch watches the change to the remote node from ZooKeeper. If the node is edited or created, the reload function
+// will be called. note the reload function should not just load the changes made within this node, but rather
+// it should reload the whole config stack. For example, if the flag or env takes precedence over the config
+// node, they should remain to be so after the node changes.
+func (r *ZooKeeper) Watch(ctx context.Context, reload func() error) error {
+	conn, err := r.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for {
+		_, _, events, err := conn.GetW(r.path)
+		if errors.Is(err, zk.ErrNoNode) {
+			// Wait for the node to be created.
+			var exists bool
+			exists, _, events, err = conn.ExistsW(r.path)
+			if err == nil && exists {
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case event := <-events:
+			// The watches are one-off, and are set again in the next loop.
+			switch event.Type {
+			case zk.EventNodeDataChanged, zk.EventNodeCreated:
+				if err := reload(); err != nil {
+					return err
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
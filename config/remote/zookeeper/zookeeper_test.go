@@ -0,0 +1,80 @@
+package zookeeper
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-zookeeper/zk"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOption(t *testing.T) {
+	bootstrap, _ := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(`
+zookeeper:
+  servers:
+    - zk1:2181
+  sessionTimeout: 5s
+  auth:
+    - scheme: digest
+      credential: user:password
+`)), yaml.Parser()))
+	option, err := ReadOption(bootstrap)
+	assert.NoError(t, err)
+	assert.Equal(t, Option{
+		Servers:        []string{"zk1:2181"},
+		SessionTimeout: config.Duration{Duration: 5 * time.Second},
+		Auth:           []Auth{{Scheme: "digest", Credential: "user:password"}},
+	}, option)
+
+	empty, _ := config.NewConfig()
+	_, err = ReadOption(empty)
+	assert.Error(t, err)
+}
+
+func TestRemote(t *testing.T) {
+	if os.Getenv("ZOOKEEPER_ADDR") == "" {
+		t.Skip("set ZOOKEEPER_ADDR to run TestRemote")
+		return
+	}
+	r := Provider(Option{Servers: strings.Split(os.Getenv("ZOOKEEPER_ADDR"), ",")}, "/core-test-config")
+
+	conn, err := r.connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Delete(r.path, -1)
+
+	_, err = r.ReadBytes()
+	assert.Error(t, err)
+
+	var ch = make(chan string)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, func() error {
+		bytes, err := r.ReadBytes()
+		if err != nil {
+			ch <- ""
+			return err
+		}
+		ch <- string(bytes)
+		return nil
+	})
+	time.Sleep(time.Second)
+
+	_, err = conn.Create(r.path, []byte("name: app"), 0, zk.WorldACL(zk.PermAll))
+	assert.NoError(t, err)
+	assert.Equal(t, "name: app", <-ch)
+
+	_, err = conn.Set(r.path, []byte("name: changed"), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "name: changed", <-ch)
+
+	conn.Delete(r.path, -1)
+}
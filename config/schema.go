@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// SchemaValidator creates a Validator that validates the whole configuration
+// against a JSON Schema (https://json-schema.org). The returned error lists the
+// key path of every failing value, eg. "http.addr: Invalid type. Expected:
+// string, given: integer".
+//
+//	validator, err := config.SchemaValidator([]byte(`{
+//		"type": "object",
+//		"properties": {
+//			"http": {
+//				"type": "object",
+//				"properties": {"addr": {"type": "string"}},
+//				"required": ["addr"]
+//			}
+//		}
+//	}`))
+//
+// The validator can be passed to WithValidators, or set as the Validate field
+// of ExportedConfig. Either way the configuration is validated on startup and
+// on every reload, and a reload failing the validation is not applied.
+func SchemaValidator(schema []byte) (Validator, error) {
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+	return func(data map[string]interface{}) error {
+		result, err := compiled.Validate(gojsonschema.NewGoLoader(data))
+		if err != nil {
+			return err
+		}
+		if result.Valid() {
+			return nil
+		}
+		var failures []string
+		for _, e := range result.Errors() {
+			failures = append(failures, fmt.Sprintf("%s: %s", e.Field(), e.Description()))
+		}
+		return errors.New(strings.Join(failures, "; "))
+	}, nil
+}
+
+// StructValidator creates a Validator that decodes the configuration at the key
+// path into a value of the prototype's type, the same way Unmarshal does, so
+// that unknown keys and mismatched types are reported. The struct fields can be
+// further constrained by the "validate" tag:
+//
+//	type Option struct {
+//		Addr  string `json:"addr" validate:"required"`
+//		Level string `json:"level" validate:"oneof=debug info warn error"`
+//	}
+//
+// The returned error contains the key path of the failing field, eg.
+// "log.level: must be one of debug info warn error, got trace".
+func StructValidator(path string, prototype interface{}) Validator {
+	typ := reflect.TypeOf(prototype)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return func(data map[string]interface{}) error {
+		raw, ok := lookup(data, path)
+		if !ok {
+			return checkFields(typ, path, nil)
+		}
+		target := reflect.New(typ)
+		decoder, err := mapstructure.NewDecoder(decoderConfig(target.Interface()))
+		if err != nil {
+			return err
+		}
+		if err := decoder.Decode(raw); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return checkFields(typ, path, raw)
+	}
+}
+
+func lookup(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	if path == "" {
+		return current, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok || current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// checkFields walks the struct type and checks the validate tags against the
+// raw configuration.
+func checkFields(typ reflect.Type, path string, raw interface{}) error {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		items, _ := raw.([]interface{})
+		for i, item := range items {
+			if err := checkFields(typ.Elem(), join(path, fmt.Sprint(i)), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	m, _ := raw.(map[string]interface{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		value, ok := m[name]
+		if ok && value == nil {
+			ok = false
+		}
+		key := join(path, name)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if err := checkRule(rule, key, value, ok); err != nil {
+				return err
+			}
+		}
+		if ok {
+			if err := checkFields(field.Type, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkRule(rule, key string, value interface{}, ok bool) error {
+	switch {
+	case rule == "required":
+		if !ok {
+			return fmt.Errorf("%s: is required", key)
+		}
+	case strings.HasPrefix(rule, "oneof="):
+		if !ok {
+			return nil
+		}
+		options := strings.Fields(strings.TrimPrefix(rule, "oneof="))
+		for _, option := range options {
+			if fmt.Sprint(value) == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: must be one of %s, got %v", key, strings.Join(options, " "), value)
+	}
+	return nil
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
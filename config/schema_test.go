@@ -0,0 +1,134 @@
+package config
+
+import (
+	gotesting "testing"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidator(t *gotesting.T) {
+	t.Parallel()
+	validator, err := SchemaValidator([]byte(`{
+		"type": "object",
+		"properties": {
+			"http": {
+				"type": "object",
+				"properties": {"addr": {"type": "string"}},
+				"required": ["addr"]
+			}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"valid", "http:\n  addr: :8080", ""},
+		{"wrong type", "http:\n  addr: 8080", "http.addr: Invalid type. Expected: string, given: integer"},
+		{"missing", "http:\n  disable: true", "http: addr is required"},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *gotesting.T) {
+			_, err := NewConfig(
+				WithProviderLayer(rawbytes.Provider([]byte(c.config)), yaml.Parser()),
+				WithValidators(validator),
+			)
+			if c.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), c.err)
+		})
+	}
+
+	_, err = SchemaValidator([]byte(`{"type": 1}`))
+	assert.Error(t, err)
+}
+
+func TestStructValidator(t *gotesting.T) {
+	t.Parallel()
+	type Output struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type Option struct {
+		Level   string   `json:"level" validate:"oneof=debug info warn error"`
+		Addr    string   `json:"addr" validate:"required"`
+		Outputs []Output `json:"outputs"`
+	}
+	validator := StructValidator("log", Option{})
+
+	cases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"valid", "log:\n  addr: :8080\n  level: info", ""},
+		{"missing", "name: app", "log.addr: is required"},
+		{"oneof", "log:\n  addr: :8080\n  level: trace", "log.level: must be one of debug info warn error, got trace"},
+		{"nested", "log:\n  addr: :8080\n  outputs:\n    - name: stdout\n    - {}", "log.outputs.1.name: is required"},
+		{"unknown key", "log:\n  addr: :8080\n  foo: bar", "log: 1 error(s) decoding"},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *gotesting.T) {
+			err := validator(mustRaw(t, c.config))
+			if c.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), c.err)
+		})
+	}
+}
+
+func TestKoanfAdapter_Reload_invalid(t *gotesting.T) {
+	t.Parallel()
+	content := "log:\n  addr: :8080"
+	conf, err := NewConfig(
+		WithValidators(StructValidator("log", struct {
+			Addr string `json:"addr" validate:"required"`
+		}{})),
+	)
+	assert.Error(t, err)
+	assert.Nil(t, conf)
+
+	provider := &mutableProvider{content: content}
+	conf, err = NewConfig(
+		WithProviderLayer(provider, yaml.Parser()),
+		WithValidators(StructValidator("log", struct {
+			Addr string `json:"addr" validate:"required"`
+		}{})),
+	)
+	assert.NoError(t, err)
+
+	provider.content = "log:\n  level: debug"
+	assert.Error(t, conf.Reload())
+	assert.Equal(t, ":8080", conf.String("log.addr"))
+}
+
+type mutableProvider struct {
+	content string
+}
+
+func (m *mutableProvider) ReadBytes() ([]byte, error) {
+	return []byte(m.content), nil
+}
+
+func (m *mutableProvider) Read() (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func mustRaw(t *gotesting.T, config string) map[string]interface{} {
+	conf, err := NewConfig(WithProviderLayer(rawbytes.Provider([]byte(config)), yaml.Parser()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf.K.Raw()
+}
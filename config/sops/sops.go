@@ -0,0 +1,273 @@
+// Package sops decrypts the configuration encrypted with SOPS
+// (https://github.com/mozilla/sops) or age (https://age-encryption.org), so
+// that secrets can be committed to git safely.
+//
+// Two formats are supported. A SOPS document encrypted with age recipients, eg.
+// by "sops --encrypt --age age1... config.yaml", is decrypted as a whole. A
+// single value may also be an armored age ciphertext, eg. the output of
+// "age --armor --recipient age1...":
+//
+//	gorm:
+//	  default:
+//	    dsn: |
+//	      -----BEGIN AGE ENCRYPTED FILE-----
+//	      YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSB...
+//	      -----END AGE ENCRYPTED FILE-----
+//
+// The age identities are loaded from the environment, in the same way as SOPS
+// does: SOPS_AGE_KEY holds the identities, and SOPS_AGE_KEY_FILE points to a
+// file of them.
+//
+//	c := core.New(sops.WithYamlFile("config/app.yaml"))
+//
+// Each SOPS value is authenticated with its key path. The MAC of the whole
+// SOPS document is not verified, as it depends on the order of keys, which is
+// lost once the document is parsed.
+package sops
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/DoNewsCode/core"
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/config/watcher"
+	"github.com/knadh/koanf"
+)
+
+const armorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+var encrypted = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.*),tag:(.*),type:(.*)]$`)
+
+// Parser is a koanf.Parser that decrypts the configuration parsed by the
+// underlying parser.
+type Parser struct {
+	// Parser parses the bytes before decryption, eg. config.CodecParser{Codec: yaml.Codec{}}.
+	Parser koanf.Parser
+	// Identities decrypt the data key of SOPS and the age values. If nil, they
+	// are loaded by IdentitiesFromEnv.
+	Identities []age.Identity
+}
+
+// IdentitiesFromEnv loads the age identities from the SOPS_AGE_KEY and
+// SOPS_AGE_KEY_FILE environment variables.
+func IdentitiesFromEnv() ([]age.Identity, error) {
+	var identities []age.Identity
+	if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+		parsed, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOPS_AGE_KEY: %w", err)
+		}
+		identities = append(identities, parsed...)
+	}
+	if path := os.Getenv("SOPS_AGE_KEY_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOPS_AGE_KEY_FILE: %w", err)
+		}
+		defer f.Close()
+		parsed, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOPS_AGE_KEY_FILE: %w", err)
+		}
+		identities = append(identities, parsed...)
+	}
+	return identities, nil
+}
+
+// Unmarshal parses the bytes with the underlying parser, and decrypts the
+// values in it.
+func (p Parser) Unmarshal(b []byte) (map[string]interface{}, error) {
+	m, err := p.Parser.Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	d := decrypter{identities: p.Identities}
+
+	if metadata, ok := m["sops"]; ok {
+		delete(m, "sops")
+		if d.key, err = d.dataKey(metadata); err != nil {
+			return nil, err
+		}
+	}
+	out, err := d.walk(m, nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]interface{}), nil
+}
+
+// Marshal is not supported, so that the decrypted values are never written
+// back to the encrypted file.
+func (p Parser) Marshal(m map[string]interface{}) ([]byte, error) {
+	return nil, errors.New("sops parser does not support writing encrypted files")
+}
+
+// WithYamlFile is a two-in-one coreOption. Like core.WithYamlFile, it uses the
+// configuration file as the source of configuration and watches it for hot
+// reloading, but decrypts the file with the identities from the environment.
+func WithYamlFile(path string) (core.CoreOption, core.CoreOption) {
+	return core.WithConfigStack(config.File{Path: path}, Parser{Parser: config.CodecParser{Codec: yaml.Codec{}}}),
+		core.WithConfigWatcher(watcher.File{Path: path})
+}
+
+type decrypter struct {
+	identities []age.Identity
+	key        []byte
+}
+
+func (d *decrypter) loadIdentities() error {
+	if d.identities != nil {
+		return nil
+	}
+	identities, err := IdentitiesFromEnv()
+	if err != nil {
+		return err
+	}
+	if len(identities) == 0 {
+		return errors.New("no age identity, set SOPS_AGE_KEY or SOPS_AGE_KEY_FILE")
+	}
+	d.identities = identities
+	return nil
+}
+
+func (d *decrypter) decryptAge(ciphertext string) ([]byte, error) {
+	if err := d.loadIdentities(); err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(ciphertext)), d.identities...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// dataKey decrypts the data key of SOPS with the age recipients in the
+// metadata.
+func (d *decrypter) dataKey(metadata interface{}) ([]byte, error) {
+	meta, ok := stringMap(metadata)
+	if !ok {
+		return nil, errors.New("invalid sops metadata")
+	}
+	recipients, _ := meta["age"].([]interface{})
+	if len(recipients) == 0 {
+		return nil, errors.New("the sops document is not encrypted with age")
+	}
+	var lastErr error
+	for _, recipient := range recipients {
+		r, _ := stringMap(recipient)
+		enc, _ := r["enc"].(string)
+		key, err := d.decryptAge(enc)
+		if err == nil {
+			return key, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to decrypt the sops data key: %w", lastErr)
+}
+
+// walk decrypts the values in the tree. The path is the key path used as the
+// additional data of SOPS, which doesn't include list indexes.
+func (d *decrypter) walk(value interface{}, path []string) (interface{}, error) {
+	if m, ok := stringMap(value); ok {
+		for k, v := range m {
+			decrypted, err := d.walk(v, append(path[:len(path):len(path)], k))
+			if err != nil {
+				return nil, err
+			}
+			m[k] = decrypted
+		}
+		return m, nil
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		for i := range v {
+			decrypted, err := d.walk(v[i], path)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = decrypted
+		}
+		return v, nil
+	case string:
+		if encrypted.MatchString(v) {
+			return d.decryptSops(v, path)
+		}
+		if strings.HasPrefix(strings.TrimSpace(v), armorHeader) {
+			plaintext, err := d.decryptAge(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt %s: %w", strings.Join(path, "."), err)
+			}
+			return string(plaintext), nil
+		}
+	}
+	return value, nil
+}
+
+func (d *decrypter) decryptSops(value string, path []string) (interface{}, error) {
+	if d.key == nil {
+		return nil, fmt.Errorf("failed to decrypt %s: no sops metadata", strings.Join(path, "."))
+	}
+	matches := encrypted.FindStringSubmatch(value)
+	var parts [3][]byte
+	for i := range parts {
+		decoded, err := base64.StdEncoding.DecodeString(matches[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", strings.Join(path, "."), err)
+		}
+		parts[i] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(d.key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(strings.Join(path, ":")+":"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", strings.Join(path, "."), err)
+	}
+
+	switch typ := matches[4]; typ {
+	case "str", "bytes":
+		return string(plaintext), nil
+	case "int":
+		return strconv.Atoi(string(plaintext))
+	case "float":
+		return strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		return strconv.ParseBool(string(plaintext))
+	default:
+		return nil, fmt.Errorf("failed to decrypt %s: unknown type %s", strings.Join(path, "."), typ)
+	}
+}
+
+// stringMap converts the maps decoded by yaml to map[string]interface{}.
+func stringMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			out[fmt.Sprint(k)] = v
+		}
+		return out, true
+	}
+	return nil, false
+}
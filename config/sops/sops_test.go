@@ -0,0 +1,137 @@
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/DoNewsCode/core"
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/DoNewsCode/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptAge(t *testing.T, recipient age.Recipient, plaintext []byte) string {
+	var buf bytes.Buffer
+	a := armor.NewWriter(&buf)
+	w, err := age.Encrypt(a, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(plaintext)
+	w.Close()
+	a.Close()
+	return buf.String()
+}
+
+// encryptSops encrypts a value the same way as SOPS does.
+func encryptSops(t *testing.T, key []byte, value, typ, path string) string {
+	block, _ := aes.NewCipher(key)
+	iv := make([]byte, 32)
+	rand.Read(iv)
+	gcm, _ := cipher.NewGCMWithNonceSize(block, len(iv))
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(path))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		typ,
+	)
+}
+
+func indent(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n    ")
+}
+
+func sopsDocument(t *testing.T, identity *age.X25519Identity) []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return []byte(fmt.Sprintf(`
+gorm:
+  default:
+    dsn: %s
+    port: %s
+hosts:
+  - %s
+name: app
+sops:
+  age:
+    - recipient: %s
+      enc: |
+        %s
+  lastmodified: "2021-06-01T00:00:00Z"
+  version: 3.7.1
+`,
+		encryptSops(t, key, "root@tcp(db)/app", "str", "gorm:default:dsn:"),
+		encryptSops(t, key, "3306", "int", "gorm:default:port:"),
+		encryptSops(t, key, "db1", "str", "hosts:"),
+		identity.Recipient(),
+		strings.ReplaceAll(strings.TrimSpace(encryptAge(t, identity.Recipient(), key)), "\n", "\n        "),
+	))
+}
+
+func TestParser_sops(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	parser := Parser{Parser: config.CodecParser{Codec: yaml.Codec{}}, Identities: []age.Identity{identity}}
+
+	m, err := parser.Unmarshal(sopsDocument(t, identity))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"gorm": map[string]interface{}{
+			"default": map[string]interface{}{"dsn": "root@tcp(db)/app", "port": 3306},
+		},
+		"hosts": []interface{}{"db1"},
+		"name":  "app",
+	}, m)
+
+	other, _ := age.GenerateX25519Identity()
+	_, err = Parser{Parser: parser.Parser, Identities: []age.Identity{other}}.Unmarshal(sopsDocument(t, identity))
+	assert.Error(t, err)
+
+	// The value is bound to its key path.
+	tampered := bytes.Replace(sopsDocument(t, identity), []byte("gorm:\n  default:"), []byte("gorm:\n  other:"), 1)
+	_, err = parser.Unmarshal(tampered)
+	assert.Error(t, err)
+}
+
+func TestParser_age(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	parser := Parser{Parser: config.CodecParser{Codec: yaml.Codec{}}, Identities: []age.Identity{identity}}
+
+	doc := fmt.Sprintf("name: app\ntoken: |\n    %s\n", indent(encryptAge(t, identity.Recipient(), []byte("secret"))))
+	m, err := parser.Unmarshal([]byte(doc))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "app", "token": "secret"}, m)
+
+	m, err = Parser{Parser: parser.Parser}.Unmarshal([]byte("name: app"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "app"}, m)
+}
+
+func TestWithYamlFile(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	dir, _ := ioutil.TempDir("", "sops")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.yaml")
+	ioutil.WriteFile(path, sopsDocument(t, identity), 0600)
+
+	keyFile := filepath.Join(dir, "keys.txt")
+	ioutil.WriteFile(keyFile, []byte(identity.String()), 0600)
+	os.Setenv("SOPS_AGE_KEY_FILE", keyFile)
+	defer os.Unsetenv("SOPS_AGE_KEY_FILE")
+
+	c := core.New(WithYamlFile(path))
+	assert.Equal(t, "root@tcp(db)/app", c.String("gorm.default.dsn"))
+	assert.Equal(t, 3306, c.Int("gorm.default.port"))
+}
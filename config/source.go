@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+var _ contract.ConfigWriter = (*KoanfAdapter)(nil)
+
+// BytesWriter is implemented by the configuration providers that can be
+// written back to, such as the file and etcd providers. The config set command
+// and Persist write to them.
+type BytesWriter interface {
+	WriteBytes([]byte) error
+}
+
+// Source returns the description of the configuration layer that the value of
+// the key comes from, that is the layer of the highest priority having the key.
+// The providers implementing fmt.Stringer describe themselves.
+func (k *KoanfAdapter) Source(key string) (string, error) {
+	for i, layer := range k.layers {
+		m, err := loadLayer(layer)
+		if err != nil {
+			return "", fmt.Errorf("unable to load config layer %d: %w", i, err)
+		}
+		tmp := koanf.New(".")
+		_ = tmp.Load(confmap.Provider(m, ""), nil)
+		if tmp.Exists(key) {
+			return describeLayer(i, layer), nil
+		}
+	}
+	k.rwlock.RLock()
+	defaults := maps.Copy(k.defaults)
+	k.rwlock.RUnlock()
+	tmp := koanf.New(".")
+	_ = tmp.Load(confmap.Provider(defaults, "."), nil)
+	if tmp.Exists(key) {
+		return "defaults", nil
+	}
+	return "", fmt.Errorf("no such config key: %s", key)
+}
+
+// Write sets the key to the value in the writable layer of the highest
+// priority, see BytesWriter. The layer is decoded, updated and encoded with its
+// parser, so the comments are lost. The change takes effect on the next
+// reload.
+func (k *KoanfAdapter) Write(key string, value interface{}) (string, error) {
+	return k.write(map[string]interface{}{key: value})
+}
+
+// Set changes the value of the key at runtime. The change overrides every
+// layer of the configuration stack and is kept across reloads, until it is
+// written back by Persist. Set reloads the configuration, so the validators
+// run and the change events are dispatched. If the validation fails, the
+// change is discarded.
+func (k *KoanfAdapter) Set(key string, value interface{}) error {
+	k.rwlock.Lock()
+	if k.pending == nil {
+		k.pending = make(map[string]interface{})
+	}
+	old, existed := k.pending[key]
+	k.pending[key] = value
+	k.rwlock.Unlock()
+
+	if err := k.Reload(); err != nil {
+		k.rwlock.Lock()
+		if existed {
+			k.pending[key] = old
+		} else {
+			delete(k.pending, key)
+		}
+		k.rwlock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Persist writes the changes made by Set back to the writable layer of the
+// highest priority, see Write.
+func (k *KoanfAdapter) Persist() error {
+	k.rwlock.RLock()
+	pending := make(map[string]interface{}, len(k.pending))
+	for key, value := range k.pending {
+		pending[key] = value
+	}
+	k.rwlock.RUnlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if _, err := k.write(pending); err != nil {
+		return err
+	}
+
+	k.rwlock.Lock()
+	defer k.rwlock.Unlock()
+	for key, value := range pending {
+		if current, ok := k.pending[key]; ok && reflect.DeepEqual(current, value) {
+			delete(k.pending, key)
+		}
+	}
+	return nil
+}
+
+// write sets the keys in the writable layer of the highest priority, and
+// returns the description of the layer.
+func (k *KoanfAdapter) write(values map[string]interface{}) (string, error) {
+	for i, layer := range k.layers {
+		writer, ok := layer.Provider.(BytesWriter)
+		if !ok || layer.Parser == nil {
+			continue
+		}
+		b, err := layer.Provider.ReadBytes()
+		if err != nil {
+			return "", fmt.Errorf("unable to read config layer %d: %w", i, err)
+		}
+		m, err := layer.Parser.Unmarshal(b)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse config layer %d: %w", i, err)
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			setPath(m, key, values[key])
+		}
+		if b, err = layer.Parser.Marshal(m); err != nil {
+			return "", err
+		}
+		if err := writer.WriteBytes(b); err != nil {
+			return "", fmt.Errorf("unable to write config layer %d: %w", i, err)
+		}
+		return describeLayer(i, layer), nil
+	}
+	return "", errors.New("no writable config layer")
+}
+
+func describeLayer(i int, layer ProviderSet) string {
+	if stringer, ok := layer.Provider.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("layer %d (%T)", i, layer.Provider)
+}
+
+// setPath sets the value at the dotted path, creating the intermediate maps.
+func setPath(m map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[key] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = value
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// Transformer rewrites the merged configuration before it is validated, eg. to
+// normalize keys or to translate legacy keys, so that organization specific
+// conventions don't need a fork of package config. The data is a copy that the
+// transformer may modify and return.
+type Transformer func(data map[string]interface{}) (map[string]interface{}, error)
+
+// WithTransformers is an option for *KoanfAdapter that adds transformers. They
+// run in order on every load, after the configuration stack is merged and
+// before the validators.
+func WithTransformers(transformers ...Transformer) Option {
+	return func(option *KoanfAdapter) {
+		option.transformers = append(option.transformers, transformers...)
+	}
+}
+
+// AddTransformers adds transformers after the configuration is created, eg. by
+// a module that owns the conventions, and reloads the configuration. If the reload fails,
+// the transformers are removed and the error is returned.
+func (k *KoanfAdapter) AddTransformers(transformers ...Transformer) error {
+	k.rwlock.Lock()
+	n := len(k.transformers)
+	k.transformers = append(k.transformers[:n:n], transformers...)
+	k.rwlock.Unlock()
+
+	if err := k.Reload(); err != nil {
+		k.rwlock.Lock()
+		k.transformers = k.transformers[:n:n]
+		k.rwlock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// transform applies the transformers to the merged configuration.
+func (k *KoanfAdapter) transform(tmp *koanf.Koanf) (*koanf.Koanf, error) {
+	k.rwlock.RLock()
+	transformers := k.transformers
+	k.rwlock.RUnlock()
+	if len(transformers) == 0 {
+		return tmp, nil
+	}
+
+	data := tmp.Raw()
+	for i, f := range transformers {
+		var err error
+		if data, err = f(data); err != nil {
+			return nil, fmt.Errorf("transformer %d failed: %w", i, err)
+		}
+	}
+	out := koanf.New(".")
+	if err := out.Load(confmap.Provider(data, ""), nil); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RenameKeys creates a Transformer that translates legacy keys. The renames map
+// the old key paths to the new ones, eg. "db" to "gorm.default". The values
+// under an old key are moved under the new key, and take precedence over the
+// values there, since the defaults are merged too and a legacy key is only ever
+// set on purpose.
+func RenameKeys(renames map[string]string) Transformer {
+	froms := make([]string, 0, len(renames))
+	for from := range renames {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	return func(data map[string]interface{}) (map[string]interface{}, error) {
+		flat, _ := maps.Flatten(data, nil, ".")
+		for _, from := range froms {
+			to := renames[from]
+			moved := make(map[string]interface{})
+			for key, value := range flat {
+				if under(key, from) {
+					moved[to+key[len(from):]] = value
+					delete(flat, key)
+				}
+			}
+			if _, ok := moved[to]; !ok && len(moved) > 0 {
+				// the subtree replaces the leaf, if any.
+				delete(flat, to)
+			}
+			for key, value := range moved {
+				flat[key] = value
+			}
+		}
+		return maps.Unflatten(flat, "."), nil
+	}
+}
+
+// NormalizeKeys creates a Transformer that rewrites every segment of every key
+// with f, eg. strings.ToLower.
+func NormalizeKeys(f func(string) string) Transformer {
+	var normalize func(m map[string]interface{}) map[string]interface{}
+	normalize = func(m map[string]interface{}) map[string]interface{} {
+		out := make(map[string]interface{}, len(m))
+		for key, value := range m {
+			if sub, ok := value.(map[string]interface{}); ok {
+				value = normalize(sub)
+			}
+			out[f(key)] = value
+		}
+		return out
+	}
+	return func(data map[string]interface{}) (map[string]interface{}, error) {
+		return normalize(data), nil
+	}
+}
+
+// under reports whether the key is the prefix, or a key under it.
+func under(key, prefix string) bool {
+	return key == prefix || strings.HasPrefix(key, prefix+".")
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	gotesting "testing"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameKeys(t *gotesting.T) {
+	t.Parallel()
+	rename := RenameKeys(map[string]string{"db": "gorm.default", "addr": "http.addr"})
+
+	out, err := rename(map[string]interface{}{
+		"db":   map[string]interface{}{"dsn": "foo", "driver": "mysql"},
+		"addr": ":80",
+		"http": map[string]interface{}{"addr": ":8080", "disable": true},
+		"gorm": map[string]interface{}{"default": map[string]interface{}{"dsn": "bar", "database": "sqlite"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"gorm": map[string]interface{}{"default": map[string]interface{}{"dsn": "foo", "driver": "mysql", "database": "sqlite"}},
+		"http": map[string]interface{}{"addr": ":80", "disable": true},
+	}, out)
+}
+
+func TestNormalizeKeys(t *gotesting.T) {
+	t.Parallel()
+	out, err := NormalizeKeys(strings.ToLower)(map[string]interface{}{
+		"HTTP": map[string]interface{}{"Addr": ":80"},
+		"Name": "app",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"http": map[string]interface{}{"addr": ":80"},
+		"name": "app",
+	}, out)
+}
+
+func TestKoanfAdapter_transformers(t *gotesting.T) {
+	t.Parallel()
+	provider := &mutableProvider{content: "db:\n  dsn: foo"}
+	conf, err := NewConfig(
+		WithProviderLayer(provider, yaml.Parser()),
+		WithTransformers(RenameKeys(map[string]string{"db": "gorm.default"})),
+		WithValidators(func(data map[string]interface{}) error {
+			if _, ok := data["db"]; ok {
+				return errors.New("validators should see the transformed config")
+			}
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", conf.String("gorm.default.dsn"))
+
+	provider.content = "DB:\n  DSN: bar"
+	assert.NoError(t, conf.Reload())
+	assert.Equal(t, "", conf.String("gorm.default.dsn"))
+
+	// transformers run in order, so the keys are normalized after the renaming.
+	assert.Error(t, conf.AddTransformers(NormalizeKeys(strings.ToLower)))
+	assert.NoError(t, conf.Reload(), "the failing transformer should be removed")
+
+	failing := func(data map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("failed")
+	}
+	assert.Error(t, conf.AddTransformers(failing))
+	assert.NoError(t, conf.Reload(), "the failing transformer should be removed")
+}
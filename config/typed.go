@@ -0,0 +1,32 @@
+//go:build go1.21
+// +build go1.21
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+// Get unmarshals the value at the key path into a T. If the key path doesn't
+// exist, the zero value of T is returned. It requires Go 1.21, the first
+// release that compiles generics in a module declaring an older go version.
+//
+//	option, err := config.Get[otgorm.Option](conf, "gorm.default")
+func Get[T any](accessor contract.ConfigAccessor, key string) (T, error) {
+	var v T
+	if err := accessor.Unmarshal(key, &v); err != nil {
+		return v, fmt.Errorf("failed to unmarshal %s into %T: %w", key, v, err)
+	}
+	return v, nil
+}
+
+// MustGet is like Get, but panics if the value cannot be unmarshalled.
+func MustGet[T any](accessor contract.ConfigAccessor, key string) T {
+	v, err := Get[T](accessor, key)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
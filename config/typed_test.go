@@ -0,0 +1,37 @@
+//go:build go1.21
+// +build go1.21
+
+package config
+
+import (
+	gotesting "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *gotesting.T) {
+	t.Parallel()
+	conf := MapAdapter{
+		"http": map[string]interface{}{"addr": ":8080", "timeout": "5s"},
+		"port": 8080,
+	}
+
+	type httpConf struct {
+		Addr    string   `json:"addr"`
+		Timeout Duration `json:"timeout"`
+	}
+	h, err := Get[httpConf](conf, "http")
+	assert.NoError(t, err)
+	assert.Equal(t, httpConf{Addr: ":8080", Timeout: Duration{5 * time.Second}}, h)
+
+	port, err := Get[int](conf, "port")
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, port)
+
+	_, err = Get[[]int](conf, "http")
+	assert.Error(t, err)
+
+	assert.Equal(t, ":8080", MustGet[string](conf, "http.addr"))
+	assert.Panics(t, func() { MustGet[int](conf, "http") })
+}
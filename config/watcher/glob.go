@@ -0,0 +1,81 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Glob is a watcher implementation to watch the files matching a pattern in a
+// directory, eg. "conf.d/*.yaml". Unlike File, it reloads when a matching file
+// is created, edited, removed or renamed, and keeps watching.
+type Glob struct {
+	Pattern string
+}
+
+// Watch watches the directory of the pattern. If a matching file changes, the
+// reload function will be called, which should reload the whole config stack.
+func (g Glob) Watch(ctx context.Context, reload func() error) error {
+	if _, err := filepath.Match(g.Pattern, ""); err != nil {
+		return err
+	}
+	dir, _ := filepath.Split(g.Pattern)
+	if dir == "" {
+		dir = "."
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if err := w.Add(dir); err != nil {
+		return err
+	}
+
+	var (
+		lastEvent     string
+		lastEventTime time.Time
+	)
+
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return errors.New("fsnotify watch channel closed")
+			}
+
+			// Use a simple timer to buffer events as certain events fire
+			// multiple times on some platforms.
+			if event.String() == lastEvent && time.Since(lastEventTime) < time.Millisecond*5 {
+				continue
+			}
+			lastEvent = event.String()
+			lastEventTime = time.Now()
+
+			if matched, _ := filepath.Match(filepath.Clean(g.Pattern), filepath.Clean(event.Name)); !matched {
+				continue
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			if err := reload(); err != nil {
+				return err
+			}
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return errors.New("fsnotify err channel closed")
+			}
+
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package watcher
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+	dir, _ := ioutil.TempDir("", "conf.d")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "a.yaml"), []byte("foo"), os.ModePerm)
+
+	ch := make(chan struct{}, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go Glob{Pattern: filepath.Join(dir, "*.yaml")}.Watch(ctx, func() error {
+		ch <- struct{}{}
+		return nil
+	})
+	time.Sleep(time.Second)
+
+	wait := func(name string) {
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			t.Fatalf("not reloaded after %s", name)
+		}
+	}
+	drain := func() {
+		time.Sleep(100 * time.Millisecond)
+		for len(ch) > 0 {
+			<-ch
+		}
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "a.yaml"), []byte("bar"), os.ModePerm)
+	wait("edit")
+	drain()
+
+	ioutil.WriteFile(filepath.Join(dir, "b.yaml"), []byte("baz"), os.ModePerm)
+	wait("create")
+	drain()
+
+	os.Remove(filepath.Join(dir, "a.yaml"))
+	wait("remove")
+	drain()
+
+	ioutil.WriteFile(filepath.Join(dir, "c.json"), []byte("baz"), os.ModePerm)
+	time.Sleep(100 * time.Millisecond)
+	if len(ch) > 0 {
+		t.Fatal("reloaded for an unmatched file")
+	}
+}
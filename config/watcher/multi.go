@@ -0,0 +1,25 @@
+package watcher
+
+import (
+	"context"
+
+	"github.com/DoNewsCode/core/contract"
+	"golang.org/x/sync/errgroup"
+)
+
+// Multi combines the watchers, so that the configuration is reloaded whenever
+// any of them notifies. Watch returns when all the watchers return, or any of
+// them fails.
+type Multi []contract.ConfigWatcher
+
+// Watch runs the watchers concurrently.
+func (m Multi) Watch(ctx context.Context, reload func() error) error {
+	group, ctx := errgroup.WithContext(ctx)
+	for _, w := range m {
+		w := w
+		group.Go(func() error {
+			return w.Watch(ctx, reload)
+		})
+	}
+	return group.Wait()
+}
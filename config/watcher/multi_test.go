@@ -0,0 +1,38 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/stretchr/testify/assert"
+)
+
+type watchFunc func(ctx context.Context, reload func() error) error
+
+func (f watchFunc) Watch(ctx context.Context, reload func() error) error {
+	return f(ctx, reload)
+}
+
+func TestMulti(t *testing.T) {
+	reloaded := make(chan struct{}, 2)
+	m := Multi{
+		watchFunc(func(ctx context.Context, reload func() error) error {
+			reload()
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		watchFunc(func(ctx context.Context, reload func() error) error {
+			reload()
+			return errors.New("stop")
+		}),
+	}
+	var _ contract.ConfigWatcher = m
+	err := m.Watch(context.Background(), func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Len(t, reloaded, 2)
+}
@@ -0,0 +1,91 @@
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/knadh/koanf"
+)
+
+// Poll is a watcher implementation for the providers that have no native watch
+// API, such as HTTP endpoints or object storages. It re-reads the provider at
+// the interval, and triggers the reload when the content changes.
+//
+//	provider := rawbytes.Provider(fetch())
+//	c := core.New(core.WithConfigStack(provider, parser), core.WithConfigWatcher(watcher.Poll{
+//		Interval: time.Minute,
+//		Provider: provider,
+//	}))
+type Poll struct {
+	// Interval defaults to one minute.
+	Interval time.Duration
+	// Provider is read by ReadBytes, or by Read if ReadBytes is not supported.
+	Provider koanf.Provider
+	// Logger logs the failed reads, which are retried at the next interval.
+	// Optional.
+	Logger log.Logger
+}
+
+// Watch polls the provider until the context is done. The first read is taken
+// as the baseline, as the configuration has been loaded before Watch.
+func (p Poll) Watch(ctx context.Context, reload func() error) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	logger := p.Logger
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	last, err := p.hash()
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to poll the config provider", "err", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sum, err := p.hash()
+			if err != nil {
+				level.Warn(logger).Log("msg", "failed to poll the config provider", "err", err)
+				continue
+			}
+			if bytes.Equal(sum, last) {
+				continue
+			}
+			last = sum
+			if err := reload(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// hash returns the content hash of the provider.
+func (p Poll) hash() ([]byte, error) {
+	b, err := p.Provider.ReadBytes()
+	if err != nil {
+		m, mapErr := p.Provider.Read()
+		if mapErr != nil {
+			return nil, fmt.Errorf("failed to read the provider: %w", err)
+		}
+		// The keys of maps are sorted by encoding/json.
+		if b, err = json.Marshal(m); err != nil {
+			return nil, err
+		}
+	}
+	sum := sha256.Sum256(b)
+	return sum[:], nil
+}
@@ -0,0 +1,83 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pollProvider struct {
+	mu      sync.Mutex
+	content []byte
+	err     error
+}
+
+func (p *pollProvider) set(content []byte, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.content, p.err = content, err
+}
+
+func (p *pollProvider) ReadBytes() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.content, p.err
+}
+
+func (p *pollProvider) Read() (map[string]interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+type mapProvider map[string]interface{}
+
+func (m mapProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m mapProvider) Read() (map[string]interface{}, error) {
+	return m, nil
+}
+
+func TestPoll(t *testing.T) {
+	provider := &pollProvider{content: []byte("foo: bar")}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reloaded := make(chan struct{}, 10)
+	go Poll{Interval: 10 * time.Millisecond, Provider: provider}.Watch(ctx, func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, reloaded, 0)
+
+	provider.set(nil, errors.New("unavailable"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, reloaded, 0)
+
+	provider.set([]byte("foo: baz"), nil)
+	select {
+	case <-reloaded:
+	case <-ctx.Done():
+		t.Fatal("not reloaded")
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, reloaded, 0)
+}
+
+func TestPoll_read(t *testing.T) {
+	p := Poll{Provider: mapProvider{"foo": "bar", "baz": 1}}
+	first, err := p.hash()
+	assert.NoError(t, err)
+	second, _ := p.hash()
+	assert.Equal(t, first, second)
+
+	p.Provider = mapProvider{"foo": "baz", "baz": 1}
+	third, _ := p.hash()
+	assert.NotEqual(t, first, third)
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+)
+
+// subscribeConfigListeners calls the modules implementing
+// contract.ConfigListener after every successful reload. A failing module is
+// logged, and doesn't keep the rest from being notified.
+func (c *C) subscribeConfigListeners() {
+	c.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+		conf := event.(events.OnReloadPayload).NewConf
+		c.Modules().Filter(func(listener contract.ConfigListener) {
+			if err := listener.OnConfigReload(conf); err != nil {
+				c.LevelLogger.Err(fmt.Sprintf("%T failed to reload config: %s", listener, err))
+			}
+		})
+		return nil
+	}))
+}
@@ -0,0 +1,22 @@
+package contract
+
+// BuildInfo is the build metadata of the application binary, usually injected
+// by the linker flags at release time.
+type BuildInfo struct {
+	// Version is the release version, eg. "v1.2.3".
+	Version string `json:"version" yaml:"version"`
+	// Commit is the VCS revision the binary is built from.
+	Commit string `json:"commit" yaml:"commit"`
+	// Date is the build date, preferably in RFC 3339.
+	Date string `json:"date" yaml:"date"`
+}
+
+// IsZero reports whether no build info is set.
+func (b BuildInfo) IsZero() bool {
+	return b == BuildInfo{}
+}
+
+// KeyVals returns the build info as log key values.
+func (b BuildInfo) KeyVals() []interface{} {
+	return []interface{}{"version", b.Version, "commit", b.Commit}
+}
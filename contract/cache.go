@@ -0,0 +1,16 @@
+package contract
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is a key value cache, such as an in-memory LRU or redis.
+type Cache interface {
+	// Get returns the value of the key. The found result is false on cache miss.
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set stores the value of the key for the ttl. A zero ttl means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the key.
+	Delete(ctx context.Context, key string) error
+}
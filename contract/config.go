@@ -19,7 +19,24 @@ type ConfigAccessor interface {
 	Unmarshal(path string, o interface{}) error
 }
 
+// ConfigWriter is implemented by the ConfigAccessor that can be changed at
+// runtime, eg. by admin endpoints. Set changes the value of a key in memory, and
+// Persist writes the changes back to the configuration source, so that they
+// survive restarts.
+type ConfigWriter interface {
+	Set(key string, value interface{}) error
+	Persist() error
+}
+
 // ConfigWatcher is an interface for hot-reload provider.
 type ConfigWatcher interface {
 	Watch(ctx context.Context, reload func() error) error
 }
+
+// ConfigListener is implemented by modules that react to configuration
+// changes. The core calls OnConfigReload on every module added by AddModule
+// after each successful reload, so the modules don't have to subscribe to the
+// dispatcher themselves.
+type ConfigListener interface {
+	OnConfigReload(conf ConfigAccessor) error
+}
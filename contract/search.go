@@ -0,0 +1,59 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Searcher is a full-text search engine, such as Elasticsearch or Bleve.
+type Searcher interface {
+	// Index adds or replaces the document with the id in the index. The
+	// document is encoded as JSON.
+	Index(ctx context.Context, index, id string, doc interface{}) error
+	// Delete removes the document with the id from the index. Deleting a
+	// missing document is not an error.
+	Delete(ctx context.Context, index, id string) error
+	// Query searches the index.
+	Query(ctx context.Context, index string, query SearchQuery) (*SearchResult, error)
+}
+
+// SearchQuery describes a search.
+type SearchQuery struct {
+	// Text is a query in the query string syntax, eg. "+title:go tutorial".
+	// An empty text matches all documents.
+	Text string
+	// Filters restrict the results to the documents whose fields equal the
+	// values.
+	Filters map[string]interface{}
+	// From is the offset of the first hit.
+	From int
+	// Size is the maximum number of hits. Defaults to 10.
+	Size int
+	// Facets are the fields whose top terms are counted.
+	Facets []string
+	// FacetSize is the maximum number of terms per facet. Defaults to 10.
+	FacetSize int
+}
+
+// SearchResult is the result of a search.
+type SearchResult struct {
+	// Total is the number of matched documents, regardless of pagination.
+	Total int64
+	// Hits are the matched documents of the requested page.
+	Hits []SearchHit
+	// Facets are the term counts, keyed by field.
+	Facets map[string][]SearchFacet
+}
+
+// SearchHit is a matched document.
+type SearchHit struct {
+	ID     string
+	Score  float64
+	Source json.RawMessage
+}
+
+// SearchFacet is the count of documents with the term.
+type SearchFacet struct {
+	Term  string
+	Count int64
+}
@@ -0,0 +1,13 @@
+package contract
+
+import "context"
+
+// SecretStore provides secrets, such as passwords and keys, by reference. The
+// format of the reference is up to the implementation.
+type SecretStore interface {
+	// Get returns the current value of the secret.
+	Get(ctx context.Context, ref string) ([]byte, error)
+	// Watch calls rotate with the new value whenever the secret changes, until
+	// the context is canceled or rotate returns an error.
+	Watch(ctx context.Context, ref string, rotate func(value []byte) error) error
+}
@@ -0,0 +1,235 @@
+/*
+Package containers starts dockerized infrastructures for integration tests, and
+injects their addresses into the config stack of a test core.
+
+The containers are started with the docker command line, so a working docker
+installation is required. If the environmental variable of a service (eg.
+REDIS_ADDR) is set, the given address is used instead, and no container is
+started for that service. This keeps the package compatible with CI pipelines
+that provide services themselves.
+
+	func TestMain(m *testing.M) {
+		options, teardown, err := containers.Start(containers.Redis, containers.MySQL)
+		if err != nil {
+			panic(err)
+		}
+		code := m.Run()
+		teardown()
+		os.Exit(code)
+	}
+
+	func TestFoo(t *testing.T) {
+		c := core.New(options...)
+		c.ProvideEssentials()
+		c.Provide(otredis.Providers())
+		// ...
+	}
+*/
+package containers
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/DoNewsCode/core"
+)
+
+// Service describes a dockerized infrastructure.
+type Service struct {
+	// Name of the service, used in container names and error messages.
+	Name string
+	// Image is the docker image to run.
+	Image string
+	// Port is the port exposed by the container.
+	Port int
+	// EnvName is the environmental variable that, if set, provides the address
+	// of an existing service.
+	EnvName string
+	// Env returns the environmental variables of the container, given the
+	// address the container is reachable from the host.
+	Env func(addr string) []string
+	// Config returns the config entries pointing to the service.
+	Config func(addr string) map[string]interface{}
+	// StartTimeout is the maximum time waiting for the service to accept
+	// connections. Defaults to one minute.
+	StartTimeout time.Duration
+}
+
+var (
+	// MySQL is a mysql service. It configures the default gorm connection.
+	MySQL = Service{
+		Name:    "mysql",
+		Image:   "mysql:5.7",
+		Port:    3306,
+		EnvName: "MYSQL_ADDR",
+		Env: func(addr string) []string {
+			return []string{"MYSQL_ALLOW_EMPTY_PASSWORD=yes", "MYSQL_DATABASE=app"}
+		},
+		Config: func(addr string) map[string]interface{} {
+			return map[string]interface{}{
+				"gorm.default.database": "mysql",
+				"gorm.default.dsn":      fmt.Sprintf("root@tcp(%s)/app?charset=utf8mb4&parseTime=True&loc=Local", addr),
+			}
+		},
+		StartTimeout: 2 * time.Minute,
+	}
+	// Redis is a redis service. It configures the default redis connection.
+	Redis = Service{
+		Name:    "redis",
+		Image:   "redis:6",
+		Port:    6379,
+		EnvName: "REDIS_ADDR",
+		Config: func(addr string) map[string]interface{} {
+			return map[string]interface{}{
+				"redis.default.addrs": []string{addr},
+			}
+		},
+	}
+	// Kafka is a single node kafka service in KRaft mode. It configures the
+	// default kafka reader and writer.
+	Kafka = Service{
+		Name:    "kafka",
+		Image:   "bitnami/kafka:3.4",
+		Port:    9092,
+		EnvName: "KAFKA_ADDR",
+		Env: func(addr string) []string {
+			return []string{
+				"KAFKA_ENABLE_KRAFT=yes",
+				"KAFKA_CFG_NODE_ID=1",
+				"KAFKA_CFG_PROCESS_ROLES=broker,controller",
+				"KAFKA_CFG_CONTROLLER_LISTENER_NAMES=CONTROLLER",
+				"KAFKA_CFG_LISTENERS=PLAINTEXT://:9092,CONTROLLER://:9093",
+				"KAFKA_CFG_LISTENER_SECURITY_PROTOCOL_MAP=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+				"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS=1@127.0.0.1:9093",
+				"KAFKA_CFG_ADVERTISED_LISTENERS=PLAINTEXT://" + addr,
+				"KAFKA_CFG_AUTO_CREATE_TOPICS_ENABLE=true",
+				"ALLOW_PLAINTEXT_LISTENER=yes",
+			}
+		},
+		Config: func(addr string) map[string]interface{} {
+			return map[string]interface{}{
+				"kafka.reader.default.brokers": []string{addr},
+				"kafka.writer.default.brokers": []string{addr},
+			}
+		},
+		StartTimeout: 2 * time.Minute,
+	}
+	// Etcd is a single node etcd service. It configures the default etcd connection.
+	Etcd = Service{
+		Name:    "etcd",
+		Image:   "quay.io/coreos/etcd:v3.5.0",
+		Port:    2379,
+		EnvName: "ETCD_ADDR",
+		Env: func(addr string) []string {
+			return []string{
+				"ETCD_LISTEN_CLIENT_URLS=http://0.0.0.0:2379",
+				"ETCD_ADVERTISE_CLIENT_URLS=http://" + addr,
+			}
+		},
+		Config: func(addr string) map[string]interface{} {
+			return map[string]interface{}{
+				"etcd.default.endpoints": []string{addr},
+			}
+		},
+	}
+)
+
+// Start starts the given services. It returns the core options that inject the
+// addresses of services into the config stack, and a teardown function that
+// removes the started containers. If any of the services fails to start, the
+// containers already started are removed.
+func Start(services ...Service) ([]core.CoreOption, func(), error) {
+	var (
+		options []core.CoreOption
+		ids     []string
+	)
+	teardown := func() {
+		for _, id := range ids {
+			_ = exec.Command("docker", "rm", "-f", "-v", id).Run()
+		}
+	}
+	for _, service := range services {
+		addr := os.Getenv(service.EnvName)
+		if addr == "" {
+			id, hostAddr, err := run(service)
+			if err != nil {
+				teardown()
+				return nil, nil, err
+			}
+			ids = append(ids, id)
+			addr = hostAddr
+		}
+		for k, v := range service.Config(strings.Split(addr, ",")[0]) {
+			options = append(options, core.WithInline(k, v))
+		}
+	}
+	return options, teardown, nil
+}
+
+func run(service Service) (id string, addr string, err error) {
+	port, err := freePort()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to allocate port for %s: %w", service.Name, err)
+	}
+	addr = fmt.Sprintf("127.0.0.1:%d", port)
+
+	args := []string{"run", "-d", "-p", fmt.Sprintf("%s:%d", addr, service.Port)}
+	if service.Env != nil {
+		for _, env := range service.Env(addr) {
+			args = append(args, "-e", env)
+		}
+	}
+	args = append(args, service.Image)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("failed to start %s container: %w: %s", service.Name, err, stderr.String())
+	}
+	id = strings.TrimSpace(stdout.String())
+
+	timeout := service.StartTimeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+	if err := waitFor(addr, timeout); err != nil {
+		_ = exec.Command("docker", "rm", "-f", "-v", id).Run()
+		return "", "", fmt.Errorf("%s container is not ready: %w", service.Name, err)
+	}
+	return id, addr, nil
+}
+
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitFor waits until the address accepts connections. Docker accepts
+// connections on published ports before the service is up, so a connection is
+// only considered successful if it is not closed immediately.
+func waitFor(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+			_ = conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+			_, err = conn.Read(make([]byte, 1))
+			conn.Close()
+			if ne, ok := err.(net.Error); err == nil || ok && ne.Timeout() {
+				return nil
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("timeout after %s", timeout)
+}
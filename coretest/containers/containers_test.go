@@ -0,0 +1,37 @@
+package containers
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/DoNewsCode/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart_fromEnv(t *testing.T) {
+	os.Setenv("REDIS_ADDR", "127.0.0.1:6380")
+	defer os.Unsetenv("REDIS_ADDR")
+
+	options, teardown, err := Start(Redis)
+	assert.NoError(t, err)
+	defer teardown()
+
+	c := core.New(options...)
+	assert.Equal(t, []string{"127.0.0.1:6380"}, c.Strings("redis.default.addrs"))
+}
+
+func TestStart_docker(t *testing.T) {
+	if os.Getenv("REDIS_ADDR") != "" {
+		t.Skip("set REDIS_ADDR to empty to test docker")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skip("docker is not available")
+	}
+	options, teardown, err := Start(Redis)
+	assert.NoError(t, err)
+	defer teardown()
+
+	c := core.New(options...)
+	assert.Len(t, c.Strings("redis.default.addrs"), 1)
+}
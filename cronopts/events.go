@@ -0,0 +1,39 @@
+package cronopts
+
+import (
+	"context"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/robfig/cron/v3"
+)
+
+// DispatchEvents returns a cron.JobWrapper that dispatches events.OnCronJobStarted
+// and events.OnCronJobFinished around each run of the jobs. Panics are reported
+// in the finished event and then resumed, so it should be placed after
+// cron.Recover in the chain if both are used.
+//
+//	c := cron.New(cron.WithChain(cron.Recover(logger), cronopts.DispatchEvents(dispatcher)))
+func DispatchEvents(dispatcher contract.Dispatcher) cron.JobWrapper {
+	return func(job cron.Job) cron.Job {
+		name := jobName(job)
+		return namedJob{name: name, run: func() {
+			ctx := context.Background()
+			_ = dispatcher.Dispatch(ctx, events.OnCronJobStarted, events.OnCronJobStartedPayload{Job: name})
+			start := time.Now()
+			defer func() {
+				r := recover()
+				_ = dispatcher.Dispatch(ctx, events.OnCronJobFinished, events.OnCronJobFinishedPayload{
+					Job:      name,
+					Duration: time.Since(start),
+					Panic:    r,
+				})
+				if r != nil {
+					panic(r)
+				}
+			}()
+			job.Run()
+		}}
+	}
+}
@@ -0,0 +1,36 @@
+package cronopts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DoNewsCode/core/events"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchEvents(t *testing.T) {
+	dispatcher := &events.SyncDispatcher{}
+	var topics []interface{}
+	var finished events.OnCronJobFinishedPayload
+	dispatcher.Subscribe(events.Listen(events.Pattern("onCronJob*"), func(ctx context.Context, event interface{}) error {
+		switch e := event.(type) {
+		case events.OnCronJobStartedPayload:
+			topics = append(topics, events.OnCronJobStarted)
+			assert.Contains(t, e.Job, "TestDispatchEvents")
+		case events.OnCronJobFinishedPayload:
+			topics = append(topics, events.OnCronJobFinished)
+			finished = e
+		}
+		return nil
+	}))
+
+	job := cron.NewChain(DispatchEvents(dispatcher)).Then(cron.FuncJob(func() {}))
+	job.Run()
+	assert.Equal(t, []interface{}{events.OnCronJobStarted, events.OnCronJobFinished}, topics)
+	assert.Nil(t, finished.Panic)
+
+	job = cron.NewChain(DispatchEvents(dispatcher)).Then(cron.FuncJob(func() { panic("boom") }))
+	assert.PanicsWithValue(t, "boom", job.Run)
+	assert.Equal(t, "boom", finished.Panic)
+}
@@ -0,0 +1,53 @@
+package cronopts
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/robfig/cron/v3"
+)
+
+// LabelJob is a cron.JobWrapper that sets the runtime/pprof label "job" while
+// running the job, so that the CPU profiles can be sliced by job. The name is
+// taken from the String method of the job if any, or the name of the function
+// for cron.FuncJob.
+//
+//	crontab := cron.New(cron.WithChain(cronopts.LabelJob))
+func LabelJob(job cron.Job) cron.Job {
+	name := jobName(job)
+	return namedJob{name: name, run: func() {
+		pprof.Do(context.Background(), pprof.Labels("job", name), func(ctx context.Context) {
+			job.Run()
+		})
+	}}
+}
+
+// namedJob is a wrapped job that keeps the name of the original job, so that
+// the wrappers can be chained in any order.
+type namedJob struct {
+	name string
+	run  func()
+}
+
+func (n namedJob) Run() {
+	n.run()
+}
+
+func (n namedJob) String() string {
+	return n.name
+}
+
+func jobName(job cron.Job) string {
+	switch j := job.(type) {
+	case fmt.Stringer:
+		return j.String()
+	case cron.FuncJob:
+		if fn := runtime.FuncForPC(reflect.ValueOf(j).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", job)
+}
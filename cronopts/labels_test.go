@@ -0,0 +1,29 @@
+package cronopts
+
+import (
+	"testing"
+
+	"github.com/DoNewsCode/core/events"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type stringerJob struct{}
+
+func (stringerJob) Run() {}
+
+func (stringerJob) String() string { return "named" }
+
+func sendReports() {}
+
+func TestJobName(t *testing.T) {
+	assert.Equal(t, "named", jobName(stringerJob{}))
+	assert.Equal(t, "github.com/DoNewsCode/core/cronopts.sendReports", jobName(cron.FuncJob(sendReports)))
+
+	chained := LabelJob(DispatchEvents(&events.SyncDispatcher{})(cron.FuncJob(sendReports)))
+	assert.Equal(t, "github.com/DoNewsCode/core/cronopts.sendReports", jobName(chained))
+
+	ran := false
+	LabelJob(cron.FuncJob(func() { ran = true })).Run()
+	assert.True(t, ran)
+}
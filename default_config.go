@@ -2,14 +2,19 @@ package core
 
 import (
 	"fmt"
+	"io"
 	stdlog "log"
 	"net"
+	"os"
+	"strings"
 
+	"github.com/DoNewsCode/core/boot"
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
 	"github.com/DoNewsCode/core/events"
 	"github.com/DoNewsCode/core/logging"
+	"github.com/DoNewsCode/core/srvhttp"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -27,6 +32,11 @@ grpc:
   disable: false
 cron:
   disable: false
+runtime:
+  maxProcs: 0
+  gcPercent: 0
+  memoryLimit: ""
+  ballast: ""
 log:
   level: debug
   format: logfmt
@@ -94,9 +104,51 @@ func ProvideLogger(conf contract.ConfigAccessor, appName contract.AppName, env c
 	if err != nil {
 		format = "logfmt"
 	}
-	logger := logging.NewLogger(format)
+	var (
+		schema logging.SchemaOption
+		async  logging.AsyncOption
+	)
+	_ = conf.Unmarshal("log.schema", &schema)
+	_ = conf.Unmarshal("log.async", &async)
+
+	var (
+		out         io.Writer = os.Stdout
+		asyncWriter *logging.AsyncWriter
+	)
+	if async.Enable {
+		asyncWriter = logging.NewAsyncWriter(os.Stdout, async)
+		out = asyncWriter
+	}
+
+	var logger log.Logger
+	switch {
+	case schema.Enable:
+		logger = logging.Normalize(logging.NewFormatLogger(out, format), appName.String(), env.String(), schema)
+	case async.Enable:
+		logger = logging.NewFormatLogger(out, format)
+		if strings.ToLower(format) != "json" {
+			logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+		}
+	default:
+		logger = logging.NewLogger(format)
+	}
 	logger = level.NewInjector(logger, level.DebugValue())
-	return level.NewFilter(logger, logging.LevelFilter(lvl))
+	levels := logging.NewLevels(lvl)
+	var modules map[string]string
+	_ = conf.Unmarshal("log.modules", &modules)
+	for module, lvl := range modules {
+		_ = levels.SetModule(module, lvl)
+	}
+	logger = levels.Filter(logger)
+	if asyncWriter != nil {
+		logger = logging.AsyncLogger{Logger: logger, Writer: asyncWriter}
+	}
+	// the sinks are added later by package logsink, as they have dependencies.
+	var sinks []interface{}
+	if err := conf.Unmarshal("log.sinks", &sinks); err == nil && len(sinks) > 0 {
+		logger = logging.NewTee(logger)
+	}
+	return logger
 }
 
 // ProvideDi is the default DiProvider for package Core.
@@ -105,8 +157,14 @@ func ProvideDi(conf contract.ConfigAccessor) DiContainer {
 }
 
 // ProvideEventDispatcher is the default EventDispatcherProvider for package Core.
+// The events.listenerTimeout config limits how long each listener may run.
 func ProvideEventDispatcher(conf contract.ConfigAccessor) contract.Dispatcher {
-	return &events.SyncDispatcher{}
+	dispatcher := &events.SyncDispatcher{}
+	var timeout config.Duration
+	if err := conf.Unmarshal("events.listenerTimeout", &timeout); err == nil {
+		dispatcher.SetTimeout(timeout.Duration)
+	}
+	return dispatcher
 }
 
 // provideDefaultConfig exports config for "name", "version", "env", "http", "grpc".
@@ -148,11 +206,13 @@ func provideDefaultConfig() []config.ExportedConfig {
 			Owner: "core",
 			Data: map[string]interface{}{
 				"http": map[string]interface{}{
-					"addr":    ":8080",
-					"disable": false,
+					"addr":          ":8080",
+					"disable":       false,
+					"proxyProtocol": false,
+					"rules":         []srvhttp.Rule{},
 				},
 			},
-			Comment: "The http address",
+			Comment: "The http address. Enable proxyProtocol behind load balancers sending PROXY protocol headers. The rules redirect, rewrite or add headers before the module routes",
 			Validate: func(data map[string]interface{}) error {
 				disable, err := getBool(data, "http", "disable")
 				if err != nil {
@@ -175,11 +235,12 @@ func provideDefaultConfig() []config.ExportedConfig {
 			Owner: "core",
 			Data: map[string]interface{}{
 				"grpc": map[string]interface{}{
-					"addr":    ":9090",
-					"disable": false,
+					"addr":          ":9090",
+					"disable":       false,
+					"proxyProtocol": false,
 				},
 			},
-			Comment: "The gRPC address",
+			Comment: "The gRPC address. Enable proxyProtocol behind load balancers sending PROXY protocol headers",
 			Validate: func(data map[string]interface{}) error {
 				disable, err := getBool(data, "grpc", "disable")
 				if err != nil {
@@ -217,9 +278,81 @@ func provideDefaultConfig() []config.ExportedConfig {
 		{
 			Owner: "core",
 			Data: map[string]interface{}{
-				"log": map[string]interface{}{"level": "debug", "format": "logfmt"},
+				"events": map[string]interface{}{
+					"listenerTimeout": "0s",
+				},
+			},
+			Comment: "The time limit of each event listener. 0s means no limit",
+		},
+		{
+			Owner: "core",
+			Data: map[string]interface{}{
+				"runtime": map[string]interface{}{
+					"maxProcs":    0,
+					"gcPercent":   0,
+					"memoryLimit": "",
+					"ballast":     "",
+				},
+			},
+			Comment: "The scheduler and garbage collector tuning, applied at bootstrap. A maxProcs of 0 follows the container CPU quota. A gcPercent of 0 leaves GOGC untouched. Sizes are like 512MiB",
+			Validate: func(data map[string]interface{}) error {
+				for _, field := range []string{"memoryLimit", "ballast"} {
+					str, err := getString(data, "runtime", field)
+					if err != nil {
+						return fmt.Errorf("the runtime.%s field is not valid: %w", field, err)
+					}
+					if _, err := parseBytes(str); err != nil {
+						return fmt.Errorf("the runtime.%s field is not valid: %w", field, err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Owner: "core",
+			Data: map[string]interface{}{
+				"boot": map[string]interface{}{
+					"timeout": "1m",
+					"waitFor": []boot.Gate{},
+				},
+			},
+			Comment: "The dependencies the serve command waits for, each with one of tcp, http or kind (gorm, redis or kafka) and entry",
+		},
+		{
+			Owner: "core",
+			Data: map[string]interface{}{
+				"log": map[string]interface{}{
+					"level":   "debug",
+					"modules": map[string]interface{}{},
+					"format":  "logfmt",
+					"schema": map[string]interface{}{
+						"enable": false,
+						"rename": map[string]interface{}{
+							"message": "msg",
+							"traceId": "trace_id",
+						},
+					},
+					"async": map[string]interface{}{
+						"enable":        false,
+						"bufferSize":    1024,
+						"maxBatch":      128,
+						"flushInterval": "100ms",
+						"policy":        "dropOldest",
+					},
+					"debug": map[string]interface{}{
+						"enable": false,
+						"token":  "",
+						"header": "X-Debug-Token",
+						"query":  "debug_token",
+					},
+					"sampling": map[string]interface{}{
+						"enable":     false,
+						"onError":    false,
+						"bufferSize": 100,
+					},
+				},
 			},
-			Comment: "The global logging level and format",
+			Comment: "The global logging level and format. The modules map overrides the level for logs with the matching module key. If sampling is enabled, logs below the level are written for sampled or errored requests",
 			Validate: func(data map[string]interface{}) error {
 				lvl, err := getString(data, "log", "level")
 				if err != nil {
@@ -1,8 +1,12 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/events"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -189,3 +193,35 @@ func TestDefaultConfig_network(t *testing.T) {
 		}
 	}
 }
+
+func TestParseBytes(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected int64
+	}{
+		{"", 0},
+		{"1024", 1024},
+		{"512MiB", 512 << 20},
+		{"1.5 GiB", 3 << 29},
+		{"2GB", 2e9},
+		{"10B", 10},
+	}
+	for _, c := range cases {
+		size, err := parseBytes(c.input)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, size)
+	}
+	_, err := parseBytes("foo")
+	assert.Error(t, err)
+	_, err = parseBytes("-1MiB")
+	assert.Error(t, err)
+}
+
+func TestProvideEventDispatcher(t *testing.T) {
+	dispatcher := ProvideEventDispatcher(config.MapAdapter{"events": map[string]interface{}{"listenerTimeout": "10ms"}})
+	dispatcher.Subscribe(events.Listen("foo", func(ctx context.Context, event interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	assert.True(t, errors.Is(dispatcher.Dispatch(context.Background(), "foo", nil), context.DeadlineExceeded))
+}
@@ -0,0 +1,99 @@
+package devserver
+
+import (
+	"fmt"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/DoNewsCode/core/otetcd"
+	"github.com/DoNewsCode/core/otredis"
+	"github.com/go-kit/kit/log"
+	"github.com/go-redis/redis/v8"
+	"go.etcd.io/etcd/client/v3"
+)
+
+/*
+Providers returns a set of dependency providers for package devserver.
+
+	Depends On:
+		log.Logger
+		contract.Env
+		contract.ConfigAccessor
+	Provide:
+		*DevServer
+		otredis.RedisConfigurationInterceptor
+		otetcd.EtcdConfigInterceptor
+*/
+func Providers() di.Deps {
+	return di.Deps{provideDevServer, provideRedisInterceptor, provideEtcdInterceptor, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger log.Logger
+	Env    contract.Env
+	Conf   contract.ConfigAccessor
+}
+
+func provideDevServer(in in) (*DevServer, func(), error) {
+	if !in.Env.IsLocal() {
+		return &DevServer{}, func() {}, nil
+	}
+	var option Option
+	if err := in.Conf.Unmarshal("devserver", &option); err != nil {
+		return nil, nil, fmt.Errorf("devserver configuration error: %w", err)
+	}
+	server, err := Start(option)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger := logging.WithLevel(in.Logger)
+	if addr := server.RedisAddr(); addr != "" {
+		logger.Infof("embedded redis is listening at %s", addr)
+	}
+	if addr := server.EtcdAddr(); addr != "" {
+		logger.Infof("embedded etcd is listening at %s", addr)
+	}
+	return server, server.Close, nil
+}
+
+func provideRedisInterceptor(server *DevServer) otredis.RedisConfigurationInterceptor {
+	return func(name string, opts *redis.UniversalOptions) {
+		if addr := server.RedisAddr(); addr != "" {
+			opts.Addrs = []string{addr}
+			opts.MasterName = ""
+		}
+	}
+}
+
+func provideEtcdInterceptor(server *DevServer) otetcd.EtcdConfigInterceptor {
+	return func(name string, options *clientv3.Config) {
+		if addr := server.EtcdAddr(); addr != "" {
+			options.Endpoints = []string{addr}
+		}
+	}
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "devserver",
+			Data: map[string]interface{}{
+				"devserver": Option{
+					Redis: ServerOption{Enable: true},
+					Etcd:  ServerOption{Enable: true},
+				},
+			},
+			Comment: "The embedded servers in local env",
+		},
+	}}
+}
@@ -0,0 +1,109 @@
+package devserver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// Option is the configuration of DevServer.
+type Option struct {
+	Redis ServerOption `json:"redis" yaml:"redis"`
+	Etcd  ServerOption `json:"etcd" yaml:"etcd"`
+}
+
+// ServerOption is the configuration of a single embedded server.
+type ServerOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+}
+
+// DevServer manages the embedded servers.
+type DevServer struct {
+	redis   *miniredis.Miniredis
+	etcd    *embed.Etcd
+	etcdDir string
+}
+
+// Start starts the embedded servers enabled in the Option. The servers should
+// be stopped by calling Close.
+func Start(option Option) (*DevServer, error) {
+	var (
+		d   DevServer
+		err error
+	)
+	if option.Redis.Enable {
+		if d.redis, err = miniredis.Run(); err != nil {
+			return nil, fmt.Errorf("failed to start embedded redis: %w", err)
+		}
+	}
+	if option.Etcd.Enable {
+		if err = d.startEtcd(); err != nil {
+			d.Close()
+			return nil, fmt.Errorf("failed to start embedded etcd: %w", err)
+		}
+	}
+	return &d, nil
+}
+
+func (d *DevServer) startEtcd() error {
+	dir, err := ioutil.TempDir("", "devserver-etcd")
+	if err != nil {
+		return err
+	}
+	d.etcdDir = dir
+
+	local, _ := url.Parse("http://127.0.0.1:0")
+	cfg := embed.NewConfig()
+	cfg.Dir = dir
+	cfg.LogLevel = "error"
+	cfg.LCUrls = []url.URL{*local}
+	cfg.LPUrls = []url.URL{*local}
+	cfg.ACUrls = []url.URL{*local}
+	cfg.APUrls = []url.URL{*local}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	d.etcd, err = embed.StartEtcd(cfg)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-d.etcd.Server.ReadyNotify():
+		return nil
+	case <-time.After(time.Minute):
+		return fmt.Errorf("embedded etcd took too long to start")
+	}
+}
+
+// RedisAddr returns the address of the embedded redis, or "" if it is not enabled.
+func (d *DevServer) RedisAddr() string {
+	if d.redis == nil {
+		return ""
+	}
+	return d.redis.Addr()
+}
+
+// EtcdAddr returns the client address of the embedded etcd, or "" if it is not enabled.
+func (d *DevServer) EtcdAddr() string {
+	if d.etcd == nil {
+		return ""
+	}
+	return d.etcd.Clients[0].Addr().String()
+}
+
+// Close stops all embedded servers.
+func (d *DevServer) Close() {
+	if d.redis != nil {
+		d.redis.Close()
+	}
+	if d.etcd != nil {
+		d.etcd.Close()
+	}
+	if d.etcdDir != "" {
+		_ = os.RemoveAll(d.etcdDir)
+	}
+}
@@ -0,0 +1,52 @@
+package devserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DoNewsCode/core"
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/otetcd"
+	"github.com/DoNewsCode/core/otredis"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/client/v3"
+)
+
+func TestDevServer(t *testing.T) {
+	c := core.New(
+		core.WithInline("env", "local"),
+		core.WithInline("log.level", "none"),
+		core.WithInline("devserver.redis.enable", true),
+		core.WithInline("devserver.etcd.enable", true),
+		core.WithInline("redis.default.addrs", []string{"127.0.0.1:1"}),
+		core.WithInline("etcd.default.endpoints", []string{"127.0.0.1:1"}),
+	)
+	defer c.Shutdown()
+	c.ProvideEssentials()
+	c.Provide(otredis.Providers())
+	c.Provide(otetcd.Providers())
+	c.Provide(Providers())
+
+	c.Invoke(func(client redis.UniversalClient, etcd *clientv3.Client) {
+		assert.NoError(t, client.Set(context.Background(), "foo", "bar", 0).Err())
+		assert.Equal(t, "bar", client.Get(context.Background(), "foo").Val())
+
+		_, err := etcd.Put(context.Background(), "foo", "bar")
+		assert.NoError(t, err)
+		resp, err := etcd.Get(context.Background(), "foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", string(resp.Kvs[0].Value))
+	})
+}
+
+func TestDevServer_notLocal(t *testing.T) {
+	server, cleanup, err := provideDevServer(in{
+		Env:  config.EnvProduction,
+		Conf: config.MapAdapter{},
+	})
+	assert.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, "", server.RedisAddr())
+	assert.Equal(t, "", server.EtcdAddr())
+}
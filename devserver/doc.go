@@ -0,0 +1,34 @@
+/*
+Package devserver runs embedded substitutes of infrastructures in local
+environment, so that the application can be started on a fresh laptop without
+any docker dependencies.
+
+Currently the embedded redis (miniredis) and the embedded etcd are supported.
+When the env is local, the enabled servers are started on demand, and the
+connections made by otredis and otetcd are redirected to them. In any other env,
+package devserver does nothing.
+
+Note there is no embedded substitute for kafka, as kafka-go talks the wire
+protocol to real brokers only.
+
+Integration
+
+package devserver exports the configuration in the following format:
+
+	devserver:
+	    redis:
+	        enable: true
+	    etcd:
+	        enable: true
+
+Add the devserver dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(otredis.Providers())
+	c.Provide(otetcd.Providers())
+	c.Provide(devserver.Providers())
+
+Package devserver provides otredis.RedisConfigurationInterceptor and
+otetcd.EtcdConfigInterceptor. Do not provide them elsewhere at the same time.
+*/
+package devserver
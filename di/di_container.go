@@ -5,12 +5,21 @@
 package di
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
 	"go.uber.org/dig"
 )
 
 // Graph is a wrapper around dig.
 type Graph struct {
 	dig *dig.Container
+
+	mu           sync.Mutex
+	providers    []Provider
+	constructors []interface{}
 }
 
 // NewGraph creates a graph
@@ -32,7 +41,15 @@ func NewGraph() *Graph {
 // that specify dependencies as di.In structs and/or specify results as di.Out
 // structs.
 func (g *Graph) Provide(constructor interface{}) error {
-	return g.dig.Provide(constructor)
+	if err := g.dig.Provide(constructor); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// recorded to rebuild the container on Override.
+	g.constructors = append(g.constructors, constructor)
+	return nil
 }
 
 // Invoke runs the given function after instantiating its dependencies. Any
@@ -44,7 +61,50 @@ func (g *Graph) Invoke(function interface{}) error {
 	return g.dig.Invoke(function)
 }
 
-// String representation of the entire Container
+// Describe records the metadata of a provided constructor, to be shown by
+// String.
+func (g *Graph) Describe(provider Provider) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.providers = append(g.providers, provider)
+}
+
+// String representation of the entire Container, followed by the metadata of
+// the described providers.
 func (g *Graph) String() string {
-	return g.dig.String()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.providers) == 0 {
+		return g.dig.String()
+	}
+	var b strings.Builder
+	b.WriteString(g.dig.String())
+	b.WriteString("\nproviders {\n")
+	for _, p := range g.providers {
+		fmt.Fprintf(&b, "\t%s -> %s", p.Owner, strings.Join(outputs(p.Constructor), ", "))
+		if p.Description != "" {
+			fmt.Fprintf(&b, " // %s", p.Description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// outputs returns the result types of the constructor, except errors.
+func outputs(constructor interface{}) []string {
+	ftype := reflect.TypeOf(constructor)
+	if ftype == nil || ftype.Kind() != reflect.Func {
+		return nil
+	}
+	var types []string
+	for i := 0; i < ftype.NumOut(); i++ {
+		if ftype.Out(i) == reflect.TypeOf((*error)(nil)).Elem() {
+			continue
+		}
+		types = append(types, ftype.Out(i).String())
+	}
+	return types
 }
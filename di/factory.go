@@ -2,7 +2,10 @@ package di
 
 import (
 	"context"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/singleflight"
 
@@ -17,24 +20,58 @@ type Pair struct {
 }
 
 // Factory is a concurrent safe, generic factory for databases and connections.
+//
+// Once subscribed to a dispatcher, the factory dispatches
+// events.OnConnectionClosing before closing a connection, and waits for the
+// listeners that delay the closing.
 type Factory struct {
-	group       singleflight.Group
-	cache       sync.Map
-	constructor func(name string) (Pair, error)
-	reloadOnce  sync.Once
+	group        singleflight.Group
+	cache        sync.Map
+	constructor  func(name string) (Pair, error)
+	reloadOnce   sync.Once
+	kind         string
+	closeTimeout time.Duration
+	dispatcher   atomic.Value
+}
+
+// FactoryOption is an option for Factory.
+type FactoryOption func(*Factory)
+
+// WithKind sets the kind of the connections in events.OnConnectionClosing, eg.
+// "gorm".
+func WithKind(kind string) FactoryOption {
+	return func(factory *Factory) {
+		factory.kind = kind
+	}
+}
+
+// WithCloseTimeout sets how long the closing of a connection can be delayed by
+// the listeners of events.OnConnectionClosing. Defaults to 30s.
+func WithCloseTimeout(timeout time.Duration) FactoryOption {
+	return func(factory *Factory) {
+		factory.closeTimeout = timeout
+	}
 }
 
 // NewFactory creates a new factory.
-func NewFactory(constructor func(name string) (Pair, error)) *Factory {
-	return &Factory{
-		constructor: constructor,
+func NewFactory(constructor func(name string) (Pair, error), opts ...FactoryOption) *Factory {
+	f := &Factory{
+		constructor:  constructor,
+		closeTimeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(f)
 	}
+	return f
 }
 
 // Make creates an instance under the provided name. It an instance is already
 // created and it is not nil, that instance is returned to the caller.
 func (f *Factory) Make(name string) (interface{}, error) {
-	var err error
+	var (
+		err     error
+		created bool
+	)
 
 	conn, err, _ := f.group.Do(name, func() (interface{}, error) {
 		if slot, ok := f.cache.Load(name); ok {
@@ -45,23 +82,62 @@ func (f *Factory) Make(name string) (interface{}, error) {
 			return nil, err
 		}
 		f.cache.Store(name, slot)
+		created = true
 		return slot.Conn, nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	// dispatched outside of the singleflight, so that the listeners can call
+	// Make for the same name. Only the caller that created the connection
+	// dispatches.
+	if created {
+		f.dispatch(events.OnConnectionUp, events.OnConnectionUpPayload{Name: name, Conn: conn})
+	}
 	return conn, nil
 }
 
 // SubscribeReloadEventFrom subscribes to the reload events from dispatcher and then notifies the di
-// factory to clear its cache and shutdown all connections gracefully.
+// factory to clear its cache and shutdown all connections gracefully. From then on,
+// events.OnConnectionUp and events.OnConnectionDown are dispatched to the dispatcher as well.
 func (f *Factory) SubscribeReloadEventFrom(dispatcher contract.Dispatcher) {
 	if dispatcher == nil {
 		return
 	}
+	f.setDispatcher(dispatcher)
 	f.reloadOnce.Do(func() {
 		dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
-			f.Close()
+			f.closeAll(events.CloseReasonReload)
+			return nil
+		}))
+	})
+}
+
+// SubscribeKeyChangedEventFrom subscribes to the key changed events from
+// dispatcher, and closes only the connections whose configuration under the
+// prefix is changed. For example, with the prefix "gorm", a change to
+// "gorm.default.dsn" closes the "default" connection, while the other
+// connections are kept. A connection is recreated by the next Make.
+//
+// A factory subscribes to either the key changed events or the reload events,
+// whichever is called first. As with SubscribeReloadEventFrom, the connection
+// events are dispatched to the dispatcher from then on.
+func (f *Factory) SubscribeKeyChangedEventFrom(dispatcher contract.Dispatcher, prefix string) {
+	if dispatcher == nil {
+		return
+	}
+	f.setDispatcher(dispatcher)
+	f.reloadOnce.Do(func() {
+		dispatcher.Subscribe(events.Listen(events.OnConfigKeyChanged, func(ctx context.Context, event interface{}) error {
+			key := event.(events.OnConfigKeyChangedPayload).Key
+			if key == prefix {
+				f.closeAll(events.CloseReasonReload)
+				return nil
+			}
+			if !strings.HasPrefix(key, prefix+".") {
+				return nil
+			}
+			f.CloseConn(strings.SplitN(strings.TrimPrefix(key, prefix+"."), ".", 2)[0])
 			return nil
 		}))
 	})
@@ -80,28 +156,81 @@ func (f *Factory) List() map[string]Pair {
 // Close closes every connection created by the factory. Connections are closed
 // concurrently.
 func (f *Factory) Close() {
+	f.closeAll(events.CloseReasonShutdown)
+}
+
+// CloseConn closes a specific connection in the factory, which is recreated by
+// the next Make.
+func (f *Factory) CloseConn(name string) {
+	f.closeConn(name, events.CloseReasonReload)
+}
+
+func (f *Factory) closeAll(reason string) {
 	var wg sync.WaitGroup
 	f.cache.Range(func(key, value interface{}) bool {
-		defer f.cache.Delete(key)
-
-		if value.(Pair).Closer == nil {
-			return true
-		}
 		wg.Add(1)
-		go func(value Pair) {
-			value.Closer()
+		go func(name string) {
+			f.closeConn(name, reason)
 			wg.Done()
-		}(value.(Pair))
+		}(key.(string))
 		return true
 	})
 	wg.Wait()
 }
 
-// CloseConn closes a specific connection in the factory.
-func (f *Factory) CloseConn(name string) {
+// closeConn notifies the listeners before closing the connection. The
+// connection is kept in the cache while the closing is delayed, so that it can
+// still be used to finish the work.
+func (f *Factory) closeConn(name string, reason string) {
+	value, ok := f.cache.Load(name)
+	if !ok {
+		return
+	}
+	if !f.notifyClosing(name, value.(Pair), reason) {
+		return
+	}
 	if value, loaded := f.cache.LoadAndDelete(name); loaded {
 		if value.(Pair).Closer != nil {
 			value.(Pair).Closer()
 		}
+		f.dispatch(events.OnConnectionDown, events.OnConnectionDownPayload{Name: name, Conn: value.(Pair).Conn})
+	}
+}
+
+func (f *Factory) dispatch(topic interface{}, payload interface{}) {
+	holder, ok := f.dispatcher.Load().(dispatcherHolder)
+	if !ok {
+		return
+	}
+	_ = holder.Dispatch(context.Background(), topic, payload)
+}
+
+// notifyClosing dispatches events.OnConnectionClosing, and reports whether the
+// connection should be closed.
+func (f *Factory) notifyClosing(name string, pair Pair, reason string) bool {
+	holder, ok := f.dispatcher.Load().(dispatcherHolder)
+	if !ok {
+		return true
 	}
+
+	control := &events.CloseControl{}
+	_ = holder.Dispatch(context.Background(), events.OnConnectionClosing, events.OnConnectionClosingPayload{
+		Kind:         f.kind,
+		Name:         name,
+		Conn:         pair.Conn,
+		Reason:       reason,
+		CloseControl: control,
+	})
+	control.Wait(f.closeTimeout)
+	return reason != events.CloseReasonReload || !control.Vetoed()
+}
+
+func (f *Factory) setDispatcher(dispatcher contract.Dispatcher) {
+	f.dispatcher.Store(dispatcherHolder{dispatcher})
+}
+
+// dispatcherHolder keeps the type stored in the atomic.Value consistent, no
+// matter the implementation of the dispatcher.
+type dispatcherHolder struct {
+	contract.Dispatcher
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -141,3 +142,162 @@ func randomString(l uint) string {
 	}
 	return string(s)
 }
+
+func TestFactory_connectionEvents(t *testing.T) {
+	t.Parallel()
+
+	f := NewFactory(func(name string) (Pair, error) {
+		return Pair{Conn: name, Closer: func() {}}, nil
+	})
+	dispatcher := &events.SyncDispatcher{}
+	f.SubscribeReloadEventFrom(dispatcher)
+
+	var up, down []string
+	dispatcher.Subscribe(events.Listen(events.OnConnectionUp, func(ctx context.Context, event interface{}) error {
+		up = append(up, event.(events.OnConnectionUpPayload).Name)
+		return nil
+	}))
+	dispatcher.Subscribe(events.Listen(events.OnConnectionDown, func(ctx context.Context, event interface{}) error {
+		down = append(down, event.(events.OnConnectionDownPayload).Conn.(string))
+		return nil
+	}))
+
+	f.Make("foo")
+	f.Make("foo")
+	assert.Equal(t, []string{"foo"}, up)
+
+	f.CloseConn("foo")
+	assert.Equal(t, []string{"foo"}, down)
+}
+
+func TestFactory_connectionEvents_reentrant(t *testing.T) {
+	t.Parallel()
+
+	f := NewFactory(func(name string) (Pair, error) {
+		return Pair{Conn: name}, nil
+	})
+	dispatcher := &events.SyncDispatcher{}
+	f.SubscribeReloadEventFrom(dispatcher)
+
+	var down []string
+	dispatcher.Subscribe(events.Listen(events.OnConnectionUp, func(ctx context.Context, event interface{}) error {
+		// eg. warming up the connection
+		_, err := f.Make(event.(events.OnConnectionUpPayload).Name)
+		return err
+	}))
+	dispatcher.Subscribe(events.Listen(events.OnConnectionDown, func(ctx context.Context, event interface{}) error {
+		down = append(down, event.(events.OnConnectionDownPayload).Name)
+		return nil
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		f.Make("foo")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Make deadlocked in the OnConnectionUp listener")
+	}
+
+	// the connection has no closer, but is reported down all the same.
+	f.CloseConn("foo")
+	assert.Equal(t, []string{"foo"}, down)
+}
+
+func TestFactory_SubscribeKeyChangedEventFrom(t *testing.T) {
+	t.Parallel()
+
+	var closed []string
+	f := NewFactory(func(name string) (Pair, error) {
+		return Pair{
+			Conn:   name,
+			Closer: func() { closed = append(closed, name) },
+		}, nil
+	})
+	dispatcher := events.SyncDispatcher{}
+	f.SubscribeKeyChangedEventFrom(&dispatcher, "gorm")
+	f.Make("default")
+	f.Make("alt")
+
+	dispatcher.Dispatch(context.Background(), events.OnConfigKeyChanged, events.OnConfigKeyChangedPayload{Key: "gormMetrics.interval"})
+	assert.Empty(t, closed)
+
+	dispatcher.Dispatch(context.Background(), events.OnConfigKeyChanged, events.OnConfigKeyChangedPayload{Key: "gorm.default.dsn"})
+	assert.Equal(t, []string{"default"}, closed)
+	assert.Len(t, f.List(), 1)
+}
+
+func TestFactory_OnConnectionClosing(t *testing.T) {
+	t.Parallel()
+
+	var closed int32
+	f := NewFactory(func(name string) (Pair, error) {
+		return Pair{
+			Conn:   name,
+			Closer: func() { atomic.AddInt32(&closed, 1) },
+		}, nil
+	}, WithKind("gorm"), WithCloseTimeout(time.Second))
+	dispatcher := events.SyncDispatcher{}
+	f.SubscribeReloadEventFrom(&dispatcher)
+
+	var payloads []events.OnConnectionClosingPayload
+	veto := true
+	dispatcher.Subscribe(events.Listen(events.OnConnectionClosing, func(ctx context.Context, event interface{}) error {
+		payload := event.(events.OnConnectionClosingPayload)
+		payloads = append(payloads, payload)
+		if veto {
+			payload.Veto()
+			return nil
+		}
+		done := payload.Delay()
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			// the connection is still usable while the closing is delayed.
+			assert.Len(t, f.List(), 1)
+			assert.Equal(t, int32(0), atomic.LoadInt32(&closed))
+			done()
+		}()
+		return nil
+	}))
+	f.Make("default")
+
+	dispatcher.Dispatch(context.Background(), events.OnReload, events.OnReloadPayload{})
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "gorm", payloads[0].Kind)
+	assert.Equal(t, "default", payloads[0].Name)
+	assert.Equal(t, "default", payloads[0].Conn)
+	assert.Equal(t, events.CloseReasonReload, payloads[0].Reason)
+	assert.Len(t, f.List(), 1)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&closed))
+
+	veto = false
+	f.Close()
+	assert.Len(t, payloads, 2)
+	assert.Equal(t, events.CloseReasonShutdown, payloads[1].Reason)
+	assert.Empty(t, f.List())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&closed))
+}
+
+func TestFactory_closeTimeout(t *testing.T) {
+	t.Parallel()
+
+	f := NewFactory(func(name string) (Pair, error) {
+		return Pair{Conn: name, Closer: func() {}}, nil
+	}, WithCloseTimeout(10*time.Millisecond))
+	dispatcher := events.SyncDispatcher{}
+	f.SubscribeReloadEventFrom(&dispatcher)
+	dispatcher.Subscribe(events.Listen(events.OnConnectionClosing, func(ctx context.Context, event interface{}) error {
+		// never done, and also vetoing a shutdown is not honored.
+		event.(events.OnConnectionClosingPayload).Delay()
+		event.(events.OnConnectionClosingPayload).Veto()
+		return nil
+	}))
+	f.Make("default")
+
+	start := time.Now()
+	f.Close()
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Empty(t, f.List())
+}
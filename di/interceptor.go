@@ -0,0 +1,70 @@
+package di
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Interceptor is an entry in the interceptor registry of the factories. It
+// allows several modules to make last minute changes to the configuration of
+// the same connections, instead of fighting over a single optional function.
+//
+// Provide it in the "interceptor" group:
+//
+//	type out struct {
+//		di.Out
+//
+//		Interceptor di.Interceptor `group:"interceptor"`
+//	}
+//
+//	func provide() out {
+//		return out{Interceptor: di.Interceptor{
+//			Kind: "gorm",
+//			Name: "default",
+//			Func: otgorm.GormConfigInterceptor(func(name string, conf *gorm.Config) {
+//				conf.PrepareStmt = true
+//			}),
+//		}}
+//	}
+type Interceptor struct {
+	// Kind is the kind of the factory, eg. "gorm" or "kafka.reader".
+	Kind string
+	// Name is the name of the connection. An empty name intercepts every
+	// connection of the kind.
+	Name string
+	// Order decides the order in which the interceptors are applied, from low to
+	// high. The interceptors of the same order are applied in the order they
+	// are provided.
+	Order int
+	// Func is the interceptor, whose type is documented by the factory, eg.
+	// otgorm.GormConfigInterceptor.
+	Func interface{}
+}
+
+// Interceptors is the interceptor registry of the factories.
+type Interceptors []Interceptor
+
+// Apply calls apply with the Func of every interceptor registered for the kind
+// and the connection name, in order. If apply reports that it doesn't accept
+// the Func, an error is returned.
+func (i Interceptors) Apply(kind, name string, apply func(fn interface{}) bool) error {
+	var matched Interceptors
+	for _, interceptor := range i {
+		if interceptor.Kind != kind {
+			continue
+		}
+		if interceptor.Name != "" && interceptor.Name != name {
+			continue
+		}
+		matched = append(matched, interceptor)
+	}
+	sort.SliceStable(matched, func(a, b int) bool {
+		return matched[a].Order < matched[b].Order
+	})
+	for _, interceptor := range matched {
+		if !apply(interceptor.Func) {
+			return fmt.Errorf("invalid %s interceptor %T", kind, interceptor.Func)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptors_Apply(t *testing.T) {
+	t.Parallel()
+	record := func(s string) func(name string, order *[]string) {
+		return func(name string, order *[]string) {
+			*order = append(*order, s)
+		}
+	}
+	interceptors := Interceptors{
+		{Kind: "gorm", Order: 1, Func: record("late")},
+		{Kind: "gorm", Name: "default", Func: record("default")},
+		{Kind: "gorm", Name: "other", Func: record("other")},
+		{Kind: "redis", Func: record("redis")},
+		{Kind: "gorm", Func: record("all")},
+		{Kind: "gorm", Order: -1, Func: record("early")},
+	}
+
+	var order []string
+	err := interceptors.Apply("gorm", "default", func(fn interface{}) bool {
+		f, ok := fn.(func(name string, order *[]string))
+		if ok {
+			f("default", &order)
+		}
+		return ok
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"early", "default", "all", "late"}, order)
+
+	err = interceptors.Apply("gorm", "default", func(fn interface{}) bool { return false })
+	assert.Error(t, err)
+	assert.NoError(t, Interceptors(nil).Apply("gorm", "default", func(fn interface{}) bool { return false }))
+}
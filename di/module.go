@@ -13,3 +13,44 @@ type Module interface {
 // Deps is a set of providers grouped together. This is used by core.Provide
 // method to identify provider sets.
 type Deps []interface{}
+
+// Merge returns a new Deps with the providers of d followed by the ones of
+// others.
+//
+//	c.Provide(otgorm.Providers().Merge(otredis.Providers(), di.If(debug, devtools.Providers())))
+func (d Deps) Merge(others ...Deps) Deps {
+	merged := make(Deps, 0, len(d))
+	merged = append(merged, d...)
+	for _, other := range others {
+		merged = append(merged, other...)
+	}
+	return merged
+}
+
+// If returns deps if the condition holds, or an empty Deps otherwise. It is
+// useful to include providers conditionally when composing provider sets.
+func If(condition bool, deps Deps) Deps {
+	if !condition {
+		return Deps{}
+	}
+	return deps
+}
+
+// Provider is a constructor annotated with metadata. It can be used in place
+// of a constructor in Deps. The metadata is shown in the dependency graph
+// output, see Graph.String.
+type Provider struct {
+	// Constructor is the constructor to provide.
+	Constructor interface{}
+	// Owner is the module or package that owns the constructor.
+	Owner string
+	// Description describes what the constructor provides.
+	Description string
+}
+
+// Describe annotates the constructor with the owner and the description.
+//
+//	di.Deps{di.Describe(provideCache, "otredis", "the redis backed cache")}
+func Describe(constructor interface{}, owner string, description string) Provider {
+	return Provider{Constructor: constructor, Owner: owner, Description: description}
+}
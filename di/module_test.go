@@ -1,6 +1,7 @@
 package di
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,3 +14,99 @@ func TestModule(t *testing.T) {
 	assert.Implements(t, (*Module)(nil), mock{})
 	assert.Implements(t, (*Module)(nil), &mock{})
 }
+
+func TestDeps(t *testing.T) {
+	a := func() int { return 1 }
+	b := func() string { return "" }
+	c := func() bool { return true }
+
+	deps := Deps{a}.Merge(If(true, Deps{b}), If(false, Deps{c}))
+	assert.Len(t, deps, 2)
+	assert.Len(t, Deps{a}, 1)
+}
+
+func TestGraph_Describe(t *testing.T) {
+	g := NewGraph()
+	p := Describe(func() (int, error) { return 1, nil }, "counter", "the number of items")
+	assert.NoError(t, g.Provide(p.Constructor))
+	g.Describe(p)
+	assert.Contains(t, g.String(), "counter -> int // the number of items")
+}
+
+func TestNamed(t *testing.T) {
+	g := NewGraph()
+	assert.NoError(t, g.Provide(func() string { return "default" }))
+	assert.NoError(t, g.Provide(Named("report", func() (string, error) { return "report", nil })))
+	assert.NoError(t, g.Provide(Named("broken", func() (string, error) { return "", errors.New("broken") })))
+
+	type in struct {
+		In
+
+		Default string
+		Report  string `name:"report"`
+	}
+	assert.NoError(t, g.Invoke(func(in in) {
+		assert.Equal(t, "default", in.Default)
+		assert.Equal(t, "report", in.Report)
+	}))
+	type broken struct {
+		In
+
+		Broken string `name:"broken"`
+	}
+	assert.Error(t, g.Invoke(func(in broken) {}))
+	assert.Panics(t, func() { Named("foo", 1) })
+	assert.Panics(t, func() { Named("foo", func() struct{ Out } { return struct{ Out }{} }) })
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGraph()
+	assert.NoError(t, g.Provide(Group("numbers", func() int { return 1 })))
+	assert.NoError(t, g.Provide(Group("numbers", func(s string) (int, func()) { return len(s), func() {} })))
+	assert.NoError(t, g.Provide(func() string { return "foo" }))
+
+	type in struct {
+		In
+
+		Numbers []int `group:"numbers"`
+	}
+	assert.NoError(t, g.Invoke(func(in in) {
+		assert.ElementsMatch(t, []int{1, 3}, in.Numbers)
+	}))
+}
+
+func TestGraph_Override(t *testing.T) {
+	type out struct {
+		Out
+
+		Number  int
+		Name    string `name:"name"`
+		Numbers []int  `group:"numbers,flatten"`
+	}
+	g := NewGraph()
+	assert.NoError(t, g.Provide(func() (out, error) {
+		return out{Number: 1, Name: "real", Numbers: []int{1}}, nil
+	}))
+	assert.NoError(t, g.Provide(func() float64 { return 1 }))
+	assert.NoError(t, g.Override(Named("name", func() string { return "fake" })))
+	assert.NoError(t, g.Override(func() float64 { return 2 }))
+	assert.NoError(t, g.Override(func() (float64, error) { return 3, nil }))
+
+	type in struct {
+		In
+
+		Number  int
+		Name    string `name:"name"`
+		Numbers []int  `group:"numbers"`
+		Float   float64
+	}
+	assert.NoError(t, g.Invoke(func(in in) {
+		assert.Equal(t, 1, in.Number)
+		assert.Equal(t, "fake", in.Name)
+		assert.Equal(t, []int{1}, in.Numbers)
+		assert.Equal(t, 3.0, in.Float)
+	}))
+
+	assert.NoError(t, g.Override(func() (int, error) { return 0, errors.New("failed") }))
+	assert.Error(t, g.Invoke(func(int) {}))
+}
@@ -0,0 +1,114 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/dig"
+)
+
+// Named annotates the results of the constructor with a name, so that several
+// values of the same type can coexist in the container. The named values are
+// injected by the name tag of a di.In struct:
+//
+//	c.Provide(di.Deps{di.Named("report", provideReportDB)})
+//
+//	type in struct {
+//		di.In
+//
+//		ReportDB *gorm.DB `name:"report"`
+//	}
+//
+// The error and the cleanup function returned by the constructor, if any, are
+// handled as usual.
+func Named(name string, constructor interface{}) interface{} {
+	return annotate(constructor, fmt.Sprintf(`name:"%s"`, name))
+}
+
+// Group annotates the results of the constructor with a value group, so that
+// the values provided by several constructors are injected together as a slice,
+// by the group tag of a di.In struct:
+//
+//	c.Provide(di.Deps{di.Group("handlers", provideFooHandler), di.Group("handlers", provideBarHandler)})
+//
+//	type in struct {
+//		di.In
+//
+//		Handlers []http.Handler `group:"handlers"`
+//	}
+func Group(group string, constructor interface{}) interface{} {
+	return annotate(constructor, fmt.Sprintf(`group:"%s"`, group))
+}
+
+var (
+	errType  = reflect.TypeOf((*error)(nil)).Elem()
+	outField = reflect.StructField{Name: "Out", Type: reflect.TypeOf(dig.Out{}), Anonymous: true}
+)
+
+// annotate wraps the constructor in one returning a dig.Out struct, whose fields
+// are the results of the constructor tagged with the tag.
+func annotate(constructor interface{}, tag string) interface{} {
+	ftype := reflect.TypeOf(constructor)
+	if ftype == nil || ftype.Kind() != reflect.Func {
+		panic(fmt.Sprintf("must annotate constructor function, got %v (type %v)", constructor, ftype))
+	}
+
+	var (
+		fields  = []reflect.StructField{outField}
+		values  []int // the indexes of the annotated results
+		outputs []reflect.Type
+	)
+	for i := 0; i < ftype.NumOut(); i++ {
+		t := ftype.Out(i)
+		if t == errType || isCleanup(t) {
+			continue
+		}
+		if dig.IsOut(t) {
+			panic(fmt.Sprintf("can't annotate the di.Out result %v, tag its fields instead", t))
+		}
+		values = append(values, i)
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Value%d", i),
+			Type: t,
+			Tag:  reflect.StructTag(tag),
+		})
+	}
+	out := reflect.StructOf(fields)
+	outputs = append(outputs, out)
+	for i := 0; i < ftype.NumOut(); i++ {
+		if t := ftype.Out(i); t == errType || isCleanup(t) {
+			outputs = append(outputs, t)
+		}
+	}
+
+	inputs := make([]reflect.Type, ftype.NumIn())
+	for i := range inputs {
+		inputs[i] = ftype.In(i)
+	}
+
+	fn := reflect.MakeFunc(reflect.FuncOf(inputs, outputs, ftype.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if ftype.IsVariadic() {
+			results = reflect.ValueOf(constructor).CallSlice(args)
+		} else {
+			results = reflect.ValueOf(constructor).Call(args)
+		}
+		annotated := reflect.New(out).Elem()
+		for field, i := range values {
+			annotated.Field(field + 1).Set(results[i])
+		}
+		returns := []reflect.Value{annotated}
+		for i, result := range results {
+			if t := ftype.Out(i); t == errType || isCleanup(t) {
+				returns = append(returns, result)
+			}
+		}
+		return returns
+	})
+	return fn.Interface()
+}
+
+// isCleanup reports whether the type is a cleanup function, func().
+func isCleanup(t reflect.Type) bool {
+	return t.Kind() == reflect.Func && t.NumIn() == 0 && t.NumOut() == 0
+}
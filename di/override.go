@@ -0,0 +1,199 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/dig"
+)
+
+// Override provides the constructor in place of the constructors provided
+// before for the same results, eg. to swap the real *gorm.DB for a fake in
+// integration tests. The results are matched by type and name. Value groups
+// are never overridden, as they are meant to be extended.
+//
+// The container is rebuilt, and the values built by previous invocations are
+// discarded, so Override should be called before Invoke. It must not be called
+// concurrently with Provide or Invoke.
+func (g *Graph) Override(constructor interface{}) error {
+	overridden := make(map[resultKey]bool)
+	for _, result := range results(reflect.TypeOf(constructor)) {
+		overridden[result.key] = true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	container := dig.New()
+	var constructors []interface{}
+	for _, c := range g.constructors {
+		stripped, ok := strip(c, overridden)
+		if !ok {
+			continue
+		}
+		if err := container.Provide(stripped); err != nil {
+			return err
+		}
+		constructors = append(constructors, stripped)
+	}
+	if err := container.Provide(constructor); err != nil {
+		return err
+	}
+	g.dig = container
+	g.constructors = append(constructors, constructor)
+	return nil
+}
+
+// resultKey identifies a result in the container.
+type resultKey struct {
+	t    reflect.Type
+	name string
+}
+
+// result is a value provided by a constructor. index is the index of the
+// result, and field is the index of the field in the di.Out struct, if any.
+type result struct {
+	key   resultKey
+	index int
+	field []int
+	tag   reflect.StructTag
+}
+
+// results lists the values provided by the constructor of the type, except
+// the value groups.
+func results(ftype reflect.Type) []result {
+	if ftype == nil || ftype.Kind() != reflect.Func {
+		return nil
+	}
+	var out []result
+	for i := 0; i < ftype.NumOut(); i++ {
+		t := ftype.Out(i)
+		if t == errType {
+			continue
+		}
+		if !dig.IsOut(t) {
+			out = append(out, result{key: resultKey{t: t}, index: i})
+			continue
+		}
+		out = append(out, fields(t, i, nil)...)
+	}
+	return out
+}
+
+// fields lists the fields of a di.Out struct, including the ones of the
+// embedded di.Out structs, except the value groups.
+func fields(t reflect.Type, index int, parent []int) []result {
+	var out []result
+	for j := 0; j < t.NumField(); j++ {
+		f := t.Field(j)
+		path := append(append([]int(nil), parent...), j)
+		if f.Type == reflect.TypeOf(dig.Out{}) {
+			continue
+		}
+		if f.Anonymous && dig.IsOut(f.Type) {
+			out = append(out, fields(f.Type, index, path)...)
+			continue
+		}
+		if f.Tag.Get("group") != "" {
+			continue
+		}
+		out = append(out, result{key: resultKey{t: f.Type, name: f.Tag.Get("name")}, index: index, field: path, tag: f.Tag})
+	}
+	return out
+}
+
+// strip wraps the constructor in one that doesn't provide the overridden
+// results. It reports false if nothing is left to provide.
+func strip(constructor interface{}, overridden map[resultKey]bool) (interface{}, bool) {
+	ftype := reflect.TypeOf(constructor)
+	all := results(ftype)
+	var kept []result
+	for _, r := range all {
+		if !overridden[r.key] {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(all) {
+		return constructor, true
+	}
+
+	// the value groups must be kept as well.
+	var groups []result
+	for i := 0; i < ftype.NumOut(); i++ {
+		if t := ftype.Out(i); t != errType && dig.IsOut(t) {
+			groups = append(groups, groupFields(t, i, nil)...)
+		}
+	}
+	kept = append(kept, groups...)
+	if len(kept) == 0 {
+		return nil, false
+	}
+
+	structFields := []reflect.StructField{outField}
+	for i, r := range kept {
+		structFields = append(structFields, reflect.StructField{
+			Name: fmt.Sprintf("Value%d", i),
+			Type: r.key.t,
+			Tag:  r.tag,
+		})
+	}
+	out := reflect.StructOf(structFields)
+	outputs := []reflect.Type{out}
+	errIndex := -1
+	for i := 0; i < ftype.NumOut(); i++ {
+		if ftype.Out(i) == errType {
+			outputs = append(outputs, errType)
+			errIndex = i
+		}
+	}
+	inputs := make([]reflect.Type, ftype.NumIn())
+	for i := range inputs {
+		inputs[i] = ftype.In(i)
+	}
+
+	fn := reflect.MakeFunc(reflect.FuncOf(inputs, outputs, ftype.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		var values []reflect.Value
+		if ftype.IsVariadic() {
+			values = reflect.ValueOf(constructor).CallSlice(args)
+		} else {
+			values = reflect.ValueOf(constructor).Call(args)
+		}
+		stripped := reflect.New(out).Elem()
+		returns := []reflect.Value{stripped}
+		if errIndex >= 0 {
+			returns = append(returns, values[errIndex])
+			if !values[errIndex].IsNil() {
+				return returns
+			}
+		}
+		for i, r := range kept {
+			v := values[r.index]
+			if r.field != nil {
+				v = v.FieldByIndex(r.field)
+			}
+			stripped.Field(i + 1).Set(v)
+		}
+		return returns
+	})
+	return fn.Interface(), true
+}
+
+// groupFields lists the value group fields of a di.Out struct.
+func groupFields(t reflect.Type, index int, parent []int) []result {
+	var out []result
+	for j := 0; j < t.NumField(); j++ {
+		f := t.Field(j)
+		path := append(append([]int(nil), parent...), j)
+		if f.Type == reflect.TypeOf(dig.Out{}) {
+			continue
+		}
+		if f.Anonymous && dig.IsOut(f.Type) {
+			out = append(out, groupFields(f.Type, index, path)...)
+			continue
+		}
+		if f.Tag.Get("group") != "" {
+			out = append(out, result{key: resultKey{t: f.Type}, index: index, field: path, tag: f.Tag})
+		}
+	}
+	return out
+}
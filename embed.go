@@ -0,0 +1,47 @@
+//go:build go1.16
+// +build go1.16
+
+package core
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+)
+
+// WithEmbeddedConfig is a CoreOption that compiles the default configuration
+// into the binary. The file at path is read from fsys, typically an embed.FS,
+// and decoded by the codec. It sits at the bottom of the configuration stack,
+// along with WithDefaults, so the file, env and remote layers override it.
+//
+//	//go:embed config/default.yaml
+//	var defaults embed.FS
+//
+//	c := core.New(
+//		core.WithEmbeddedConfig(defaults, "config/default.yaml", yaml.Codec{}),
+//		core.WithConfigStack(env.Provider("APP_", ".", transform), nil),
+//	)
+func WithEmbeddedConfig(fsys fs.FS, path string, codec contract.Codec) CoreOption {
+	return func(values *coreValues) {
+		values.configDefaults = append(values.configDefaults, config.ProviderSet{
+			Provider: embeddedFile{fsys: fsys, path: path},
+			Parser:   config.CodecParser{Codec: codec},
+		})
+	}
+}
+
+// embeddedFile is a koanf.Provider that reads a file in fs.FS.
+type embeddedFile struct {
+	fsys fs.FS
+	path string
+}
+
+func (e embeddedFile) ReadBytes() ([]byte, error) {
+	return fs.ReadFile(e.fsys, e.path)
+}
+
+func (e embeddedFile) Read() (map[string]interface{}, error) {
+	return nil, errors.New("embedded file provider does not support this method")
+}
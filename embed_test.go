@@ -0,0 +1,25 @@
+//go:build go1.16
+// +build go1.16
+
+package core
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEmbeddedConfig(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/default.yaml": {Data: []byte("name: embedded\nhttp:\n  addr: :8080\nlog:\n  level: error")},
+	}
+	c := New(
+		WithEmbeddedConfig(fsys, "config/default.yaml", yaml.Codec{}),
+		WithInline("name", "inline"),
+	)
+	assert.Equal(t, "inline", c.String("name"))
+	assert.Equal(t, ":8080", c.String("http.addr"))
+	assert.Equal(t, "error", c.String("log.level"))
+}
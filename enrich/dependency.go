@@ -0,0 +1,113 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+)
+
+// Option is the configuration of the enricher.
+type Option struct {
+	GeoIP GeoIPOption `json:"geoip" yaml:"geoip"`
+	// AccessLog logs each request with the enrichment.
+	AccessLog bool `json:"accessLog" yaml:"accessLog"`
+}
+
+// GeoIPOption configures GeoIP.
+type GeoIPOption struct {
+	// Path is the MaxMind database, eg. GeoLite2-City.mmdb. If empty, the
+	// location is not resolved.
+	Path string `json:"path" yaml:"path"`
+}
+
+/*
+Providers returns a set of dependency providers for package enrich.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		*Metrics `optional:"true"`
+	Provide:
+		*Enricher
+*/
+func Providers() di.Deps {
+	return di.Deps{provideEnricher, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger  log.Logger
+	Conf    contract.ConfigAccessor
+	Metrics *Metrics `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Enricher *Enricher
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideEnricher(in in) (out, func(), error) {
+	var option Option
+	if err := in.Conf.Unmarshal("enrich", &option); err != nil {
+		return out{}, nil, fmt.Errorf("enrich configuration error: %w", err)
+	}
+	var options []EnricherOption
+	if option.AccessLog {
+		options = append(options, WithAccessLog(log.With(in.Logger, "tag", "access")))
+	}
+	if in.Metrics != nil {
+		options = append(options, WithMetrics(in.Metrics))
+	}
+	if option.GeoIP.Path == "" {
+		return out{Enricher: NewEnricher(nil, options...)}, func() {}, nil
+	}
+	geoip, err := NewGeoIP(option.GeoIP.Path, in.Logger)
+	if err != nil {
+		return out{}, nil, err
+	}
+	return out{Enricher: NewEnricher(geoip, options...)}, func() { _ = geoip.Close() }, nil
+}
+
+// ProvideRunGroup reloads the GeoIP database when the file changes.
+func (o out) ProvideRunGroup(group *run.Group) {
+	if o.Enricher.geoip == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		return o.Enricher.geoip.Watch(ctx)
+	}, func(err error) {
+		cancel()
+	})
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "enrich",
+			Data: map[string]interface{}{
+				"enrich": Option{
+					GeoIP:     GeoIPOption{Path: ""},
+					AccessLog: false,
+				},
+			},
+			Comment: "The GeoIP and user agent enrichment",
+		},
+	}}
+}
@@ -0,0 +1,30 @@
+/*
+Package enrich resolves the geographic location and the user agent of incoming
+requests, and puts them into the request context.
+
+The location is looked up in a MaxMind database, such as GeoLite2-City.mmdb. The
+database file is reloaded when it changes, so it can be updated by
+geoipupdate without restarts. Handlers read the enrichment with
+LocationFromContext and UserAgentFromContext.
+
+Integration
+
+package enrich exports the configuration in the following format:
+
+	enrich:
+	  geoip:
+	    path: /usr/share/GeoIP/GeoLite2-City.mmdb
+	  accessLog: false
+
+Add the enrich dependency to core, and the middleware to the HTTP server:
+
+	var c *core.C = core.New()
+	c.Provide(enrich.Providers())
+	c.Invoke(func(router *mux.Router, enricher *enrich.Enricher) {
+		router.Use(enrich.MakeHTTPMiddleware(enricher))
+	})
+
+If *enrich.Metrics is in the graph, eg. from observability.Providers, requests
+are counted by country and device.
+*/
+package enrich
@@ -0,0 +1,172 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// mmdb encodes a minimal IPv4 MaxMind database, where 0.0.0.0/1 is located in
+// the country and 128.0.0.0/1 is not in the database.
+func mmdb(country string) []byte {
+	var buf bytes.Buffer
+	// the search tree has one node with 24 bit records. The left record points
+	// to the first data, the right one means not found.
+	const nodeCount = 1
+	left, right := nodeCount+16, nodeCount
+	buf.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+	buf.Write(make([]byte, 16))
+	encode(&buf, map[string]interface{}{
+		"country":  map[string]interface{}{"iso_code": country},
+		"city":     map[string]interface{}{"names": map[string]interface{}{"en": "Beijing"}},
+		"location": map[string]interface{}{"latitude": 39.9, "longitude": 116.4, "time_zone": "Asia/Shanghai"},
+	})
+	buf.WriteString("\xab\xcd\xefMaxMind.com")
+	encode(&buf, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"database_type":               "Test",
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint32(0),
+	})
+	return buf.Bytes()
+}
+
+func encode(buf *bytes.Buffer, v interface{}) {
+	control := func(typ, size int) { buf.WriteByte(byte(typ<<5 | size)) }
+	uint := func(typ int, n uint64) {
+		var b []byte
+		for ; n > 0; n >>= 8 {
+			b = append([]byte{byte(n)}, b...)
+		}
+		control(typ, len(b))
+		buf.Write(b)
+	}
+	switch v := v.(type) {
+	case string:
+		control(2, len(v))
+		buf.WriteString(v)
+	case float64:
+		control(3, 8)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case uint16:
+		uint(5, uint64(v))
+	case uint32:
+		uint(6, uint64(v))
+	case map[string]interface{}:
+		control(7, len(v))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encode(buf, k)
+			encode(buf, v[k])
+		}
+	}
+}
+
+func TestGeoIP(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "enrich")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.mmdb")
+	ioutil.WriteFile(path, mmdb("CN"), 0600)
+
+	geoip, err := NewGeoIP(path, log.NewNopLogger())
+	assert.NoError(t, err)
+	defer geoip.Close()
+
+	loc, found, err := geoip.Lookup(net.ParseIP("1.2.3.4"))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Location{Country: "CN", City: "Beijing", Latitude: 39.9, Longitude: 116.4, TimeZone: "Asia/Shanghai"}, loc)
+
+	_, found, err = geoip.Lookup(net.ParseIP("200.2.3.4"))
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		geoip.Watch(ctx)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	ioutil.WriteFile(path+".tmp", mmdb("US"), 0600)
+	os.Rename(path+".tmp", path)
+	assert.Eventually(t, func() bool {
+		loc, _, _ := geoip.Lookup(net.ParseIP("1.2.3.4"))
+		return loc.Country == "US"
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestParseUserAgent(t *testing.T) {
+	cases := []struct {
+		header string
+		want   UserAgent
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+			UserAgent{Browser: "Chrome", BrowserVersion: "91.0.4472.124", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 14_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.1 Mobile/15E148 Safari/604.1",
+			UserAgent{Browser: "Safari", BrowserVersion: "14.1.1", OS: "iPhone OS", Device: DeviceMobile},
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			UserAgent{Browser: "Googlebot", BrowserVersion: "2.1", OS: "", Device: DeviceBot},
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, ParseUserAgent(c.header))
+	}
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "enrich")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.mmdb")
+	ioutil.WriteFile(path, mmdb("CN"), 0600)
+	geoip, _ := NewGeoIP(path, log.NewNopLogger())
+	defer geoip.Close()
+
+	var logged []interface{}
+	enricher := NewEnricher(geoip, WithAccessLog(log.LoggerFunc(func(keyvals ...interface{}) error {
+		logged = keyvals
+		return nil
+	})))
+	handler := MakeHTTPMiddleware(enricher)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		loc, ok := LocationFromContext(request.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "CN", loc.Country)
+		ua, ok := UserAgentFromContext(request.Context())
+		assert.True(t, ok)
+		assert.Equal(t, DeviceBot, ua.Device)
+		writer.WriteHeader(http.StatusTeapot)
+	}))
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "1.2.3.4:5678"
+	request.Header.Set("User-Agent", "Googlebot/2.1")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	assert.Contains(t, logged, http.StatusTeapot)
+	assert.Contains(t, logged, "CN")
+}
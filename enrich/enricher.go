@@ -0,0 +1,188 @@
+package enrich
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type contextKey string
+
+const (
+	locationKey  contextKey = "location"
+	userAgentKey contextKey = "userAgent"
+)
+
+// WithLocation returns a copy of the context carrying the location.
+func WithLocation(ctx context.Context, loc Location) context.Context {
+	return context.WithValue(ctx, locationKey, loc)
+}
+
+// LocationFromContext returns the location carried by the context, if any.
+func LocationFromContext(ctx context.Context) (Location, bool) {
+	loc, ok := ctx.Value(locationKey).(Location)
+	return loc, ok
+}
+
+// WithUserAgent returns a copy of the context carrying the user agent.
+func WithUserAgent(ctx context.Context, ua UserAgent) context.Context {
+	return context.WithValue(ctx, userAgentKey, ua)
+}
+
+// UserAgentFromContext returns the user agent carried by the context, if any.
+func UserAgentFromContext(ctx context.Context) (UserAgent, bool) {
+	ua, ok := ctx.Value(userAgentKey).(UserAgent)
+	return ua, ok
+}
+
+// KeyVals returns the location and user agent carried by the context as log
+// key values.
+func KeyVals(ctx context.Context) []interface{} {
+	var kvs []interface{}
+	if loc, ok := LocationFromContext(ctx); ok {
+		kvs = append(kvs, "country", loc.Country, "city", loc.City)
+	}
+	if ua, ok := UserAgentFromContext(ctx); ok {
+		kvs = append(kvs, "browser", ua.Browser, "os", ua.OS, "device", ua.Device)
+	}
+	return kvs
+}
+
+// Metrics is a collection of metrics for enriched requests.
+type Metrics struct {
+	// Requests counts the requests by the "country" and "device" labels.
+	Requests metrics.Counter
+}
+
+// Enricher resolves the location and the user agent of requests.
+type Enricher struct {
+	geoip     *GeoIP
+	accessLog log.Logger
+	metrics   *Metrics
+}
+
+// EnricherOption changes the behavior of Enricher.
+type EnricherOption func(*Enricher)
+
+// WithAccessLog logs each request with the enrichment to the logger.
+func WithAccessLog(logger log.Logger) EnricherOption {
+	return func(enricher *Enricher) {
+		enricher.accessLog = logger
+	}
+}
+
+// WithMetrics counts the requests by country and device.
+func WithMetrics(metrics *Metrics) EnricherOption {
+	return func(enricher *Enricher) {
+		enricher.metrics = metrics
+	}
+}
+
+// NewEnricher creates a new *Enricher. If geoip is nil, only the user agent is
+// resolved.
+func NewEnricher(geoip *GeoIP, options ...EnricherOption) *Enricher {
+	enricher := &Enricher{geoip: geoip}
+	for _, option := range options {
+		option(enricher)
+	}
+	return enricher
+}
+
+// Enrich adds the location of the ip and the parsed user agent to the context.
+func (e *Enricher) Enrich(ctx context.Context, ip string, userAgent string) context.Context {
+	if e.geoip != nil {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			if loc, found, err := e.geoip.Lookup(parsed); err == nil && found {
+				ctx = WithLocation(ctx, loc)
+			}
+		}
+	}
+	if userAgent != "" {
+		ctx = WithUserAgent(ctx, ParseUserAgent(userAgent))
+	}
+	if e.metrics != nil {
+		loc, _ := LocationFromContext(ctx)
+		ua, _ := UserAgentFromContext(ctx)
+		e.metrics.Requests.With("country", loc.Country, "device", ua.Device).Add(1)
+	}
+	return ctx
+}
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that enriches the
+// request context. The client IP is taken from contract.IpKey if present in
+// the context, or the remote address otherwise.
+func MakeHTTPMiddleware(enricher *Enricher) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ip, _ := request.Context().Value(contract.IpKey).(string)
+			if ip == "" {
+				ip, _, _ = net.SplitHostPort(request.RemoteAddr)
+			}
+			ctx := enricher.Enrich(request.Context(), ip, request.UserAgent())
+			request = request.WithContext(ctx)
+			if enricher.accessLog == nil {
+				handler.ServeHTTP(writer, request)
+				return
+			}
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+			handler.ServeHTTP(recorder, request)
+			kvs := []interface{}{
+				"method", request.Method,
+				"path", request.URL.Path,
+				"status", recorder.status,
+				"duration", time.Since(start),
+				"clientIp", ip,
+			}
+			level.Info(enricher.accessLog).Log(append(kvs, KeyVals(ctx)...)...)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that enriches the
+// context from the peer address and the "user-agent" metadata.
+func MakeUnaryInterceptor(enricher *Enricher) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ip, _ := ctx.Value(contract.IpKey).(string)
+		if p, ok := peer.FromContext(ctx); ip == "" && ok {
+			ip, _, _ = net.SplitHostPort(p.Addr.String())
+		}
+		var userAgent string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("user-agent")) > 0 {
+			userAgent = md.Get("user-agent")[0]
+		}
+		ctx = enricher.Enrich(ctx, ip, userAgent)
+		if enricher.accessLog == nil {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		kvs := []interface{}{
+			"method", info.FullMethod,
+			"err", err,
+			"duration", time.Since(start),
+			"clientIp", ip,
+		}
+		level.Info(enricher.accessLog).Log(append(kvs, KeyVals(ctx)...)...)
+		return resp, err
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
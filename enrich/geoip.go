@@ -0,0 +1,125 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/DoNewsCode/core/config/watcher"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is the geographic location of an IP address.
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 country code, eg. "CN".
+	Country string
+	// City is the English city name.
+	City      string
+	Latitude  float64
+	Longitude float64
+	TimeZone  string
+}
+
+// record mirrors the GeoIP2/GeoLite2 City and Country databases.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+		TimeZone  string  `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+}
+
+// GeoIP looks up IP addresses in a MaxMind database. The database can be
+// reloaded while in use. GeoIP is safe for concurrent use.
+type GeoIP struct {
+	path   string
+	logger log.Logger
+
+	mu     sync.RWMutex
+	reader *maxminddb.Reader
+}
+
+// NewGeoIP opens the MaxMind database at the path. The logger receives the
+// reload failures while watching.
+func NewGeoIP(path string, logger log.Logger) (*GeoIP, error) {
+	g := &GeoIP{path: path, logger: logger}
+	if err := g.Reload(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Reload reopens the database file.
+func (g *GeoIP) Reload() error {
+	reader, err := maxminddb.Open(g.path)
+	if err != nil {
+		return fmt.Errorf("failed to open geoip database %s: %w", g.path, err)
+	}
+	g.mu.Lock()
+	old := g.reader
+	g.reader = reader
+	g.mu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// Watch reloads the database whenever the file changes, until the context is
+// canceled. If the new file can't be opened, eg. because it is half written,
+// the database in use is kept. Watch never stops on errors, so that a broken
+// watch doesn't take down the application.
+func (g *GeoIP) Watch(ctx context.Context) error {
+	err := watcher.File{Path: g.path}.Watch(ctx, func() error {
+		if err := g.Reload(); err != nil {
+			level.Warn(g.logger).Log("msg", "failed to reload geoip database", "err", err)
+			return nil
+		}
+		level.Info(g.logger).Log("msg", "geoip database reloaded", "path", g.path)
+		return nil
+	})
+	if err != nil {
+		level.Warn(g.logger).Log("msg", "stopped watching geoip database", "err", err)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// Lookup returns the location of the IP. The found result is false if the IP
+// is not in the database.
+func (g *GeoIP) Lookup(ip net.IP) (loc Location, found bool, err error) {
+	var r record
+
+	g.mu.RLock()
+	_, found, err = g.reader.LookupNetwork(ip, &r)
+	g.mu.RUnlock()
+
+	if err != nil || !found {
+		return Location{}, false, err
+	}
+	return Location{
+		Country:   r.Country.ISOCode,
+		City:      r.City.Names["en"],
+		Latitude:  r.Location.Latitude,
+		Longitude: r.Location.Longitude,
+		TimeZone:  r.Location.TimeZone,
+	}, true, nil
+}
+
+// Close closes the database.
+func (g *GeoIP) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.reader.Close()
+}
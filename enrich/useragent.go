@@ -0,0 +1,40 @@
+package enrich
+
+import (
+	"github.com/mssola/user_agent"
+)
+
+// Device types of UserAgent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceBot     = "bot"
+)
+
+// UserAgent is the parsed User-Agent header.
+type UserAgent struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	// Device is one of DeviceDesktop, DeviceMobile or DeviceBot.
+	Device string
+}
+
+// ParseUserAgent parses the User-Agent header.
+func ParseUserAgent(header string) UserAgent {
+	ua := user_agent.New(header)
+	browser, version := ua.Browser()
+	device := DeviceDesktop
+	switch {
+	case ua.Bot():
+		device = DeviceBot
+	case ua.Mobile():
+		device = DeviceMobile
+	}
+	return UserAgent{
+		Browser:        browser,
+		BrowserVersion: version,
+		OS:             ua.OSInfo().Name,
+		Device:         device,
+	}
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+// ErrDispatcherClosed is returned when dispatching to a closed AsyncDispatcher.
+var ErrDispatcherClosed = errors.New("dispatcher is closed")
+
+// AsyncDispatcher is a contract.Dispatcher implementation that dispatches events
+// to listeners in worker goroutines, so that slow listeners don't block the
+// caller. The listeners of an event are still called sequentially, in the order
+// of subscription. Since the caller doesn't wait, errors returned by listeners
+// are reported to the error handler instead. AsyncDispatcher is safe for
+// concurrent use.
+type AsyncDispatcher struct {
+	SyncDispatcher
+
+	bufferSize   int
+	workers      int
+	errorHandler func(topic interface{}, event interface{}, err error)
+
+	closeLock sync.RWMutex
+	closed    bool
+	queue     chan asyncJob
+	done      sync.WaitGroup
+
+	pendingLock sync.Mutex
+	pending     int
+	// idle is closed when pending drops to zero.
+	idle chan struct{}
+}
+
+type asyncJob struct {
+	ctx   context.Context
+	topic interface{}
+	event interface{}
+}
+
+// AsyncOption changes the behavior of AsyncDispatcher.
+type AsyncOption func(*AsyncDispatcher)
+
+// WithBufferSize sets the number of events that can be queued before Dispatch
+// blocks. Defaults to 1024.
+func WithBufferSize(size int) AsyncOption {
+	return func(dispatcher *AsyncDispatcher) {
+		dispatcher.bufferSize = size
+	}
+}
+
+// WithWorkers sets the number of worker goroutines. Defaults to 4.
+func WithWorkers(workers int) AsyncOption {
+	return func(dispatcher *AsyncDispatcher) {
+		dispatcher.workers = workers
+	}
+}
+
+// WithErrorHandler sets the handler of the errors returned by listeners. By
+// default the errors are discarded.
+func WithErrorHandler(handler func(topic interface{}, event interface{}, err error)) AsyncOption {
+	return func(dispatcher *AsyncDispatcher) {
+		dispatcher.errorHandler = handler
+	}
+}
+
+// NewAsyncDispatcher creates a new *AsyncDispatcher and starts its workers.
+// Close must be called to stop the workers.
+func NewAsyncDispatcher(options ...AsyncOption) *AsyncDispatcher {
+	d := &AsyncDispatcher{
+		bufferSize:   1024,
+		workers:      4,
+		errorHandler: func(topic interface{}, event interface{}, err error) {},
+	}
+	for _, option := range options {
+		option(d)
+	}
+	d.queue = make(chan asyncJob, d.bufferSize)
+	d.done.Add(d.workers)
+	for i := 0; i < d.workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+// Dispatch queues the event and returns immediately, unless the buffer is full,
+// in which case it blocks until there is room or the context is canceled. The
+// listeners receive a context that carries the values of ctx, but isn't
+// canceled with it, since the caller is likely gone by then.
+func (d *AsyncDispatcher) Dispatch(ctx context.Context, topic interface{}, event interface{}) error {
+	if len(d.listeners(topic)) == 0 {
+		return nil
+	}
+
+	d.closeLock.RLock()
+	defer d.closeLock.RUnlock()
+
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+	d.addPending(1)
+	select {
+	case d.queue <- asyncJob{ctx: detached{ctx}, topic: topic, event: event}:
+		return nil
+	case <-ctx.Done():
+		d.addPending(-1)
+		return ctx.Err()
+	}
+}
+
+func (d *AsyncDispatcher) addPending(delta int) {
+	d.pendingLock.Lock()
+	defer d.pendingLock.Unlock()
+
+	if d.pending == 0 {
+		d.idle = make(chan struct{})
+	}
+	d.pending += delta
+	if d.pending == 0 {
+		close(d.idle)
+	}
+}
+
+func (d *AsyncDispatcher) work() {
+	defer d.done.Done()
+
+	for job := range d.queue {
+		// Label the profiles by event, as the workers are shared by all events.
+		pprof.Do(job.ctx, pprof.Labels("event", fmt.Sprint(job.topic)), func(ctx context.Context) {
+			if err := d.SyncDispatcher.Dispatch(ctx, job.topic, job.event); err != nil {
+				d.errorHandler(job.topic, job.event, err)
+			}
+		})
+		d.addPending(-1)
+	}
+}
+
+// Drain waits until no event is queued or being processed, or the context is
+// canceled. Unlike Close, the dispatcher keeps accepting events.
+func (d *AsyncDispatcher) Drain(ctx context.Context) error {
+	d.pendingLock.Lock()
+	if d.pending == 0 {
+		d.pendingLock.Unlock()
+		return nil
+	}
+	idle := d.idle
+	d.pendingLock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events, processes the queued ones and stops the
+// workers. Calling Close more than once is a no-op.
+func (d *AsyncDispatcher) Close() error {
+	d.closeLock.Lock()
+	if d.closed {
+		d.closeLock.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.queue)
+	d.closeLock.Unlock()
+
+	d.done.Wait()
+	return nil
+}
+
+// detached is a context that carries the values of its parent, but not its
+// deadline or cancellation.
+type detached struct {
+	parent context.Context
+}
+
+func (d detached) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (d detached) Done() <-chan struct{}             { return nil }
+func (d detached) Err() error                        { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
+
+var _ contract.Dispatcher = (*AsyncDispatcher)(nil)
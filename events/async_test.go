@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncDispatcher(t *testing.T) {
+	var (
+		processed int32
+		errs      int32
+	)
+	release := make(chan struct{})
+	dispatcher := NewAsyncDispatcher(WithWorkers(2), WithBufferSize(10), WithErrorHandler(func(topic interface{}, event interface{}, err error) {
+		atomic.AddInt32(&errs, 1)
+	}))
+	dispatcher.Subscribe(Listen("foo", func(ctx context.Context, event interface{}) error {
+		<-release
+		assert.NoError(t, ctx.Err())
+		atomic.AddInt32(&processed, 1)
+		if event == "bad" {
+			return errors.New("bad")
+		}
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, dispatcher.Dispatch(ctx, "foo", "good"))
+	}
+	assert.NoError(t, dispatcher.Dispatch(ctx, "foo", "bad"))
+	assert.NoError(t, dispatcher.Dispatch(ctx, "bar", "nobody listens"))
+	// the caller is not blocked by the listeners, and the listeners outlive
+	// the caller context.
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	cancel()
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer drainCancel()
+	assert.Error(t, dispatcher.Drain(drainCtx))
+
+	close(release)
+	assert.NoError(t, dispatcher.Drain(context.Background()))
+	assert.Equal(t, int32(6), atomic.LoadInt32(&processed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&errs))
+
+	assert.NoError(t, dispatcher.Close())
+	assert.NoError(t, dispatcher.Close())
+	assert.Equal(t, ErrDispatcherClosed, dispatcher.Dispatch(context.Background(), "foo", "good"))
+}
+
+func TestAsyncDispatcher_backpressure(t *testing.T) {
+	release := make(chan struct{})
+	dispatcher := NewAsyncDispatcher(WithWorkers(1), WithBufferSize(1))
+	dispatcher.Subscribe(Listen("foo", func(ctx context.Context, event interface{}) error {
+		<-release
+		return nil
+	}))
+	// one event is being processed, and one is buffered.
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", 1))
+	assert.Eventually(t, func() bool { return len(dispatcher.queue) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", 2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, dispatcher.Dispatch(ctx, "foo", 3))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", 4))
+	}()
+	close(release)
+	wg.Wait()
+	assert.NoError(t, dispatcher.Close())
+}
+
+func TestAsyncDispatcher_pattern(t *testing.T) {
+	dispatcher := NewAsyncDispatcher()
+	defer dispatcher.Close()
+
+	received := make(chan interface{}, 1)
+	dispatcher.Subscribe(Listen(Pattern("user.*"), func(ctx context.Context, event interface{}) error {
+		received <- event
+		return nil
+	}))
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "user.created", 1))
+	assert.Equal(t, 1, <-received)
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+// OnDeadLetter is an event of a listener failing to process another event.
+// The event payload is OnDeadLetterPayload.
+const OnDeadLetter event = "onDeadLetter"
+
+// OnDeadLetterPayload is the payload of OnDeadLetter.
+type OnDeadLetterPayload struct {
+	// Topic is the topic of the failed event.
+	Topic interface{}
+	// Event is the failed event.
+	Event interface{}
+	// Listener is the name of the failed listener.
+	Listener string
+	// Err is the error returned by the listener.
+	Err error
+}
+
+// DeadLetterHandler handles the failures of the listeners.
+type DeadLetterHandler func(ctx context.Context, payload OnDeadLetterPayload)
+
+type deadLetterKey struct{}
+
+// Republish creates a DeadLetterHandler that dispatches the failures to the
+// topic, eg. OnDeadLetter, so that they can be logged, retried or stored. The
+// failures of the dead letter listeners are neither dead-lettered nor
+// returned.
+//
+//	dispatcher.SetDeadLetter(events.Republish(dispatcher, events.OnDeadLetter))
+//	dispatcher.Subscribe(events.Listen(events.OnDeadLetter, logDeadLetter))
+func Republish(dispatcher contract.Dispatcher, topic interface{}) DeadLetterHandler {
+	return func(ctx context.Context, payload OnDeadLetterPayload) {
+		_ = dispatcher.Dispatch(ctx, topic, payload)
+	}
+}
@@ -2,43 +2,204 @@ package events
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/DoNewsCode/core/contract"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 )
 
 // SyncDispatcher is a contract.Dispatcher implementation that dispatches events synchronously.
 // SyncDispatcher is safe for concurrent use.
 type SyncDispatcher struct {
-	registry map[interface{}][]contract.Listener
-	rwLock   sync.RWMutex
+	registry   map[interface{}][]contract.Listener
+	patterns   []contract.Listener
+	tracer     opentracing.Tracer
+	deadLetter DeadLetterHandler
+	timeout    time.Duration
+	rwLock     sync.RWMutex
 }
 
 // Dispatch dispatches events synchronously. If any listener returns an error,
-// abort the process immediately and return that error to caller.
+// abort the process immediately and return that error to caller, unless a
+// DeadLetterHandler is set. If the context is canceled, the remaining
+// listeners are not invoked, and the context error is returned.
 func (d *SyncDispatcher) Dispatch(ctx context.Context, topic interface{}, event interface{}) error {
+	listeners := d.listeners(topic)
 	d.rwLock.RLock()
-	listeners, ok := d.registry[topic]
+	tracer := d.tracer
+	deadLetter := d.deadLetter
+	timeout := d.timeout
 	d.rwLock.RUnlock()
 
-	if !ok {
+	// The failures in handling dead letters are not dead-lettered again.
+	if deadLetter == nil || ctx.Value(deadLetterKey{}) != nil {
+		for _, listener := range listeners {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := processWithTimeout(ctx, tracer, timeout, topic, listener, event); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 	for _, listener := range listeners {
-		if err := listener.Process(ctx, event); err != nil {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if err := processWithTimeout(ctx, tracer, timeout, topic, listener, event); err != nil {
+			deadLetter(context.WithValue(ctx, deadLetterKey{}, true), OnDeadLetterPayload{
+				Topic:    topic,
+				Event:    event,
+				Listener: listenerName(listener),
+				Err:      err,
+			})
+		}
 	}
 	return nil
 }
 
-// Subscribe subscribes the listener to the dispatcher.
+// SetDeadLetter makes the dispatcher invoke all listeners even if some fail.
+// Each failure is passed to the handler instead of being returned by
+// Dispatch, so that one broken listener doesn't break the others.
+func (d *SyncDispatcher) SetDeadLetter(handler DeadLetterHandler) {
+	d.rwLock.Lock()
+	defer d.rwLock.Unlock()
+
+	d.deadLetter = handler
+}
+
+// SetTracer makes the dispatcher create a span for each listener invocation,
+// tagged with the topic and the listener name. The span is a child of the span
+// in the context, if any.
+func (d *SyncDispatcher) SetTracer(tracer opentracing.Tracer) {
+	d.rwLock.Lock()
+	defer d.rwLock.Unlock()
+
+	d.tracer = tracer
+}
+
+func process(ctx context.Context, tracer opentracing.Tracer, topic interface{}, listener contract.Listener, event interface{}) error {
+	if tracer == nil {
+		return listener.Process(ctx, event)
+	}
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, fmt.Sprintf("event %v", topic))
+	defer span.Finish()
+
+	span.SetTag("topic", fmt.Sprint(topic))
+	span.SetTag("listener", listenerName(listener))
+	err := listener.Process(ctx, event)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+	}
+	return err
+}
+
+func listenerName(listener contract.Listener) string {
+	if stringer, ok := listener.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", listener)
+}
+
+// Subscribe subscribes the listener to the dispatcher. Listeners with a higher
+// priority are invoked first, see Prioritized. Listeners of the same priority
+// are invoked in the order of subscription, except that the listeners of a
+// Pattern or MatchAll are invoked after the ones of the exact topic.
 func (d *SyncDispatcher) Subscribe(listener contract.Listener) {
 	d.rwLock.Lock()
 	defer d.rwLock.Unlock()
 
+	if isPattern(listener.Listen()) {
+		d.patterns = insertByPriority(d.patterns, listener)
+		return
+	}
 	if d.registry == nil {
 		d.registry = make(map[interface{}][]contract.Listener)
 	}
-	d.registry[listener.Listen()] = append(d.registry[listener.Listen()], listener)
+	d.registry[listener.Listen()] = insertByPriority(d.registry[listener.Listen()], listener)
+}
+
+// Unsubscribe removes the listener from the dispatcher. Listeners are compared
+// by identity, so pass the same value that was subscribed, eg. the
+// *ListenerFunc returned by Listen. Listeners of types that can't be compared
+// can't be unsubscribed.
+func (d *SyncDispatcher) Unsubscribe(listener contract.Listener) {
+	if !reflect.TypeOf(listener).Comparable() {
+		return
+	}
+	d.rwLock.Lock()
+	defer d.rwLock.Unlock()
+
+	if isPattern(listener.Listen()) {
+		d.patterns = remove(d.patterns, listener)
+		return
+	}
+	listeners := remove(d.registry[listener.Listen()], listener)
+	if len(listeners) == 0 {
+		delete(d.registry, listener.Listen())
+		return
+	}
+	d.registry[listener.Listen()] = listeners
+}
+
+// listeners returns the listeners of the topic, including the ones of the
+// matching patterns, ordered by priority.
+func (d *SyncDispatcher) listeners(topic interface{}) []contract.Listener {
+	d.rwLock.RLock()
+	defer d.rwLock.RUnlock()
+
+	listeners := d.registry[topic]
+	for _, listener := range d.patterns {
+		if matches(listener.Listen(), topic) {
+			listeners = insertByPriority(listeners, listener)
+		}
+	}
+	return listeners
+}
+
+// insertByPriority inserts the listener after the listeners of a higher or
+// equal priority. The listeners are copied, since Dispatch may be iterating
+// the old slice.
+func insertByPriority(listeners []contract.Listener, listener contract.Listener) []contract.Listener {
+	i := sort.Search(len(listeners), func(i int) bool {
+		return priorityOf(listeners[i]) < priorityOf(listener)
+	})
+	inserted := make([]contract.Listener, 0, len(listeners)+1)
+	inserted = append(inserted, listeners[:i]...)
+	inserted = append(inserted, listener)
+	inserted = append(inserted, listeners[i:]...)
+	return inserted
+}
+
+// remove removes the listener. Like insertByPriority, the listeners are
+// copied.
+func remove(listeners []contract.Listener, listener contract.Listener) []contract.Listener {
+	removed := make([]contract.Listener, 0, len(listeners))
+	for _, l := range listeners {
+		if reflect.TypeOf(l).Comparable() && l == listener {
+			continue
+		}
+		removed = append(removed, l)
+	}
+	return removed
+}
+
+// Topics returns the topics that have at least one listener. The patterns are
+// not included.
+func (d *SyncDispatcher) Topics() []interface{} {
+	d.rwLock.RLock()
+	defer d.rwLock.RUnlock()
+
+	topics := make([]interface{}, 0, len(d.registry))
+	for topic := range d.registry {
+		topics = append(topics, topic)
+	}
+	return topics
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -133,3 +135,150 @@ func TestDispatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestDispatcher_priority(t *testing.T) {
+	var order []string
+	record := func(name string) func(ctx context.Context, event interface{}) error {
+		return func(ctx context.Context, event interface{}) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	dispatcher := &SyncDispatcher{}
+	dispatcher.Subscribe(Listen("foo", record("business")))
+	dispatcher.Subscribe(ListenWithPriority("foo", -10, record("cleanup")))
+	dispatcher.Subscribe(ListenWithPriority("foo", 100, record("audit")))
+	dispatcher.Subscribe(MockListener{"foo", func(event interface{}) error {
+		order = append(order, "mock")
+		return nil
+	}})
+	dispatcher.Subscribe(ListenWithPriority("foo", 100, record("audit2")))
+	dispatcher.Subscribe(WithPriority(MockListener{"foo", func(event interface{}) error {
+		order = append(order, "validate")
+		return nil
+	}}, 50))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	assert.Equal(t, []string{"audit", "audit2", "validate", "business", "mock", "cleanup"}, order)
+}
+
+func TestDispatcher_pattern(t *testing.T) {
+	var order []string
+	record := func(name string) func(ctx context.Context, event interface{}) error {
+		return func(ctx context.Context, event interface{}) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	dispatcher := &SyncDispatcher{}
+	dispatcher.Subscribe(Listen(Pattern("user.*"), record("user.*")))
+	dispatcher.Subscribe(Listen("user.created", record("user.created")))
+	dispatcher.Subscribe(ListenWithPriority(MatchAll, 100, record("all")))
+	dispatcher.Subscribe(Listen(Pattern("on*"), record("on*")))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "user.created", nil))
+	assert.Equal(t, []string{"all", "user.created", "user.*"}, order)
+
+	order = nil
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), OnReload, nil))
+	assert.Equal(t, []string{"all", "on*"}, order)
+
+	order = nil
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), MockEvent{}, nil))
+	assert.Equal(t, []string{"all"}, order)
+	assert.Equal(t, []interface{}{"user.created"}, dispatcher.Topics())
+}
+
+func TestDispatcher_Unsubscribe(t *testing.T) {
+	var count int
+	increment := func(ctx context.Context, event interface{}) error {
+		count++
+		return nil
+	}
+	dispatcher := &SyncDispatcher{}
+	listener := Listen("foo", increment)
+	pattern := Listen(Pattern("f*"), increment)
+	dispatcher.Subscribe(listener)
+	dispatcher.Subscribe(pattern)
+	dispatcher.Subscribe(MockListener{"foo", func(event interface{}) error { return nil }})
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	assert.Equal(t, 2, count)
+
+	dispatcher.Unsubscribe(listener)
+	dispatcher.Unsubscribe(pattern)
+	dispatcher.Unsubscribe(MockListener{"foo", nil})
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	assert.Equal(t, 2, count)
+	assert.Len(t, dispatcher.Topics(), 1)
+}
+
+func TestSubscribeOnce(t *testing.T) {
+	var count int
+	underlying := &SyncDispatcher{}
+	dispatcher := ChainDispatcher(underlying, Recovery())
+	SubscribeOnce(dispatcher, Listen("foo", func(ctx context.Context, event interface{}) error {
+		count++
+		return nil
+	}))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	assert.Equal(t, 1, count)
+	assert.Empty(t, underlying.Topics())
+}
+
+func TestDispatcher_tracing(t *testing.T) {
+	tracer := mocktracer.New()
+	dispatcher := &SyncDispatcher{}
+	dispatcher.SetTracer(tracer)
+	dispatcher.Subscribe(Listen("foo", func(ctx context.Context, event interface{}) error {
+		return nil
+	}))
+	dispatcher.Subscribe(MockListener{"foo", func(event interface{}) error {
+		return fmt.Errorf("bar")
+	}})
+
+	span, ctx := opentracing.StartSpanFromContextWithTracer(context.Background(), tracer, "parent")
+	assert.Error(t, dispatcher.Dispatch(ctx, "foo", nil))
+	span.Finish()
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "event foo", spans[0].OperationName)
+	assert.Equal(t, "foo", spans[0].Tag("topic"))
+	assert.Contains(t, spans[0].Tag("listener"), "TestDispatcher_tracing")
+	assert.Equal(t, spans[2].SpanContext.SpanID, spans[0].ParentID)
+	assert.Equal(t, "events.MockListener", spans[1].Tag("listener"))
+	assert.Equal(t, true, spans[1].Tag("error"))
+}
+
+func TestDispatcher_deadLetter(t *testing.T) {
+	var (
+		called      []string
+		deadLetters []OnDeadLetterPayload
+	)
+	dispatcher := &SyncDispatcher{}
+	dispatcher.SetDeadLetter(Republish(dispatcher, OnDeadLetter))
+	dispatcher.Subscribe(MockListener{"foo", func(event interface{}) error {
+		called = append(called, "broken")
+		return fmt.Errorf("broken")
+	}})
+	dispatcher.Subscribe(MockListener{"foo", func(event interface{}) error {
+		called = append(called, "healthy")
+		return nil
+	}})
+	dispatcher.Subscribe(Listen(OnDeadLetter, func(ctx context.Context, event interface{}) error {
+		deadLetters = append(deadLetters, event.(OnDeadLetterPayload))
+		// not dead-lettered again.
+		return fmt.Errorf("dead letter listener failed")
+	}))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", 1))
+	assert.Equal(t, []string{"broken", "healthy"}, called)
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, "foo", deadLetters[0].Topic)
+	assert.Equal(t, 1, deadLetters[0].Event)
+	assert.Equal(t, "events.MockListener", deadLetters[0].Listener)
+	assert.EqualError(t, deadLetters[0].Err, "broken")
+}
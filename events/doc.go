@@ -6,10 +6,45 @@ listeners in a synchronous, sequential execution. The synchronous listener is
 only a "go" away from an asynchronous handler, but asynchronous listener can not
 be easily made synchronous.
 
+When the listeners are slow, and the caller shouldn't wait for them, eg. in HTTP
+handlers, use AsyncDispatcher. It processes the events in worker goroutines
+with a bounded buffer. Drain or Close it on shutdown so that no queued event is
+lost.
+
+Listeners are invoked in the order of subscription. To run some listeners
+first regardless, eg. auditing before business logic, register them with
+ListenWithPriority, implement Prioritized, or wrap them with WithPriority.
+Listeners of the same priority keep the order of subscription.
+
+A listener can also subscribe to a topic pattern, eg. Pattern("user.*"), to
+receive the events of every matching string topic, or to MatchAll to receive
+every event. This enables generic listeners such as auditing and logging.
+
+Listeners can be removed with Unsubscribe. Temporary listeners that only care
+about the next event can be subscribed with SubscribeOnce.
+
+Cross-cutting concerns that apply to every Dispatch call, such as logging and
+recovery, are added by wrapping the dispatcher with middlewares, see
+ChainDispatcher.
+
+By default, Dispatch stops at the first failing listener. With SetDeadLetter,
+all listeners are invoked, and the failures are handed to a DeadLetterHandler,
+eg. Republish to the OnDeadLetter topic.
+
+With SetTimeout, or WithTimeout for a single listener, Dispatch gives up on
+listeners that run too long, so that one stuck listener can't hang the caller.
+Dispatch also stops invoking listeners once the context is canceled.
+
+With SetTracer, each listener invocation is traced as a span tagged with the
+topic and the listener. The serve command sets the opentracing.Tracer, if any
+is provided, on the core dispatcher.
+
 The event listeners can also be used as hooks. If the event data is a pointer type,
 listeners may alter the data. This enables plugin/addon style decoupling.
 
 Note: Package event focus on events within the system, not events outsource to
-eternal system. For that, use a message queue like kafka.
+eternal system. For that, use a message queue like kafka. To dispatch events
+across instances of the same system, see package eventskafka, or package
+eventsredis for lightweight notifications.
 */
 package events
@@ -1,6 +1,10 @@
 package events
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/DoNewsCode/core/contract"
 )
 
@@ -14,3 +18,104 @@ type OnReloadPayload struct {
 	// NewConf is the latest configuration after the reload.
 	NewConf contract.ConfigAccessor
 }
+
+// OnConfigKeyChanged is an event dispatched by a configuration reload for every
+// key whose value is added, changed or removed, before OnReload. The event
+// payload is OnConfigKeyChangedPayload.
+const OnConfigKeyChanged event = "onConfigKeyChanged"
+
+// OnConfigKeyChangedPayload is the payload of OnConfigKeyChanged.
+type OnConfigKeyChangedPayload struct {
+	// Key is the flattened key path, eg. "gorm.default.dsn".
+	Key string
+	// Old is the value before the reload, or nil if the key is added.
+	Old interface{}
+	// New is the value after the reload, or nil if the key is removed.
+	New interface{}
+}
+
+// OnConnectionClosing is an event dispatched by di.Factory before a connection
+// is closed, so that the long-running consumers of the connection can finish
+// or checkpoint. The event payload is OnConnectionClosingPayload.
+const OnConnectionClosing event = "onConnectionClosing"
+
+// The reasons of OnConnectionClosing.
+const (
+	// CloseReasonReload means the connection is closed because its
+	// configuration is changed. It is recreated by the next Make.
+	CloseReasonReload = "reload"
+	// CloseReasonShutdown means the application is shutting down.
+	CloseReasonShutdown = "shutdown"
+)
+
+// OnConnectionClosingPayload is the payload of OnConnectionClosing. The
+// listeners can delay or veto the closing through the embedded CloseControl:
+//
+//	dispatcher.Subscribe(events.Listen(events.OnConnectionClosing, func(ctx context.Context, event interface{}) error {
+//		payload := event.(events.OnConnectionClosingPayload)
+//		if payload.Conn != reader {
+//			return nil
+//		}
+//		done := payload.Delay()
+//		go func() {
+//			defer done()
+//			commitBatch()
+//		}()
+//		return nil
+//	}))
+type OnConnectionClosingPayload struct {
+	// Kind is the kind of the connection, eg. "gorm" or "kafka.reader".
+	Kind string
+	// Name is the name of the connection, eg. "default".
+	Name string
+	// Conn is the connection being closed.
+	Conn interface{}
+	// Reason is either CloseReasonReload or CloseReasonShutdown.
+	Reason string
+	*CloseControl
+}
+
+// CloseControl lets the listeners of OnConnectionClosing delay or veto the
+// closing. It is safe for concurrent use.
+type CloseControl struct {
+	wg     sync.WaitGroup
+	vetoed int32
+}
+
+// Delay postpones the closing until done is called, or the close timeout of
+// the factory is reached. It must be called before the listener returns.
+func (c *CloseControl) Delay() (done func()) {
+	c.wg.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(c.wg.Done)
+	}
+}
+
+// Veto keeps the connection open. It is only honored for CloseReasonReload,
+// in which case the connection keeps serving with the old configuration until
+// the next reload.
+func (c *CloseControl) Veto() {
+	atomic.StoreInt32(&c.vetoed, 1)
+}
+
+// Vetoed reports whether any listener has vetoed the closing.
+func (c *CloseControl) Vetoed() bool {
+	return atomic.LoadInt32(&c.vetoed) == 1
+}
+
+// Wait waits for the delays to be done, and reports false if the timeout is
+// reached first.
+func (c *CloseControl) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
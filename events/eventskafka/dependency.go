@@ -0,0 +1,111 @@
+package eventskafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/codec"
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/otkafka"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+)
+
+// Config is the configuration of Dispatcher.
+type Config struct {
+	// Writer is the name of the otkafka writer that publishes the events.
+	Writer string `json:"writer" yaml:"writer"`
+	// Reader is the name of the otkafka reader that consumes the events.
+	Reader string `json:"reader" yaml:"reader"`
+	// Codec is the name of the codec of the events, such as json, msgpack,
+	// protobuf or gob. See package codec. Defaults to json.
+	Codec string `json:"codec" yaml:"codec"`
+}
+
+/*
+Providers returns a set of dependency providers for *Dispatcher. The kafka
+writer and reader are made by otkafka.Providers.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		otkafka.WriterMaker
+		otkafka.ReaderMaker
+	Provide:
+		*Dispatcher
+*/
+func Providers() di.Deps {
+	return di.Deps{provideDispatcher, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger      log.Logger
+	Conf        contract.ConfigAccessor
+	WriterMaker otkafka.WriterMaker
+	ReaderMaker otkafka.ReaderMaker
+}
+
+type out struct {
+	di.Out
+
+	Dispatcher *Dispatcher
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideDispatcher(in in) (out, error) {
+	var conf Config
+	if err := in.Conf.Unmarshal("eventsKafka", &conf); err != nil {
+		return out{}, fmt.Errorf("eventsKafka configuration error: %w", err)
+	}
+	c, err := codec.Get(conf.Codec)
+	if err != nil {
+		return out{}, fmt.Errorf("eventsKafka configuration error: %w", err)
+	}
+	writer, err := in.WriterMaker.Make(conf.Writer)
+	if err != nil {
+		return out{}, fmt.Errorf("failed to make kafka writer %s: %w", conf.Writer, err)
+	}
+	reader, err := in.ReaderMaker.Make(conf.Reader)
+	if err != nil {
+		return out{}, fmt.Errorf("failed to make kafka reader %s: %w", conf.Reader, err)
+	}
+	return out{Dispatcher: NewDispatcher(writer, reader, WithLogger(in.Logger), WithCodec(c))}, nil
+}
+
+// ProvideRunGroup consumes the events in background.
+func (o out) ProvideRunGroup(group *run.Group) {
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		return o.Dispatcher.Run(ctx)
+	}, func(err error) {
+		cancel()
+	})
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "eventsKafka",
+			Data: map[string]interface{}{
+				"eventsKafka": Config{
+					Writer: "events",
+					Reader: "events",
+					Codec:  "json",
+				},
+			},
+			Comment: "The otkafka writer and reader that carry the distributed events",
+		},
+	}}
+}
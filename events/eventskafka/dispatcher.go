@@ -0,0 +1,201 @@
+package eventskafka
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime/pprof"
+	"sync"
+
+	"github.com/DoNewsCode/core/codec/json"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/timeout"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/segmentio/kafka-go"
+)
+
+// headerEvent is the message header that carries the event name.
+const headerEvent = "event"
+
+var _ contract.Dispatcher = (*Dispatcher)(nil)
+
+// Writer publishes messages to kafka, eg. *kafka.Writer.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Reader consumes messages from kafka, eg. *kafka.Reader.
+type Reader interface {
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+}
+
+type binding struct {
+	topic interface{}
+	name  string
+	typ   reflect.Type
+}
+
+// Dispatcher is a contract.Dispatcher that carries events across process
+// boundaries. The events of the registered topics are encoded and published to
+// kafka, and then consumed by Run on every instance, including the one
+// publishing, and delivered to the local listeners. The events of the other
+// topics are dispatched locally and synchronously. Dispatcher is safe for
+// concurrent use.
+type Dispatcher struct {
+	events.SyncDispatcher
+
+	writer Writer
+	reader Reader
+	codec  contract.Codec
+	logger log.Logger
+
+	rwLock  sync.RWMutex
+	byTopic map[interface{}]binding
+	byName  map[string]binding
+}
+
+// Option changes the behavior of Dispatcher.
+type Option func(*Dispatcher)
+
+// WithCodec sets the codec of the events. Defaults to JSON.
+func WithCodec(codec contract.Codec) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.codec = codec
+	}
+}
+
+// WithLogger sets the logger for the errors of the remote listeners.
+func WithLogger(logger log.Logger) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.logger = logger
+	}
+}
+
+// NewDispatcher creates a new *Dispatcher. The writer and the reader should
+// point to the same kafka topic.
+func NewDispatcher(writer Writer, reader Reader, options ...Option) *Dispatcher {
+	dispatcher := &Dispatcher{
+		writer:  writer,
+		reader:  reader,
+		codec:   json.NewCodec(),
+		logger:  log.NewNopLogger(),
+		byTopic: make(map[interface{}]binding),
+		byName:  make(map[string]binding),
+	}
+	for _, option := range options {
+		option(dispatcher)
+	}
+	return dispatcher
+}
+
+// Register makes the events of the topic distributed. The name identifies the
+// topic across processes, and the prototype is an event value whose type the
+// received events are decoded into. Typically:
+//
+//	dispatcher.Register(OnOrderCreated, "orderCreated", OrderCreatedPayload{})
+func (d *Dispatcher) Register(topic interface{}, name string, prototype interface{}) {
+	b := binding{topic: topic, name: name, typ: reflect.TypeOf(prototype)}
+
+	d.rwLock.Lock()
+	defer d.rwLock.Unlock()
+
+	d.byTopic[topic] = b
+	d.byName[name] = b
+}
+
+// Dispatch publishes the event to kafka if the topic is registered, otherwise
+// dispatches it locally. Publishing doesn't wait for the remote listeners. The
+// deadline of the context, if any, is published along with the event, and the
+// remote listeners receive it in their context.
+func (d *Dispatcher) Dispatch(ctx context.Context, topic interface{}, event interface{}) error {
+	d.rwLock.RLock()
+	b, ok := d.byTopic[topic]
+	d.rwLock.RUnlock()
+
+	if !ok {
+		return d.SyncDispatcher.Dispatch(ctx, topic, event)
+	}
+	value, err := d.codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", b.name, err)
+	}
+	headers := []kafka.Header{{Key: headerEvent, Value: []byte(b.name)}}
+	if deadline, ok := ctx.Deadline(); ok {
+		headers = append(headers, kafka.Header{Key: timeout.DeadlineHeader, Value: []byte(timeout.FormatDeadline(deadline))})
+	}
+	return d.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(b.name),
+		Value:   value,
+		Headers: headers,
+	})
+}
+
+// Run consumes the events from kafka and delivers them to the local
+// listeners, until the context is canceled. The errors of the listeners are
+// logged rather than returned, so that one bad event doesn't stop the
+// consumption.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	for {
+		message, err := d.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := d.deliver(ctx, message); err != nil {
+			level.Warn(d.logger).Log("msg", "failed to process event", "err", err)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, message kafka.Message) error {
+	var name string
+	for _, header := range message.Headers {
+		switch header.Key {
+		case headerEvent:
+			name = string(header.Value)
+		case timeout.DeadlineHeader:
+			if deadline, err := timeout.ParseDeadline(string(header.Value)); err == nil {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, deadline)
+				defer cancel()
+			}
+		}
+	}
+
+	d.rwLock.RLock()
+	b, ok := d.byName[name]
+	d.rwLock.RUnlock()
+
+	if !ok {
+		// Not interested. Other instances may have registered more topics.
+		return nil
+	}
+	event, err := d.decode(b, message.Value)
+	if err != nil {
+		return fmt.Errorf("failed to decode event %s: %w", b.name, err)
+	}
+	pprof.Do(ctx, pprof.Labels("event", b.name), func(ctx context.Context) {
+		err = d.SyncDispatcher.Dispatch(ctx, b.topic, event)
+	})
+	return err
+}
+
+func (d *Dispatcher) decode(b binding, data []byte) (interface{}, error) {
+	if b.typ == nil {
+		var event interface{}
+		err := d.codec.Unmarshal(data, &event)
+		return event, err
+	}
+	if b.typ.Kind() == reflect.Ptr {
+		event := reflect.New(b.typ.Elem())
+		err := d.codec.Unmarshal(data, event.Interface())
+		return event.Interface(), err
+	}
+	event := reflect.New(b.typ)
+	err := d.codec.Unmarshal(data, event.Interface())
+	return event.Elem().Interface(), err
+}
@@ -0,0 +1,128 @@
+package eventskafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/codec"
+	"github.com/DoNewsCode/core/events"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// loopback is a fake kafka topic that delivers the written messages to the
+// reader.
+type loopback chan kafka.Message
+
+func (l loopback) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		l <- msg
+	}
+	return nil
+}
+
+func (l loopback) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-l:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+type orderCreated struct {
+	ID    int    `json:"id"`
+	Buyer string `json:"buyer"`
+}
+
+func TestDispatcher(t *testing.T) {
+	topic := make(loopback, 10)
+	publisher := NewDispatcher(topic, topic)
+	consumer := NewDispatcher(topic, topic)
+	publisher.Register("order", "order", orderCreated{})
+	consumer.Register("order", "order", orderCreated{})
+	consumer.Register("ptr", "ptr", &orderCreated{})
+
+	received := make(chan interface{}, 10)
+	listener := func(ctx context.Context, event interface{}) error {
+		received <- event
+		return nil
+	}
+	consumer.Subscribe(events.Listen("order", listener))
+	consumer.Subscribe(events.Listen("ptr", listener))
+	consumer.Subscribe(events.Listen("local", listener))
+	consumer.Subscribe(events.Listen("order", func(ctx context.Context, event interface{}) error {
+		return errors.New("doesn't stop the consumption")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+
+	// the publisher doesn't know the "ptr" topic, so it stays local there.
+	assert.NoError(t, publisher.Dispatch(context.Background(), "ptr", &orderCreated{ID: 2}))
+	assert.NoError(t, publisher.Dispatch(context.Background(), "order", orderCreated{ID: 1, Buyer: "foo"}))
+	assert.Equal(t, orderCreated{ID: 1, Buyer: "foo"}, <-received)
+
+	assert.NoError(t, consumer.Dispatch(context.Background(), "ptr", &orderCreated{ID: 3}))
+	assert.Equal(t, &orderCreated{ID: 3}, <-received)
+
+	assert.NoError(t, consumer.Dispatch(context.Background(), "local", "bar"))
+	assert.Equal(t, "bar", <-received)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run should return when the context is canceled")
+	}
+	assert.Len(t, received, 0)
+}
+
+func TestDispatcher_codec(t *testing.T) {
+	c, err := codec.Get("msgpack")
+	assert.NoError(t, err)
+
+	topic := make(loopback, 10)
+	dispatcher := NewDispatcher(topic, topic, WithCodec(c))
+	dispatcher.Register("order", "order", orderCreated{})
+	received := make(chan interface{}, 1)
+	dispatcher.Subscribe(events.Listen("order", func(ctx context.Context, event interface{}) error {
+		received <- event
+		return nil
+	}))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "order", orderCreated{ID: 1, Buyer: "foo"}))
+	message := <-topic
+	var m map[string]interface{}
+	assert.Error(t, json.Unmarshal(message.Value, &m), "the event should be encoded as msgpack")
+	assert.NoError(t, dispatcher.deliver(context.Background(), message))
+	assert.Equal(t, orderCreated{ID: 1, Buyer: "foo"}, <-received)
+}
+
+func TestDispatcher_deadline(t *testing.T) {
+	topic := make(loopback, 10)
+	dispatcher := NewDispatcher(topic, topic)
+	dispatcher.Register("order", "order", orderCreated{})
+	deadlines := make(chan time.Time, 2)
+	dispatcher.Subscribe(events.Listen("order", func(ctx context.Context, event interface{}) error {
+		d, _ := ctx.Deadline()
+		deadlines <- d
+		return nil
+	}))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "order", orderCreated{ID: 1}))
+	assert.NoError(t, dispatcher.deliver(context.Background(), <-topic))
+	assert.True(t, (<-deadlines).IsZero())
+
+	deadline := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	assert.NoError(t, dispatcher.Dispatch(ctx, "order", orderCreated{ID: 2}))
+	assert.NoError(t, dispatcher.deliver(context.Background(), <-topic))
+	assert.True(t, deadline.Equal(<-deadlines))
+}
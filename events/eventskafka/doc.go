@@ -0,0 +1,50 @@
+/*
+Package eventskafka provides a contract.Dispatcher backed by kafka, so that
+events can cross process boundaries.
+
+Events of the registered topics are encoded, JSON by default, and published to
+a kafka topic. Every instance consumes the kafka topic, and dispatches the
+events to its own listeners. To deliver each event to all instances, give each
+instance its own consumer group. To deliver each event to one instance, share
+the consumer group. Events of the other topics never leave the process.
+
+Integration
+
+The package exports the configuration in the following format:
+
+	eventsKafka:
+	  writer: events
+	  reader: events
+	  codec: json
+
+The writer and reader name the otkafka entries. The codec is one of json,
+msgpack, protobuf and gob, or the name given to codec.Register. All instances
+must use the same codec.
+
+	kafka:
+	  writer:
+	    events:
+	      brokers:
+	        - 127.0.0.1:9092
+	      topic: events
+	  reader:
+	    events:
+	      brokers:
+	        - 127.0.0.1:9092
+	      topic: events
+	      groupId: my-instance
+
+Add the dependencies to core, and register the distributed topics:
+
+	var c *core.C = core.New()
+	c.Provide(otkafka.Providers())
+	c.Provide(eventskafka.Providers())
+	c.Invoke(func(dispatcher *eventskafka.Dispatcher) {
+		dispatcher.Register(OnOrderCreated, "orderCreated", OrderCreatedPayload{})
+		dispatcher.Subscribe(events.Listen(OnOrderCreated, handle))
+	})
+
+Listeners receive the events dispatched by any instance. The errors they
+return are logged rather than sent back to the publisher.
+*/
+package eventskafka
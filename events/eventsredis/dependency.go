@@ -0,0 +1,112 @@
+package eventsredis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/codec"
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/key"
+	"github.com/DoNewsCode/core/otredis"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+)
+
+// Config is the configuration of Dispatcher.
+type Config struct {
+	// Redis is the name of the otredis client.
+	Redis string `json:"redis" yaml:"redis"`
+	// Codec is the name of the codec of the events, such as json, msgpack,
+	// protobuf or gob. See package codec. Defaults to json.
+	Codec string `json:"codec" yaml:"codec"`
+}
+
+/*
+Providers returns a set of dependency providers for *Dispatcher. The redis
+client is made by otredis.Providers. The channels are prefixed by the AppName
+and Env.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		contract.AppName
+		contract.Env
+		otredis.Maker
+	Provide:
+		*Dispatcher
+*/
+func Providers() di.Deps {
+	return di.Deps{provideDispatcher, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger  log.Logger
+	Conf    contract.ConfigAccessor
+	AppName contract.AppName
+	Env     contract.Env
+	Maker   otredis.Maker
+}
+
+type out struct {
+	di.Out
+
+	Dispatcher *Dispatcher
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideDispatcher(in in) (out, error) {
+	var conf Config
+	if err := in.Conf.Unmarshal("eventsRedis", &conf); err != nil {
+		return out{}, fmt.Errorf("eventsRedis configuration error: %w", err)
+	}
+	if conf.Redis == "" {
+		conf.Redis = "default"
+	}
+	c, err := codec.Get(conf.Codec)
+	if err != nil {
+		return out{}, fmt.Errorf("eventsRedis configuration error: %w", err)
+	}
+	client, err := in.Maker.Make(conf.Redis)
+	if err != nil {
+		return out{}, fmt.Errorf("failed to make redis client %s: %w", conf.Redis, err)
+	}
+	keyer := key.New(in.AppName.String(), in.Env.String(), "events")
+	return out{Dispatcher: NewDispatcher(client, keyer, WithLogger(in.Logger), WithCodec(c))}, nil
+}
+
+// ProvideRunGroup receives the events in background.
+func (o out) ProvideRunGroup(group *run.Group) {
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		return o.Dispatcher.Run(ctx)
+	}, func(err error) {
+		cancel()
+	})
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "eventsRedis",
+			Data: map[string]interface{}{
+				"eventsRedis": Config{
+					Redis: "default",
+					Codec: "json",
+				},
+			},
+			Comment: "The otredis client that carries the distributed events",
+		},
+	}}
+}
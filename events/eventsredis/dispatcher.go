@@ -0,0 +1,206 @@
+package eventsredis
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/codec/json"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-redis/redis/v8"
+)
+
+var _ contract.Dispatcher = (*Dispatcher)(nil)
+
+type binding struct {
+	topic interface{}
+	name  string
+	typ   reflect.Type
+}
+
+// Dispatcher is a contract.Dispatcher that bridges events over redis pub/sub.
+// It is meant for lightweight notifications, such as config reloads and cache
+// invalidations: redis pub/sub delivers at most once, and events published
+// while an instance is disconnected are lost.
+//
+// The events of the registered topics are encoded and published to the
+// channel "<prefix>:<name>", and then received by Run on every instance,
+// including the one publishing, and delivered to the local listeners. The
+// events of the other topics are dispatched locally and synchronously.
+// Dispatcher is safe for concurrent use.
+type Dispatcher struct {
+	events.SyncDispatcher
+
+	client     redis.UniversalClient
+	keyer      contract.Keyer
+	codec      contract.Codec
+	logger     log.Logger
+	maxBackoff time.Duration
+
+	rwLock  sync.RWMutex
+	byTopic map[interface{}]binding
+	byName  map[string]binding
+}
+
+// Option changes the behavior of Dispatcher.
+type Option func(*Dispatcher)
+
+// WithCodec sets the codec of the events. Defaults to JSON.
+func WithCodec(codec contract.Codec) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.codec = codec
+	}
+}
+
+// WithLogger sets the logger for the connection errors and the errors of the
+// remote listeners.
+func WithLogger(logger log.Logger) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.logger = logger
+	}
+}
+
+// WithMaxBackoff sets the maximum wait between reconnection attempts.
+// Defaults to 30 seconds.
+func WithMaxBackoff(duration time.Duration) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.maxBackoff = duration
+	}
+}
+
+// NewDispatcher creates a new *Dispatcher. The channels are prefixed by the
+// keyer, typically key.New(appName.String(), env.String()), so that apps and
+// environments sharing a redis don't receive each other's events.
+func NewDispatcher(client redis.UniversalClient, keyer contract.Keyer, options ...Option) *Dispatcher {
+	dispatcher := &Dispatcher{
+		client:     client,
+		keyer:      keyer,
+		codec:      json.NewCodec(),
+		logger:     log.NewNopLogger(),
+		maxBackoff: 30 * time.Second,
+		byTopic:    make(map[interface{}]binding),
+		byName:     make(map[string]binding),
+	}
+	for _, option := range options {
+		option(dispatcher)
+	}
+	return dispatcher
+}
+
+// Register makes the events of the topic distributed. The name identifies the
+// topic across processes, and the prototype is an event value whose type the
+// received events are decoded into. Typically:
+//
+//	dispatcher.Register(OnCacheInvalidated, "cacheInvalidated", CacheInvalidatedPayload{})
+func (d *Dispatcher) Register(topic interface{}, name string, prototype interface{}) {
+	b := binding{topic: topic, name: name, typ: reflect.TypeOf(prototype)}
+
+	d.rwLock.Lock()
+	defer d.rwLock.Unlock()
+
+	d.byTopic[topic] = b
+	d.byName[name] = b
+}
+
+// Dispatch publishes the event to redis if the topic is registered, otherwise
+// dispatches it locally. Publishing doesn't wait for the remote listeners.
+func (d *Dispatcher) Dispatch(ctx context.Context, topic interface{}, event interface{}) error {
+	d.rwLock.RLock()
+	b, ok := d.byTopic[topic]
+	d.rwLock.RUnlock()
+
+	if !ok {
+		return d.SyncDispatcher.Dispatch(ctx, topic, event)
+	}
+	value, err := d.codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", b.name, err)
+	}
+	return d.client.Publish(ctx, d.keyer.Key(":", b.name), value).Err()
+}
+
+// Run receives the events from redis and delivers them to the local
+// listeners, until the context is canceled. Lost connections are
+// re-established with exponential backoff. The errors of the listeners are
+// logged rather than returned.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	prefix := d.keyer.Key(":", "")
+	pubSub := d.client.PSubscribe(ctx, prefix+"*")
+	// ReceiveMessage doesn't watch the context, but returns once closed.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stop:
+		}
+		pubSub.Close()
+	}()
+
+	backoff := 100 * time.Millisecond
+	for {
+		message, err := pubSub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// go-redis reconnects and resubscribes on the next receive.
+			level.Warn(d.logger).Log("msg", "redis pub/sub disconnected", "err", err, "retry", backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil
+			}
+			if backoff *= 2; backoff > d.maxBackoff {
+				backoff = d.maxBackoff
+			}
+			continue
+		}
+		backoff = 100 * time.Millisecond
+		if err := d.deliver(ctx, strings.TrimPrefix(message.Channel, prefix), message.Payload); err != nil {
+			level.Warn(d.logger).Log("msg", "failed to process event", "err", err)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, name string, payload string) error {
+	d.rwLock.RLock()
+	b, ok := d.byName[name]
+	d.rwLock.RUnlock()
+
+	if !ok {
+		// Not interested. Other instances may have registered more topics.
+		return nil
+	}
+	event, err := d.decode(b, []byte(payload))
+	if err != nil {
+		return fmt.Errorf("failed to decode event %s: %w", b.name, err)
+	}
+	pprof.Do(ctx, pprof.Labels("event", b.name), func(ctx context.Context) {
+		err = d.SyncDispatcher.Dispatch(ctx, b.topic, event)
+	})
+	return err
+}
+
+func (d *Dispatcher) decode(b binding, data []byte) (interface{}, error) {
+	if b.typ == nil {
+		var event interface{}
+		err := d.codec.Unmarshal(data, &event)
+		return event, err
+	}
+	if b.typ.Kind() == reflect.Ptr {
+		event := reflect.New(b.typ.Elem())
+		err := d.codec.Unmarshal(data, event.Interface())
+		return event.Interface(), err
+	}
+	event := reflect.New(b.typ)
+	err := d.codec.Unmarshal(data, event.Interface())
+	return event.Elem().Interface(), err
+}
@@ -0,0 +1,76 @@
+package eventsredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/key"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheInvalidated struct {
+	Keys []string `json:"keys"`
+}
+
+func TestDispatcher(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{s.Addr()}})
+	dispatcher := NewDispatcher(client, key.New("app", "testing"), WithMaxBackoff(100*time.Millisecond))
+	dispatcher.Register("invalidate", "invalidate", cacheInvalidated{})
+	other := NewDispatcher(client, key.New("other", "testing"))
+	other.Register("invalidate", "invalidate", cacheInvalidated{})
+
+	received := make(chan interface{}, 10)
+	listener := func(ctx context.Context, event interface{}) error {
+		received <- event
+		return nil
+	}
+	dispatcher.Subscribe(events.Listen("invalidate", listener))
+	dispatcher.Subscribe(events.Listen("local", listener))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- dispatcher.Run(ctx) }()
+
+	publish := func(d *Dispatcher, event cacheInvalidated) {
+		// the subscription is asynchronous, so publish until received.
+		assert.Eventually(t, func() bool {
+			assert.NoError(t, d.Dispatch(context.Background(), "invalidate", event))
+			select {
+			case e := <-received:
+				assert.Equal(t, event, e)
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, 5*time.Second, time.Millisecond)
+	}
+	publish(dispatcher, cacheInvalidated{Keys: []string{"foo"}})
+
+	// the events of other apps are not received.
+	assert.NoError(t, other.Dispatch(context.Background(), "invalidate", cacheInvalidated{Keys: []string{"baz"}}))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "local", "bar"))
+	assert.Equal(t, "bar", <-received)
+
+	// reconnects after redis restarts.
+	s.Close()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, s.Restart())
+	publish(dispatcher, cacheInvalidated{Keys: []string{"qux"}})
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run should return when the context is canceled")
+	}
+}
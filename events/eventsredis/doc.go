@@ -0,0 +1,37 @@
+/*
+Package eventsredis provides a contract.Dispatcher that bridges events over
+redis pub/sub, for lightweight cross-instance notifications such as config
+reloads and cache invalidations.
+
+Events of the registered topics are encoded, JSON by default, and published to
+the channel "<app>:<env>:events:<name>". Every instance receives them, and
+dispatches them to its own listeners. Redis pub/sub doesn't persist messages:
+events published while an instance is disconnected are lost. For durable
+events, see package eventskafka.
+
+Integration
+
+The package exports the configuration in the following format:
+
+	eventsRedis:
+	  redis: default
+	  codec: json
+
+The codec is one of json, msgpack, protobuf and gob, or the name given to
+codec.Register. All instances must use the same codec.
+
+Add the dependencies to core, and register the distributed topics:
+
+	var c *core.C = core.New()
+	c.Provide(otredis.Providers())
+	c.Provide(eventsredis.Providers())
+	c.Invoke(func(dispatcher *eventsredis.Dispatcher) {
+		dispatcher.Register(OnCacheInvalidated, "cacheInvalidated", CacheInvalidatedPayload{})
+		dispatcher.Subscribe(events.Listen(OnCacheInvalidated, handle))
+	})
+
+Note the payload of events.OnReload carries a contract.ConfigAccessor, which
+can't be encoded. To reload the configs of all instances, register a topic of
+your own, and dispatch events.OnReload locally in its listener.
+*/
+package eventsredis
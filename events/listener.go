@@ -2,6 +2,9 @@ package events
 
 import (
 	"context"
+	"reflect"
+	"runtime"
+	"time"
 
 	"github.com/DoNewsCode/core/contract"
 )
@@ -16,10 +19,67 @@ func Listen(topic interface{}, callback func(ctx context.Context, event interfac
 	}
 }
 
+// ListenWithPriority creates a functional listener with the priority. Listeners
+// with a higher priority are invoked first. The default priority is 0.
+//
+//	dispatcher.Subscribe(events.ListenWithPriority("order", 100, audit))
+//	dispatcher.Subscribe(events.Listen("order", fulfill))
+func ListenWithPriority(topic interface{}, priority int, callback func(ctx context.Context, event interface{}) error) *ListenerFunc {
+	return &ListenerFunc{
+		topic:    topic,
+		priority: priority,
+		callback: callback,
+	}
+}
+
+// Prioritized is an optional interface of contract.Listener. SyncDispatcher
+// invokes the listeners with a higher priority first. Listeners that don't
+// implement Prioritized have the priority 0.
+type Prioritized interface {
+	Priority() int
+}
+
+// WithPriority wraps the listener with the priority, for listeners that don't
+// implement Prioritized, eg. the ones provided by other packages. To
+// unsubscribe, pass the returned listener.
+//
+//	dispatcher.Subscribe(events.WithPriority(audit.Listener{}, -100))
+func WithPriority(listener contract.Listener, priority int) contract.Listener {
+	return &priorityListener{Listener: listener, priority: priority}
+}
+
+type priorityListener struct {
+	contract.Listener
+	priority int
+}
+
+// Priority implements Prioritized
+func (p *priorityListener) Priority() int {
+	return p.priority
+}
+
+// Timeout implements TimeLimited
+func (p *priorityListener) Timeout() time.Duration {
+	return timeoutOf(p.Listener)
+}
+
+// String returns the name of the wrapped listener.
+func (p *priorityListener) String() string {
+	return listenerName(p.Listener)
+}
+
+func priorityOf(listener contract.Listener) int {
+	if p, ok := listener.(Prioritized); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
 // ListenerFunc is a listener that can be constructed from one function Listen.
 // It listens to the given topic and then execute the callback.
 type ListenerFunc struct {
 	topic    interface{}
+	priority int
 	callback func(ctx context.Context, event interface{}) error
 }
 
@@ -32,3 +92,14 @@ func (f *ListenerFunc) Listen() interface{} {
 func (f *ListenerFunc) Process(ctx context.Context, event interface{}) error {
 	return f.callback(ctx, event)
 }
+
+// Priority implements Prioritized
+func (f *ListenerFunc) Priority() int {
+	return f.priority
+}
+
+// String returns the name of the callback function, which is used to tag the
+// tracing spans.
+func (f *ListenerFunc) String() string {
+	return runtime.FuncForPC(reflect.ValueOf(f.callback).Pointer()).Name()
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/metrics"
+)
+
+// Metrics is a collection of metrics for dispatching events. All of them have
+// the label "topic".
+type Metrics struct {
+	// Dispatches counts the dispatched events.
+	Dispatches metrics.Counter
+	// Errors counts the dispatches that failed because of a listener error.
+	Errors metrics.Counter
+	// Duration observes the time spent dispatching, in seconds.
+	Duration metrics.Histogram
+}
+
+// Instrument returns a Middleware that records the metrics of each Dispatch
+// call. The topic label is the string value of string topics, and the type of
+// the others. Note for AsyncDispatcher the duration only covers queuing the
+// event.
+//
+// To instrument the dispatcher of core, and expose the metrics through
+// srvhttp.MetricsModule:
+//
+//	core.New(core.SetEventDispatcherProvider(func(conf contract.ConfigAccessor) contract.Dispatcher {
+//		return events.ChainDispatcher(&events.SyncDispatcher{}, events.Instrument(observability.ProvideEventMetrics()))
+//	}))
+func Instrument(m *Metrics) Middleware {
+	return DispatchMiddleware(func(ctx context.Context, topic interface{}, event interface{}, next contract.Dispatcher) error {
+		name, ok := topicName(topic)
+		if !ok {
+			name = fmt.Sprintf("%T", topic)
+		}
+		start := time.Now()
+		err := next.Dispatch(ctx, topic, event)
+		m.Duration.With("topic", name).Observe(time.Since(start).Seconds())
+		m.Dispatches.With("topic", name).Add(1)
+		if err != nil {
+			m.Errors.With("topic", name).Add(1)
+		}
+		return err
+	})
+}
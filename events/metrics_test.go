@@ -0,0 +1,49 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/stretchr/testify/assert"
+)
+
+type topicCounter map[string]float64
+
+func (c topicCounter) With(labelValues ...string) metrics.Counter {
+	return labeledCounter{c, labelValues[1]}
+}
+
+func (c topicCounter) Add(delta float64) {}
+
+type labeledCounter struct {
+	counts topicCounter
+	topic  string
+}
+
+func (c labeledCounter) With(labelValues ...string) metrics.Counter { return c }
+
+func (c labeledCounter) Add(delta float64) { c.counts[c.topic] += delta }
+
+func TestInstrument(t *testing.T) {
+	dispatches, errs := topicCounter{}, topicCounter{}
+	m := &Metrics{
+		Dispatches: dispatches,
+		Errors:     errs,
+		Duration:   generic.NewHistogram("duration", 10),
+	}
+	dispatcher := &SyncDispatcher{}
+	dispatcher.Subscribe(Listen("fail", func(ctx context.Context, event interface{}) error {
+		return errors.New("failed")
+	}))
+	instrumented := ChainDispatcher(dispatcher, Instrument(m))
+
+	assert.NoError(t, instrumented.Dispatch(context.Background(), "ok", nil))
+	assert.Error(t, instrumented.Dispatch(context.Background(), "fail", nil))
+	assert.NoError(t, instrumented.Dispatch(context.Background(), 1, nil))
+
+	assert.Equal(t, topicCounter{"ok": 1, "fail": 1, "int": 1}, dispatches)
+	assert.Equal(t, topicCounter{"fail": 1}, errs)
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Middleware wraps a contract.Dispatcher, so that cross-cutting concerns such
+// as logging, metrics and recovery apply to every Dispatch call.
+type Middleware func(next contract.Dispatcher) contract.Dispatcher
+
+// ChainDispatcher wraps the dispatcher with the middlewares. The first
+// middleware is the outermost one. The returned dispatcher only has the methods
+// of contract.Dispatcher, eg. AsyncDispatcher.Close must be called on the
+// original one.
+//
+// To wrap the dispatcher of core:
+//
+//	core.New(core.SetEventDispatcherProvider(func(conf contract.ConfigAccessor) contract.Dispatcher {
+//		return events.ChainDispatcher(&events.SyncDispatcher{}, events.Recovery(), events.Logging(logger))
+//	}))
+func ChainDispatcher(dispatcher contract.Dispatcher, middlewares ...Middleware) contract.Dispatcher {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		dispatcher = middlewares[i](dispatcher)
+	}
+	return dispatcher
+}
+
+// DispatchMiddleware creates a Middleware that intercepts Dispatch. Subscribe
+// is passed to the next dispatcher unchanged.
+func DispatchMiddleware(dispatch func(ctx context.Context, topic interface{}, event interface{}, next contract.Dispatcher) error) Middleware {
+	return func(next contract.Dispatcher) contract.Dispatcher {
+		return dispatchInterceptor{Dispatcher: next, dispatch: dispatch}
+	}
+}
+
+type dispatchInterceptor struct {
+	contract.Dispatcher
+	dispatch func(ctx context.Context, topic interface{}, event interface{}, next contract.Dispatcher) error
+}
+
+func (d dispatchInterceptor) Dispatch(ctx context.Context, topic interface{}, event interface{}) error {
+	return d.dispatch(ctx, topic, event, d.Dispatcher)
+}
+
+// Unsubscribe implements Unsubscriber, if the next dispatcher does.
+func (d dispatchInterceptor) Unsubscribe(listener contract.Listener) {
+	if u, ok := d.Dispatcher.(Unsubscriber); ok {
+		u.Unsubscribe(listener)
+	}
+}
+
+// Recovery returns a Middleware that turns the panics of listeners into
+// errors returned by Dispatch. It only covers the listeners called within
+// Dispatch, not the ones called later by AsyncDispatcher.
+func Recovery() Middleware {
+	return DispatchMiddleware(func(ctx context.Context, topic interface{}, event interface{}, next contract.Dispatcher) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic while dispatching %v: %v", topic, r)
+			}
+		}()
+		return next.Dispatch(ctx, topic, event)
+	})
+}
+
+// Logging returns a Middleware that logs the dispatched topics at debug level,
+// and the errors returned by Dispatch at warn level.
+func Logging(logger log.Logger) Middleware {
+	return DispatchMiddleware(func(ctx context.Context, topic interface{}, event interface{}, next contract.Dispatcher) error {
+		err := next.Dispatch(ctx, topic, event)
+		if err != nil {
+			level.Warn(logger).Log("msg", fmt.Sprintf("failed to dispatch %v", topic), "err", err)
+			return err
+		}
+		level.Debug(logger).Log("msg", fmt.Sprintf("dispatched %v", topic))
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainDispatcher(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return DispatchMiddleware(func(ctx context.Context, topic interface{}, event interface{}, next contract.Dispatcher) error {
+			order = append(order, name)
+			return next.Dispatch(ctx, topic, event)
+		})
+	}
+	var buf bytes.Buffer
+	dispatcher := ChainDispatcher(&SyncDispatcher{}, record("outer"), record("inner"), Recovery(), Logging(log.NewLogfmtLogger(&buf)))
+	dispatcher.Subscribe(Listen("foo", func(ctx context.Context, event interface{}) error {
+		order = append(order, "listener")
+		return nil
+	}))
+	dispatcher.Subscribe(Listen("panic", func(ctx context.Context, event interface{}) error {
+		panic("boom")
+	}))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	assert.Equal(t, []string{"outer", "inner", "listener"}, order)
+	assert.Contains(t, buf.String(), "dispatched foo")
+
+	err := dispatcher.Dispatch(context.Background(), "panic", nil)
+	assert.EqualError(t, err, "panic while dispatching panic: boom")
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+// Unsubscriber is implemented by the dispatchers that can remove listeners,
+// such as SyncDispatcher and AsyncDispatcher.
+type Unsubscriber interface {
+	Unsubscribe(listener contract.Listener)
+}
+
+// SubscribeOnce subscribes the listener to the dispatcher for the next event
+// only. The listener is unsubscribed before it is invoked, if the dispatcher
+// implements Unsubscriber. It is useful for temporary listeners, eg. waiting
+// for OnHTTPServerStart in tests.
+//
+//	events.SubscribeOnce(dispatcher, events.Listen(core.OnHTTPServerStart, func(ctx context.Context, event interface{}) error {
+//		close(started)
+//		return nil
+//	}))
+func SubscribeOnce(dispatcher contract.Dispatcher, listener contract.Listener) {
+	dispatcher.Subscribe(&onceListener{Listener: listener, dispatcher: dispatcher})
+}
+
+type onceListener struct {
+	contract.Listener
+	dispatcher contract.Dispatcher
+	once       sync.Once
+}
+
+// Process implements contract.Listener
+func (o *onceListener) Process(ctx context.Context, event interface{}) error {
+	var (
+		first bool
+		err   error
+	)
+	o.once.Do(func() {
+		first = true
+		if u, ok := o.dispatcher.(Unsubscriber); ok {
+			u.Unsubscribe(o)
+		}
+	})
+	if first {
+		err = o.Listener.Process(ctx, event)
+	}
+	return err
+}
+
+// Priority implements Prioritized
+func (o *onceListener) Priority() int {
+	return priorityOf(o.Listener)
+}
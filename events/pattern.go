@@ -0,0 +1,62 @@
+package events
+
+import (
+	"path"
+	"reflect"
+)
+
+// Pattern is a topic pattern. A listener that listens to a Pattern receives
+// the events of every string topic matching it, in the syntax of path.Match.
+// For example, "user.*" matches "user.created" and "user.deleted". Topics of
+// named string types, such as OnReload, are matched by their string value.
+//
+//	dispatcher.Subscribe(events.Listen(events.Pattern("user.*"), audit))
+type Pattern string
+
+// Match reports whether the topic matches the pattern. Malformed patterns
+// match nothing.
+func (p Pattern) Match(topic interface{}) bool {
+	name, ok := topicName(topic)
+	if !ok {
+		return false
+	}
+	matched, _ := path.Match(string(p), name)
+	return matched
+}
+
+type matchAll struct{}
+
+// MatchAll is a topic that matches every topic, string or not. It is useful
+// for generic audit or logging listeners.
+//
+//	dispatcher.Subscribe(events.Listen(events.MatchAll, audit))
+var MatchAll = matchAll{}
+
+func isPattern(topic interface{}) bool {
+	switch topic.(type) {
+	case Pattern, matchAll:
+		return true
+	}
+	return false
+}
+
+func matches(pattern interface{}, topic interface{}) bool {
+	switch p := pattern.(type) {
+	case Pattern:
+		return p.Match(topic)
+	case matchAll:
+		return true
+	}
+	return false
+}
+
+func topicName(topic interface{}) (string, bool) {
+	if topic == nil {
+		return "", false
+	}
+	v := reflect.ValueOf(topic)
+	if v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
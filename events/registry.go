@@ -0,0 +1,136 @@
+package events
+
+import (
+	"time"
+)
+
+// The lifecycle events of the framework. Subscribing to them is the uniform way
+// to observe the framework internals. The events and their payloads are:
+//
+//	Topic                       Payload                           Dispatched by
+//	events.OnReload             OnReloadPayload                   config watchers, after the configuration is reloaded
+//	events.OnConfigKeyChanged   OnConfigKeyChangedPayload         config.KoanfAdapter.Reload, for each changed key
+//	events.OnDeadLetter         OnDeadLetterPayload               events.Republish, for the failures of listeners
+//	events.OnModuleAdded        OnModuleAddedPayload              core.C.AddModule and core.HotPlug.Plug
+//	events.OnModuleRemoved      OnModuleRemovedPayload            core.HotPlug.Unplug
+//	events.OnCronJobStarted     OnCronJobStartedPayload           cronopts.DispatchEvents
+//	events.OnCronJobFinished    OnCronJobFinishedPayload          cronopts.DispatchEvents
+//	events.OnQueueJobFailed     OnQueueJobFailedPayload           search.Indexer
+//	events.OnLeadershipAcquired OnLeadershipAcquiredPayload       leader.Election.Campaign
+//	events.OnLeadershipLost     OnLeadershipLostPayload           leader.Election.Resign
+//	events.OnConnectionUp       OnConnectionUpPayload             di.Factory.Make
+//	events.OnConnectionDown     OnConnectionDownPayload           di.Factory.Close and di.Factory.CloseConn
+//	core.OnHTTPServerStart      core.OnHTTPServerStartPayload     the serve command
+//	core.OnHTTPServerShutdown   core.OnHTTPServerShutdownPayload  the serve command
+//	core.OnGRPCServerStart      core.OnGRPCServerStartPayload     the serve command
+//	core.OnGRPCServerShutdown   core.OnGRPCServerShutdownPayload  the serve command
+//	leader.OnStatusChanged      leader.OnStatusChangedPayload     leader.Election
+//
+// All of them are string topics, so that they can be matched by Pattern too.
+// The connection events are only dispatched by the factories that subscribe to
+// the reload or key changed events, which is the case for the factories in this
+// module.
+const (
+	// OnModuleAdded is an event triggered when a module is added to the core. The
+	// event payload is OnModuleAddedPayload.
+	OnModuleAdded event = "onModuleAdded"
+
+	// OnModuleRemoved is an event triggered when a module is unplugged from
+	// core.HotPlug. The event payload is OnModuleRemovedPayload.
+	OnModuleRemoved event = "onModuleRemoved"
+
+	// OnCronJobStarted is an event triggered before a cron job runs. The event
+	// payload is OnCronJobStartedPayload.
+	OnCronJobStarted event = "onCronJobStarted"
+
+	// OnCronJobFinished is an event triggered after a cron job returns or
+	// panics. The event payload is OnCronJobFinishedPayload.
+	OnCronJobFinished event = "onCronJobFinished"
+
+	// OnQueueJobFailed is an event triggered when a job taken from a queue
+	// fails. The event payload is OnQueueJobFailedPayload.
+	OnQueueJobFailed event = "onQueueJobFailed"
+
+	// OnLeadershipAcquired is an event triggered when the current node becomes
+	// the leader. The event payload is OnLeadershipAcquiredPayload.
+	OnLeadershipAcquired event = "onLeadershipAcquired"
+
+	// OnLeadershipLost is an event triggered when the current node resigns from
+	// the leadership. The event payload is OnLeadershipLostPayload.
+	OnLeadershipLost event = "onLeadershipLost"
+
+	// OnConnectionUp is an event triggered when a connection, such as a
+	// database or redis client, is created by a factory. The event payload is
+	// OnConnectionUpPayload.
+	OnConnectionUp event = "onConnectionUp"
+
+	// OnConnectionDown is an event triggered after a connection created by a
+	// factory is closed. The event payload is OnConnectionDownPayload.
+	OnConnectionDown event = "onConnectionDown"
+)
+
+// OnModuleAddedPayload is the payload of OnModuleAdded.
+type OnModuleAddedPayload struct {
+	// Module is the added module.
+	Module interface{}
+}
+
+// OnModuleRemovedPayload is the payload of OnModuleRemoved.
+type OnModuleRemovedPayload struct {
+	// Module is the removed module.
+	Module interface{}
+}
+
+// OnCronJobStartedPayload is the payload of OnCronJobStarted.
+type OnCronJobStartedPayload struct {
+	// Job is the name of the job, which is its type, or the function name of
+	// function jobs.
+	Job string
+}
+
+// OnCronJobFinishedPayload is the payload of OnCronJobFinished.
+type OnCronJobFinishedPayload struct {
+	// Job is the name of the job, as in OnCronJobStartedPayload.
+	Job string
+	// Duration is how long the job ran.
+	Duration time.Duration
+	// Panic is the recovered value if the job panicked. The panic is resumed
+	// after the event is dispatched.
+	Panic interface{}
+}
+
+// OnQueueJobFailedPayload is the payload of OnQueueJobFailed.
+type OnQueueJobFailedPayload struct {
+	// Queue is the name of the queue.
+	Queue string
+	// Job describes the failed job.
+	Job string
+	// Err is the error returned by the job.
+	Err error
+}
+
+// OnLeadershipAcquiredPayload is the payload of OnLeadershipAcquired. It
+// carries no data.
+type OnLeadershipAcquiredPayload struct{}
+
+// OnLeadershipLostPayload is the payload of OnLeadershipLost.
+type OnLeadershipLostPayload struct {
+	// Err is the error returned by the driver when resigning, if any.
+	Err error
+}
+
+// OnConnectionUpPayload is the payload of OnConnectionUp.
+type OnConnectionUpPayload struct {
+	// Name is the name of the connection in the configuration.
+	Name string
+	// Conn is the created connection, eg. *gorm.DB or redis.UniversalClient.
+	Conn interface{}
+}
+
+// OnConnectionDownPayload is the payload of OnConnectionDown.
+type OnConnectionDownPayload struct {
+	// Name is the name of the connection in the configuration.
+	Name string
+	// Conn is the closed connection.
+	Conn interface{}
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/opentracing/opentracing-go"
+)
+
+// TimeLimited is an optional interface of contract.Listener. SyncDispatcher
+// gives up on the listener once the timeout elapses. A timeout of 0 falls back
+// to the one set with SetTimeout.
+type TimeLimited interface {
+	Timeout() time.Duration
+}
+
+// WithTimeout wraps the listener, so that SyncDispatcher gives up on it once
+// the timeout elapses, regardless of the timeout set with SetTimeout. To
+// unsubscribe, pass the returned listener.
+//
+//	dispatcher.Subscribe(events.WithTimeout(events.Listen("order", notify), time.Second))
+func WithTimeout(listener contract.Listener, timeout time.Duration) contract.Listener {
+	return &timeoutListener{Listener: listener, timeout: timeout}
+}
+
+type timeoutListener struct {
+	contract.Listener
+	timeout time.Duration
+}
+
+// Timeout implements TimeLimited
+func (t *timeoutListener) Timeout() time.Duration {
+	return t.timeout
+}
+
+// Priority implements Prioritized
+func (t *timeoutListener) Priority() int {
+	return priorityOf(t.Listener)
+}
+
+// String returns the name of the wrapped listener.
+func (t *timeoutListener) String() string {
+	return listenerName(t.Listener)
+}
+
+// SetTimeout limits how long each listener may run within Dispatch, so that one
+// stuck listener can't hang the caller indefinitely. The listener receives a
+// context with the deadline. If it doesn't return in time, Dispatch proceeds
+// as if the listener failed with an error wrapping context.DeadlineExceeded;
+// the listener keeps running in the background until it returns. A timeout of
+// 0, the default, means no limit.
+func (d *SyncDispatcher) SetTimeout(timeout time.Duration) {
+	d.rwLock.Lock()
+	defer d.rwLock.Unlock()
+
+	d.timeout = timeout
+}
+
+func timeoutOf(listener contract.Listener) time.Duration {
+	if t, ok := listener.(TimeLimited); ok {
+		return t.Timeout()
+	}
+	return 0
+}
+
+func processWithTimeout(ctx context.Context, tracer opentracing.Tracer, timeout time.Duration, topic interface{}, listener contract.Listener, event interface{}) error {
+	if t := timeoutOf(listener); t > 0 {
+		timeout = t
+	}
+	if timeout <= 0 {
+		return process(ctx, tracer, topic, listener, event)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The panics are passed back to the caller, so that they can be recovered
+	// there, eg. by the Recovery middleware.
+	type result struct {
+		err   error
+		panic interface{}
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		defer func() {
+			r.panic = recover()
+			done <- r
+		}()
+		r.err = process(ctx, tracer, topic, listener, event)
+	}()
+	select {
+	case r := <-done:
+		if r.panic != nil {
+			panic(r.panic)
+		}
+		return r.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("listener %s of %v: %w", listenerName(listener), topic, ctx.Err())
+		}
+		return ctx.Err()
+	}
+}
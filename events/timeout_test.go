@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher_timeout(t *testing.T) {
+	stuck := func(ctx context.Context, event interface{}) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	t.Run("default", func(t *testing.T) {
+		dispatcher := &SyncDispatcher{}
+		dispatcher.SetTimeout(10 * time.Millisecond)
+		dispatcher.Subscribe(Listen("foo", stuck))
+		err := dispatcher.Dispatch(context.Background(), "foo", nil)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Contains(t, err.Error(), "TestDispatcher_timeout")
+	})
+
+	t.Run("per listener", func(t *testing.T) {
+		dispatcher := &SyncDispatcher{}
+		dispatcher.SetTimeout(time.Hour)
+		listener := WithTimeout(Listen("foo", stuck), 10*time.Millisecond)
+		dispatcher.Subscribe(listener)
+		err := dispatcher.Dispatch(context.Background(), "foo", nil)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+		dispatcher.Unsubscribe(listener)
+		assert.NoError(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var called bool
+		dispatcher := &SyncDispatcher{}
+		dispatcher.Subscribe(Listen("foo", func(ctx context.Context, event interface{}) error {
+			cancel()
+			return nil
+		}))
+		dispatcher.Subscribe(Listen("foo", func(ctx context.Context, event interface{}) error {
+			called = true
+			return nil
+		}))
+		assert.Equal(t, context.Canceled, dispatcher.Dispatch(ctx, "foo", nil))
+		assert.False(t, called)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		dispatcher := ChainDispatcher(&SyncDispatcher{timeout: time.Second}, Recovery())
+		dispatcher.Subscribe(Listen("foo", func(ctx context.Context, event interface{}) error {
+			panic("boom")
+		}))
+		assert.Error(t, dispatcher.Dispatch(context.Background(), "foo", nil))
+	})
+}
@@ -0,0 +1,35 @@
+package gops
+
+import (
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/di"
+)
+
+// Providers returns the exported configuration of the gops agent. Add the
+// agent itself with New.
+func Providers() di.Deps {
+	return di.Deps{provideConfig}
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "gops",
+			Data: map[string]interface{}{
+				"gops": Option{
+					Enable:    false,
+					Envs:      []string{},
+					Addr:      "127.0.0.1:0",
+					ConfigDir: "",
+				},
+			},
+			Comment: "The gops agent for runtime inspection",
+		},
+	}}
+}
@@ -0,0 +1,31 @@
+/*
+Package gops embeds the gops agent, so that operators can inspect the GC,
+memory stats and stack traces of production processes with the gops command:
+
+	gops stats <pid>
+	gops stack <pid>
+	gops memstats <pid>
+
+The agent is opt-in. It is started and stopped along with the serve command,
+and can be limited to some environments. Note that the agent accepts commands
+from any program that can reach its address, so keep it on the loopback
+interface.
+
+Integration
+
+package gops exports the configuration in the following format:
+
+	gops:
+	    enable: true
+	    envs:
+	        - production
+	    addr: 127.0.0.1:0
+	    configDir: ""
+
+Add the gops module to core:
+
+	var c *core.C = core.New()
+	c.Provide(gops.Providers())
+	c.AddModuleFunc(gops.New)
+*/
+package gops
@@ -0,0 +1,49 @@
+package gops
+
+import (
+	"github.com/DoNewsCode/core/contract"
+	"github.com/google/gops/agent"
+)
+
+// Option is the configuration of the gops agent.
+type Option struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Envs are the environments the agent is allowed to run in, eg.
+	// "production". If empty, the agent runs in all environments.
+	Envs []string `json:"envs" yaml:"envs"`
+	// Addr is the host:port the agent listens at. The port is chosen by the
+	// system if omitted.
+	Addr string `json:"addr" yaml:"addr"`
+	// ConfigDir is the directory the agent writes its port to, so that the gops
+	// command can find it. Defaults to the gops config directory.
+	ConfigDir string `json:"configDir" yaml:"configDir"`
+}
+
+// Enabled reports whether the agent should run in the environment.
+func (o Option) Enabled(env contract.Env) bool {
+	if !o.Enable {
+		return false
+	}
+	if len(o.Envs) == 0 {
+		return true
+	}
+	for _, e := range o.Envs {
+		if env != nil && env.String() == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Listen starts the gops agent. Only one agent can run in a process.
+func Listen(option Option) error {
+	return agent.Listen(agent.Options{
+		Addr:      option.Addr,
+		ConfigDir: option.ConfigDir,
+	})
+}
+
+// Close stops the gops agent.
+func Close() {
+	agent.Close()
+}
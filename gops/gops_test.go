@@ -0,0 +1,64 @@
+package gops
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOption_Enabled(t *testing.T) {
+	t.Parallel()
+	assert.False(t, Option{}.Enabled(config.EnvProduction))
+	assert.True(t, Option{Enable: true}.Enabled(config.EnvProduction))
+	assert.True(t, Option{Enable: true, Envs: []string{"production"}}.Enabled(config.EnvProduction))
+	assert.False(t, Option{Enable: true, Envs: []string{"production"}}.Enabled(config.EnvDevelopment))
+}
+
+func TestModule_ProvideRunGroup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	module, err := New(ModuleIn{
+		Logger: log.NewNopLogger(),
+		Conf: config.MapAdapter{"gops": Option{
+			Enable:    true,
+			Addr:      "127.0.0.1:0",
+			ConfigDir: dir,
+		}},
+		Env: config.EnvDevelopment,
+	})
+	assert.NoError(t, err)
+
+	var group run.Group
+	module.ProvideRunGroup(&group)
+	stop := make(chan struct{})
+	group.Add(func() error {
+		<-stop
+		return errors.New("stopped")
+	}, func(err error) {})
+	done := make(chan error)
+	go func() { done <- group.Run() }()
+
+	portFile := filepath.Join(dir, strconv.Itoa(os.Getpid()))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(portFile)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	close(stop)
+	assert.EqualError(t, <-done, "stopped")
+	_, err = os.Stat(portFile)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,58 @@
+package gops
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/run"
+)
+
+// Module is the registration unit for package core. It runs the gops agent
+// along with the serve command.
+type Module struct {
+	logger log.Logger
+	option Option
+	env    contract.Env
+}
+
+// ModuleIn contains the input parameters needed for creating the new module.
+type ModuleIn struct {
+	di.In
+
+	Logger log.Logger
+	Conf   contract.ConfigAccessor
+	Env    contract.Env
+}
+
+// New creates a Module.
+func New(in ModuleIn) (Module, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("gops", &option); err != nil {
+		return Module{}, fmt.Errorf("gops configuration error: %w", err)
+	}
+	return Module{logger: in.Logger, option: option, env: in.Env}, nil
+}
+
+// ProvideRunGroup runs the gops agent until the serve command exits, if it is
+// enabled in the environment.
+func (m Module) ProvideRunGroup(group *run.Group) {
+	if !m.option.Enabled(m.env) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		if err := Listen(m.option); err != nil {
+			return fmt.Errorf("failed to start gops agent: %w", err)
+		}
+		level.Info(m.logger).Log("msg", "gops agent started")
+		<-ctx.Done()
+		return nil
+	}, func(err error) {
+		cancel()
+		Close()
+	})
+}
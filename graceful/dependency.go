@@ -0,0 +1,70 @@
+package graceful
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+)
+
+// Option is the configuration of Tracker.
+type Option struct {
+	// Grace is how long the connections have to finish after the shutdown
+	// begins.
+	Grace config.Duration `json:"grace" yaml:"grace"`
+}
+
+/*
+Providers returns a set of dependency providers for *Tracker. Once provided,
+the serve command shuts down the HTTP and gRPC servers through the Tracker.
+
+	Depends On:
+		contract.ConfigAccessor
+		*Metrics `optional:"true"`
+	Provide:
+		*Tracker
+*/
+func Providers() di.Deps {
+	return di.Deps{provideTracker, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Conf    contract.ConfigAccessor
+	Metrics *Metrics `optional:"true"`
+}
+
+func provideTracker(in in) (*Tracker, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("graceful", &option); err != nil {
+		return nil, fmt.Errorf("graceful configuration error: %w", err)
+	}
+	var options []TrackerOption
+	if in.Metrics != nil {
+		options = append(options, WithMetrics(in.Metrics))
+	}
+	return NewTracker(option.Grace.Duration, options...), nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "graceful",
+			Data: map[string]interface{}{
+				"graceful": Option{
+					Grace: config.Duration{Duration: 10 * time.Second},
+				},
+			},
+			Comment: "The grace window for connections to finish on shutdown",
+		},
+	}}
+}
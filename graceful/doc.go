@@ -0,0 +1,43 @@
+/*
+Package graceful shuts down long-lived connections, such as WebSockets, SSE and
+streaming gRPC, without cutting them off mid-flight.
+
+When the serve command stops, the Tracker closes its GoingAway channel. The gRPC
+server sends GOAWAY to its clients, and streams ending from then on carry the
+"going-away" trailer. HTTP handlers should select on graceful.GoingAway(ctx)
+and send the going-away signal of their protocol, eg. a WebSocket close frame.
+Connections still alive after the grace window are killed. The drained and
+killed connections are counted if *graceful.Metrics is in the graph, eg. from
+observability.Providers.
+
+Integration
+
+package graceful exports the configuration in the following format:
+
+	graceful:
+	  grace: 10s
+
+Add the graceful dependency to core, and apply the middleware and interceptor:
+
+	var c *core.C = core.New()
+	c.Provide(graceful.Providers())
+	c.Invoke(func(router *mux.Router, tracker *graceful.Tracker) {
+		router.Use(graceful.MakeHTTPMiddleware(tracker))
+	})
+	c.Provide(di.Deps{func(tracker *graceful.Tracker) *grpc.Server {
+		return grpc.NewServer(grpc.StreamInterceptor(graceful.MakeStreamInterceptor(tracker)))
+	}})
+
+Then in a WebSocket handler:
+
+	for {
+		select {
+		case msg := <-messages:
+			conn.WriteMessage(websocket.TextMessage, msg)
+		case <-graceful.GoingAway(r.Context()):
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+			return
+		}
+	}
+*/
+package graceful
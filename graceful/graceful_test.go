@@ -0,0 +1,98 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type counter struct {
+	value float64
+}
+
+func (c *counter) With(labelValues ...string) metrics.Counter { return c }
+
+func (c *counter) Add(delta float64) { c.value += delta }
+
+func TestTracker(t *testing.T) {
+	drainedCounter, killedCounter := &counter{}, &counter{}
+	m := &Metrics{Drained: drainedCounter, Killed: killedCounter}
+	tracker := NewTracker(20*time.Millisecond, WithMetrics(m))
+
+	killed := make(chan struct{})
+	drained := tracker.Track("http", nil)
+	stuck := tracker.Track("http", func() { close(killed) })
+	other := tracker.Track("grpc", nil)
+	defer other()
+
+	// finished before the shutdown, not counted as drained.
+	tracker.Track("http", nil)()
+
+	ctx, cancel := tracker.Context()
+	assert.NoError(t, ctx.Err())
+	cancel()
+
+	tracker.GoAway()
+	tracker.GoAway()
+	select {
+	case <-tracker.GoingAway():
+	default:
+		t.Fatal("the shutdown should have begun")
+	}
+
+	drained()
+	ctx, cancel = tracker.Context()
+	defer cancel()
+	assert.False(t, tracker.Wait(ctx, "http"))
+	assert.Equal(t, 1, tracker.Kill("http"))
+	<-killed
+	stuck()
+
+	assert.True(t, tracker.Wait(context.Background(), "http"))
+	assert.Equal(t, 0, tracker.Kill("http"))
+	assert.Equal(t, 1.0, drainedCounter.value)
+	assert.Equal(t, 1.0, killedCounter.value)
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	tracker := NewTracker(time.Second)
+	handler := MakeHTTPMiddleware(tracker)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		conn, rw, err := writer.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhello")
+		rw.Flush()
+		<-GoingAway(request.Context())
+		// never ends by itself, waits to be killed.
+		conn.Read(make([]byte, 1))
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	tracker.GoAway()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, tracker.Wait(ctx, "http"))
+	assert.Equal(t, 1, tracker.Kill("http"))
+	assert.True(t, tracker.Wait(context.Background(), "http"))
+}
+
+func TestGoingAway(t *testing.T) {
+	assert.Nil(t, GoingAway(context.Background()))
+
+	var _ http.Flusher = &hijackWriter{ResponseWriter: httptest.NewRecorder()}
+	w := &hijackWriter{ResponseWriter: httptest.NewRecorder()}
+	_, _, err := w.Hijack()
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package graceful
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type contextKey struct{}
+
+// GoingAway returns a channel that is closed when the shutdown begins. It
+// returns nil, which blocks forever, if the context doesn't come from the
+// middleware or interceptor of this package.
+func GoingAway(ctx context.Context) <-chan struct{} {
+	tracker, _ := ctx.Value(contextKey{}).(*Tracker)
+	if tracker == nil {
+		return nil
+	}
+	return tracker.GoingAway()
+}
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that tracks the
+// requests. Hijacked connections, such as WebSockets, are closed when killed.
+func MakeHTTPMiddleware(tracker *Tracker) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			w := &hijackWriter{ResponseWriter: writer}
+			done := tracker.Track("http", w.kill)
+			defer done()
+
+			ctx := context.WithValue(request.Context(), contextKey{}, tracker)
+			handler.ServeHTTP(w, request.WithContext(ctx))
+		})
+	}
+}
+
+// hijackWriter remembers the hijacked connection, since http.Server doesn't
+// close it on shutdown.
+type hijackWriter struct {
+	http.ResponseWriter
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (h *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer doesn't support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		h.mu.Lock()
+		h.conn = conn
+		h.mu.Unlock()
+	}
+	return conn, rw, err
+}
+
+func (h *hijackWriter) Flush() {
+	if flusher, ok := h.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (h *hijackWriter) kill() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conn != nil {
+		h.conn.Close()
+	}
+}
+
+// MakeStreamInterceptor creates a grpc.StreamServerInterceptor that tracks the
+// streams. Streams that end after the shutdown began carry the "going-away"
+// trailer, so that clients know to reconnect elsewhere. Killed streams have
+// their context canceled.
+func MakeStreamInterceptor(tracker *Tracker) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		done := tracker.Track("grpc", cancel)
+		defer done()
+
+		ctx = context.WithValue(ctx, contextKey{}, tracker)
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		select {
+		case <-tracker.GoingAway():
+			ss.SetTrailer(metadata.Pairs("going-away", "true"))
+		default:
+		}
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,176 @@
+package graceful
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// Metrics is a collection of metrics for the shutdown of long-lived
+// connections.
+type Metrics struct {
+	// Drained counts the connections that ended by themselves within the grace
+	// window, by the "transport" label.
+	Drained metrics.Counter
+	// Killed counts the connections that were forcibly closed after the grace
+	// window, by the "transport" label.
+	Killed metrics.Counter
+}
+
+// Tracker tracks the in-flight requests and long-lived connections, such as
+// WebSockets, SSE and streaming gRPC. On shutdown, it tells them to go away,
+// and gives them a grace window to finish before they are killed. Tracker is
+// safe for concurrent use.
+type Tracker struct {
+	grace   time.Duration
+	metrics *Metrics
+
+	mu        sync.Mutex
+	entries   map[*entry]struct{}
+	goingAway chan struct{}
+	deadline  time.Time
+}
+
+type entry struct {
+	transport string
+	kill      func()
+	done      chan struct{}
+}
+
+// TrackerOption changes the behavior of Tracker.
+type TrackerOption func(*Tracker)
+
+// WithMetrics sets the metrics.
+func WithMetrics(metrics *Metrics) TrackerOption {
+	return func(tracker *Tracker) {
+		tracker.metrics = metrics
+	}
+}
+
+// NewTracker creates a new *Tracker with the grace window.
+func NewTracker(grace time.Duration, options ...TrackerOption) *Tracker {
+	tracker := &Tracker{
+		grace:     grace,
+		entries:   make(map[*entry]struct{}),
+		goingAway: make(chan struct{}),
+	}
+	for _, option := range options {
+		option(tracker)
+	}
+	return tracker
+}
+
+// GoingAway returns a channel that is closed when the shutdown begins.
+// Long-lived handlers should select on it, and send the going-away signal of
+// their protocol, eg. a WebSocket close frame or a final SSE event.
+func (t *Tracker) GoingAway() <-chan struct{} {
+	return t.goingAway
+}
+
+// GoAway begins the shutdown, and starts the grace window. Calling GoAway more
+// than once is a no-op.
+func (t *Tracker) GoAway() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.goingAway:
+	default:
+		t.deadline = time.Now().Add(t.grace)
+		close(t.goingAway)
+	}
+}
+
+// Context returns a context that expires at the end of the grace window. It
+// never expires before GoAway is called.
+func (t *Tracker) Context() (context.Context, context.CancelFunc) {
+	t.mu.Lock()
+	deadline := t.deadline
+	t.mu.Unlock()
+
+	if deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), deadline)
+}
+
+// Track registers a connection of the transport. The kill function forcibly
+// closes it. The returned function must be called when the connection ends.
+func (t *Tracker) Track(transport string, kill func()) (done func()) {
+	e := &entry{transport: transport, kill: kill, done: make(chan struct{})}
+
+	t.mu.Lock()
+	t.entries[e] = struct{}{}
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			_, ok := t.entries[e]
+			delete(t.entries, e)
+			t.mu.Unlock()
+
+			close(e.done)
+			select {
+			case <-t.goingAway:
+				if ok && t.metrics != nil {
+					t.metrics.Drained.With("transport", transport).Add(1)
+				}
+			default:
+			}
+		})
+	}
+}
+
+func (t *Tracker) snapshot(transport string) []*entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var entries []*entry
+	for e := range t.entries {
+		if e.transport == transport {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Wait waits until the connections of the transport end, or the context is
+// done. It returns false if some connections are still alive.
+func (t *Tracker) Wait(ctx context.Context, transport string) bool {
+	for _, e := range t.snapshot(transport) {
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// Kill forcibly closes the remaining connections of the transport, and returns
+// how many were killed.
+func (t *Tracker) Kill(transport string) int {
+	t.mu.Lock()
+	var killed []*entry
+	for e := range t.entries {
+		if e.transport == transport {
+			killed = append(killed, e)
+			delete(t.entries, e)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, e := range killed {
+		if e.kill != nil {
+			e.kill()
+		}
+	}
+	if t.metrics != nil && len(killed) > 0 {
+		t.metrics.Killed.With("transport", transport).Add(float64(len(killed)))
+	}
+	return len(killed)
+}
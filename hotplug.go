@@ -0,0 +1,252 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/DoNewsCode/core/container"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/gorilla/mux"
+	"github.com/oklog/run"
+	"github.com/robfig/cron/v3"
+)
+
+// HotPlug registers and deregisters modules while the application is serving.
+// It is experimental.
+//
+// A plugged module may provide HTTP routes, cron jobs and run groups, as in
+// container.HTTPProvider, container.CronProvider and container.RunProvider.
+// The HTTP routes are served by a router that is rebuilt on each change, and
+// takes precedence over the routes of the container. The run groups of each module run in their own run.Group, and the cron jobs
+// are added to and removed from the running cron. gRPC services can't be
+// plugged, as a *grpc.Server can't register services once serving. A module
+// implementing container.CloserProvider is closed when unplugged.
+//
+// Modules plugged before serving are started when serving starts. This makes it
+// possible to load functionality behind flags, or A/B load implementations:
+//
+//	c.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+//		if event.(events.OnReloadPayload).NewConf.Bool("flags.checkoutV2") {
+//			return c.HotPlug().Plug("checkout", checkoutv2.New())
+//		}
+//		return c.HotPlug().Unplug("checkout")
+//	}))
+//
+// HotPlug is safe for concurrent use.
+type HotPlug struct {
+	dispatcher contract.Dispatcher
+
+	mu      sync.Mutex
+	modules []*pluggedModule
+	// base serves the routes of the container. It is set when serving.
+	base    http.Handler
+	router  *mux.Router
+	crontab *cron.Cron
+	serving bool
+}
+
+type pluggedModule struct {
+	name    string
+	module  interface{}
+	entries []cron.EntryID
+	stop    func()
+}
+
+// NewHotPlug creates a *HotPlug. The dispatcher, if not nil, receives
+// events.OnModuleAdded and events.OnModuleRemoved.
+func NewHotPlug(dispatcher contract.Dispatcher) *HotPlug {
+	return &HotPlug{dispatcher: dispatcher}
+}
+
+// Plug registers the module under the name. The name must not be in use.
+func (h *HotPlug) Plug(name string, module interface{}) error {
+	h.mu.Lock()
+	for _, m := range h.modules {
+		if m.name == name {
+			h.mu.Unlock()
+			return fmt.Errorf("module %s is already plugged", name)
+		}
+	}
+	m := &pluggedModule{name: name, module: module}
+	h.modules = append(h.modules, m)
+	if h.serving {
+		h.start(m)
+		h.rebuild()
+	}
+	h.mu.Unlock()
+
+	h.dispatch(events.OnModuleAdded, events.OnModuleAddedPayload{Module: module})
+	return nil
+}
+
+// Unplug deregisters the module under the name, waiting for its run groups to
+// return. It is a no-op if no module is plugged under the name.
+func (h *HotPlug) Unplug(name string) error {
+	h.mu.Lock()
+	var m *pluggedModule
+	for i := range h.modules {
+		if h.modules[i].name == name {
+			m = h.modules[i]
+			h.modules = append(h.modules[:i], h.modules[i+1:]...)
+			break
+		}
+	}
+	if m == nil {
+		h.mu.Unlock()
+		return nil
+	}
+	if h.serving {
+		h.rebuild()
+		h.stopModule(m)
+	}
+	h.mu.Unlock()
+
+	if closer, ok := m.module.(container.CloserProvider); ok {
+		closer.ProvideCloser()
+	}
+	h.dispatch(events.OnModuleRemoved, events.OnModuleRemovedPayload{Module: m.module})
+	return nil
+}
+
+// Names returns the names of the plugged modules, sorted.
+func (h *HotPlug) Names() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	names := make([]string, 0, len(h.modules))
+	for _, m := range h.modules {
+		names = append(names, m.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP serves the requests matching the routes of the plugged modules, and
+// passes the others to the routes of the container. Before serving starts, it
+// responds 404 Not Found.
+func (h *HotPlug) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	h.mu.Lock()
+	router, base := h.router, h.base
+	h.mu.Unlock()
+	var match mux.RouteMatch
+	if router != nil && router.Match(request, &match) && match.MatchErr == nil {
+		router.ServeHTTP(writer, request)
+		return
+	}
+	if base == nil {
+		http.NotFound(writer, request)
+		return
+	}
+	base.ServeHTTP(writer, request)
+}
+
+// handler sets the handler of the container routes, and returns h as the
+// http.Handler.
+func (h *HotPlug) handler(base http.Handler) http.Handler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.base = base
+	return h
+}
+
+// setCron sets the cron that the cron jobs of the modules are added to.
+func (h *HotPlug) setCron(crontab *cron.Cron) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.crontab = crontab
+}
+
+// serve starts the plugged modules, and the ones plugged later, until stop is
+// called.
+func (h *HotPlug) serve() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.serving = true
+	for _, m := range h.modules {
+		h.start(m)
+	}
+	h.rebuild()
+}
+
+// stop stops the plugged modules. They stay plugged.
+func (h *HotPlug) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.serving = false
+	for _, m := range h.modules {
+		h.stopModule(m)
+	}
+}
+
+func (h *HotPlug) rebuild() {
+	router := mux.NewRouter()
+	router.Use(srvhttp.MakeLabelMiddleware())
+	for _, m := range h.modules {
+		if p, ok := m.module.(container.HTTPProvider); ok {
+			p.ProvideHTTP(router)
+		}
+	}
+	h.router = router
+}
+
+func (h *HotPlug) start(m *pluggedModule) {
+	if p, ok := m.module.(container.CronProvider); ok && h.crontab != nil {
+		existing := make(map[cron.EntryID]bool)
+		for _, entry := range h.crontab.Entries() {
+			existing[entry.ID] = true
+		}
+		p.ProvideCron(h.crontab)
+		for _, entry := range h.crontab.Entries() {
+			if !existing[entry.ID] {
+				m.entries = append(m.entries, entry.ID)
+			}
+		}
+	}
+	if p, ok := m.module.(container.RunProvider); ok {
+		var g run.Group
+		p.ProvideRunGroup(&g)
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			<-ctx.Done()
+			return nil
+		}, func(err error) {
+			cancel()
+		})
+		done := make(chan struct{})
+		go func() {
+			_ = g.Run()
+			close(done)
+		}()
+		m.stop = func() {
+			cancel()
+			<-done
+		}
+	}
+}
+
+func (h *HotPlug) stopModule(m *pluggedModule) {
+	for _, id := range m.entries {
+		h.crontab.Remove(id)
+	}
+	m.entries = nil
+	if m.stop != nil {
+		m.stop()
+		m.stop = nil
+	}
+}
+
+func (h *HotPlug) dispatch(topic interface{}, payload interface{}) {
+	if h.dispatcher == nil {
+		return
+	}
+	_ = h.dispatcher.Dispatch(context.Background(), topic, payload)
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DoNewsCode/core/events"
+	"github.com/gorilla/mux"
+	"github.com/oklog/run"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type pluggable struct {
+	running chan struct{}
+	stopped chan struct{}
+	closed  bool
+}
+
+func (p *pluggable) ProvideHTTP(router *mux.Router) {
+	router.HandleFunc("/plugged", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("plugged"))
+	})
+}
+
+func (p *pluggable) ProvideCron(crontab *cron.Cron) {
+	crontab.AddFunc("@every 1h", func() {})
+}
+
+func (p *pluggable) ProvideRunGroup(group *run.Group) {
+	stop := make(chan struct{})
+	group.Add(func() error {
+		close(p.running)
+		<-stop
+		return nil
+	}, func(err error) {
+		close(stop)
+		close(p.stopped)
+	})
+}
+
+func (p *pluggable) ProvideCloser() {
+	p.closed = true
+}
+
+func TestHotPlug(t *testing.T) {
+	dispatcher := &events.SyncDispatcher{}
+	var removed interface{}
+	dispatcher.Subscribe(events.Listen(events.OnModuleRemoved, func(ctx context.Context, event interface{}) error {
+		removed = event.(events.OnModuleRemovedPayload).Module
+		return nil
+	}))
+	hotPlug := NewHotPlug(dispatcher)
+	base := mux.NewRouter()
+	base.HandleFunc("/base", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("base"))
+	})
+	handler := hotPlug.handler(base)
+	crontab := cron.New()
+	hotPlug.setCron(crontab)
+	hotPlug.serve()
+	defer hotPlug.stop()
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	module := &pluggable{running: make(chan struct{}), stopped: make(chan struct{})}
+	assert.NoError(t, hotPlug.Plug("foo", module))
+	assert.Error(t, hotPlug.Plug("foo", module))
+	<-module.running
+	assert.Equal(t, []string{"foo"}, hotPlug.Names())
+	assert.Len(t, crontab.Entries(), 1)
+	code, body := get("/plugged")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "plugged", body)
+	_, body = get("/base")
+	assert.Equal(t, "base", body)
+
+	assert.NoError(t, hotPlug.Unplug("foo"))
+	<-module.stopped
+	assert.True(t, module.closed)
+	assert.Equal(t, module, removed)
+	assert.Empty(t, hotPlug.Names())
+	assert.Empty(t, crontab.Entries())
+	code, _ = get("/plugged")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
 	"go.uber.org/atomic"
 )
 
@@ -46,6 +47,7 @@ func (e *Election) Campaign(ctx context.Context) error {
 	e.status.isLeader.Store(true)
 	// trigger events
 	e.dispatcher.Dispatch(ctx, OnStatusChanged, OnStatusChangedPayload{Status: e.status})
+	e.dispatcher.Dispatch(ctx, events.OnLeadershipAcquired, events.OnLeadershipAcquiredPayload{})
 	return nil
 }
 
@@ -54,10 +56,12 @@ func (e *Election) Resign(ctx context.Context) error {
 	if !e.status.isLeader.Load() {
 		return ErrNotALeader
 	}
+	err := e.driver.Resign(ctx)
+	e.status.isLeader.Store(false)
 	// trigger events
-	defer e.dispatcher.Dispatch(ctx, OnStatusChanged, OnStatusChangedPayload{Status: e.status})
-	defer e.status.isLeader.Store(false)
-	return e.driver.Resign(ctx)
+	e.dispatcher.Dispatch(ctx, OnStatusChanged, OnStatusChangedPayload{Status: e.status})
+	e.dispatcher.Dispatch(ctx, events.OnLeadershipLost, events.OnLeadershipLostPayload{Err: err})
+	return err
 }
 
 // Status returns the current status of the election.
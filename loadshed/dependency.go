@@ -0,0 +1,78 @@
+package loadshed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+)
+
+/*
+Providers returns a set of dependency providers for *Limiter.
+
+	Depends On:
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		*Limiter
+*/
+func Providers() di.Deps {
+	return di.Deps{provideLimiter, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+func provideLimiter(in in) (*Limiter, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("loadshed", &option); err != nil {
+		return nil, fmt.Errorf("loadshed configuration error: %w", err)
+	}
+	limiter := NewLimiter(option)
+	if in.Dispatcher != nil {
+		in.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+			var option Option
+			if err := event.(events.OnReloadPayload).NewConf.Unmarshal("loadshed", &option); err != nil {
+				return fmt.Errorf("loadshed configuration error: %w", err)
+			}
+			limiter.Update(option)
+			return nil
+		}))
+	}
+	return limiter, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "loadshed",
+			Data: map[string]interface{}{
+				"loadshed": Option{
+					MaxConcurrency: 0,
+					QueueTimeout:   config.Duration{Duration: 100 * time.Millisecond},
+					RetryAfter:     config.Duration{Duration: time.Second},
+					Codel: CodelOption{
+						Enable:   false,
+						Target:   config.Duration{Duration: 5 * time.Millisecond},
+						Interval: config.Duration{Duration: 100 * time.Millisecond},
+					},
+				},
+			},
+			Comment: "The load shedding configuration",
+		},
+	}}
+}
@@ -0,0 +1,38 @@
+/*
+Package loadshed provides a load shedding middleware for HTTP and an interceptor
+for gRPC.
+
+The Limiter caps the number of in-flight requests. Requests beyond the cap wait
+in a queue for at most the configured queue timeout. If CoDel is enabled, the
+queueing delay is measured: when the minimum delay within an interval stays
+above the target, the server is considered overloaded and the queue timeout is
+shortened to the target, so that standing queues drain quickly. Rejected
+requests receive 429 (HTTP) or RESOURCE_EXHAUSTED (gRPC) with a retry hint.
+
+The limits can be tuned at runtime. They are reloaded with the configuration.
+
+Integration
+
+package loadshed exports the configuration in the following format:
+
+	loadshed:
+	    maxConcurrency: 0
+	    queueTimeout: 100ms
+	    retryAfter: 1s
+	    codel:
+	        enable: false
+	        target: 5ms
+	        interval: 100ms
+
+A maxConcurrency of zero disables load shedding. Add the loadshed dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(loadshed.Providers())
+
+Then apply the middleware or interceptor:
+
+	c.Invoke(func(limiter *loadshed.Limiter) {
+		router.Use(loadshed.MakeHTTPMiddleware(limiter))
+	})
+*/
+package loadshed
@@ -0,0 +1,183 @@
+package loadshed
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+)
+
+// ErrOverloaded is returned by Limiter.Acquire when a request is shed.
+var ErrOverloaded = errors.New("server overloaded")
+
+// Option is the configuration of Limiter.
+type Option struct {
+	// MaxConcurrency is the maximum number of in-flight requests. Zero disables load shedding.
+	MaxConcurrency int `json:"maxConcurrency" yaml:"maxConcurrency"`
+	// QueueTimeout is the maximum time a request waits for a free slot. Zero
+	// rejects requests immediately when saturated.
+	QueueTimeout config.Duration `json:"queueTimeout" yaml:"queueTimeout"`
+	// RetryAfter is the retry hint sent along with rejected requests.
+	RetryAfter config.Duration `json:"retryAfter" yaml:"retryAfter"`
+	// Codel configures the adaptive queue timeout.
+	Codel CodelOption `json:"codel" yaml:"codel"`
+}
+
+// CodelOption is the configuration for CoDel style queue management.
+type CodelOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Target is the acceptable queueing delay. It is also the queue timeout when overloaded.
+	Target config.Duration `json:"target" yaml:"target"`
+	// Interval is the window in which the minimum queueing delay is measured.
+	Interval config.Duration `json:"interval" yaml:"interval"`
+}
+
+// Limiter caps in-flight requests. Limiter is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	option   Option
+	inFlight int
+	waiters  list.List
+
+	// CoDel states
+	intervalStart time.Time
+	minDelay      time.Duration
+	overloaded    bool
+}
+
+// NewLimiter creates a new *Limiter from the given Option.
+func NewLimiter(option Option) *Limiter {
+	return &Limiter{option: option, minDelay: -1}
+}
+
+// Update replaces the Option of Limiter. Raising the limit admits queued
+// requests immediately.
+func (l *Limiter) Update(option Option) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.option = option
+	for l.waiters.Len() > 0 && (option.MaxConcurrency <= 0 || l.inFlight < option.MaxConcurrency) {
+		l.inFlight++
+		l.admit()
+	}
+}
+
+// RetryAfter returns the configured retry hint.
+func (l *Limiter) RetryAfter() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.option.RetryAfter.Duration
+}
+
+// InFlight returns the number of requests holding a slot.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
+
+// Acquire obtains a slot for a request. On success, the returned release
+// function must be called when the request is done. If no slot is available
+// within the queue timeout, ErrOverloaded is returned.
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	l.mu.Lock()
+	if l.option.MaxConcurrency <= 0 {
+		l.mu.Unlock()
+		return func() {}, nil
+	}
+	if l.inFlight < l.option.MaxConcurrency && l.waiters.Len() == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	timeout := l.queueTimeout()
+	if timeout <= 0 {
+		l.mu.Unlock()
+		return nil, ErrOverloaded
+	}
+	w := &waiter{ready: make(chan struct{}), enqueued: time.Now()}
+	elem := l.waiters.PushBack(w)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-w.ready:
+		return l.release, nil
+	case <-timer.C:
+		err = ErrOverloaded
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-w.ready:
+		// admitted while timing out, the slot is ours.
+		return l.release, nil
+	default:
+		l.waiters.Remove(elem)
+		return nil, err
+	}
+}
+
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.waiters.Len() > 0 && l.inFlight <= l.option.MaxConcurrency {
+		// hand the slot over to the next waiter.
+		l.admit()
+		return
+	}
+	l.inFlight--
+}
+
+// admit wakes up the first waiter. The slot must be accounted by the caller.
+func (l *Limiter) admit() {
+	w := l.waiters.Remove(l.waiters.Front()).(*waiter)
+	l.recordDelay(time.Since(w.enqueued))
+	close(w.ready)
+}
+
+func (l *Limiter) queueTimeout() time.Duration {
+	if l.option.Codel.Enable && l.isOverloaded() {
+		return l.option.Codel.Target.Duration
+	}
+	return l.option.QueueTimeout.Duration
+}
+
+func (l *Limiter) recordDelay(delay time.Duration) {
+	if !l.option.Codel.Enable {
+		return
+	}
+	l.isOverloaded()
+	if l.minDelay < 0 || delay < l.minDelay {
+		l.minDelay = delay
+	}
+}
+
+// isOverloaded rotates the CoDel interval if due, and reports whether the
+// minimum queueing delay of the last interval exceeded the target.
+func (l *Limiter) isOverloaded() bool {
+	now := time.Now()
+	if now.Sub(l.intervalStart) >= l.option.Codel.Interval.Duration {
+		l.overloaded = l.minDelay > l.option.Codel.Target.Duration
+		l.minDelay = -1
+		l.intervalStart = now
+	}
+	return l.overloaded
+}
+
+type waiter struct {
+	ready    chan struct{}
+	enqueued time.Time
+}
@@ -0,0 +1,80 @@
+package loadshed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Acquire(t *testing.T) {
+	limiter := NewLimiter(Option{MaxConcurrency: 1})
+
+	release, err := limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+	_, err = limiter.Acquire(context.Background())
+	assert.Equal(t, ErrOverloaded, err)
+	release()
+	assert.Equal(t, 0, limiter.InFlight())
+
+	release, err = limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+	release()
+}
+
+func TestLimiter_Queue(t *testing.T) {
+	limiter := NewLimiter(Option{MaxConcurrency: 1, QueueTimeout: config.Duration{Duration: time.Second}})
+
+	release, err := limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release()
+	}()
+	release, err = limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, limiter.InFlight())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	release()
+	assert.Equal(t, 0, limiter.InFlight())
+}
+
+func TestLimiter_Update(t *testing.T) {
+	limiter := NewLimiter(Option{MaxConcurrency: 1, QueueTimeout: config.Duration{Duration: time.Second}})
+
+	release, err := limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+	defer release()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.Update(Option{MaxConcurrency: 2, QueueTimeout: config.Duration{Duration: time.Second}})
+	}()
+	release2, err := limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, limiter.InFlight())
+	release2()
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	limiter := NewLimiter(Option{MaxConcurrency: 1, RetryAfter: config.Duration{Duration: 1500 * time.Millisecond}})
+	release, _ := limiter.Acquire(context.Background())
+	defer release()
+
+	handler := MakeHTTPMiddleware(limiter)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Fatal("request should be shed")
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+}
@@ -0,0 +1,69 @@
+package loadshed
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/DoNewsCode/core/unierr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that sheds requests
+// when the Limiter is saturated. Shed requests receive 429 Too Many Requests
+// with a Retry-After header.
+func MakeHTTPMiddleware(limiter *Limiter) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			release, err := limiter.Acquire(request.Context())
+			if err != nil {
+				retryAfter := limiter.RetryAfter()
+				if retryAfter > 0 {
+					writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				}
+				srvhttp.NewResponseEncoder(writer).EncodeError(overloaded(err, limiter))
+				return
+			}
+			defer release()
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that sheds calls
+// when the Limiter is saturated. Shed calls receive RESOURCE_EXHAUSTED with a
+// google.rpc.RetryInfo detail.
+func MakeUnaryInterceptor(limiter *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		release, err := limiter.Acquire(ctx)
+		if err != nil {
+			return nil, overloaded(err, limiter)
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// MakeStreamInterceptor creates a grpc.StreamServerInterceptor that sheds
+// streams when the Limiter is saturated. A stream holds its slot for its whole
+// lifetime.
+func MakeStreamInterceptor(limiter *Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := limiter.Acquire(ss.Context())
+		if err != nil {
+			return overloaded(err, limiter)
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
+
+func overloaded(err error, limiter *Limiter) *unierr.Error {
+	if err != ErrOverloaded {
+		return unierr.FromStatus(status.FromContextError(err))
+	}
+	return unierr.ResourceExhaustedErr(err).WithRetryDelay(limiter.RetryAfter())
+}
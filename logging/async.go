@@ -0,0 +1,206 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/bufpool"
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+)
+
+const (
+	// PolicyDropOldest discards the oldest buffered log when the buffer is full.
+	PolicyDropOldest = "dropOldest"
+	// PolicyBlock blocks the writer until the buffer has room.
+	PolicyBlock = "block"
+)
+
+// AsyncOption is the configuration of AsyncWriter.
+type AsyncOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// BufferSize is the maximum number of buffered logs. Defaults to 1024.
+	BufferSize int `json:"bufferSize" yaml:"bufferSize"`
+	// MaxBatch is the number of logs that triggers a flush before the flush
+	// interval is due. It is also the maximum number of logs written at once.
+	// Defaults to 128.
+	MaxBatch int `json:"maxBatch" yaml:"maxBatch"`
+	// FlushInterval is the period between two flushes. Defaults to 100ms.
+	FlushInterval config.Duration `json:"flushInterval" yaml:"flushInterval"`
+	// Policy is the overflow policy, either "dropOldest" or "block". Defaults
+	// to "dropOldest".
+	Policy string `json:"policy" yaml:"policy"`
+}
+
+// AsyncWriter is an io.Writer that buffers writes in a ring buffer, and flushes
+// them to the underlying writer in background. It keeps slow writes, such as
+// stdout under pressure, off the request path. Each Write is treated as a
+// single log line.
+type AsyncWriter struct {
+	next    io.Writer
+	writeMu sync.Mutex
+	option  AsyncOption
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    []*bytes.Buffer
+	head    int
+	count   int
+	dropped uint64
+	closed  bool
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewAsyncWriter creates a new *AsyncWriter and starts the background flusher.
+// The writer must be closed to flush the remaining logs.
+func NewAsyncWriter(w io.Writer, option AsyncOption) *AsyncWriter {
+	if option.BufferSize <= 0 {
+		option.BufferSize = 1024
+	}
+	if option.MaxBatch <= 0 {
+		option.MaxBatch = 128
+	}
+	if option.FlushInterval.Duration <= 0 {
+		option.FlushInterval = config.Duration{Duration: 100 * time.Millisecond}
+	}
+	a := &AsyncWriter{
+		next:   w,
+		option: option,
+		ring:   make([]*bytes.Buffer, option.BufferSize),
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	a.notFull = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// Write buffers a copy of p in a pooled buffer. It never returns an error, as the actual write
+// happens later. Once the writer is closed, writes go to the underlying writer
+// directly.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	for !a.closed && a.count == len(a.ring) && a.option.Policy == PolicyBlock {
+		a.notFull.Wait()
+	}
+	if a.closed {
+		a.mu.Unlock()
+		a.writeMu.Lock()
+		defer a.writeMu.Unlock()
+		return a.next.Write(p)
+	}
+	if a.count == len(a.ring) {
+		bufpool.Put(a.ring[a.head])
+		a.ring[a.head] = nil
+		a.head = (a.head + 1) % len(a.ring)
+		a.count--
+		a.dropped++
+	}
+	buf := bufpool.Get(len(p))
+	buf.Write(p)
+	a.ring[(a.head+a.count)%len(a.ring)] = buf
+	a.count++
+	full := a.count >= a.option.MaxBatch
+	a.mu.Unlock()
+
+	if full {
+		select {
+		case a.notify <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of logs discarded due to overflow.
+func (a *AsyncWriter) Dropped() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.dropped
+}
+
+// Close flushes the buffered logs and stops the background flusher.
+func (a *AsyncWriter) Close() error {
+	a.once.Do(func() {
+		close(a.stop)
+		<-a.done
+	})
+	return nil
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.option.FlushInterval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.notify:
+			a.flush(false)
+		case <-ticker.C:
+			a.flush(false)
+		case <-a.stop:
+			a.flush(true)
+			return
+		}
+	}
+}
+
+// flush writes the buffered logs in batches. If final is true, the writer is
+// marked as closed in the same critical section as the last batch is taken, so
+// that no log slips in between.
+func (a *AsyncWriter) flush(final bool) {
+	batch := bufpool.Get(0)
+	defer bufpool.Put(batch)
+	for {
+		a.mu.Lock()
+		n := a.count
+		if n > a.option.MaxBatch {
+			n = a.option.MaxBatch
+		}
+		for i := 0; i < n; i++ {
+			batch.Write(a.ring[a.head].Bytes())
+			bufpool.Put(a.ring[a.head])
+			a.ring[a.head] = nil
+			a.head = (a.head + 1) % len(a.ring)
+		}
+		a.count -= n
+		last := a.count == 0
+		if last && final {
+			a.closed = true
+		}
+		a.notFull.Broadcast()
+		a.writeMu.Lock()
+		a.mu.Unlock()
+
+		if batch.Len() > 0 {
+			_, _ = a.next.Write(batch.Bytes())
+			batch.Reset()
+		}
+		a.writeMu.Unlock()
+		if last {
+			return
+		}
+	}
+}
+
+// AsyncLogger is a log.Logger backed by an AsyncWriter. It implements
+// container.CloserProvider, so that the buffered logs are flushed when the
+// core shuts down.
+type AsyncLogger struct {
+	log.Logger
+	Writer *AsyncWriter
+}
+
+// ProvideCloser flushes the AsyncWriter.
+func (a AsyncLogger) ProvideCloser() {
+	_ = a.Writer.Close()
+}
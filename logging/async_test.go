@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestAsyncWriter_flush(t *testing.T) {
+	var buf lockedBuffer
+	writer := NewAsyncWriter(&buf, AsyncOption{MaxBatch: 2, FlushInterval: config.Duration{Duration: time.Hour}})
+	writer.Write([]byte("a\n"))
+	assert.Empty(t, buf.String())
+	writer.Write([]byte("b\n"))
+	assert.Eventually(t, func() bool { return buf.String() == "a\nb\n" }, time.Second, time.Millisecond)
+
+	writer.Write([]byte("c\n"))
+	writer.Close()
+	assert.Equal(t, "a\nb\nc\n", buf.String())
+
+	writer.Write([]byte("d\n"))
+	assert.Equal(t, "a\nb\nc\nd\n", buf.String())
+}
+
+func TestAsyncWriter_dropOldest(t *testing.T) {
+	var buf lockedBuffer
+	writer := NewAsyncWriter(&buf, AsyncOption{BufferSize: 2, MaxBatch: 10, FlushInterval: config.Duration{Duration: time.Hour}})
+	writer.Write([]byte("a\n"))
+	writer.Write([]byte("b\n"))
+	writer.Write([]byte("c\n"))
+	assert.Equal(t, uint64(1), writer.Dropped())
+	writer.Close()
+	assert.Equal(t, "b\nc\n", buf.String())
+}
+
+func TestAsyncWriter_block(t *testing.T) {
+	var buf lockedBuffer
+	writer := NewAsyncWriter(&buf, AsyncOption{BufferSize: 1, MaxBatch: 10, FlushInterval: config.Duration{Duration: 10 * time.Millisecond}, Policy: PolicyBlock})
+	writer.Write([]byte("a\n"))
+	writer.Write([]byte("b\n"))
+	writer.Close()
+	assert.Equal(t, uint64(0), writer.Dropped())
+	assert.Equal(t, "a\nb\n", buf.String())
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// DebugOption configures the per request debug trigger. A request carrying the
+// secret token, either in the header or in the query parameter, is logged at
+// debug level regardless of the global level.
+type DebugOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Token is the secret that triggers debug logging. An empty token never matches.
+	Token string `json:"token" yaml:"token"`
+	// Header is the HTTP header, or gRPC metadata key, carrying the token.
+	// Defaults to "X-Debug-Token".
+	Header string `json:"header" yaml:"header"`
+	// Query is the HTTP query parameter carrying the token. Defaults to "debug_token".
+	Query string `json:"query" yaml:"query"`
+}
+
+func (d DebugOption) header() string {
+	if d.Header == "" {
+		return "X-Debug-Token"
+	}
+	return d.Header
+}
+
+func (d DebugOption) query() string {
+	if d.Query == "" {
+		return "debug_token"
+	}
+	return d.Query
+}
+
+func (d DebugOption) verify(token string) bool {
+	if !d.Enable || d.Token == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(d.Token)) == 1
+}
+
+// WithDebug returns a context in which all logs are written regardless of the
+// global level. Only loggers created by WithContext or WithRequestLevel from
+// that context are affected.
+func WithDebug(ctx context.Context) context.Context {
+	if s, ok := ctx.Value(samplingKey{}).(*sampling); ok {
+		s.elevate()
+		return ctx
+	}
+	return context.WithValue(ctx, samplingKey{}, &sampling{elevated: true})
+}
+
+// WithRequestLevel decorates the logger with the per request level decision in
+// the context, made by the sampling middlewares or the debug trigger. Unlike
+// WithContext, no other information is added.
+func WithRequestLevel(logger log.Logger, ctx context.Context) log.Logger {
+	if s, ok := ctx.Value(samplingKey{}).(*sampling); ok {
+		return log.With(logger, samplingKey{}, s)
+	}
+	return logger
+}
+
+// MakeHTTPDebugMiddleware creates a standard HTTP middleware that enables debug
+// logging for requests carrying the debug token.
+func MakeHTTPDebugMiddleware(option DebugOption) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			token := request.Header.Get(option.header())
+			if token == "" {
+				token = request.URL.Query().Get(option.query())
+			}
+			if option.verify(token) {
+				request = request.WithContext(WithDebug(request.Context()))
+			}
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryDebugInterceptor creates a grpc.UnaryServerInterceptor that enables
+// debug logging for calls carrying the debug token in metadata.
+func MakeUnaryDebugInterceptor(option DebugOption) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(strings.ToLower(option.header())); len(values) > 0 && option.verify(values[0]) {
+				ctx = WithDebug(ctx)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMakeHTTPDebugMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLevelFilter(log.NewLogfmtLogger(&buf), "error")
+	handler := MakeHTTPDebugMiddleware(DebugOption{Enable: true, Token: "secret"})(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		level.Debug(WithRequestLevel(logger, request.Context())).Log("msg", request.URL.RequestURI())
+	}))
+
+	for _, c := range []struct {
+		target string
+		header string
+		logged bool
+	}{
+		{"/none", "", false},
+		{"/header", "secret", true},
+		{"/wrong", "wrong", false},
+		{"/query?debug_token=secret", "", true},
+	} {
+		buf.Reset()
+		request := httptest.NewRequest(http.MethodGet, c.target, nil)
+		if c.header != "" {
+			request.Header.Set("X-Debug-Token", c.header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		assert.Equal(t, c.logged, buf.Len() > 0, c.target)
+	}
+
+	handler = MakeHTTPDebugMiddleware(DebugOption{Enable: true})(handler)
+	buf.Reset()
+	request := httptest.NewRequest(http.MethodGet, "/empty", nil)
+	request.Header.Set("X-Debug-Token", "")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	assert.Zero(t, buf.Len())
+}
+
+func TestMakeUnaryDebugInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLevelFilter(log.NewLogfmtLogger(&buf), "error")
+	interceptor := MakeUnaryDebugInterceptor(DebugOption{Enable: true, Token: "secret"})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-debug-token", "secret"))
+	_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		level.Debug(WithContext(logger, ctx)).Log("msg", "debug")
+		return nil, nil
+	})
+	assert.Contains(t, buf.String(), "msg=debug")
+}
@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Levels holds the log level that can be adjusted at runtime. Besides the
+// global level, each subsystem can have its own level, which applies to the
+// logs with the matching "module" key. Levels is safe for concurrent use.
+type Levels struct {
+	min int32
+
+	mu      sync.Mutex
+	modules atomic.Value // map[string]int
+}
+
+// NewLevels creates a *Levels with the given level. The allowed levels are
+// "debug", "info", "warn", "error", or "none". Unknown levels allow all logs.
+func NewLevels(lvl string) *Levels {
+	return &Levels{min: int32(minRank(lvl))}
+}
+
+// Set changes the level.
+func (l *Levels) Set(lvl string) error {
+	if !isLevel(lvl) {
+		return fmt.Errorf("allowed levels are \"debug\", \"info\", \"warn\", \"error\", or \"none\", got \"%s\"", lvl)
+	}
+	atomic.StoreInt32(&l.min, int32(minRank(lvl)))
+	return nil
+}
+
+// Get returns the current level.
+func (l *Levels) Get() string {
+	return levelName(int(atomic.LoadInt32(&l.min)))
+}
+
+// SetModule changes the level of the logs whose "module" key equals module. An
+// empty level removes the override, so that the global level applies again.
+func (l *Levels) SetModule(module, lvl string) error {
+	if lvl != "" && !isLevel(lvl) {
+		return fmt.Errorf("allowed levels are \"debug\", \"info\", \"warn\", \"error\", or \"none\", got \"%s\"", lvl)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	old, _ := l.modules.Load().(map[string]int)
+	modules := make(map[string]int, len(old)+1)
+	for k, v := range old {
+		modules[k] = v
+	}
+	if lvl == "" {
+		delete(modules, module)
+	} else {
+		modules[module] = rank(lvl)
+	}
+	l.modules.Store(modules)
+	return nil
+}
+
+// Modules returns the per module levels.
+func (l *Levels) Modules() map[string]string {
+	modules, _ := l.modules.Load().(map[string]int)
+	out := make(map[string]string, len(modules))
+	for k, v := range modules {
+		out[k] = levelName(v)
+	}
+	return out
+}
+
+// Filter decorates the logger with a level filter that follows the Levels.
+// See NewLevelFilter.
+func (l *Levels) Filter(logger log.Logger) log.Logger {
+	return levelFilter{next: logger, levels: l}
+}
+
+func (l *Levels) allow(lvl string, module interface{}) bool {
+	min := int(atomic.LoadInt32(&l.min))
+	if modules, _ := l.modules.Load().(map[string]int); len(modules) > 0 && module != nil {
+		if r, ok := modules[fmt.Sprint(module)]; ok {
+			min = r
+		}
+	}
+	return rank(lvl) >= min
+}
+
+// hasModules reports whether there is any per module level.
+func (l *Levels) hasModules() bool {
+	modules, _ := l.modules.Load().(map[string]int)
+	return len(modules) > 0
+}
+
+// LevelsOf returns the *Levels used by the logger, if the logger is created by
+// NewLevelFilter or Levels.Filter, possibly wrapped by AsyncLogger or Tee.
+// Otherwise nil is returned.
+func LevelsOf(logger log.Logger) *Levels {
+	switch l := logger.(type) {
+	case levelFilter:
+		return l.levels
+	case AsyncLogger:
+		return LevelsOf(l.Logger)
+	case *Tee:
+		return LevelsOf(l.next)
+	default:
+		return nil
+	}
+}
+
+func isLevel(lvl string) bool {
+	switch lvl {
+	case "debug", "info", "warn", "error", "none":
+		return true
+	default:
+		return false
+	}
+}
+
+func minRank(lvl string) int {
+	if !isLevel(lvl) {
+		return rank("debug")
+	}
+	return rank(lvl)
+}
+
+func levelName(r int) string {
+	for _, lvl := range []string{"debug", "info", "warn", "error", "none"} {
+		if rank(lvl) == r {
+			return lvl
+		}
+	}
+	return "debug"
+}
@@ -14,12 +14,88 @@ Package logging is bundled in core. Enable logging as dependency by calling:
 	c.ProvideEssentials()
 
 See example for usage.
+
+Schema
+
+Downstream pipelines (eg. ELK, Loki) expect consistent keys. With the schema
+enabled, every log starts with the canonical fields ts, level, msg, app, env,
+module and trace_id. Inconsistent keys can be renamed to canonical ones:
+
+	log:
+	  format: json
+	  schema:
+	    enable: true
+	    rename:
+	      message: msg
+	      traceId: trace_id
+
+The trace_id is left empty, unless a TraceIDExtractor for the tracer in use is
+set. For the jaeger tracer provided by package observability:
+
+	logging.SetTraceIDExtractor(observability.TraceID)
+
+Async Writes
+
+Writing to stdout may block under pressure. The logs can be buffered and
+written in background instead. When the buffer is full, the oldest log is
+dropped, or the writer blocks, depending on the policy. The buffer is flushed
+when the core shuts down.
+
+	log:
+	  async:
+	    enable: true
+	    bufferSize: 1024
+	    maxBatch: 128
+	    flushInterval: 100ms
+	    policy: dropOldest # or block
+
+Sampling
+
+To keep the steady-state volume low, set a high log level, and enable trace
+based sampling. Logs below the level are then written for requests whose trace
+is sampled. With onError, the suppressed logs are buffered, and written if the
+request errors.
+
+	log:
+	  level: info
+	  sampling:
+	    enable: true
+	    onError: true
+	    bufferSize: 100
+
+The sampling middleware must be placed after the tracing middleware, and the
+request logger must be created by WithContext:
+
+	var option logging.SamplingOption
+	conf.Unmarshal("log.sampling", &option)
+	router.Use(logging.MakeHTTPSamplingMiddleware(option))
+
+Debug Trigger
+
+For production debugging, a single request can be logged at debug level
+without changing the global level, by carrying a secret token in the header
+"X-Debug-Token" or the query parameter "debug_token":
+
+	log:
+	  debug:
+	    enable: true
+	    token: some-secret
+
+Install the middleware the same way as the sampling middleware:
+
+	var option logging.DebugOption
+	conf.Unmarshal("log.debug", &option)
+	router.Use(logging.MakeHTTPDebugMiddleware(option))
+
+The SQL logs of otgorm respect the trigger, as long as the context is passed to
+gorm by db.WithContext(ctx).
 */
 package logging
 
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -72,6 +148,16 @@ func NewLogger(format string) (logger log.Logger) {
 	}
 }
 
+// NewFormatLogger constructs a log.Logger writing to w in the given format.
+// Unlike NewLogger, no timestamp is added, and the output is not colored. It
+// is meant to be decorated, eg. by Normalize.
+func NewFormatLogger(w io.Writer, format string) log.Logger {
+	if strings.ToLower(format) == "json" {
+		return log.NewJSONLogger(log.NewSyncWriter(w))
+	}
+	return log.NewLogfmtLogger(log.NewSyncWriter(w))
+}
+
 // LevelFilter filters the log output based on its level.
 // Allowed levels are "debug", "info", "warn", "error", or "none"
 func LevelFilter(levelCfg string) level.Option {
@@ -120,14 +206,19 @@ func withContext(logger log.Logger, ctx context.Context) log.Logger {
 		tenant = contract.MapTenant{}
 	}
 	args := []interface{}{"transport", transport, "requestUrl", requestUrl, "clientIp", ip}
+	if extract := loadTraceIDExtractor(); extract != nil {
+		if traceID := extract(ctx); traceID != "" {
+			args = append(args, "trace_id", traceID)
+		}
+	}
 	for k, v := range tenant.KV() {
 		args = append(args, k, v)
 	}
 
-	return log.With(
+	return WithRequestLevel(log.With(
 		logger,
 		args...,
-	)
+	), ctx)
 }
 
 type levelLogger struct {
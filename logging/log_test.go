@@ -2,6 +2,8 @@ package logging
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/go-kit/kit/log"
@@ -29,3 +31,24 @@ func TestLevelFilter(t *testing.T) {
 func TestNewLogger(t *testing.T) {
 	_ = NewLogger("logfmt")
 }
+
+func TestNormalize(t *testing.T) {
+	var buf bytes.Buffer
+	logger := Normalize(log.NewLogfmtLogger(&buf), "app", "testing", SchemaOption{
+		Rename: map[string]string{"message": "msg"},
+	})
+	level.Info(logger).Log("message", "hello", "err", errors.New("foo"), "module", "test", "foo", []byte("bar"))
+	assert.Regexp(t, `^ts=\S+ level=info msg=hello app=app env=testing module=test trace_id= err=foo foo=bar\n$`, buf.String())
+}
+
+func TestSetTraceIDExtractor(t *testing.T) {
+	var buf bytes.Buffer
+	WithContext(log.NewLogfmtLogger(&buf), context.Background()).Log("msg", "hello")
+	assert.NotContains(t, buf.String(), "trace_id")
+
+	SetTraceIDExtractor(func(ctx context.Context) string { return "foo" })
+	defer SetTraceIDExtractor(nil)
+	buf.Reset()
+	WithContext(log.NewLogfmtLogger(&buf), context.Background()).Log("msg", "hello")
+	assert.Contains(t, buf.String(), "trace_id=foo")
+}
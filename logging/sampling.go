@@ -0,0 +1,249 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+)
+
+// SamplingOption configures trace based log sampling. With sampling enabled,
+// debug logs (or any logs below the configured level) are written for requests
+// whose trace is sampled. If OnError is true, suppressed logs of other requests
+// are buffered, and written only if the request errors.
+type SamplingOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// OnError enables buffering suppressed logs until the request errors.
+	OnError bool `json:"onError" yaml:"onError"`
+	// BufferSize is the maximum number of suppressed logs buffered per request.
+	// Defaults to 100.
+	BufferSize int `json:"bufferSize" yaml:"bufferSize"`
+}
+
+type samplingKey struct{}
+
+// sampling is the per request sampling state. It travels alongside the keyvals
+// until it is picked up by the level filter.
+type sampling struct {
+	mu       sync.Mutex
+	elevated bool
+	onError  bool
+	size     int
+	buffer   []bufferedLog
+}
+
+type bufferedLog struct {
+	next    log.Logger
+	keyvals []interface{}
+}
+
+// admit reports whether the suppressed log should be written now. If not, the
+// log may be buffered for later.
+func (s *sampling) admit(next log.Logger, keyvals []interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.elevated {
+		return true
+	}
+	if s.onError && len(s.buffer) < s.size {
+		s.buffer = append(s.buffer, bufferedLog{next: next, keyvals: keyvals})
+	}
+	return false
+}
+
+// elevate writes the buffered logs, and lets subsequent logs of the request
+// pass through the level filter.
+func (s *sampling) elevate() {
+	s.mu.Lock()
+	buffer := s.buffer
+	s.buffer = nil
+	s.elevated = true
+	s.mu.Unlock()
+
+	for _, l := range buffer {
+		_ = l.next.Log(l.keyvals...)
+	}
+}
+
+func (s *sampling) isElevated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.elevated
+}
+
+func (s *sampling) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer = nil
+}
+
+// withSampling starts the sampling state for a request. The request is elevated
+// from the beginning if its trace is sampled.
+func withSampling(ctx context.Context, option SamplingOption) (context.Context, *sampling) {
+	s := &sampling{onError: option.OnError, size: option.BufferSize}
+	if s.size <= 0 {
+		s.size = 100
+	}
+	if parent, ok := ctx.Value(samplingKey{}).(*sampling); ok && parent.isElevated() {
+		s.elevated = true
+	}
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		if sc, ok := span.Context().(interface{ IsSampled() bool }); ok && sc.IsSampled() {
+			s.elevated = true
+		}
+	}
+	return context.WithValue(ctx, samplingKey{}, s), s
+}
+
+// NewLevelFilter is like level.NewFilter, but cooperates with the log sampling
+// middlewares: logs below the level are still written if the request is
+// sampled, or buffered until the request errors. The allowed levels are
+// "debug", "info", "warn", "error", or "none".
+func NewLevelFilter(logger log.Logger, levelCfg string) log.Logger {
+	return NewLevels(levelCfg).Filter(logger)
+}
+
+type levelFilter struct {
+	next   log.Logger
+	levels *Levels
+}
+
+func (l levelFilter) Log(keyvals ...interface{}) error {
+	var (
+		s        *sampling
+		lvl      string
+		module   interface{}
+		modules  = l.levels.hasModules()
+		filtered = make([]interface{}, 0, len(keyvals))
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 >= len(keyvals) {
+			filtered = append(filtered, keyvals[i])
+			break
+		}
+		if _, ok := keyvals[i].(samplingKey); ok {
+			s, _ = keyvals[i+1].(*sampling)
+			continue
+		}
+		if v, ok := keyvals[i+1].(level.Value); ok {
+			lvl = v.String()
+		}
+		if modules && keyvals[i] == "module" {
+			module = keyvals[i+1]
+		}
+		filtered = append(filtered, keyvals[i], keyvals[i+1])
+	}
+	if s != nil && lvl == "error" {
+		s.elevate()
+	}
+	if l.levels.allow(lvl, module) {
+		return l.next.Log(filtered...)
+	}
+	if s != nil && s.admit(l.next, filtered) {
+		return l.next.Log(filtered...)
+	}
+	return nil
+}
+
+// rank returns the severity of the level. Logs without a known level are never
+// filtered.
+func rank(lvl string) int {
+	switch lvl {
+	case "debug":
+		return 0
+	case "info":
+		return 1
+	case "warn":
+		return 2
+	case "error":
+		return 3
+	case "none":
+		return 4
+	default:
+		return 5
+	}
+}
+
+// MakeHTTPSamplingMiddleware creates a standard HTTP middleware that starts
+// log sampling for each request. It must be placed after the tracing
+// middleware to see the sampling decision. A request is considered errored if
+// it responds with a status code of 500 or above.
+func MakeHTTPSamplingMiddleware(option SamplingOption) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		if !option.Enable {
+			return handler
+		}
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ctx, s := withSampling(request.Context(), option)
+			defer s.release()
+			recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+			handler.ServeHTTP(recorder, request.WithContext(ctx))
+			if recorder.status >= http.StatusInternalServerError {
+				s.elevate()
+			}
+		})
+	}
+}
+
+// MakeUnarySamplingInterceptor creates a grpc.UnaryServerInterceptor that
+// starts log sampling for each call. It must be placed after the tracing
+// interceptor to see the sampling decision.
+func MakeUnarySamplingInterceptor(option SamplingOption) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !option.Enable {
+			return handler(ctx, req)
+		}
+		ctx, s := withSampling(ctx, option)
+		defer s.release()
+		resp, err := handler(ctx, req)
+		if err != nil {
+			s.elevate()
+		}
+		return resp, err
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Flush, Hijack and Push are forwarded, so that streaming responses and
+// websocket upgrades work behind the sampling middleware.
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer doesn't support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+func (s *statusRecorder) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := s.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
+}
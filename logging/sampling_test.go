@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
+	"google.golang.org/grpc"
+)
+
+func TestNewLevelFilter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLevelFilter(log.NewLogfmtLogger(&buf), "info")
+	level.Debug(logger).Log("foo", "debug")
+	level.Info(logger).Log("foo", "info")
+	logger.Log("foo", "none")
+	assert.Equal(t, "level=info foo=info\nfoo=none\n", buf.String())
+}
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels("info")
+	logger := levels.Filter(log.NewLogfmtLogger(&buf))
+	assert.Equal(t, levels, LevelsOf(NewTee(AsyncLogger{Logger: logger})))
+
+	assert.NoError(t, levels.SetModule("foo", "debug"))
+	assert.NoError(t, levels.Set("warn"))
+	assert.Error(t, levels.Set("bar"))
+	level.Info(logger).Log("module", "bar")
+	level.Debug(logger).Log("module", "foo")
+	assert.Equal(t, map[string]string{"foo": "debug"}, levels.Modules())
+
+	assert.NoError(t, levels.SetModule("foo", ""))
+	level.Debug(logger).Log("module", "foo")
+	assert.Equal(t, "level=debug module=foo\n", buf.String())
+}
+
+func TestMakeHTTPSamplingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLevelFilter(log.NewLogfmtLogger(&buf), "info")
+	option := SamplingOption{Enable: true, OnError: true}
+
+	handler := MakeHTTPSamplingMiddleware(option)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		level.Debug(WithContext(logger, request.Context())).Log("msg", request.URL.Path)
+		if request.URL.Path == "/error" {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Empty(t, buf.String())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Contains(t, buf.String(), "msg=/error")
+
+	buf.Reset()
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	request := httptest.NewRequest(http.MethodGet, "/sampled", nil)
+	request = request.WithContext(opentracing.ContextWithSpan(request.Context(), span))
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	assert.Contains(t, buf.String(), "msg=/sampled")
+}
+
+func TestMakeHTTPSamplingMiddleware_flusher(t *testing.T) {
+	handler := MakeHTTPSamplingMiddleware(SamplingOption{Enable: true})(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		flusher, ok := writer.(http.Flusher)
+		assert.True(t, ok)
+		writer.Write([]byte("data: foo\n\n"))
+		flusher.Flush()
+		_, ok = writer.(http.Hijacker)
+		assert.True(t, ok)
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.True(t, recorder.Flushed)
+}
+
+func TestMakeUnarySamplingInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLevelFilter(log.NewLogfmtLogger(&buf), "info")
+	interceptor := MakeUnarySamplingInterceptor(SamplingOption{Enable: true, OnError: true})
+
+	_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		ctxLogger := WithContext(logger, ctx)
+		level.Debug(ctxLogger).Log("msg", "before")
+		level.Error(ctxLogger).Log("msg", "failed")
+		level.Debug(ctxLogger).Log("msg", "after")
+		return nil, nil
+	})
+	assert.Contains(t, buf.String(), "msg=before")
+	assert.Contains(t, buf.String(), "msg=failed")
+	assert.Contains(t, buf.String(), "msg=after")
+}
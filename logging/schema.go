@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+)
+
+// canonicalKeys are the fields always present in a normalized log, in order.
+var canonicalKeys = []string{"ts", "level", "msg", "app", "env", "module", "trace_id"}
+
+// SchemaOption configures the log schema enforcement.
+type SchemaOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Rename maps inconsistent keys to canonical ones, eg. "message" to "msg".
+	Rename map[string]string `json:"rename" yaml:"rename"`
+}
+
+// TraceIDExtractor returns the trace id of the span in the context, or "" if
+// there is none. It is specific to the tracer in use.
+type TraceIDExtractor func(ctx context.Context) string
+
+var traceIDExtractor atomic.Value
+
+type traceIDExtractorHolder struct {
+	extractor TraceIDExtractor
+}
+
+// SetTraceIDExtractor makes WithContext add the trace_id field, as returned by
+// the extractor. The field is not added by default. It is meant to be used with
+// the schema enabled, where trace_id is a canonical field. Pass nil to stop
+// adding the field.
+func SetTraceIDExtractor(extractor TraceIDExtractor) {
+	traceIDExtractor.Store(traceIDExtractorHolder{extractor})
+}
+
+func loadTraceIDExtractor() TraceIDExtractor {
+	holder, _ := traceIDExtractor.Load().(traceIDExtractorHolder)
+	return holder.extractor
+}
+
+// NewSchemaLogger constructs a log.Logger like NewLogger, but enforces a
+// canonical field set on every log: ts, level, msg, app, env, module and
+// trace_id. The canonical fields always come first, and are set to "" if
+// missing. Keys are renamed according to the SchemaOption. Values are
+// normalized so that they serialize consistently: errors, fmt.Stringers and
+// byte slices become strings, and times are formatted in RFC3339.
+func NewSchemaLogger(format string, appName contract.AppName, env contract.Env, option SchemaOption) log.Logger {
+	return Normalize(NewFormatLogger(os.Stdout, format), appName.String(), env.String(), option)
+}
+
+// Normalize decorates the logger with the schema enforcement. The logger
+// should not add the canonical fields by itself, or they will be duplicated.
+// See NewSchemaLogger.
+func Normalize(logger log.Logger, app, env string, option SchemaOption) log.Logger {
+	return schemaLogger{
+		next:   logger,
+		app:    app,
+		env:    env,
+		rename: option.Rename,
+	}
+}
+
+type schemaLogger struct {
+	next   log.Logger
+	app    string
+	env    string
+	rename map[string]string
+}
+
+func (s schemaLogger) Log(keyvals ...interface{}) error {
+	canonical := map[string]interface{}{
+		"ts":  time.Now().UTC().Format(time.RFC3339Nano),
+		"app": s.app,
+		"env": s.env,
+	}
+	rest := make([]interface{}, 0, len(keyvals))
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(normalize(keyvals[i]))
+		if renamed, ok := s.rename[key]; ok {
+			key = renamed
+		}
+		var value interface{} = log.ErrMissingValue
+		if i+1 < len(keyvals) {
+			value = normalize(keyvals[i+1])
+		}
+		if isCanonical(key) {
+			canonical[key] = value
+			continue
+		}
+		rest = append(rest, key, value)
+	}
+	out := make([]interface{}, 0, 2*len(canonicalKeys)+len(rest))
+	for _, key := range canonicalKeys {
+		value, ok := canonical[key]
+		if !ok {
+			value = ""
+		}
+		out = append(out, key, value)
+	}
+	return s.next.Log(append(out, rest...)...)
+}
+
+func isCanonical(key string) bool {
+	for _, k := range canonicalKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return string(v)
+	default:
+		return v
+	}
+}
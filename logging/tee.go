@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"sync"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Tee is a log.Logger that duplicates logs to additional sinks. Sinks can be
+// added after the logger is in use, which allows the sinks to be built from
+// dependencies.
+type Tee struct {
+	next  log.Logger
+	mu    sync.RWMutex
+	sinks []log.Logger
+}
+
+// NewTee creates a new *Tee writing to the given logger.
+func NewTee(logger log.Logger) *Tee {
+	return &Tee{next: logger}
+}
+
+// AddSink adds a sink to the Tee. The sink receives logs as is, so it is
+// responsible for level filtering.
+func (t *Tee) AddSink(sink log.Logger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sinks = append(t.sinks, sink)
+}
+
+// Log implements log.Logger. Errors from sinks are ignored.
+func (t *Tee) Log(keyvals ...interface{}) error {
+	t.mu.RLock()
+	sinks := t.sinks
+	t.mu.RUnlock()
+
+	for _, sink := range sinks {
+		_ = sink.Log(keyvals...)
+	}
+	return t.next.Log(keyvals...)
+}
+
+// ProvideCloser closes the underlying logger if it has a closer, such as
+// AsyncLogger.
+func (t *Tee) ProvideCloser() {
+	if closer, ok := t.next.(interface{ ProvideCloser() }); ok {
+		closer.ProvideCloser()
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Package logsink ships structured logs to Kafka or Fluentd, in addition to
+stdout. It is meant for environments without node-level log collection.
+
+Logs are buffered and shipped in background by a logging.AsyncWriter, so that
+a slow sink never blocks the request path. The logs are filtered by the global
+log level, and normalized if the log schema is enabled.
+
+Integration
+
+The sinks are selected by "log.sinks":
+
+	log:
+	  sinks:
+	    - type: kafka
+	      writer: log # the name of the kafka writer, see package otkafka
+	    - type: fluentd
+	      addr: 127.0.0.1:24224
+	      tag: app
+
+The Kafka sink writes one JSON message per log. It creates its own writer from
+the configuration of the named kafka writer, without loggers to avoid feedback
+loops, so the writers made by otkafka are left untouched. The Fluentd sink
+speaks the forward protocol, which is also understood by Fluent Bit.
+
+Add the module to core:
+
+	var c *core.C = core.Default()
+	c.AddModuleFunc(logsink.New)
+
+Note the Tee must be installed by the default logger provider, which only
+happens if "log.sinks" is not empty.
+*/
+package logsink
@@ -0,0 +1,97 @@
+package logsink
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/DoNewsCode/core"
+	"github.com/DoNewsCode/core/otkafka"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf)
+	assert.NoError(t, logger.Log("msg", "hello", "err", errors.New("foo")))
+	assert.NoError(t, logger.Log("msg", "world"))
+	assert.Equal(t, "{\"err\":\"foo\",\"msg\":\"hello\"}\n{\"msg\":\"world\"}\n", buf.String())
+}
+
+func TestFluentLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewFluentLogger(&buf, "test")
+	assert.NoError(t, logger.Log("msg", "hello", "err", errors.New("foo"), "n", 1, "missing"))
+
+	var event []interface{}
+	assert.NoError(t, msgpack.Unmarshal(buf.Bytes(), &event))
+	assert.Len(t, event, 3)
+	assert.Equal(t, "test", event[0])
+	assert.Equal(t, map[string]interface{}{
+		"msg":     "hello",
+		"err":     "foo",
+		"n":       int8(1),
+		"missing": log.ErrMissingValue.Error(),
+	}, event[2])
+}
+
+func TestNew_fluentd(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	received := make(chan []byte)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		data, _ := ioutil.ReadAll(conn)
+		received <- data
+	}()
+
+	c := core.New(
+		core.WithInline("log.level", "info"),
+		core.WithInline("log.sinks", []map[string]interface{}{
+			{"type": "fluentd", "addr": ln.Addr().String(), "tag": "test"},
+		}),
+	)
+	c.ProvideEssentials()
+	c.AddModuleFunc(New)
+	c.Info("hello")
+	c.Debug("filtered")
+	c.Shutdown()
+
+	data := <-received
+	assert.Contains(t, string(data), "test")
+	assert.Contains(t, string(data), "hello")
+	assert.NotContains(t, string(data), "filtered")
+}
+
+func TestNew_kafka(t *testing.T) {
+	c := core.New(
+		core.WithInline("log.level", "none"),
+		core.WithInline("log.sinks", []map[string]interface{}{
+			{"type": "kafka", "writer": "default"},
+		}),
+	)
+	c.ProvideEssentials()
+	c.Provide(otkafka.Providers())
+	c.AddModuleFunc(New)
+	c.Invoke(func(maker otkafka.WriterMaker) {
+		writer, err := maker.Make("default")
+		assert.NoError(t, err)
+		assert.NotNil(t, writer.Logger, "the shared writer must not be changed by the sink")
+		assert.NotNil(t, writer.ErrorLogger, "the shared writer must not be changed by the sink")
+	})
+	c.Shutdown()
+}
+
+func TestNew_noSinks(t *testing.T) {
+	c := core.New()
+	c.ProvideEssentials()
+	c.AddModuleFunc(New)
+}
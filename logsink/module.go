@@ -0,0 +1,122 @@
+package logsink
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/DoNewsCode/core/otkafka"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// SinkOption is the configuration of a single sink.
+type SinkOption struct {
+	// Type is either "kafka" or "fluentd".
+	Type string `json:"type" yaml:"type"`
+	// Writer is the name of the kafka writer. Only used by kafka sinks.
+	Writer string `json:"writer" yaml:"writer"`
+	// Addr is the address of the Fluentd forward input. Only used by fluentd sinks.
+	Addr string `json:"addr" yaml:"addr"`
+	// Tag is the Fluentd tag. Defaults to the app name. Only used by fluentd sinks.
+	Tag string `json:"tag" yaml:"tag"`
+	// Async configures the buffering of the sink.
+	Async logging.AsyncOption `json:"async" yaml:"async"`
+}
+
+// ModuleIn contains the input parameters needed for creating the new module.
+type ModuleIn struct {
+	di.In
+
+	AppName contract.AppName
+	Env     contract.Env
+	Conf    contract.ConfigAccessor
+	LogTee  *logging.Tee
+}
+
+// Module is the registration unit for package core. It flushes the sinks on
+// shutdown.
+type Module struct {
+	closers []func()
+}
+
+// New creates the sinks configured in "log.sinks", and adds them to the
+// logging.Tee of core.
+func New(in ModuleIn) (Module, error) {
+	var options []SinkOption
+	if err := in.Conf.Unmarshal("log.sinks", &options); err != nil {
+		return Module{}, fmt.Errorf("log.sinks configuration error: %w", err)
+	}
+	if len(options) == 0 {
+		return Module{}, nil
+	}
+	if in.LogTee == nil {
+		return Module{}, fmt.Errorf("the logger doesn't support sinks, is the default logger provider replaced?")
+	}
+
+	var (
+		schema logging.SchemaOption
+		m      Module
+	)
+	_ = in.Conf.Unmarshal("log.schema", &schema)
+
+	// share the levels with the core logger, so that runtime changes apply to
+	// the sinks too.
+	levels := logging.LevelsOf(in.LogTee)
+	if levels == nil {
+		var lvl string
+		_ = in.Conf.Unmarshal("log.level", &lvl)
+		levels = logging.NewLevels(lvl)
+	}
+
+	for _, option := range options {
+		var sink log.Logger
+		switch strings.ToLower(option.Type) {
+		case "kafka":
+			var writerConfig otkafka.WriterConfig
+			if err := in.Conf.Unmarshal(fmt.Sprintf("kafka.writer.%s", option.Writer), &writerConfig); err != nil {
+				return Module{}, fmt.Errorf("kafka log sink: kafka writer configuration %s not valid: %w", option.Writer, err)
+			}
+			// the sink has its own writer without loggers, as they would log
+			// through the sink, creating a feedback loop.
+			writer := otkafka.NewWriter(writerConfig)
+			asyncWriter := logging.NewAsyncWriter(KafkaWriter{Writer: writer}, option.Async)
+			m.closers = append(m.closers, func() {
+				_ = asyncWriter.Close()
+				_ = writer.Close()
+			})
+			sink = NewJSONLogger(asyncWriter)
+		case "fluentd":
+			tag := option.Tag
+			if tag == "" {
+				tag = in.AppName.String()
+			}
+			fluentWriter := &FluentWriter{Addr: option.Addr}
+			asyncWriter := logging.NewAsyncWriter(fluentWriter, option.Async)
+			m.closers = append(m.closers, func() {
+				_ = asyncWriter.Close()
+				_ = fluentWriter.Close()
+			})
+			sink = NewFluentLogger(asyncWriter, tag)
+		default:
+			return Module{}, fmt.Errorf("unknown log sink type %q", option.Type)
+		}
+		if schema.Enable {
+			sink = logging.Normalize(sink, in.AppName.String(), in.Env.String(), schema)
+		} else {
+			sink = log.With(sink, "ts", log.DefaultTimestampUTC)
+		}
+		sink = level.NewInjector(sink, level.DebugValue())
+		in.LogTee.AddSink(levels.Filter(sink))
+	}
+	return m, nil
+}
+
+// ProvideCloser flushes the sinks.
+func (m Module) ProvideCloser() {
+	for _, closer := range m.closers {
+		closer()
+	}
+}
@@ -0,0 +1,179 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/bufpool"
+	"github.com/go-kit/kit/log"
+	"github.com/segmentio/kafka-go"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// KafkaWriter is an io.Writer that sends each line as a kafka message. It is
+// meant to be wrapped by a logging.AsyncWriter.
+type KafkaWriter struct {
+	Writer *kafka.Writer
+}
+
+// Write sends the lines in p as kafka messages.
+func (k KafkaWriter) Write(p []byte) (int, error) {
+	lines := bytes.Split(bytes.TrimSuffix(p, []byte("\n")), []byte("\n"))
+	messages := make([]kafka.Message, 0, len(lines))
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		messages = append(messages, kafka.Message{Value: line})
+	}
+	if err := k.Writer.WriteMessages(context.Background(), messages...); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewJSONLogger creates a log.Logger that encodes each log as a line of JSON,
+// like log.NewJSONLogger, but in a pooled buffer. The lines should be written
+// to a KafkaWriter, usually through a logging.AsyncWriter.
+func NewJSONLogger(w io.Writer) log.Logger {
+	return jsonLogger{w: w}
+}
+
+type jsonLogger struct {
+	w io.Writer
+}
+
+// Log encodes the keyvals as a JSON object.
+func (j jsonLogger) Log(keyvals ...interface{}) error {
+	record := make(map[string]interface{}, len(keyvals)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = log.ErrMissingValue
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		record[fmt.Sprint(keyvals[i])] = sinkValue(value)
+	}
+
+	buf := bufpool.Get(512)
+	defer bufpool.Put(buf)
+	if err := json.NewEncoder(buf).Encode(record); err != nil {
+		return err
+	}
+	_, err := j.w.Write(buf.Bytes())
+	return err
+}
+
+// NewFluentLogger creates a log.Logger that encodes logs as Fluentd forward
+// protocol events with the given tag. The events should be written to a
+// FluentWriter, usually through a logging.AsyncWriter.
+func NewFluentLogger(w io.Writer, tag string) log.Logger {
+	return fluentLogger{w: w, tag: tag}
+}
+
+type fluentLogger struct {
+	w   io.Writer
+	tag string
+}
+
+// Log encodes the keyvals as an event in message mode: [tag, time, record].
+func (f fluentLogger) Log(keyvals ...interface{}) error {
+	record := make(map[string]interface{}, len(keyvals)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = log.ErrMissingValue
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		record[fmt.Sprint(keyvals[i])] = sinkValue(value)
+	}
+
+	buf := bufpool.Get(512)
+	defer bufpool.Put(buf)
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(buf)
+	enc.SetSortMapKeys(true)
+	if err := encodeEvent(enc, f.tag, time.Now(), record); err != nil {
+		return err
+	}
+	_, err := f.w.Write(buf.Bytes())
+	return err
+}
+
+func encodeEvent(enc *msgpack.Encoder, tag string, t time.Time, record map[string]interface{}) error {
+	if err := enc.EncodeArrayLen(3); err != nil {
+		return err
+	}
+	if err := enc.EncodeString(tag); err != nil {
+		return err
+	}
+	if err := enc.EncodeInt(t.Unix()); err != nil {
+		return err
+	}
+	return enc.Encode(record)
+}
+
+// sinkValue converts the values that json and msgpack don't encode as expected
+// in logs to strings.
+func sinkValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return string(v)
+	default:
+		return v
+	}
+}
+
+// FluentWriter writes to a Fluentd forward input over TCP. The connection is
+// established lazily, and re-established after a failure.
+type FluentWriter struct {
+	Addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Write writes p to the connection.
+func (f *FluentWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn == nil {
+		conn, err := net.DialTimeout("tcp", f.Addr, 5*time.Second)
+		if err != nil {
+			return 0, fmt.Errorf("failed to connect to fluentd: %w", err)
+		}
+		f.conn = conn
+	}
+	_ = f.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	n, err := f.conn.Write(p)
+	if err != nil {
+		_ = f.conn.Close()
+		f.conn = nil
+	}
+	return n, err
+}
+
+// Close closes the connection.
+func (f *FluentWriter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn == nil {
+		return nil
+	}
+	err := f.conn.Close()
+	f.conn = nil
+	return err
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DoNewsCode/core/codec/dotenv"
+	"github.com/DoNewsCode/core/codec/hcl"
+	"github.com/DoNewsCode/core/codec/json"
+	"github.com/DoNewsCode/core/codec/toml"
+	"github.com/DoNewsCode/core/codec/yaml"
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/config/watcher"
+	"github.com/DoNewsCode/core/di"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// Manifest describes the composition of an app, so that services can be
+// bootstrapped the same way by FromManifest. The providers and modules are
+// referred to by the names they are registered with, see RegisterProviders
+// and RegisterModule.
+//
+//	config:
+//	  - file: config.yaml
+//	    watch: true
+//	  - inline:
+//	      http.addr: ":8080"
+//	providers:
+//	  - otgorm
+//	  - otredis
+//	modules:
+//	  - orders
+type Manifest struct {
+	// Config are the configuration sources. Earlier sources take precedence.
+	Config []ManifestConfig `json:"config" yaml:"config"`
+	// Providers are the names of the registered provider sets.
+	Providers []string `json:"providers" yaml:"providers"`
+	// Modules are the names of the registered module constructors.
+	Modules []string `json:"modules" yaml:"modules"`
+}
+
+// ManifestConfig is a configuration source in the manifest. Either File or
+// Inline is set.
+type ManifestConfig struct {
+	// File is the path of a yaml, json, toml, hcl or .env file, relative to the
+	// manifest.
+	File string `json:"file" yaml:"file"`
+	// Watch reloads the configuration when the file changes. Only one file can
+	// be watched.
+	Watch bool `json:"watch" yaml:"watch"`
+	// Inline is the configuration in the manifest, keyed by dotted paths.
+	Inline map[string]interface{} `json:"inline" yaml:"inline"`
+}
+
+var registry = struct {
+	sync.Mutex
+	providers map[string]di.Deps
+	modules   map[string]interface{}
+}{
+	providers: make(map[string]di.Deps),
+	modules:   make(map[string]interface{}),
+}
+
+// RegisterProviders registers the provider set under the name, so that it can
+// be enabled in the manifest. It panics if the name is registered already.
+//
+//	core.RegisterProviders("otgorm", otgorm.Providers())
+func RegisterProviders(name string, deps di.Deps) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.providers[name]; ok {
+		panic(fmt.Sprintf("providers %s are registered twice", name))
+	}
+	registry.providers[name] = deps
+}
+
+// RegisterModule registers the module constructor under the name, so that it
+// can be enabled in the manifest. The constructor is consumed by
+// C.AddModuleFunc. It panics if the name is registered already.
+//
+//	core.RegisterModule("orders", orders.New)
+func RegisterModule(name string, constructor interface{}) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.modules[name]; ok {
+		panic(fmt.Sprintf("module %s is registered twice", name))
+	}
+	registry.modules[name] = constructor
+}
+
+// FromManifest creates a core.C from the manifest file in yaml or json. The
+// essentials are provided, and the providers and modules in the manifest are
+// added in order. The opts take precedence over the manifest, eg. a config
+// stack in the opts overrides those in the manifest.
+func FromManifest(path string, opts ...CoreOption) (*C, error) {
+	var manifest Manifest
+	if err := readManifestFile(path, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	providers, modules, err := manifest.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	watched := false
+	for i, source := range manifest.Config {
+		switch {
+		case source.File != "" && source.Inline != nil:
+			return nil, fmt.Errorf("config %d of manifest sets both file and inline", i)
+		case source.File != "":
+			filePath := source.File
+			if !filepath.IsAbs(filePath) {
+				filePath = filepath.Join(dir, filePath)
+			}
+			parser, err := parserFor(filePath)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithConfigStack(config.File{Path: filePath}, parser))
+			if source.Watch {
+				if watched {
+					return nil, fmt.Errorf("config %d of manifest: only one file can be watched", i)
+				}
+				watched = true
+				opts = append(opts, WithConfigWatcher(watcher.File{Path: filePath}))
+			}
+		case source.Inline != nil:
+			opts = append(opts, WithConfigStack(confmap.Provider(source.Inline, "."), nil))
+		default:
+			return nil, fmt.Errorf("config %d of manifest sets neither file nor inline", i)
+		}
+	}
+
+	c := New(opts...)
+	c.ProvideEssentials()
+	for _, deps := range providers {
+		c.Provide(deps)
+	}
+	for _, constructor := range modules {
+		c.AddModuleFunc(constructor)
+	}
+	return c, nil
+}
+
+// resolve looks up the registered providers and modules in the manifest.
+func (m Manifest) resolve() ([]di.Deps, []interface{}, error) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	var (
+		providers []di.Deps
+		modules   []interface{}
+	)
+	for _, name := range m.Providers {
+		deps, ok := registry.providers[name]
+		if !ok {
+			var registered []string
+			for n := range registry.providers {
+				registered = append(registered, n)
+			}
+			return nil, nil, fmt.Errorf("unknown providers %s in manifest, registered: %s", name, sorted(registered))
+		}
+		providers = append(providers, deps)
+	}
+	for _, name := range m.Modules {
+		constructor, ok := registry.modules[name]
+		if !ok {
+			var registered []string
+			for n := range registry.modules {
+				registered = append(registered, n)
+			}
+			return nil, nil, fmt.Errorf("unknown module %s in manifest, registered: %s", name, sorted(registered))
+		}
+		modules = append(modules, constructor)
+	}
+	return providers, modules, nil
+}
+
+func sorted(names []string) []string {
+	sort.Strings(names)
+	return names
+}
+
+func readManifestFile(path string, manifest *Manifest) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return json.NewCodec().Unmarshal(data, manifest)
+	}
+	return yaml.Codec{}.Unmarshal(data, manifest)
+}
+
+func parserFor(path string) (ConfParser, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return config.CodecParser{Codec: yaml.Codec{}}, nil
+	case ".json":
+		return config.CodecParser{Codec: json.NewCodec()}, nil
+	case ".toml":
+		return config.CodecParser{Codec: toml.Codec{}}, nil
+	case ".hcl":
+		return config.CodecParser{Codec: hcl.Codec{}}, nil
+	case ".env":
+		return config.CodecParser{Codec: dotenv.Codec{}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported config file %s, expecting yaml, json, toml, hcl or .env", path)
+	}
+}
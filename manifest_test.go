@@ -0,0 +1,62 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type manifestDep struct{ value string }
+
+type manifestModule struct{ dep manifestDep }
+
+func (m manifestModule) ProvideHTTP(router *mux.Router) {}
+
+func TestFromManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	RegisterProviders("manifestDep", di.Deps{func(conf contract.ConfigAccessor) manifestDep {
+		return manifestDep{value: conf.String("dep.value")}
+	}})
+	RegisterModule("manifestModule", func(dep manifestDep) manifestModule {
+		return manifestModule{dep: dep}
+	})
+
+	ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: manifest\ndep:\n  value: file\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(`
+config:
+  - inline:
+      dep.value: inline
+  - file: config.yaml
+providers:
+  - manifestDep
+modules:
+  - manifestModule
+`), 0644)
+
+	c, err := FromManifest(filepath.Join(dir, "manifest.yaml"), WithInline("env", "testing"))
+	assert.NoError(t, err)
+	assert.Equal(t, "manifest", c.AppName.String())
+	assert.Equal(t, "testing", c.Env.String())
+
+	var found bool
+	for _, module := range c.Modules() {
+		if m, ok := module.(manifestModule); ok {
+			found = true
+			assert.Equal(t, "inline", m.dep.value)
+		}
+	}
+	assert.True(t, found)
+
+	ioutil.WriteFile(filepath.Join(dir, "unknown.yaml"), []byte("modules: [unknown]\n"), 0644)
+	_, err = FromManifest(filepath.Join(dir, "unknown.yaml"))
+	assert.Error(t, err)
+}
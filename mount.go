@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/container"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/oklog/run"
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// Mount creates a sub-app named name, which is served together with c. It is
+// meant for modular monoliths: each sub-app has its own modules, dependency
+// graph and event dispatcher, and can later be split into a service of its
+// own with little change.
+//
+// The sub-app reads its configuration under "apps.<name>" of c. Its HTTP
+// routes are mounted under "/<name>", its gRPC services, cron jobs and run
+// groups are added to those of c, and its commands are grouped under the
+// "<name>" command. The logs are tagged with "app".
+//
+// The shared arguments are pointers to the types that the sub-app resolves from
+// c rather than constructing its own, typically infrastructure factories. Share
+// a pointer type *T with (**T)(nil).
+//
+//	orders := c.Mount("orders", (*otgorm.Maker)(nil), (*otredis.Maker)(nil))
+//	orders.AddModuleFunc(NewOrderModule)
+//
+// Note the configuration of the sub-app is a snapshot, and is not reloaded.
+func (c *C) Mount(name string, shared ...interface{}) *C {
+	var conf contract.ConfigAccessor = config.MapAdapter{}
+	if router, ok := c.ConfigAccessor.(contract.ConfigRouter); ok {
+		conf = router.Route("apps." + name)
+	}
+	logger := log.With(c.LevelLogger, "app", name)
+	sub := &C{
+		AppName:        config.AppName(name),
+		Env:            c.Env,
+		BuildInfo:      c.BuildInfo,
+		ConfigAccessor: conf,
+		LevelLogger:    logging.WithLevel(logger),
+		Container:      &container.Container{},
+		Dispatcher:     &events.SyncDispatcher{},
+		di:             di.NewGraph(),
+		logLevels:      c.logLevels,
+	}
+	sub.ProvideEssentials()
+	for _, ptr := range shared {
+		sub.share(c, ptr)
+	}
+	c.AddModule(mountModule{name: name, sub: sub})
+	return sub
+}
+
+// share provides the type pointed by ptr in c, by resolving it from parent.
+func (c *C) share(parent *C, ptr interface{}) {
+	ptrType := reflect.TypeOf(ptr)
+	if ptrType == nil || ptrType.Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("must share types as pointers, eg. (*otredis.Maker)(nil), got %T", ptr))
+	}
+	t := ptrType.Elem()
+
+	fnType := reflect.FuncOf(nil, []reflect.Type{t, _errType}, false /* variadic */)
+	fn := reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		var result reflect.Value
+		capture := reflect.MakeFunc(reflect.FuncOf([]reflect.Type{t}, nil, false), func(args []reflect.Value) []reflect.Value {
+			result = args[0]
+			return nil
+		})
+		if err := parent.di.Invoke(capture.Interface()); err != nil {
+			return []reflect.Value{reflect.Zero(t), reflect.ValueOf(&err).Elem()}
+		}
+		return []reflect.Value{result, reflect.Zero(_errType)}
+	})
+	if err := c.di.Provide(fn.Interface()); err != nil {
+		panic(err)
+	}
+}
+
+// mountModule adds the sub-app to the container of the parent.
+type mountModule struct {
+	name string
+	sub  *C
+}
+
+// ProvideHTTP implements container.HTTPProvider
+func (m mountModule) ProvideHTTP(router *mux.Router) {
+	m.sub.ApplyRouter(router.PathPrefix("/" + m.name).Subrouter())
+}
+
+// ProvideGRPC implements container.GRPCProvider
+func (m mountModule) ProvideGRPC(server *grpc.Server) {
+	m.sub.ApplyGRPCServer(server)
+}
+
+// ProvideCron implements container.CronProvider
+func (m mountModule) ProvideCron(crontab *cron.Cron) {
+	m.sub.ApplyCron(crontab)
+}
+
+// ProvideRunGroup implements container.RunProvider
+func (m mountModule) ProvideRunGroup(group *run.Group) {
+	m.sub.ApplyRunGroup(group)
+}
+
+// ProvideCommand implements container.CommandProvider
+func (m mountModule) ProvideCommand(command *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   m.name,
+		Short: fmt.Sprintf("Commands of the %s app", m.name),
+	}
+	m.sub.ApplyRootCommand(cmd)
+	if cmd.HasSubCommands() {
+		command.AddCommand(cmd)
+	}
+}
+
+// ProvideCloser implements container.CloserProvider
+func (m mountModule) ProvideCloser() {
+	m.sub.Shutdown()
+}
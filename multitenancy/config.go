@@ -0,0 +1,82 @@
+package multitenancy
+
+import (
+	"context"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+// Config returns a contract.ConfigAccessor scoped by the tenant in context.
+// Values under "tenants.<tenant id>" take precedence over the top level ones.
+// For example, with the configuration below, Config(ctx, conf).Int("limit")
+// returns 100 for tenant foo and 10 for everyone else.
+//
+//	limit: 10
+//	tenants:
+//	  foo:
+//	    limit: 100
+//
+// The overlay is evaluated on every call, so it follows config reloads. If the
+// context carries no Tenant, conf is returned as is.
+func Config(ctx context.Context, conf contract.ConfigAccessor) contract.ConfigAccessor {
+	tenant, ok := FromContext(ctx)
+	if !ok {
+		return conf
+	}
+	return overlay{base: conf, prefix: "tenants." + tenant.String()}
+}
+
+type overlay struct {
+	base   contract.ConfigAccessor
+	prefix string
+}
+
+func (o overlay) key(s string) string {
+	if s == "" {
+		return o.prefix
+	}
+	return o.prefix + "." + s
+}
+
+func (o overlay) pick(s string) string {
+	if o.base.Get(o.key(s)) != nil {
+		return o.key(s)
+	}
+	return s
+}
+
+func (o overlay) String(s string) string {
+	return o.base.String(o.pick(s))
+}
+
+func (o overlay) Int(s string) int {
+	return o.base.Int(o.pick(s))
+}
+
+func (o overlay) Strings(s string) []string {
+	return o.base.Strings(o.pick(s))
+}
+
+func (o overlay) Bool(s string) bool {
+	return o.base.Bool(o.pick(s))
+}
+
+func (o overlay) Get(s string) interface{} {
+	return o.base.Get(o.pick(s))
+}
+
+func (o overlay) Float64(s string) float64 {
+	return o.base.Float64(o.pick(s))
+}
+
+// Unmarshal unmarshals the top level values first, then the tenant values on
+// top of them. Fields absent in the tenant tree keep the top level values.
+func (o overlay) Unmarshal(path string, target interface{}) error {
+	if err := o.base.Unmarshal(path, target); err != nil {
+		return err
+	}
+	if o.base.Get(o.key(path)) == nil {
+		return nil
+	}
+	return o.base.Unmarshal(o.key(path), target)
+}
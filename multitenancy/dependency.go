@@ -0,0 +1,43 @@
+package multitenancy
+
+import (
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+)
+
+/*
+Providers returns a set of dependency providers for package multitenancy.
+
+	Depends On:
+		contract.ConfigAccessor
+	Provide:
+		Registry
+*/
+func Providers() di.Deps {
+	return di.Deps{provideRegistry, provideConfig}
+}
+
+func provideRegistry(conf contract.ConfigAccessor) Registry {
+	return ConfigRegistry{Conf: conf}
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "multitenancy",
+			Data: map[string]interface{}{
+				"multitenancy": map[string]interface{}{
+					"tenants": []Tenant{},
+				},
+			},
+			Comment: "The tenants registered in the application",
+		},
+	}}
+}
@@ -0,0 +1,42 @@
+/*
+Package multitenancy coordinates the per-tenant concerns of an application.
+
+A Resolver extracts the tenant ID from an incoming request, for example from a
+header, a subdomain or a JWT claim. A Registry looks up the Tenant by ID, from
+the configuration or from a database table. The HTTP middleware and gRPC
+interceptor put the resolved Tenant into the context under contract.TenantKey,
+where the rest of the system picks it up:
+
+	- logging.WithContext adds the tenant fields to log lines.
+	- DB returns the *gorm.DB connection assigned to the tenant.
+	- Keyer prefixes redis keys (or any other labels) with the tenant ID.
+	- Config overlays the tenant specific configuration under "tenants.<id>".
+
+Integration
+
+package multitenancy exports the configuration in the following format:
+
+	multitenancy:
+	    tenants:
+	        - id: foo
+	          database: default
+	          redis: default
+
+Add the multitenancy dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(multitenancy.Providers())
+
+The Registry provided by default reads tenants from the configuration. To resolve
+tenants from the header "X-Tenant-ID":
+
+	c.Invoke(func(registry multitenancy.Registry) {
+		router.Use(multitenancy.MakeHTTPMiddleware(multitenancy.FromHeader("X-Tenant-ID"), registry))
+	})
+
+Then in the handlers:
+
+	db, err := multitenancy.DB(ctx, maker)
+	client.Get(ctx, multitenancy.Keyer(ctx, keyer).Key(":", "mykey"))
+*/
+package multitenancy
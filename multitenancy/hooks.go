@@ -0,0 +1,54 @@
+package multitenancy
+
+import (
+	"context"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/key"
+	"github.com/DoNewsCode/core/otgorm"
+	"github.com/DoNewsCode/core/otredis"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// DB returns the *gorm.DB assigned to the tenant in context. If the context
+// carries no Tenant, or the tenant has no database assigned, the "default"
+// connection is returned.
+func DB(ctx context.Context, maker otgorm.Maker) (*gorm.DB, error) {
+	name := "default"
+	if tenant, ok := FromContext(ctx); ok {
+		if t, ok := tenant.(Tenant); ok && t.Database != "" {
+			name = t.Database
+		}
+	}
+	db, err := maker.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	return db.WithContext(ctx), nil
+}
+
+// Redis returns the redis.UniversalClient assigned to the tenant in context. If
+// the context carries no Tenant, or the tenant has no redis assigned, the
+// "default" connection is returned.
+func Redis(ctx context.Context, maker otredis.Maker) (redis.UniversalClient, error) {
+	name := "default"
+	if tenant, ok := FromContext(ctx); ok {
+		if t, ok := tenant.(Tenant); ok && t.Redis != "" {
+			name = t.Redis
+		}
+	}
+	return maker.Make(name)
+}
+
+// Keyer scopes the contract.Keyer by the tenant in context, so that the labels
+// "tenant", <tenant id> are appended to the existing ones. It is commonly used
+// to separate redis keys of tenants sharing the same redis. If the context
+// carries no Tenant, the keyer is returned as is.
+func Keyer(ctx context.Context, keyer contract.Keyer) contract.Keyer {
+	tenant, ok := FromContext(ctx)
+	if !ok {
+		return keyer
+	}
+	return key.With(keyer, "tenant", tenant.String())
+}
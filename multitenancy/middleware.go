@@ -0,0 +1,69 @@
+package multitenancy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/pprof"
+
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/DoNewsCode/core/unierr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that resolves the
+// tenant of incoming requests and puts it into the request context. Requests
+// without a valid tenant are rejected. The runtime/pprof label "tenant" is set
+// while serving the request.
+func MakeHTTPMiddleware(resolver Resolver, registry Registry) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ctx, err := resolve(request.Context(), HTTPCarrier{request}, resolver, registry)
+			if err != nil {
+				srvhttp.NewResponseEncoder(writer).EncodeError(err)
+				return
+			}
+			pprof.Do(ctx, labels(ctx), func(ctx context.Context) {
+				handler.ServeHTTP(writer, request.WithContext(ctx))
+			})
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that resolves the
+// tenant of incoming calls from metadata and puts it into the context. The
+// runtime/pprof label "tenant" is set while serving the call.
+func MakeUnaryInterceptor(resolver Resolver, registry Registry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx, err = resolve(ctx, GRPCCarrier(md), resolver, registry)
+		if err != nil {
+			return nil, err
+		}
+		pprof.Do(ctx, labels(ctx), func(ctx context.Context) {
+			resp, err = handler(ctx, req)
+		})
+		return resp, err
+	}
+}
+
+func labels(ctx context.Context) pprof.LabelSet {
+	tenant, _ := FromContext(ctx)
+	return pprof.Labels("tenant", tenant.String())
+}
+
+func resolve(ctx context.Context, carrier Carrier, resolver Resolver, registry Registry) (context.Context, error) {
+	id, err := resolver(carrier)
+	if err != nil {
+		return nil, unierr.InvalidArgumentErr(err)
+	}
+	tenant, err := registry.Find(ctx, id)
+	if errors.Is(err, ErrUnknownTenant) {
+		return nil, unierr.NotFoundErr(err, "tenant %s not found", id)
+	}
+	if err != nil {
+		return nil, unierr.InternalErr(err)
+	}
+	return WithTenant(ctx, tenant), nil
+}
@@ -0,0 +1,114 @@
+package multitenancy
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/key"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestResolvers(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "http://foo.example.com:8080/", nil)
+	request.Header.Set("X-Tenant-ID", "bar")
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"tid":"baz"}`))
+	request.Header.Set("Authorization", "Bearer header."+payload+".signature")
+
+	cases := []struct {
+		name     string
+		resolver Resolver
+		want     string
+		err      error
+	}{
+		{"header", FromHeader("X-Tenant-ID"), "bar", nil},
+		{"missing header", FromHeader("X-Foo"), "", ErrNoTenant},
+		{"subdomain", FromSubdomain("example.com"), "foo", nil},
+		{"wrong domain", FromSubdomain("example.org"), "", ErrNoTenant},
+		{"jwt", FromJWTClaim("Authorization", "tid"), "baz", nil},
+		{"missing claim", FromJWTClaim("Authorization", "sub"), "", ErrNoTenant},
+		{"chain", Chain(FromHeader("X-Foo"), FromSubdomain("example.com")), "foo", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			id, err := c.resolver(HTTPCarrier{request})
+			assert.Equal(t, c.want, id)
+			assert.Equal(t, c.err, err)
+		})
+	}
+
+	id, err := FromHeader("x-tenant-id")(GRPCCarrier(metadata.Pairs("x-tenant-id", "qux")))
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", id)
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	registry := ConfigRegistry{Conf: config.MapAdapter{"multitenancy": map[string]interface{}{
+		"tenants": []map[string]interface{}{{"id": "foo", "database": "foo"}},
+	}}}
+	handler := MakeHTTPMiddleware(FromHeader("X-Tenant-ID"), registry)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		tenant, ok := FromContext(request.Context())
+		assert.True(t, ok)
+		assert.Equal(t, Tenant{ID: "foo", Database: "foo"}, tenant)
+		assert.Equal(t, "app:tenant:foo:bar", Keyer(request.Context(), key.New("app")).Key(":", "bar"))
+	}))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Tenant-ID", "foo")
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Tenant-ID", "bar")
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestKeyer(t *testing.T) {
+	keyer := key.New("app")
+	assert.Equal(t, keyer, Keyer(context.Background(), keyer))
+}
+
+func TestConfig(t *testing.T) {
+	conf, _ := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(`
+limit: 10
+feature:
+  enable: false
+  name: base
+tenants:
+  foo:
+    limit: 100
+    feature:
+      enable: true
+`)), yaml.Parser()))
+
+	assert.Equal(t, conf, Config(context.Background(), conf))
+
+	scoped := Config(WithTenant(context.Background(), Tenant{ID: "foo"}), conf)
+	assert.Equal(t, 100, scoped.Int("limit"))
+	assert.True(t, scoped.Bool("feature.enable"))
+	assert.Equal(t, "base", scoped.String("feature.name"))
+
+	var feature struct {
+		Enable bool   `json:"enable"`
+		Name   string `json:"name"`
+	}
+	assert.NoError(t, scoped.Unmarshal("feature", &feature))
+	assert.True(t, feature.Enable)
+	assert.Equal(t, "base", feature.Name)
+
+	other := Config(WithTenant(context.Background(), Tenant{ID: "bar"}), conf)
+	assert.Equal(t, 10, other.Int("limit"))
+}
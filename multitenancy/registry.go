@@ -0,0 +1,66 @@
+package multitenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DoNewsCode/core/contract"
+	"gorm.io/gorm"
+)
+
+// ErrUnknownTenant is returned by a Registry when the tenant ID is not registered.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Registry looks up tenants by ID.
+type Registry interface {
+	Find(ctx context.Context, id string) (contract.Tenant, error)
+}
+
+// ConfigRegistry is a Registry that reads tenants from the configuration under
+// "multitenancy.tenants". The configuration is read on every lookup, so newly
+// added tenants are picked up when the configuration reloads.
+type ConfigRegistry struct {
+	Conf contract.ConfigAccessor
+}
+
+// Find implements Registry.
+func (c ConfigRegistry) Find(ctx context.Context, id string) (contract.Tenant, error) {
+	var tenants []Tenant
+	if err := c.Conf.Unmarshal("multitenancy.tenants", &tenants); err != nil {
+		return nil, fmt.Errorf("multitenancy configuration error: %w", err)
+	}
+	for _, tenant := range tenants {
+		if tenant.ID == id {
+			return tenant, nil
+		}
+	}
+	return nil, ErrUnknownTenant
+}
+
+// GormRegistry is a Registry that reads tenants from a database table. The
+// table schema is derived from Tenant.
+type GormRegistry struct {
+	DB *gorm.DB
+	// Table is the name of the tenant table. If empty, "tenants" is used.
+	Table string
+}
+
+// Find implements Registry.
+func (g GormRegistry) Find(ctx context.Context, id string) (contract.Tenant, error) {
+	var (
+		tenant Tenant
+		table  = g.Table
+	)
+	if table == "" {
+		table = "tenants"
+	}
+	err := g.DB.WithContext(ctx).Table(table).Where("id = ?", id).First(&tenant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownTenant
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tenant %s: %w", id, err)
+	}
+	return tenant, nil
+}
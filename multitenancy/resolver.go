@@ -0,0 +1,135 @@
+package multitenancy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// ErrNoTenant is returned by a Resolver when the request carries no tenant.
+var ErrNoTenant = errors.New("no tenant in request")
+
+// Carrier abstracts the transport from which the tenant is resolved.
+type Carrier interface {
+	// Get returns the value of the given header or metadata key.
+	Get(key string) string
+	// Host returns the requested host, without port.
+	Host() string
+}
+
+// Resolver extracts the tenant ID from the Carrier.
+type Resolver func(carrier Carrier) (string, error)
+
+// FromHeader resolves the tenant ID from the given header (or gRPC metadata key).
+func FromHeader(name string) Resolver {
+	return func(carrier Carrier) (string, error) {
+		if id := carrier.Get(name); id != "" {
+			return id, nil
+		}
+		return "", ErrNoTenant
+	}
+}
+
+// FromSubdomain resolves the tenant ID from the subdomain of the given base
+// domain. For example, with base domain "example.com", the tenant of host
+// "foo.example.com" is "foo".
+func FromSubdomain(baseDomain string) Resolver {
+	suffix := "." + strings.TrimPrefix(baseDomain, ".")
+	return func(carrier Carrier) (string, error) {
+		host := carrier.Host()
+		if !strings.HasSuffix(host, suffix) {
+			return "", ErrNoTenant
+		}
+		id := strings.TrimSuffix(host, suffix)
+		if id == "" || strings.Contains(id, ".") {
+			return "", ErrNoTenant
+		}
+		return id, nil
+	}
+}
+
+// FromJWTClaim resolves the tenant ID from a claim of the bearer token in the
+// given header, usually "Authorization".
+//
+// Note the token signature is NOT verified here. The token must be
+// authenticated before reaching the business logic.
+func FromJWTClaim(header, claim string) Resolver {
+	return func(carrier Carrier) (string, error) {
+		token := strings.TrimSpace(strings.TrimPrefix(carrier.Get(header), "Bearer "))
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			return "", ErrNoTenant
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return "", fmt.Errorf("malformed jwt payload: %w", err)
+		}
+		var claims map[string]interface{}
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			return "", fmt.Errorf("malformed jwt claims: %w", err)
+		}
+		if id, ok := claims[claim].(string); ok && id != "" {
+			return id, nil
+		}
+		return "", ErrNoTenant
+	}
+}
+
+// Chain tries the resolvers in order and returns the first tenant ID found.
+func Chain(resolvers ...Resolver) Resolver {
+	return func(carrier Carrier) (string, error) {
+		for _, resolver := range resolvers {
+			id, err := resolver(carrier)
+			if errors.Is(err, ErrNoTenant) {
+				continue
+			}
+			return id, err
+		}
+		return "", ErrNoTenant
+	}
+}
+
+// HTTPCarrier adapts *http.Request to Carrier.
+type HTTPCarrier struct {
+	*http.Request
+}
+
+// Get returns the value of the given header.
+func (h HTTPCarrier) Get(key string) string {
+	return h.Request.Header.Get(key)
+}
+
+// Host returns the requested host, without port.
+func (h HTTPCarrier) Host() string {
+	return stripPort(h.Request.Host)
+}
+
+// GRPCCarrier adapts incoming gRPC metadata to Carrier.
+type GRPCCarrier metadata.MD
+
+// Get returns the first value of the given metadata key.
+func (g GRPCCarrier) Get(key string) string {
+	values := metadata.MD(g).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Host returns the :authority pseudo header, without port.
+func (g GRPCCarrier) Host() string {
+	return stripPort(g.Get(":authority"))
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,40 @@
+package multitenancy
+
+import (
+	"context"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+var _ contract.Tenant = (*Tenant)(nil)
+
+// Tenant is the contract.Tenant implementation resolved by package multitenancy.
+type Tenant struct {
+	// ID uniquely identifies the tenant.
+	ID string `json:"id" yaml:"id" gorm:"primaryKey"`
+	// Database is the otgorm connection name assigned to the tenant. If empty, "default" is used.
+	Database string `json:"database" yaml:"database"`
+	// Redis is the otredis connection name assigned to the tenant. If empty, "default" is used.
+	Redis string `json:"redis" yaml:"redis"`
+}
+
+// KV contains key values about this tenant. They are added to log lines.
+func (t Tenant) KV() map[string]interface{} {
+	return map[string]interface{}{"tenant": t.ID}
+}
+
+// String returns the tenant ID.
+func (t Tenant) String() string {
+	return t.ID
+}
+
+// WithTenant returns a copy of the context carrying the tenant.
+func WithTenant(ctx context.Context, tenant contract.Tenant) context.Context {
+	return context.WithValue(ctx, contract.TenantKey, tenant)
+}
+
+// FromContext returns the tenant carried by the context, if any.
+func FromContext(ctx context.Context) (contract.Tenant, bool) {
+	tenant, ok := ctx.Value(contract.TenantKey).(contract.Tenant)
+	return tenant, ok
+}
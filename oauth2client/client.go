@@ -0,0 +1,70 @@
+package oauth2client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"google.golang.org/grpc/credentials"
+)
+
+func authorization(token Token) string {
+	tokenType := token.TokenType
+	if strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + token.AccessToken
+}
+
+// Doer is a contract.HttpDoer that authorizes the requests with the tokens
+// from the TokenSource. It can be passed to clihttp.WithDoer.
+type Doer struct {
+	Source     *TokenSource
+	Underlying contract.HttpDoer
+}
+
+// NewDoer creates a new *Doer. If underlying is nil, http.DefaultClient is
+// used.
+func NewDoer(source *TokenSource, underlying contract.HttpDoer) *Doer {
+	if underlying == nil {
+		underlying = http.DefaultClient
+	}
+	return &Doer{Source: source, Underlying: underlying}
+}
+
+// Do sets the Authorization header and sends the request.
+func (d *Doer) Do(req *http.Request) (*http.Response, error) {
+	token, err := d.Source.Token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize request: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", authorization(token))
+	return d.Underlying.Do(req)
+}
+
+// PerRPCCredentials returns credentials.PerRPCCredentials backed by the
+// TokenSource. Use it with grpc.WithPerRPCCredentials. If requireTLS is true,
+// the token is only sent over secure connections.
+func PerRPCCredentials(source *TokenSource, requireTLS bool) credentials.PerRPCCredentials {
+	return perRPCCredentials{source: source, requireTLS: requireTLS}
+}
+
+type perRPCCredentials struct {
+	source     *TokenSource
+	requireTLS bool
+}
+
+func (p perRPCCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := p.source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": authorization(token)}, nil
+}
+
+func (p perRPCCredentials) RequireTransportSecurity() bool {
+	return p.requireTLS
+}
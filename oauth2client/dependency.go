@@ -0,0 +1,103 @@
+package oauth2client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+)
+
+/*
+Providers returns a set of dependency providers for package oauth2client.
+
+	Depends On:
+		contract.ConfigAccessor
+		contract.HttpDoer `optional:"true"`
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		Factory
+		Maker
+*/
+func Providers() di.Deps {
+	return di.Deps{provideFactory, provideConfig}
+}
+
+// Maker is an interface for *Factory. Used as a type hint for injection.
+type Maker interface {
+	Make(name string) (*TokenSource, error)
+}
+
+// Factory creates the TokenSource by name. The token sources are cached, so
+// that the tokens are shared.
+type Factory struct {
+	*di.Factory
+}
+
+// Make returns the TokenSource under the given name.
+func (f Factory) Make(name string) (*TokenSource, error) {
+	source, err := f.Factory.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	return source.(*TokenSource), nil
+}
+
+type factoryIn struct {
+	di.In
+
+	Conf       contract.ConfigAccessor
+	Client     contract.HttpDoer   `optional:"true"`
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+type factoryOut struct {
+	di.Out
+
+	Factory Factory
+	Maker   Maker
+}
+
+func provideFactory(in factoryIn) factoryOut {
+	factory := di.NewFactory(func(name string) (di.Pair, error) {
+		var conf Config
+		if err := in.Conf.Unmarshal(fmt.Sprintf("oauth2client.%s", name), &conf); err != nil {
+			return di.Pair{}, fmt.Errorf("oauth2client configuration %s not valid: %w", name, err)
+		}
+		if conf.TokenURL == "" {
+			return di.Pair{}, fmt.Errorf("oauth2client configuration %s not found", name)
+		}
+		return di.Pair{Conn: NewTokenSource(conf, in.Client)}, nil
+	}, di.WithKind("oauth2client"))
+	f := Factory{factory}
+	f.SubscribeReloadEventFrom(in.Dispatcher)
+	return factoryOut{Factory: f, Maker: f}
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "oauth2client",
+			Data: map[string]interface{}{
+				"oauth2client": map[string]Config{
+					"default": {
+						TokenURL:      "",
+						ClientID:      "",
+						ClientSecret:  "",
+						Scopes:        []string{},
+						Audience:      "",
+						RefreshBefore: config.Duration{Duration: time.Minute},
+					},
+				},
+			},
+			Comment: "The OAuth2 clients for outbound calls, keyed by name",
+		},
+	}}
+}
@@ -0,0 +1,41 @@
+/*
+Package oauth2client manages OAuth2 tokens for outbound service to service
+calls.
+
+A TokenSource fetches tokens with the client credentials flow, or the refresh
+token flow if a refresh token is configured. Tokens are cached until they
+expire, and proactively refreshed in background shortly before expiry. Each
+named configuration, usually one per audience, gets its own TokenSource.
+
+The tokens are attached to outbound calls declaratively: Doer wraps a
+contract.HttpDoer, such as the clihttp.Client, and PerRPCCredentials plugs into
+gRPC clients.
+
+Integration
+
+package oauth2client exports the configuration in the following format:
+
+	oauth2client:
+	  default:
+	    tokenURL: https://auth.example.com/oauth/token
+	    clientID: my-service
+	    clientSecret: secret
+	    scopes: []
+	    audience: https://api.example.com
+	    refreshToken: ""
+	    refreshBefore: 1m
+
+Add the oauth2client dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(oauth2client.Providers())
+
+Then build the clients:
+
+	c.Invoke(func(maker oauth2client.Maker) {
+		source, _ := maker.Make("default")
+		client := clihttp.NewClient(tracer, clihttp.WithDoer(oauth2client.NewDoer(source, nil)))
+		conn, _ := grpc.Dial(target, grpc.WithPerRPCCredentials(oauth2client.PerRPCCredentials(source, true)))
+	})
+*/
+package oauth2client
@@ -0,0 +1,82 @@
+package oauth2client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenSource(t *testing.T) {
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		id, secret, _ := request.BasicAuth()
+		assert.Equal(t, "foo", id)
+		assert.Equal(t, "bar", secret)
+		assert.NoError(t, request.ParseForm())
+		assert.Equal(t, "client_credentials", request.PostForm.Get("grant_type"))
+		assert.Equal(t, "api", request.PostForm.Get("audience"))
+		n := atomic.AddInt32(&issued, 1)
+		fmt.Fprintf(writer, `{"access_token":"token%d","token_type":"bearer","expires_in":120}`, n)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	source := NewTokenSource(Config{TokenURL: server.URL, ClientID: "foo", ClientSecret: "bar", Audience: "api"}, nil)
+	source.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	token, err := source.Token(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+
+	// cached
+	token, _ = source.Token(ctx)
+	assert.Equal(t, "token1", token.AccessToken)
+
+	// proactively refreshed: the old token is returned meanwhile.
+	now = now.Add(90 * time.Second)
+	token, _ = source.Token(ctx)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.Eventually(t, func() bool {
+		token, _ := source.Token(ctx)
+		return token.AccessToken == "token2"
+	}, time.Second, 10*time.Millisecond)
+
+	// expired
+	now = now.Add(time.Hour)
+	token, _ = source.Token(ctx)
+	assert.Equal(t, "token3", token.AccessToken)
+}
+
+func TestDoer(t *testing.T) {
+	auth := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.NoError(t, request.ParseForm())
+		assert.Equal(t, "refresh_token", request.PostForm.Get("grant_type"))
+		fmt.Fprintf(writer, `{"access_token":"%s","refresh_token":"next"}`, request.PostForm.Get("refresh_token"))
+	}))
+	defer auth.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.Header.Get("Authorization")))
+	}))
+	defer api.Close()
+
+	source := NewTokenSource(Config{TokenURL: auth.URL, RefreshToken: "first"}, nil)
+	req, _ := http.NewRequest(http.MethodGet, api.URL, nil)
+	resp, err := NewDoer(source, nil).Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var buf [64]byte
+	n, _ := resp.Body.Read(buf[:])
+	assert.Equal(t, "Bearer first", string(buf[:n]))
+	assert.Empty(t, req.Header.Get("Authorization"))
+
+	md, err := PerRPCCredentials(source, false).GetRequestMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer first", md["authorization"])
+}
@@ -0,0 +1,188 @@
+package oauth2client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+)
+
+// Config is the configuration of a TokenSource.
+type Config struct {
+	// TokenURL is the token endpoint of the authorization server.
+	TokenURL     string   `json:"tokenURL" yaml:"tokenURL"`
+	ClientID     string   `json:"clientID" yaml:"clientID"`
+	ClientSecret string   `json:"clientSecret" yaml:"clientSecret"`
+	Scopes       []string `json:"scopes" yaml:"scopes"`
+	// Audience is the intended audience of the token, sent as the "audience"
+	// parameter. Leave empty if the server doesn't support it.
+	Audience string `json:"audience" yaml:"audience"`
+	// RefreshToken switches to the refresh token flow. The rotated refresh
+	// tokens returned by the server are used for subsequent refreshes.
+	RefreshToken string `json:"refreshToken" yaml:"refreshToken"`
+	// RefreshBefore is how long before expiry the token is proactively
+	// refreshed. Defaults to 1m.
+	RefreshBefore config.Duration `json:"refreshBefore" yaml:"refreshBefore"`
+}
+
+// Token is an OAuth2 access token.
+type Token struct {
+	AccessToken string
+	TokenType   string
+	Expiry      time.Time
+}
+
+// Valid reports whether the token is set and not expired.
+func (t Token) Valid(now time.Time) bool {
+	return t.AccessToken != "" && (t.Expiry.IsZero() || now.Before(t.Expiry))
+}
+
+// TokenSource fetches tokens with the client credentials or the refresh token
+// flow, and caches them until they expire. When a cached token is about to
+// expire, it is still returned while a new one is fetched in background, so
+// that callers rarely wait for the authorization server. TokenSource is safe
+// for concurrent use.
+type TokenSource struct {
+	config Config
+	client contract.HttpDoer
+	now    func() time.Time
+
+	mu           sync.Mutex
+	token        Token
+	refreshToken string
+	refreshing   chan struct{}
+	err          error
+}
+
+// NewTokenSource creates a new *TokenSource. If client is nil,
+// http.DefaultClient is used.
+func NewTokenSource(conf Config, client contract.HttpDoer) *TokenSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if conf.RefreshBefore.Duration <= 0 {
+		conf.RefreshBefore = config.Duration{Duration: time.Minute}
+	}
+	return &TokenSource{config: conf, client: client, now: time.Now, refreshToken: conf.RefreshToken}
+}
+
+// Token returns a valid token, fetching a new one if necessary.
+func (s *TokenSource) Token(ctx context.Context) (Token, error) {
+	s.mu.Lock()
+	now := s.now()
+	token := s.token
+	if token.Valid(now) {
+		if !token.Expiry.IsZero() && token.Expiry.Sub(now) < s.config.RefreshBefore.Duration && s.refreshing == nil {
+			s.startRefresh()
+		}
+		s.mu.Unlock()
+		return token, nil
+	}
+	if s.refreshing == nil {
+		s.startRefresh()
+	}
+	done := s.refreshing
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return Token{}, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return Token{}, s.err
+	}
+	return s.token, nil
+}
+
+// startRefresh fetches a token in background. It must be called with s.mu
+// held.
+func (s *TokenSource) startRefresh() {
+	done := make(chan struct{})
+	s.refreshing = done
+	refreshToken := s.refreshToken
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		token, newRefreshToken, err := s.fetch(ctx, refreshToken)
+
+		s.mu.Lock()
+		s.err = err
+		if err == nil {
+			s.token = token
+			if newRefreshToken != "" {
+				s.refreshToken = newRefreshToken
+			}
+		}
+		s.refreshing = nil
+		s.mu.Unlock()
+		close(done)
+	}()
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+func (s *TokenSource) fetch(ctx context.Context, refreshToken string) (Token, string, error) {
+	form := url.Values{}
+	if refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.Audience != "" {
+		form.Set("audience", s.config.Audience)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	now := s.now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Token{}, "", fmt.Errorf("failed to fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, "", fmt.Errorf("failed to fetch token: %w", err)
+	}
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return Token{}, "", fmt.Errorf("failed to decode token response (%d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		return Token{}, "", fmt.Errorf("failed to fetch token (%d): %s %s", resp.StatusCode, tr.Error, tr.Description)
+	}
+	token := Token{AccessToken: tr.AccessToken, TokenType: tr.TokenType}
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return token, tr.RefreshToken, nil
+}
@@ -16,5 +16,24 @@ Add the observabilities to core:
 	c.provide(observability.Providers())
 
 See example for usage.
+
+Pushing Metrics
+
+Batch jobs and cron jobs may exit before being scraped. The metrics can be
+pushed to a Prometheus Pushgateway or a remote-write endpoint instead:
+
+	metrics:
+	  push:
+	    enable: true
+	    mode: pushgateway # or remoteWrite
+	    addr: http://127.0.0.1:9091
+	    job: "" # defaults to the app name
+	    interval: 0s # zero pushes only on shutdown
+
+The pusher is created lazily, so it must be invoked once. Metrics are pushed
+one last time when the core shuts down:
+
+	c.Invoke(func(pusher *observability.MetricsPusher) {})
+	defer c.Shutdown()
 */
 package observability
@@ -3,10 +3,15 @@ package observability
 import (
 	"sync"
 
+	"github.com/DoNewsCode/core/certs"
+	"github.com/DoNewsCode/core/enrich"
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/graceful"
 	"github.com/DoNewsCode/core/otkafka"
 
 	"github.com/DoNewsCode/core/otgorm"
 	"github.com/DoNewsCode/core/otredis"
+	"github.com/DoNewsCode/core/srvgrpc"
 	"github.com/go-kit/kit/metrics"
 	"github.com/go-kit/kit/metrics/prometheus"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
@@ -32,6 +37,97 @@ func ProvideHistogramMetrics() metrics.Histogram {
 	return &his
 }
 
+var grpcRequestMetrics struct {
+	once sync.Once
+	*srvgrpc.RequestMetrics
+}
+
+// ProvideGRPCRequestMetrics returns a *srvgrpc.RequestMetrics that measures
+// incoming gRPC requests. The metric names mirror http_request_duration_seconds,
+// so that HTTP and gRPC traffic can share the same dashboards.
+func ProvideGRPCRequestMetrics() *srvgrpc.RequestMetrics {
+	grpcRequestMetrics.once.Do(func() {
+		labels := []string{"service", "method"}
+		grpcRequestMetrics.RequestMetrics = &srvgrpc.RequestMetrics{
+			Total: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Name: "grpc_request_total",
+				Help: "Total number of requests served.",
+			}, labels),
+			Errors: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Name: "grpc_request_error_total",
+				Help: "Total number of requests failed, by status code.",
+			}, append(labels, "code")),
+			Duration: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+				Name: "grpc_request_duration_seconds",
+				Help: "Total time spent serving requests.",
+			}, labels),
+			InFlight: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+				Name: "grpc_request_in_flight",
+				Help: "Number of requests being served.",
+			}, labels),
+		}
+	})
+	return grpcRequestMetrics.RequestMetrics
+}
+
+var grpcStreamMetrics struct {
+	once sync.Once
+	*srvgrpc.StreamMetrics
+}
+
+// ProvideGRPCStreamMetrics returns a *srvgrpc.StreamMetrics that measures the
+// gRPC streams. It is meant to be consumed by
+// srvgrpc.MakeStreamActivityInterceptor.
+func ProvideGRPCStreamMetrics() *srvgrpc.StreamMetrics {
+	grpcStreamMetrics.once.Do(func() {
+		labels := []string{"service", "method"}
+		grpcStreamMetrics.StreamMetrics = &srvgrpc.StreamMetrics{
+			Active: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+				Name: "grpc_stream_active",
+				Help: "Number of open streams.",
+			}, labels),
+			Sent: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Name: "grpc_stream_sent_total",
+				Help: "Total number of stream messages sent.",
+			}, labels),
+			Received: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Name: "grpc_stream_received_total",
+				Help: "Total number of stream messages received.",
+			}, labels),
+		}
+	})
+	return grpcStreamMetrics.StreamMetrics
+}
+
+var eventMetrics struct {
+	once sync.Once
+	*events.Metrics
+}
+
+// ProvideEventMetrics returns a *events.Metrics that measures the dispatched
+// events. It is meant to be consumed by events.Instrument. As the event
+// dispatcher is created before the dependency graph, it can be called directly.
+func ProvideEventMetrics() *events.Metrics {
+	eventMetrics.once.Do(func() {
+		labels := []string{"topic"}
+		eventMetrics.Metrics = &events.Metrics{
+			Dispatches: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Name: "event_dispatch_total",
+				Help: "Total number of dispatched events.",
+			}, labels),
+			Errors: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Name: "event_dispatch_error_total",
+				Help: "Total number of dispatches failed by listeners.",
+			}, labels),
+			Duration: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+				Name: "event_dispatch_duration_seconds",
+				Help: "Total time spent dispatching events.",
+			}, labels),
+		}
+	})
+	return eventMetrics.Metrics
+}
+
 // ProvideGORMMetrics returns a *otgorm.Gauges that measures the connection info in databases.
 // It is meant to be consumed by the otgorm.Providers.
 func ProvideGORMMetrics() *otgorm.Gauges {
@@ -82,6 +178,52 @@ func ProvideRedisMetrics() *otredis.Gauges {
 	}
 }
 
+// ProvideCertsMetrics returns a *certs.Metrics that measures the certificate
+// rotation. It is meant to be consumed by the certs.Providers.
+func ProvideCertsMetrics() *certs.Metrics {
+	return &certs.Metrics{
+		Expiry: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Name: "certificate_expiry_timestamp_seconds",
+			Help: "expiry of the certificate in use, in unix seconds",
+		}, nil),
+		Rotations: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Name: "certificate_rotations_total",
+			Help: "number of certificate rotations",
+		}, nil),
+		Failures: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Name: "certificate_refresh_failures_total",
+			Help: "number of failed certificate refreshes",
+		}, nil),
+	}
+}
+
+// ProvideEnrichMetrics returns a *enrich.Metrics that counts the enriched
+// requests. It is meant to be consumed by the enrich.Providers.
+func ProvideEnrichMetrics() *enrich.Metrics {
+	return &enrich.Metrics{
+		Requests: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Name: "enriched_requests_total",
+			Help: "number of requests by country and device",
+		}, []string{"country", "device"}),
+	}
+}
+
+// ProvideGracefulMetrics returns a *graceful.Metrics that counts the drained
+// and killed connections on shutdown. It is meant to be consumed by the
+// graceful.Providers.
+func ProvideGracefulMetrics() *graceful.Metrics {
+	return &graceful.Metrics{
+		Drained: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Name: "shutdown_drained_connections_total",
+			Help: "number of connections that finished within the shutdown grace window",
+		}, []string{"transport"}),
+		Killed: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Name: "shutdown_killed_connections_total",
+			Help: "number of connections forcibly closed after the shutdown grace window",
+		}, []string{"transport"}),
+	}
+}
+
 // ProvideKafkaReaderMetrics returns a *otkafka.ReaderStats that measures the reader info in kafka.
 // It is meant to be consumed by the otkafka.Providers.
 func ProvideKafkaReaderMetrics() *otkafka.ReaderStats {
@@ -14,19 +14,34 @@ Providers returns a set of providers available in package observability
 		contract.ConfigAccessor
 		contract.AppName
 		contract.Env
+		contract.BuildInfo
 	Provides:
 		opentracing.Tracer
 		metrics.Histogram
+		*srvgrpc.RequestMetrics
+		*srvgrpc.StreamMetrics
+		*events.Metrics
+		*certs.Metrics
+		*enrich.Metrics
+		*graceful.Metrics
+		*MetricsPusher
 */
 func Providers() di.Deps {
 	return di.Deps{
 		ProvideJaegerLogAdapter,
 		ProvideOpentracing,
 		ProvideHistogramMetrics,
+		ProvideGRPCRequestMetrics,
+		ProvideGRPCStreamMetrics,
+		ProvideEventMetrics,
 		ProvideGORMMetrics,
 		ProvideRedisMetrics,
 		ProvideKafkaReaderMetrics,
 		ProvideKafkaWriterMetrics,
+		ProvideCertsMetrics,
+		ProvideEnrichMetrics,
+		ProvideGracefulMetrics,
+		ProvideMetricsPusher,
 		provideConfig,
 	}
 }
@@ -40,6 +55,13 @@ jaeger:
     log:
       enable: false
     addr:
+metrics:
+  push:
+    enable: false
+    mode: pushgateway
+    addr: http://127.0.0.1:9091
+    job: ""
+    interval: 0s
 `
 
 type configOut struct {
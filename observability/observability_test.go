@@ -1,21 +1,30 @@
 package observability
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/DoNewsCode/core"
 	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/otgorm"
 	"github.com/DoNewsCode/core/otkafka"
 	"github.com/DoNewsCode/core/otredis"
 	"github.com/go-kit/kit/log"
 	"github.com/go-redis/redis/v8"
+	"github.com/golang/snappy"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/opentracing/opentracing-go"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
 	"gorm.io/gorm"
 )
 
@@ -24,6 +33,7 @@ func TestProvideOpentracing(t *testing.T) {
 	Out, cleanup, err := ProvideOpentracing(
 		config.AppName("foo"),
 		config.EnvTesting,
+		contract.BuildInfo{Version: "v1.0.0"},
 		ProvideJaegerLogAdapter(log.NewNopLogger()),
 		conf,
 	)
@@ -32,6 +42,17 @@ func TestProvideOpentracing(t *testing.T) {
 	cleanup()
 }
 
+func TestTraceID(t *testing.T) {
+	assert.Empty(t, TraceID(context.Background()))
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	assert.Equal(t, span.Context().(jaeger.SpanContext).TraceID().String(), TraceID(ctx))
+}
+
 func TestProvideHistogramMetrics(t *testing.T) {
 	Out := ProvideHistogramMetrics()
 	assert.NotNil(t, Out)
@@ -186,3 +207,52 @@ func Test_provideConfig(t *testing.T) {
 	Conf := provideConfig()
 	assert.NotEmpty(t, Conf.Config)
 }
+
+func TestProvideGRPCRequestMetrics(t *testing.T) {
+	Out := ProvideGRPCRequestMetrics()
+	assert.NotNil(t, Out)
+	assert.Equal(t, Out, ProvideGRPCRequestMetrics())
+}
+
+func TestProvideGRPCStreamMetrics(t *testing.T) {
+	Out := ProvideGRPCStreamMetrics()
+	assert.NotNil(t, Out)
+	assert.Equal(t, Out, ProvideGRPCStreamMetrics())
+}
+
+func TestMetricsPusher(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+	counter := stdprometheus.NewCounter(stdprometheus.CounterOpts{Name: "foo_total", Help: "foo"})
+	registry.MustRegister(counter)
+	counter.Inc()
+
+	var (
+		path string
+		body []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		path = request.URL.Path
+		body, _ = ioutil.ReadAll(request.Body)
+		if request.Header.Get("Content-Encoding") == "snappy" {
+			body, _ = snappy.Decode(nil, body)
+		}
+	}))
+	defer server.Close()
+
+	pusher := NewMetricsPusher(PushOption{Enable: true, Mode: PushModePushgateway, Addr: server.URL, Job: "foo"}, registry, log.NewNopLogger())
+	pusher.Run()
+	assert.NoError(t, pusher.Close())
+	assert.Equal(t, "/metrics/job/foo", path)
+
+	pusher = NewMetricsPusher(PushOption{Enable: true, Mode: PushModeRemoteWrite, Addr: server.URL + "/api/v1/write", Job: "foo"}, registry, log.NewNopLogger())
+	assert.NoError(t, pusher.Push(context.Background()))
+	assert.Equal(t, "/api/v1/write", path)
+	assert.Contains(t, string(body), "foo_total")
+	assert.Contains(t, string(body), "__name__")
+}
+
+func TestProvideEventMetrics(t *testing.T) {
+	Out := ProvideEventMetrics()
+	assert.NotNil(t, Out)
+	assert.Equal(t, Out, ProvideEventMetrics())
+}
@@ -0,0 +1,267 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/snappy"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// PushModePushgateway pushes metrics to a Prometheus Pushgateway.
+	PushModePushgateway = "pushgateway"
+	// PushModeRemoteWrite pushes metrics to a Prometheus remote-write endpoint.
+	PushModeRemoteWrite = "remoteWrite"
+)
+
+// PushOption is the configuration of MetricsPusher.
+type PushOption struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Mode is either "pushgateway" or "remoteWrite".
+	Mode string `json:"mode" yaml:"mode"`
+	// Addr is the address of the Pushgateway, eg. "http://127.0.0.1:9091", or
+	// the URL of the remote-write endpoint, eg. "http://127.0.0.1:9090/api/v1/write".
+	Addr string `json:"addr" yaml:"addr"`
+	// Job is the job label of the pushed metrics. Defaults to the app name.
+	Job string `json:"job" yaml:"job"`
+	// Interval is the period between two pushes. If zero, metrics are only
+	// pushed when the MetricsPusher is closed.
+	Interval config.Duration `json:"interval" yaml:"interval"`
+}
+
+// MetricsPusher pushes the gathered metrics to a Pushgateway or a remote-write
+// endpoint. It is meant for batch jobs and cron jobs that may exit before being
+// scraped.
+type MetricsPusher struct {
+	option   PushOption
+	gatherer stdprometheus.Gatherer
+	doer     contract.HttpDoer
+	logger   log.Logger
+
+	once   sync.Once
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewMetricsPusher creates a new *MetricsPusher.
+func NewMetricsPusher(option PushOption, gatherer stdprometheus.Gatherer, logger log.Logger) *MetricsPusher {
+	return &MetricsPusher{
+		option:   option,
+		gatherer: gatherer,
+		doer:     http.DefaultClient,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+}
+
+// Run pushes metrics periodically until Close is called. If the pusher is
+// disabled or the interval is zero, Run returns immediately.
+func (m *MetricsPusher) Run() {
+	if !m.option.Enable || m.option.Interval.Duration <= 0 {
+		close(m.done)
+		return
+	}
+	var ctx context.Context
+	ctx, m.cancel = context.WithCancel(context.Background())
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.option.Interval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.Push(ctx); err != nil {
+					level.Warn(m.logger).Log("msg", "failed to push metrics", "err", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the periodical push, and pushes the metrics one last time if the
+// pusher is enabled.
+func (m *MetricsPusher) Close() error {
+	var err error
+	m.once.Do(func() {
+		if !m.option.Enable {
+			return
+		}
+		if m.cancel != nil {
+			m.cancel()
+			<-m.done
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err = m.Push(ctx)
+	})
+	return err
+}
+
+// Push gathers and pushes the metrics once.
+func (m *MetricsPusher) Push(ctx context.Context) error {
+	switch m.option.Mode {
+	case PushModePushgateway, "":
+		return push.New(m.option.Addr, m.option.Job).Gatherer(m.gatherer).Client(contextDoer{ctx, m.doer}).Push()
+	case PushModeRemoteWrite:
+		return m.remoteWrite(ctx)
+	default:
+		return fmt.Errorf("unknown metrics push mode %q", m.option.Mode)
+	}
+}
+
+func (m *MetricsPusher) remoteWrite(ctx context.Context) error {
+	families, err := m.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	body := snappy.Encode(nil, encodeWriteRequest(families, m.option.Job, time.Now()))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, m.option.Addr, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Encoding", "snappy")
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	request.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := m.doer.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write returned %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+// encodeWriteRequest encodes the metric families into a prometheus.WriteRequest
+// in protobuf wire format. Histograms and summaries are flattened into series
+// the same way the Prometheus server does when scraping.
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(families []*dto.MetricFamily, job string, now time.Time) []byte {
+	var buf []byte
+	ts := now.UnixNano() / int64(time.Millisecond)
+	add := func(name string, labels []*dto.LabelPair, value float64, extra ...string) {
+		pairs := map[string]string{"__name__": name, "job": job}
+		for _, label := range labels {
+			pairs[label.GetName()] = label.GetValue()
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			pairs[extra[i]] = extra[i+1]
+		}
+		names := make([]string, 0, len(pairs))
+		for name := range pairs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var series []byte
+		for _, name := range names {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, pairs[name])
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, label)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(ts))
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, sample)
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, series)
+	}
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			labels := metric.GetLabel()
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, labels, metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, labels, metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, labels, metric.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, q := range summary.GetQuantile() {
+					add(name, labels, q.GetValue(), "quantile", formatFloat(q.GetQuantile()))
+				}
+				add(name+"_sum", labels, summary.GetSampleSum())
+				add(name+"_count", labels, float64(summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				for _, b := range histogram.GetBucket() {
+					add(name+"_bucket", labels, float64(b.GetCumulativeCount()), "le", formatFloat(b.GetUpperBound()))
+				}
+				add(name+"_bucket", labels, float64(histogram.GetSampleCount()), "le", "+Inf")
+				add(name+"_sum", labels, histogram.GetSampleSum())
+				add(name+"_count", labels, float64(histogram.GetSampleCount()))
+			}
+		}
+	}
+	return buf
+}
+
+// contextDoer binds the context to requests made by push.Pusher, which doesn't
+// accept a context by itself.
+type contextDoer struct {
+	ctx  context.Context
+	doer contract.HttpDoer
+}
+
+func (c contextDoer) Do(request *http.Request) (*http.Response, error) {
+	return c.doer.Do(request.WithContext(c.ctx))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// ProvideMetricsPusher provides a *MetricsPusher configured under
+// "metrics.push". The metrics are pushed one last time when the core shuts
+// down.
+func ProvideMetricsPusher(appName contract.AppName, conf contract.ConfigAccessor, logger log.Logger) (*MetricsPusher, func(), error) {
+	var option PushOption
+	if err := conf.Unmarshal("metrics.push", &option); err != nil {
+		return nil, nil, fmt.Errorf("metrics.push configuration error: %w", err)
+	}
+	if option.Job == "" {
+		option.Job = appName.String()
+	}
+	pusher := NewMetricsPusher(option, stdprometheus.DefaultGatherer, logger)
+	pusher.Run()
+	return pusher, func() {
+		if err := pusher.Close(); err != nil {
+			level.Warn(logger).Log("msg", "failed to push metrics", "err", err)
+		}
+	}, nil
+}
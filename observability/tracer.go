@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"context"
 	"fmt"
 	"io"
 
@@ -11,10 +12,12 @@ import (
 	jaegermetric "github.com/uber/jaeger-lib/metrics"
 )
 
-// ProvideOpentracing provides a opentracing.Tracer.
+// ProvideOpentracing provides a opentracing.Tracer. The build info, if any, is
+// added to the tracer tags.
 func ProvideOpentracing(
 	appName contract.AppName,
 	env contract.Env,
+	buildInfo contract.BuildInfo,
 	log jaeger.Logger,
 	conf contract.ConfigAccessor,
 ) (opentracing.Tracer, func(), error) {
@@ -29,6 +32,12 @@ func ProvideOpentracing(
 			LocalAgentHostPort: conf.String("jaeger.reporter.addr"),
 		},
 	}
+	if !buildInfo.IsZero() {
+		cfg.Tags = []opentracing.Tag{
+			{Key: "version", Value: buildInfo.Version},
+			{Key: "commit", Value: buildInfo.Commit},
+		}
+	}
 	// Example logger and metrics factory. Use github.com/uber/jaeger-client-go/log
 	// and github.com/uber/jaeger-lib/metrics respectively to bind to real logging and metrics
 	// frameworks.
@@ -53,3 +62,18 @@ func ProvideOpentracing(
 
 	return tracer, closer, nil
 }
+
+// TraceID returns the trace id of the jaeger span in the context, or "" if there
+// is none. It can be set as the logging.TraceIDExtractor:
+//
+//	logging.SetTraceIDExtractor(observability.TraceID)
+func TraceID(ctx context.Context) string {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	if sc, ok := span.Context().(jaeger.SpanContext); ok {
+		return sc.TraceID().String()
+	}
+	return ""
+}
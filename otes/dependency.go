@@ -20,6 +20,7 @@ default *elastic.Client and exported configs.
 		log.Logger
 		contract.ConfigAccessor
 		EsConfigInterceptor `optional:"true"`
+		[]di.Interceptor `group:"interceptor"`
 		opentracing.Tracer     `optional:"true"`
 	Provides:
 		Factory
@@ -38,12 +39,13 @@ type EsConfigInterceptor func(name string, opt *Config)
 type factoryIn struct {
 	dig.In
 
-	Logger      log.Logger
-	Conf        contract.ConfigAccessor
-	Interceptor EsConfigInterceptor        `optional:"true"`
-	Tracer      opentracing.Tracer         `optional:"true"`
-	Options     []elastic.ClientOptionFunc `optional:"true"`
-	Dispatcher  contract.Dispatcher        `optional:"true"`
+	Logger       log.Logger
+	Conf         contract.ConfigAccessor
+	Interceptor  EsConfigInterceptor        `optional:"true"`
+	Interceptors []di.Interceptor           `group:"interceptor"`
+	Tracer       opentracing.Tracer         `optional:"true"`
+	Options      []elastic.ClientOptionFunc `optional:"true"`
+	Dispatcher   contract.Dispatcher        `optional:"true"`
 }
 
 // factoryOut is the result of Provide.
@@ -72,6 +74,20 @@ func provideEsFactory(p factoryIn) (factoryOut, func()) {
 		if p.Interceptor != nil {
 			p.Interceptor(name, &conf)
 		}
+		err := di.Interceptors(p.Interceptors).Apply("es", name, func(fn interface{}) bool {
+			switch fn := fn.(type) {
+			case EsConfigInterceptor:
+				fn(name, &conf)
+			case func(name string, opt *Config):
+				fn(name, &conf)
+			default:
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return di.Pair{}, err
+		}
 
 		if p.Tracer != nil {
 			options = append(options,
@@ -113,7 +129,7 @@ func provideEsFactory(p factoryIn) (factoryOut, func()) {
 				client.Stop()
 			},
 		}, nil
-	})
+	}, di.WithKind("es"))
 	f := Factory{factory}
 	f.SubscribeReloadEventFrom(p.Dispatcher)
 	return factoryOut{
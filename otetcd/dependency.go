@@ -20,6 +20,7 @@ Providers returns a set of dependencies including the Maker, the default *client
 		log.Logger
 		contract.ConfigAccessor
 		EtcdConfigInterceptor `optional:"true"`
+		[]di.Interceptor `group:"interceptor"`
 		opentracing.Tracer    `optional:"true"`
 	Provide:
 		Maker
@@ -39,11 +40,12 @@ type EtcdConfigInterceptor func(name string, options *clientv3.Config)
 type factoryIn struct {
 	di.In
 
-	Logger      log.Logger
-	Conf        contract.ConfigAccessor
-	Interceptor EtcdConfigInterceptor `optional:"true"`
-	Tracer      opentracing.Tracer    `optional:"true"`
-	Dispatcher  contract.Dispatcher   `optional:"true"`
+	Logger       log.Logger
+	Conf         contract.ConfigAccessor
+	Interceptor  EtcdConfigInterceptor `optional:"true"`
+	Interceptors []di.Interceptor      `group:"interceptor"`
+	Tracer       opentracing.Tracer    `optional:"true"`
+	Dispatcher   contract.Dispatcher   `optional:"true"`
 }
 
 // FactoryOut is the result of Provide.
@@ -95,6 +97,20 @@ func provideFactory(p factoryIn) (FactoryOut, func()) {
 		if p.Interceptor != nil {
 			p.Interceptor(name, &co)
 		}
+		err := di.Interceptors(p.Interceptors).Apply("etcd", name, func(fn interface{}) bool {
+			switch fn := fn.(type) {
+			case EtcdConfigInterceptor:
+				fn(name, &co)
+			case func(name string, options *clientv3.Config):
+				fn(name, &co)
+			default:
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return di.Pair{}, err
+		}
 		client, _ := clientv3.New(co)
 		return di.Pair{
 			Conn: client,
@@ -102,7 +118,7 @@ func provideFactory(p factoryIn) (FactoryOut, func()) {
 				_ = client.Close()
 			},
 		}, nil
-	})
+	}, di.WithKind("etcd"))
 	etcdFactory := Factory{factory}
 	etcdFactory.SubscribeReloadEventFrom(p.Dispatcher)
 	out := FactoryOut{
@@ -0,0 +1,198 @@
+package otgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultBulkBatchSize is used when neither WithBatchSize nor the
+// createBatchSize of the connection is set.
+const defaultBulkBatchSize = 1000
+
+// ConflictPolicy decides what BulkInsert does when a row conflicts with an
+// existing one on a unique key.
+type ConflictPolicy int
+
+const (
+	// ConflictError fails the batch. It is the default policy of BulkInsert.
+	ConflictError ConflictPolicy = iota
+	// ConflictIgnore keeps the existing row, eg. "ON CONFLICT DO NOTHING".
+	ConflictIgnore
+	// ConflictUpdate overwrites the existing row, eg. "ON CONFLICT DO UPDATE" or
+	// "ON DUPLICATE KEY UPDATE". It is the default policy of BulkUpsert.
+	ConflictUpdate
+)
+
+type bulk struct {
+	batchSize       int
+	policy          ConflictPolicy
+	conflictColumns []string
+	updateColumns   []string
+	transaction     bool
+	progress        func(done, total int)
+}
+
+// BulkOption is the functional option of BulkInsert and BulkUpsert.
+type BulkOption func(*bulk)
+
+// WithBatchSize sets the number of rows in a batch. It defaults to the
+// createBatchSize of the connection, or 1000 if that is not set either.
+func WithBatchSize(size int) BulkOption {
+	return func(b *bulk) {
+		b.batchSize = size
+	}
+}
+
+// WithConflictPolicy sets the ConflictPolicy.
+func WithConflictPolicy(policy ConflictPolicy) BulkOption {
+	return func(b *bulk) {
+		b.policy = policy
+	}
+}
+
+// WithConflictColumns sets the columns of the unique key that conflicts. It
+// defaults to the primary key. MySQL ignores it, as "ON DUPLICATE KEY UPDATE"
+// applies to every unique key.
+func WithConflictColumns(columns ...string) BulkOption {
+	return func(b *bulk) {
+		b.conflictColumns = columns
+	}
+}
+
+// WithUpdateColumns sets the columns overwritten by ConflictUpdate. It
+// defaults to every inserted column except the primary key.
+func WithUpdateColumns(columns ...string) BulkOption {
+	return func(b *bulk) {
+		b.updateColumns = columns
+	}
+}
+
+// WithTransaction inserts all batches in one transaction, so either all rows
+// or none are inserted. Otherwise each batch is committed on its own, and the
+// batches before a failure remain.
+func WithTransaction() BulkOption {
+	return func(b *bulk) {
+		b.transaction = true
+	}
+}
+
+// WithProgress sets a callback that is called after each batch with the
+// number of rows inserted so far and the total number of rows.
+func WithProgress(progress func(done, total int)) BulkOption {
+	return func(b *bulk) {
+		b.progress = progress
+	}
+}
+
+// BulkInsert inserts a slice of models in batches. The context is checked
+// before each batch, so a cancelled insert stops at the batch boundary.
+//
+//	err := otgorm.BulkInsert(ctx, db, users,
+//		otgorm.WithConflictPolicy(otgorm.ConflictIgnore),
+//		otgorm.WithProgress(func(done, total int) {
+//			level.Info(logger).Log("msg", fmt.Sprintf("%d/%d users inserted", done, total))
+//		}),
+//	)
+func BulkInsert(ctx context.Context, db *gorm.DB, rows interface{}, options ...BulkOption) error {
+	b := bulk{batchSize: db.CreateBatchSize}
+	for _, f := range options {
+		f(&b)
+	}
+	return b.insert(ctx, db, rows)
+}
+
+// BulkUpsert is like BulkInsert, but overwrites the conflicting rows by
+// default. See WithConflictColumns and WithUpdateColumns.
+func BulkUpsert(ctx context.Context, db *gorm.DB, rows interface{}, options ...BulkOption) error {
+	return BulkInsert(ctx, db, rows, append([]BulkOption{WithConflictPolicy(ConflictUpdate)}, options...)...)
+}
+
+func (b bulk) insert(ctx context.Context, db *gorm.DB, rows interface{}) error {
+	value := reflect.ValueOf(rows)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return fmt.Errorf("bulk insert expects a slice, got %T", rows)
+	}
+	if b.batchSize <= 0 {
+		b.batchSize = defaultBulkBatchSize
+	}
+
+	onConflict, err := b.onConflict(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	if onConflict != nil && len(onConflict.Columns) == 0 && len(onConflict.DoUpdates) > 0 {
+		// Unlike UpdateAll, gorm doesn't default the conflict target of
+		// DoUpdates, which postgres and sqlite require.
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(rows); err != nil {
+			return err
+		}
+		for _, field := range stmt.Schema.PrimaryFields {
+			onConflict.Columns = append(onConflict.Columns, clause.Column{Name: field.DBName})
+		}
+	}
+
+	run := func(tx *gorm.DB) error {
+		if onConflict != nil {
+			tx = tx.Clauses(*onConflict)
+		}
+		total := value.Len()
+		for start := 0; start < total; start += b.batchSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			end := start + b.batchSize
+			if end > total {
+				end = total
+			}
+			if err := tx.Create(value.Slice(start, end).Interface()).Error; err != nil {
+				return fmt.Errorf("failed to insert rows %d to %d: %w", start, end, err)
+			}
+			if b.progress != nil {
+				b.progress(end, total)
+			}
+		}
+		return nil
+	}
+
+	db = db.WithContext(ctx)
+	if b.transaction {
+		return db.Transaction(run)
+	}
+	return run(db)
+}
+
+func (b bulk) onConflict(dialect string) (*clause.OnConflict, error) {
+	if b.policy == ConflictError {
+		return nil, nil
+	}
+	if dialect == "clickhouse" {
+		return nil, errors.New("clickhouse does not support conflict policies")
+	}
+
+	var onConflict clause.OnConflict
+	for _, column := range b.conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+	switch b.policy {
+	case ConflictIgnore:
+		onConflict.DoNothing = true
+	case ConflictUpdate:
+		if len(b.updateColumns) > 0 {
+			onConflict.DoUpdates = clause.AssignmentColumns(b.updateColumns)
+		} else {
+			onConflict.UpdateAll = true
+		}
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %d", b.policy)
+	}
+	return &onConflict, nil
+}
@@ -0,0 +1,102 @@
+package otgorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type bulkUser struct {
+	ID    uint   `gorm:"primaryKey"`
+	Email string `gorm:"uniqueIndex"`
+	Name  string
+	Age   int
+}
+
+func prepareBulkDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{CreateBatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&bulkUser{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestBulkInsert(t *testing.T) {
+	db := prepareBulkDB(t)
+
+	var progress [][2]int
+	users := []bulkUser{{ID: 1, Email: "a", Name: "a"}, {ID: 2, Email: "b", Name: "b"}, {ID: 3, Email: "c", Name: "c"}}
+	err := BulkInsert(context.Background(), db, users, WithProgress(func(done, total int) {
+		progress = append(progress, [2]int{done, total})
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int{{2, 3}, {3, 3}}, progress)
+
+	err = BulkInsert(context.Background(), db, []bulkUser{{ID: 1, Email: "a", Name: "changed"}})
+	assert.Error(t, err)
+
+	err = BulkInsert(context.Background(), db, []bulkUser{{ID: 1, Email: "a", Name: "changed"}, {ID: 4, Email: "d"}}, WithConflictPolicy(ConflictIgnore))
+	assert.NoError(t, err)
+	var user bulkUser
+	db.First(&user, 1)
+	assert.Equal(t, "a", user.Name)
+
+	var count int64
+	db.Model(&bulkUser{}).Count(&count)
+	assert.Equal(t, int64(4), count)
+
+	err = BulkInsert(context.Background(), db, bulkUser{})
+	assert.Error(t, err)
+}
+
+func TestBulkUpsert(t *testing.T) {
+	db := prepareBulkDB(t)
+	assert.NoError(t, BulkInsert(context.Background(), db, []bulkUser{{ID: 1, Email: "a", Name: "a", Age: 1}}))
+
+	err := BulkUpsert(context.Background(), db, []bulkUser{{ID: 1, Email: "a", Name: "b", Age: 2}})
+	assert.NoError(t, err)
+	var user bulkUser
+	db.First(&user, 1)
+	assert.Equal(t, "b", user.Name)
+	assert.Equal(t, 2, user.Age)
+
+	err = BulkUpsert(context.Background(), db, []bulkUser{{ID: 2, Email: "a", Name: "c", Age: 3}}, WithConflictColumns("email"), WithUpdateColumns("name"))
+	assert.NoError(t, err)
+	db.First(&user, 1)
+	assert.Equal(t, "c", user.Name)
+	assert.Equal(t, 2, user.Age)
+
+	err = BulkUpsert(context.Background(), db, []bulkUser{{ID: 1, Email: "a", Name: "d"}}, WithUpdateColumns("name"))
+	assert.NoError(t, err)
+	db.First(&user, 1)
+	assert.Equal(t, "d", user.Name)
+}
+
+func TestBulkInsert_cancel(t *testing.T) {
+	db := prepareBulkDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	users := []bulkUser{{ID: 1, Email: "a"}, {ID: 2, Email: "b"}, {ID: 3, Email: "c"}}
+	err := BulkInsert(ctx, db, users, WithBatchSize(1), WithProgress(func(done, total int) {
+		cancel()
+	}))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var count int64
+	db.Model(&bulkUser{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	err = BulkInsert(ctx, db, users[1:], WithBatchSize(1), WithTransaction(), WithProgress(func(done, total int) {
+		cancel()
+	}))
+	assert.Error(t, err)
+	db.Model(&bulkUser{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
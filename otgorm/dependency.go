@@ -1,15 +1,19 @@
 package otgorm
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net"
 	"time"
 
+	"github.com/DoNewsCode/core/boot"
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/opentracing/opentracing-go"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -22,21 +26,25 @@ the Maker, database configs and the default *gorm.DB instance.
 		contract.ConfigAccessor
 		log.Logger
 		GormConfigInterceptor `optional:"true"`
+		[]di.Interceptor `group:"interceptor"`
 		opentracing.Tracer    `optional:"true"`
 		Gauges `optional:"true"`
+		contract.SecretStore `optional:"true"`
 	Provide:
 		Maker
 		Factory
 		*gorm.DB
 		*SQLite
 		*collector
+		boot.Kind "gorm"
 */
 func Providers() []interface{} {
-	return []interface{}{provideDatabaseFactory, provideConfig, provideDefaultDatabase, provideMemoryDatabase}
+	return []interface{}{provideDatabaseFactory, provideConfig, provideDefaultDatabase, provideMemoryDatabase, provideBootKind}
 }
 
 // GormConfigInterceptor is a function that allows user to Make last minute
-// change to *gorm.Config when constructing *gorm.DB.
+// change to *gorm.Config when constructing *gorm.DB. Besides the single optional
+// one, any number of them can be registered as di.Interceptor of the kind "gorm".
 type GormConfigInterceptor func(name string, conf *gorm.Config)
 
 // SQLite is an alias of gorm.DB. This is useful when injecting test db.
@@ -59,6 +67,17 @@ type databaseConf struct {
 		TablePrefix   string `json:"tablePrefix" yaml:"tablePrefix"`
 		SingularTable bool   `json:"singularTable" yaml:"singularTable"`
 	} `json:"namingStrategy" yaml:"namingStrategy"`
+	// Replicas are the DSNs of the read replicas. The queries are sent to them,
+	// see Resolver.
+	Replicas []string `json:"replicas" yaml:"replicas"`
+	// ReadYourWritesWindow is how long the reads go to the primary after a
+	// write, see WithReadYourWrites.
+	ReadYourWritesWindow config.Duration `json:"readYourWritesWindow" yaml:"readYourWritesWindow"`
+}
+
+type encryptionConf struct {
+	Key     string `json:"key" yaml:"key"`
+	HashKey string `json:"hashKey" yaml:"hashKey"`
 }
 
 type metricsConf struct {
@@ -89,10 +108,12 @@ type factoryIn struct {
 	Conf                  contract.ConfigAccessor
 	Logger                log.Logger
 	GormConfigInterceptor GormConfigInterceptor `optional:"true"`
+	Interceptors          []di.Interceptor      `group:"interceptor"`
 	Tracer                opentracing.Tracer    `optional:"true"`
 	Gauges                *Gauges               `optional:"true"`
 	Dispatcher            contract.Dispatcher   `optional:"true"`
 	Drivers               Drivers               `optional:"true"`
+	SecretStore           contract.SecretStore  `optional:"true"`
 }
 
 // databaseOut is the result of provideDatabaseFactory. *gorm.DB is not a interface
@@ -170,7 +191,29 @@ func provideDatabaseFactory(p factoryIn) (databaseOut, func(), error) {
 		p.Conf.Unmarshal("gormMetrics.interval", &interval)
 		collector = newCollector(factory, p.Gauges, interval)
 	}
-	factory.SubscribeReloadEventFrom(p.Dispatcher)
+	factory.SubscribeKeyChangedEventFrom(p.Dispatcher, "gorm")
+
+	var encryption encryptionConf
+	_ = p.Conf.Unmarshal("gormEncryption", &encryption)
+	if encryption.Key != "" {
+		if p.SecretStore == nil {
+			cleanup()
+			return databaseOut{}, nil, fmt.Errorf("gormEncryption references secrets, but contract.SecretStore is not provided")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		keyring, err := KeyringFromSecretStore(ctx, p.SecretStore, encryption.Key, encryption.HashKey)
+		if err != nil {
+			cancel()
+			cleanup()
+			return databaseOut{}, nil, err
+		}
+		SetKeyring(keyring)
+		closeFactory := cleanup
+		cleanup = func() {
+			cancel()
+			closeFactory()
+		}
+	}
 
 	return databaseOut{
 		Factory:   factory,
@@ -183,9 +226,29 @@ func provideDefaultDatabase(maker Maker) (*gorm.DB, error) {
 	return maker.Make("default")
 }
 
+// provideBootKind provides the "gorm" kind of boot gates, which pings the
+// database of the entry.
+func provideBootKind(maker Maker) boot.KindOut {
+	return boot.KindOut{Kind: boot.Kind{
+		Name: "gorm",
+		Probe: func(ctx context.Context, entry string) error {
+			db, err := maker.Make(entry)
+			if err != nil {
+				return err
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	}}
+}
+
 func provideDBFactory(p factoryIn) (Factory, func()) {
 	logger := log.With(p.Logger, "tag", "database")
 
+	var dbFactory Factory
 	factory := di.NewFactory(func(name string) (di.Pair, error) {
 		var (
 			dialector gorm.Dialector
@@ -200,7 +263,12 @@ func provideDBFactory(p factoryIn) (Factory, func()) {
 		if p.Drivers == nil {
 			p.Drivers = getDefaultDrivers()
 		}
-		dialector, err := provideDialector(&conf, p.Drivers)
+		dsn, refs, err := resolveDSN(context.Background(), conf.Dsn, p.SecretStore)
+		if err != nil {
+			return di.Pair{}, fmt.Errorf("database configuration %s not valid: %w", name, err)
+		}
+		conf.Dsn = dsn
+		dialector, err = provideDialector(&conf, p.Drivers)
 		if err != nil {
 			return di.Pair{}, err
 		}
@@ -208,20 +276,110 @@ func provideDBFactory(p factoryIn) (Factory, func()) {
 		if p.GormConfigInterceptor != nil {
 			p.GormConfigInterceptor(name, gormConfig)
 		}
+		err = di.Interceptors(p.Interceptors).Apply("gorm", name, func(fn interface{}) bool {
+			switch fn := fn.(type) {
+			case GormConfigInterceptor:
+				fn(name, gormConfig)
+			case func(name string, conf *gorm.Config):
+				fn(name, gormConfig)
+			default:
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return di.Pair{}, err
+		}
 		conn, cleanup, err = provideGormDB(dialector, gormConfig, p.Tracer)
 		if err != nil {
 			return di.Pair{}, err
 		}
+		if len(conf.Replicas) > 0 {
+			replicaRefs, closeReplicas, err := useReplicas(conn, &conf, p.Drivers, p.SecretStore)
+			if err != nil {
+				cleanup()
+				return di.Pair{}, fmt.Errorf("database %s replicas not valid: %w", name, err)
+			}
+			refs = append(refs, replicaRefs...)
+			closePrimary := cleanup
+			cleanup = func() {
+				closeReplicas()
+				closePrimary()
+			}
+		}
+		if len(refs) > 0 {
+			// reconnect with the new credentials on rotation.
+			ctx, cancel := context.WithCancel(context.Background())
+			watchSecrets(ctx, p.SecretStore, refs, func(ref string) {
+				level.Info(logger).Log("msg", fmt.Sprintf("secret %s rotated, reconnecting database %s", ref, name))
+				dbFactory.CloseConn(name)
+			})
+			closeDB := cleanup
+			cleanup = func() {
+				cancel()
+				closeDB()
+			}
+		}
 		return di.Pair{
 			Conn:   conn,
 			Closer: cleanup,
 		}, err
-	})
-	dbFactory := Factory{factory}
-	dbFactory.SubscribeReloadEventFrom(p.Dispatcher)
+	}, di.WithKind("gorm"))
+	dbFactory = Factory{factory}
+	dbFactory.SubscribeKeyChangedEventFrom(p.Dispatcher, "gorm")
 	return dbFactory, dbFactory.Close
 }
 
+// useReplicas opens the replicas of the database, and registers the Resolver
+// on the primary. The replicas share the dialect of the primary. They are only
+// used as connection pools, so the queries still run the callbacks of the
+// primary, eg. the tracing ones.
+func useReplicas(primary *gorm.DB, conf *databaseConf, drivers Drivers, store contract.SecretStore) ([]string, func(), error) {
+	var (
+		refs     []string
+		replicas []gorm.ConnPool
+	)
+	closeReplicas := func() {
+		for _, replica := range replicas {
+			replica.(*sql.DB).Close()
+		}
+	}
+	for _, replicaDSN := range conf.Replicas {
+		dsn, replicaRefs, err := resolveDSN(context.Background(), replicaDSN, store)
+		if err != nil {
+			closeReplicas()
+			return nil, nil, err
+		}
+		refs = append(refs, replicaRefs...)
+		replicaConf := *conf
+		replicaConf.Dsn = dsn
+		dialector, err := provideDialector(&replicaConf, drivers)
+		if err != nil {
+			closeReplicas()
+			return nil, nil, err
+		}
+		replica, err := gorm.Open(dialector, &gorm.Config{
+			Logger:               primary.Logger,
+			DisableAutomaticPing: conf.DisableAutomaticPing,
+		})
+		if err != nil {
+			closeReplicas()
+			return nil, nil, err
+		}
+		sqlDB, err := replica.DB()
+		if err != nil {
+			closeReplicas()
+			return nil, nil, err
+		}
+		replicas = append(replicas, sqlDB)
+	}
+	if err := primary.Use(&Resolver{Replicas: replicas, Window: conf.ReadYourWritesWindow.Duration}); err != nil {
+		closeReplicas()
+		return nil, nil, err
+	}
+	return refs, closeReplicas, nil
+}
+
 type configOut struct {
 	di.Out
 
@@ -252,13 +410,16 @@ func provideConfig() configOut {
 							TablePrefix   string `json:"tablePrefix" yaml:"tablePrefix"`
 							SingularTable bool   `json:"singularTable" yaml:"singularTable"`
 						}{},
+						Replicas:             []string{},
+						ReadYourWritesWindow: config.Duration{Duration: time.Second},
 					},
 				},
 				"gormMetrics": metricsConf{
 					Interval: config.Duration{Duration: 15 * time.Second},
 				},
+				"gormEncryption": encryptionConf{},
 			},
-			Comment: "The database configuration",
+			Comment: "The database configuration. The queries are sent to the replicas, if any, except the ones following a write within readYourWritesWindow. The gormEncryption key and hashKey are the secret references of the keys of EncryptedString and HashedString",
 		},
 	}
 	return configOut{Config: exported}
@@ -1,8 +1,11 @@
 package otgorm
 
 import (
+	"context"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DoNewsCode/core"
 	"github.com/DoNewsCode/core/di"
@@ -77,3 +80,100 @@ func TestProvideConfigs(t *testing.T) {
 	c := provideConfig()
 	assert.NotEmpty(t, c.Config)
 }
+
+type secretStore struct {
+	mu      sync.Mutex
+	value   string
+	rotated chan struct{}
+}
+
+func (s *secretStore) Get(ctx context.Context, ref string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []byte(s.value), nil
+}
+
+func (s *secretStore) Watch(ctx context.Context, ref string, rotate func(value []byte) error) error {
+	select {
+	case <-s.rotated:
+		return rotate(nil)
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func TestProvideDBFactory_secret(t *testing.T) {
+	store := &secretStore{value: "first", rotated: make(chan struct{})}
+	factory, cleanup := provideDBFactory(factoryIn{
+		Conf: config.MapAdapter{"gorm": map[string]databaseConf{
+			"default": {Database: "sqlite", Dsn: "file:${secret:db#name}?mode=memory"},
+		}},
+		Logger:      log.NewNopLogger(),
+		SecretStore: store,
+	})
+	defer cleanup()
+
+	db, err := factory.Make("default")
+	assert.NoError(t, err)
+
+	store.mu.Lock()
+	store.value = "second"
+	store.mu.Unlock()
+	close(store.rotated)
+	assert.Eventually(t, func() bool {
+		_, ok := factory.List()["default"]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	reconnected, err := factory.Make("default")
+	assert.NoError(t, err)
+	assert.NotSame(t, db, reconnected)
+
+	_, _, err = resolveDSN(context.Background(), "${secret:foo}", nil)
+	assert.Error(t, err)
+	dsn, refs, err := resolveDSN(context.Background(), "${secret:a}:${secret:a}@${secret:b}", store)
+	assert.NoError(t, err)
+	assert.Equal(t, "second:second@second", dsn)
+	assert.Equal(t, []string{"a", "b"}, refs)
+}
+
+func TestProvideDBFactory_interceptors(t *testing.T) {
+	var order []string
+	factory, cleanup := provideDBFactory(factoryIn{
+		Conf: config.MapAdapter{"gorm": map[string]databaseConf{
+			"default": {Database: "sqlite", Dsn: ":memory:"},
+		}},
+		Logger: log.NewNopLogger(),
+		GormConfigInterceptor: func(name string, conf *gorm.Config) {
+			order = append(order, "single")
+		},
+		Interceptors: []di.Interceptor{
+			{Kind: "gorm", Order: 1, Func: func(name string, conf *gorm.Config) {
+				order = append(order, "last")
+				conf.CreateBatchSize = 10
+			}},
+			{Kind: "gorm", Name: "default", Func: GormConfigInterceptor(func(name string, conf *gorm.Config) {
+				order = append(order, name)
+			})},
+			{Kind: "gorm", Name: "other", Func: GormConfigInterceptor(func(name string, conf *gorm.Config) {
+				order = append(order, name)
+			})},
+		},
+	})
+	defer cleanup()
+
+	db, err := factory.Make("default")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, db.CreateBatchSize)
+	assert.Equal(t, []string{"single", "default", "last"}, order)
+
+	factory, cleanup = provideDBFactory(factoryIn{
+		Conf: config.MapAdapter{"gorm": map[string]databaseConf{
+			"default": {Database: "sqlite", Dsn: ":memory:"},
+		}},
+		Logger:       log.NewNopLogger(),
+		Interceptors: []di.Interceptor{{Kind: "gorm", Func: func(name string) {}}},
+	})
+	defer cleanup()
+	_, err = factory.Make("default")
+	assert.Error(t, err)
+}
@@ -39,6 +39,12 @@ For example, if we want to use postgres:
         }
     }}
 
+The dsn may reference secrets in the form of ${secret:REF}, eg.
+"root:${secret:db#password}@tcp(127.0.0.1:3306)/app". The placeholders are
+resolved by the contract.SecretStore in the dependency graph. When a referenced
+secret rotates, the connection is closed, and the next Make reconnects with the
+new value.
+
 Sometimes there are valid reasons to connect to more than one mysql server.
 Inject otgorm.Maker to factory a *gorm.DB with a specific configuration entry.
 
@@ -47,6 +53,18 @@ Inject otgorm.Maker to factory a *gorm.DB with a specific configuration entry.
 		// do something with client
 	})
 
+If replicas are configured for a database, the queries are sent to them. To
+read your own writes, pass a context of WithReadYourWrites to gorm, or use
+MakeReadYourWritesMiddleware, so that the reads following a write go to the
+primary.
+
+Personally identifiable information can be protected with the EncryptedString
+and HashedString column types, whose keys come from the contract.SecretStore
+referenced by the gormEncryption config. The encryption key can be rotated.
+
+In unit tests, the otgorm.Maker can be replaced with otgormtest.Maker, whose
+connections are backed by sqlmock.
+
 Migration and Seeding
 
 package otgorm comes with migration and seeding support. Other modules can
@@ -62,5 +80,12 @@ Then you can migrate the database by running:
 	go run main.go database migrate
 
 See examples to learn more.
+
+Bulk Insert
+
+BulkInsert and BulkUpsert insert large slices of models in batches, with a
+ConflictPolicy for the rows conflicting on a unique key:
+
+	err := otgorm.BulkUpsert(ctx, db, users, otgorm.WithConflictColumns("email"))
 */
 package otgorm
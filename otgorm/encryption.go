@@ -0,0 +1,246 @@
+package otgorm
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+// Keyring holds the keys of the encrypted columns. The values are encrypted
+// with AES-GCM under the current key, and tagged with the fingerprint of the
+// key, so that the values encrypted under the previous keys can still be
+// decrypted after a rotation. Keyring is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	current string
+	aeads   map[string]cipher.AEAD
+	hashKey []byte
+}
+
+// NewKeyring creates a *Keyring. The key is an AES key of 16, 24 or 32 bytes.
+// The hash key is the HMAC key of the hashed columns. Unlike the key, it can't
+// be rotated without rehashing the columns, as the lookups would fail.
+func NewKeyring(key []byte, hashKey []byte) (*Keyring, error) {
+	if len(hashKey) == 0 {
+		return nil, errors.New("the hash key is empty")
+	}
+	k := &Keyring{aeads: make(map[string]cipher.AEAD), hashKey: hashKey}
+	if err := k.Rotate(key); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Rotate makes the key the current one. The previous keys are kept for
+// decryption.
+func (k *Keyring) Rotate(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(key)
+	id := hex.EncodeToString(sum[:4])
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.aeads[id] = aead
+	k.current = id
+	return nil
+}
+
+// Encrypt encrypts the plaintext under the current key.
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	k.mu.RLock()
+	id, aead := k.current, k.aeads[k.current]
+	k.mu.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the ciphertext under the key it was encrypted with.
+func (k *Keyring) Decrypt(ciphertext string) ([]byte, error) {
+	parts := strings.SplitN(ciphertext, ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("malformed ciphertext")
+	}
+	k.mu.RLock()
+	aead, ok := k.aeads[parts[0]]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", parts[0])
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed ciphertext")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
+
+// Hash returns the hex encoded HMAC-SHA256 of the value.
+func (k *Keyring) Hash(value []byte) string {
+	mac := hmac.New(sha256.New, k.hashKey)
+	mac.Write(value)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// KeyringFromSecretStore creates a *Keyring with the keys from the store. The
+// key is rotated whenever the secret changes, until the context is canceled.
+func KeyringFromSecretStore(ctx context.Context, store contract.SecretStore, keyRef string, hashKeyRef string) (*Keyring, error) {
+	key, err := store.Get(ctx, keyRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the key %s: %w", keyRef, err)
+	}
+	hashKey, err := store.Get(ctx, hashKeyRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hash key %s: %w", hashKeyRef, err)
+	}
+	keyring, err := NewKeyring(key, hashKey)
+	if err != nil {
+		return nil, err
+	}
+	go store.Watch(ctx, keyRef, keyring.Rotate)
+	return keyring, nil
+}
+
+var (
+	keyringMu sync.RWMutex
+	keyring   *Keyring
+)
+
+// SetKeyring sets the *Keyring of EncryptedString and HashedString. The
+// factory sets it if gormEncryption is configured.
+func SetKeyring(k *Keyring) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+
+	keyring = k
+}
+
+func getKeyring() (*Keyring, error) {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	if keyring == nil {
+		return nil, errors.New("otgorm: the keyring is not set, see SetKeyring")
+	}
+	return keyring, nil
+}
+
+// EncryptedString is a string column encrypted at rest, for personally
+// identifiable information and the like. The column type must hold the
+// ciphertext, which is longer than the plaintext.
+//
+//	type User struct {
+//		ID        uint
+//		Email     otgorm.EncryptedString `gorm:"type:varchar(512)"`
+//		EmailHash otgorm.HashedString    `gorm:"index"`
+//	}
+type EncryptedString string
+
+// Value implements driver.Valuer
+func (s EncryptedString) Value() (driver.Value, error) {
+	k, err := getKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt([]byte(s))
+}
+
+// Scan implements sql.Scanner
+func (s *EncryptedString) Scan(src interface{}) error {
+	k, err := getKeyring()
+	if err != nil {
+		return err
+	}
+	var ciphertext string
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		ciphertext = v
+	case []byte:
+		ciphertext = string(v)
+	default:
+		return fmt.Errorf("otgorm: can't scan %T into EncryptedString", src)
+	}
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// hashPrefix marks the hashed values, so that the scanned ones are not hashed
+// again when saved.
+const hashPrefix = "hmac:"
+
+// HashedString is a string column stored as its keyed hash. It can't be read
+// back, but it can be looked up by the value, usually next to an
+// EncryptedString of the same value:
+//
+//	db.Where("email_hash = ?", otgorm.HashedString(email)).First(&user)
+//
+// The scanned value is the hash, which is saved as is.
+type HashedString string
+
+// Value implements driver.Valuer
+func (s HashedString) Value() (driver.Value, error) {
+	if isHash(string(s)) {
+		return string(s), nil
+	}
+	k, err := getKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return hashPrefix + k.Hash([]byte(s)), nil
+}
+
+func isHash(s string) bool {
+	if !strings.HasPrefix(s, hashPrefix) || len(s) != len(hashPrefix)+sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s[len(hashPrefix):])
+	return err == nil
+}
+
+// Scan implements sql.Scanner. The hash is scanned as is.
+func (s *HashedString) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = HashedString(v)
+	case []byte:
+		*s = HashedString(v)
+	default:
+		return fmt.Errorf("otgorm: can't scan %T into HashedString", src)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package otgorm
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type piiUser struct {
+	ID        uint
+	Email     EncryptedString
+	EmailHash HashedString
+}
+
+type mockSecretStore map[string][]byte
+
+func (m mockSecretStore) Get(ctx context.Context, ref string) ([]byte, error) {
+	return m[ref], nil
+}
+
+func (m mockSecretStore) Watch(ctx context.Context, ref string, rotate func(value []byte) error) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestEncryptedColumns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k, err := KeyringFromSecretStore(ctx, mockSecretStore{
+		"key":     bytes.Repeat([]byte("k"), 32),
+		"hashKey": []byte("hash"),
+	}, "key", "hashKey")
+	assert.NoError(t, err)
+	SetKeyring(k)
+	defer SetKeyring(nil)
+
+	db, err := gorm.Open(sqlite.Open("file:encryption?mode=memory"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&piiUser{}))
+	assert.NoError(t, db.Create(&piiUser{Email: "alice@example.com", EmailHash: "alice@example.com"}).Error)
+
+	var raw string
+	db.Raw("SELECT email FROM pii_users").Scan(&raw)
+	assert.NotContains(t, raw, "alice")
+
+	// the values encrypted under the previous keys are still readable.
+	assert.NoError(t, k.Rotate(bytes.Repeat([]byte("r"), 32)))
+
+	var user piiUser
+	assert.NoError(t, db.Where("email_hash = ?", HashedString("alice@example.com")).First(&user).Error)
+	assert.Equal(t, EncryptedString("alice@example.com"), user.Email)
+
+	// saving the loaded user keeps the hash.
+	assert.NoError(t, db.Save(&user).Error)
+	assert.NoError(t, db.Where("email_hash = ?", HashedString("alice@example.com")).First(&user).Error)
+}
+
+func TestKeyring_invalid(t *testing.T) {
+	_, err := NewKeyring([]byte("short"), []byte("hash"))
+	assert.Error(t, err)
+
+	k, err := NewKeyring(bytes.Repeat([]byte("k"), 16), []byte("hash"))
+	assert.NoError(t, err)
+	_, err = k.Decrypt("unknown:AAAA")
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/DoNewsCode/core/logging"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"gorm.io/gorm/logger"
@@ -22,17 +23,17 @@ func (g GormLogAdapter) LogMode(logLevel logger.LogLevel) logger.Interface {
 
 // Info implements logger.Interface
 func (g GormLogAdapter) Info(ctx context.Context, s string, i ...interface{}) {
-	level.Info(g.Logging).Log("msg", fmt.Sprintf(s, i...))
+	level.Info(logging.WithRequestLevel(g.Logging, ctx)).Log("msg", fmt.Sprintf(s, i...))
 }
 
 // Warn implements logger.Interface
 func (g GormLogAdapter) Warn(ctx context.Context, s string, i ...interface{}) {
-	level.Warn(g.Logging).Log("msg", fmt.Sprintf(s, i...))
+	level.Warn(logging.WithRequestLevel(g.Logging, ctx)).Log("msg", fmt.Sprintf(s, i...))
 }
 
 // Error implements logger.Interface
 func (g GormLogAdapter) Error(ctx context.Context, s string, i ...interface{}) {
-	level.Error(g.Logging).Log("msg", fmt.Sprintf(s, i...))
+	level.Error(logging.WithRequestLevel(g.Logging, ctx)).Log("msg", fmt.Sprintf(s, i...))
 }
 
 // Trace implements logger.Interface
@@ -42,9 +43,9 @@ func (g GormLogAdapter) Trace(ctx context.Context, begin time.Time, fc func() (s
 
 	var l log.Logger
 	if err == nil {
-		l = level.Debug(g.Logging)
+		l = level.Debug(logging.WithRequestLevel(g.Logging, ctx))
 	} else {
-		l = level.Warn(g.Logging)
+		l = level.Warn(logging.WithRequestLevel(g.Logging, ctx))
 	}
 	if rows == -1 {
 		l.Log("sql", sql, "duration", elapsed, "rows", "-", "err", err)
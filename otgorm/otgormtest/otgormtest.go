@@ -0,0 +1,195 @@
+/*
+Package otgormtest provides an otgorm.Maker backed by sqlmock, so that
+repository unit tests can assert the exact SQL sent to the database, without
+the behavioral differences of sqlite.
+
+	func TestUserRepository_Create(t *testing.T) {
+		maker := otgormtest.NewMaker()
+		defer maker.Close()
+
+		otgormtest.ExpectWrite(maker.Mock("default"), "INSERT INTO `users` (`name`) VALUES (?)", "alice").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		repo := NewUserRepository(maker)
+		assert.NoError(t, repo.Create(context.Background(), "alice"))
+		assert.NoError(t, maker.ExpectationsWereMet())
+	}
+
+The connections speak the mysql dialect by default. The SQL is matched
+exactly, see sqlmock.QueryMatcherEqual.
+*/
+package otgormtest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/DoNewsCode/core/otgorm"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var _ otgorm.Maker = (*Maker)(nil)
+
+// Option configures the Maker.
+type Option func(*Maker)
+
+// WithDialector replaces the mysql dialect, eg. with postgres:
+//
+//	otgormtest.NewMaker(otgormtest.WithDialector(func(conn *sql.DB) gorm.Dialector {
+//		return postgres.New(postgres.Config{Conn: conn})
+//	}))
+func WithDialector(dialector func(conn *sql.DB) gorm.Dialector) Option {
+	return func(maker *Maker) {
+		maker.dialector = dialector
+	}
+}
+
+// WithGormConfig sets the *gorm.Config of the connections.
+func WithGormConfig(conf *gorm.Config) Option {
+	return func(maker *Maker) {
+		maker.gormConfig = conf
+	}
+}
+
+// WithQueryMatcher replaces the exact SQL matching, eg. with
+// sqlmock.QueryMatcherRegexp.
+func WithQueryMatcher(matcher sqlmock.QueryMatcher) Option {
+	return func(maker *Maker) {
+		maker.matcher = matcher
+	}
+}
+
+// Maker is an otgorm.Maker whose connections are backed by sqlmock. A
+// connection is created for each name on first use, by either Make or Mock.
+// Maker is safe for concurrent use.
+type Maker struct {
+	dialector  func(conn *sql.DB) gorm.Dialector
+	gormConfig *gorm.Config
+	matcher    sqlmock.QueryMatcher
+
+	mu    sync.Mutex
+	conns map[string]*conn
+}
+
+type conn struct {
+	sqlDB *sql.DB
+	db    *gorm.DB
+	mock  sqlmock.Sqlmock
+}
+
+// NewMaker creates a *Maker.
+func NewMaker(options ...Option) *Maker {
+	maker := &Maker{
+		dialector: func(conn *sql.DB) gorm.Dialector {
+			return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true})
+		},
+		matcher: sqlmock.QueryMatcherEqual,
+		conns:   make(map[string]*conn),
+	}
+	for _, f := range options {
+		f(maker)
+	}
+	return maker
+}
+
+// Make returns the *gorm.DB of the name.
+func (m *Maker) Make(name string) (*gorm.DB, error) {
+	c, err := m.conn(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.db, nil
+}
+
+// Mock returns the sqlmock.Sqlmock of the name, to set the expectations on.
+// It panics if the connection can't be created.
+func (m *Maker) Mock(name string) sqlmock.Sqlmock {
+	c, err := m.conn(name)
+	if err != nil {
+		panic(err)
+	}
+	return c.mock
+}
+
+// ExpectationsWereMet checks the expectations of all connections.
+func (m *Maker) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.conns))
+	for name := range m.conns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := m.conns[name].mock.ExpectationsWereMet(); err != nil {
+			return fmt.Errorf("connection %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Close closes all connections.
+func (m *Maker) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, c := range m.conns {
+		_ = c.sqlDB.Close()
+		delete(m.conns, name)
+	}
+}
+
+func (m *Maker) conn(name string) (*conn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.conns[name]; ok {
+		return c, nil
+	}
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(m.matcher))
+	if err != nil {
+		return nil, err
+	}
+	gormConfig := &gorm.Config{}
+	if m.gormConfig != nil {
+		copied := *m.gormConfig
+		gormConfig = &copied
+	}
+	db, err := gorm.Open(m.dialector(sqlDB), gormConfig)
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("failed to open the mock connection %s: %w", name, err)
+	}
+	c := &conn{sqlDB: sqlDB, db: db, mock: mock}
+	m.conns[name] = c
+	return c, nil
+}
+
+// ExpectWrite expects the statement to be executed within the transaction that
+// gorm wraps writes in, unless SkipDefaultTransaction is set. The result is
+// set on the returned expectation.
+func ExpectWrite(mock sqlmock.Sqlmock, sql string, args ...driver.Value) *sqlmock.ExpectedExec {
+	mock.ExpectBegin()
+	exec := mock.ExpectExec(sql)
+	if len(args) > 0 {
+		exec = exec.WithArgs(args...)
+	}
+	mock.ExpectCommit()
+	return exec
+}
+
+// ExpectRows expects the query, and returns the rows with the columns and
+// values, one slice per row.
+func ExpectRows(mock sqlmock.Sqlmock, sql string, columns []string, values ...[]driver.Value) *sqlmock.ExpectedQuery {
+	rows := sqlmock.NewRows(columns)
+	for _, row := range values {
+		rows.AddRow(row...)
+	}
+	return mock.ExpectQuery(sql).WillReturnRows(rows)
+}
@@ -0,0 +1,46 @@
+package otgormtest
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func TestMaker(t *testing.T) {
+	maker := NewMaker()
+	defer maker.Close()
+
+	ExpectWrite(maker.Mock("default"), "INSERT INTO `users` (`name`) VALUES (?)", "alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	ExpectRows(maker.Mock("replica"), "SELECT * FROM `users` WHERE `users`.`id` = ? ORDER BY `users`.`id` LIMIT 1",
+		[]string{"id", "name"}, []driver.Value{1, "alice"}).WithArgs(1)
+
+	db, err := maker.Make("default")
+	assert.NoError(t, err)
+	u := user{Name: "alice"}
+	assert.NoError(t, db.Create(&u).Error)
+	assert.Equal(t, 1, u.ID)
+
+	replica, err := maker.Make("replica")
+	assert.NoError(t, err)
+	var found user
+	assert.NoError(t, replica.First(&found, 1).Error)
+	assert.Equal(t, "alice", found.Name)
+
+	assert.NoError(t, maker.ExpectationsWereMet())
+}
+
+func TestMaker_unmet(t *testing.T) {
+	maker := NewMaker()
+	defer maker.Close()
+
+	maker.Mock("default").ExpectExec("DELETE FROM `users`")
+	assert.Error(t, maker.ExpectationsWereMet())
+}
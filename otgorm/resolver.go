@@ -0,0 +1,183 @@
+package otgorm
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const writtenCookie = "otgorm_written"
+
+type consistencyKey struct{}
+
+// consistency records the last write made with a context.
+type consistency struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+func (c *consistency) markWritten(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t.After(c.lastWrite) {
+		c.lastWrite = t
+	}
+}
+
+func (c *consistency) written(window time.Duration, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lastWrite.IsZero() {
+		return false
+	}
+	return window <= 0 || now.Sub(c.lastWrite) < window
+}
+
+// WithReadYourWrites returns a context that tracks the writes made with it.
+// When the database has replicas, the reads made with the context shortly
+// after a write are sent to the primary, so that they see the write. Call it
+// at the start of a request or a job, and pass the context to gorm with
+// db.WithContext. See also MakeReadYourWritesMiddleware.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(consistencyKey{}).(*consistency); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, consistencyKey{}, &consistency{})
+}
+
+// MarkWritten records a write in the context created by WithReadYourWrites.
+// The writes made with gorm are recorded automatically. Call it for writes made
+// otherwise, eg. by another service sharing the database.
+func MarkWritten(ctx context.Context) {
+	if c, ok := ctx.Value(consistencyKey{}).(*consistency); ok {
+		c.markWritten(time.Now())
+	}
+}
+
+// MakeReadYourWritesMiddleware creates a HTTP middleware that applies
+// WithReadYourWrites to each request. To span the requests of a session, such
+// as a redirect after a form submission, a cookie is set after a write, so that
+// the reads of the session go to the primary for the window.
+func MakeReadYourWritesMiddleware(window time.Duration) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ctx := WithReadYourWrites(request.Context())
+			c := ctx.Value(consistencyKey{}).(*consistency)
+			if cookie, err := request.Cookie(writtenCookie); err == nil {
+				if nano, err := strconv.ParseInt(cookie.Value, 10, 64); err == nil {
+					c.markWritten(time.Unix(0, nano))
+				}
+			}
+			start := time.Now()
+			handler.ServeHTTP(&writtenCookieWriter{
+				ResponseWriter: writer,
+				consistency:    c,
+				start:          start,
+				window:         window,
+			}, request.WithContext(ctx))
+		})
+	}
+}
+
+// writtenCookieWriter sets the cookie before the headers are written, if a
+// write is made during the request.
+type writtenCookieWriter struct {
+	http.ResponseWriter
+	consistency *consistency
+	start       time.Time
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (w *writtenCookieWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.consistency.mu.Lock()
+		lastWrite := w.consistency.lastWrite
+		w.consistency.mu.Unlock()
+		if !lastWrite.Before(w.start) && w.window > 0 {
+			http.SetCookie(w.ResponseWriter, &http.Cookie{
+				Name:     writtenCookie,
+				Value:    strconv.FormatInt(lastWrite.UnixNano(), 10),
+				Path:     "/",
+				MaxAge:   int(w.window.Seconds()) + 1,
+				HttpOnly: true,
+			})
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *writtenCookieWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Resolver is a gorm.Plugin that splits the reads and writes. The queries, such
+// as Find and First, are sent to the replicas in turn, and everything else to
+// the primary, including Raw and Rows, which the migrator relies on. The
+// queries within transactions go to the primary, and so do the ones made with
+// a context of WithReadYourWrites within the window after a write. A window of
+// 0 pins the reads to the primary for the rest of the context after a write.
+//
+// The factory registers the Resolver for databases with replicas configured.
+type Resolver struct {
+	Replicas []gorm.ConnPool
+	Window   time.Duration
+
+	next uint32
+}
+
+// Name implements gorm.Plugin
+func (r *Resolver) Name() string {
+	return "otgorm:resolver"
+}
+
+// Initialize implements gorm.Plugin
+func (r *Resolver) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("otgorm:resolver", r.route); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("otgorm:resolver", r.markWritten); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("otgorm:resolver", r.markWritten); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Register("otgorm:resolver", r.markWritten); err != nil {
+		return err
+	}
+	return db.Callback().Raw().After("gorm:raw").Register("otgorm:resolver", r.markWritten)
+}
+
+func (r *Resolver) route(db *gorm.DB) {
+	if len(r.Replicas) == 0 {
+		return
+	}
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if ctx := db.Statement.Context; ctx != nil {
+		if c, ok := ctx.Value(consistencyKey{}).(*consistency); ok && c.written(r.Window, time.Now()) {
+			return
+		}
+	}
+	i := atomic.AddUint32(&r.next, 1)
+	db.Statement.ConnPool = r.Replicas[int(i)%len(r.Replicas)]
+}
+
+func (r *Resolver) markWritten(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return
+	}
+	MarkWritten(db.Statement.Context)
+}
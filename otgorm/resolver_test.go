@@ -0,0 +1,91 @@
+package otgorm
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type resolverModel struct {
+	ID   uint
+	Name string
+}
+
+func TestResolver(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "resolver")
+	defer os.RemoveAll(dir)
+	primaryDSN, replicaDSN := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+
+	factory, cleanup := provideDBFactory(factoryIn{
+		Conf: config.MapAdapter{"gorm": map[string]databaseConf{
+			"default": {
+				Database:             "sqlite",
+				Dsn:                  primaryDSN,
+				Replicas:             []string{replicaDSN},
+				ReadYourWritesWindow: config.Duration{Duration: time.Hour},
+			},
+			"replica": {Database: "sqlite", Dsn: replicaDSN},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	db, err := factory.Make("default")
+	assert.NoError(t, err)
+	replica, err := factory.Make("replica")
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&resolverModel{}))
+	assert.NoError(t, replica.AutoMigrate(&resolverModel{}))
+
+	ctx := WithReadYourWrites(context.Background())
+	assert.NoError(t, db.WithContext(ctx).Create(&resolverModel{Name: "alice"}).Error)
+
+	var count int64
+	db.Model(&resolverModel{}).Count(&count)
+	assert.Equal(t, int64(0), count, "reads go to the replica")
+
+	db.WithContext(ctx).Model(&resolverModel{}).Count(&count)
+	assert.Equal(t, int64(1), count, "reads after a write go to the primary")
+
+	db.Transaction(func(tx *gorm.DB) error {
+		tx.Model(&resolverModel{}).Count(&count)
+		return nil
+	})
+	assert.Equal(t, int64(1), count, "reads in transactions go to the primary")
+}
+
+func TestMakeReadYourWritesMiddleware(t *testing.T) {
+	var pinned bool
+	handler := MakeReadYourWritesMiddleware(time.Minute)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		c := request.Context().Value(consistencyKey{}).(*consistency)
+		pinned = c.written(time.Minute, time.Now())
+		if request.Method == http.MethodPost {
+			MarkWritten(request.Context())
+		}
+		writer.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.False(t, pinned)
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 1)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, pinned)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, pinned)
+}
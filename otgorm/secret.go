@@ -0,0 +1,59 @@
+package otgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/DoNewsCode/core/contract"
+)
+
+// secretPattern matches the secret placeholders in DSN, eg.
+// "root:${secret:db#password}@tcp(127.0.0.1:3306)/app".
+var secretPattern = regexp.MustCompile(`\$\{secret:([^}]+)\}`)
+
+var errRotated = errors.New("secret rotated")
+
+// resolveDSN replaces the secret placeholders in the dsn with the values from
+// the store. It returns the referenced secrets as well.
+func resolveDSN(ctx context.Context, dsn string, store contract.SecretStore) (string, []string, error) {
+	matches := secretPattern.FindAllStringSubmatch(dsn, -1)
+	if len(matches) == 0 {
+		return dsn, nil, nil
+	}
+	if store == nil {
+		return "", nil, fmt.Errorf("dsn references secrets, but contract.SecretStore is not provided")
+	}
+	var (
+		refs   []string
+		values = make(map[string]string, len(matches))
+	)
+	for _, match := range matches {
+		ref := match[1]
+		if _, ok := values[ref]; ok {
+			continue
+		}
+		value, err := store.Get(ctx, ref)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to resolve secret %s: %w", ref, err)
+		}
+		values[ref] = string(value)
+		refs = append(refs, ref)
+	}
+	return secretPattern.ReplaceAllStringFunc(dsn, func(s string) string {
+		return values[secretPattern.FindStringSubmatch(s)[1]]
+	}), refs, nil
+}
+
+// watchSecrets calls rotate once any of the secrets changes, until the context
+// is canceled.
+func watchSecrets(ctx context.Context, store contract.SecretStore, refs []string, rotate func(ref string)) {
+	for _, ref := range refs {
+		ref := ref
+		go store.Watch(ctx, ref, func(value []byte) error {
+			rotate(ref)
+			return errRotated
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package otkafka
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/DoNewsCode/core/boot"
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
@@ -18,6 +20,7 @@ Providers is a set of dependencies including ReaderMaker, WriterMaker and export
 	Depends On:
 		ReaderInterceptor `optional:"true"`
 		WriterInterceptor `optional:"true"`
+		[]di.Interceptor `group:"interceptor"`
 		contract.ConfigAccessor
 		log.Logger
 	Provide:
@@ -29,9 +32,10 @@ Providers is a set of dependencies including ReaderMaker, WriterMaker and export
 		*kafka.Writer
 		*readerCollector
 		*writerCollector
+		boot.Kind "kafka"
 */
 func Providers() []interface{} {
-	return []interface{}{provideKafkaFactory, provideConfig}
+	return []interface{}{provideKafkaFactory, provideConfig, provideBootKind}
 }
 
 // WriterMaker models a WriterFactory
@@ -50,6 +54,7 @@ type factoryIn struct {
 
 	ReaderInterceptor ReaderInterceptor  `optional:"true"`
 	WriterInterceptor WriterInterceptor  `optional:"true"`
+	Interceptors      []di.Interceptor   `group:"interceptor"`
 	Tracer            opentracing.Tracer `optional:"true"`
 	Conf              contract.ConfigAccessor
 	Logger            log.Logger
@@ -129,9 +134,23 @@ func provideReaderFactory(p factoryIn) (ReaderFactory, func()) {
 		conf := fromReaderConfig(readerConfig)
 		conf.Logger = KafkaLogAdapter{Logging: level.Debug(p.Logger)}
 		conf.ErrorLogger = KafkaLogAdapter{Logging: level.Warn(p.Logger)}
-		if p.WriterInterceptor != nil {
+		if p.ReaderInterceptor != nil {
 			p.ReaderInterceptor(name, &conf)
 		}
+		err = di.Interceptors(p.Interceptors).Apply("kafka.reader", name, func(fn interface{}) bool {
+			switch fn := fn.(type) {
+			case ReaderInterceptor:
+				fn(name, &conf)
+			case func(name string, reader *kafka.ReaderConfig):
+				fn(name, &conf)
+			default:
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return di.Pair{}, err
+		}
 		client := kafka.NewReader(conf)
 		return di.Pair{
 			Conn: client,
@@ -139,7 +158,7 @@ func provideReaderFactory(p factoryIn) (ReaderFactory, func()) {
 				_ = client.Close()
 			},
 		}, nil
-	})
+	}, di.WithKind("kafka.reader"))
 	return ReaderFactory{factory}, factory.Close
 }
 
@@ -164,6 +183,20 @@ func provideWriterFactory(p factoryIn) (WriterFactory, func()) {
 		if p.WriterInterceptor != nil {
 			p.WriterInterceptor(name, &writer)
 		}
+		err = di.Interceptors(p.Interceptors).Apply("kafka.writer", name, func(fn interface{}) bool {
+			switch fn := fn.(type) {
+			case WriterInterceptor:
+				fn(name, &writer)
+			case func(name string, writer *kafka.Writer):
+				fn(name, &writer)
+			default:
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return di.Pair{}, err
+		}
 
 		return di.Pair{
 			Conn: &writer,
@@ -171,10 +204,38 @@ func provideWriterFactory(p factoryIn) (WriterFactory, func()) {
 				_ = writer.Close()
 			},
 		}, nil
-	})
+	}, di.WithKind("kafka.writer"))
 	return WriterFactory{factory}, factory.Close
 }
 
+// provideBootKind provides the "kafka" kind of boot gates, which connects to
+// the brokers of the writer entry, or of the reader entry if there is no such
+// writer.
+func provideBootKind(conf contract.ConfigAccessor) boot.KindOut {
+	return boot.KindOut{Kind: boot.Kind{
+		Name: "kafka",
+		Probe: func(ctx context.Context, entry string) error {
+			var brokers []string
+			if err := conf.Unmarshal(fmt.Sprintf("kafka.writer.%s.brokers", entry), &brokers); err != nil || len(brokers) == 0 {
+				if err := conf.Unmarshal(fmt.Sprintf("kafka.reader.%s.brokers", entry), &brokers); err != nil {
+					return err
+				}
+			}
+			if len(brokers) == 0 {
+				return fmt.Errorf("kafka configuration %s has no brokers", entry)
+			}
+			var err error
+			for _, broker := range brokers {
+				var conn *kafka.Conn
+				if conn, err = kafka.DialContext(ctx, "tcp", broker); err == nil {
+					return conn.Close()
+				}
+			}
+			return err
+		},
+	}}
+}
+
 type metricsConf struct {
 	Interval config.Duration `json:"interval" yaml:"interval"`
 }
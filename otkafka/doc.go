@@ -38,5 +38,28 @@ writers/readers are provided by github.com/segmentio/kafka-go.
 		writer.WriteMessage(kafka.Message{})
 	})
 
+Offset Management
+
+With the module added by c.AddModuleFunc(otkafka.New), the offsets of the
+consumer group in a reader configuration can be listed or reset:
+
+	go run main.go kafka offsets list default
+	go run main.go kafka offsets reset default --to 2021-06-01T00:00:00Z
+
+The target of reset is "earliest", "latest" or a RFC3339 timestamp. Stop the
+consumers of the group before the reset.
+
+Message Replay
+
+The messages of the topic in a reader configuration can be replayed to the topic
+in a writer configuration, for example to recover after a consumer bug:
+
+	go run main.go kafka replay default replay --since 2021-06-01T00:00:00Z --key '^order-'
+
+Replayed messages carry the x-replay headers with the time of the replay and the
+topic, partition and offset of the original message. Use IsReplay to tell them
+apart in consumers. When a tracer is provided, each replayed message starts a
+span following from the span of the original message.
+
 */
 package otkafka
@@ -2,7 +2,9 @@ package otkafka
 
 import (
 	"context"
+	"strings"
 
+	"github.com/DoNewsCode/core/timeout"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/segmentio/kafka-go"
@@ -28,6 +30,28 @@ func SpanFromMessage(ctx context.Context, tracer opentracing.Tracer, message *ka
 	return span, opentracing.ContextWithSpan(ctx, span), nil
 }
 
+// DeadlineFromMessage applies the deadline propagated by the producer in
+// timeout.DeadlineHeader to the context. The earlier deadline wins if the
+// context already has one. Consumers can then drop the messages nobody is
+// waiting for anymore:
+//
+//	ctx, cancel := otkafka.DeadlineFromMessage(ctx, &message)
+//	defer cancel()
+//	if ctx.Err() != nil {
+//		return nil
+//	}
+func DeadlineFromMessage(ctx context.Context, message *kafka.Message) (context.Context, context.CancelFunc) {
+	for _, h := range message.Headers {
+		if !strings.EqualFold(h.Key, timeout.DeadlineHeader) {
+			continue
+		}
+		if deadline, err := timeout.ParseDeadline(string(h.Value)); err == nil {
+			return context.WithDeadline(ctx, deadline)
+		}
+	}
+	return context.WithCancel(ctx)
+}
+
 func getCarrier(msg *kafka.Message) opentracing.TextMapCarrier {
 
 	var mapCarrier = make(opentracing.TextMapCarrier)
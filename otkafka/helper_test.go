@@ -3,7 +3,9 @@ package otkafka
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/DoNewsCode/core/timeout"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
@@ -14,3 +16,18 @@ func TestHelper_no_parent(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Zero(t, span.(*mocktracer.MockSpan).ParentID)
 }
+
+func TestDeadlineFromMessage(t *testing.T) {
+	ctx, cancel := DeadlineFromMessage(context.Background(), &kafka.Message{})
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	deadline := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	message := &kafka.Message{Headers: []kafka.Header{{Key: "x-request-deadline", Value: []byte(timeout.FormatDeadline(deadline))}}}
+	ctx, cancel = DeadlineFromMessage(context.Background(), message)
+	defer cancel()
+	d, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, deadline.Equal(d))
+}
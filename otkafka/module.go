@@ -2,12 +2,21 @@ package otkafka
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
 	"time"
 
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/logging"
 	"github.com/go-kit/kit/log"
 	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/segmentio/kafka-go"
+	"github.com/spf13/cobra"
 )
 
 const defaultInterval = 15 * time.Second
@@ -23,6 +32,8 @@ type Module struct {
 	writerCollector *writerCollector
 	interval        time.Duration
 	dispatcher      contract.Dispatcher
+	conf            contract.ConfigAccessor
+	tracer          opentracing.Tracer
 }
 
 // ModuleIn contains the input parameters needed for creating the new module.
@@ -38,6 +49,7 @@ type ModuleIn struct {
 	WriterCollector *writerCollector
 	Conf            contract.ConfigAccessor
 	Dispatcher      contract.Dispatcher `optional:"true"`
+	Tracer          opentracing.Tracer  `optional:"true"`
 }
 
 // New creates a Module.
@@ -54,16 +66,190 @@ func New(in ModuleIn) Module {
 		writerCollector: in.WriterCollector,
 		interval:        duration,
 		dispatcher:      in.Dispatcher,
+		conf:            in.Conf,
+		tracer:          in.Tracer,
 	}
 	if m.canHotReloadReader() {
-		m.readerMaker.(ReaderFactory).SubscribeReloadEventFrom(m.dispatcher)
+		m.readerMaker.(ReaderFactory).SubscribeKeyChangedEventFrom(m.dispatcher, "kafka.reader")
 	}
 	if m.canHotReloadWriter() {
-		m.writerMaker.(WriterFactory).SubscribeReloadEventFrom(m.dispatcher)
+		m.writerMaker.(WriterFactory).SubscribeKeyChangedEventFrom(m.dispatcher, "kafka.writer")
 	}
 	return m
 }
 
+// ProvideCommand provides the "kafka offsets" commands, which list or reset the
+// offsets of the consumer group in a reader configuration, and the "kafka replay"
+// command, which replays the messages of a reader configuration to a writer.
+func (m Module) ProvideCommand(command *cobra.Command) {
+	var (
+		force  bool
+		target string
+		logger = logging.WithLevel(m.logger)
+	)
+	readerConfig := func(args []string) (ReaderConfig, error) {
+		var name = "default"
+		if len(args) > 0 {
+			name = args[0]
+		}
+		var conf ReaderConfig
+		if err := m.conf.Unmarshal(fmt.Sprintf("kafka.reader.%s", name), &conf); err != nil {
+			return conf, fmt.Errorf("kafka reader configuration %s not valid: %w", name, err)
+		}
+		return conf, nil
+	}
+
+	var listCmd = &cobra.Command{
+		Use:   "list [reader]",
+		Short: "List the offsets of a consumer group",
+		Long:  `List the committed offsets and lags of the consumer group in the reader configuration.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := readerConfig(args)
+			if err != nil {
+				return err
+			}
+			offsets, err := ListOffsets(cmd.Context(), conf)
+			if err != nil {
+				return err
+			}
+			printOffsets(cmd.OutOrStdout(), offsets)
+			return nil
+		},
+	}
+
+	var resetCmd = &cobra.Command{
+		Use:   "reset [reader]",
+		Short: "Reset the offsets of a consumer group",
+		Long:  `Reset the offsets of the consumer group in the reader configuration to the earliest, the latest or a point in time. The consumers of the group must be stopped.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if m.env.IsProduction() && !force {
+				return fmt.Errorf("resetting offsets in production requires force flag to be set")
+			}
+			conf, err := readerConfig(args)
+			if err != nil {
+				return err
+			}
+			offsets, err := ResetOffsets(cmd.Context(), conf, target)
+			if err != nil {
+				return err
+			}
+			printOffsets(cmd.OutOrStdout(), offsets)
+			logger.Info(fmt.Sprintf("offsets of group %s reset to %s", conf.GroupID, target))
+			return nil
+		},
+	}
+	resetCmd.Flags().StringVarP(&target, "to", "t", "latest", "earliest, latest or a RFC3339 timestamp, eg. 2021-06-01T00:00:00Z")
+	resetCmd.Flags().BoolVarP(&force, "force", "f", false, "resetting offsets in production requires force flag to be set")
+
+	var offsetsCmd = &cobra.Command{
+		Use:   "offsets",
+		Short: "manage consumer group offsets",
+	}
+	offsetsCmd.AddCommand(listCmd, resetCmd)
+
+	var (
+		since, until string
+		replayOpts   ReplayOptions
+		keyPattern   string
+	)
+	var replayCmd = &cobra.Command{
+		Use:   "replay reader writer",
+		Short: "Replay messages to another topic",
+		Long:  `Replay the messages of the topic in the reader configuration to the writer configuration, within a time or offset range. Replayed messages carry the x-replay headers.`,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if m.env.IsProduction() && !force {
+				return fmt.Errorf("replaying messages in production requires force flag to be set")
+			}
+			conf, err := readerConfig(args[:1])
+			if err != nil {
+				return err
+			}
+			opts := replayOpts
+			if opts.Since, err = parseReplayTime(since); err != nil {
+				return err
+			}
+			if opts.Until, err = parseReplayTime(until); err != nil {
+				return err
+			}
+			if keyPattern != "" {
+				re, err := regexp.Compile(keyPattern)
+				if err != nil {
+					return fmt.Errorf("the key pattern is not valid: %w", err)
+				}
+				opts.Filter = func(message kafka.Message) bool {
+					return re.Match(message.Key)
+				}
+			}
+			opts.Tracer = m.tracer
+			writer, err := m.writerMaker.Make(args[1])
+			if err != nil {
+				return err
+			}
+			replayed, err := Replay(cmd.Context(), conf, writer, opts)
+			printReplayed(cmd.OutOrStdout(), replayed)
+			if err != nil {
+				return err
+			}
+			logger.Info(fmt.Sprintf("messages of topic %s replayed to %s", conf.Topic, args[1]))
+			return nil
+		},
+	}
+	replayCmd.Flags().StringVar(&since, "since", "", "replay messages produced at or after the RFC3339 timestamp")
+	replayCmd.Flags().StringVar(&until, "until", "", "replay messages produced before the RFC3339 timestamp")
+	replayCmd.Flags().Int64Var(&replayOpts.StartOffset, "start-offset", 0, "replay messages at or after the offset in each partition")
+	replayCmd.Flags().Int64Var(&replayOpts.EndOffset, "end-offset", 0, "replay messages before the offset in each partition")
+	replayCmd.Flags().IntSliceVarP(&replayOpts.Partitions, "partition", "p", nil, "partitions to replay, all if not set")
+	replayCmd.Flags().StringVarP(&keyPattern, "key", "k", "", "replay only messages whose key matches the regular expression")
+	replayCmd.Flags().IntVar(&replayOpts.BatchSize, "batch-size", defaultReplayBatchSize, "number of messages written at a time")
+	replayCmd.Flags().BoolVarP(&force, "force", "f", false, "replaying messages in production requires force flag to be set")
+
+	var kafkaCmd = &cobra.Command{
+		Use:   "kafka",
+		Short: "manage kafka",
+		Long:  "manage kafka, such as the offsets of consumer groups and message replays",
+	}
+	kafkaCmd.AddCommand(offsetsCmd, replayCmd)
+	command.AddCommand(kafkaCmd)
+}
+
+func printOffsets(out io.Writer, offsets []PartitionOffset) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tCOMMITTED\tFIRST\tLAST\tLAG")
+	for _, p := range offsets {
+		committed := "-"
+		if p.Committed >= 0 {
+			committed = strconv.FormatInt(p.Committed, 10)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\n", p.Partition, committed, p.First, p.Last, p.Lag())
+	}
+	w.Flush()
+}
+
+func printReplayed(out io.Writer, replayed []ReplayedPartition) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tSTART\tEND\tREPLAYED")
+	for _, p := range replayed {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\n", p.Partition, p.Start, p.End, p.Replayed)
+	}
+	w.Flush()
+}
+
+// parseReplayTime parses the time bound of a replay. An empty string is
+// unbounded.
+func parseReplayTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("the time must be a RFC3339 timestamp, got %s", s)
+	}
+	return t, nil
+}
+
 // ProvideRunGroup add a goroutine to periodically scan kafka's reader&writer info and
 // report them to metrics collector such as prometheus.
 func (m Module) ProvideRunGroup(group *run.Group) {
@@ -0,0 +1,160 @@
+package otkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// PartitionOffset is the position of a consumer group in a partition.
+type PartitionOffset struct {
+	Partition int
+	// Committed is the committed offset of the consumer group, or -1 if the
+	// group has not committed any.
+	Committed int64
+	// First and Last are the offsets of the first message and after the last
+	// message in the partition.
+	First int64
+	Last  int64
+}
+
+// Lag is the number of messages the consumer group has not consumed.
+func (p PartitionOffset) Lag() int64 {
+	if p.Committed < 0 {
+		return p.Last - p.First
+	}
+	return p.Last - p.Committed
+}
+
+// ListOffsets lists the offsets of the consumer group in every partition of
+// the topic, both taken from the reader configuration.
+func ListOffsets(ctx context.Context, conf ReaderConfig) ([]PartitionOffset, error) {
+	if conf.GroupID == "" || conf.Topic == "" {
+		return nil, errors.New("the reader configuration needs both groupID and topic")
+	}
+	client := &kafka.Client{Addr: kafka.TCP(conf.Brokers...)}
+
+	committed, err := client.ConsumerOffsets(ctx, kafka.TopicAndGroup{Topic: conf.Topic, GroupId: conf.GroupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the offsets of group %s: %w", conf.GroupID, err)
+	}
+	var requests []kafka.OffsetRequest
+	for partition := range committed {
+		requests = append(requests, kafka.FirstOffsetOf(partition), kafka.LastOffsetOf(partition))
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{conf.Topic: requests}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the offsets of topic %s: %w", conf.Topic, err)
+	}
+
+	offsets := make([]PartitionOffset, 0, len(committed))
+	for _, p := range resp.Topics[conf.Topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list the offsets of partition %d: %w", p.Partition, p.Error)
+		}
+		offsets = append(offsets, PartitionOffset{
+			Partition: p.Partition,
+			Committed: committed[p.Partition],
+			First:     p.FirstOffset,
+			Last:      p.LastOffset,
+		})
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i].Partition < offsets[j].Partition
+	})
+	return offsets, nil
+}
+
+// ResetOffsets commits new offsets for the consumer group in every partition of
+// the topic. The target is "earliest", "latest" or a RFC3339 timestamp, in which
+// case the offset is the first message at or after the time. The consumer group
+// must have no active members, so stop the consumers before the reset.
+func ResetOffsets(ctx context.Context, conf ReaderConfig, target string) ([]PartitionOffset, error) {
+	timestamp, err := parseResetTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := ListOffsets(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &kafka.Client{Addr: kafka.TCP(conf.Brokers...)}
+	var requests []kafka.OffsetRequest
+	for _, p := range offsets {
+		requests = append(requests, kafka.OffsetRequest{Partition: p.Partition, Timestamp: timestamp})
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{conf.Topic: requests}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the offsets of topic %s: %w", conf.Topic, err)
+	}
+	targets := make(map[int]int64)
+	for _, p := range resp.Topics[conf.Topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list the offsets of partition %d: %w", p.Partition, p.Error)
+		}
+		targets[p.Partition] = targetOffset(p, timestamp)
+	}
+	for i := range offsets {
+		if targets[offsets[i].Partition] < 0 {
+			// No message after the timestamp.
+			targets[offsets[i].Partition] = offsets[i].Last
+		}
+		offsets[i].Committed = targets[offsets[i].Partition]
+	}
+
+	group, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:      conf.GroupID,
+		Brokers: conf.Brokers,
+		Topics:  []string{conf.Topic},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer group.Close()
+
+	generation, err := group.Next(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join group %s: %w", conf.GroupID, err)
+	}
+	if len(generation.Assignments[conf.Topic]) != len(offsets) {
+		return nil, fmt.Errorf("group %s has active members, stop the consumers before the reset", conf.GroupID)
+	}
+	if err := generation.CommitOffsets(map[string]map[int]int64{conf.Topic: targets}); err != nil {
+		return nil, fmt.Errorf("failed to commit the offsets of group %s: %w", conf.GroupID, err)
+	}
+	return offsets, nil
+}
+
+// parseResetTarget converts the target to the timestamp of a kafka offset
+// request.
+func parseResetTarget(target string) (int64, error) {
+	switch target {
+	case "earliest":
+		return kafka.FirstOffset, nil
+	case "latest":
+		return kafka.LastOffset, nil
+	}
+	t, err := time.Parse(time.RFC3339, target)
+	if err != nil {
+		return 0, fmt.Errorf("the target must be earliest, latest or a RFC3339 timestamp, got %s", target)
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
+
+func targetOffset(p kafka.PartitionOffsets, timestamp int64) int64 {
+	switch timestamp {
+	case kafka.FirstOffset:
+		return p.FirstOffset
+	case kafka.LastOffset:
+		return p.LastOffset
+	}
+	for offset := range p.Offsets {
+		return offset
+	}
+	return -1
+}
@@ -0,0 +1,63 @@
+package otkafka
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResetTarget(t *testing.T) {
+	t.Parallel()
+	timestamp, err := parseResetTarget("earliest")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(kafka.FirstOffset), timestamp)
+
+	timestamp, err = parseResetTarget("latest")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(kafka.LastOffset), timestamp)
+
+	timestamp, err = parseResetTarget("2021-06-01T00:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC).UnixNano()/int64(time.Millisecond), timestamp)
+
+	_, err = parseResetTarget("yesterday")
+	assert.Error(t, err)
+}
+
+func TestPartitionOffset_Lag(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, int64(3), PartitionOffset{Committed: 7, First: 2, Last: 10}.Lag())
+	assert.Equal(t, int64(8), PartitionOffset{Committed: -1, First: 2, Last: 10}.Lag())
+}
+
+func TestResetOffsets(t *testing.T) {
+	if os.Getenv("KAFKA_ADDR") == "" {
+		t.Skip("set KAFKA_ADDR to run TestResetOffsets")
+	}
+	brokers := strings.Split(os.Getenv("KAFKA_ADDR"), ",")
+	conf := ReaderConfig{Brokers: brokers, GroupID: "offsets", Topic: "test"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	writer := kafka.Writer{Addr: kafka.TCP(brokers...), Topic: "test"}
+	defer writer.Close()
+	assert.NoError(t, writer.WriteMessages(ctx, kafka.Message{Value: []byte("offsets")}))
+
+	offsets, err := ResetOffsets(ctx, conf, "latest")
+	assert.NoError(t, err)
+	assert.Len(t, offsets, 1)
+	assert.Equal(t, offsets[0].Last, offsets[0].Committed)
+
+	offsets, err = ResetOffsets(ctx, conf, "earliest")
+	assert.NoError(t, err)
+	assert.Equal(t, offsets[0].First, offsets[0].Committed)
+
+	offsets, err = ListOffsets(ctx, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, offsets[0].First, offsets[0].Committed)
+}
@@ -127,7 +127,8 @@ type ReaderConfig struct {
 }
 
 // ReaderInterceptor is an interceptor that makes last minute change to a *kafka.ReaderConfig
-// during kafka.Reader's creation
+// during kafka.Reader's creation. Besides the single optional one, any number of
+// them can be registered as di.Interceptor of the kind "kafka.reader".
 type ReaderInterceptor func(name string, reader *kafka.ReaderConfig)
 
 func fromReaderConfig(conf ReaderConfig) kafka.ReaderConfig {
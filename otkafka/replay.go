@@ -0,0 +1,318 @@
+package otkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DoNewsCode/core/timeout"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/segmentio/kafka-go"
+)
+
+// The headers added to every replayed message, so that consumers can tell
+// replays from the original messages.
+const (
+	// ReplayHeader holds the RFC3339 time at which the replay started.
+	ReplayHeader = "x-replay"
+	// ReplayTopicHeader holds the topic of the original message.
+	ReplayTopicHeader = "x-replay-topic"
+	// ReplayPartitionHeader holds the partition of the original message.
+	ReplayPartitionHeader = "x-replay-partition"
+	// ReplayOffsetHeader holds the offset of the original message.
+	ReplayOffsetHeader = "x-replay-offset"
+)
+
+const defaultReplayBatchSize = 100
+
+// ReplayOptions selects the messages to replay. The zero value replays every
+// message in every partition.
+type ReplayOptions struct {
+	// Partitions to replay. Empty means all partitions of the topic.
+	Partitions []int
+	// Since and Until limit the replay to messages produced at or after Since
+	// and before Until. Zero values are unbounded.
+	Since time.Time
+	Until time.Time
+	// StartOffset and EndOffset limit the replay to messages at or after
+	// StartOffset and before EndOffset in each partition. Zero values are
+	// unbounded.
+	StartOffset int64
+	EndOffset   int64
+	// Filter reports whether the message should be replayed. Nil replays all.
+	Filter func(message kafka.Message) bool
+	// Tracer, if set, starts a span for every replayed message that follows
+	// from the span of the original message, and injects it into the headers.
+	Tracer opentracing.Tracer
+	// BatchSize is the number of messages written at a time. Defaults to 100.
+	BatchSize int
+}
+
+// ReplayedPartition is the result of replaying a partition.
+type ReplayedPartition struct {
+	Partition int
+	// Start and End are the range of offsets read, End exclusive.
+	Start int64
+	End   int64
+	// Replayed is the number of messages written, after filtering.
+	Replayed int
+}
+
+// Replay reads the messages of the topic in the reader configuration within
+// the range of the options, and writes them to the writer with the replay
+// headers added. The consumer group of the reader configuration is neither
+// used nor committed.
+func Replay(ctx context.Context, conf ReaderConfig, writer *kafka.Writer, opts ReplayOptions) ([]ReplayedPartition, error) {
+	if conf.Topic == "" {
+		return nil, errors.New("the reader configuration needs a topic")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultReplayBatchSize
+	}
+	ranges, err := replayRanges(ctx, conf, opts)
+	if err != nil {
+		return nil, err
+	}
+	replayedAt := time.Now().UTC().Format(time.RFC3339)
+	for i := range ranges {
+		if ranges[i].Start >= ranges[i].End {
+			continue
+		}
+		if ranges[i].Replayed, err = replayPartition(ctx, conf, writer, opts, ranges[i], replayedAt); err != nil {
+			return ranges, fmt.Errorf("failed to replay partition %d: %w", ranges[i].Partition, err)
+		}
+	}
+	return ranges, nil
+}
+
+// IsReplay reports whether the message is written by Replay.
+func IsReplay(message *kafka.Message) bool {
+	for _, h := range message.Headers {
+		if h.Key == ReplayHeader {
+			return true
+		}
+	}
+	return false
+}
+
+// replayRanges finds the offsets to read in each partition.
+func replayRanges(ctx context.Context, conf ReaderConfig, opts ReplayOptions) ([]ReplayedPartition, error) {
+	client := &kafka.Client{Addr: kafka.TCP(conf.Brokers...)}
+
+	partitions := opts.Partitions
+	if len(partitions) == 0 {
+		metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{conf.Topic}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the metadata of topic %s: %w", conf.Topic, err)
+		}
+		for _, topic := range metadata.Topics {
+			if topic.Error != nil {
+				return nil, fmt.Errorf("failed to fetch the metadata of topic %s: %w", conf.Topic, topic.Error)
+			}
+			for _, p := range topic.Partitions {
+				partitions = append(partitions, p.ID)
+			}
+		}
+	}
+
+	var requests []kafka.OffsetRequest
+	for _, partition := range partitions {
+		requests = append(requests, kafka.FirstOffsetOf(partition), kafka.LastOffsetOf(partition))
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{conf.Topic: requests}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the offsets of topic %s: %w", conf.Topic, err)
+	}
+	since, err := offsetsAt(ctx, client, conf.Topic, partitions, opts.Since)
+	if err != nil {
+		return nil, err
+	}
+	until, err := offsetsAt(ctx, client, conf.Topic, partitions, opts.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make([]ReplayedPartition, 0, len(partitions))
+	for _, p := range resp.Topics[conf.Topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list the offsets of partition %d: %w", p.Partition, p.Error)
+		}
+		ranges = append(ranges, replayRange(p.Partition, p.FirstOffset, p.LastOffset, since[p.Partition], until[p.Partition], opts))
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Partition < ranges[j].Partition
+	})
+	return ranges, nil
+}
+
+// offsetsAt finds the offset of the first message at or after the time in each
+// partition, or -1 if there is no such message. A zero time yields nil.
+func offsetsAt(ctx context.Context, client *kafka.Client, topic string, partitions []int, t time.Time) (map[int]int64, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	timestamp := t.UnixNano() / int64(time.Millisecond)
+	var requests []kafka.OffsetRequest
+	for _, partition := range partitions {
+		requests = append(requests, kafka.OffsetRequest{Partition: partition, Timestamp: timestamp})
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{topic: requests}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the offsets of topic %s: %w", topic, err)
+	}
+	offsets := make(map[int]int64)
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list the offsets of partition %d: %w", p.Partition, p.Error)
+		}
+		offsets[p.Partition] = targetOffset(p, timestamp)
+	}
+	return offsets, nil
+}
+
+// replayRange narrows the offsets of a partition, from first to last, down to
+// the bounds of the options. since and until are the offsets of the time
+// bounds, -1 if no message is produced after the time.
+func replayRange(partition int, first, last, since, until int64, opts ReplayOptions) ReplayedPartition {
+	r := ReplayedPartition{Partition: partition, Start: first, End: last}
+	if opts.StartOffset > r.Start {
+		r.Start = opts.StartOffset
+	}
+	if opts.EndOffset > 0 && opts.EndOffset < r.End {
+		r.End = opts.EndOffset
+	}
+	if !opts.Since.IsZero() {
+		if since < 0 {
+			since = last
+		}
+		if since > r.Start {
+			r.Start = since
+		}
+	}
+	if !opts.Until.IsZero() && until >= 0 && until < r.End {
+		r.End = until
+	}
+	return r
+}
+
+// replayPartition reads the messages within the range of the partition and
+// writes them in batches. It returns the number of messages written.
+func replayPartition(ctx context.Context, conf ReaderConfig, writer *kafka.Writer, opts ReplayOptions, r ReplayedPartition, replayedAt string) (int, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   conf.Brokers,
+		Topic:     conf.Topic,
+		Partition: r.Partition,
+		MinBytes:  conf.MinBytes,
+		MaxBytes:  conf.MaxBytes,
+	})
+	defer reader.Close()
+	if err := reader.SetOffset(r.Start); err != nil {
+		return 0, err
+	}
+
+	var (
+		replayed int
+		batch    []kafka.Message
+		spans    []opentracing.Span
+	)
+	flush := func() error {
+		defer func() {
+			for _, span := range spans {
+				span.Finish()
+			}
+			batch, spans = batch[:0], spans[:0]
+		}()
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := writer.WriteMessages(ctx, batch...); err != nil {
+			for _, span := range spans {
+				ext.Error.Set(span, true)
+			}
+			return err
+		}
+		replayed += len(batch)
+		return nil
+	}
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return replayed, err
+		}
+		if message.Offset >= r.End {
+			break
+		}
+		if opts.Filter == nil || opts.Filter(message) {
+			replay, span := replayMessage(message, opts.Tracer, replayedAt)
+			batch = append(batch, replay)
+			if span != nil {
+				spans = append(spans, span)
+			}
+		}
+		if len(batch) >= opts.BatchSize {
+			if err := flush(); err != nil {
+				return replayed, err
+			}
+		}
+		// Stop at the last message rather than waiting for the next one.
+		if message.Offset+1 >= r.End {
+			break
+		}
+	}
+	return replayed, flush()
+}
+
+// replayMessage copies the key, value and headers of the original message and
+// adds the replay headers. The propagated deadline is dropped, as it has
+// long expired. If the tracer is not nil, the tracing headers of the
+// original message are replaced by those of a new span.
+func replayMessage(message kafka.Message, tracer opentracing.Tracer, replayedAt string) (kafka.Message, opentracing.Span) {
+	headers := map[string]string{
+		ReplayHeader:          replayedAt,
+		ReplayTopicHeader:     message.Topic,
+		ReplayPartitionHeader: strconv.Itoa(message.Partition),
+		ReplayOffsetHeader:    strconv.FormatInt(message.Offset, 10),
+	}
+	var span opentracing.Span
+	if tracer != nil {
+		var opts []opentracing.StartSpanOption
+		if spanContext, err := tracer.Extract(opentracing.TextMap, getCarrier(&message)); err == nil {
+			opts = append(opts, opentracing.FollowsFrom(spanContext))
+		}
+		span = tracer.StartSpan("kafka replay", opts...)
+		ext.SpanKind.Set(span, ext.SpanKindProducerEnum)
+		span.SetTag("topic", message.Topic)
+		span.SetTag("partition", message.Partition)
+		span.SetTag("offset", message.Offset)
+		carrier := make(opentracing.TextMapCarrier)
+		if err := tracer.Inject(span.Context(), opentracing.TextMap, carrier); err == nil {
+			for k, v := range carrier {
+				headers[k] = v
+			}
+		}
+	}
+
+	replay := kafka.Message{Key: message.Key, Value: message.Value}
+	for _, h := range message.Headers {
+		if strings.EqualFold(h.Key, timeout.DeadlineHeader) {
+			continue
+		}
+		if _, ok := headers[h.Key]; !ok {
+			replay.Headers = append(replay.Headers, h)
+		}
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		replay.Headers = append(replay.Headers, kafka.Header{Key: k, Value: []byte(headers[k])})
+	}
+	return replay, span
+}
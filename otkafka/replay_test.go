@@ -0,0 +1,127 @@
+package otkafka
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/timeout"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayRange(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	cases := []struct {
+		name  string
+		since int64
+		until int64
+		opts  ReplayOptions
+		start int64
+		end   int64
+	}{
+		{"all", 0, 0, ReplayOptions{}, 2, 10},
+		{"offsets", 0, 0, ReplayOptions{StartOffset: 4, EndOffset: 8}, 4, 8},
+		{"offsets out of range", 0, 0, ReplayOptions{StartOffset: 1, EndOffset: 20}, 2, 10},
+		{"time", 5, 7, ReplayOptions{Since: now, Until: now}, 5, 7},
+		{"no message since", -1, -1, ReplayOptions{Since: now}, 10, 10},
+		{"no message until", 3, -1, ReplayOptions{Since: now, Until: now}, 3, 10},
+		{"time and offsets", 5, 9, ReplayOptions{Since: now, Until: now, StartOffset: 6, EndOffset: 8}, 6, 8},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			r := replayRange(1, 2, 10, c.since, c.until, c.opts)
+			assert.Equal(t, ReplayedPartition{Partition: 1, Start: c.start, End: c.end}, r)
+		})
+	}
+}
+
+func TestReplayMessage(t *testing.T) {
+	t.Parallel()
+	tracer := mocktracer.New()
+	original := tracer.StartSpan("original")
+	carrier := make(opentracing.TextMapCarrier)
+	assert.NoError(t, tracer.Inject(original.Context(), opentracing.TextMap, carrier))
+
+	message := kafka.Message{Topic: "foo", Partition: 1, Offset: 42, Key: []byte("key"), Value: []byte("value")}
+	message.Headers = append(message.Headers, kafka.Header{Key: "bar", Value: []byte("baz")})
+	message.Headers = append(message.Headers, kafka.Header{Key: timeout.DeadlineHeader, Value: []byte("2021-06-01T00:00:01Z")})
+	for k, v := range carrier {
+		message.Headers = append(message.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	replay, span := replayMessage(message, tracer, "2021-06-01T00:00:00Z")
+	span.Finish()
+	assert.True(t, IsReplay(&replay))
+	assert.False(t, IsReplay(&message))
+	assert.Equal(t, message.Key, replay.Key)
+	assert.Equal(t, message.Value, replay.Value)
+
+	headers := getCarrier(&replay)
+	assert.Equal(t, "baz", headers["bar"])
+	assert.Equal(t, "2021-06-01T00:00:00Z", headers[ReplayHeader])
+	assert.Equal(t, "foo", headers[ReplayTopicHeader])
+	assert.Equal(t, "1", headers[ReplayPartitionHeader])
+	assert.Equal(t, "42", headers[ReplayOffsetHeader])
+	assert.NotContains(t, headers, timeout.DeadlineHeader, "the expired deadline should be dropped")
+	assert.Len(t, replay.Headers, len(headers), "tracing headers should be replaced")
+
+	spanContext, err := tracer.Extract(opentracing.TextMap, headers)
+	assert.NoError(t, err)
+	assert.Equal(t, span.Context().(mocktracer.MockSpanContext).SpanID, spanContext.(mocktracer.MockSpanContext).SpanID)
+	assert.Equal(t, original.Context().(mocktracer.MockSpanContext).SpanID, span.(*mocktracer.MockSpan).ParentID)
+
+	replay, span = replayMessage(message, nil, "2021-06-01T00:00:00Z")
+	assert.Nil(t, span)
+	assert.True(t, IsReplay(&replay))
+}
+
+func TestParseReplayTime(t *testing.T) {
+	t.Parallel()
+	tm, err := parseReplayTime("")
+	assert.NoError(t, err)
+	assert.True(t, tm.IsZero())
+
+	tm, err = parseReplayTime("2021-06-01T00:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), tm)
+
+	_, err = parseReplayTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestReplay(t *testing.T) {
+	if os.Getenv("KAFKA_ADDR") == "" {
+		t.Skip("set KAFKA_ADDR to run TestReplay")
+	}
+	brokers := strings.Split(os.Getenv("KAFKA_ADDR"), ",")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	writer := kafka.Writer{Addr: kafka.TCP(brokers...), Topic: "test"}
+	defer writer.Close()
+	assert.NoError(t, writer.WriteMessages(ctx,
+		kafka.Message{Key: []byte("keep"), Value: []byte("replay")},
+		kafka.Message{Key: []byte("drop"), Value: []byte("replay")},
+	))
+	offsets, err := ListOffsets(ctx, ReaderConfig{Brokers: brokers, GroupID: "replay", Topic: "test"})
+	assert.NoError(t, err)
+
+	replayWriter := kafka.Writer{Addr: kafka.TCP(brokers...), Topic: "replay"}
+	defer replayWriter.Close()
+	replayed, err := Replay(ctx, ReaderConfig{Brokers: brokers, Topic: "test"}, &replayWriter, ReplayOptions{
+		StartOffset: offsets[0].Last - 2,
+		Filter: func(message kafka.Message) bool {
+			return string(message.Key) == "keep"
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, replayed, 1)
+	assert.Equal(t, 1, replayed[0].Replayed)
+}
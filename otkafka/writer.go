@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/DoNewsCode/core/timeout"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/opentracing/opentracing-go"
@@ -43,7 +44,8 @@ func Trace(writer *kafka.Writer, tracer opentracing.Tracer, opts ...WriterOption
 // WriteMessages writes a batch of messages to the kafka topic configured on this
 // writer. Each message written has been injected tracing headers. The upstream
 // consumer can extract tracing spans from kafka headers, forming a distributed
-// tracing via messaging.
+// tracing via messaging. If the context has a deadline, it is injected as
+// timeout.DeadlineHeader too, see DeadlineFromMessage.
 func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
 	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, w.tracer, "kafka writer")
 	defer span.Finish()
@@ -58,6 +60,10 @@ func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error
 		_ = level.Debug(w.logger).Log("msg", "trace injected")
 	}
 
+	if deadline, ok := ctx.Deadline(); ok {
+		carrier[timeout.DeadlineHeader] = timeout.FormatDeadline(deadline)
+	}
+
 	for i := range msgs {
 		for k := range carrier {
 			var header kafka.Header
@@ -7,7 +7,8 @@ import (
 )
 
 // WriterInterceptor is an interceptor that makes last minute change to a
-// *kafka.Writer during its creation
+// *kafka.Writer during its creation. Besides the single optional one, any number
+// of them can be registered as di.Interceptor of the kind "kafka.writer".
 type WriterInterceptor func(name string, writer *kafka.Writer)
 
 // WriterConfig is a configuration type used to create new instances of Writer.
@@ -82,6 +83,14 @@ type WriterConfig struct {
 	Async bool `json:"async" yaml:"async"`
 }
 
+// NewWriter creates a *kafka.Writer from the WriterConfig. Unlike the writers
+// made by WriterFactory, it has no loggers, no tracing and no interceptors, and
+// it is not shared. The caller is responsible for closing it.
+func NewWriter(conf WriterConfig) *kafka.Writer {
+	writer := fromWriterConfig(conf)
+	return &writer
+}
+
 func fromWriterConfig(conf WriterConfig) kafka.Writer {
 	if len(conf.Brokers) == 0 {
 		conf.Brokers = []string{"127.0.0.1:9092"}
@@ -21,6 +21,7 @@ default mongo.Client and exported configs.
 		log.Logger
 		contract.ConfigAccessor
 		MongoConfigInterceptor `optional:"true"`
+		[]di.Interceptor `group:"interceptor"`
 		opentracing.Tracer     `optional:"true"`
 	Provides:
 		Factory
@@ -41,11 +42,12 @@ type MongoConfigInterceptor func(name string, clientOptions *options.ClientOptio
 type factoryIn struct {
 	dig.In
 
-	Logger      log.Logger
-	Conf        contract.ConfigAccessor
-	Interceptor MongoConfigInterceptor `optional:"true"`
-	Tracer      opentracing.Tracer     `optional:"true"`
-	Dispatcher  contract.Dispatcher    `optional:"true"`
+	Logger       log.Logger
+	Conf         contract.ConfigAccessor
+	Interceptor  MongoConfigInterceptor `optional:"true"`
+	Interceptors []di.Interceptor       `group:"interceptor"`
+	Tracer       opentracing.Tracer     `optional:"true"`
+	Dispatcher   contract.Dispatcher    `optional:"true"`
 }
 
 // factoryOut is the result of Provide. The official mongo package doesn't
@@ -80,6 +82,20 @@ func provideMongoFactory(p factoryIn) (factoryOut, func()) {
 		if p.Interceptor != nil {
 			p.Interceptor(name, opts)
 		}
+		err := di.Interceptors(p.Interceptors).Apply("mongo", name, func(fn interface{}) bool {
+			switch fn := fn.(type) {
+			case MongoConfigInterceptor:
+				fn(name, opts)
+			case func(name string, clientOptions *options.ClientOptions):
+				fn(name, opts)
+			default:
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return di.Pair{}, err
+		}
 		client, err := mongo.Connect(context.Background(), opts)
 		if err != nil {
 			return di.Pair{}, err
@@ -90,7 +106,7 @@ func provideMongoFactory(p factoryIn) (factoryOut, func()) {
 				_ = client.Disconnect(context.Background())
 			},
 		}, nil
-	})
+	}, di.WithKind("mongo"))
 	f := Factory{factory}
 	f.SubscribeReloadEventFrom(p.Dispatcher)
 	return factoryOut{
@@ -1,9 +1,11 @@
 package otredis
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/DoNewsCode/core/boot"
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
@@ -21,15 +23,17 @@ Maker, the default redis.UniversalClient and exported configs.
 		log.Logger
 		contract.ConfigAccessor
 		RedisConfigurationInterceptor `optional:"true"`
+		[]di.Interceptor `group:"interceptor"`
 		opentracing.Tracer            `optional:"true"`
 	Provide:
 		Maker
 		Factory
 		redis.UniversalClient
 		*collector
+		boot.Kind "redis"
 */
 func Providers() []interface{} {
-	return []interface{}{provideRedisFactory, provideDefaultClient, provideConfig}
+	return []interface{}{provideRedisFactory, provideDefaultClient, provideConfig, provideBootKind}
 }
 
 // RedisConfigurationInterceptor intercepts the redis.UniversalOptions before
@@ -43,12 +47,13 @@ type RedisConfigurationInterceptor func(name string, opts *redis.UniversalOption
 type factoryIn struct {
 	di.In
 
-	Logger      log.Logger
-	Conf        contract.ConfigAccessor
-	Interceptor RedisConfigurationInterceptor `optional:"true"`
-	Tracer      opentracing.Tracer            `optional:"true"`
-	Gauges      *Gauges                       `optional:"true"`
-	Dispatcher  contract.Dispatcher           `optional:"true"`
+	Logger       log.Logger
+	Conf         contract.ConfigAccessor
+	Interceptor  RedisConfigurationInterceptor `optional:"true"`
+	Interceptors []di.Interceptor              `group:"interceptor"`
+	Tracer       opentracing.Tracer            `optional:"true"`
+	Gauges       *Gauges                       `optional:"true"`
+	Dispatcher   contract.Dispatcher           `optional:"true"`
 }
 
 // factoryOut is the result of provideRedisFactory.
@@ -105,6 +110,20 @@ func provideRedisFactory(p factoryIn) (factoryOut, func()) {
 		if p.Interceptor != nil {
 			p.Interceptor(name, &full)
 		}
+		err := di.Interceptors(p.Interceptors).Apply("redis", name, func(fn interface{}) bool {
+			switch fn := fn.(type) {
+			case RedisConfigurationInterceptor:
+				fn(name, &full)
+			case func(name string, opts *redis.UniversalOptions):
+				fn(name, &full)
+			default:
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return di.Pair{}, err
+		}
 		redis.SetLogger(&RedisLogAdapter{level.Debug(p.Logger)})
 
 		client := redis.NewUniversalClient(&full)
@@ -123,7 +142,7 @@ func provideRedisFactory(p factoryIn) (factoryOut, func()) {
 				_ = client.Close()
 			},
 		}, nil
-	})
+	}, di.WithKind("redis"))
 	redisFactory := Factory{factory}
 	redisFactory.SubscribeReloadEventFrom(p.Dispatcher)
 	var collector *collector
@@ -145,6 +164,21 @@ func provideDefaultClient(maker Maker) (redis.UniversalClient, error) {
 	return maker.Make("default")
 }
 
+// provideBootKind provides the "redis" kind of boot gates, which pings the
+// redis of the entry.
+func provideBootKind(maker Maker) boot.KindOut {
+	return boot.KindOut{Kind: boot.Kind{
+		Name: "redis",
+		Probe: func(ctx context.Context, entry string) error {
+			client, err := maker.Make(entry)
+			if err != nil {
+				return err
+			}
+			return client.Ping(ctx).Err()
+		},
+	}}
+}
+
 type configOut struct {
 	di.Out
 
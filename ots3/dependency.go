@@ -96,7 +96,7 @@ func provideFactory(p factoryIn) factoryOut {
 			Closer: nil,
 			Conn:   manager,
 		}, nil
-	})
+	}, di.WithKind("s3"))
 
 	s3Factory := Factory{factory}
 	s3Factory.SubscribeReloadEventFrom(p.Dispatcher)
@@ -0,0 +1,91 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/DoNewsCode/core"
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/di"
+)
+
+// Option is the configuration of the plugins.
+type Option struct {
+	// GoPlugins are the paths of the Go plugins, see OpenGoPlugin.
+	GoPlugins []string `json:"goPlugins" yaml:"goPlugins"`
+	// Processes are the plugin processes launched by the host, see Launch.
+	Processes []Process `json:"processes" yaml:"processes"`
+}
+
+// Process is the configuration of a plugin process.
+type Process struct {
+	// Name identifies the plugin in the logs.
+	Name string `json:"name" yaml:"name"`
+	// Path is the executable.
+	Path string `json:"path" yaml:"path"`
+	// Args are the command line arguments.
+	Args []string `json:"args" yaml:"args"`
+	// Env are the extra environment variables in the form of "KEY=value".
+	Env []string `json:"env" yaml:"env"`
+	// StartTimeout bounds the time to wait for the handshake. Defaults to 10s.
+	StartTimeout config.Duration `json:"startTimeout" yaml:"startTimeout"`
+}
+
+// Load loads the plugins in the configuration as modules of c. Loading is
+// opt-in: nothing is loaded unless Load is called.
+func Load(c *core.C) error {
+	c.Provide(di.Deps{provideConfig})
+
+	var option Option
+	if err := c.ConfigAccessor.Unmarshal("plugins", &option); err != nil {
+		return fmt.Errorf("plugins configuration error: %w", err)
+	}
+	for _, path := range option.GoPlugins {
+		constructor, err := OpenGoPlugin(path)
+		if err != nil {
+			return err
+		}
+		c.AddModuleFunc(constructor)
+	}
+	for _, process := range option.Processes {
+		timeout := process.StartTimeout.Duration
+		if timeout == 0 {
+			timeout = 10 * time.Second
+		}
+		cmd := exec.Command(process.Path, process.Args...)
+		cmd.Env = append(os.Environ(), process.Env...)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		plugin, err := Launch(ctx, process.Name, cmd, WithLogger(c.LevelLogger))
+		cancel()
+		if err != nil {
+			return err
+		}
+		c.AddModule(plugin)
+	}
+	return nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "plugins",
+			Data: map[string]interface{}{
+				"plugins": Option{
+					GoPlugins: []string{},
+					Processes: []Process{},
+				},
+			},
+			Comment: "The out-of-tree modules",
+		},
+	}}
+}
@@ -0,0 +1,62 @@
+/*
+Package plugins loads modules shipped out-of-tree, so that optional heavy
+integrations don't have to be compiled into every service.
+
+Two kinds of plugins are supported. Go plugins are shared objects built with
+"go build -buildmode=plugin" that export a module constructor named Module. They
+run in the host process and can provide anything a module can, but must be built
+with the same versions of Go and the dependencies as the host.
+
+Plugin processes are separate executables that speak a small gRPC module-host
+protocol, in the style of HashiCorp go-plugin. The host starts the process,
+which listens on a loopback port and prints the handshake line
+
+	CORE_PLUGIN|1|tcp|127.0.0.1:40123
+
+to stdout. The host then connects, asks for the HTTP path prefixes and the cron
+jobs of the plugin, mounts the prefixes on its router and adds the jobs to its
+cron. HTTP requests under the prefixes are forwarded to the plugin with their
+bodies buffered in memory. Each call carries a random token generated by the
+host, so other local processes can't call the plugin. When the host shuts down,
+it closes stdin of the process, and kills it after a grace window.
+
+A plugin process is a main package that calls Serve:
+
+	func main() {
+		err := plugins.Serve(plugins.Module{
+			Prefixes: []string{"/reports/"},
+			Handler:  reportsHandler,
+			Jobs: []plugins.Job{
+				{Name: "daily", Schedule: "@daily", Run: sendDailyReports},
+			},
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+Integration
+
+package plugins exports the configuration in the following format:
+
+	plugins:
+	  goPlugins:
+	    - ./plugins/search.so
+	  processes:
+	    - name: reports
+	      path: ./bin/reports
+	      args: []
+	      env:
+	        - REPORTS_DB=reports
+	      startTimeout: 10s
+
+Loading plugins is opt-in. Load them after the core is created:
+
+	c := core.Default()
+	if err := plugins.Load(c); err != nil {
+		panic(err)
+	}
+
+The plugin processes are started by Load, regardless of the command being run.
+*/
+package plugins
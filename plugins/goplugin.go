@@ -0,0 +1,35 @@
+package plugins
+
+import (
+	"fmt"
+	"plugin"
+	"reflect"
+)
+
+// OpenGoPlugin opens the Go plugin built with "go build -buildmode=plugin", and
+// returns the module constructor it exports as "Module". The constructor is
+// meant for core.C.AddModuleFunc, so it can take any dependency, eg.:
+//
+//	func Module(conf contract.ConfigAccessor, logger log.Logger) ReportModule
+//
+// Go plugins are only supported on Linux, FreeBSD and macOS, and must be built
+// with the same versions of Go and the dependencies as the host.
+func OpenGoPlugin(path string) (interface{}, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open go plugin %s: %w", path, err)
+	}
+	symbol, err := p.Lookup("Module")
+	if err != nil {
+		return nil, fmt.Errorf("go plugin %s: %w", path, err)
+	}
+	// Lookup returns functions as is, and variables as pointers.
+	v := reflect.ValueOf(symbol)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Func {
+		return nil, fmt.Errorf("go plugin %s: Module must be a function, got %T", path, symbol)
+	}
+	return v.Interface(), nil
+}
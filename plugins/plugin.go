@@ -0,0 +1,258 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/robfig/cron/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Plugin is the host side of a plugin process. It is a module that mounts the
+// HTTP routes and adds the cron jobs of the plugin.
+type Plugin struct {
+	name     string
+	token    string
+	logger   log.Logger
+	conn     *grpc.ClientConn
+	manifest manifest
+
+	// set if the plugin is launched by the host.
+	stdin  io.Closer
+	cmd    *exec.Cmd
+	exited chan error
+}
+
+// PluginOption changes the behavior of Plugin.
+type PluginOption func(*Plugin)
+
+// WithLogger sets the logger for the output and errors of the plugin.
+func WithLogger(logger log.Logger) PluginOption {
+	return func(plugin *Plugin) {
+		plugin.logger = logger
+	}
+}
+
+// WithToken sets the token sent along with each call. Launch generates one.
+func WithToken(token string) PluginOption {
+	return func(plugin *Plugin) {
+		plugin.token = token
+	}
+}
+
+// Connect connects to a plugin process listening at addr, and asks for what
+// it registers.
+func Connect(ctx context.Context, name string, addr string, options ...PluginOption) (*Plugin, error) {
+	p := &Plugin{name: name, logger: log.NewNopLogger()}
+	for _, option := range options {
+		option(p)
+	}
+	if err := p.connect(ctx, addr); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Plugin) connect(ctx context.Context, addr string) error {
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.ForceCodec(jsonCodec{})))
+	if err != nil {
+		return fmt.Errorf("failed to dial plugin %s: %w", p.name, err)
+	}
+	if err := conn.Invoke(p.context(ctx), method("Describe"), &empty{}, &p.manifest); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to describe plugin %s: %w", p.name, err)
+	}
+	p.conn = conn
+	return nil
+}
+
+// Launch starts the plugin process, and connects to it after the handshake.
+// The process must serve the module with Serve. ctx bounds the time to wait
+// for the handshake. The stdout of the process other than the handshake is
+// logged, and the stderr goes to that of the host unless cmd.Stderr is set.
+func Launch(ctx context.Context, name string, cmd *exec.Cmd, options ...PluginOption) (*Plugin, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return nil, err
+	}
+	p := &Plugin{name: name, logger: log.NewNopLogger(), token: hex.EncodeToString(buf[:])}
+	for _, option := range options {
+		option(p)
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, tokenEnv+"="+p.token)
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", name, err)
+	}
+	p.cmd, p.stdin, p.exited = cmd, stdin, make(chan error, 1)
+
+	handshake := make(chan string, 1)
+	go func() {
+		shaken := false
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !shaken && strings.HasPrefix(line, handshakePrefix+"|") {
+				shaken = true
+				handshake <- line
+				continue
+			}
+			level.Info(p.logger).Log("plugin", name, "msg", line)
+		}
+		p.exited <- cmd.Wait()
+	}()
+
+	var line string
+	select {
+	case line = <-handshake:
+	case err := <-p.exited:
+		p.exited <- err
+		return nil, fmt.Errorf("plugin %s exited before the handshake: %v", name, err)
+	case <-ctx.Done():
+		p.kill()
+		return nil, fmt.Errorf("plugin %s didn't handshake: %w", name, ctx.Err())
+	}
+	addr, err := parseHandshake(line)
+	if err != nil {
+		p.kill()
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	if err := p.connect(ctx, addr); err != nil {
+		p.kill()
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseHandshake parses "CORE_PLUGIN|<version>|tcp|<addr>".
+func parseHandshake(line string) (string, error) {
+	parts := strings.Split(line, "|")
+	if len(parts) != 4 || parts[2] != "tcp" {
+		return "", fmt.Errorf("malformed handshake %q", line)
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil || version != ProtocolVersion {
+		return "", fmt.Errorf("unsupported protocol version %s, expecting %d", parts[1], ProtocolVersion)
+	}
+	return parts[3], nil
+}
+
+func (p *Plugin) kill() {
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	<-p.exited
+}
+
+func (p *Plugin) context(ctx context.Context) context.Context {
+	if p.token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, tokenKey, p.token)
+}
+
+// ServeHTTP forwards the request to the plugin. The request and response
+// bodies are buffered in memory.
+func (p *Plugin) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var resp httpResponse
+	err = p.conn.Invoke(p.context(request.Context()), method("ServeHTTP"), &httpRequest{
+		Method:     request.Method,
+		URL:        request.URL.RequestURI(),
+		Header:     request.Header,
+		Body:       body,
+		RemoteAddr: request.RemoteAddr,
+	}, &resp)
+	if err != nil {
+		level.Warn(p.logger).Log("plugin", p.name, "msg", "failed to forward request", "err", err)
+		http.Error(writer, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	for k, v := range resp.Header {
+		writer.Header()[k] = v
+	}
+	writer.WriteHeader(resp.Status)
+	writer.Write(resp.Body)
+}
+
+// RunJob runs the job of the plugin once.
+func (p *Plugin) RunJob(ctx context.Context, name string) error {
+	return p.conn.Invoke(p.context(ctx), method("RunJob"), &jobRequest{Name: name}, &empty{})
+}
+
+// ProvideHTTP implements container.HTTPProvider
+func (p *Plugin) ProvideHTTP(router *mux.Router) {
+	for _, prefix := range p.manifest.Prefixes {
+		router.PathPrefix(prefix).Handler(p)
+	}
+}
+
+// ProvideCron implements container.CronProvider
+func (p *Plugin) ProvideCron(crontab *cron.Cron) {
+	for _, job := range p.manifest.Jobs {
+		name := job.Name
+		_, err := crontab.AddFunc(job.Schedule, func() {
+			if err := p.RunJob(context.Background(), name); err != nil {
+				level.Warn(p.logger).Log("plugin", p.name, "job", name, "err", err)
+			}
+		})
+		if err != nil {
+			level.Warn(p.logger).Log("plugin", p.name, "job", name, "msg", "invalid schedule", "err", err)
+		}
+	}
+}
+
+// ProvideCloser implements container.CloserProvider
+func (p *Plugin) ProvideCloser() {
+	p.Close()
+}
+
+// Close disconnects from the plugin. If the plugin is launched by the host, its
+// stdin is closed, and it is killed if it doesn't exit in five seconds.
+func (p *Plugin) Close() error {
+	err := p.conn.Close()
+	if p.cmd == nil {
+		return err
+	}
+	p.stdin.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(5 * time.Second):
+		level.Warn(p.logger).Log("plugin", p.name, "msg", "killed after the grace window")
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func testModule(ran chan<- string) Module {
+	return Module{
+		Prefixes: []string{"/reports/"},
+		Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("X-Plugin", "reports")
+			writer.WriteHeader(http.StatusTeapot)
+			fmt.Fprintf(writer, "%s %s", request.Method, request.URL.Path)
+		}),
+		Jobs: []Job{
+			{Name: "daily", Schedule: "@daily", Run: func(ctx context.Context) error {
+				ran <- "daily"
+				return nil
+			}},
+			{Name: "broken", Schedule: "@daily", Run: func(ctx context.Context) error {
+				return errors.New("broken")
+			}},
+		},
+	}
+}
+
+// TestHelperProcess is the plugin process launched by TestLaunch.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	fmt.Println("hello from the plugin")
+	if err := Serve(testModule(make(chan string, 1))); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestConnect(t *testing.T) {
+	ran := make(chan string, 1)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := NewServer(testModule(ran), "secret")
+	go server.Serve(ln)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = Connect(ctx, "reports", ln.Addr().String(), WithToken("wrong"))
+	assert.Error(t, err)
+
+	plugin, err := Connect(ctx, "reports", ln.Addr().String(), WithToken("secret"))
+	assert.NoError(t, err)
+	defer plugin.Close()
+
+	router := mux.NewRouter()
+	plugin.ProvideHTTP(router)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/reports/today", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, "reports", rec.Header().Get("X-Plugin"))
+	assert.Equal(t, "POST /reports/today", rec.Body.String())
+
+	crontab := cron.New()
+	plugin.ProvideCron(crontab)
+	assert.Len(t, crontab.Entries(), 2)
+
+	assert.NoError(t, plugin.RunJob(ctx, "daily"))
+	assert.Equal(t, "daily", <-ran)
+	assert.Error(t, plugin.RunJob(ctx, "broken"))
+	assert.Error(t, plugin.RunJob(ctx, "unknown"))
+}
+
+func TestLaunch(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	plugin, err := Launch(ctx, "reports", cmd)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	plugin.ServeHTTP(rec, httptest.NewRequest("GET", "/reports/", nil))
+	assert.Equal(t, "GET /reports/", rec.Body.String())
+
+	assert.NoError(t, plugin.Close())
+	assert.True(t, plugin.cmd.ProcessState.Exited())
+}
+
+func TestParseHandshake(t *testing.T) {
+	addr, err := parseHandshake("CORE_PLUGIN|1|tcp|127.0.0.1:1234")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:1234", addr)
+
+	_, err = parseHandshake("CORE_PLUGIN|2|tcp|127.0.0.1:1234")
+	assert.Error(t, err)
+	_, err = parseHandshake("CORE_PLUGIN|1|unix")
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// ProtocolVersion is the version of the module-host protocol. It is part of the
+// handshake, so that the host refuses the plugins speaking another version.
+const ProtocolVersion = 1
+
+const (
+	// handshakePrefix starts the handshake line printed by the plugin process.
+	handshakePrefix = "CORE_PLUGIN"
+	// tokenEnv is the environment variable holding the token that the host
+	// sends along with each call.
+	tokenEnv = "CORE_PLUGIN_TOKEN"
+	// tokenKey is the metadata key of the token.
+	tokenKey = "x-core-plugin-token"
+
+	serviceName = "core.plugins.ModuleHost"
+)
+
+// manifest describes what the plugin registers with the host.
+type manifest struct {
+	Prefixes []string  `json:"prefixes"`
+	Jobs     []jobSpec `json:"jobs"`
+}
+
+type jobSpec struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+}
+
+type httpRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	RemoteAddr string      `json:"remoteAddr"`
+}
+
+type httpResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+type jobRequest struct {
+	Name string `json:"name"`
+}
+
+type empty struct{}
+
+// moduleHost is implemented by the plugin side of the protocol.
+type moduleHost interface {
+	describe(ctx context.Context) (*manifest, error)
+	serveHTTP(ctx context.Context, req *httpRequest) (*httpResponse, error)
+	runJob(ctx context.Context, req *jobRequest) (*empty, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*moduleHost)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("Describe", func() interface{} { return &empty{} }, func(ctx context.Context, host moduleHost, req interface{}) (interface{}, error) {
+			return host.describe(ctx)
+		}),
+		unary("ServeHTTP", func() interface{} { return &httpRequest{} }, func(ctx context.Context, host moduleHost, req interface{}) (interface{}, error) {
+			return host.serveHTTP(ctx, req.(*httpRequest))
+		}),
+		unary("RunJob", func() interface{} { return &jobRequest{} }, func(ctx context.Context, host moduleHost, req interface{}) (interface{}, error) {
+			return host.runJob(ctx, req.(*jobRequest))
+		}),
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func unary(name string, newRequest func() interface{}, call func(ctx context.Context, host moduleHost, req interface{}) (interface{}, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := newRequest()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(ctx, srv.(moduleHost), req)
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: method(name)}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+func method(name string) string {
+	return "/" + serviceName + "/" + name
+}
+
+// jsonCodec encodes the messages of the protocol with JSON, so that plugins
+// don't need any generated code.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
@@ -0,0 +1,152 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Module is what a plugin process registers with the host.
+type Module struct {
+	// Prefixes are the HTTP path prefixes forwarded to Handler, eg. "/reports/".
+	Prefixes []string
+	// Handler serves the forwarded HTTP requests.
+	Handler http.Handler
+	// Jobs are the cron jobs run by the host.
+	Jobs []Job
+}
+
+// Job is a cron job of a plugin.
+type Job struct {
+	// Name identifies the job.
+	Name string
+	// Schedule is the cron spec, eg. "@every 1m".
+	Schedule string
+	// Run runs the job once.
+	Run func(ctx context.Context) error
+}
+
+// Serve serves the module to the host that launched the process. It prints the
+// handshake to stdout, and returns when the host closes stdin of the process.
+// It is typically the only statement in the main function of a plugin:
+//
+//	func main() {
+//		if err := plugins.Serve(plugins.Module{...}); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func Serve(module Module) error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	server := NewServer(module, os.Getenv(tokenEnv))
+	go func() {
+		// The host closes stdin when it shuts down.
+		_, _ = io.Copy(ioutil.Discard, os.Stdin)
+		server.GracefulStop()
+	}()
+
+	fmt.Fprintf(os.Stdout, "%s|%d|tcp|%s\n", handshakePrefix, ProtocolVersion, ln.Addr())
+	return server.Serve(ln)
+}
+
+// NewServer creates the gRPC server of the module. Unless token is empty, the
+// calls must carry the token. Most plugins should use Serve instead.
+func NewServer(module Module, token string) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ForceServerCodec(jsonCodec{}),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if token == "" {
+				return handler(ctx, req)
+			}
+			md, _ := metadata.FromIncomingContext(ctx)
+			if values := md.Get(tokenKey); len(values) != 1 || subtle.ConstantTimeCompare([]byte(values[0]), []byte(token)) != 1 {
+				return nil, status.Error(codes.Unauthenticated, "invalid plugin token")
+			}
+			return handler(ctx, req)
+		}),
+	)
+	server.RegisterService(&serviceDesc, &moduleServer{module: module})
+	return server
+}
+
+type moduleServer struct {
+	module Module
+}
+
+func (m *moduleServer) describe(ctx context.Context) (*manifest, error) {
+	var out manifest
+	out.Prefixes = m.module.Prefixes
+	for _, job := range m.module.Jobs {
+		out.Jobs = append(out.Jobs, jobSpec{Name: job.Name, Schedule: job.Schedule})
+	}
+	return &out, nil
+}
+
+func (m *moduleServer) serveHTTP(ctx context.Context, req *httpRequest) (*httpResponse, error) {
+	if m.module.Handler == nil {
+		return nil, status.Error(codes.Unimplemented, "the plugin doesn't serve http")
+	}
+	r, err := http.NewRequest(req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	r = r.WithContext(ctx)
+	r.Header = req.Header
+	r.RemoteAddr = req.RemoteAddr
+
+	rec := &recorder{header: make(http.Header)}
+	m.module.Handler.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return &httpResponse{Status: rec.status, Header: rec.header, Body: rec.body.Bytes()}, nil
+}
+
+func (m *moduleServer) runJob(ctx context.Context, req *jobRequest) (*empty, error) {
+	for _, job := range m.module.Jobs {
+		if job.Name == req.Name {
+			if err := job.Run(ctx); err != nil {
+				return nil, status.Error(codes.Unknown, err.Error())
+			}
+			return &empty{}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "unknown job %s", req.Name)
+}
+
+// recorder buffers the response of the handler.
+type recorder struct {
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
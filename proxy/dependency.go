@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+/*
+Providers returns a set of dependency providers for package proxy.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		contract.Cache `optional:"true"`
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		*Proxy
+*/
+func Providers() di.Deps {
+	return di.Deps{provideProxy, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger     log.Logger
+	Conf       contract.ConfigAccessor
+	Cache      contract.Cache      `optional:"true"`
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Proxy *Proxy
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideProxy(in in) (out, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("proxy", &option); err != nil {
+		return out{}, fmt.Errorf("proxy configuration error: %w", err)
+	}
+	client := &http.Client{
+		// redirects are passed through to the caller.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	p, err := NewProxy(option, client, in.Cache, in.Logger)
+	if err != nil {
+		return out{}, fmt.Errorf("proxy configuration error: %w", err)
+	}
+	if in.Dispatcher != nil {
+		in.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+			var option Option
+			if err := event.(events.OnReloadPayload).NewConf.Unmarshal("proxy", &option); err != nil {
+				return fmt.Errorf("proxy configuration error: %w", err)
+			}
+			return p.Update(option)
+		}))
+	}
+	return out{Proxy: p}, nil
+}
+
+// ProvideHTTP mounts the proxy at the route prefixes. Routes added by a config
+// reload are only served if their prefixes are mounted already.
+func (o out) ProvideHTTP(router *mux.Router) {
+	for _, prefix := range o.Proxy.Prefixes() {
+		router.PathPrefix(prefix).Handler(o.Proxy)
+	}
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "proxy",
+			Data: map[string]interface{}{
+				"proxy": Option{
+					Routes: []Route{},
+				},
+			},
+			Comment: "The reverse proxy routes, eg. {prefix: /legacy/, upstream: http://legacy:8080, stripPrefix: true, retries: 1, cacheTTL: 10s, breaker: {threshold: 5, cooldown: 10s}}",
+		},
+	}}
+}
@@ -0,0 +1,42 @@
+/*
+Package proxy provides a lightweight reverse proxy, so that core can serve as a
+slim API gateway in front of legacy services.
+
+Requests are forwarded by path prefix to the upstream URLs. For each route, the
+proxy can rewrite request and response headers, retry idempotent requests that
+fail with a network error or 502, 503 and 504, cache successful GET responses in
+a contract.Cache, and open a circuit breaker after consecutive failures.
+Concurrent identical GET requests are coalesced into a single upstream request.
+
+The request and response bodies are buffered in memory, so the proxy is not
+suitable for streaming or websockets.
+
+Integration
+
+package proxy exports the configuration in the following format:
+
+	proxy:
+	  routes:
+	    - prefix: /legacy/
+	      upstream: http://legacy:8080/api
+	      stripPrefix: true
+	      requestHeaders:
+	        X-Gateway: core
+	      responseHeaders:
+	        Server: ""
+	      timeout: 10s
+	      retries: 1
+	      cacheTTL: 10s
+	      breaker:
+	        threshold: 5
+	        cooldown: 10s
+
+Add the proxy dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(proxy.Providers())
+
+The routes are mounted on the HTTP router. Response caching requires a
+contract.Cache to be provided.
+*/
+package proxy
@@ -0,0 +1,344 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/DoNewsCode/core/unierr"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/sync/singleflight"
+)
+
+// Option is the configuration of the Proxy.
+type Option struct {
+	Routes []Route `json:"routes" yaml:"routes"`
+}
+
+// Route forwards the requests under a path prefix to an upstream.
+type Route struct {
+	// Prefix is the path prefix to match, eg. "/legacy/". The longest matching
+	// prefix wins.
+	Prefix string `json:"prefix" yaml:"prefix"`
+	// Upstream is the base URL of the upstream, eg. "http://legacy:8080/api".
+	Upstream string `json:"upstream" yaml:"upstream"`
+	// StripPrefix removes the prefix from the path before it is appended to the
+	// upstream path.
+	StripPrefix bool `json:"stripPrefix" yaml:"stripPrefix"`
+	// RequestHeaders are set on the upstream request. An empty value removes
+	// the header.
+	RequestHeaders map[string]string `json:"requestHeaders" yaml:"requestHeaders"`
+	// ResponseHeaders are set on the response. An empty value removes the
+	// header.
+	ResponseHeaders map[string]string `json:"responseHeaders" yaml:"responseHeaders"`
+	// Timeout is the timeout of each upstream attempt. Defaults to 10s.
+	Timeout config.Duration `json:"timeout" yaml:"timeout"`
+	// Retries is the number of retries for idempotent requests that fail with
+	// a network error or 502, 503 and 504.
+	Retries int `json:"retries" yaml:"retries"`
+	// CacheTTL enables caching of successful GET responses for the duration.
+	// It requires a contract.Cache.
+	CacheTTL config.Duration `json:"cacheTTL" yaml:"cacheTTL"`
+	// Breaker opens the circuit after the consecutive failures.
+	Breaker BreakerOption `json:"breaker" yaml:"breaker"`
+}
+
+// BreakerOption configures the circuit breaker of a route.
+type BreakerOption struct {
+	// Threshold is the number of consecutive failures that opens the circuit.
+	// Zero disables the breaker.
+	Threshold int `json:"threshold" yaml:"threshold"`
+	// Cooldown is the time the circuit stays open before a trial request.
+	// Defaults to 10s.
+	Cooldown config.Duration `json:"cooldown" yaml:"cooldown"`
+}
+
+// hopHeaders are removed when forwarding, see RFC 7230, section 6.1.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+type route struct {
+	Route
+	upstream *url.URL
+	breaker  *breaker
+}
+
+// Proxy is a reverse proxy driven by routes. Concurrent identical GET requests
+// are coalesced into one upstream request. Request and response bodies are
+// buffered in memory, so Proxy is not suitable for streaming.
+type Proxy struct {
+	client contract.HttpDoer
+	cache  contract.Cache
+	logger log.Logger
+	group  singleflight.Group
+
+	mu     sync.RWMutex
+	routes []*route
+}
+
+// NewProxy creates a new *Proxy. The cache can be nil, in which case responses
+// are never cached.
+func NewProxy(option Option, client contract.HttpDoer, cache contract.Cache, logger log.Logger) (*Proxy, error) {
+	p := &Proxy{client: client, cache: cache, logger: logger}
+	if err := p.Update(option); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update replaces the routes. The circuit state of the routes is reset.
+func (p *Proxy) Update(option Option) error {
+	routes := make([]*route, 0, len(option.Routes))
+	for _, r := range option.Routes {
+		upstream, err := url.Parse(r.Upstream)
+		if err != nil {
+			return fmt.Errorf("invalid upstream %s: %w", r.Upstream, err)
+		}
+		if r.Timeout.Duration <= 0 {
+			r.Timeout = config.Duration{Duration: 10 * time.Second}
+		}
+		routes = append(routes, &route{
+			Route:    r,
+			upstream: upstream,
+			breaker:  newBreaker(r.Breaker.Threshold, r.Breaker.Cooldown.Duration),
+		})
+	}
+	sort.SliceStable(routes, func(i, j int) bool { return len(routes[i].Prefix) > len(routes[j].Prefix) })
+	p.mu.Lock()
+	p.routes = routes
+	p.mu.Unlock()
+	return nil
+}
+
+// Prefixes returns the path prefixes of the routes.
+func (p *Proxy) Prefixes() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	prefixes := make([]string, 0, len(p.routes))
+	for _, r := range p.routes {
+		prefixes = append(prefixes, r.Prefix)
+	}
+	return prefixes
+}
+
+func (p *Proxy) match(path string) *route {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, r := range p.routes {
+		if strings.HasPrefix(path, r.Prefix) {
+			return r
+		}
+	}
+	return nil
+}
+
+type response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	r := p.match(request.URL.Path)
+	if r == nil {
+		srvhttp.NewResponseEncoder(writer).EncodeError(unierr.NotFoundErr(nil, "no route for %s", request.URL.Path))
+		return
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		srvhttp.NewResponseEncoder(writer).EncodeError(unierr.InvalidArgumentErr(err, "failed to read request body"))
+		return
+	}
+
+	var resp *response
+	if request.Method == http.MethodGet {
+		resp, err = p.get(request, r)
+	} else {
+		resp, err = p.forward(request, r, body)
+	}
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "proxy request failed", "upstream", r.Upstream, "err", err)
+		srvhttp.NewResponseEncoder(writer).EncodeError(err)
+		return
+	}
+	for k, v := range resp.Header {
+		writer.Header()[k] = v
+	}
+	for k, v := range r.ResponseHeaders {
+		if v == "" {
+			writer.Header().Del(k)
+			continue
+		}
+		writer.Header().Set(k, v)
+	}
+	writer.WriteHeader(resp.Status)
+	_, _ = writer.Write(resp.Body)
+}
+
+// get serves GET requests from the cache, and coalesces the concurrent
+// identical requests.
+func (p *Proxy) get(request *http.Request, r *route) (*response, error) {
+	key := cacheKey(request, r)
+	ttl := r.CacheTTL.Duration
+	if p.cache != nil && ttl > 0 {
+		if b, found, err := p.cache.Get(request.Context(), key); err == nil && found {
+			var resp response
+			if err := json.Unmarshal(b, &resp); err == nil {
+				return &resp, nil
+			}
+		}
+	}
+	v, err, _ := p.group.Do(key, func() (interface{}, error) {
+		resp, err := p.forward(request, r, nil)
+		if err != nil {
+			return nil, err
+		}
+		if p.cache != nil && ttl > 0 && resp.Status == http.StatusOK {
+			if b, err := json.Marshal(resp); err == nil {
+				_ = p.cache.Set(context.Background(), key, b, ttl)
+			}
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*response), nil
+}
+
+// forward sends the request upstream, with retries and circuit breaking.
+func (p *Proxy) forward(request *http.Request, r *route, body []byte) (*response, error) {
+	if !r.breaker.allow() {
+		return nil, unierr.UnavailableErr(nil, "circuit open for %s", r.Upstream)
+	}
+	attempts := 1
+	if isIdempotent(request.Method) {
+		attempts += r.Retries
+	}
+	var (
+		resp *response
+		err  error
+	)
+	for i := 0; i < attempts; i++ {
+		resp, err = p.do(request, r, body)
+		if err == nil && !isRetryable(resp.Status) {
+			break
+		}
+	}
+	r.breaker.done(err == nil && resp.Status < http.StatusInternalServerError)
+	if err != nil {
+		return nil, unierr.UnavailableErr(err, "failed to reach %s", r.Upstream)
+	}
+	return resp, nil
+}
+
+func (p *Proxy) do(request *http.Request, r *route, body []byte) (*response, error) {
+	ctx, cancel := context.WithTimeout(request.Context(), r.Timeout.Duration)
+	defer cancel()
+
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, request.Method, upstreamURL(request, r), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = request.Header.Clone()
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+	if ip, _, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+		if prior := req.Header.Get("X-Forwarded-For"); prior != "" {
+			ip = prior + ", " + ip
+		}
+		req.Header.Set("X-Forwarded-For", ip)
+	}
+	req.Header.Set("X-Forwarded-Host", request.Host)
+	for k, v := range r.RequestHeaders {
+		if v == "" {
+			req.Header.Del(k)
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Header.Clone()
+	for _, h := range hopHeaders {
+		header.Del(h)
+	}
+	return &response{Status: resp.StatusCode, Header: header, Body: b}, nil
+}
+
+func upstreamURL(request *http.Request, r *route) string {
+	path := request.URL.Path
+	if r.StripPrefix {
+		path = "/" + strings.TrimPrefix(strings.TrimPrefix(path, r.Prefix), "/")
+	}
+	u := *r.upstream
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = ""
+	u.RawQuery = request.URL.RawQuery
+	return u.String()
+}
+
+// cacheKey identifies a GET request. The credentials are part of the key, so
+// that responses are never shared between users.
+func cacheKey(request *http.Request, r *route) string {
+	h := sha1.New()
+	for _, s := range []string{r.Upstream, request.URL.String(), request.Header.Get("Authorization"), request.Header.Get("Cookie"), request.Header.Get("Accept")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return "proxy:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryable(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapCache struct {
+	sync.Mutex
+	m map[string][]byte
+}
+
+func (c *mapCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.Lock()
+	defer c.Unlock()
+	v, ok := c.m[key]
+	return v, ok, nil
+}
+
+func (c *mapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.Lock()
+	defer c.Unlock()
+	c.m[key] = value
+	return nil
+}
+
+func (c *mapCache) Delete(ctx context.Context, key string) error {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.m, key)
+	return nil
+}
+
+func TestProxy(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		if request.URL.Path == "/api/flaky" && n == 2 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.Header().Set("Server", "legacy")
+		writer.Header().Set("X-Path", request.URL.Path)
+		writer.Header().Set("X-Gateway", request.Header.Get("X-Gateway"))
+		writer.Write([]byte(request.URL.RawQuery))
+	}))
+	defer upstream.Close()
+
+	p, err := NewProxy(Option{Routes: []Route{
+		{
+			Prefix:          "/legacy/",
+			Upstream:        upstream.URL + "/api",
+			StripPrefix:     true,
+			RequestHeaders:  map[string]string{"X-Gateway": "core"},
+			ResponseHeaders: map[string]string{"Server": ""},
+			Retries:         1,
+			CacheTTL:        config.Duration{Duration: time.Minute},
+		},
+	}}, http.DefaultClient, &mapCache{m: map[string][]byte{}}, log.NewNopLogger())
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	p.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/legacy/foo?a=b", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "/api/foo", recorder.Header().Get("X-Path"))
+	assert.Equal(t, "core", recorder.Header().Get("X-Gateway"))
+	assert.Empty(t, recorder.Header().Get("Server"))
+	assert.Equal(t, "a=b", recorder.Body.String())
+
+	// served from the cache
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/legacy/foo?a=b", nil))
+	assert.Equal(t, "a=b", recorder.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// retried
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/legacy/flaky", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestProxy_breaker(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	p, err := NewProxy(Option{Routes: []Route{
+		{Prefix: "/", Upstream: upstream.URL, Breaker: BreakerOption{Threshold: 2, Cooldown: config.Duration{Duration: time.Hour}}},
+	}}, http.DefaultClient, nil, log.NewNopLogger())
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(1, time.Second)
+	b.now = func() time.Time { return now }
+	assert.True(t, b.allow())
+	b.done(false)
+	assert.False(t, b.allow())
+	now = now.Add(2 * time.Second)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+	b.done(true)
+	assert.True(t, b.allow())
+}
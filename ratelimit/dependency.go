@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/otredis"
+)
+
+/*
+Providers returns a set of dependency providers for *Limiter.
+	Depends On:
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+		otredis.Maker       `optional:"true"`
+	Provide:
+		*Limiter
+*/
+func Providers() di.Deps {
+	return di.Deps{provideLimiter, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+	Maker      otredis.Maker       `optional:"true"`
+}
+
+func provideLimiter(in in) (*Limiter, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("http.rateLimit", &option); err != nil {
+		return nil, fmt.Errorf("rate limit configuration error: %w", err)
+	}
+	var store Store = NewMemoryStore()
+	if option.Redis != "" {
+		if in.Maker == nil {
+			return nil, fmt.Errorf("rate limit configuration error: redis %s requires otredis.Providers", option.Redis)
+		}
+		client, err := in.Maker.Make(option.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit configuration error: %w", err)
+		}
+		store = NewRedisStore(client)
+	}
+	limiter := NewLimiter(option, store)
+	if in.Dispatcher != nil {
+		in.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+			var option Option
+			if err := event.(events.OnReloadPayload).NewConf.Unmarshal("http.rateLimit", &option); err != nil {
+				return fmt.Errorf("rate limit configuration error: %w", err)
+			}
+			limiter.Update(option)
+			return nil
+		}))
+	}
+	return limiter, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "ratelimit",
+			Data: map[string]interface{}{
+				"http": map[string]interface{}{
+					"rateLimit": Option{
+						Redis: "",
+						Rules: []Rule{
+							{Name: "ip", Key: KeyIP, Limit: 0, Window: config.Duration{Duration: time.Minute}},
+						},
+					},
+				},
+			},
+			Comment: "The rate limit configuration",
+		},
+	}}
+}
@@ -0,0 +1,50 @@
+/*
+Package ratelimit provides a rate limiting middleware for HTTP and interceptors
+for gRPC.
+
+The requests are counted in fixed windows by the client IP, an API key header,
+or the authenticated user (the contract.Tenant in the context). The counters are
+kept in redis, so that the limits hold across instances, or in memory for a
+single instance. The responses carry the RateLimit-Limit, RateLimit-Remaining
+and RateLimit-Reset headers (header metadata for gRPC). Requests over the limit
+receive 429 (HTTP) or RESOURCE_EXHAUSTED (gRPC) with a retry hint.
+
+The rules are reloaded with the configuration.
+
+Integration
+
+package ratelimit exports the configuration in the following format:
+
+	http:
+	    rateLimit:
+	        redis: default
+	        rules:
+	            - name: ip
+	              key: ip
+	              limit: 100
+	              window: 1m
+	            - name: partner
+	              key: apiKey
+	              header: X-API-Key
+	              prefix: /api/
+	              limit: 1000
+	              window: 1m
+	            - name: user
+	              key: principal
+	              limit: 300
+	              window: 1m
+
+A rule with a zero limit is disabled. Leave redis empty to keep the counters in
+memory. Add the ratelimit dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(otredis.Providers())
+	c.Provide(ratelimit.Providers())
+
+Then apply the middleware or interceptor:
+
+	c.Invoke(func(limiter *ratelimit.Limiter) {
+		router.Use(ratelimit.MakeHTTPMiddleware(limiter))
+	})
+*/
+package ratelimit
@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+)
+
+// ErrLimitExceeded is returned by Limiter.Allow when a request is over the
+// limit of a rule.
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// The kinds of keys the requests are counted by.
+const (
+	// KeyIP counts the requests by the client IP.
+	KeyIP = "ip"
+	// KeyAPIKey counts the requests by the API key in a header.
+	KeyAPIKey = "apiKey"
+	// KeyPrincipal counts the requests by the authenticated user, that is the
+	// contract.Tenant in the context.
+	KeyPrincipal = "principal"
+)
+
+// Option is the configuration of Limiter.
+type Option struct {
+	// Redis is the name of the otredis connection that keeps the counters, so
+	// that the limits are shared by all instances. If empty, the counters are
+	// kept in memory.
+	Redis string `json:"redis" yaml:"redis"`
+	// Rules are the limits. A request must be within every rule it matches.
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule limits the number of requests of a key within a window.
+type Rule struct {
+	// Name identifies the counters of the rule. It must be unique.
+	Name string `json:"name" yaml:"name"`
+	// Key is the kind of key the requests are counted by, one of "ip",
+	// "apiKey" and "principal".
+	Key string `json:"key" yaml:"key"`
+	// Header is the HTTP header or gRPC metadata carrying the API key.
+	// Defaults to "X-API-Key".
+	Header string `json:"header" yaml:"header"`
+	// Prefix limits the rule to the HTTP paths or gRPC full methods with the
+	// prefix. An empty prefix matches every request.
+	Prefix string `json:"prefix" yaml:"prefix"`
+	// Limit is the number of requests allowed within a window.
+	Limit int `json:"limit" yaml:"limit"`
+	// Window is the length of the fixed window the requests are counted in.
+	Window config.Duration `json:"window" yaml:"window"`
+}
+
+func (r Rule) header() string {
+	if r.Header == "" {
+		return "X-API-Key"
+	}
+	return r.Header
+}
+
+// Subject describes a request to Limiter.
+type Subject struct {
+	// Route is the HTTP path or gRPC full method.
+	Route     string
+	IP        string
+	Principal string
+	// APIKey returns the API key in the given header.
+	APIKey func(header string) string
+}
+
+func (s Subject) key(rule Rule) string {
+	switch rule.Key {
+	case KeyIP:
+		return s.IP
+	case KeyPrincipal:
+		return s.Principal
+	case KeyAPIKey:
+		if s.APIKey == nil {
+			return ""
+		}
+		return s.APIKey(rule.header())
+	}
+	return ""
+}
+
+// Result is the outcome of Limiter.Allow. If several rules apply, it describes
+// the one with the fewest remaining requests.
+type Result struct {
+	// Rule is the name of the rule, empty if no rule applies.
+	Rule      string
+	Limit     int
+	Remaining int
+	// Reset is the time until the window of the rule resets.
+	Reset time.Duration
+}
+
+// Store keeps the counters of the rules.
+type Store interface {
+	// Increment increments the counter of the key in the current window, and
+	// returns the count and the time until the window resets.
+	Increment(ctx context.Context, key string, window time.Duration) (count int64, reset time.Duration, err error)
+}
+
+// Limiter limits the request rate by the configured rules. Limiter is safe
+// for concurrent use.
+type Limiter struct {
+	store Store
+
+	mu    sync.Mutex
+	rules []Rule
+}
+
+// NewLimiter creates a new *Limiter that keeps the counters in the store.
+func NewLimiter(option Option, store Store) *Limiter {
+	return &Limiter{store: store, rules: option.Rules}
+}
+
+// Update replaces the rules of Limiter. The store is not changed.
+func (l *Limiter) Update(option Option) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules = option.Rules
+}
+
+// Allow counts the request against the rules it matches. ErrLimitExceeded is
+// returned if the request is over any limit. Other errors come from the store.
+func (l *Limiter) Allow(ctx context.Context, subject Subject) (Result, error) {
+	l.mu.Lock()
+	rules := l.rules
+	l.mu.Unlock()
+
+	var (
+		result   Result
+		exceeded bool
+	)
+	for _, rule := range rules {
+		if rule.Limit <= 0 || !strings.HasPrefix(subject.Route, rule.Prefix) {
+			continue
+		}
+		value := subject.key(rule)
+		if value == "" {
+			continue
+		}
+		count, reset, err := l.store.Increment(ctx, fmt.Sprintf("%s:%s", rule.Name, value), rule.Window.Duration)
+		if err != nil {
+			return Result{}, fmt.Errorf("rate limit rule %s: %w", rule.Name, err)
+		}
+		remaining := rule.Limit - int(count)
+		if remaining < 0 {
+			remaining = 0
+		}
+		if result.Rule == "" || remaining < result.Remaining {
+			result = Result{Rule: rule.Name, Limit: rule.Limit, Remaining: remaining, Reset: reset}
+		}
+		exceeded = exceeded || int(count) > rule.Limit
+	}
+	if exceeded {
+		return result, ErrLimitExceeded
+	}
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/DoNewsCode/core/unierr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that limits the
+// request rate. The responses carry the RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset headers. Requests over the limit receive 429 Too Many
+// Requests with a Retry-After header. If the store fails, requests are let
+// through.
+//
+// Apply realip.MakeHTTPMiddleware before it to count the requests by the client
+// IP behind proxies.
+func MakeHTTPMiddleware(limiter *Limiter) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			subject := subjectFromContext(request.Context(), request.RemoteAddr)
+			subject.Route = request.URL.Path
+			subject.APIKey = request.Header.Get
+			result, err := limiter.Allow(request.Context(), subject)
+			if result.Rule != "" {
+				writer.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+				writer.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+				writer.Header().Set("RateLimit-Reset", seconds(result))
+			}
+			if err == ErrLimitExceeded {
+				writer.Header().Set("Retry-After", seconds(result))
+				srvhttp.NewResponseEncoder(writer).EncodeError(limitExceeded(result))
+				return
+			}
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that limits the
+// call rate. The rate limit headers are sent as header metadata. Calls over
+// the limit receive RESOURCE_EXHAUSTED with a google.rpc.RetryInfo detail.
+func MakeUnaryInterceptor(limiter *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		result, err := limiter.Allow(ctx, grpcSubject(ctx, info.FullMethod))
+		if result.Rule != "" {
+			_ = grpc.SetHeader(ctx, headers(result))
+		}
+		if err == ErrLimitExceeded {
+			return nil, limitExceeded(result)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// MakeStreamInterceptor creates a grpc.StreamServerInterceptor that limits the
+// stream rate, in the same way as MakeUnaryInterceptor.
+func MakeStreamInterceptor(limiter *Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		result, err := limiter.Allow(ss.Context(), grpcSubject(ss.Context(), info.FullMethod))
+		if result.Rule != "" {
+			_ = ss.SetHeader(headers(result))
+		}
+		if err == ErrLimitExceeded {
+			return limitExceeded(result)
+		}
+		return handler(srv, ss)
+	}
+}
+
+func grpcSubject(ctx context.Context, method string) Subject {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	subject := subjectFromContext(ctx, remoteAddr)
+	subject.Route = method
+	md, _ := metadata.FromIncomingContext(ctx)
+	subject.APIKey = func(header string) string {
+		if values := md.Get(header); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	return subject
+}
+
+// subjectFromContext reads the client IP and the principal put into the
+// context by the other middlewares, eg. the ones of package realip.
+func subjectFromContext(ctx context.Context, remoteAddr string) Subject {
+	var subject Subject
+	if ip, ok := ctx.Value(contract.IpKey).(string); ok && ip != "" {
+		subject.IP = ip
+	} else if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		subject.IP = host
+	} else {
+		subject.IP = remoteAddr
+	}
+	if tenant, ok := ctx.Value(contract.TenantKey).(contract.Tenant); ok {
+		subject.Principal = tenant.String()
+	}
+	return subject
+}
+
+func headers(result Result) metadata.MD {
+	return metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(result.Limit),
+		"ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"ratelimit-reset", seconds(result),
+	)
+}
+
+func seconds(result Result) string {
+	return strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+}
+
+func limitExceeded(result Result) *unierr.Error {
+	return unierr.ResourceExhaustedErr(ErrLimitExceeded).WithRetryDelay(result.Reset)
+}
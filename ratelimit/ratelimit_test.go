@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Parallel()
+	limiter := NewLimiter(Option{Rules: []Rule{
+		{Name: "ip", Key: KeyIP, Limit: 3, Window: config.Duration{Duration: time.Minute}},
+		{Name: "user", Key: KeyPrincipal, Limit: 1, Window: config.Duration{Duration: time.Minute}},
+		{Name: "partner", Key: KeyAPIKey, Prefix: "/api/", Limit: 2, Window: config.Duration{Duration: time.Minute}},
+	}}, NewMemoryStore())
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, Subject{IP: "1.1.1.1", Route: "/"})
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Rule: "ip", Limit: 3, Remaining: 2, Reset: result.Reset}, result)
+
+	result, err = limiter.Allow(ctx, Subject{IP: "1.1.1.1", Principal: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "user", result.Rule)
+	assert.Equal(t, 0, result.Remaining)
+	_, err = limiter.Allow(ctx, Subject{IP: "2.2.2.2", Principal: "alice"})
+	assert.Equal(t, ErrLimitExceeded, err)
+
+	apiKey := func(header string) string {
+		assert.Equal(t, "X-API-Key", header)
+		return "secret"
+	}
+	for i := 0; i < 2; i++ {
+		_, err = limiter.Allow(ctx, Subject{Route: "/api/orders", APIKey: apiKey})
+		assert.NoError(t, err)
+	}
+	_, err = limiter.Allow(ctx, Subject{Route: "/api/orders", APIKey: apiKey})
+	assert.Equal(t, ErrLimitExceeded, err)
+	_, err = limiter.Allow(ctx, Subject{Route: "/health", APIKey: apiKey})
+	assert.NoError(t, err)
+
+	limiter.Update(Option{})
+	result, err = limiter.Allow(ctx, Subject{Principal: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, Result{}, result)
+}
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: s.Addr()}))
+
+	count, reset, err := store.Increment(context.Background(), "ip:1.1.1.1", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, time.Minute, reset)
+	count, _, err = store.Increment(context.Background(), "ip:1.1.1.1", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	s.FastForward(time.Minute)
+	count, _, err = store.Increment(context.Background(), "ip:1.1.1.1", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// a counter without expiry, eg. left by an interrupted script, still resets.
+	s.Set("ratelimit:ip:2.2.2.2", "5")
+	count, reset, err = store.Increment(context.Background(), "ip:2.2.2.2", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), count)
+	assert.Equal(t, time.Minute, reset)
+	s.FastForward(time.Minute)
+	count, _, err = store.Increment(context.Background(), "ip:2.2.2.2", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	t.Parallel()
+	limiter := NewLimiter(Option{Rules: []Rule{
+		{Name: "ip", Key: KeyIP, Limit: 1, Window: config.Duration{Duration: 1500 * time.Millisecond}},
+	}}, NewMemoryStore())
+	handler := MakeHTTPMiddleware(limiter)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", recorder.Header().Get("RateLimit-Reset"))
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+
+	// a different client is counted separately.
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request = request.WithContext(context.WithValue(request.Context(), contract.IpKey, "1.1.1.1"))
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestMakeUnaryInterceptor(t *testing.T) {
+	t.Parallel()
+	limiter := NewLimiter(Option{Rules: []Rule{
+		{Name: "partner", Key: KeyAPIKey, Limit: 1, Window: config.Duration{Duration: time.Minute}},
+	}}, NewMemoryStore())
+	interceptor := MakeUnaryInterceptor(limiter)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "secret"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/app.Orders/List"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	resp, err := interceptor(ctx, nil, info, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// MemoryStore keeps the counters in memory. The limits are per instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	nextSweep time.Time
+}
+
+type counter struct {
+	count   int64
+	expires time.Time
+}
+
+// NewMemoryStore creates a *MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*counter)}
+}
+
+// Increment implements Store.
+func (m *MemoryStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.After(m.nextSweep) {
+		for k, c := range m.counters {
+			if !now.Before(c.expires) {
+				delete(m.counters, k)
+			}
+		}
+		m.nextSweep = now.Add(time.Minute)
+	}
+	c, ok := m.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{expires: now.Add(window)}
+		m.counters[key] = c
+	}
+	c.count++
+	return c.count, c.expires.Sub(now), nil
+}
+
+// incrementScript starts the window with the first increment, so that the
+// counter expires at the end of it. The expiry is also set if the counter has
+// none, eg. left by an interrupted script, so that it never lives forever.
+var incrementScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisStore keeps the counters in redis, so that the limits are shared by all
+// instances.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore creates a *RedisStore.
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Increment implements Store.
+func (r *RedisStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	result, err := incrementScript.Run(ctx, r.client, []string{"ratelimit:" + key}, window.Milliseconds()).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, 0, fmt.Errorf("unexpected redis reply %v", result)
+	}
+	count, _ := values[0].(int64)
+	ttl, _ := values[1].(int64)
+	if ttl < 0 {
+		ttl = 0
+	}
+	return count, time.Duration(ttl) * time.Millisecond, nil
+}
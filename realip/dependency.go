@@ -0,0 +1,53 @@
+package realip
+
+import (
+	"fmt"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+)
+
+/*
+Providers returns a set of dependency providers for *Resolver.
+
+	Depends On:
+		contract.ConfigAccessor
+	Provide:
+		*Resolver
+*/
+func Providers() di.Deps {
+	return di.Deps{provideResolver, provideConfig}
+}
+
+func provideResolver(conf contract.ConfigAccessor) (*Resolver, error) {
+	var option Option
+	if err := conf.Unmarshal("realip", &option); err != nil {
+		return nil, fmt.Errorf("realip configuration error: %w", err)
+	}
+	resolver, err := NewResolver(option)
+	if err != nil {
+		return nil, fmt.Errorf("realip configuration error: %w", err)
+	}
+	return resolver, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "realip",
+			Data: map[string]interface{}{
+				"realip": Option{
+					TrustedProxies: []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.1"},
+				},
+			},
+			Comment: "The trusted proxies for client IP resolution and PROXY protocol",
+		},
+	}}
+}
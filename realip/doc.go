@@ -0,0 +1,40 @@
+/*
+Package realip resolves the true client IP behind load balancers and reverse
+proxies, so that rate limiting, logging and audit entries record the client
+rather than the proxy.
+
+Two mechanisms are supported. The Resolver reads the X-Forwarded-For and
+X-Real-IP headers set by layer 7 proxies. The Listener reads the PROXY protocol
+header sent by layer 4 load balancers, such as HAProxy or AWS NLB. Both only
+trust the proxies listed in the configuration, since the headers are trivially
+forged by anyone else.
+
+Integration
+
+package realip exports the configuration in the following format:
+
+	realip:
+	  trustedProxies:
+	    - 10.0.0.0/8
+	    - 127.0.0.1
+
+The PROXY protocol is enabled on the core listeners by:
+
+	http:
+	  proxyProtocol: true
+	grpc:
+	  proxyProtocol: true
+
+Add the realip dependency to core, and the middleware to the HTTP server. The
+middleware should come before the ones consuming the client IP:
+
+	var c *core.C = core.New()
+	c.Provide(realip.Providers())
+	c.Invoke(func(router *mux.Router, resolver *realip.Resolver) {
+		router.Use(realip.MakeHTTPMiddleware(resolver))
+	})
+
+The resolved IP is stored in the context under contract.IpKey, and shows up in
+the logs decorated by logging.WithContext.
+*/
+package realip
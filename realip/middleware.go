@@ -0,0 +1,51 @@
+package realip
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/DoNewsCode/core/contract"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that resolves the
+// client IP, and puts it into the context under contract.IpKey. The
+// request.RemoteAddr is rewritten too, so that access logs record the client.
+func MakeHTTPMiddleware(resolver *Resolver) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ip := resolver.Resolve(request.RemoteAddr, request.Header)
+			ctx := context.WithValue(request.Context(), contract.IpKey, ip)
+			request = request.WithContext(ctx)
+			if _, port, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+				request.RemoteAddr = net.JoinHostPort(ip, port)
+			}
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that resolves
+// the client IP from the peer address and the "x-forwarded-for" and
+// "x-real-ip" metadata, and puts it into the context under contract.IpKey.
+func MakeUnaryInterceptor(resolver *Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var remoteAddr string
+		if p, ok := peer.FromContext(ctx); ok {
+			remoteAddr = p.Addr.String()
+		}
+		header := http.Header{}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			for _, key := range []string{"X-Forwarded-For", "X-Real-IP"} {
+				for _, value := range md.Get(key) {
+					header.Add(key, value)
+				}
+			}
+		}
+		ip := resolver.Resolve(remoteAddr, header)
+		return handler(context.WithValue(ctx, contract.IpKey, ip), req)
+	}
+}
@@ -0,0 +1,187 @@
+package realip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// ErrInvalidHeader is returned when the PROXY protocol header is malformed.
+var ErrInvalidHeader = errors.New("invalid proxy protocol header")
+
+// Listener accepts connections that may start with a PROXY protocol (v1 or v2)
+// header, as sent by load balancers such as HAProxy or AWS NLB. The header is
+// only honored from trusted peers, or from any peer if Trusted is empty. The
+// RemoteAddr of the accepted connections is the client address in the header.
+type Listener struct {
+	net.Listener
+	// Trusted are the peers allowed to send the header.
+	Trusted Trusted
+	// HeaderTimeout bounds the time to read the header. Defaults to 5s.
+	HeaderTimeout time.Duration
+}
+
+// Accept waits for the next connection. The header is read lazily by the
+// connection, so that a slow peer doesn't block Accept.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	timeout := l.HeaderTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return &conn{
+		Conn:    c,
+		reader:  bufio.NewReader(c),
+		trusted: l.Trusted,
+		timeout: timeout,
+	}, nil
+}
+
+type conn struct {
+	net.Conn
+	reader  *bufio.Reader
+	trusted Trusted
+	timeout time.Duration
+
+	once   sync.Once
+	remote net.Addr
+	err    error
+}
+
+func (c *conn) init() {
+	c.once.Do(func() {
+		c.remote = c.Conn.RemoteAddr()
+		if len(c.trusted) > 0 {
+			if addr, ok := c.remote.(*net.TCPAddr); !ok || !c.trusted.Contains(addr.IP) {
+				return
+			}
+		}
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		defer c.Conn.SetReadDeadline(time.Time{})
+
+		var addr net.Addr
+		addr, c.err = readHeader(c.reader)
+		if addr != nil {
+			c.remote = addr
+		}
+	})
+}
+
+// Read reads data after the header.
+func (c *conn) Read(p []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(p)
+}
+
+// RemoteAddr returns the client address in the header, or the peer address if
+// there is none.
+func (c *conn) RemoteAddr() net.Addr {
+	c.init()
+	return c.remote
+}
+
+// readHeader consumes the header, if any. The returned address is nil if the
+// connection has no header, or the header carries no address.
+func readHeader(r *bufio.Reader) (net.Addr, error) {
+	peek, err := r.Peek(len(v1Prefix))
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if bytes.Equal(peek, v1Prefix) {
+		return readV1(r)
+	}
+	if peek[0] != v2Signature[0] {
+		return nil, nil
+	}
+	peek, err = r.Peek(len(v2Signature))
+	if err != nil || !bytes.Equal(peek, v2Signature) {
+		return nil, nil
+	}
+	return readV2(r)
+}
+
+// readV1 parses "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < 107 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrInvalidHeader
+	}
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, ErrInvalidHeader
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.Atoi(fields[4])
+	if ip == nil || err != nil || port < 0 || port > 65535 {
+		return nil, ErrInvalidHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// readV2 parses the binary header.
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[12]>>4 != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHeader, header[12]>>4)
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	// LOCAL connections, eg. health checks of the proxy, carry no address.
+	if header[12]&0x0f == 0 {
+		return nil, nil
+	}
+	switch header[13] >> 4 {
+	case 1: // AF_INET
+		if len(payload) < 12 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:]))}, nil
+	case 2: // AF_INET6
+		if len(payload) < 36 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:]))}, nil
+	default:
+		return nil, nil
+	}
+}
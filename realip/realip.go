@@ -0,0 +1,107 @@
+package realip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Option is the configuration of Resolver.
+type Option struct {
+	// TrustedProxies are the CIDRs or IPs of the load balancers and reverse
+	// proxies in front of the application. Forwarding headers and PROXY
+	// protocol headers are only honored from these addresses.
+	TrustedProxies []string `json:"trustedProxies" yaml:"trustedProxies"`
+}
+
+// Trusted is a list of trusted networks.
+type Trusted []*net.IPNet
+
+// ParseTrusted parses the CIDRs or IPs.
+func ParseTrusted(cidrs []string) (Trusted, error) {
+	var trusted Trusted
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %s: %w", cidr, err)
+		}
+		trusted = append(trusted, network)
+	}
+	return trusted, nil
+}
+
+// Contains reports whether the ip belongs to a trusted network.
+func (t Trusted) Contains(ip net.IP) bool {
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver resolves the client IP of requests that passed through trusted
+// proxies.
+type Resolver struct {
+	trusted Trusted
+}
+
+// NewResolver creates a new *Resolver from the given Option.
+func NewResolver(option Option) (*Resolver, error) {
+	trusted, err := ParseTrusted(option.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{trusted: trusted}, nil
+}
+
+// Resolve returns the client IP. If the peer is a trusted proxy, the
+// X-Forwarded-For header is walked from right to left, and the first untrusted
+// address is the client. Without X-Forwarded-For, X-Real-IP is used. Headers
+// from untrusted peers are ignored, since they can be forged.
+func (r *Resolver) Resolve(remoteAddr string, header http.Header) string {
+	peer := hostOf(remoteAddr)
+	peerIP := net.ParseIP(peer)
+	if peerIP == nil || !r.trusted.Contains(peerIP) {
+		return peer
+	}
+
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hostOf(hops[i]))
+		if ip == nil {
+			// a malformed hop can't be trusted any further.
+			break
+		}
+		if !r.trusted.Contains(ip) || i == 0 {
+			return ip.String()
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return peer
+}
+
+// hostOf strips the port, if any.
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
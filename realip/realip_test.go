@@ -0,0 +1,149 @@
+package realip
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolver(t *testing.T) {
+	resolver, err := NewResolver(Option{TrustedProxies: []string{"10.0.0.0/8", "127.0.0.1", "::1"}})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{"direct", "1.1.1.1:1234", http.Header{}, "1.1.1.1"},
+		{"untrusted peer", "1.1.1.1:1234", http.Header{"X-Forwarded-For": {"2.2.2.2"}}, "1.1.1.1"},
+		{"trusted peer", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"2.2.2.2"}}, "2.2.2.2"},
+		{"forged hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"3.3.3.3, 2.2.2.2, 10.0.0.2"}}, "2.2.2.2"},
+		{"multiple headers", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"3.3.3.3", "2.2.2.2"}}, "2.2.2.2"},
+		{"all trusted", "127.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"real ip", "[::1]:1234", http.Header{"X-Real-Ip": {"2.2.2.2"}}, "2.2.2.2"},
+		{"malformed", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"foo"}}, "10.0.0.1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, resolver.Resolve(c.remoteAddr, c.header))
+		})
+	}
+
+	_, err = NewResolver(Option{TrustedProxies: []string{"foo"}})
+	assert.Error(t, err)
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	resolver, _ := NewResolver(Option{TrustedProxies: []string{"10.0.0.0/8"}})
+	handler := MakeHTTPMiddleware(resolver)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "2.2.2.2", request.Context().Value(contract.IpKey))
+		assert.Equal(t, "2.2.2.2:1234", request.RemoteAddr)
+	}))
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "2.2.2.2")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+}
+
+func v2Header(ip net.IP, port uint16) []byte {
+	payload := make([]byte, 12)
+	copy(payload, ip.To4())
+	copy(payload[4:], net.IPv4(10, 0, 0, 1).To4())
+	binary.BigEndian.PutUint16(payload[8:], port)
+	binary.BigEndian.PutUint16(payload[10:], 443)
+	header := append([]byte{}, v2Signature...)
+	header = append(header, 0x21, 0x11, 0, byte(len(payload)))
+	return append(header, payload...)
+}
+
+func TestReadHeader(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		addr  string
+		rest  string
+		err   bool
+	}{
+		{"none", "GET / HTTP/1.1\r\n", "", "GET / HTTP/1.1\r\n", false},
+		{"v1", "PROXY TCP4 2.2.2.2 10.0.0.1 5678 443\r\nGET /", "2.2.2.2:5678", "GET /", false},
+		{"v1 ipv6", "PROXY TCP6 ::2 ::1 5678 443\r\nGET /", "[::2]:5678", "GET /", false},
+		{"v1 unknown", "PROXY UNKNOWN\r\nGET /", "", "GET /", false},
+		{"v1 invalid", "PROXY TCP4 foo\r\nGET /", "", "", true},
+		{"v2", string(v2Header(net.IPv4(2, 2, 2, 2), 5678)) + "GET /", "2.2.2.2:5678", "GET /", false},
+		{"short", "GET", "", "GET", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(c.input))
+			addr, err := readHeader(r)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if c.addr == "" {
+				assert.Nil(t, addr)
+			} else {
+				assert.Equal(t, c.addr, addr.String())
+			}
+			rest, _ := ioutil.ReadAll(r)
+			assert.Equal(t, c.rest, string(rest))
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	trusted, _ := ParseTrusted([]string{"127.0.0.1"})
+	server := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.RemoteAddr))
+	})}
+	go server.Serve(&Listener{Listener: ln, Trusted: trusted})
+	defer server.Shutdown(context.Background())
+
+	get := func(header string) string {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer conn.Close()
+		conn.Write([]byte(header + "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return string(b)
+	}
+	assert.Equal(t, "2.2.2.2:5678", get("PROXY TCP4 2.2.2.2 127.0.0.1 5678 443\r\n"))
+	assert.True(t, strings.HasPrefix(get(""), "127.0.0.1:"))
+
+	// the header is not honored from untrusted peers.
+	ln2, _ := net.Listen("tcp", "127.0.0.1:0")
+	untrusted, _ := ParseTrusted([]string{"10.0.0.1"})
+	conns := make(chan net.Conn, 1)
+	go func() {
+		c, _ := (&Listener{Listener: ln2, Trusted: untrusted}).Accept()
+		conns <- c
+	}()
+	client, _ := net.Dial("tcp", ln2.Addr().String())
+	defer client.Close()
+	client.Write([]byte("PROXY TCP4 2.2.2.2 127.0.0.1 5678 443\r\n"))
+	c := <-conns
+	defer c.Close()
+	assert.True(t, strings.HasPrefix(c.RemoteAddr().String(), "127.0.0.1:"))
+	ln2.Close()
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/logging"
+	"go.uber.org/automaxprocs/maxprocs"
+)
+
+// runtimeOption tunes the scheduler and the garbage collector. It is configured
+// under "runtime".
+type runtimeOption struct {
+	// MaxProcs overrides GOMAXPROCS. 0 derives it from the cgroup CPU quota in
+	// containers, unless the GOMAXPROCS environment variable is set.
+	MaxProcs int `json:"maxProcs" yaml:"maxProcs"`
+	// GCPercent overrides GOGC. -1 disables the GC, leaving the memory limit as
+	// the only trigger. 0 leaves GOGC untouched.
+	GCPercent int `json:"gcPercent" yaml:"gcPercent"`
+	// MemoryLimit is the soft memory limit, eg. "1GiB". It requires go1.19.
+	MemoryLimit string `json:"memoryLimit" yaml:"memoryLimit"`
+	// Ballast is the size of a heap allocation that is never used, eg.
+	// "256MiB". It delays GC cycles of services with a small live heap.
+	Ballast string `json:"ballast" yaml:"ballast"`
+}
+
+// ballast keeps the allocation alive. Being never written, it occupies virtual
+// memory only.
+var ballast []byte
+
+// applyRuntime applies the runtime configuration, and logs the GC values in
+// effect. The effective GOMAXPROCS is logged by the serve command.
+func applyRuntime(conf contract.ConfigAccessor, logger logging.LevelLogger) error {
+	var option runtimeOption
+	if err := conf.Unmarshal("runtime", &option); err != nil {
+		return fmt.Errorf("runtime configuration error: %w", err)
+	}
+	memoryLimit, err := parseBytes(option.MemoryLimit)
+	if err != nil {
+		return fmt.Errorf("the runtime.memoryLimit field is not valid: %w", err)
+	}
+	ballastSize, err := parseBytes(option.Ballast)
+	if err != nil {
+		return fmt.Errorf("the runtime.ballast field is not valid: %w", err)
+	}
+	if option.MaxProcs > 0 {
+		runtime.GOMAXPROCS(option.MaxProcs)
+	} else if _, err := maxprocs.Set(maxprocs.Logger(func(string, ...interface{}) {})); err != nil {
+		logger.Warnf("failed to set GOMAXPROCS from the CPU quota: %s", err)
+	}
+
+	if option.GCPercent == 0 && memoryLimit == 0 && ballastSize == 0 {
+		return nil
+	}
+
+	gcPercent := debug.SetGCPercent(100)
+	if option.GCPercent != 0 {
+		gcPercent = option.GCPercent
+	}
+	debug.SetGCPercent(gcPercent)
+	if memoryLimit > 0 {
+		if err := setMemoryLimit(memoryLimit); err != nil {
+			return err
+		}
+	}
+	if ballastSize > 0 {
+		ballast = make([]byte, ballastSize)
+	}
+	logger.Infof("runtime tuned: gcPercent=%d memoryLimit=%d ballast=%d", gcPercent, memoryLimit, ballastSize)
+	return nil
+}
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"B", 1},
+}
+
+// parseBytes parses sizes like "512MiB", "1GB" or "1024". Empty is zero.
+func parseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	size := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, size = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(size)), nil
+}
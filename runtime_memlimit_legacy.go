@@ -0,0 +1,10 @@
+//go:build !go1.19
+// +build !go1.19
+
+package core
+
+import "errors"
+
+func setMemoryLimit(limit int64) error {
+	return errors.New("runtime.memoryLimit requires go1.19 or later")
+}
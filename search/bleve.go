@@ -0,0 +1,176 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// BleveSearcher is a contract.Searcher backed by embedded Bleve indexes, one
+// per index name. It suits single instance applications and tests. The fields
+// are analyzed by the standard analyzer, so facet terms are lower cased tokens.
+// BleveSearcher is safe for concurrent use.
+type BleveSearcher struct {
+	dir string
+
+	mu      sync.Mutex
+	indexes map[string]bleve.Index
+}
+
+// NewBleveSearcher creates a new *BleveSearcher that stores the indexes in the
+// directory. If dir is empty, the indexes are kept in memory.
+func NewBleveSearcher(dir string) *BleveSearcher {
+	return &BleveSearcher{dir: dir, indexes: make(map[string]bleve.Index)}
+}
+
+func (b *BleveSearcher) index(name string) (bleve.Index, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if idx, ok := b.indexes[name]; ok {
+		return idx, nil
+	}
+	var (
+		idx bleve.Index
+		err error
+	)
+	if b.dir == "" {
+		idx, err = bleve.NewMemOnly(bleve.NewIndexMapping())
+	} else {
+		path := filepath.Join(b.dir, filepath.Clean("/"+name))
+		if _, statErr := os.Stat(path); statErr == nil {
+			idx, err = bleve.Open(path)
+		} else {
+			idx, err = bleve.New(path, bleve.NewIndexMapping())
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index %s: %w", name, err)
+	}
+	b.indexes[name] = idx
+	return idx, nil
+}
+
+// Index indexes the document. The JSON encoding of the document is kept
+// alongside, and returned as the source of hits.
+func (b *BleveSearcher) Index(ctx context.Context, index, id string, doc interface{}) error {
+	idx, err := b.index(index)
+	if err != nil {
+		return err
+	}
+	source, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", index, id, err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(source, &fields); err != nil {
+		return fmt.Errorf("document %s/%s is not an object: %w", index, id, err)
+	}
+	if err := idx.Index(id, fields); err != nil {
+		return fmt.Errorf("failed to index %s/%s: %w", index, id, err)
+	}
+	return idx.SetInternal([]byte(id), source)
+}
+
+// Delete deletes the document.
+func (b *BleveSearcher) Delete(ctx context.Context, index, id string) error {
+	idx, err := b.index(index)
+	if err != nil {
+		return err
+	}
+	if err := idx.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w", index, id, err)
+	}
+	return idx.DeleteInternal([]byte(id))
+}
+
+// Query searches the index.
+func (b *BleveSearcher) Query(ctx context.Context, index string, q contract.SearchQuery) (*contract.SearchResult, error) {
+	idx, err := b.index(index)
+	if err != nil {
+		return nil, err
+	}
+	var conjuncts []query.Query
+	if q.Text != "" {
+		conjuncts = append(conjuncts, bleve.NewQueryStringQuery(q.Text))
+	} else {
+		conjuncts = append(conjuncts, bleve.NewMatchAllQuery())
+	}
+	for field, value := range q.Filters {
+		conjuncts = append(conjuncts, filterQuery(field, value))
+	}
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(conjuncts...), sizeOf(q.Size), q.From, false)
+	for _, field := range q.Facets {
+		req.AddFacet(field, bleve.NewFacetRequest(field, sizeOf(q.FacetSize)))
+	}
+	resp, err := idx.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", index, err)
+	}
+
+	result := &contract.SearchResult{Total: int64(resp.Total)}
+	for _, hit := range resp.Hits {
+		source, err := idx.GetInternal([]byte(hit.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s/%s: %w", index, hit.ID, err)
+		}
+		result.Hits = append(result.Hits, contract.SearchHit{ID: hit.ID, Score: hit.Score, Source: source})
+	}
+	if len(resp.Facets) > 0 {
+		result.Facets = make(map[string][]contract.SearchFacet, len(resp.Facets))
+		for field, facet := range resp.Facets {
+			for _, term := range facet.Terms {
+				result.Facets[field] = append(result.Facets[field], contract.SearchFacet{
+					Term:  term.Term,
+					Count: int64(term.Count),
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// Close closes all opened indexes.
+func (b *BleveSearcher) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var firstErr error
+	for name, idx := range b.indexes {
+		if err := idx.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(b.indexes, name)
+	}
+	return firstErr
+}
+
+func filterQuery(field string, value interface{}) query.Query {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		q := bleve.NewBoolFieldQuery(v.Bool())
+		q.SetField(field)
+		return q
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		f := v.Convert(reflect.TypeOf(float64(0))).Float()
+		inclusive := true
+		q := bleve.NewNumericRangeInclusiveQuery(&f, &f, &inclusive, &inclusive)
+		q.SetField(field)
+		return q
+	default:
+		q := bleve.NewMatchPhraseQuery(fmt.Sprint(value))
+		q.SetField(field)
+		return q
+	}
+}
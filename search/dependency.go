@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/otes"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+)
+
+// Option is the configuration of the search engine.
+type Option struct {
+	// Provider is one of "bleve" or "elastic".
+	Provider string        `json:"provider" yaml:"provider"`
+	Bleve    BleveOption   `json:"bleve" yaml:"bleve"`
+	Elastic  ElasticOption `json:"elastic" yaml:"elastic"`
+	// QueueSize is the capacity of the indexer queue.
+	QueueSize int `json:"queueSize" yaml:"queueSize"`
+}
+
+// BleveOption configures BleveSearcher.
+type BleveOption struct {
+	// Dir stores the indexes. If empty, the indexes are kept in memory.
+	Dir string `json:"dir" yaml:"dir"`
+}
+
+// ElasticOption configures ElasticSearcher.
+type ElasticOption struct {
+	// Client is the name of the otes client.
+	Client string `json:"client" yaml:"client"`
+}
+
+/*
+Providers returns a set of dependency providers for package search.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		otes.Maker `optional:"true"`
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		contract.Searcher
+		*Indexer
+*/
+func Providers() di.Deps {
+	return di.Deps{provideSearcher, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger     log.Logger
+	Conf       contract.ConfigAccessor
+	Maker      otes.Maker          `optional:"true"`
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Searcher contract.Searcher
+	Indexer  *Indexer
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideSearcher(in in) (out, func(), error) {
+	var option Option
+	if err := in.Conf.Unmarshal("search", &option); err != nil {
+		return out{}, nil, fmt.Errorf("search configuration error: %w", err)
+	}
+	var (
+		searcher contract.Searcher
+		cleanup  = func() {}
+	)
+	switch option.Provider {
+	case "bleve":
+		bleveSearcher := NewBleveSearcher(option.Bleve.Dir)
+		searcher = bleveSearcher
+		cleanup = func() { _ = bleveSearcher.Close() }
+	case "elastic":
+		if in.Maker == nil {
+			return out{}, nil, fmt.Errorf("search provider elastic requires otes.Providers")
+		}
+		client, err := in.Maker.Make(option.Elastic.Client)
+		if err != nil {
+			return out{}, nil, fmt.Errorf("failed to make elastic client: %w", err)
+		}
+		searcher = ElasticSearcher{Client: client}
+	default:
+		return out{}, nil, fmt.Errorf("unknown search provider %q", option.Provider)
+	}
+	options := []IndexerOption{WithLogger(in.Logger)}
+	if in.Dispatcher != nil {
+		options = append(options, WithDispatcher(in.Dispatcher))
+	}
+	if option.QueueSize > 0 {
+		options = append(options, WithQueueSize(option.QueueSize))
+	}
+	return out{Searcher: searcher, Indexer: NewIndexer(searcher, options...)}, cleanup, nil
+}
+
+// ProvideRunGroup applies the queued index changes in background.
+func (o out) ProvideRunGroup(group *run.Group) {
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		return o.Indexer.Run(ctx)
+	}, func(err error) {
+		cancel()
+	})
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "search",
+			Data: map[string]interface{}{
+				"search": Option{
+					Provider:  "bleve",
+					Bleve:     BleveOption{Dir: "data/search"},
+					Elastic:   ElasticOption{Client: "default"},
+					QueueSize: 1000,
+				},
+			},
+			Comment: "The full-text search engine, one of bleve or elastic",
+		},
+	}}
+}
@@ -0,0 +1,44 @@
+/*
+Package search implements contract.Searcher on top of Elasticsearch and embedded
+Bleve indexes, and keeps the indexes in sync with gorm models.
+
+Integration
+
+package search exports the configuration in the following format:
+
+	search:
+	  provider: bleve
+	  bleve:
+	    dir: data/search
+	  elastic:
+	    client: default
+	  queueSize: 1000
+
+The elastic provider uses the client of the same name from package otes. Add
+the search dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(otes.Providers())
+	c.Provide(search.Providers())
+
+Then consume the contract.Searcher:
+
+	c.Invoke(func(searcher contract.Searcher) {
+		result, err := searcher.Query(ctx, "articles", contract.SearchQuery{
+			Text:   "golang",
+			Size:   20,
+			Facets: []string{"category"},
+		})
+	})
+
+Indexing gorm models
+
+Models implementing Searchable are indexed automatically once the callbacks
+are added to the *gorm.DB. The changes are applied in background through the
+queue of the Indexer, which runs as part of the serve command:
+
+	c.Invoke(func(db *gorm.DB, indexer *search.Indexer) error {
+		return search.AddGormCallbacks(db, indexer)
+	})
+*/
+package search
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/olivere/elastic/v7"
+)
+
+// ElasticSearcher is a contract.Searcher backed by Elasticsearch. With the
+// dynamic mapping, filters and facets should target the keyword sub fields, eg.
+// "category.keyword".
+type ElasticSearcher struct {
+	Client *elastic.Client
+}
+
+// Index indexes the document.
+func (e ElasticSearcher) Index(ctx context.Context, index, id string, doc interface{}) error {
+	if _, err := e.Client.Index().Index(index).Id(id).BodyJson(doc).Do(ctx); err != nil {
+		return fmt.Errorf("failed to index %s/%s: %w", index, id, err)
+	}
+	return nil
+}
+
+// Delete deletes the document.
+func (e ElasticSearcher) Delete(ctx context.Context, index, id string) error {
+	if _, err := e.Client.Delete().Index(index).Id(id).Do(ctx); err != nil && !elastic.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s/%s: %w", index, id, err)
+	}
+	return nil
+}
+
+// Query searches the index.
+func (e ElasticSearcher) Query(ctx context.Context, index string, query contract.SearchQuery) (*contract.SearchResult, error) {
+	bq := elastic.NewBoolQuery()
+	if query.Text != "" {
+		bq.Must(elastic.NewQueryStringQuery(query.Text))
+	} else {
+		bq.Must(elastic.NewMatchAllQuery())
+	}
+	for field, value := range query.Filters {
+		bq.Filter(elastic.NewTermQuery(field, value))
+	}
+	service := e.Client.Search(index).Query(bq).From(query.From).Size(sizeOf(query.Size))
+	for _, field := range query.Facets {
+		service.Aggregation(field, elastic.NewTermsAggregation().Field(field).Size(sizeOf(query.FacetSize)))
+	}
+	resp, err := service.Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", index, err)
+	}
+
+	result := &contract.SearchResult{Total: resp.TotalHits()}
+	if resp.Hits != nil {
+		for _, hit := range resp.Hits.Hits {
+			var score float64
+			if hit.Score != nil {
+				score = *hit.Score
+			}
+			result.Hits = append(result.Hits, contract.SearchHit{ID: hit.Id, Score: score, Source: hit.Source})
+		}
+	}
+	if len(query.Facets) > 0 {
+		result.Facets = make(map[string][]contract.SearchFacet, len(query.Facets))
+		for _, field := range query.Facets {
+			terms, ok := resp.Aggregations.Terms(field)
+			if !ok {
+				continue
+			}
+			for _, bucket := range terms.Buckets {
+				result.Facets[field] = append(result.Facets[field], contract.SearchFacet{
+					Term:  fmt.Sprint(bucket.Key),
+					Count: bucket.DocCount,
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+func sizeOf(size int) int {
+	if size <= 0 {
+		return 10
+	}
+	return size
+}
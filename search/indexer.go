@@ -0,0 +1,200 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"gorm.io/gorm"
+)
+
+// Searchable is implemented by models that are indexed automatically by the
+// gorm callbacks. The model itself, encoded as JSON, is the document.
+type Searchable interface {
+	// SearchIndex returns the name of the index.
+	SearchIndex() string
+	// SearchID returns the id of the document.
+	SearchID() string
+}
+
+// ErrQueueFull is returned when the indexer queue is full.
+var ErrQueueFull = errors.New("search indexer queue is full")
+
+type job struct {
+	index string
+	id    string
+	// doc is nil for deletion.
+	doc json.RawMessage
+}
+
+// Indexer applies index changes to a contract.Searcher asynchronously through
+// an in-process queue, so that writes to the database are not slowed down by
+// the search engine. Changes still queued when the application crashes are lost.
+type Indexer struct {
+	searcher   contract.Searcher
+	queue      chan job
+	logger     log.Logger
+	dispatcher contract.Dispatcher
+}
+
+// IndexerOption changes the behavior of Indexer.
+type IndexerOption func(*Indexer)
+
+// WithQueueSize sets the capacity of the queue. Defaults to 1000.
+func WithQueueSize(size int) IndexerOption {
+	return func(indexer *Indexer) {
+		indexer.queue = make(chan job, size)
+	}
+}
+
+// WithLogger sets the logger for failed index changes.
+func WithLogger(logger log.Logger) IndexerOption {
+	return func(indexer *Indexer) {
+		indexer.logger = logger
+	}
+}
+
+// WithDispatcher sets the dispatcher to which events.OnQueueJobFailed is
+// dispatched for failed index changes.
+func WithDispatcher(dispatcher contract.Dispatcher) IndexerOption {
+	return func(indexer *Indexer) {
+		indexer.dispatcher = dispatcher
+	}
+}
+
+// NewIndexer creates a new *Indexer. Run must be called for the queued changes
+// to be applied.
+func NewIndexer(searcher contract.Searcher, options ...IndexerOption) *Indexer {
+	indexer := &Indexer{
+		searcher: searcher,
+		queue:    make(chan job, 1000),
+		logger:   log.NewNopLogger(),
+	}
+	for _, option := range options {
+		option(indexer)
+	}
+	return indexer
+}
+
+// EnqueueIndex queues the model for indexing. The model is encoded right away,
+// so later changes to it are not picked up.
+func (i *Indexer) EnqueueIndex(model Searchable) error {
+	doc, err := json.Marshal(model)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", model.SearchIndex(), model.SearchID(), err)
+	}
+	return i.enqueue(job{index: model.SearchIndex(), id: model.SearchID(), doc: doc})
+}
+
+// EnqueueDelete queues the model for deletion from the index.
+func (i *Indexer) EnqueueDelete(model Searchable) error {
+	return i.enqueue(job{index: model.SearchIndex(), id: model.SearchID()})
+}
+
+func (i *Indexer) enqueue(j job) error {
+	select {
+	case i.queue <- j:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run applies the queued changes until the context is canceled. The changes
+// queued by then are applied before Run returns.
+func (i *Indexer) Run(ctx context.Context) error {
+	for {
+		select {
+		case j := <-i.queue:
+			i.apply(ctx, j)
+		case <-ctx.Done():
+			for {
+				select {
+				case j := <-i.queue:
+					i.apply(context.Background(), j)
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (i *Indexer) apply(ctx context.Context, j job) {
+	var err error
+	if j.doc == nil {
+		err = i.searcher.Delete(ctx, j.index, j.id)
+	} else {
+		err = i.searcher.Index(ctx, j.index, j.id, j.doc)
+	}
+	if err != nil {
+		level.Warn(i.logger).Log("msg", "failed to update search index", "index", j.index, "id", j.id, "err", err)
+		if i.dispatcher != nil {
+			_ = i.dispatcher.Dispatch(ctx, events.OnQueueJobFailed, events.OnQueueJobFailedPayload{
+				Queue: "search",
+				Job:   j.index + "/" + j.id,
+				Err:   err,
+			})
+		}
+	}
+}
+
+// AddGormCallbacks registers callbacks on the db, so that the created, updated
+// and deleted models implementing Searchable are queued to the indexer. Batch
+// updates and deletes without primary keys are not tracked.
+func AddGormCallbacks(db *gorm.DB, indexer *Indexer) error {
+	if err := db.Callback().Create().After("gorm:create").Register("search:create", indexer.afterSave); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("search:update", indexer.afterSave); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("search:delete", indexer.afterDelete)
+}
+
+func (i *Indexer) afterSave(db *gorm.DB)   { i.enqueueModels(db, i.EnqueueIndex) }
+func (i *Indexer) afterDelete(db *gorm.DB) { i.enqueueModels(db, i.EnqueueDelete) }
+
+func (i *Indexer) enqueueModels(db *gorm.DB, enqueue func(Searchable) error) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for j := 0; j < rv.Len(); j++ {
+			i.enqueueValue(db, reflect.Indirect(rv.Index(j)), enqueue)
+		}
+	case reflect.Struct:
+		i.enqueueValue(db, rv, enqueue)
+	}
+}
+
+func (i *Indexer) enqueueValue(db *gorm.DB, v reflect.Value, enqueue func(Searchable) error) {
+	if field := db.Statement.Schema.PrioritizedPrimaryField; field != nil {
+		if _, zero := field.ValueOf(v); zero {
+			return
+		}
+	}
+	var (
+		model Searchable
+		ok    bool
+	)
+	if v.CanAddr() {
+		model, ok = v.Addr().Interface().(Searchable)
+	} else {
+		model, ok = v.Interface().(Searchable)
+	}
+	if !ok {
+		return
+	}
+	if err := enqueue(model); err != nil {
+		level.Warn(i.logger).Log("msg", "failed to queue search index update", "index", model.SearchIndex(), "id", model.SearchID(), "err", err)
+	}
+}
@@ -0,0 +1,95 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type article struct {
+	ID       uint   `json:"id"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	Stars    int    `json:"stars"`
+}
+
+func (a *article) SearchIndex() string { return "articles" }
+func (a *article) SearchID() string    { return strconv.Itoa(int(a.ID)) }
+
+func TestBleveSearcher(t *testing.T) {
+	ctx := context.Background()
+	searcher := NewBleveSearcher("")
+	defer searcher.Close()
+
+	articles := []article{
+		{ID: 1, Title: "learning go", Category: "programming", Stars: 5},
+		{ID: 2, Title: "go concurrency patterns", Category: "programming", Stars: 3},
+		{ID: 3, Title: "the go board game", Category: "games", Stars: 5},
+	}
+	for i := range articles {
+		assert.NoError(t, searcher.Index(ctx, "articles", articles[i].SearchID(), articles[i]))
+	}
+
+	result, err := searcher.Query(ctx, "articles", contract.SearchQuery{Text: "go", Facets: []string{"category"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.ElementsMatch(t, []contract.SearchFacet{{Term: "programming", Count: 2}, {Term: "games", Count: 1}}, result.Facets["category"])
+
+	result, err = searcher.Query(ctx, "articles", contract.SearchQuery{Text: "go", Filters: map[string]interface{}{"stars": 5, "category": "programming"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	var a article
+	assert.NoError(t, json.Unmarshal(result.Hits[0].Source, &a))
+	assert.Equal(t, articles[0], a)
+
+	result, err = searcher.Query(ctx, "articles", contract.SearchQuery{From: 1, Size: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Len(t, result.Hits, 1)
+
+	assert.NoError(t, searcher.Delete(ctx, "articles", "1"))
+	result, err = searcher.Query(ctx, "articles", contract.SearchQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+}
+
+func TestAddGormCallbacks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	searcher := NewBleveSearcher("")
+	defer searcher.Close()
+	indexer := NewIndexer(searcher)
+	done := make(chan struct{})
+	go func() {
+		indexer.Run(ctx)
+		close(done)
+	}()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&article{}))
+	assert.NoError(t, AddGormCallbacks(db, indexer))
+
+	assert.NoError(t, db.Create(&[]article{{Title: "learning go"}, {Title: "learning rust"}}).Error)
+	first := article{ID: 1}
+	assert.NoError(t, db.Model(&first).Update("title", "mastering go").Error)
+	assert.NoError(t, db.Delete(&article{ID: 2}).Error)
+
+	query := func(text string) int64 {
+		result, err := searcher.Query(context.Background(), "articles", contract.SearchQuery{Text: text})
+		assert.NoError(t, err)
+		return result.Total
+	}
+	assert.Eventually(t, func() bool {
+		return query("mastering") == 1 && query("learning") == 0
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,130 @@
+package secrets
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+)
+
+// Option is the configuration of the secret store.
+type Option struct {
+	// Provider is one of "file", "vault", "aws" or "gcp".
+	Provider string `json:"provider" yaml:"provider"`
+	// TTL is how long a secret is cached.
+	TTL config.Duration `json:"ttl" yaml:"ttl"`
+	// PollInterval is the interval at which watched secrets are fetched.
+	PollInterval config.Duration `json:"pollInterval" yaml:"pollInterval"`
+	File         FileOption      `json:"file" yaml:"file"`
+	Vault        VaultOption     `json:"vault" yaml:"vault"`
+	AWS          AWSOption       `json:"aws" yaml:"aws"`
+}
+
+// FileOption configures FileFetcher.
+type FileOption struct {
+	Dir string `json:"dir" yaml:"dir"`
+}
+
+// VaultOption configures VaultFetcher.
+type VaultOption struct {
+	Addr  string `json:"addr" yaml:"addr"`
+	Token string `json:"token" yaml:"token"`
+}
+
+// AWSOption configures AWSFetcher.
+type AWSOption struct {
+	Region string `json:"region" yaml:"region"`
+}
+
+// NewFetcher creates the Fetcher described by the Option.
+func NewFetcher(option Option, client contract.HttpDoer) (Fetcher, error) {
+	switch option.Provider {
+	case "file":
+		return FileFetcher{Dir: option.File.Dir}, nil
+	case "vault":
+		return VaultFetcher{Addr: option.Vault.Addr, Token: option.Vault.Token, Client: client}, nil
+	case "aws":
+		return NewAWSFetcher(option.AWS.Region)
+	case "gcp":
+		return GCPFetcher{Client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret provider %q", option.Provider)
+	}
+}
+
+/*
+Providers returns a set of dependency providers for package secrets.
+
+	Depends On:
+		contract.ConfigAccessor
+		contract.HttpDoer `optional:"true"`
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		contract.SecretStore
+		*Store
+*/
+func Providers() di.Deps {
+	return di.Deps{provideStore, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Conf       contract.ConfigAccessor
+	Client     contract.HttpDoer   `optional:"true"`
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Store       *Store
+	SecretStore contract.SecretStore
+}
+
+func provideStore(in in) (out, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("secrets", &option); err != nil {
+		return out{}, fmt.Errorf("secrets configuration error: %w", err)
+	}
+	fetcher, err := NewFetcher(option, in.Client)
+	if err != nil {
+		return out{}, fmt.Errorf("secrets configuration error: %w", err)
+	}
+	options := []StoreOption{WithDispatcher(in.Dispatcher)}
+	if option.TTL.Duration > 0 {
+		options = append(options, WithTTL(option.TTL.Duration))
+	}
+	if option.PollInterval.Duration > 0 {
+		options = append(options, WithPollInterval(option.PollInterval.Duration))
+	}
+	store := NewStore(fetcher, options...)
+	return out{Store: store, SecretStore: store}, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "secrets",
+			Data: map[string]interface{}{
+				"secrets": Option{
+					Provider:     "file",
+					TTL:          config.Duration{Duration: 5 * time.Minute},
+					PollInterval: config.Duration{Duration: time.Minute},
+					File:         FileOption{Dir: "/var/run/secrets/app"},
+					Vault:        VaultOption{Addr: "http://127.0.0.1:8200"},
+					AWS:          AWSOption{Region: "us-east-1"},
+				},
+			},
+			Comment: "The secret store, one of file, vault, aws or gcp",
+		},
+	}}
+}
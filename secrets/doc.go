@@ -0,0 +1,38 @@
+/*
+Package secrets implements contract.SecretStore on top of files, Vault, AWS
+Secrets Manager and GCP Secret Manager.
+
+Secrets are referred to by a provider specific reference, optionally followed by
+"#key" to select a field of a JSON secret, eg. "secret/data/db#password" for
+Vault. The Store caches the secrets in memory, and watches them by polling, so
+that consumers such as database connections can pick up rotated credentials
+without restarts. When a watched secret changes, OnRotate is dispatched.
+
+Integration
+
+package secrets exports the configuration in the following format:
+
+	secrets:
+	  provider: file
+	  ttl: 5m
+	  pollInterval: 1m
+	  file:
+	    dir: /var/run/secrets/app
+	  vault:
+	    addr: http://127.0.0.1:8200
+	    token: ""
+	  aws:
+	    region: us-east-1
+
+Add the secrets dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(secrets.Providers())
+
+Then consume the contract.SecretStore:
+
+	c.Invoke(func(store contract.SecretStore) {
+		password, err := store.Get(ctx, "db#password")
+	})
+*/
+package secrets
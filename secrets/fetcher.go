@@ -0,0 +1,230 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// splitRef splits a reference in the form of "name#key". The key selects a
+// field if the secret is a JSON object.
+func splitRef(ref string) (name, key string) {
+	if i := strings.LastIndex(ref, "#"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// pick returns the field of the JSON object, or the value itself if key is
+// empty.
+func pick(value []byte, key string) ([]byte, error) {
+	if key == "" {
+		return value, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	return field(fields, key)
+}
+
+func field(fields map[string]interface{}, key string) ([]byte, error) {
+	v, ok := fields[key]
+	if !ok {
+		return nil, fmt.Errorf("secret has no field %s", key)
+	}
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(v)
+}
+
+// FileFetcher reads secrets from files, such as the kubernetes secret volumes.
+// The reference is the file path relative to Dir, optionally followed by
+// "#key" to select a field of a JSON file.
+type FileFetcher struct {
+	Dir string
+}
+
+// Fetch reads the file.
+func (f FileFetcher) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	name, key := splitRef(ref)
+	path := filepath.Join(f.Dir, filepath.Clean("/"+name))
+	value, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return pick([]byte(strings.TrimRight(string(value), "\r\n")), key)
+}
+
+// VaultFetcher reads secrets from the Vault KV secrets engine. The reference
+// is the API path and the field, eg. "secret/data/db#password" for KV version 2
+// or "kv/db#password" for version 1.
+type VaultFetcher struct {
+	// Addr is the Vault address, eg. "https://vault:8200".
+	Addr string
+	// Token is the Vault token.
+	Token string
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client contract.HttpDoer
+}
+
+// Fetch reads the secret.
+func (v VaultFetcher) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	name, key := splitRef(ref)
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(v.Addr, "/"), strings.TrimPrefix(name, "/")), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s from vault: %w", name, err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Errors []string               `json:"errors"`
+		Data   map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response (%d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read secret %s from vault (%d): %s", name, resp.StatusCode, strings.Join(result.Errors, "; "))
+	}
+	data := result.Data
+	// KV version 2 nests the secret in data.data.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	if key == "" {
+		return json.Marshal(data)
+	}
+	return field(data, key)
+}
+
+// AWSFetcher reads secrets from AWS Secrets Manager. The reference is the
+// secret id, optionally followed by "#key" to select a field of a JSON secret.
+type AWSFetcher struct {
+	Client *secretsmanager.SecretsManager
+}
+
+// NewAWSFetcher creates an AWSFetcher with the default credential chain.
+func NewAWSFetcher(region string) (AWSFetcher, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return AWSFetcher{}, err
+	}
+	return AWSFetcher{Client: secretsmanager.New(sess)}, nil
+}
+
+// Fetch reads the secret.
+func (a AWSFetcher) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	name, key := splitRef(ref)
+	out, err := a.Client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s from aws: %w", name, err)
+	}
+	value := out.SecretBinary
+	if out.SecretString != nil {
+		value = []byte(*out.SecretString)
+	}
+	return pick(value, key)
+}
+
+// GCPFetcher reads secrets from GCP Secret Manager through its REST API. The
+// reference is the secret version resource name, eg.
+// "projects/p/secrets/db/versions/latest", optionally followed by "#key".
+type GCPFetcher struct {
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client contract.HttpDoer
+	// Token returns the access token. Defaults to the token of the default
+	// service account from the metadata server.
+	Token func(ctx context.Context) (string, error)
+	// Endpoint defaults to "https://secretmanager.googleapis.com".
+	Endpoint string
+}
+
+// Fetch reads the secret.
+func (g GCPFetcher) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	name, key := splitRef(ref)
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	token := g.Token
+	if token == nil {
+		token = func(ctx context.Context) (string, error) { return metadataToken(ctx, client) }
+	}
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com"
+	}
+	accessToken, err := token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gcp access token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s:access", endpoint, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s from gcp: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to read secret %s from gcp (%d): %s", name, resp.StatusCode, body)
+	}
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode gcp response: %w", err)
+	}
+	value, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gcp secret: %w", err)
+	}
+	return pick(value, key)
+}
+
+func metadataToken(ctx context.Context, client contract.HttpDoer) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.AccessToken, nil
+}
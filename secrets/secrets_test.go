@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingFetcher struct {
+	count int32
+}
+
+func (c *countingFetcher) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	n := atomic.AddInt32(&c.count, 1)
+	return []byte(fmt.Sprintf("%s%d", ref, n)), nil
+}
+
+func TestStore(t *testing.T) {
+	fetcher := &countingFetcher{}
+	store := NewStore(fetcher, WithTTL(time.Hour), WithPollInterval(10*time.Millisecond))
+	ctx := context.Background()
+
+	value, err := store.Get(ctx, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo1", string(value))
+	value, _ = store.Get(ctx, "foo")
+	assert.Equal(t, "foo1", string(value))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	rotated := make(chan string, 1)
+	go store.Watch(ctx, "foo", func(value []byte) error {
+		rotated <- string(value)
+		return fmt.Errorf("stop")
+	})
+	assert.Equal(t, "foo2", <-rotated)
+	value, _ = store.Get(ctx, "foo")
+	assert.Equal(t, "foo2", string(value))
+}
+
+func TestFileFetcher(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "secrets")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "password"), []byte("foo\n"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "db.json"), []byte(`{"user":"bar"}`), 0600)
+
+	value, err := FileFetcher{Dir: dir}.Fetch(context.Background(), "password")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(value))
+
+	value, err = FileFetcher{Dir: dir}.Fetch(context.Background(), "db.json#user")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(value))
+
+	_, err = FileFetcher{Dir: dir}.Fetch(context.Background(), "../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestVaultFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "token", request.Header.Get("X-Vault-Token"))
+		switch request.URL.Path {
+		case "/v1/secret/data/db":
+			json.NewEncoder(writer).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"data": map[string]interface{}{"password": "v2"}, "metadata": map[string]interface{}{}},
+			})
+		case "/v1/kv/db":
+			json.NewEncoder(writer).Encode(map[string]interface{}{"data": map[string]interface{}{"password": "v1"}})
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+			writer.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := VaultFetcher{Addr: server.URL, Token: "token"}
+	value, err := fetcher.Fetch(context.Background(), "secret/data/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(value))
+	value, err = fetcher.Fetch(context.Background(), "kv/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(value))
+	_, err = fetcher.Fetch(context.Background(), "kv/unknown#password")
+	assert.Error(t, err)
+}
+
+func TestGCPFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/v1/projects/p/secrets/db/versions/latest:access", request.URL.Path)
+		assert.Equal(t, "Bearer token", request.Header.Get("Authorization"))
+		json.NewEncoder(writer).Encode(map[string]interface{}{
+			"payload": map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("foo"))},
+		})
+	}))
+	defer server.Close()
+
+	fetcher := GCPFetcher{
+		Endpoint: server.URL,
+		Token:    func(ctx context.Context) (string, error) { return "token", nil },
+	}
+	value, err := fetcher.Fetch(context.Background(), "projects/p/secrets/db/versions/latest")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(value))
+}
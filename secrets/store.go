@@ -0,0 +1,141 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"golang.org/x/sync/singleflight"
+)
+
+type event string
+
+// OnRotate is an event triggered when Store notices that a watched secret has
+// changed. The event payload is OnRotatePayload.
+const OnRotate event = "onSecretRotate"
+
+// OnRotatePayload is the payload of OnRotate. The value is deliberately left
+// out, so that it doesn't leak into logs.
+type OnRotatePayload struct {
+	Ref string
+}
+
+// Fetcher reads a secret from its backend, such as Vault or AWS Secrets
+// Manager.
+type Fetcher interface {
+	Fetch(ctx context.Context, ref string) ([]byte, error)
+}
+
+// Store is a contract.SecretStore that caches the secrets fetched by a Fetcher
+// in memory. Secrets are watched by polling the Fetcher. Store is safe for
+// concurrent use.
+type Store struct {
+	fetcher      Fetcher
+	ttl          time.Duration
+	pollInterval time.Duration
+	dispatcher   contract.Dispatcher
+	group        singleflight.Group
+
+	mu    sync.Mutex
+	cache map[string]entry
+}
+
+type entry struct {
+	value     []byte
+	fetchedAt time.Time
+}
+
+// StoreOption changes the behavior of Store.
+type StoreOption func(*Store)
+
+// WithTTL sets how long a secret is cached. Defaults to 5m.
+func WithTTL(ttl time.Duration) StoreOption {
+	return func(store *Store) {
+		store.ttl = ttl
+	}
+}
+
+// WithPollInterval sets the interval at which watched secrets are fetched.
+// Defaults to 1m.
+func WithPollInterval(interval time.Duration) StoreOption {
+	return func(store *Store) {
+		store.pollInterval = interval
+	}
+}
+
+// WithDispatcher sets the dispatcher for OnRotate.
+func WithDispatcher(dispatcher contract.Dispatcher) StoreOption {
+	return func(store *Store) {
+		store.dispatcher = dispatcher
+	}
+}
+
+// NewStore creates a new *Store.
+func NewStore(fetcher Fetcher, options ...StoreOption) *Store {
+	store := &Store{
+		fetcher:      fetcher,
+		ttl:          5 * time.Minute,
+		pollInterval: time.Minute,
+		cache:        make(map[string]entry),
+	}
+	for _, option := range options {
+		option(store)
+	}
+	return store
+}
+
+// Get returns the cached secret, or fetches it if the cache is expired.
+func (s *Store) Get(ctx context.Context, ref string) ([]byte, error) {
+	s.mu.Lock()
+	e, ok := s.cache[ref]
+	s.mu.Unlock()
+	if ok && time.Since(e.fetchedAt) < s.ttl {
+		return e.value, nil
+	}
+	return s.fetch(ctx, ref)
+}
+
+func (s *Store) fetch(ctx context.Context, ref string) ([]byte, error) {
+	v, err, _ := s.group.Do(ref, func() (interface{}, error) {
+		value, err := s.fetcher.Fetch(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.cache[ref] = entry{value: value, fetchedAt: time.Now()}
+		s.mu.Unlock()
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// Watch polls the secret and calls rotate when it changes. Fetch errors are
+// ignored, so that a flaky backend doesn't stop the watch.
+func (s *Store) Watch(ctx context.Context, ref string, rotate func(value []byte) error) error {
+	last, _ := s.Get(ctx, ref)
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			value, err := s.fetch(ctx, ref)
+			if err != nil || bytes.Equal(value, last) {
+				continue
+			}
+			last = value
+			if s.dispatcher != nil {
+				_ = s.dispatcher.Dispatch(ctx, OnRotate, OnRotatePayload{Ref: ref})
+			}
+			if err := rotate(value); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
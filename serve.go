@@ -7,17 +7,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
 	"syscall"
 
+	"github.com/DoNewsCode/core/boot"
+	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/container"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/cronopts"
 	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+	"github.com/DoNewsCode/core/graceful"
 	"github.com/DoNewsCode/core/logging"
+	"github.com/DoNewsCode/core/realip"
+	"github.com/DoNewsCode/core/srvgrpc"
+	"github.com/DoNewsCode/core/srvhttp"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
@@ -27,13 +36,18 @@ import (
 type serveIn struct {
 	di.In
 
-	Dispatcher contract.Dispatcher
-	Config     contract.ConfigAccessor
-	Logger     log.Logger
-	Container  contract.Container
-	HTTPServer *http.Server `optional:"true"`
-	GRPCServer *grpc.Server `optional:"true"`
-	Cron       *cron.Cron   `optional:"true"`
+	Dispatcher  contract.Dispatcher
+	Config      contract.ConfigAccessor
+	Logger      log.Logger
+	Container   contract.Container
+	HTTPServer  *http.Server       `optional:"true"`
+	GRPCServer  *grpc.Server       `optional:"true"`
+	Cron        *cron.Cron         `optional:"true"`
+	Tracker     *graceful.Tracker  `optional:"true"`
+	Tracer      opentracing.Tracer `optional:"true"`
+	HotPlug     *HotPlug           `optional:"true"`
+	ConfigFlags *config.Flags      `optional:"true"`
+	BootKinds   []boot.Kind        `group:"bootKinds"`
 }
 
 func NewServeModule(in serveIn) serveModule {
@@ -63,7 +77,12 @@ func (s serveIn) httpServe(ctx context.Context, logger logging.LevelLogger) (fun
 		s.HTTPServer = &http.Server{}
 	}
 	router := mux.NewRouter()
+	router.Use(srvhttp.MakeLabelMiddleware())
 	s.Container.ApplyRouter(router)
+	var handler http.Handler = router
+	if s.HotPlug != nil {
+		handler = s.HotPlug.handler(router)
+	}
 
 	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 		tpl, _ := route.GetPathTemplate()
@@ -71,10 +90,26 @@ func (s serveIn) httpServe(ctx context.Context, logger logging.LevelLogger) (fun
 		return nil
 	})
 
-	s.HTTPServer.Handler = router
+	var rules []srvhttp.Rule
+	if err := s.Config.Unmarshal("http.rules", &rules); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read http.rules")
+	}
+	engine, err := srvhttp.NewRules(rules)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid http.rules")
+	}
+	s.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+		var rules []srvhttp.Rule
+		if err := event.(events.OnReloadPayload).NewConf.Unmarshal("http.rules", &rules); err != nil {
+			return errors.Wrap(err, "failed to read http.rules")
+		}
+		return errors.Wrap(engine.Update(rules), "invalid http.rules")
+	}))
+
+	s.HTTPServer.Handler = srvhttp.MakeRulesMiddleware(engine)(handler)
 
 	httpAddr := s.Config.String("http.addr")
-	ln, err := net.Listen("tcp", httpAddr)
+	ln, err := s.listen(httpAddr, s.Config.Bool("http.proxyProtocol"))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed start http server")
 	}
@@ -85,14 +120,28 @@ func (s serveIn) httpServe(ctx context.Context, logger logging.LevelLogger) (fun
 				OnHTTPServerStart,
 				OnHTTPServerStartPayload{s.HTTPServer, ln},
 			)
+			// The shutdown is dispatched after ctx is canceled.
 			defer s.Dispatcher.Dispatch(
-				ctx,
+				context.Background(),
 				OnHTTPServerShutdown,
 				OnHTTPServerShutdownPayload{s.HTTPServer, ln},
 			)
 			return s.HTTPServer.Serve(ln)
 		}, func(err error) {
-			_ = s.HTTPServer.Shutdown(context.Background())
+			if s.Tracker == nil {
+				_ = s.HTTPServer.Shutdown(context.Background())
+				_ = ln.Close()
+				return
+			}
+			s.Tracker.GoAway()
+			ctx, cancel := s.Tracker.Context()
+			defer cancel()
+			// Shutdown doesn't wait for the hijacked connections, hence the
+			// tracker.
+			if err := s.HTTPServer.Shutdown(ctx); err != nil || !s.Tracker.Wait(ctx, "http") {
+				_ = s.HTTPServer.Close()
+				logger.Warnf("killed %d http connections after the grace window", s.Tracker.Kill("http"))
+			}
 			_ = ln.Close()
 		}, nil
 }
@@ -102,7 +151,10 @@ func (s serveIn) grpcServe(ctx context.Context, logger logging.LevelLogger) (fun
 		return nil, nil, nil
 	}
 	if s.GRPCServer == nil {
-		s.GRPCServer = grpc.NewServer()
+		s.GRPCServer = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(srvgrpc.MakeUnaryLabelInterceptor()),
+			grpc.ChainStreamInterceptor(srvgrpc.MakeStreamLabelInterceptor()),
+		)
 	}
 	s.Container.ApplyGRPCServer(s.GRPCServer)
 
@@ -113,7 +165,7 @@ func (s serveIn) grpcServe(ctx context.Context, logger logging.LevelLogger) (fun
 	}
 
 	grpcAddr := s.Config.String("grpc.addr")
-	ln, err := net.Listen("tcp", grpcAddr)
+	ln, err := s.listen(grpcAddr, s.Config.Bool("grpc.proxyProtocol"))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed start grpc server")
 	}
@@ -124,26 +176,67 @@ func (s serveIn) grpcServe(ctx context.Context, logger logging.LevelLogger) (fun
 				OnGRPCServerStart,
 				OnGRPCServerStartPayload{s.GRPCServer, ln},
 			)
+			// The shutdown is dispatched after ctx is canceled.
 			defer s.Dispatcher.Dispatch(
-				ctx,
+				context.Background(),
 				OnGRPCServerShutdown,
 				OnGRPCServerShutdownPayload{s.GRPCServer, ln},
 			)
 			return s.GRPCServer.Serve(ln)
 		}, func(err error) {
-			s.GRPCServer.GracefulStop()
+			if s.Tracker == nil {
+				s.GRPCServer.GracefulStop()
+				_ = ln.Close()
+				return
+			}
+			s.Tracker.GoAway()
+			ctx, cancel := s.Tracker.Context()
+			defer cancel()
+			stopped := make(chan struct{})
+			go func() {
+				s.GRPCServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				logger.Warnf("killed %d grpc streams after the grace window", s.Tracker.Kill("grpc"))
+				s.GRPCServer.Stop()
+				<-stopped
+			}
 			_ = ln.Close()
 		}, nil
 }
 
+// listen listens on the address. If proxyProtocol is true, the PROXY protocol
+// headers from the trusted proxies in "realip.trustedProxies" are honored.
+func (s serveIn) listen(addr string, proxyProtocol bool) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil || !proxyProtocol {
+		return ln, err
+	}
+	trusted, err := realip.ParseTrusted(s.Config.Strings("realip.trustedProxies"))
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return &realip.Listener{Listener: ln, Trusted: trusted}, nil
+}
+
 func (s serveIn) cronServe(ctx context.Context, logger logging.LevelLogger) (func() error, func(err error), error) {
 	if s.Config.Bool("cron.disable") {
 		return nil, nil, nil
 	}
 	if s.Cron == nil {
-		s.Cron = cron.New(cron.WithLogger(cronopts.CronLogAdapter{Logging: s.Logger}))
+		s.Cron = cron.New(
+			cron.WithLogger(cronopts.CronLogAdapter{Logging: s.Logger}),
+			cron.WithChain(cronopts.DispatchEvents(s.Dispatcher), cronopts.LabelJob),
+		)
 	}
 	s.Container.ApplyCron(s.Cron)
+	if s.HotPlug != nil {
+		s.HotPlug.setCron(s.Cron)
+	}
 
 	return func() error {
 			logger.Infof("cron runner started")
@@ -154,6 +247,23 @@ func (s serveIn) cronServe(ctx context.Context, logger logging.LevelLogger) (fun
 		}, nil
 }
 
+// hotPlugServe starts the modules plugged into the HotPlug, and stops them on
+// shutdown.
+func (s serveIn) hotPlugServe(ctx context.Context, logger logging.LevelLogger) (func() error, func(err error), error) {
+	if s.HotPlug == nil {
+		return nil, nil, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return func() error {
+			s.HotPlug.serve()
+			<-ctx.Done()
+			return nil
+		}, func(err error) {
+			cancel()
+			s.HotPlug.stop()
+		}, nil
+}
+
 func (s serveIn) signalWatch(ctx context.Context, logger logging.LevelLogger) (func() error, func(err error), error) {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
@@ -175,6 +285,17 @@ func newServeCmd(s serveIn) *cobra.Command {
 		Use:   "serve",
 		Short: "Start the server",
 		Long:  `Start the gRPC server, HTTP server, and cron job runner.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// the config is loaded before the flags are parsed, so the
+			// flags only take effect after a reload.
+			if s.ConfigFlags == nil || !s.ConfigFlags.Changed() {
+				return nil
+			}
+			if reloader, ok := s.Config.(interface{ Reload() error }); ok {
+				return reloader.Reload()
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
 			var (
@@ -185,12 +306,27 @@ func newServeCmd(s serveIn) *cobra.Command {
 			for _, m := range s.Container.Modules() {
 				l.Debugf("load module: %T", m)
 			}
+			l.Infof("GOMAXPROCS is %d", runtime.GOMAXPROCS(0))
+
+			if tracing, ok := s.Dispatcher.(interface{ SetTracer(opentracing.Tracer) }); ok && s.Tracer != nil {
+				tracing.SetTracer(s.Tracer)
+			}
+
+			// Wait for the dependencies in boot.waitFor before serving.
+			var bootConf boot.Config
+			if err := s.Config.Unmarshal("boot", &bootConf); err != nil {
+				return errors.Wrap(err, "failed to read boot")
+			}
+			if err := boot.NewWaiter(s.BootKinds, s.Logger).Wait(cmd.Context(), bootConf); err != nil {
+				return err
+			}
 
 			// Add serve and signalWatch
 			serves := []runGroupFunc{
 				s.httpServe,
 				s.grpcServe,
 				s.cronServe,
+				s.hotPlugServe,
 				s.signalWatch,
 			}
 
@@ -216,5 +352,8 @@ func newServeCmd(s serveIn) *cobra.Command {
 			return nil
 		},
 	}
+	if s.ConfigFlags != nil {
+		serveCmd.Flags().AddFlagSet(s.ConfigFlags.FlagSet)
+	}
 	return serveCmd
 }
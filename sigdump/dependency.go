@@ -0,0 +1,126 @@
+package sigdump
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/ots3"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+)
+
+/*
+Providers returns a set of dependency providers for *Dumper.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		contract.AppName
+		contract.Env
+		contract.BuildInfo
+		contract.Container
+		ots3.Maker `optional:"true"`
+	Provide:
+		*Dumper
+*/
+func Providers() di.Deps {
+	return di.Deps{provideDumper, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger    log.Logger
+	Conf      contract.ConfigAccessor
+	AppName   contract.AppName
+	Env       contract.Env
+	BuildInfo contract.BuildInfo
+	Container contract.Container
+	Maker     ots3.Maker `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Dumper *Dumper
+	Option Option
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideDumper(in in) (out, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("sigdump", &option); err != nil {
+		return out{}, fmt.Errorf("sigdump configuration error: %w", err)
+	}
+	if option.Dir == "" {
+		option.Dir = os.TempDir()
+	}
+	var storage Storage = Dir(option.Dir)
+	if option.S3 != "" {
+		if in.Maker == nil {
+			return out{}, fmt.Errorf("sigdump configuration error: s3 %s requires ots3.Providers", option.S3)
+		}
+		manager, err := in.Maker.Make(option.S3)
+		if err != nil {
+			return out{}, fmt.Errorf("sigdump configuration error: %w", err)
+		}
+		storage = UploaderStorage{Uploader: manager}
+	}
+
+	startedAt := time.Now()
+	report := func() Report {
+		report := NewReport(startedAt)
+		report.AppName = in.AppName.String()
+		report.Env = in.Env.String()
+		report.Build = in.BuildInfo
+		for _, module := range in.Container.Modules() {
+			report.Modules = append(report.Modules, fmt.Sprintf("%T", module))
+		}
+		return report
+	}
+	dumper := NewDumper(storage, WithReport(report), WithLogger(in.Logger))
+	return out{Dumper: dumper, Option: option}, nil
+}
+
+// ProvideRunGroup dumps on the signals in background.
+func (o out) ProvideRunGroup(group *run.Group) {
+	if !o.Option.Enable {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		return o.Dumper.Run(ctx, o.Option.Signals)
+	}, func(err error) {
+		cancel()
+	})
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "sigdump",
+			Data: map[string]interface{}{
+				"sigdump": Option{
+					Enable:  false,
+					Signals: []string{"SIGQUIT"},
+					Dir:     os.TempDir(),
+					S3:      "",
+				},
+			},
+			Comment: "Dump goroutines, heap profile and bootstrap report on signals",
+		},
+	}}
+}
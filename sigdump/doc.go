@@ -0,0 +1,36 @@
+/*
+Package sigdump dumps the goroutine stacks, the heap profile and the bootstrap
+report of the application on signals. It aids post-incident analysis when the
+debug port isn't reachable: send the signal, and collect the dumps from the
+directory or the object storage.
+
+	kill -QUIT <pid>
+
+The dumps of one signal are placed under a directory named after the app, the
+host, the process and the time:
+
+	app-host-42-20210101T000000Z/goroutine.txt
+	app-host-42-20210101T000000Z/heap.pprof
+	app-host-42-20210101T000000Z/report.json
+
+Note that a process handling SIGQUIT no longer exits on it.
+
+Integration
+
+package sigdump exports the configuration in the following format:
+
+	sigdump:
+	    enable: true
+	    signals:
+	        - SIGQUIT
+	    dir: /tmp
+	    s3: ""
+
+Set s3 to the name of an ots3 connection to upload the dumps instead. Add the
+sigdump dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(ots3.Providers())
+	c.Provide(sigdump.Providers())
+*/
+package sigdump
@@ -0,0 +1,162 @@
+package sigdump
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Option is the configuration of Dumper.
+type Option struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Signals are the signals that trigger a dump, eg. "SIGQUIT" and "SIGUSR2".
+	// Note SIGUSR1 triggers configuration reloads if the signal watcher is used.
+	Signals []string `json:"signals" yaml:"signals"`
+	// Dir is the directory the dumps are written to, defaults to the temporary
+	// directory. It is ignored if the dumps are uploaded to the object storage.
+	Dir string `json:"dir" yaml:"dir"`
+	// S3 is the name of the ots3 connection the dumps are uploaded to. If
+	// empty, the dumps are written to Dir.
+	S3 string `json:"s3" yaml:"s3"`
+}
+
+// Report describes the application at bootstrap. It is dumped along with the
+// profiles, so that the dumps can be told apart after an incident.
+type Report struct {
+	AppName   string             `json:"appName"`
+	Env       string             `json:"env"`
+	Build     contract.BuildInfo `json:"build"`
+	GoVersion string             `json:"goVersion"`
+	Hostname  string             `json:"hostname"`
+	PID       int                `json:"pid"`
+	Args      []string           `json:"args"`
+	StartedAt time.Time          `json:"startedAt"`
+	// Modules are the types of the modules registered in the container, as of
+	// the dump.
+	Modules []string `json:"modules"`
+}
+
+// Dumper dumps the goroutine stacks, the heap profile and the bootstrap report
+// to the storage.
+type Dumper struct {
+	storage Storage
+	report  func() Report
+	logger  log.Logger
+}
+
+// DumperOption is an option for Dumper.
+type DumperOption func(*Dumper)
+
+// WithReport sets the function that returns the bootstrap report.
+func WithReport(report func() Report) DumperOption {
+	return func(dumper *Dumper) {
+		dumper.report = report
+	}
+}
+
+// WithLogger sets the logger of Dumper.
+func WithLogger(logger log.Logger) DumperOption {
+	return func(dumper *Dumper) {
+		dumper.logger = logger
+	}
+}
+
+// NewDumper creates a *Dumper that writes to the storage.
+func NewDumper(storage Storage, opts ...DumperOption) *Dumper {
+	startedAt := time.Now()
+	d := &Dumper{
+		storage: storage,
+		report: func() Report {
+			return NewReport(startedAt)
+		},
+		logger: log.NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// NewReport creates a Report of the current process.
+func NewReport(startedAt time.Time) Report {
+	hostname, _ := os.Hostname()
+	return Report{
+		GoVersion: runtime.Version(),
+		Hostname:  hostname,
+		PID:       os.Getpid(),
+		Args:      os.Args,
+		StartedAt: startedAt,
+		Modules:   []string{},
+	}
+}
+
+// Dump writes goroutine.txt, heap.pprof and report.json under a directory
+// named after the host, the process and the time, which is returned.
+func (d *Dumper) Dump(ctx context.Context) (string, error) {
+	report := d.report()
+	prefix := fmt.Sprintf("%s-%d-%s", report.Hostname, report.PID, time.Now().UTC().Format("20060102T150405Z"))
+	if report.AppName != "" {
+		prefix = report.AppName + "-" + prefix
+	}
+
+	var goroutines bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return "", fmt.Errorf("unable to dump goroutines: %w", err)
+	}
+	var heap bytes.Buffer
+	if err := pprof.WriteHeapProfile(&heap); err != nil {
+		return "", fmt.Errorf("unable to dump heap: %w", err)
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("unable to dump report: %w", err)
+	}
+
+	for name, content := range map[string]*bytes.Buffer{
+		"goroutine.txt": &goroutines,
+		"heap.pprof":    &heap,
+		"report.json":   bytes.NewBuffer(reportJSON),
+	} {
+		if err := d.storage.Store(ctx, prefix+"/"+name, content); err != nil {
+			return "", fmt.Errorf("unable to store %s: %w", name, err)
+		}
+	}
+	return prefix, nil
+}
+
+// Run dumps on each of the signals until the context is canceled.
+func (d *Dumper) Run(ctx context.Context, signals []string) error {
+	sigs := make(chan os.Signal, 1)
+	for _, name := range signals {
+		sig, ok := signalsByName[name]
+		if !ok {
+			return fmt.Errorf("unsupported signal %s", name)
+		}
+		signal.Notify(sigs, sig)
+	}
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-sigs:
+			prefix, err := d.Dump(ctx)
+			if err != nil {
+				level.Warn(d.logger).Log("msg", fmt.Sprintf("failed to dump on %s", sig), "err", err)
+				continue
+			}
+			level.Info(d.logger).Log("msg", fmt.Sprintf("dumped to %s on %s", prefix, sig))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package sigdump
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumper_Dump(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "sigdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dumper := NewDumper(Dir(dir), WithReport(func() Report {
+		report := NewReport(time.Now())
+		report.AppName = "app"
+		return report
+	}))
+	prefix, err := dumper.Dump(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, prefix, "app-")
+
+	goroutines, err := ioutil.ReadFile(filepath.Join(dir, prefix, "goroutine.txt"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(goroutines), "TestDumper_Dump")
+	assert.FileExists(t, filepath.Join(dir, prefix, "heap.pprof"))
+
+	var report Report
+	content, err := ioutil.ReadFile(filepath.Join(dir, prefix, "report.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, "app", report.AppName)
+	assert.Equal(t, os.Getpid(), report.PID)
+}
+
+type uploader map[string]string
+
+func (u uploader) Upload(ctx context.Context, name string, reader io.Reader) (string, error) {
+	content, err := ioutil.ReadAll(reader)
+	u[name] = string(content)
+	return "https://example.org/" + name, err
+}
+
+func TestUploaderStorage(t *testing.T) {
+	t.Parallel()
+	u := uploader{}
+	prefix, err := NewDumper(UploaderStorage{Uploader: u}).Dump(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, u, 3)
+	assert.Contains(t, u[prefix+"/report.json"], `"pid"`)
+}
+
+func TestDumper_Run(t *testing.T) {
+	t.Parallel()
+	err := NewDumper(UploaderStorage{Uploader: uploader{}}).Run(context.Background(), []string{"SIGFOO"})
+	assert.EqualError(t, err, "unsupported signal SIGFOO")
+}
+
+func TestProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sigdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := core.Default(core.WithInline("sigdump.enable", true), core.WithInline("sigdump.dir", dir))
+	c.Provide(Providers())
+	c.Invoke(func(dumper *Dumper) {
+		prefix, err := dumper.Dump(context.Background())
+		assert.NoError(t, err)
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, prefix, "report.json"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "sigdump.out")
+	})
+}
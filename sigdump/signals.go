@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package sigdump
+
+import (
+	"os"
+	"syscall"
+)
+
+var signalsByName = map[string]os.Signal{
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
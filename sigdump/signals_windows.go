@@ -0,0 +1,6 @@
+package sigdump
+
+import "os"
+
+// signals can't be sent to processes on windows.
+var signalsByName = map[string]os.Signal{}
@@ -0,0 +1,47 @@
+package sigdump
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Storage stores the dumped files.
+type Storage interface {
+	Store(ctx context.Context, name string, reader io.Reader) error
+}
+
+// Dir is a Storage that writes the files to a directory.
+type Dir string
+
+// Store implements Storage.
+func (d Dir) Store(ctx context.Context, name string, reader io.Reader) error {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, reader)
+	return err
+}
+
+// Uploader is the interface of ots3.Uploader.
+type Uploader interface {
+	Upload(ctx context.Context, name string, reader io.Reader) (string, error)
+}
+
+// UploaderStorage is a Storage that uploads the files to the object storage.
+type UploaderStorage struct {
+	Uploader Uploader
+}
+
+// Store implements Storage.
+func (u UploaderStorage) Store(ctx context.Context, name string, reader io.Reader) error {
+	_, err := u.Uploader.Upload(ctx, name, reader)
+	return err
+}
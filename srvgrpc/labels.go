@@ -0,0 +1,49 @@
+package srvgrpc
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"google.golang.org/grpc"
+)
+
+// MakeUnaryLabelInterceptor creates a grpc.UnaryServerInterceptor that sets the
+// runtime/pprof labels "transport" and "route" while serving the call, so that
+// the CPU profiles can be sliced by method. The route is the full method name.
+//
+// Provide the grpc.Server with:
+//		opts := []grpc.ServerOption{
+//			grpc.ChainUnaryInterceptor(srvgrpc.MakeUnaryLabelInterceptor()),
+//			grpc.ChainStreamInterceptor(srvgrpc.MakeStreamLabelInterceptor()),
+//		}
+//		server = grpc.NewServer(opts...)
+func MakeUnaryLabelInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		pprof.Do(ctx, pprof.Labels("transport", "grpc", "route", info.FullMethod), func(ctx context.Context) {
+			resp, err = handler(ctx, req)
+		})
+		return resp, err
+	}
+}
+
+// MakeStreamLabelInterceptor creates a grpc.StreamServerInterceptor that sets
+// the runtime/pprof labels "transport" and "route" for the lifetime of the
+// stream.
+func MakeStreamLabelInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		pprof.Do(ss.Context(), pprof.Labels("transport", "grpc", "route", info.FullMethod), func(ctx context.Context) {
+			err = handler(srv, labeledStream{ss, ctx})
+		})
+		return err
+	}
+}
+
+// labeledStream carries the labels in its context.
+type labeledStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s labeledStream) Context() context.Context {
+	return s.ctx
+}
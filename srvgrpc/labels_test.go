@@ -0,0 +1,20 @@
+package srvgrpc
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestMakeUnaryLabelInterceptor(t *testing.T) {
+	interceptor := MakeUnaryLabelInterceptor()
+	resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/app.Foo/Bar"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		route, _ := pprof.Label(ctx, "route")
+		return route, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/app.Foo/Bar", resp)
+}
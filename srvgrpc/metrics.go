@@ -14,6 +14,9 @@ import (
 //			grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
 //		}
 //		server = grpc.NewServer(opts...)
+//
+// For metrics that share naming with the HTTP request metrics, see
+// MakeUnaryMetricsInterceptor and MakeStreamMetricsInterceptor.
 type MetricsModule struct{}
 
 // ProvideGRPC implements container.GRPCProvider
@@ -0,0 +1,222 @@
+package srvgrpc
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/proto" // registers the proto codec
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ProxyOption is the configuration of Proxy.
+type ProxyOption struct {
+	Routes []ProxyRoute `json:"routes" yaml:"routes"`
+}
+
+// ProxyRoute forwards the calls whose full method name has the prefix to the
+// target.
+type ProxyRoute struct {
+	// Prefix is matched against the full method name, eg. "/legacy.Foo/" for a
+	// service, or "/legacy." for a package. An empty prefix matches everything.
+	// The longest matching prefix wins.
+	Prefix string `json:"prefix" yaml:"prefix"`
+	// Target is the dial target of the backend, eg. "legacy:9090".
+	Target string `json:"target" yaml:"target"`
+}
+
+// Proxy transparently forwards the calls to unknown services to the configured
+// backends, so that core can front a legacy gRPC server and take over its
+// services one by one. Messages are passed through as raw bytes, so the proxy
+// doesn't need the backend's protobuf definitions. Metadata, headers and
+// trailers are forwarded as well.
+//
+// Build the grpc.Server with the proxy, and provide it to core:
+//		var option srvgrpc.ProxyOption
+//		conf.Unmarshal("grpc.proxy", &option)
+//		proxy := srvgrpc.NewProxy(option)
+//		server = grpc.NewServer(proxy.ServerOptions()...)
+type Proxy struct {
+	dialOptions []grpc.DialOption
+
+	mu     sync.Mutex
+	routes []ProxyRoute
+	conns  map[string]*grpc.ClientConn
+}
+
+// NewProxy creates a new *Proxy. The backends are dialed lazily with the dial
+// options. If no dial options are given, the backends are dialed without TLS.
+func NewProxy(option ProxyOption, dialOptions ...grpc.DialOption) *Proxy {
+	if len(dialOptions) == 0 {
+		dialOptions = []grpc.DialOption{grpc.WithInsecure()}
+	}
+	p := &Proxy{dialOptions: dialOptions, conns: make(map[string]*grpc.ClientConn)}
+	p.Update(option)
+	return p
+}
+
+// Update replaces the routes.
+func (p *Proxy) Update(option ProxyOption) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.routes = option.Routes
+}
+
+// ServerOptions returns the grpc.ServerOption that installs the proxy as the
+// handler for unknown services. The options replace the server codec with one
+// that falls back to protobuf for the registered services.
+func (p *Proxy) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ForceServerCodec(proxyCodec{}),
+		grpc.UnknownServiceHandler(p.handle),
+	}
+}
+
+// Close closes the connections to the backends.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for target, conn := range p.conns {
+		if e := conn.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(p.conns, target)
+	}
+	return err
+}
+
+func (p *Proxy) conn(method string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var route *ProxyRoute
+	for i := range p.routes {
+		if strings.HasPrefix(method, p.routes[i].Prefix) && (route == nil || len(p.routes[i].Prefix) > len(route.Prefix)) {
+			route = &p.routes[i]
+		}
+	}
+	if route == nil {
+		return nil, status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	if conn, ok := p.conns[route.Target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(route.Target, p.dialOptions...)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to dial %s: %s", route.Target, err)
+	}
+	p.conns[route.Target] = conn
+	return conn, nil
+}
+
+func (p *Proxy) handle(srv interface{}, stream grpc.ServerStream) error {
+	method, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "failed to get method from stream")
+	}
+	conn, err := p.conn(method)
+	if err != nil {
+		return err
+	}
+
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(stream.Context(), md.Copy()))
+	defer cancel()
+	client, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, method, grpc.ForceCodec(proxyCodec{}))
+	if err != nil {
+		return err
+	}
+
+	upstream := make(chan error, 1)
+	go func() {
+		for {
+			f := &frame{}
+			if err := stream.RecvMsg(f); err != nil {
+				if err == io.EOF {
+					err = client.CloseSend()
+				}
+				upstream <- err
+				return
+			}
+			if err := client.SendMsg(f); err != nil {
+				upstream <- err
+				return
+			}
+		}
+	}()
+	downstream := make(chan error, 1)
+	go func() {
+		for i := 0; ; i++ {
+			f := &frame{}
+			if err := client.RecvMsg(f); err != nil {
+				downstream <- err
+				return
+			}
+			if i == 0 {
+				if header, err := client.Header(); err == nil {
+					if err := stream.SendHeader(header); err != nil {
+						downstream <- err
+						return
+					}
+				}
+			}
+			if err := stream.SendMsg(f); err != nil {
+				downstream <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case err := <-upstream:
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to forward request: %s", err)
+			}
+			// the request is fully sent, wait for the response.
+			upstream = nil
+		case err := <-downstream:
+			stream.SetTrailer(client.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// frame is a raw message.
+type frame struct {
+	payload []byte
+}
+
+// proxyCodec passes frames through as is, and encodes other messages with the
+// proto codec.
+type proxyCodec struct{}
+
+func (proxyCodec) Marshal(v interface{}) ([]byte, error) {
+	if f, ok := v.(*frame); ok {
+		return f.payload, nil
+	}
+	return encoding.GetCodec("proto").Marshal(v)
+}
+
+func (proxyCodec) Unmarshal(data []byte, v interface{}) error {
+	if f, ok := v.(*frame); ok {
+		f.payload = append([]byte(nil), data...)
+		return nil
+	}
+	return encoding.GetCodec("proto").Unmarshal(data, v)
+}
+
+func (proxyCodec) Name() string {
+	return "proto"
+}
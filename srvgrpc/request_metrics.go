@@ -0,0 +1,79 @@
+package srvgrpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// RequestMetrics is a collection of RED metrics for incoming gRPC requests.
+// Every metric is labeled by "service" and "method". Errors is additionally
+// labeled by "code".
+type RequestMetrics struct {
+	// Total counts the number of handled requests.
+	Total metrics.Counter
+	// Errors counts the number of failed requests.
+	Errors metrics.Counter
+	// Duration measures the time spent serving requests, in seconds.
+	Duration metrics.Histogram
+	// InFlight gauges the number of requests being served.
+	InFlight metrics.Gauge
+}
+
+// MakeUnaryMetricsInterceptor creates a grpc.UnaryServerInterceptor that
+// records RequestMetrics for every unary call.
+//
+// Provide the grpc.Server with:
+//		opts := []grpc.ServerOption{
+//			grpc.UnaryInterceptor(srvgrpc.MakeUnaryMetricsInterceptor(requestMetrics)),
+//			grpc.StreamInterceptor(srvgrpc.MakeStreamMetricsInterceptor(requestMetrics)),
+//		}
+//		server = grpc.NewServer(opts...)
+func MakeUnaryMetricsInterceptor(m *RequestMetrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done := m.observe(info.FullMethod)
+		resp, err := handler(ctx, req)
+		done(err)
+		return resp, err
+	}
+}
+
+// MakeStreamMetricsInterceptor creates a grpc.StreamServerInterceptor that
+// records RequestMetrics for every stream. The duration covers the whole
+// lifetime of the stream.
+func MakeStreamMetricsInterceptor(m *RequestMetrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done := m.observe(info.FullMethod)
+		err := handler(srv, ss)
+		done(err)
+		return err
+	}
+}
+
+func (m *RequestMetrics) observe(fullMethod string) func(err error) {
+	service, method := splitMethodName(fullMethod)
+	labels := []string{"service", service, "method", method}
+	start := time.Now()
+
+	m.InFlight.With(labels...).Add(1)
+	return func(err error) {
+		m.InFlight.With(labels...).Add(-1)
+		m.Total.With(labels...).Add(1)
+		m.Duration.With(labels...).Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.Errors.With(append(labels, "code", status.Code(err).String())...).Add(1)
+		}
+	}
+}
+
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", "unknown"
+}
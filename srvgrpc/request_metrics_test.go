@@ -0,0 +1,43 @@
+package srvgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMakeUnaryMetricsInterceptor(t *testing.T) {
+	labels := []string{"service", "method"}
+	total := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "total"}, labels)
+	errs := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "errors"}, append(labels, "code"))
+	duration := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{Name: "duration"}, labels)
+	inFlight := stdprometheus.NewGaugeVec(stdprometheus.GaugeOpts{Name: "in_flight"}, labels)
+
+	m := &RequestMetrics{
+		Total:    prometheus.NewCounter(total),
+		Errors:   prometheus.NewCounter(errs),
+		Duration: prometheus.NewHistogram(duration),
+		InFlight: prometheus.NewGauge(inFlight),
+	}
+	interceptor := MakeUnaryMetricsInterceptor(m)
+	info := &grpc.UnaryServerInfo{FullMethod: "/foo.Bar/Baz"}
+
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.Equal(t, 1.0, testutil.ToFloat64(inFlight.WithLabelValues("foo.Bar", "Baz")))
+		return nil, nil
+	})
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(total.WithLabelValues("foo.Bar", "Baz")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(errs.WithLabelValues("foo.Bar", "Baz", "NotFound")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(inFlight.WithLabelValues("foo.Bar", "Baz")))
+}
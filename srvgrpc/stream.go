@@ -0,0 +1,219 @@
+package srvgrpc
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"google.golang.org/grpc"
+)
+
+// ErrStreamClosed is returned by StreamSender.Send after the sender stops,
+// either because it is closed or because the client has gone.
+var ErrStreamClosed = errors.New("stream closed")
+
+const defaultStreamBufferSize = 16
+
+// StreamSender sends the messages of a server-streaming call from a buffer in
+// the background. When the buffer is full, Send blocks until the client
+// catches up, so a slow client slows down the producer rather than growing
+// the memory. Optionally, a heartbeat message is sent whenever the stream has
+// been idle for an interval, so that proxies and clients can tell an idle
+// stream from a dead one.
+//
+// Typically:
+//
+//	func (s *Server) Watch(req *pb.WatchRequest, stream pb.Service_WatchServer) error {
+//		sender := srvgrpc.NewStreamSender(stream, srvgrpc.WithHeartbeat(15*time.Second, func() interface{} {
+//			return &pb.WatchResponse{Heartbeat: true}
+//		}))
+//		defer sender.Close()
+//		for event := range s.events(stream.Context()) {
+//			if err := sender.Send(event); err != nil {
+//				return err
+//			}
+//		}
+//		return sender.Close()
+//	}
+type StreamSender struct {
+	stream    grpc.ServerStream
+	queue     chan interface{}
+	interval  time.Duration
+	heartbeat func() interface{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// StreamOption changes the behavior of StreamSender.
+type StreamOption func(*StreamSender)
+
+// WithBufferSize sets the number of messages buffered before Send blocks.
+// Defaults to 16.
+func WithBufferSize(size int) StreamOption {
+	return func(sender *StreamSender) {
+		sender.queue = make(chan interface{}, size)
+	}
+}
+
+// WithHeartbeat sends the message returned by heartbeat whenever no message
+// has been sent for the interval.
+func WithHeartbeat(interval time.Duration, heartbeat func() interface{}) StreamOption {
+	return func(sender *StreamSender) {
+		sender.interval = interval
+		sender.heartbeat = heartbeat
+	}
+}
+
+// NewStreamSender creates a *StreamSender and starts sending in background.
+// Close must be called to release it.
+func NewStreamSender(stream grpc.ServerStream, options ...StreamOption) *StreamSender {
+	sender := &StreamSender{
+		stream: stream,
+		queue:  make(chan interface{}, defaultStreamBufferSize),
+		done:   make(chan struct{}),
+	}
+	for _, option := range options {
+		option(sender)
+	}
+	go sender.run()
+	return sender
+}
+
+// Send queues the message. It blocks while the buffer is full. It returns the
+// error of the stream if the sender has stopped, eg. the client disconnected.
+// Send must not be called after or concurrently with Close.
+func (s *StreamSender) Send(message interface{}) error {
+	select {
+	case <-s.done:
+		return s.Err()
+	default:
+	}
+	select {
+	case s.queue <- message:
+		return nil
+	case <-s.done:
+		return s.Err()
+	}
+}
+
+// Done is closed when the sender stops, eg. the client disconnected. Producers
+// can select on it to stop early.
+func (s *StreamSender) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns nil while the sender is running. After it stops, Err returns
+// the error of the stream, or ErrStreamClosed if it is closed cleanly.
+func (s *StreamSender) Err() error {
+	select {
+	case <-s.done:
+		if s.err == nil {
+			return ErrStreamClosed
+		}
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close waits until the buffered messages are sent, and stops the sender. It
+// returns the error of the stream, if any. It is safe to call Close multiple
+// times.
+func (s *StreamSender) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.queue)
+	})
+	<-s.done
+	return s.err
+}
+
+func (s *StreamSender) run() {
+	defer close(s.done)
+
+	var tick <-chan time.Time
+	if s.interval > 0 && s.heartbeat != nil {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	lastSent := time.Now()
+	ctx := s.stream.Context()
+	for {
+		select {
+		case message, ok := <-s.queue:
+			if !ok {
+				return
+			}
+			if s.err = s.stream.SendMsg(message); s.err != nil {
+				return
+			}
+			lastSent = time.Now()
+		case <-tick:
+			if time.Since(lastSent) < s.interval {
+				continue
+			}
+			if s.err = s.stream.SendMsg(s.heartbeat()); s.err != nil {
+				return
+			}
+			lastSent = time.Now()
+		case <-ctx.Done():
+			s.err = ctx.Err()
+			return
+		}
+	}
+}
+
+// StreamMetrics is a collection of metrics for gRPC streams. Every metric is
+// labeled by "service" and "method".
+type StreamMetrics struct {
+	// Active gauges the number of open streams.
+	Active metrics.Gauge
+	// Sent counts the number of messages sent to the clients.
+	Sent metrics.Counter
+	// Received counts the number of messages received from the clients.
+	Received metrics.Counter
+}
+
+// MakeStreamActivityInterceptor creates a grpc.StreamServerInterceptor that
+// records StreamMetrics for every stream. It can be chained with
+// MakeStreamMetricsInterceptor.
+func MakeStreamActivityInterceptor(m *StreamMetrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method := splitMethodName(info.FullMethod)
+		labels := []string{"service", service, "method", method}
+
+		m.Active.With(labels...).Add(1)
+		defer m.Active.With(labels...).Add(-1)
+		return handler(srv, &countedStream{
+			ServerStream: ss,
+			sent:         m.Sent.With(labels...),
+			received:     m.Received.With(labels...),
+		})
+	}
+}
+
+// countedStream counts the messages sent and received.
+type countedStream struct {
+	grpc.ServerStream
+	sent     metrics.Counter
+	received metrics.Counter
+}
+
+func (s *countedStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+func (s *countedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package srvgrpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// fakeStream records the sent messages. Each SendMsg waits for a token from
+// unblock if it is not nil.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	unblock chan struct{}
+	err     error
+
+	mu   sync.Mutex
+	sent []interface{}
+}
+
+func (f *fakeStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeStream) SendMsg(m interface{}) error {
+	if f.unblock != nil {
+		<-f.unblock
+	}
+	if f.err != nil {
+		return f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func (f *fakeStream) RecvMsg(m interface{}) error {
+	return f.err
+}
+
+func (f *fakeStream) messages() []interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]interface{}(nil), f.sent...)
+}
+
+func TestStreamSender(t *testing.T) {
+	t.Parallel()
+	stream := &fakeStream{ctx: context.Background()}
+	sender := NewStreamSender(stream)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, sender.Send(i))
+	}
+	assert.NoError(t, sender.Close())
+	assert.NoError(t, sender.Close())
+	assert.Len(t, stream.messages(), 100)
+	assert.Equal(t, 99, stream.messages()[99])
+	assert.Equal(t, ErrStreamClosed, sender.Send(100))
+}
+
+func TestStreamSender_backpressure(t *testing.T) {
+	t.Parallel()
+	stream := &fakeStream{ctx: context.Background(), unblock: make(chan struct{})}
+	sender := NewStreamSender(stream, WithBufferSize(1))
+
+	// One message is being sent, one is buffered.
+	assert.NoError(t, sender.Send(1))
+	assert.NoError(t, sender.Send(2))
+	sent := make(chan struct{})
+	go func() {
+		assert.NoError(t, sender.Send(3))
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		t.Fatal("Send should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	stream.unblock <- struct{}{}
+	<-sent
+	close(stream.unblock)
+	assert.NoError(t, sender.Close())
+	assert.Equal(t, []interface{}{1, 2, 3}, stream.messages())
+}
+
+func TestStreamSender_heartbeat(t *testing.T) {
+	t.Parallel()
+	stream := &fakeStream{ctx: context.Background()}
+	sender := NewStreamSender(stream, WithHeartbeat(10*time.Millisecond, func() interface{} {
+		return "heartbeat"
+	}))
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, sender.Close())
+	assert.Contains(t, stream.messages(), "heartbeat")
+}
+
+func TestStreamSender_disconnect(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeStream{ctx: ctx, unblock: make(chan struct{})}
+	sender := NewStreamSender(stream, WithBufferSize(1))
+	cancel()
+	<-sender.Done()
+	assert.Equal(t, context.Canceled, sender.Send(1))
+	assert.Equal(t, context.Canceled, sender.Close())
+
+	stream = &fakeStream{ctx: context.Background(), err: errors.New("broken pipe")}
+	sender = NewStreamSender(stream)
+	assert.NoError(t, sender.Send(1))
+	<-sender.Done()
+	assert.EqualError(t, sender.Send(2), "broken pipe")
+	assert.EqualError(t, sender.Close(), "broken pipe")
+}
+
+func TestMakeStreamActivityInterceptor(t *testing.T) {
+	labels := []string{"service", "method"}
+	active := stdprometheus.NewGaugeVec(stdprometheus.GaugeOpts{Name: "active"}, labels)
+	sent := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "sent"}, labels)
+	received := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "received"}, labels)
+
+	m := &StreamMetrics{
+		Active:   prometheus.NewGauge(active),
+		Sent:     prometheus.NewCounter(sent),
+		Received: prometheus.NewCounter(received),
+	}
+	interceptor := MakeStreamActivityInterceptor(m)
+	info := &grpc.StreamServerInfo{FullMethod: "/foo.Bar/Baz"}
+
+	err := interceptor(nil, &fakeStream{ctx: context.Background()}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		assert.Equal(t, 1.0, testutil.ToFloat64(active.WithLabelValues("foo.Bar", "Baz")))
+		assert.NoError(t, stream.RecvMsg(nil))
+		assert.NoError(t, stream.SendMsg(1))
+		assert.NoError(t, stream.SendMsg(2))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, testutil.ToFloat64(active.WithLabelValues("foo.Bar", "Baz")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(sent.WithLabelValues("foo.Bar", "Baz")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(received.WithLabelValues("foo.Bar", "Baz")))
+}
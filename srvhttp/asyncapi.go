@@ -0,0 +1,194 @@
+package srvhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/gorilla/mux"
+)
+
+// AsyncAPI is an AsyncAPI 2.0 document. Only the commonly used fields are
+// modelled. See https://www.asyncapi.com/docs/specifications/v2.0.0.
+type AsyncAPI struct {
+	AsyncAPI string                     `json:"asyncapi"`
+	Info     AsyncAPIInfo               `json:"info"`
+	Channels map[string]AsyncAPIChannel `json:"channels"`
+}
+
+// AsyncAPIInfo is the metadata of the application.
+type AsyncAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// AsyncAPIChannel describes a channel, such as a kafka topic. Note in AsyncAPI,
+// Subscribe describes the messages the application sends, and Publish
+// describes the messages the application receives.
+type AsyncAPIChannel struct {
+	Description string                 `json:"description,omitempty"`
+	Subscribe   *AsyncAPIOperation     `json:"subscribe,omitempty"`
+	Publish     *AsyncAPIOperation     `json:"publish,omitempty"`
+	Bindings    map[string]interface{} `json:"bindings,omitempty"`
+}
+
+// AsyncAPIOperation describes sending or receiving messages on a channel.
+type AsyncAPIOperation struct {
+	OperationID string                 `json:"operationId,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	Message     *AsyncAPIMessage       `json:"message,omitempty"`
+	Bindings    map[string]interface{} `json:"bindings,omitempty"`
+}
+
+// AsyncAPIMessage describes a message. Payload is a JSON schema.
+type AsyncAPIMessage struct {
+	Name        string      `json:"name,omitempty"`
+	ContentType string      `json:"contentType,omitempty"`
+	Payload     interface{} `json:"payload,omitempty"`
+}
+
+// AsyncAPIProvider is implemented by modules that declare their channels, such
+// as event topics, kafka message schemas or queue job types. Channels already
+// discovered from config can be enriched by modifying the document.
+type AsyncAPIProvider interface {
+	ProvideAsyncAPI(doc *AsyncAPI)
+}
+
+// AsyncAPIIn is the injection parameter for NewAsyncAPIModule.
+type AsyncAPIIn struct {
+	di.In
+
+	AppName    contract.AppName
+	Conf       contract.ConfigAccessor
+	Container  contract.Container
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+// AsyncAPIModule serves the AsyncAPI document of the application at
+// "/asyncapi.json". The document is assembled from:
+//
+//  - the topics of kafka readers and writers in config,
+//  - the topics of the event dispatcher, if it is a events.SyncDispatcher,
+//  - the channels declared by modules implementing AsyncAPIProvider.
+type AsyncAPIModule struct {
+	appName    contract.AppName
+	conf       contract.ConfigAccessor
+	container  contract.Container
+	dispatcher contract.Dispatcher
+}
+
+// NewAsyncAPIModule creates a AsyncAPIModule.
+func NewAsyncAPIModule(in AsyncAPIIn) AsyncAPIModule {
+	return AsyncAPIModule{
+		appName:    in.AppName,
+		conf:       in.Conf,
+		container:  in.Container,
+		dispatcher: in.Dispatcher,
+	}
+}
+
+// ProvideHTTP implements container.HTTPProvider
+func (a AsyncAPIModule) ProvideHTTP(router *mux.Router) {
+	router.Methods(http.MethodGet).Path("/asyncapi.json").HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(a.Document())
+	})
+}
+
+// Document assembles the AsyncAPI document.
+func (a AsyncAPIModule) Document() *AsyncAPI {
+	var version string
+	_ = a.conf.Unmarshal("version", &version)
+	if version == "" {
+		version = "unknown"
+	}
+	doc := &AsyncAPI{
+		AsyncAPI: "2.0.0",
+		Info:     AsyncAPIInfo{Title: a.appName.String(), Version: version},
+		Channels: make(map[string]AsyncAPIChannel),
+	}
+	a.addKafkaChannels(doc)
+	a.addEventChannels(doc)
+	if a.container != nil {
+		_ = a.container.Modules().Filter(func(p AsyncAPIProvider) {
+			p.ProvideAsyncAPI(doc)
+		})
+	}
+	return doc
+}
+
+type kafkaTopic struct {
+	Topic   string `json:"topic" yaml:"topic"`
+	GroupID string `json:"groupId" yaml:"groupID"`
+}
+
+func (a AsyncAPIModule) addKafkaChannels(doc *AsyncAPI) {
+	var writers, readers map[string]kafkaTopic
+	_ = a.conf.Unmarshal("kafka.writer", &writers)
+	_ = a.conf.Unmarshal("kafka.reader", &readers)
+
+	for _, name := range sortedKeys(writers) {
+		topic := writers[name].Topic
+		if topic == "" {
+			continue
+		}
+		channel := doc.Channels[topic]
+		channel.Bindings = map[string]interface{}{"kafka": map[string]interface{}{}}
+		channel.Subscribe = &AsyncAPIOperation{
+			OperationID: "kafka.writer." + name,
+			Summary:     fmt.Sprintf("Produced by kafka writer %s", name),
+		}
+		doc.Channels[topic] = channel
+	}
+	for _, name := range sortedKeys(readers) {
+		topic := readers[name].Topic
+		if topic == "" {
+			continue
+		}
+		channel := doc.Channels[topic]
+		channel.Bindings = map[string]interface{}{"kafka": map[string]interface{}{}}
+		channel.Publish = &AsyncAPIOperation{
+			OperationID: "kafka.reader." + name,
+			Summary:     fmt.Sprintf("Consumed by kafka reader %s", name),
+		}
+		if groupID := readers[name].GroupID; groupID != "" {
+			channel.Publish.Bindings = map[string]interface{}{
+				"kafka": map[string]interface{}{
+					"groupId": map[string]interface{}{"type": "string", "enum": []string{groupID}},
+				},
+			}
+		}
+		doc.Channels[topic] = channel
+	}
+}
+
+func (a AsyncAPIModule) addEventChannels(doc *AsyncAPI) {
+	d, ok := a.dispatcher.(interface{ Topics() []interface{} })
+	if !ok {
+		return
+	}
+	var names []string
+	for _, topic := range d.Topics() {
+		names = append(names, fmt.Sprint(topic))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		channel := doc.Channels["event/"+name]
+		channel.Description = "In-process event dispatched by contract.Dispatcher."
+		channel.Publish = &AsyncAPIOperation{OperationID: "event." + name}
+		doc.Channels["event/"+name] = channel
+	}
+}
+
+func sortedKeys(m map[string]kafkaTopic) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package srvhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/container"
+	"github.com/DoNewsCode/core/events"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type asyncAPIProvider struct{}
+
+func (a asyncAPIProvider) ProvideAsyncAPI(doc *AsyncAPI) {
+	doc.Channels["jobs/email"] = AsyncAPIChannel{
+		Publish: &AsyncAPIOperation{Message: &AsyncAPIMessage{
+			Name:    "email",
+			Payload: map[string]interface{}{"type": "object"},
+		}},
+	}
+}
+
+func TestAsyncAPIModule(t *testing.T) {
+	conf := config.MapAdapter{
+		"version": "1.0.0",
+		"kafka": map[string]interface{}{
+			"writer": map[string]interface{}{"default": map[string]interface{}{"topic": "orders"}},
+			"reader": map[string]interface{}{"default": map[string]interface{}{"topic": "orders", "groupID": "app"}},
+		},
+	}
+	dispatcher := &events.SyncDispatcher{}
+	dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+		return nil
+	}))
+	var ctn container.Container
+	ctn.AddModule(asyncAPIProvider{})
+
+	module := NewAsyncAPIModule(AsyncAPIIn{
+		AppName:    config.AppName("app"),
+		Conf:       conf,
+		Container:  &ctn,
+		Dispatcher: dispatcher,
+	})
+	router := mux.NewRouter()
+	module.ProvideHTTP(router)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/asyncapi.json", nil))
+
+	var doc AsyncAPI
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	assert.Equal(t, "app", doc.Info.Title)
+	assert.Equal(t, "1.0.0", doc.Info.Version)
+	assert.Equal(t, "kafka.writer.default", doc.Channels["orders"].Subscribe.OperationID)
+	assert.Equal(t, "kafka.reader.default", doc.Channels["orders"].Publish.OperationID)
+	assert.NotNil(t, doc.Channels["orders"].Publish.Bindings)
+	assert.Contains(t, doc.Channels, "event/onReload")
+	assert.Equal(t, "email", doc.Channels["jobs/email"].Publish.Message.Name)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/DoNewsCode/core/bufpool"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
@@ -74,23 +75,24 @@ func encode(w http.ResponseWriter, any interface{}, code int) {
 	}
 	w.WriteHeader(code)
 
+	buf := bufpool.Get(512)
+	defer bufpool.Put(buf)
+
 	switch x := any.(type) {
 	case json.Marshaler:
-		encoder := json.NewEncoder(w)
-		_ = encoder.Encode(x)
+		_ = json.NewEncoder(buf).Encode(x)
 	case proto.Message:
 		bytes, _ := protojson.MarshalOptions{
 			EmitUnpopulated: true,
 			UseProtoNames:   true,
 		}.Marshal(x)
-		w.Write(bytes)
+		buf.Write(bytes)
 	case error:
-		encoder := json.NewEncoder(w)
-		_ = encoder.Encode(map[string]string{
+		_ = json.NewEncoder(buf).Encode(map[string]string{
 			"message": x.Error(),
 		})
 	default:
-		encoder := json.NewEncoder(w)
-		_ = encoder.Encode(x)
+		_ = json.NewEncoder(buf).Encode(x)
 	}
+	w.Write(buf.Bytes())
 }
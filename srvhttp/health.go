@@ -0,0 +1,163 @@
+package srvhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The health statuses, from the best to the worst.
+const (
+	HealthUp       = "up"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// HealthCheck is a named check in Health.
+type HealthCheck struct {
+	// Name identifies the check in the report and in DependsOn.
+	Name string
+	// Check returns an error when the checked component is unhealthy.
+	Check func(ctx context.Context) error
+	// Soft checks degrade the overall status rather than bringing it down,
+	// eg. an optional cache.
+	Soft bool
+	// DependsOn names the checks this one depends on. If any of them is not up,
+	// this check fails without running.
+	DependsOn []string
+	// Failures and Window damp flapping: a failing check is only reported as
+	// failing after Failures failures within Window, or Failures consecutive
+	// failures if Window is zero. By default every failure is reported. A
+	// success is always reported immediately.
+	Failures int
+	Window   time.Duration
+}
+
+// HealthCheckStatus is the status of a check in HealthReport.
+type HealthCheckStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Soft   bool   `json:"soft,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Failures is the number of failures within the window of the check.
+	Failures    int        `json:"failures,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+}
+
+// HealthReport is the result of Health.Check.
+type HealthReport struct {
+	// Status is down if any hard check fails, degraded if any soft check
+	// fails, and up otherwise.
+	Status string              `json:"status"`
+	Checks []HealthCheckStatus `json:"checks"`
+}
+
+// Health aggregates the health checks of the application. Serving HTTP, it
+// runs the checks and writes the HealthReport as JSON, with the status code
+// 503 if the overall status is down. Health is safe for concurrent use.
+type Health struct {
+	mu      sync.Mutex
+	checks  []HealthCheck
+	history map[string]*checkHistory
+	now     func() time.Time
+}
+
+// checkHistory is what Health remembers of a check between runs.
+type checkHistory struct {
+	failures    []time.Time
+	lastSuccess *time.Time
+}
+
+// NewHealth creates a *Health with the checks.
+func NewHealth(checks ...HealthCheck) *Health {
+	h := &Health{history: make(map[string]*checkHistory), now: time.Now}
+	for _, check := range checks {
+		h.Add(check)
+	}
+	return h
+}
+
+// Add adds a check. The checks run in the order they are added, so add the
+// dependencies first.
+func (h *Health) Add(check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, check)
+	h.history[check.Name] = &checkHistory{}
+}
+
+// Check runs every check and reports the overall status.
+func (h *Health) Check(ctx context.Context) HealthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	report := HealthReport{Status: HealthUp, Checks: make([]HealthCheckStatus, 0, len(h.checks))}
+	statuses := make(map[string]string, len(h.checks))
+	for _, check := range h.checks {
+		status := h.run(ctx, check, statuses)
+		statuses[check.Name] = status.Status
+		report.Checks = append(report.Checks, status)
+		switch {
+		case status.Status == HealthUp:
+		case check.Soft && report.Status == HealthUp:
+			report.Status = HealthDegraded
+		case !check.Soft:
+			report.Status = HealthDown
+		}
+	}
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check HealthCheck, statuses map[string]string) HealthCheckStatus {
+	status := HealthCheckStatus{Name: check.Name, Status: HealthUp, Soft: check.Soft}
+	history := h.history[check.Name]
+	now := h.now()
+
+	var err error
+	for _, dependency := range check.DependsOn {
+		if s, ok := statuses[dependency]; !ok || s != HealthUp {
+			err = fmt.Errorf("dependency %s is not up", dependency)
+			break
+		}
+	}
+	if err == nil {
+		err = check.Check(ctx)
+	}
+
+	if err == nil {
+		history.lastSuccess = &now
+		if check.Window == 0 {
+			history.failures = nil
+		}
+	} else {
+		history.failures = append(history.failures, now)
+		status.Error = err.Error()
+	}
+	if check.Window > 0 {
+		i := 0
+		for i < len(history.failures) && now.Sub(history.failures[i]) > check.Window {
+			i++
+		}
+		history.failures = history.failures[i:]
+	}
+	status.Failures = len(history.failures)
+	status.LastSuccess = history.lastSuccess
+	if err != nil && len(history.failures) >= check.Failures {
+		status.Status = HealthDown
+	}
+	return status
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Health) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	report := h.Check(request.Context())
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if report.Status == HealthDown {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(writer).Encode(report)
+}
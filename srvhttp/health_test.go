@@ -0,0 +1,92 @@
+package srvhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth_Check(t *testing.T) {
+	var dbErr, cacheErr error
+	health := NewHealth(
+		HealthCheck{Name: "db", Check: func(ctx context.Context) error { return dbErr }},
+		HealthCheck{Name: "cache", Soft: true, Check: func(ctx context.Context) error { return cacheErr }},
+		HealthCheck{Name: "orders", DependsOn: []string{"db"}, Check: func(ctx context.Context) error { return nil }},
+	)
+
+	report := health.Check(context.Background())
+	assert.Equal(t, HealthUp, report.Status)
+	assert.Len(t, report.Checks, 3)
+	assert.NotNil(t, report.Checks[0].LastSuccess)
+
+	cacheErr = errors.New("cache unreachable")
+	report = health.Check(context.Background())
+	assert.Equal(t, HealthDegraded, report.Status)
+	assert.Equal(t, HealthDown, report.Checks[1].Status)
+	assert.Equal(t, "cache unreachable", report.Checks[1].Error)
+
+	dbErr = errors.New("db unreachable")
+	report = health.Check(context.Background())
+	assert.Equal(t, HealthDown, report.Status)
+	assert.Equal(t, HealthDown, report.Checks[2].Status)
+	assert.Equal(t, "dependency db is not up", report.Checks[2].Error)
+}
+
+func TestHealth_damping(t *testing.T) {
+	now := time.Now()
+	var err error
+	health := NewHealth(HealthCheck{
+		Name:     "flaky",
+		Check:    func(ctx context.Context) error { return err },
+		Failures: 2,
+		Window:   time.Minute,
+	})
+	health.now = func() time.Time { return now }
+
+	err = errors.New("timeout")
+	report := health.Check(context.Background())
+	assert.Equal(t, HealthUp, report.Status, "the first failure should be damped")
+	assert.Equal(t, 1, report.Checks[0].Failures)
+	assert.Equal(t, "timeout", report.Checks[0].Error)
+	assert.Nil(t, report.Checks[0].LastSuccess)
+
+	now = now.Add(2 * time.Minute)
+	report = health.Check(context.Background())
+	assert.Equal(t, HealthUp, report.Status, "the failures out of window should be forgotten")
+
+	now = now.Add(time.Second)
+	report = health.Check(context.Background())
+	assert.Equal(t, HealthDown, report.Status)
+	assert.Equal(t, 2, report.Checks[0].Failures)
+
+	err = nil
+	report = health.Check(context.Background())
+	assert.Equal(t, HealthUp, report.Status, "a success should be reported immediately")
+	assert.Equal(t, now, *report.Checks[0].LastSuccess)
+}
+
+func TestHealthCheckModule(t *testing.T) {
+	router := mux.NewRouter()
+	HealthCheckModule{Health: NewHealth(HealthCheck{Name: "db", Check: func(ctx context.Context) error {
+		return errors.New("db unreachable")
+	}})}.ProvideHTTP(router)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	var report HealthReport
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.Equal(t, HealthDown, report.Status)
+	assert.Equal(t, "db unreachable", report.Checks[0].Error)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/live", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -9,10 +9,19 @@ import (
 // It uses github.com/heptiolabs/healthcheck underneath. It doesn't do much out of box other than providing liveness
 // check at ``/live`` and readiness check at ``/ready``. End user should add health checking functionality by themself,
 // e.g. probe if database connection pool has exhausted at readiness check.
-type HealthCheckModule struct{}
+//
+// If Health is set, the readiness check at ``/ready`` is served by it instead,
+// reporting the status of every check as JSON.
+type HealthCheckModule struct {
+	Health *Health
+}
 
 // ProvideHTTP implements container.HTTPProvider
 func (h HealthCheckModule) ProvideHTTP(router *mux.Router) {
 	router.PathPrefix("/live").Handler(healthcheck.NewHandler())
+	if h.Health != nil {
+		router.PathPrefix("/ready").Handler(h.Health)
+		return
+	}
 	router.PathPrefix("/ready").Handler(healthcheck.NewHandler())
 }
@@ -0,0 +1,31 @@
+package srvhttp
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+
+	"github.com/gorilla/mux"
+)
+
+// MakeLabelMiddleware creates a standard HTTP middleware that sets the
+// runtime/pprof labels "transport", "route" and "method" while serving the
+// request, so that the CPU profiles can be sliced by endpoint. The route is the
+// path template of the matched mux route, so the middleware should be added
+// with mux.Router.Use.
+func MakeLabelMiddleware() func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			route := "unknown"
+			if current := mux.CurrentRoute(request); current != nil {
+				if tpl, err := current.GetPathTemplate(); err == nil {
+					route = tpl
+				}
+			}
+			labels := pprof.Labels("transport", "http", "route", route, "method", request.Method)
+			pprof.Do(request.Context(), labels, func(ctx context.Context) {
+				handler.ServeHTTP(writer, request.WithContext(ctx))
+			})
+		})
+	}
+}
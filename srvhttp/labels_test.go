@@ -0,0 +1,27 @@
+package srvhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeLabelMiddleware(t *testing.T) {
+	var labels map[string]string
+	router := mux.NewRouter()
+	router.Use(MakeLabelMiddleware())
+	router.HandleFunc("/users/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		labels = make(map[string]string)
+		pprof.ForLabels(request.Context(), func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users/1", nil))
+	assert.Equal(t, map[string]string{"transport": "http", "route": "/users/{id}", "method": "POST"}, labels)
+}
@@ -1,15 +1,38 @@
 package srvhttp
 
 import (
+	"net/http"
+	"sync"
+
+	"github.com/DoNewsCode/core/bufpool"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/prometheus"
 	"github.com/gorilla/mux"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+var (
+	bufferHitRate     metrics.Gauge
+	bufferHitRateOnce sync.Once
+)
+
 // MetricsModule exposes prometheus metrics to `/metrics`. This is the standard route
-// for prometheus metrics scrappers.
+// for prometheus metrics scrappers. The hit rate of bufpool.Default is reported
+// on every scrape as "buffer_pool_hit_rate", labeled by the tier size.
 type MetricsModule struct{}
 
 // ProvideHTTP implements container.HTTPProvider
 func (m MetricsModule) ProvideHTTP(router *mux.Router) {
-	router.PathPrefix("/metrics").Handler(promhttp.Handler())
+	bufferHitRateOnce.Do(func() {
+		bufferHitRate = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Name: "buffer_pool_hit_rate",
+			Help: "ratio of buffers served from the pool to buffers taken, by tier size",
+		}, []string{"size"})
+	})
+	handler := promhttp.Handler()
+	router.PathPrefix("/metrics").Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		bufpool.Default.Collect(bufferHitRate)
+		handler.ServeHTTP(writer, request)
+	}))
 }
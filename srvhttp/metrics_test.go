@@ -0,0 +1,26 @@
+package srvhttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DoNewsCode/core/bufpool"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsModule(t *testing.T) {
+	bufpool.Put(bufpool.Get(100))
+	bufpool.Get(100)
+
+	router := mux.NewRouter()
+	MetricsModule{}.ProvideHTTP(router)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, string(body), `buffer_pool_hit_rate{size="512"}`)
+}
@@ -0,0 +1,153 @@
+package srvhttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Rule is a routing rule applied before the module routes. A request matches
+// the rule if both the Host and the Path match. Matching rules add their
+// Headers to the response, and rewrite the path. The first matching redirect
+// rule ends the request.
+//
+// Canonical host, for example:
+//
+//	http:
+//	  rules:
+//	    - host: www.example.com
+//	      redirect: https://example.com$0
+type Rule struct {
+	// Host is the host to match, without port. A leading "*." matches any
+	// subdomain. Empty matches any host.
+	Host string `json:"host" yaml:"host"`
+	// Path is a regular expression matched against the URL path. Empty
+	// matches any path.
+	Path string `json:"path" yaml:"path"`
+	// Redirect is the target URL. The submatches of Path can be referenced as
+	// $1, ${name}, and the whole path as $0. The query string is kept if the
+	// target has none.
+	Redirect string `json:"redirect" yaml:"redirect"`
+	// Status is the redirect status code. Defaults to 301.
+	Status int `json:"status" yaml:"status"`
+	// Rewrite is the new path, which can reference the submatches like
+	// Redirect.
+	Rewrite string `json:"rewrite" yaml:"rewrite"`
+	// Headers are set on the response.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+}
+
+type compiledRule struct {
+	Rule
+	path *regexp.Regexp
+}
+
+func (c compiledRule) matchHost(host string) bool {
+	if c.Host == "" {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if strings.HasPrefix(c.Host, "*.") {
+		return len(host) > len(c.Host)-1 && strings.EqualFold(host[len(host)-len(c.Host)+1:], c.Host[1:])
+	}
+	return strings.EqualFold(host, c.Host)
+}
+
+func (c compiledRule) expand(template string, path string, match []int) string {
+	return string(c.path.ExpandString(nil, template, path, match))
+}
+
+// Rules is a set of routing rules, such as redirects, rewrites and response
+// headers, that are evaluated in order. Rules is safe for concurrent use.
+type Rules struct {
+	rwLock sync.RWMutex
+	rules  []compiledRule
+}
+
+// NewRules compiles the rules. It returns an error if any rule is invalid.
+func NewRules(rules []Rule) (*Rules, error) {
+	r := &Rules{}
+	if err := r.Update(rules); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces the rules. It is called when config reloads. The rules are
+// left unchanged if any new rule is invalid.
+func (r *Rules) Update(rules []Rule) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Redirect == "" && rule.Rewrite == "" && len(rule.Headers) == 0 {
+			return fmt.Errorf("rule %d has no redirect, rewrite or headers", i)
+		}
+		if rule.Redirect != "" && rule.Rewrite != "" {
+			return fmt.Errorf("rule %d cannot both redirect and rewrite", i)
+		}
+		if rule.Status == 0 {
+			rule.Status = http.StatusMovedPermanently
+		}
+		if rule.Status < 300 || rule.Status > 399 {
+			return fmt.Errorf("rule %d has an invalid redirect status %d", i, rule.Status)
+		}
+		pattern := rule.Path
+		if pattern == "" {
+			pattern = "^.*$"
+		}
+		path, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("rule %d has an invalid path: %w", i, err)
+		}
+		compiled = append(compiled, compiledRule{Rule: rule, path: path})
+	}
+
+	r.rwLock.Lock()
+	defer r.rwLock.Unlock()
+
+	r.rules = compiled
+	return nil
+}
+
+// MakeRulesMiddleware creates a standard HTTP middleware that applies the
+// rules before the handler.
+func MakeRulesMiddleware(rules *Rules) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			rules.rwLock.RLock()
+			compiled := rules.rules
+			rules.rwLock.RUnlock()
+
+			for _, rule := range compiled {
+				if !rule.matchHost(request.Host) {
+					continue
+				}
+				path := request.URL.Path
+				match := rule.path.FindStringSubmatchIndex(path)
+				if match == nil {
+					continue
+				}
+				for k, v := range rule.Headers {
+					writer.Header().Set(k, v)
+				}
+				if rule.Redirect != "" {
+					target := rule.expand(rule.Redirect, path, match)
+					if request.URL.RawQuery != "" && !strings.Contains(target, "?") {
+						target += "?" + request.URL.RawQuery
+					}
+					http.Redirect(writer, request, target, rule.Status)
+					return
+				}
+				if rule.Rewrite != "" {
+					request.URL.Path = rule.expand(rule.Rewrite, path, match)
+					request.URL.RawPath = ""
+				}
+			}
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
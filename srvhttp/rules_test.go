@@ -0,0 +1,58 @@
+package srvhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	rules, err := NewRules([]Rule{
+		{Host: "www.example.com", Redirect: "https://example.com$0"},
+		{Path: `^/old/(?P<rest>.*)$`, Redirect: "/new/${rest}", Status: http.StatusFound},
+		{Host: "*.example.com", Headers: map[string]string{"X-Frame-Options": "DENY"}},
+		{Path: `^/v1/(.*)$`, Rewrite: "/api/$1"},
+	})
+	assert.NoError(t, err)
+	handler := MakeRulesMiddleware(rules)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.URL.Path))
+	}))
+
+	cases := []struct {
+		name     string
+		url      string
+		code     int
+		location string
+		body     string
+		header   string
+	}{
+		{"canonical host", "http://www.example.com:8080/foo?bar=baz", 301, "https://example.com/foo?bar=baz", "", ""},
+		{"redirect", "http://example.com/old/foo", 302, "/new/foo", "", ""},
+		{"rewrite", "http://api.example.com/v1/foo", 200, "", "/api/foo", "DENY"},
+		{"no match", "http://example.com/foo", 200, "", "/foo", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, c.url, nil))
+			assert.Equal(t, c.code, recorder.Code)
+			assert.Equal(t, c.location, recorder.Header().Get("Location"))
+			assert.Equal(t, c.header, recorder.Header().Get("X-Frame-Options"))
+			if c.body != "" {
+				assert.Equal(t, c.body, recorder.Body.String())
+			}
+		})
+	}
+
+	assert.Error(t, rules.Update([]Rule{{Path: "("}}))
+	assert.Error(t, rules.Update([]Rule{{Path: "(", Rewrite: "/"}}))
+	assert.Error(t, rules.Update([]Rule{{Redirect: "/", Rewrite: "/"}}))
+	assert.Error(t, rules.Update([]Rule{{Redirect: "/", Status: 200}}))
+
+	assert.NoError(t, rules.Update(nil))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://www.example.com/old/foo", nil))
+	assert.Equal(t, "/old/foo", recorder.Body.String())
+}
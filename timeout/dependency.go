@@ -0,0 +1,73 @@
+package timeout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+)
+
+/*
+Providers returns a set of dependency providers for *Budget.
+
+	Depends On:
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		*Budget
+*/
+func Providers() di.Deps {
+	return di.Deps{provideBudget, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+func provideBudget(in in) (*Budget, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("timeout", &option); err != nil {
+		return nil, fmt.Errorf("timeout configuration error: %w", err)
+	}
+	budget := NewBudget(option)
+	if in.Dispatcher != nil {
+		in.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+			var option Option
+			if err := event.(events.OnReloadPayload).NewConf.Unmarshal("timeout", &option); err != nil {
+				return fmt.Errorf("timeout configuration error: %w", err)
+			}
+			budget.Update(option)
+			return nil
+		}))
+	}
+	return budget, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "timeout",
+			Data: map[string]interface{}{
+				"timeout": Option{
+					Default:  config.Duration{Duration: 5 * time.Second},
+					Overhead: config.Duration{Duration: 10 * time.Millisecond},
+					Routes:   []Route{},
+				},
+			},
+			Comment: "The default deadlines of incoming requests",
+		},
+	}}
+}
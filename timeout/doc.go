@@ -0,0 +1,58 @@
+/*
+Package timeout provides systematic deadline management across the layers that
+package core wires together.
+
+A Budget assigns default deadlines to incoming requests, keyed by HTTP route
+template or gRPC full method name. Downstream calls (databases, kafka, other
+services) can then derive a child budget from the request context. The child
+budget is the remaining time reduced by a configured overhead, so that the
+caller still has time to handle the downstream failure before its own deadline
+fires. When the budget is exhausted, the active span is annotated so that the
+culprit is visible in traces.
+
+Integration
+
+package timeout exports the configuration in the following format:
+
+	timeout:
+	    default: 5s
+	    overhead: 10ms
+	    routes:
+	        - name: /foo/{id}
+	          timeout: 1s
+	        - name: /pkg.Service/Method
+	          timeout: 2s
+
+Add the timeout dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(timeout.Providers())
+
+Then apply the middleware or interceptor, and derive child budgets when calling
+downstream:
+
+	c.Invoke(func(budget *timeout.Budget) {
+		router.Use(timeout.MakeHTTPMiddleware(budget))
+	})
+
+	ctx, cancel := budget.Derive(ctx)
+	defer cancel()
+	db.WithContext(ctx).Find(&users)
+
+Propagation
+
+Deadlines propagate across the services built on core, so that a callee stops
+working as soon as its caller gives up:
+
+	- The HTTP middleware honors the absolute deadline in the
+	  X-Request-Deadline header, or the relative one in Grpc-Timeout.
+	- clihttp.Client sets X-Request-Deadline from the context of the request.
+	- gRPC propagates deadlines by itself, through grpc-timeout. The gRPC
+	  interceptor also honors X-Request-Deadline in the metadata.
+	- otkafka.Writer and eventskafka.Dispatcher add X-Request-Deadline to the
+	  messages. Consumers apply it with otkafka.DeadlineFromMessage, and the
+	  listeners of eventskafka receive it in their context.
+
+The propagated deadline only ever shortens the deadline of the route.
+*/
+package timeout
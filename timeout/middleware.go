@@ -0,0 +1,54 @@
+package timeout
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that assigns deadlines
+// to incoming requests. Routes are matched by the mux path template, falling
+// back to the request path. The deadline propagated by the caller in
+// DeadlineHeader or GRPCTimeoutHeader is honored if it is earlier.
+func MakeHTTPMiddleware(budget *Budget) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			name := request.URL.Path
+			if route := mux.CurrentRoute(request); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					name = tpl
+				}
+			}
+			ctx, cancelPropagated := FromHTTPHeader(request.Context(), request.Header)
+			defer cancelPropagated()
+			ctx, cancel := budget.WithTimeout(ctx, name)
+			defer cancel()
+
+			handler.ServeHTTP(writer, request.WithContext(ctx))
+			if ctx.Err() == context.DeadlineExceeded {
+				annotate(ctx, 0)
+			}
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that assigns
+// deadlines to incoming calls, keyed by the full method name. The deadline
+// propagated by the caller, in grpc-timeout or in the DeadlineHeader metadata,
+// is honored if it is earlier.
+func MakeUnaryInterceptor(budget *Budget) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancelPropagated := fromGRPCMetadata(ctx)
+		defer cancelPropagated()
+		ctx, cancel := budget.WithTimeout(ctx, info.FullMethod)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if ctx.Err() == context.DeadlineExceeded {
+			annotate(ctx, 0)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,100 @@
+package timeout
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DeadlineHeader carries the absolute deadline of a request, formatted in
+	// RFC3339 with nanoseconds, across HTTP calls and kafka messages. It relies
+	// on the clocks of the services being synchronized.
+	DeadlineHeader = "X-Request-Deadline"
+	// GRPCTimeoutHeader carries the relative timeout of a gRPC call, eg.
+	// "100m". It is accepted on incoming HTTP requests too, for the callers
+	// that prefer a timeout to an absolute deadline.
+	GRPCTimeoutHeader = "Grpc-Timeout"
+)
+
+// FormatDeadline formats the deadline for DeadlineHeader.
+func FormatDeadline(deadline time.Time) string {
+	return deadline.UTC().Format(time.RFC3339Nano)
+}
+
+// ParseDeadline parses the value of DeadlineHeader.
+func ParseDeadline(value string) (time.Time, error) {
+	deadline, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline %q: %w", value, err)
+	}
+	return deadline, nil
+}
+
+// ParseGRPCTimeout parses the value of GRPCTimeoutHeader, a positive integer of
+// at most 8 digits followed by one of the units H, M, S, m, u and n.
+func ParseGRPCTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, fmt.Errorf("invalid grpc timeout %q", value)
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, fmt.Errorf("invalid grpc timeout unit %q", value)
+	}
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid grpc timeout %q", value)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// FromHTTPHeader applies the deadline propagated by the caller in the header,
+// either DeadlineHeader or GRPCTimeoutHeader, to the context. The earlier
+// deadline wins if the context already has one. Malformed headers are ignored.
+func FromHTTPHeader(ctx context.Context, header http.Header) (context.Context, context.CancelFunc) {
+	if value := header.Get(DeadlineHeader); value != "" {
+		if deadline, err := ParseDeadline(value); err == nil {
+			return context.WithDeadline(ctx, deadline)
+		}
+	}
+	if value := header.Get(GRPCTimeoutHeader); value != "" {
+		if d, err := ParseGRPCTimeout(value); err == nil {
+			return context.WithTimeout(ctx, d)
+		}
+	}
+	return context.WithCancel(ctx)
+}
+
+// InjectHTTPHeader sets DeadlineHeader to the deadline of the context, if any,
+// so that the callee inherits it.
+func InjectHTTPHeader(ctx context.Context, header http.Header) {
+	if deadline, ok := ctx.Deadline(); ok {
+		header.Set(DeadlineHeader, FormatDeadline(deadline))
+	}
+}
+
+// fromGRPCMetadata applies DeadlineHeader in the incoming gRPC metadata to the
+// context, eg. one set by an HTTP gateway. The grpc-timeout of the call is
+// already applied by gRPC itself.
+func fromGRPCMetadata(ctx context.Context) (context.Context, context.CancelFunc) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(strings.ToLower(DeadlineHeader)) {
+		if deadline, err := ParseDeadline(value); err == nil {
+			return context.WithDeadline(ctx, deadline)
+		}
+	}
+	return context.WithCancel(ctx)
+}
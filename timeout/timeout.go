@@ -0,0 +1,116 @@
+package timeout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Option is the configuration of Budget.
+type Option struct {
+	// Default is the deadline assigned to requests without a matching route.
+	// Zero means no deadline.
+	Default config.Duration `json:"default" yaml:"default"`
+	// Overhead is subtracted from the remaining time when deriving a child budget.
+	Overhead config.Duration `json:"overhead" yaml:"overhead"`
+	// Routes overrides the default deadline for individual routes.
+	Routes []Route `json:"routes" yaml:"routes"`
+}
+
+// Route is the deadline for a single route. Routes are given as a list rather
+// than a map, since gRPC method names contain the config delimiter.
+type Route struct {
+	// Name is the HTTP route template or the gRPC full method name.
+	Name    string          `json:"name" yaml:"name"`
+	Timeout config.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// Budget assigns deadlines to requests and derives child budgets for
+// downstream calls. Budget is safe for concurrent use.
+type Budget struct {
+	rwLock sync.RWMutex
+	option Option
+}
+
+// NewBudget creates a new *Budget from the given Option.
+func NewBudget(option Option) *Budget {
+	return &Budget{option: option}
+}
+
+// Update replaces the Option of Budget. It is called when config reloads.
+func (b *Budget) Update(option Option) {
+	b.rwLock.Lock()
+	defer b.rwLock.Unlock()
+
+	b.option = option
+}
+
+// Timeout returns the deadline configured for the given route or method name,
+// falling back to the default one.
+func (b *Budget) Timeout(name string) time.Duration {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	for _, route := range b.option.Routes {
+		if route.Name == name {
+			return route.Timeout.Duration
+		}
+	}
+	return b.option.Default.Duration
+}
+
+// WithTimeout assigns the deadline configured for the given route or method name
+// to the context. If the context already carries an earlier deadline, eg. one
+// propagated by the caller, that deadline wins.
+func (b *Budget) WithTimeout(ctx context.Context, name string) (context.Context, context.CancelFunc) {
+	d := b.Timeout(name)
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Derive derives a child budget for a downstream call. The child deadline is
+// the parent deadline minus the configured overhead. If the context has no
+// deadline, Derive returns a cancelable copy of it. If the budget is exhausted,
+// the active span is tagged with "timeout.exhausted" and the returned context
+// is already expired, so that the downstream call fails fast.
+func (b *Budget) Derive(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	b.rwLock.RLock()
+	overhead := b.option.Overhead.Duration
+	b.rwLock.RUnlock()
+
+	remaining := time.Until(deadline) - overhead
+	if remaining <= 0 {
+		annotate(ctx, remaining+overhead)
+		return context.WithDeadline(ctx, time.Now())
+	}
+	return context.WithTimeout(ctx, remaining)
+}
+
+// Remaining returns the time left before the deadline of the context. The
+// second return value is false if the context carries no deadline.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+func annotate(ctx context.Context, remaining time.Duration) {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	span.SetTag("timeout.exhausted", true)
+	span.LogKV("event", "timeout budget exhausted", "remaining", remaining.String())
+}
@@ -0,0 +1,174 @@
+package timeout
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBudget_WithTimeout(t *testing.T) {
+	budget := NewBudget(Option{
+		Default: config.Duration{Duration: time.Second},
+		Routes:  []Route{{Name: "/foo", Timeout: config.Duration{Duration: time.Minute}}},
+	})
+	assert.Equal(t, time.Second, budget.Timeout("/bar"))
+	assert.Equal(t, time.Minute, budget.Timeout("/foo"))
+
+	ctx, cancel := budget.WithTimeout(context.Background(), "/foo")
+	defer cancel()
+	remaining, ok := Remaining(ctx)
+	assert.True(t, ok)
+	assert.True(t, remaining > time.Second)
+
+	// an earlier deadline from the caller wins.
+	parent, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	ctx, cancel = budget.WithTimeout(parent, "/foo")
+	defer cancel()
+	remaining, _ = Remaining(ctx)
+	assert.True(t, remaining <= time.Millisecond)
+}
+
+func TestBudget_Derive(t *testing.T) {
+	budget := NewBudget(Option{Overhead: config.Duration{Duration: 100 * time.Millisecond}})
+
+	ctx, cancel := budget.Derive(context.Background())
+	defer cancel()
+	_, ok := Remaining(ctx)
+	assert.False(t, ok)
+
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx, cancel = budget.Derive(parent)
+	defer cancel()
+	remaining, _ := Remaining(ctx)
+	assert.True(t, remaining <= 900*time.Millisecond)
+
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("test")
+	parent, cancel = context.WithTimeout(opentracing.ContextWithSpan(context.Background(), span), 50*time.Millisecond)
+	defer cancel()
+	ctx, cancel = budget.Derive(parent)
+	defer cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	span.Finish()
+	assert.Equal(t, true, tracer.FinishedSpans()[0].Tag("timeout.exhausted"))
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	budget := NewBudget(Option{Default: config.Duration{Duration: time.Second}})
+	handler := MakeHTTPMiddleware(budget)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, ok := request.Context().Deadline()
+		assert.True(t, ok)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestProvideBudget(t *testing.T) {
+	conf, _ := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(`
+timeout:
+  default: 2s
+  overhead: 10ms
+  routes:
+    - name: /pkg.Service/Method
+      timeout: 3s
+`)), yaml.Parser()))
+	budget, err := provideBudget(in{Conf: conf})
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, budget.Timeout("/foo"))
+	assert.Equal(t, 3*time.Second, budget.Timeout("/pkg.Service/Method"))
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	cases := []struct {
+		value string
+		want  time.Duration
+		err   bool
+	}{
+		{"100m", 100 * time.Millisecond, false},
+		{"2S", 2 * time.Second, false},
+		{"1H", time.Hour, false},
+		{"5u", 5 * time.Microsecond, false},
+		{"100", 0, true},
+		{"m", 0, true},
+		{"123456789S", 0, true},
+		{"-1S", 0, true},
+	}
+	for _, c := range cases {
+		d, err := ParseGRPCTimeout(c.value)
+		if c.err {
+			assert.Error(t, err, c.value)
+			continue
+		}
+		assert.NoError(t, err, c.value)
+		assert.Equal(t, c.want, d, c.value)
+	}
+}
+
+func TestFromHTTPHeader(t *testing.T) {
+	deadline := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	header := http.Header{}
+	InjectHTTPHeader(context.Background(), header)
+	assert.Empty(t, header.Get(DeadlineHeader))
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	InjectHTTPHeader(ctx, header)
+	assert.Equal(t, FormatDeadline(deadline), header.Get(DeadlineHeader))
+
+	propagated, cancel := FromHTTPHeader(context.Background(), header)
+	defer cancel()
+	d, ok := propagated.Deadline()
+	assert.True(t, ok)
+	assert.True(t, deadline.Equal(d))
+
+	header = http.Header{}
+	header.Set(GRPCTimeoutHeader, "1S")
+	propagated, cancel = FromHTTPHeader(context.Background(), header)
+	defer cancel()
+	remaining, ok := Remaining(propagated)
+	assert.True(t, ok)
+	assert.True(t, remaining <= time.Second)
+
+	header.Set(GRPCTimeoutHeader, "soon")
+	propagated, cancel = FromHTTPHeader(context.Background(), header)
+	defer cancel()
+	_, ok = propagated.Deadline()
+	assert.False(t, ok)
+}
+
+func TestMakeHTTPMiddleware_propagated(t *testing.T) {
+	budget := NewBudget(Option{Default: config.Duration{Duration: time.Minute}})
+	deadline := time.Now().Add(time.Second).Truncate(time.Millisecond)
+	handler := MakeHTTPMiddleware(budget)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		d, _ := request.Context().Deadline()
+		assert.True(t, deadline.Equal(d), "the earlier propagated deadline should win")
+	}))
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(DeadlineHeader, FormatDeadline(deadline))
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+}
+
+func TestMakeUnaryInterceptor_propagated(t *testing.T) {
+	budget := NewBudget(Option{Default: config.Duration{Duration: time.Minute}})
+	deadline := time.Now().Add(time.Second).Truncate(time.Millisecond)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(DeadlineHeader, FormatDeadline(deadline)))
+	_, err := MakeUnaryInterceptor(budget)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		d, _ := ctx.Deadline()
+		assert.True(t, deadline.Equal(d), "the earlier propagated deadline should win")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+}
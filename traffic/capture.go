@@ -0,0 +1,185 @@
+package traffic
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const redacted = "[REDACTED]"
+
+// Option is the configuration of Capturer.
+type Option struct {
+	// SampleRate is the fraction of requests to capture, between 0 and 1.
+	SampleRate float64 `json:"sampleRate" yaml:"sampleRate"`
+	// MaxBodySize is the maximum number of body bytes to capture. Zero captures no body.
+	MaxBodySize int `json:"maxBodySize" yaml:"maxBodySize"`
+	// Redact is the list of header names whose values are redacted.
+	Redact []string `json:"redact" yaml:"redact"`
+	// RedactQuery is the list of query parameters whose values are redacted in
+	// the recorded URL. The bodies are stored as captured.
+	RedactQuery []string `json:"redactQuery" yaml:"redactQuery"`
+	// File is the path of the JSON lines file. If empty, records are kept in a ring buffer.
+	File string `json:"file" yaml:"file"`
+	// BufferSize is the capacity of the ring buffer.
+	BufferSize int `json:"bufferSize" yaml:"bufferSize"`
+	// Expose serves the captured records at "/debug/traffic". Keep it off unless
+	// the endpoint is guarded, as the records may contain sensitive data.
+	Expose bool `json:"expose" yaml:"expose"`
+}
+
+// Capturer samples inbound requests and saves them to the Store.
+type Capturer struct {
+	rwLock sync.RWMutex
+	option Option
+	store  Store
+	logger log.Logger
+}
+
+// NewCapturer creates a new *Capturer.
+func NewCapturer(option Option, store Store, logger log.Logger) *Capturer {
+	return &Capturer{option: option, store: store, logger: logger}
+}
+
+// Update replaces the Option of Capturer. The Store is not changed.
+func (c *Capturer) Update(option Option) {
+	c.rwLock.Lock()
+	defer c.rwLock.Unlock()
+
+	c.option = option
+}
+
+// Store returns the Store of Capturer.
+func (c *Capturer) Store() Store {
+	return c.store
+}
+
+func (c *Capturer) exposed() bool {
+	c.rwLock.RLock()
+	defer c.rwLock.RUnlock()
+
+	return c.option.Expose
+}
+
+func (c *Capturer) sample() (Option, bool) {
+	c.rwLock.RLock()
+	defer c.rwLock.RUnlock()
+
+	if c.option.SampleRate <= 0 || rand.Float64() >= c.option.SampleRate {
+		return Option{}, false
+	}
+	return c.option, true
+}
+
+func (c *Capturer) save(record Record, option Option) {
+	for _, name := range option.Redact {
+		for key := range record.Header {
+			if strings.EqualFold(key, name) {
+				record.Header[key] = []string{redacted}
+			}
+		}
+	}
+	if len(option.RedactQuery) > 0 {
+		record.URL = redactQuery(record.URL, option.RedactQuery)
+	}
+	if len(record.Body) > option.MaxBodySize {
+		record.Body = record.Body[:option.MaxBodySize]
+		record.Truncated = true
+	}
+	if err := c.store.Add(record); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to capture request", "err", err)
+	}
+}
+
+// redactQuery redacts the values of the query parameters in the request URI.
+// The order of the parameters is kept, so that the request can be replayed as is.
+func redactQuery(uri string, names []string) string {
+	i := strings.IndexByte(uri, '?')
+	if i < 0 {
+		return uri
+	}
+	pairs := strings.Split(uri[i+1:], "&")
+	for j, pair := range pairs {
+		rawKey := pair
+		if k := strings.IndexByte(pair, '='); k >= 0 {
+			rawKey = pair[:k]
+		}
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				pairs[j] = rawKey + "=" + url.QueryEscape(redacted)
+				break
+			}
+		}
+	}
+	return uri[:i+1] + strings.Join(pairs, "&")
+}
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that captures sampled requests.
+func MakeHTTPMiddleware(capturer *Capturer) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			option, ok := capturer.sample()
+			if !ok {
+				handler.ServeHTTP(writer, request)
+				return
+			}
+			record := Record{
+				Time:      time.Now(),
+				Transport: "http",
+				Method:    request.Method,
+				URL:       request.URL.RequestURI(),
+				Header:    request.Header.Clone(),
+			}
+			if request.Body != nil {
+				// read one extra byte to find out if the body is truncated.
+				body, _ := ioutil.ReadAll(io.LimitReader(request.Body, int64(option.MaxBodySize)+1))
+				request.Body = readCloser{io.MultiReader(bytes.NewReader(body), request.Body), request.Body}
+				record.Body = body
+			}
+			capturer.save(record, option)
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that captures sampled calls.
+func MakeUnaryInterceptor(capturer *Capturer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if option, ok := capturer.sample(); ok {
+			md, _ := metadata.FromIncomingContext(ctx)
+			record := Record{
+				Time:      time.Now(),
+				Transport: "grpc",
+				URL:       info.FullMethod,
+				Header:    http.Header(md.Copy()),
+			}
+			if msg, ok := req.(proto.Message); ok {
+				record.Body, _ = proto.Marshal(msg)
+			}
+			capturer.save(record, option)
+		}
+		return handler(ctx, req)
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,92 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+	"github.com/go-kit/kit/log"
+)
+
+/*
+Providers returns a set of dependency providers for package traffic.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		*Capturer
+*/
+func Providers() di.Deps {
+	return di.Deps{provideCapturer, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger     log.Logger
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Capturer *Capturer
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideCapturer(in in) (out, error) {
+	var option Option
+	if err := in.Conf.Unmarshal("traffic", &option); err != nil {
+		return out{}, fmt.Errorf("traffic configuration error: %w", err)
+	}
+	var store Store = NewRingBuffer(option.BufferSize)
+	if option.File != "" {
+		store = &FileStore{Path: option.File}
+	}
+	capturer := NewCapturer(option, store, in.Logger)
+	if in.Dispatcher != nil {
+		in.Dispatcher.Subscribe(events.Listen(events.OnReload, func(ctx context.Context, event interface{}) error {
+			var option Option
+			if err := event.(events.OnReloadPayload).NewConf.Unmarshal("traffic", &option); err != nil {
+				return fmt.Errorf("traffic configuration error: %w", err)
+			}
+			capturer.Update(option)
+			return nil
+		}))
+	}
+	return out{Capturer: capturer}, nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "traffic",
+			Data: map[string]interface{}{
+				"traffic": Option{
+					SampleRate:  0,
+					MaxBodySize: 4096,
+					Redact:      []string{"authorization", "cookie", "set-cookie", "x-api-key", "x-debug-token"},
+					RedactQuery: []string{"debug_token"},
+					File:        "",
+					BufferSize:  100,
+					Expose:      false,
+				},
+			},
+			Comment: "The traffic capture configuration",
+		},
+	}}
+}
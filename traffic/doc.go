@@ -0,0 +1,44 @@
+/*
+Package traffic captures sampled inbound requests and replays them against a
+target. It is useful for reproducing production bugs locally.
+
+The HTTP middleware and the gRPC interceptor record the headers and the body
+(up to a size cap) of sampled requests. Sensitive headers, such as
+Authorization and Cookie, and sensitive query parameters, such as debug_token,
+are redacted before the request is stored. The bodies are stored as captured.
+The records are kept in a ring buffer, or appended to a file in JSON lines,
+depending on the configuration.
+
+If expose is enabled, captured records can be inspected at the debug endpoint
+"/debug/traffic". Records can be replayed with the command:
+
+	app traffic replay --file traffic.jsonl --target http://127.0.0.1:8080
+
+Integration
+
+package traffic exports the configuration in the following format:
+
+	traffic:
+	    sampleRate: 0
+	    maxBodySize: 4096
+	    redact:
+	        - authorization
+	        - cookie
+	        - set-cookie
+	        - x-api-key
+	        - x-debug-token
+	    redactQuery:
+	        - debug_token
+	    file: ""
+	    bufferSize: 100
+	    expose: false
+
+A sampleRate of zero disables the capture. Add the traffic dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(traffic.Providers())
+	c.Invoke(func(capturer *traffic.Capturer) {
+		router.Use(traffic.MakeHTTPMiddleware(capturer))
+	})
+*/
+package traffic
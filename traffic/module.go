@@ -0,0 +1,93 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// ProvideHTTP exposes the captured records at "/debug/traffic", if enabled by
+// Option.Expose.
+func (m out) ProvideHTTP(router *mux.Router) {
+	router.Methods(http.MethodGet).Path("/debug/traffic").HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !m.Capturer.exposed() {
+			http.NotFound(writer, request)
+			return
+		}
+		records, err := m.Capturer.Store().List()
+		if records == nil {
+			records = []Record{}
+		}
+		srvhttp.NewResponseEncoder(writer).Encode(records, err)
+	})
+}
+
+// ProvideCommand adds the "traffic replay" command.
+func (m out) ProvideCommand(command *cobra.Command) {
+	var (
+		file   string
+		target string
+	)
+	replayCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "replay captured requests",
+		Long:  "replay the requests captured in the traffic file against the target, one by one.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := (&FileStore{Path: file}).List()
+			if err != nil {
+				return err
+			}
+			var conn *grpc.ClientConn
+			for _, record := range records {
+				switch record.Transport {
+				case "http":
+					resp, err := ReplayHTTP(cmd.Context(), http.DefaultClient, target, record)
+					if err != nil {
+						cmd.PrintErrf("%s %s: %s\n", record.Method, record.URL, err)
+						continue
+					}
+					resp.Body.Close()
+					cmd.Printf("%s %s: %s\n", record.Method, record.URL, resp.Status)
+				case "grpc":
+					if conn == nil {
+						if conn, err = grpc.DialContext(context.Background(), grpcAddr(target), grpc.WithInsecure()); err != nil {
+							return fmt.Errorf("failed to dial %s: %w", target, err)
+						}
+						defer conn.Close()
+					}
+					if _, err := ReplayGRPC(cmd.Context(), conn, record); err != nil {
+						cmd.PrintErrf("%s: %s\n", record.URL, err)
+						continue
+					}
+					cmd.Printf("%s: OK\n", record.URL)
+				}
+			}
+			return nil
+		},
+	}
+	replayCmd.Flags().StringVarP(&file, "file", "f", "traffic.jsonl", "the traffic file to replay")
+	replayCmd.Flags().StringVarP(&target, "target", "t", "http://127.0.0.1:8080", "the target address")
+
+	trafficCmd := &cobra.Command{
+		Use:   "traffic",
+		Short: "manage captured traffic",
+		Long:  "manage captured traffic",
+	}
+	trafficCmd.AddCommand(replayCmd)
+	command.AddCommand(trafficCmd)
+}
+
+// grpcAddr strips the scheme of the target, if any, eg. "http://127.0.0.1:8080"
+// becomes "127.0.0.1:8080".
+func grpcAddr(target string) string {
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return target
+}
@@ -0,0 +1,82 @@
+package traffic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ReplayHTTP sends the captured HTTP request to the target, eg.
+// "http://127.0.0.1:8080". Redacted headers are not sent.
+func ReplayHTTP(ctx context.Context, doer contract.HttpDoer, target string, record Record) (*http.Response, error) {
+	if record.Transport != "http" {
+		return nil, fmt.Errorf("cannot replay %s record over http", record.Transport)
+	}
+	if record.Truncated {
+		return nil, fmt.Errorf("cannot replay truncated record %s %s", record.Method, record.URL)
+	}
+	request, err := http.NewRequestWithContext(ctx, record.Method, strings.TrimSuffix(target, "/")+record.URL, bytes.NewReader(record.Body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range record.Header {
+		for _, value := range values {
+			if value != redacted {
+				request.Header.Add(key, value)
+			}
+		}
+	}
+	return doer.Do(request)
+}
+
+// ReplayGRPC sends the captured gRPC call through the connection. The response
+// is returned in protobuf wire format. Redacted metadata are not sent.
+func ReplayGRPC(ctx context.Context, conn *grpc.ClientConn, record Record) ([]byte, error) {
+	if record.Transport != "grpc" {
+		return nil, fmt.Errorf("cannot replay %s record over grpc", record.Transport)
+	}
+	if record.Truncated {
+		return nil, fmt.Errorf("cannot replay truncated record %s", record.URL)
+	}
+	md := metadata.MD{}
+	for key, values := range record.Header {
+		if strings.HasPrefix(key, ":") {
+			continue
+		}
+		for _, value := range values {
+			if value != redacted {
+				md.Append(key, value)
+			}
+		}
+	}
+	var reply rawMessage
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	if err := conn.Invoke(ctx, record.URL, rawMessage(record.Body), &reply, grpc.ForceCodec(rawCodec{})); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+type rawMessage []byte
+
+// rawCodec passes the protobuf wire format through as is.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return v.(rawMessage), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*rawMessage)) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
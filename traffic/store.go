@@ -0,0 +1,121 @@
+package traffic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record is a captured request.
+type Record struct {
+	// Time is when the request is received.
+	Time time.Time `json:"time"`
+	// Transport is either "http" or "grpc".
+	Transport string `json:"transport"`
+	// Method is the HTTP method. It is empty for gRPC.
+	Method string `json:"method,omitempty"`
+	// URL is the request URI for HTTP, or the full method name for gRPC.
+	URL string `json:"url"`
+	// Header is the HTTP header or gRPC metadata, with sensitive values redacted.
+	Header http.Header `json:"header"`
+	// Body is the request body. For gRPC, it is the protobuf wire format of the request message.
+	Body []byte `json:"body"`
+	// Truncated reports whether the body has been cut at the size cap.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Store persists captured records.
+type Store interface {
+	Add(record Record) error
+	List() ([]Record, error)
+}
+
+// RingBuffer is an in-memory Store that keeps the latest records.
+type RingBuffer struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+// NewRingBuffer creates a *RingBuffer holding at most size records.
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = 100
+	}
+	return &RingBuffer{records: make([]Record, size)}
+}
+
+// Add implements Store.
+func (r *RingBuffer) Add(record Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// List implements Store. The records are ordered from the oldest to the latest.
+func (r *RingBuffer) List() ([]Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]Record(nil), r.records[:r.next]...), nil
+	}
+	return append(append([]Record(nil), r.records[r.next:]...), r.records[:r.next]...), nil
+}
+
+// FileStore is a Store that appends records to a file in JSON lines.
+type FileStore struct {
+	mu   sync.Mutex
+	Path string
+}
+
+// Add implements Store.
+func (f *FileStore) Add(record Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open traffic file: %w", err)
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(record)
+}
+
+// List implements Store.
+func (f *FileStore) List() ([]Record, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.Open(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traffic file: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("malformed traffic record: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,145 @@
+package traffic
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingBuffer(t *testing.T) {
+	buffer := NewRingBuffer(2)
+	for _, url := range []string{"/1", "/2", "/3"} {
+		assert.NoError(t, buffer.Add(Record{URL: url}))
+	}
+	records, err := buffer.List()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "/2", records[0].URL)
+	assert.Equal(t, "/3", records[1].URL)
+}
+
+func TestFileStore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "traffic")
+	defer os.RemoveAll(dir)
+	store := &FileStore{Path: filepath.Join(dir, "traffic.jsonl")}
+	assert.NoError(t, store.Add(Record{Transport: "http", URL: "/1", Body: []byte("foo")}))
+	assert.NoError(t, store.Add(Record{Transport: "grpc", URL: "/2"}))
+	records, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, []byte("foo"), records[0].Body)
+	assert.Equal(t, "grpc", records[1].Transport)
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	capturer := NewCapturer(Option{SampleRate: 1, MaxBodySize: 3, Redact: []string{"authorization"}}, NewRingBuffer(10), log.NewNopLogger())
+	handler := MakeHTTPMiddleware(capturer)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		assert.Equal(t, "hello", string(body))
+		assert.Equal(t, "Bearer foo", request.Header.Get("Authorization"))
+	}))
+	request := httptest.NewRequest(http.MethodPost, "/foo?bar=baz", strings.NewReader("hello"))
+	request.Header.Set("Authorization", "Bearer foo")
+	request.Header.Set("X-Foo", "bar")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	records, _ := capturer.Store().List()
+	assert.Len(t, records, 1)
+	assert.Equal(t, "/foo?bar=baz", records[0].URL)
+	assert.Equal(t, redacted, records[0].Header.Get("Authorization"))
+	assert.Equal(t, "bar", records[0].Header.Get("X-Foo"))
+	assert.Equal(t, "hel", string(records[0].Body))
+	assert.True(t, records[0].Truncated)
+
+	capturer.Update(Option{SampleRate: 0})
+	request = httptest.NewRequest(http.MethodPost, "/foo?bar=baz", strings.NewReader("hello"))
+	request.Header.Set("Authorization", "Bearer foo")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	records, _ = capturer.Store().List()
+	assert.Len(t, records, 1)
+}
+
+func TestCapturer_redact(t *testing.T) {
+	option := Option{
+		SampleRate:  1,
+		MaxBodySize: 10,
+		Redact:      []string{"x-debug-token"},
+		RedactQuery: []string{"debug_token"},
+	}
+	capturer := NewCapturer(option, NewRingBuffer(10), log.NewNopLogger())
+	handler := MakeHTTPMiddleware(capturer)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "secret", request.URL.Query().Get("debug_token"))
+	}))
+	request := httptest.NewRequest(http.MethodGet, "/foo?a=1&debug_token=secret&b=2", nil)
+	request.Header.Set("X-Debug-Token", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	records, _ := capturer.Store().List()
+	assert.Len(t, records, 1)
+	assert.Equal(t, "/foo?a=1&debug_token=%5BREDACTED%5D&b=2", records[0].URL)
+	assert.Equal(t, redacted, records[0].Header.Get("X-Debug-Token"))
+}
+
+func TestReplayHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		assert.Equal(t, "/foo?bar=baz", request.URL.RequestURI())
+		assert.Equal(t, "hello", string(body))
+		assert.Empty(t, request.Header.Get("Authorization"))
+		assert.Equal(t, "bar", request.Header.Get("X-Foo"))
+	}))
+	defer server.Close()
+
+	record := Record{
+		Transport: "http",
+		Method:    http.MethodPost,
+		URL:       "/foo?bar=baz",
+		Header:    http.Header{"Authorization": {redacted}, "X-Foo": {"bar"}},
+		Body:      []byte("hello"),
+	}
+	resp, err := ReplayHTTP(context.Background(), http.DefaultClient, server.URL, record)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	record.Truncated = true
+	_, err = ReplayHTTP(context.Background(), http.DefaultClient, server.URL, record)
+	assert.Error(t, err)
+}
+
+func TestModule_ProvideHTTP(t *testing.T) {
+	capturer := NewCapturer(Option{}, NewRingBuffer(10), log.NewNopLogger())
+	router := mux.NewRouter()
+	out{Capturer: capturer}.ProvideHTTP(router)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/traffic", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	capturer.Update(Option{Expose: true})
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/traffic", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestGRPCAddr(t *testing.T) {
+	for _, c := range []struct {
+		target string
+		addr   string
+	}{
+		{"http://127.0.0.1:8080", "127.0.0.1:8080"},
+		{"127.0.0.1:8080", "127.0.0.1:8080"},
+		{"localhost:8080", "localhost:8080"},
+	} {
+		assert.Equal(t, c.addr, grpcAddr(c.target), c.target)
+	}
+}
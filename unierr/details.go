@@ -0,0 +1,116 @@
+package unierr
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// QuotaViolation describes a single quota check failure. It is converted to
+// google.rpc.QuotaFailure in gRPC status.
+type QuotaViolation struct {
+	// Subject is the subject on which the quota check failed, eg. "clientip:<ip>".
+	Subject string
+	// Description explains how the quota check failed.
+	Description string
+}
+
+// FieldViolation describes a single bad request field. It is converted to
+// google.rpc.BadRequest in gRPC status.
+type FieldViolation struct {
+	// Field is the path to the offending field in the request body.
+	Field string
+	// Description explains why the field is bad.
+	Description string
+}
+
+// WithRetryDelay attaches a retry hint to the Error. Clients should wait at
+// least the given delay before retrying. It is converted to google.rpc.RetryInfo
+// in gRPC status.
+func (e *Error) WithRetryDelay(delay time.Duration) *Error {
+	e.retryDelay = delay
+	return e
+}
+
+// WithQuotaViolation appends a QuotaViolation to the Error.
+func (e *Error) WithQuotaViolation(subject, description string) *Error {
+	e.quotaViolations = append(e.quotaViolations, QuotaViolation{Subject: subject, Description: description})
+	return e
+}
+
+// WithFieldViolation appends a FieldViolation to the Error.
+func (e *Error) WithFieldViolation(field, description string) *Error {
+	e.fieldViolations = append(e.fieldViolations, FieldViolation{Field: field, Description: description})
+	return e
+}
+
+// RetryDelay returns the retry hint attached to the Error. A zero value means
+// the server has given no hint.
+func (e *Error) RetryDelay() time.Duration {
+	return e.retryDelay
+}
+
+// QuotaViolations returns the quota violations attached to the Error.
+func (e *Error) QuotaViolations() []QuotaViolation {
+	return e.quotaViolations
+}
+
+// FieldViolations returns the field violations attached to the Error.
+func (e *Error) FieldViolations() []FieldViolation {
+	return e.fieldViolations
+}
+
+func (e *Error) withDetails(s *status.Status) *status.Status {
+	var details []proto.Message
+	if e.retryDelay > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.retryDelay)})
+	}
+	if len(e.quotaViolations) > 0 {
+		var quotaFailure errdetails.QuotaFailure
+		for _, v := range e.quotaViolations {
+			quotaFailure.Violations = append(quotaFailure.Violations, &errdetails.QuotaFailure_Violation{
+				Subject:     v.Subject,
+				Description: v.Description,
+			})
+		}
+		details = append(details, &quotaFailure)
+	}
+	if len(e.fieldViolations) > 0 {
+		var badRequest errdetails.BadRequest
+		for _, v := range e.fieldViolations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		details = append(details, &badRequest)
+	}
+	if len(details) == 0 {
+		return s
+	}
+	withDetails, err := s.WithDetails(details...)
+	if err != nil {
+		return s
+	}
+	return withDetails
+}
+
+func (e *Error) fromDetails(s *status.Status) {
+	for _, detail := range s.Details() {
+		switch d := detail.(type) {
+		case *errdetails.RetryInfo:
+			e.retryDelay = d.RetryDelay.AsDuration()
+		case *errdetails.QuotaFailure:
+			for _, v := range d.Violations {
+				e.quotaViolations = append(e.quotaViolations, QuotaViolation{Subject: v.Subject, Description: v.Description})
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				e.fieldViolations = append(e.fieldViolations, FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/text"
@@ -80,6 +81,10 @@ type Error struct {
 	msg  string
 	args []interface{}
 	code codes.Code
+	// retry hints and error details, converted to google.rpc.* detail messages in gRPC status.
+	retryDelay      time.Duration
+	quotaViolations []QuotaViolation
+	fieldViolations []FieldViolation
 	// Printer can ben used to achieve i18n. By default it is a text.BasePrinter.
 	Printer contract.Printer
 	// HttpStatusCodeFunc can overwrites the inferred HTTP status code from gRPC status.
@@ -120,18 +125,23 @@ func (e *Error) Error() string {
 	return e.Printer.Sprintf(e.msg, e.args...)
 }
 
-// GRPCStatus produces a native gRPC status.
+// GRPCStatus produces a native gRPC status. Retry hints, quota violations and
+// field violations attached to the Error are converted to google.rpc.RetryInfo,
+// google.rpc.QuotaFailure and google.rpc.BadRequest details respectively.
 func (e *Error) GRPCStatus() *status.Status {
-	return status.New(e.code, e.Error())
+	return e.withDetails(status.New(e.code, e.Error()))
 }
 
-// FromStatus constructs the Error from a gRPC status.
+// FromStatus constructs the Error from a gRPC status. Known google.rpc.*
+// details in the status are restored as well.
 func FromStatus(s *status.Status) *Error {
-	return &Error{
+	e := &Error{
 		err:  s.Err(),
 		msg:  s.Message(),
 		code: s.Code(),
 	}
+	e.fromDetails(s)
+	return e
 }
 
 // StatusCode infers the correct http status corresponding to Error's internal code.
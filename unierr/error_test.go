@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -121,3 +122,21 @@ func TestError_StackTrace(t *testing.T) {
 		})
 	}
 }
+
+func TestError_Details(t *testing.T) {
+	testError := New(codes.ResourceExhausted, "slow down").
+		WithRetryDelay(3*time.Second).
+		WithQuotaViolation("clientip:127.0.0.1", "daily limit exceeded").
+		WithFieldViolation("name", "must not be empty")
+
+	status := testError.GRPCStatus()
+	assert.Len(t, status.Details(), 3)
+
+	result := FromStatus(status)
+	assert.Equal(t, 3*time.Second, result.RetryDelay())
+	assert.Equal(t, []QuotaViolation{{Subject: "clientip:127.0.0.1", Description: "daily limit exceeded"}}, result.QuotaViolations())
+	assert.Equal(t, []FieldViolation{{Field: "name", Description: "must not be empty"}}, result.FieldViolations())
+
+	plain := New(codes.NotFound, "missing").GRPCStatus()
+	assert.Len(t, plain.Details(), 0)
+}
@@ -0,0 +1,102 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+)
+
+/*
+Providers returns a set of dependency providers for *Watchdog.
+
+	Depends On:
+		log.Logger
+		contract.ConfigAccessor
+		contract.Dispatcher `optional:"true"`
+	Provide:
+		*Watchdog
+*/
+func Providers() di.Deps {
+	return di.Deps{provideWatchdog, provideConfig}
+}
+
+type in struct {
+	di.In
+
+	Logger     log.Logger
+	Conf       contract.ConfigAccessor
+	Dispatcher contract.Dispatcher `optional:"true"`
+}
+
+type out struct {
+	di.Out
+
+	Watchdog *Watchdog
+}
+
+// ModuleSentinel marks out as module.
+func (o out) ModuleSentinel() {}
+
+func provideWatchdog(in in) (out, error) {
+	option, err := loadOption(in.Conf)
+	if err != nil {
+		return out{}, err
+	}
+	return out{Watchdog: New(option, WithLogger(in.Logger), WithDispatcher(in.Dispatcher))}, nil
+}
+
+func loadOption(conf contract.ConfigAccessor) (Option, error) {
+	var option Option
+	if err := conf.Unmarshal("watchdog", &option); err != nil {
+		return Option{}, fmt.Errorf("watchdog configuration error: %w", err)
+	}
+	return option, nil
+}
+
+// ProvideRunGroup checks the budgets in background.
+func (o out) ProvideRunGroup(group *run.Group) {
+	ctx, cancel := context.WithCancel(context.Background())
+	group.Add(func() error {
+		return o.Watchdog.Run(ctx)
+	}, func(err error) {
+		cancel()
+	})
+}
+
+// OnConfigReload implements contract.ConfigListener.
+func (o out) OnConfigReload(conf contract.ConfigAccessor) error {
+	option, err := loadOption(conf)
+	if err != nil {
+		return err
+	}
+	o.Watchdog.Update(option)
+	return nil
+}
+
+type configOut struct {
+	di.Out
+
+	Config []config.ExportedConfig `group:"config,flatten"`
+}
+
+func provideConfig() configOut {
+	return configOut{Config: []config.ExportedConfig{
+		{
+			Owner: "watchdog",
+			Data: map[string]interface{}{
+				"watchdog": Option{
+					Enable:   false,
+					Interval: config.Duration{Duration: 10 * time.Second},
+					Modules:  map[string]Budget{},
+				},
+			},
+			Comment: "The resource budgets of modules, checked at the interval",
+		},
+	}}
+}
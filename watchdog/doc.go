@@ -0,0 +1,43 @@
+/*
+Package watchdog guards the resources used by the modules plugged into the
+container, such as the ones provided by third parties.
+
+Each module is given a budget of goroutines, queue depth and request latency.
+The goroutines are the ones started by Watchdog.Go plus the in-flight requests
+of the module. The queue depth is the sum of the queues registered by the
+module. The latency is the mean latency of the requests within a check
+interval. On each check, a module exceeding its budget is logged and
+OnBudgetExceeded is dispatched, which can be turned into notifications by
+package alerts. If the budget says so, the requests of the module are shed
+until it is back within budget.
+
+Integration
+
+package watchdog exports the configuration in the following format:
+
+	watchdog:
+	    enable: true
+	    interval: 10s
+	    modules:
+	        orders:
+	            maxGoroutines: 1000
+	            maxQueueDepth: 500
+	            maxLatency: 500ms
+	            shed: true
+
+Add the watchdog dependency to core:
+
+	var c *core.C = core.New()
+	c.Provide(watchdog.Providers())
+
+Then account the traffic, goroutines and queues to the modules:
+
+	c.Invoke(func(w *watchdog.Watchdog) {
+		ordersRouter.Use(watchdog.MakeHTTPMiddleware(w, "orders"))
+		w.Go("orders", worker.Run)
+		w.RegisterQueue("orders", func() int { return len(worker.jobs) })
+	})
+
+The budgets are reloaded with the configuration.
+*/
+package watchdog
@@ -0,0 +1,48 @@
+package watchdog
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/DoNewsCode/core/srvhttp"
+	"github.com/DoNewsCode/core/unierr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// MakeHTTPMiddleware creates a standard HTTP middleware that accounts the
+// requests to the module. Apply it to the routes of the module. While the
+// module is being shed, the requests receive 503 Service Unavailable.
+func MakeHTTPMiddleware(watchdog *Watchdog, module string) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if watchdog.Shedding(module) {
+				srvhttp.NewResponseEncoder(writer).EncodeError(overBudget())
+				return
+			}
+			defer watchdog.Track(module)()
+			handler.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MakeUnaryInterceptor creates a grpc.UnaryServerInterceptor that accounts the
+// calls to the module. While the module is being shed, the calls receive
+// UNAVAILABLE.
+func MakeUnaryInterceptor(watchdog *Watchdog, module string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if watchdog.Shedding(module) {
+			return nil, overBudget()
+		}
+		defer watchdog.Track(module)()
+		return handler(ctx, req)
+	}
+}
+
+func overBudget() *unierr.Error {
+	e := unierr.UnavailableErr(ErrOverBudget)
+	e.HttpStatusCodeFunc = func(code codes.Code) int {
+		return http.StatusServiceUnavailable
+	}
+	return e
+}
@@ -0,0 +1,316 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// ErrOverBudget is the error sent to the shed requests.
+var ErrOverBudget = errors.New("module over budget")
+
+type event string
+
+// OnBudgetExceeded is an event triggered on each check while a module exceeds
+// its budget. The event payload is OnBudgetExceededPayload.
+const OnBudgetExceeded event = "onBudgetExceeded"
+
+// OnBudgetExceededPayload is the payload of OnBudgetExceeded.
+type OnBudgetExceededPayload struct {
+	Module     string
+	Violations []Violation
+	// Shedding is true if the traffic of the module is being shed.
+	Shedding bool
+}
+
+// Resources that can be budgeted.
+const (
+	Goroutines = "goroutines"
+	QueueDepth = "queueDepth"
+	Latency    = "latency"
+)
+
+// Violation is a resource of a module used beyond its budget.
+type Violation struct {
+	// Resource is one of Goroutines, QueueDepth and Latency.
+	Resource string
+	// Usage and Budget are counts, or seconds for Latency.
+	Usage  float64
+	Budget float64
+}
+
+// String formats the violation, eg. "latency 1.2s > 500ms".
+func (v Violation) String() string {
+	if v.Resource == Latency {
+		return fmt.Sprintf("%s %s > %s", v.Resource, seconds(v.Usage), seconds(v.Budget))
+	}
+	return fmt.Sprintf("%s %g > %g", v.Resource, v.Usage, v.Budget)
+}
+
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
+
+// Budget is the resource budget of a module. A zero value means unlimited.
+type Budget struct {
+	// MaxGoroutines limits the goroutines started by Watchdog.Go and the
+	// in-flight requests of the module.
+	MaxGoroutines int `json:"maxGoroutines" yaml:"maxGoroutines"`
+	// MaxQueueDepth limits the sum of the queues registered by the module.
+	MaxQueueDepth int `json:"maxQueueDepth" yaml:"maxQueueDepth"`
+	// MaxLatency limits the mean latency of the requests of the module within
+	// a check interval.
+	MaxLatency config.Duration `json:"maxLatency" yaml:"maxLatency"`
+	// Shed rejects the requests of the module until the next check that finds
+	// it back within budget.
+	Shed bool `json:"shed" yaml:"shed"`
+}
+
+// Option is the configuration of Watchdog.
+type Option struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Interval is the period between two checks. Defaults to 10s.
+	Interval config.Duration `json:"interval" yaml:"interval"`
+	// Modules are the budgets by module name.
+	Modules map[string]Budget `json:"modules" yaml:"modules"`
+}
+
+// Usage is a snapshot of the resources used by a module.
+type Usage struct {
+	Goroutines int
+	QueueDepth int
+	// Latency is the mean latency since the last check.
+	Latency time.Duration
+}
+
+// module holds the counters of a module. The 64-bit counters come first for
+// atomic alignment.
+type module struct {
+	latencySum   int64
+	latencyCount int64
+	goroutines   int64
+	shedding     int32
+	queues       []func() int
+}
+
+// Watchdog tracks the resources used by modules against their budgets. When a
+// module exceeds the budget, Watchdog logs it, dispatches OnBudgetExceeded and
+// optionally sheds the traffic of the module. Watchdog is safe for concurrent
+// use.
+type Watchdog struct {
+	mu         sync.RWMutex
+	option     Option
+	modules    map[string]*module
+	logger     log.Logger
+	dispatcher contract.Dispatcher
+}
+
+// WatchdogOption is an option for Watchdog.
+type WatchdogOption func(*Watchdog)
+
+// WithLogger sets the logger for the violations.
+func WithLogger(logger log.Logger) WatchdogOption {
+	return func(watchdog *Watchdog) {
+		watchdog.logger = logger
+	}
+}
+
+// WithDispatcher sets the dispatcher for OnBudgetExceeded.
+func WithDispatcher(dispatcher contract.Dispatcher) WatchdogOption {
+	return func(watchdog *Watchdog) {
+		watchdog.dispatcher = dispatcher
+	}
+}
+
+// New creates a new *Watchdog from the given Option.
+func New(option Option, opts ...WatchdogOption) *Watchdog {
+	w := &Watchdog{
+		option:  option,
+		modules: make(map[string]*module),
+		logger:  log.NewNopLogger(),
+	}
+	for _, f := range opts {
+		f(w)
+	}
+	return w
+}
+
+// Update replaces the Option of Watchdog. It is called when config reloads.
+// The modules no longer budgeted stop being shed.
+func (w *Watchdog) Update(option Option) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.option = option
+	for name, m := range w.modules {
+		if budget, ok := option.Modules[name]; !option.Enable || !ok || !budget.Shed {
+			atomic.StoreInt32(&m.shedding, 0)
+		}
+	}
+}
+
+func (w *Watchdog) module(name string) *module {
+	w.mu.RLock()
+	m, ok := w.modules[name]
+	w.mu.RUnlock()
+	if ok {
+		return m
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if m, ok = w.modules[name]; !ok {
+		m = &module{}
+		w.modules[name] = m
+	}
+	return m
+}
+
+// Go runs f in a new goroutine accounted to the module.
+func (w *Watchdog) Go(name string, f func()) {
+	m := w.module(name)
+	atomic.AddInt64(&m.goroutines, 1)
+	go func() {
+		defer atomic.AddInt64(&m.goroutines, -1)
+		f()
+	}()
+}
+
+// Track accounts a request to the module. The returned function must be called
+// when the request is done, which records the latency.
+func (w *Watchdog) Track(name string) (done func()) {
+	m := w.module(name)
+	atomic.AddInt64(&m.goroutines, 1)
+	start := time.Now()
+	return func() {
+		atomic.AddInt64(&m.goroutines, -1)
+		atomic.AddInt64(&m.latencySum, int64(time.Since(start)))
+		atomic.AddInt64(&m.latencyCount, 1)
+	}
+}
+
+// RegisterQueue adds a queue of the module, such as the buffered channel of a
+// worker pool. The depth function is called on every check.
+func (w *Watchdog) RegisterQueue(name string, depth func() int) {
+	m := w.module(name)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	m.queues = append(m.queues, depth)
+}
+
+// Shedding reports whether the traffic of the module is being shed.
+func (w *Watchdog) Shedding(name string) bool {
+	w.mu.RLock()
+	m, ok := w.modules[name]
+	w.mu.RUnlock()
+	return ok && atomic.LoadInt32(&m.shedding) == 1
+}
+
+// Usage returns the resources used by the module. The latency is the mean
+// since the last check.
+func (w *Watchdog) Usage(name string) Usage {
+	m := w.module(name)
+	usage := Usage{Goroutines: int(atomic.LoadInt64(&m.goroutines))}
+	if count := atomic.LoadInt64(&m.latencyCount); count > 0 {
+		usage.Latency = time.Duration(atomic.LoadInt64(&m.latencySum) / count)
+	}
+
+	w.mu.RLock()
+	queues := m.queues
+	w.mu.RUnlock()
+	for _, depth := range queues {
+		usage.QueueDepth += depth()
+	}
+	return usage
+}
+
+// Check compares the usages of the budgeted modules with their budgets, and
+// returns the modules over budget. It resets the latencies for the next check.
+func (w *Watchdog) Check(ctx context.Context) []OnBudgetExceededPayload {
+	w.mu.RLock()
+	option := w.option
+	w.mu.RUnlock()
+	if !option.Enable {
+		return nil
+	}
+
+	names := make([]string, 0, len(option.Modules))
+	for name := range option.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var exceeded []OnBudgetExceededPayload
+	for _, name := range names {
+		budget := option.Modules[name]
+		usage := w.Usage(name)
+		m := w.module(name)
+		atomic.StoreInt64(&m.latencySum, 0)
+		atomic.StoreInt64(&m.latencyCount, 0)
+
+		violations := budget.check(usage)
+		if len(violations) == 0 {
+			atomic.StoreInt32(&m.shedding, 0)
+			continue
+		}
+		if budget.Shed {
+			atomic.StoreInt32(&m.shedding, 1)
+		}
+		payload := OnBudgetExceededPayload{Module: name, Violations: violations, Shedding: budget.Shed}
+		exceeded = append(exceeded, payload)
+
+		level.Warn(w.logger).Log("msg", "module over budget", "module", name, "violations", fmt.Sprint(violations), "shedding", budget.Shed)
+		if w.dispatcher != nil {
+			_ = w.dispatcher.Dispatch(ctx, OnBudgetExceeded, payload)
+		}
+	}
+	return exceeded
+}
+
+func (b Budget) check(usage Usage) []Violation {
+	var violations []Violation
+	if b.MaxGoroutines > 0 && usage.Goroutines > b.MaxGoroutines {
+		violations = append(violations, Violation{Resource: Goroutines, Usage: float64(usage.Goroutines), Budget: float64(b.MaxGoroutines)})
+	}
+	if b.MaxQueueDepth > 0 && usage.QueueDepth > b.MaxQueueDepth {
+		violations = append(violations, Violation{Resource: QueueDepth, Usage: float64(usage.QueueDepth), Budget: float64(b.MaxQueueDepth)})
+	}
+	if b.MaxLatency.Duration > 0 && usage.Latency > b.MaxLatency.Duration {
+		violations = append(violations, Violation{Resource: Latency, Usage: usage.Latency.Seconds(), Budget: b.MaxLatency.Seconds()})
+	}
+	return violations
+}
+
+// Run checks the budgets periodically until the context is done.
+func (w *Watchdog) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-time.After(w.interval()):
+			w.Check(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (w *Watchdog) interval() time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.option.Interval.Duration <= 0 {
+		return 10 * time.Second
+	}
+	return w.option.Interval.Duration
+}
@@ -0,0 +1,91 @@
+package watchdog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	t.Parallel()
+	dispatcher := &events.SyncDispatcher{}
+	var received []OnBudgetExceededPayload
+	dispatcher.Subscribe(events.Listen(OnBudgetExceeded, func(ctx context.Context, event interface{}) error {
+		received = append(received, event.(OnBudgetExceededPayload))
+		return nil
+	}))
+	w := New(Option{
+		Enable: true,
+		Modules: map[string]Budget{
+			"orders": {MaxGoroutines: 1, MaxQueueDepth: 5, MaxLatency: config.Duration{Duration: time.Millisecond}, Shed: true},
+			"users":  {MaxGoroutines: 1},
+		},
+	}, WithDispatcher(dispatcher))
+
+	stop := make(chan struct{})
+	w.Go("orders", func() { <-stop })
+	w.Go("orders", func() { <-stop })
+	w.RegisterQueue("orders", func() int { return 10 })
+	done := w.Track("orders")
+	time.Sleep(2 * time.Millisecond)
+	done()
+	w.Go("users", func() { <-stop })
+
+	assert.Equal(t, 2, w.Usage("orders").Goroutines)
+	exceeded := w.Check(context.Background())
+	assert.Len(t, exceeded, 1)
+	assert.Equal(t, "orders", exceeded[0].Module)
+	assert.Len(t, exceeded[0].Violations, 3)
+	assert.Equal(t, Goroutines, exceeded[0].Violations[0].Resource)
+	assert.Equal(t, "goroutines 2 > 1", exceeded[0].Violations[0].String())
+	assert.True(t, w.Shedding("orders"))
+	assert.False(t, w.Shedding("users"))
+	assert.Equal(t, exceeded, received)
+
+	close(stop)
+	assert.Eventually(t, func() bool { return w.Usage("orders").Goroutines == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, time.Duration(0), w.Usage("orders").Latency)
+	exceeded = w.Check(context.Background())
+	assert.Len(t, exceeded, 1)
+	assert.Equal(t, QueueDepth, exceeded[0].Violations[0].Resource)
+
+	w.Update(Option{Enable: true})
+	assert.False(t, w.Shedding("orders"))
+	assert.Empty(t, w.Check(context.Background()))
+
+	w.Update(Option{Modules: map[string]Budget{"orders": {MaxQueueDepth: 1}}})
+	assert.Empty(t, w.Check(context.Background()))
+}
+
+func TestMakeHTTPMiddleware(t *testing.T) {
+	t.Parallel()
+	w := New(Option{
+		Enable:  true,
+		Modules: map[string]Budget{"orders": {MaxLatency: config.Duration{Duration: time.Millisecond}, Shed: true}},
+	})
+	handler := MakeHTTPMiddleware(w, "orders")(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Greater(t, int64(w.Usage("orders").Latency), int64(time.Millisecond))
+
+	w.Check(context.Background())
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	// no requests are let in, so the next check finds it within budget.
+	w.Check(context.Background())
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}